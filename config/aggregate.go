@@ -0,0 +1,394 @@
+package config
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nao1215/sqly/domain/model"
+	"modernc.org/sqlite"
+)
+
+// The statistical aggregates SQLite lacks: the median, percentiles, the
+// standard deviation and variance, and the mode. Without them a median is a
+// window-function exercise — number the rows, count them, average the middle
+// one or two — that nobody gets right twice in a row.
+//
+// They read values the way the table view decides what a number is
+// (model.IsNumericValue), so a CSV column that loaded as TEXT, "1,200" and all,
+// still has a median. NULL is skipped, as every SQL aggregate skips it, and so
+// is a value that is not a number at all: a column that mixes numbers with N/A
+// has the statistics of its numbers. The driver registers an aggregate as a
+// window function too, so each of them also works with OVER.
+//
+// The dialect translation rewrites MySQL's, PostgreSQL's, and GoogleSQL's
+// STDDEV and VARIANCE into plain SQLite expressions before a query runs, so
+// those spellings keep the estimator their dialect chose; the functions here
+// are what the SQLite dialect, and every dialect's MEDIAN and PERCENTILE_*,
+// reach.
+
+// statisticalAggregate is one aggregate registerStatisticalAggregates
+// registers.
+type statisticalAggregate struct {
+	name string
+	nArg int32
+	// start returns a fresh state for one group, or for one window.
+	start func() sqlite.AggregateFunction
+}
+
+// statisticalAggregates are the aggregates registerStatisticalAggregates
+// registers. A bare stddev and variance are the sample estimators, as they are
+// in PostgreSQL and GoogleSQL.
+var statisticalAggregates = []statisticalAggregate{
+	{name: "median", nArg: 1, start: func() sqlite.AggregateFunction {
+		return &percentileAggregate{name: "median", continuous: true, fraction: 0.5, fractionSet: true}
+	}},
+	{name: "percentile_cont", nArg: 2, start: func() sqlite.AggregateFunction {
+		return &percentileAggregate{name: "percentile_cont", continuous: true}
+	}},
+	{name: "percentile_disc", nArg: 2, start: func() sqlite.AggregateFunction {
+		return &percentileAggregate{name: "percentile_disc"}
+	}},
+	{name: "stddev", nArg: 1, start: func() sqlite.AggregateFunction { return &momentAggregate{sample: true, root: true} }},
+	{name: "stddev_samp", nArg: 1, start: func() sqlite.AggregateFunction { return &momentAggregate{sample: true, root: true} }},
+	{name: "stddev_pop", nArg: 1, start: func() sqlite.AggregateFunction { return &momentAggregate{root: true} }},
+	{name: "variance", nArg: 1, start: func() sqlite.AggregateFunction { return &momentAggregate{sample: true} }},
+	{name: "var_samp", nArg: 1, start: func() sqlite.AggregateFunction { return &momentAggregate{sample: true} }},
+	{name: "var_pop", nArg: 1, start: func() sqlite.AggregateFunction { return &momentAggregate{} }},
+	{name: "mode", nArg: 1, start: func() sqlite.AggregateFunction { return &modeAggregate{counts: map[modeKey]int{}} }},
+}
+
+// registerStatisticalAggregates registers the statistical aggregates with the
+// driver. Like the scalar functions, they reach only the connections opened
+// afterwards.
+func registerStatisticalAggregates() error {
+	return registerAggregates(statisticalAggregates)
+}
+
+// registerAggregates registers aggregates with the driver, each of which the
+// driver also registers as a window function.
+func registerAggregates(aggregates []statisticalAggregate) error {
+	for _, a := range aggregates {
+		impl := &sqlite.FunctionImpl{
+			NArgs:         a.nArg,
+			Deterministic: true,
+			MakeAggregate: func(sqlite.FunctionContext) (sqlite.AggregateFunction, error) {
+				return a.start(), nil
+			},
+		}
+		if err := sqlite.RegisterFunction(a.name, impl); err != nil {
+			return fmt.Errorf("register %s: %w", a.name, err)
+		}
+	}
+	return nil
+}
+
+// statValue is one value an aggregate counts: the number it is, and the value
+// to return when an aggregate answers with one of its inputs, so an integer
+// column's percentile_disc is an integer.
+type statValue struct {
+	f float64
+	v driver.Value
+}
+
+// numericValue reads arg as a number. It reports false for NULL and for
+// anything model.IsNumericValue would not call a number.
+func numericValue(arg driver.Value) (statValue, bool) {
+	switch v := arg.(type) {
+	case int64:
+		return statValue{f: float64(v), v: v}, true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return statValue{}, false
+		}
+		return statValue{f: v, v: v}, true
+	case string:
+		return numericText(v)
+	case []byte:
+		return numericText(string(v))
+	default:
+		return statValue{}, false
+	}
+}
+
+// numericText reads a text value as the number it spells, thousands separators
+// and all, keeping an integer an integer.
+func numericText(s string) (statValue, bool) {
+	if !model.IsNumericValue(s) {
+		return statValue{}, false
+	}
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return statValue{f: float64(i), v: i}, true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return statValue{}, false
+	}
+	return statValue{f: f, v: f}, true
+}
+
+// numberSet is the numbers a group, or a window, holds so far. A window drops
+// the rows that leave it, so the set is kept whole rather than as running sums:
+// a sum minus the value that left drifts, and a percentile cannot be taken from
+// sums at all.
+type numberSet struct {
+	values []statValue
+}
+
+// add counts arg, when it is a number.
+func (s *numberSet) add(arg driver.Value) {
+	if v, ok := numericValue(arg); ok {
+		s.values = append(s.values, v)
+	}
+}
+
+// remove forgets one value equal to arg, for a row leaving the window. Equal
+// values are interchangeable, so which one goes does not matter.
+func (s *numberSet) remove(arg driver.Value) {
+	v, ok := numericValue(arg)
+	if !ok {
+		return
+	}
+	if i := slices.IndexFunc(s.values, func(x statValue) bool { return x.f == v.f }); i >= 0 {
+		s.values = slices.Delete(s.values, i, i+1)
+	}
+}
+
+// sorted returns the values in ascending order, leaving the set as it is.
+func (s *numberSet) sorted() []statValue {
+	sorted := slices.Clone(s.values)
+	slices.SortFunc(sorted, func(a, b statValue) int { return cmp.Compare(a.f, b.f) })
+	return sorted
+}
+
+// percentileAggregate is median, percentile_cont, and percentile_disc.
+type percentileAggregate struct {
+	numberSet
+	name string
+	// continuous interpolates between the two values around the fraction, as
+	// percentile_cont does; percentile_disc returns the first value at or past
+	// it instead.
+	continuous bool
+	fraction   float64
+	// fractionSet is true once a row has given the fraction, which has to be
+	// the same for every row.
+	fractionSet bool
+	// nullFraction is true when the fraction is NULL, which makes the result
+	// NULL, as it does in PostgreSQL.
+	nullFraction bool
+}
+
+// Step counts one row.
+func (a *percentileAggregate) Step(_ *sqlite.FunctionContext, args []driver.Value) error {
+	if len(args) == 2 {
+		if err := a.setFraction(args[1]); err != nil {
+			return err
+		}
+	}
+	a.add(args[0])
+	return nil
+}
+
+// setFraction reads a row's fraction.
+func (a *percentileAggregate) setFraction(arg driver.Value) error {
+	if arg == nil {
+		a.nullFraction = true
+		return nil
+	}
+	v, ok := numericValue(arg)
+	if !ok || v.f < 0 || v.f > 1 {
+		return fmt.Errorf("%s: the fraction must be a number from 0 to 1, got %v", a.name, arg)
+	}
+	if a.fractionSet && v.f != a.fraction {
+		return fmt.Errorf("%s: the fraction must be the same for every row, got %v and %v", a.name, a.fraction, v.f)
+	}
+	a.fraction, a.fractionSet = v.f, true
+	return nil
+}
+
+// WindowInverse forgets a row that left the window.
+func (a *percentileAggregate) WindowInverse(_ *sqlite.FunctionContext, args []driver.Value) error {
+	a.remove(args[0])
+	return nil
+}
+
+// WindowValue returns the percentile of the values so far, or NULL when there
+// are none.
+func (a *percentileAggregate) WindowValue(_ *sqlite.FunctionContext) (driver.Value, error) {
+	if len(a.values) == 0 || a.nullFraction {
+		return nil, nil
+	}
+	sorted := a.sorted()
+	if !a.continuous {
+		i := max(int(math.Ceil(a.fraction*float64(len(sorted))))-1, 0)
+		return sorted[i].v, nil
+	}
+	position := a.fraction * float64(len(sorted)-1)
+	below := int(math.Floor(position))
+	above := int(math.Ceil(position))
+	low, high := sorted[below].f, sorted[above].f
+	return low + (high-low)*(position-float64(below)), nil
+}
+
+// Final has nothing to release.
+func (a *percentileAggregate) Final(*sqlite.FunctionContext) {}
+
+// momentAggregate is the standard deviation and the variance.
+type momentAggregate struct {
+	numberSet
+	// sample divides by n-1 rather than n, and leaves a single value with no
+	// answer rather than an answer of 0.
+	sample bool
+	// root is the standard deviation, the square root of the variance.
+	root bool
+}
+
+// Step counts one row.
+func (a *momentAggregate) Step(_ *sqlite.FunctionContext, args []driver.Value) error {
+	a.add(args[0])
+	return nil
+}
+
+// WindowInverse forgets a row that left the window.
+func (a *momentAggregate) WindowInverse(_ *sqlite.FunctionContext, args []driver.Value) error {
+	a.remove(args[0])
+	return nil
+}
+
+// WindowValue returns the variance or standard deviation of the values so far.
+// It takes the mean first and sums the squared distances from it, which stays
+// accurate where the sum of squares minus the squared sum cancels to noise.
+func (a *momentAggregate) WindowValue(_ *sqlite.FunctionContext) (driver.Value, error) {
+	n := len(a.values)
+	if n == 0 || (a.sample && n < 2) {
+		return nil, nil
+	}
+	var sum float64
+	for _, v := range a.values {
+		sum += v.f
+	}
+	mean := sum / float64(n)
+	var squares float64
+	for _, v := range a.values {
+		squares += (v.f - mean) * (v.f - mean)
+	}
+	divisor := float64(n)
+	if a.sample {
+		divisor--
+	}
+	variance := squares / divisor
+	if a.root {
+		return math.Sqrt(variance), nil
+	}
+	return variance, nil
+}
+
+// Final has nothing to release.
+func (a *momentAggregate) Final(*sqlite.FunctionContext) {}
+
+// modeKey identifies a value mode counts: a number by its value, so 2 and 2.0
+// are one value, and text by itself, so '00123' and '123' are two. A zip code
+// or an account number is text precisely because its leading zeros matter.
+type modeKey struct {
+	number bool
+	f      float64
+	text   string
+}
+
+// modeAggregate is mode, the most frequent value. Unlike the other aggregates it
+// counts text too: the most common category in a column is as fair a question
+// as the most common number.
+type modeAggregate struct {
+	counts map[modeKey]int
+	// values is the value first seen for each key, which is what is returned.
+	values map[modeKey]driver.Value
+}
+
+// modeValue returns the key arg is counted under, and false for NULL. Unlike
+// numericValue it leaves text as text: mode answers with one of the values, and
+// the value a TEXT column holds is the text.
+func modeValue(arg driver.Value) (modeKey, driver.Value, bool) {
+	switch v := arg.(type) {
+	case int64:
+		return modeKey{number: true, f: float64(v)}, v, true
+	case float64:
+		if math.IsNaN(v) {
+			return modeKey{}, nil, false
+		}
+		return modeKey{number: true, f: v}, v, true
+	case string:
+		return modeKey{text: v}, v, true
+	case []byte:
+		return modeKey{text: string(v)}, string(v), true
+	default:
+		return modeKey{}, nil, false
+	}
+}
+
+// Step counts one row.
+func (a *modeAggregate) Step(_ *sqlite.FunctionContext, args []driver.Value) error {
+	key, value, ok := modeValue(args[0])
+	if !ok {
+		return nil
+	}
+	if a.values == nil {
+		a.values = map[modeKey]driver.Value{}
+	}
+	if _, seen := a.values[key]; !seen {
+		a.values[key] = value
+	}
+	a.counts[key]++
+	return nil
+}
+
+// WindowInverse forgets a row that left the window.
+func (a *modeAggregate) WindowInverse(_ *sqlite.FunctionContext, args []driver.Value) error {
+	key, _, ok := modeValue(args[0])
+	if !ok {
+		return nil
+	}
+	if a.counts[key]--; a.counts[key] <= 0 {
+		delete(a.counts, key)
+		delete(a.values, key)
+	}
+	return nil
+}
+
+// WindowValue returns the most frequent value so far, or NULL when there is
+// none. A tie goes to the value that sorts first, numbers before text as SQLite
+// sorts them, so the answer does not depend on the order rows arrived in.
+func (a *modeAggregate) WindowValue(_ *sqlite.FunctionContext) (driver.Value, error) {
+	var (
+		best  modeKey
+		count int
+	)
+	for key, n := range a.counts {
+		if n > count || (n == count && modeKeyLess(key, best)) {
+			best, count = key, n
+		}
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	return a.values[best], nil
+}
+
+// modeKeyLess orders keys as SQLite orders the values: numbers first, by value,
+// then text.
+func modeKeyLess(a, b modeKey) bool {
+	if a.number != b.number {
+		return a.number
+	}
+	if a.number {
+		return a.f < b.f
+	}
+	return a.text < b.text
+}
+
+// Final has nothing to release.
+func (a *modeAggregate) Final(*sqlite.FunctionContext) {}
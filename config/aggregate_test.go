@@ -0,0 +1,83 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestStatisticalAggregates(t *testing.T) {
+	db := openFunctionDB(t)
+	ctx := context.Background()
+	// v is TEXT, as a CSV column with a placeholder in it loads: the numbers
+	// are read as numbers, and NULL and N/A are skipped.
+	for _, stmt := range []string{
+		"CREATE TABLE t (g TEXT, v TEXT)",
+		"INSERT INTO t VALUES ('a', '1'), ('a', '2'), ('a', '3'), ('a', '1,000'), ('a', NULL), ('a', 'N/A'), ('b', '7')",
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  sql.NullString
+	}{
+		{"SELECT median(v) FROM t WHERE g = 'a'", sql.NullString{String: "2.5", Valid: true}},
+		{"SELECT median(v) FROM t WHERE g = 'a' AND v <> '1,000'", sql.NullString{String: "2.0", Valid: true}},
+		{"SELECT round(percentile_cont(v, 0.9), 6) FROM t WHERE g = 'a'", sql.NullString{String: "700.9", Valid: true}},
+		{"SELECT percentile_disc(v, 0.5) FROM t WHERE g = 'a'", sql.NullString{String: "2", Valid: true}},
+		{"SELECT typeof(percentile_disc(v, 0.5)) FROM t WHERE g = 'a'", sql.NullString{String: "integer", Valid: true}},
+		{"SELECT percentile_disc(v, 0) FROM t WHERE g = 'a'", sql.NullString{String: "1", Valid: true}},
+		{"SELECT percentile_cont(v, NULL) FROM t", sql.NullString{}},
+		{"SELECT round(var_pop(v), 6) FROM t WHERE g = 'a' AND v <> '1,000'", sql.NullString{String: "0.666667", Valid: true}},
+		{"SELECT variance(v) FROM t WHERE g = 'a' AND v <> '1,000'", sql.NullString{String: "1.0", Valid: true}},
+		{"SELECT var_samp(v) FROM t WHERE g = 'a' AND v <> '1,000'", sql.NullString{String: "1.0", Valid: true}},
+		{"SELECT stddev_pop(v) FROM t WHERE g = 'b'", sql.NullString{String: "0.0", Valid: true}},
+		{"SELECT stddev(v) FROM t WHERE g = 'b'", sql.NullString{}},
+		{"SELECT stddev_samp(v) FROM t WHERE g = 'a' AND v <> '1,000'", sql.NullString{String: "1.0", Valid: true}},
+		{"SELECT median(v) FROM t WHERE g = 'none'", sql.NullString{}},
+		{"SELECT mode(v) FROM (SELECT 2 AS v UNION ALL SELECT 2.0 UNION ALL SELECT 3 UNION ALL SELECT '3' UNION ALL SELECT 1)", sql.NullString{String: "2", Valid: true}},
+		{"SELECT mode(v) FROM (SELECT '00123' AS v UNION ALL SELECT '00123' UNION ALL SELECT '123' UNION ALL SELECT 123)", sql.NullString{String: "00123", Valid: true}},
+		{"SELECT mode(g) FROM t", sql.NullString{String: "a", Valid: true}},
+		{"SELECT group_concat(m, ' ') FROM (SELECT median(v) OVER (ORDER BY rowid ROWS BETWEEN 1 PRECEDING AND CURRENT ROW) AS m FROM t WHERE g = 'a' AND v IS NOT NULL AND v <> 'N/A')",
+			sql.NullString{String: "1.0 1.5 2.5 501.5", Valid: true}},
+		{"SELECT group_concat(m, ' ') FROM (SELECT mode(g) OVER (ORDER BY rowid ROWS BETWEEN CURRENT ROW AND CURRENT ROW) AS m FROM t WHERE rowid > 5)",
+			sql.NullString{String: "a b", Valid: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got sql.NullString
+			if err := db.QueryRowContext(ctx, "SELECT CAST(("+tt.query+") AS TEXT)").Scan(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatisticalAggregates_Errors(t *testing.T) {
+	db := openFunctionDB(t)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT percentile_cont(1, 1.5)", "percentile_cont: the fraction must be a number from 0 to 1, got 1.5"},
+		{"SELECT percentile_disc(1, 'half')", "percentile_disc: the fraction must be a number from 0 to 1, got half"},
+		{"SELECT percentile_cont(v, v / 10.0) FROM (SELECT 1 AS v UNION ALL SELECT 2)", "percentile_cont: the fraction must be the same for every row"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got sql.NullString
+			err := db.QueryRowContext(context.Background(), tt.query).Scan(&got)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // SHA-1 is what RFC 9562 defines a version 5 UUID with, not a security control
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// The functions a session's SQL can call to pseudonymize a column before it
+// leaves the machine: hash it, key the hash, replace it with a UUID that is the
+// same for the same name, or blank out all but its last few characters.
+//
+// md5(x) is not among them because filesql's dialect helpers already register
+// it under every dialect, as the lowercase hex PostgreSQL's MD5 returns, which
+// is what it would be here. Like sha256, it is a fingerprint: a hash of an
+// email address is reversed by hashing a list of addresses, so a vendor who
+// must not be able to do that is sent hmac_sha256 with a key kept at home.
+
+// anonymizeFunctions are the functions registered by registerAnonymizeFunctions.
+var anonymizeFunctions = []scalarFunction{
+	{name: "sha256", nArg: 1, fn: sha256Hex},
+	{name: "hmac_sha256", nArg: 2, fn: hmacSHA256},
+	{name: "uuid", nArg: 0, fn: randomUUID, volatile: true},
+	{name: "uuid_v5", nArg: 2, fn: nameUUID},
+	{name: "redact", nArg: 2, fn: redact},
+}
+
+// registerAnonymizeFunctions registers the hashing, UUID, and redaction
+// functions with the driver. Like dialect.RegisterFunctions, it has to run
+// before the first connection is opened.
+func registerAnonymizeFunctions() error {
+	return registerScalarFunctions(anonymizeFunctions)
+}
+
+// sha256Hex implements sha256(x): the SHA-256 of x's text, or of its bytes for
+// a BLOB, in lowercase hex.
+func sha256Hex(args []driver.Value) (driver.Value, error) {
+	texts, ok := textArgs(args)
+	if !ok {
+		return nil, nil
+	}
+	return model.SHA256Hex([]byte(texts[0])), nil
+}
+
+// hmacSHA256 implements hmac_sha256(x, key): the HMAC-SHA256 of x under key,
+// in lowercase hex. Without the key, the result cannot be recomputed from a
+// guess at x, which is what makes it a pseudonym rather than a fingerprint.
+func hmacSHA256(args []driver.Value) (driver.Value, error) {
+	texts, ok := textArgs(args)
+	if !ok {
+		return nil, nil
+	}
+	mac := hmac.New(sha256.New, []byte(texts[1]))
+	mac.Write([]byte(texts[0]))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// randomUUID implements uuid(): a random version 4 UUID, different on every
+// call.
+func randomUUID(_ []driver.Value) (driver.Value, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, fmt.Errorf("uuid: %w", err)
+	}
+	return formatUUID(b, 4), nil
+}
+
+// uuidNamespaces are the namespaces RFC 9562 defines, by the names uuid_v5
+// accepts for them.
+var uuidNamespaces = map[string]string{
+	"dns":  "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+	"url":  "6ba7b811-9dad-11d1-80b4-00c04fd430c8",
+	"oid":  "6ba7b812-9dad-11d1-80b4-00c04fd430c8",
+	"x500": "6ba7b814-9dad-11d1-80b4-00c04fd430c8",
+}
+
+// nameUUID implements uuid_v5(ns, name): the version 5 UUID of name in the
+// namespace ns, which is a UUID or one of dns, url, oid, and x500. The same
+// name always gives the same UUID, so a column replaced with it still joins.
+func nameUUID(args []driver.Value) (driver.Value, error) {
+	texts, ok := textArgs(args)
+	if !ok {
+		return nil, nil
+	}
+	text := strings.TrimSpace(texts[0])
+	if known, ok := uuidNamespaces[strings.ToLower(text)]; ok {
+		text = known
+	}
+	namespace, err := parseUUID(text)
+	if err != nil {
+		return nil, fmt.Errorf("uuid_v5: namespace %q is not a UUID, nor one of dns, url, oid, and x500", texts[0])
+	}
+	h := sha1.New() //nolint:gosec // see the import
+	h.Write(namespace[:])
+	h.Write([]byte(texts[1]))
+	var b [16]byte
+	copy(b[:], h.Sum(nil))
+	return formatUUID(b, 5), nil
+}
+
+// parseUUID reads a UUID in its hyphenated form, or as 32 hex digits, with or
+// without braces.
+func parseUUID(s string) ([16]byte, error) {
+	var b [16]byte
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	digits := strings.ReplaceAll(s, "-", "")
+	if len(digits) != 32 || (len(s) != 32 && len(s) != 36) {
+		return b, fmt.Errorf("invalid UUID %q", s)
+	}
+	if _, err := hex.Decode(b[:], []byte(digits)); err != nil {
+		return b, fmt.Errorf("invalid UUID %q: %w", s, err)
+	}
+	return b, nil
+}
+
+// formatUUID sets the version and the RFC 9562 variant bits of b and writes it
+// in the hyphenated form.
+func formatUUID(b [16]byte, version byte) string {
+	b[6] = (b[6] & 0x0f) | version<<4
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// redact implements redact(x, keep_last): x with every character but the last
+// keep_last replaced by *, so 555-0142 redacted to 4 is ****0142.
+func redact(args []driver.Value) (driver.Value, error) {
+	texts, ok := textArgs(args[:1])
+	if !ok || args[1] == nil {
+		return nil, nil
+	}
+	keep, ok := regexpIntArg(args[1])
+	if !ok || keep < 0 {
+		return nil, fmt.Errorf("redact: keep_last must be a count of characters, 0 or more, not %v", args[1])
+	}
+	return model.Redact(texts[0], int(keep)), nil
+}
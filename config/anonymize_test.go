@@ -0,0 +1,82 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAnonymizeFunctions(t *testing.T) {
+	db := openFunctionDB(t)
+
+	tests := []struct {
+		query string
+		want  sql.NullString
+	}{
+		{"SELECT sha256('abc')", sql.NullString{String: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", Valid: true}},
+		{"SELECT sha256(NULL)", sql.NullString{}},
+		{"SELECT md5('abc')", sql.NullString{String: "900150983cd24fb0d6963f7d28e17f72", Valid: true}},
+		{"SELECT hmac_sha256('The quick brown fox jumps over the lazy dog', 'key')", sql.NullString{String: "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", Valid: true}},
+		{"SELECT hmac_sha256('a', NULL)", sql.NullString{}},
+		{"SELECT uuid_v5('dns', 'python.org')", sql.NullString{String: "886313e1-3b8a-5372-9b90-0c9aee199e5d", Valid: true}},
+		{"SELECT uuid_v5('6ba7b810-9dad-11d1-80b4-00c04fd430c8', 'python.org')", sql.NullString{String: "886313e1-3b8a-5372-9b90-0c9aee199e5d", Valid: true}},
+		{"SELECT redact('555-0142', 4)", sql.NullString{String: "****0142", Valid: true}},
+		{"SELECT redact('山田太郎', 1)", sql.NullString{String: "***郎", Valid: true}},
+		{"SELECT redact('42', 4)", sql.NullString{String: "42", Valid: true}},
+		{"SELECT redact(4111111111111111, 0)", sql.NullString{String: "****************", Valid: true}},
+		{"SELECT redact(NULL, 4)", sql.NullString{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got sql.NullString
+			if err := db.QueryRowContext(context.Background(), tt.query).Scan(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnonymizeFunctions_UUID(t *testing.T) {
+	db := openFunctionDB(t)
+
+	// uuid() is registered as non-deterministic, so each row gets its own.
+	var first, second string
+	row := db.QueryRowContext(context.Background(), "SELECT uuid(), uuid()")
+	if err := row.Scan(&first, &second); err != nil {
+		t.Fatal(err)
+	}
+	v4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !v4.MatchString(first) || !v4.MatchString(second) {
+		t.Errorf("uuid() = %q, %q, want version 4 UUIDs", first, second)
+	}
+	if first == second {
+		t.Errorf("uuid() returned %q twice", first)
+	}
+}
+
+func TestAnonymizeFunctions_Errors(t *testing.T) {
+	db := openFunctionDB(t)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT uuid_v5('mail', 'a')", `uuid_v5: namespace "mail" is not a UUID, nor one of dns, url, oid, and x500`},
+		{"SELECT redact('abc', -1)", "redact: keep_last must be a count of characters, 0 or more, not -1"},
+		{"SELECT redact('abc', 'all')", "redact: keep_last must be a count of characters, 0 or more, not all"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got sql.NullString
+			err := db.QueryRowContext(context.Background(), tt.query).Scan(&got)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
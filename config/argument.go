@@ -3,8 +3,11 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"runtime/debug"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/mattn/go-colorable"
@@ -32,16 +35,51 @@ var (
 // number rather than "yes", so the caller states how much it wants.
 const DefaultInspectSample = 0
 
+// DefaultWatchInterval is how often --watch looks at the inputs unless
+// --watch-interval says otherwise. A second is short next to how often a job
+// appends to a log, and long enough that a stat of each input costs nothing.
+const DefaultWatchInterval = time.Second
+
 // defaultStdinTable is the table name a --stdin-format dataset gets when
 // --stdin-table does not name one.
 const defaultStdinTable = "stdin"
 
+// InspectFormat is what --inspect prints.
+type InspectFormat string
+
+const (
+	// InspectFormatJSON is the --inspect report: tables, sources, columns, row
+	// counts, and any sample rows.
+	InspectFormatJSON InspectFormat = "json"
+	// InspectFormatSchema is the schema sidecar of the imported tables: only
+	// what an import applies, in the shape it reads back.
+	InspectFormatSchema InspectFormat = "schema"
+)
+
 // Output is configuration for output data to file.
 type Output struct {
 	// FilePath is output destination path
 	FilePath string
 	// Mode is enum to specify output method
 	Mode model.PrintMode
+	// Dialect is the database a sql output script is written for. It is not
+	// Arg.Dialect, which is the dialect queries are read in: a run can read
+	// PostgreSQL and write a script for MySQL.
+	Dialect model.SQLDialect
+	// PartitionBy names the result columns --output-partition-by splits the
+	// result on. FilePath is then a directory, written as a Hive-style
+	// partitioned tree of files, one per combination of these columns' values.
+	PartitionBy []string
+	// CSV is how a CSV or TSV file sqly writes is laid out: --output, .dump,
+	// and .save DIR. An in-place save keeps the layout its source already has.
+	CSV model.CSVOutput
+	// NullString is how a SQL NULL is written in table, CSV, TSV, and LTSV
+	// output, from --null-string. Empty writes an empty value.
+	NullString string
+	// Mask names the columns whose values are masked in every file sqly writes
+	// from a table, from --mask: --output, .dump, and .save DIR. The screen is
+	// not a file, and is not masked.
+	Mask model.ColumnMasks
 }
 
 // Arg is a structure for managing options and arguments
@@ -69,6 +107,12 @@ type Arg struct {
 	// .sql file that silently ran .save would be a shell script wearing a SQL
 	// extension.
 	ScriptFilePath string
+	// Watch keeps a --sql or --sql-file run alive after its first result: the
+	// inputs are polled, and whenever one changes it is imported again and the
+	// query is re-run and re-printed (for --watch).
+	Watch bool
+	// WatchInterval is how often --watch polls the inputs (for --watch-interval).
+	WatchInterval time.Duration
 	// AllowRemote lets this session download the http(s) URLs it is given. It is
 	// an explicit capability rather than a default: without it sqly makes no HTTP
 	// request at all, so a wrapper that never passes the flag has turned sqly's
@@ -84,6 +128,16 @@ type Arg struct {
 	// 0 means schema-only (no sample rows), which keeps the report small for
 	// wide or multi-table sources.
 	InspectSample int
+	// InspectFormat is what --inspect prints: the JSON report, or the schema
+	// sidecar of the imported tables, which an import applies when it is kept
+	// beside its data as FILE.schema.json.
+	InspectFormat InspectFormat
+	// ServeAddr, when non-empty, makes sqly import its inputs once and then
+	// answer HTTP queries on this address until it is stopped (for --serve).
+	ServeAddr string
+	// AllowWrites lets a --serve session run statements that change its tables.
+	// Without it the server answers only statements that read.
+	AllowWrites bool
 	// Usage message
 	Usage string
 	// StdinFormat, when non-empty, makes sqly read stdin as an input dataset of
@@ -106,6 +160,58 @@ type Arg struct {
 	// meant to publish. It sets the policy for the whole session, so a later
 	// .import applies it too.
 	IncludeHiddenSheets bool
+	// XMLRecord names the elements of an XML input that are its rows, as a path
+	// from the root such as /feed/item. Empty takes the children of the root
+	// element. Like the sheet policy, it holds for the whole session.
+	XMLRecord string
+	// CSVDialect is how the CSV and TSV inputs are written where they differ
+	// from their format — the delimiter, the quote, comment lines, lines to skip
+	// above the header, or no header at all — from --delimiter, --quote,
+	// --comment-prefix, --skip-lines, and --no-header. Like XMLRecord, it holds
+	// for the whole session; a .import that gives its own reads its files with
+	// that instead.
+	CSVDialect model.CSVDialect
+	// Cleaning is what is done to the values of the CSV and TSV inputs as they
+	// are read — the whitespace around them trimmed, the placeholders for a
+	// missing value read as NULL, the dates in a layout of the file's own read
+	// as ISO 8601, and the amounts kept as exact decimals — from --trim,
+	// --null-values, --date-columns, and --decimal-columns. Like CSVDialect, it
+	// holds for the whole session.
+	Cleaning model.ImportCleaning
+	// Normalize is the Unicode normalization form the text inputs are brought to
+	// as they are read, from --normalize. Empty reads the text as it is.
+	Normalize model.UnicodeNormalization
+	// SQLiteTables names the tables an import of a SQLite database copies, from
+	// --sqlite-tables. Empty copies every table. Like XMLRecord, it holds for the
+	// whole session.
+	SQLiteTables []string
+	// ColumnTypes declares the type some imported columns are created with, in
+	// place of the type the import infers, from --column-type. Each names its
+	// table, so it applies to whichever input creates that table, including a
+	// later .import or .reload.
+	ColumnTypes model.ColumnTypes
+	// PrimaryKeys declares the primary key some imported tables are created
+	// with, from --primary-key: one per table, validated for uniqueness by the
+	// import. Indexes declares the indexes created on imported tables, from
+	// --index. Like ColumnTypes, each names its table and holds for every import
+	// of the session.
+	PrimaryKeys model.TableKeys
+	Indexes     model.TableKeys
+	// Union names the one table every input file is read into, from --union,
+	// in place of a table per file. It is for data split into shards by
+	// whatever wrote it, such as a file a day. SourceFileColumn adds a column
+	// to that table naming the file each row came from.
+	Union            string
+	SourceFileColumn bool
+	// Partitioned reads each input directory as one Hive-style partitioned
+	// table, from --partitioned: its key=value subdirectories become columns.
+	// With Union set, every directory is read into that one table.
+	Partitioned bool
+	// DBPath is the SQLite database file the session keeps its tables in (for
+	// --db). Empty means an in-memory session, which is gone when sqly exits.
+	// A file-backed session also records where each table came from, so the
+	// next run can skip importing a source that has not changed since.
+	DBPath string
 	// ExplicitFlags names the flags the user actually typed, as opposed to the
 	// ones sitting at their default. A flag that was typed but can never apply to
 	// any of this run's inputs is an error, and only the user's intent — not the
@@ -154,9 +260,28 @@ func newArg(args []string) (*Arg, error) {
 	// Input.
 	stdinFormat := flag.String("stdin-format", "", "read stdin as a dataset instead of as SQL; one of: "+model.StdinFormatNames())
 	stdinTable := flag.String("stdin-table", defaultStdinTable, "table name for the --stdin-format dataset")
-	importEncoding := flag.String("encoding", model.TextEncodingUTF8.String(), "decode every csv, tsv, ltsv, json, and jsonl input that has no BOM as one of: "+strings.ReplaceAll(model.TextEncodingHelp(), "|", ", "))
+	importEncoding := flag.String("encoding", model.TextEncodingUTF8.String(), "decode every csv, tsv, ltsv, json, and jsonl input that has no BOM as one of: "+strings.ReplaceAll(model.TextEncodingHelp(), "|", ", ")+" (auto guesses each file's encoding and reports the guess)")
 	rowMismatch := flag.String("row-mismatch", model.RowMismatchError.String(), "for csv and tsv, what to do with a row whose field count differs from the header: error (fail the import), skip (drop the row), pad (fill a short row, fail on a long one)")
+	delimiter := flag.String("delimiter", "", "for csv and tsv, the character between fields, such as ';' or '|', or tab (default: a comma for csv, a tab for tsv)")
+	quote := flag.String("quote", "", "for csv and tsv, the character a field is enclosed in, such as \"'\", or none for a file that quotes nothing (default: a double quote for csv, none for tsv)")
+	commentPrefix := flag.String("comment-prefix", "", "for csv and tsv, skip every line that starts with this text, such as #")
+	skipLines := flag.Int("skip-lines", 0, "for csv and tsv, skip this many lines at the top of the file, before the header")
+	flag.BoolVar(&arg.CSVDialect.NoHeader, "no-header", false, "for csv and tsv, read the first line as data and name the columns c1, c2, and so on")
+	nullValues := flag.String("null-values", "", "for csv and tsv only (ltsv and json are read as they are), read these values as NULL, as VALUE[,VALUE...] such as 'NA,N/A,-'; an empty field is read as NULL too, and '' names it alone")
+	flag.BoolVar(&arg.Cleaning.Trim, "trim", false, "for csv and tsv only (ltsv and json are read as they are), strip the whitespace around every value and column name")
+	decimalColumns := flag.String("decimal-columns", "", "for csv and tsv, keep the named columns as exact decimals, stored as their text and declared DECIMAL, as [TABLE.]COLUMN[,...] such as 'amount,orders.fee'")
+	dateColumns := flag.String("date-columns", "", "for csv and tsv, read the named columns' dates with a strftime layout and store them as ISO 8601, as [TABLE.]COLUMN=LAYOUT[,...] such as 'ordered=%m/%d/%Y'")
+	normalize := flag.String("normalize", "", "bring the text of every csv, tsv, ltsv, json, and jsonl input to this unicode normalization form: "+strings.ReplaceAll(model.UnicodeNormalizationHelp(), "|", ", "))
 	flag.BoolVar(&arg.IncludeHiddenSheets, "include-hidden-sheets", false, "import the sheets an excel workbook hides as well as the ones it shows")
+	xmlRecord := flag.String("xml-record", "", "for xml, the path from the root of the elements that are rows, such as /feed/item (default: the children of the root element)")
+	sqliteTables := flag.String("sqlite-tables", "", "for a sqlite database (.db, .sqlite, .sqlite3), import only these tables, as TABLE[,TABLE...] (default: every table)")
+	columnTypes := flag.String("column-type", "", "create the named columns with these types instead of inferred ones, as TABLE.COLUMN=TYPE[,...] such as users.zip=TEXT; TYPE is one of: text, integer, real, numeric, datetime")
+	primaryKeys := flag.String("primary-key", "", "create the named tables with this primary key, as TABLE(COLUMN[,COLUMN...])[,...] such as users(id); an import with a repeated or empty key fails")
+	indexes := flag.String("index", "", "create an index on the named columns after import, as TABLE(COLUMN[,COLUMN...])[,...] such as orders(user_id)")
+	union := flag.String("union", "", "read every input file into this one table, matching their columns by name, instead of a table per file")
+	flag.BoolVar(&arg.SourceFileColumn, "source-file-column", false, "with --union or --partitioned, add a _source_file column holding the file each row came from")
+	flag.BoolVar(&arg.Partitioned, "partitioned", false, "read each input directory as one hive-style partitioned table, such as sales/year=2024/region=eu/part-0.parquet, with each key=value directory a column")
+	dbPath := flag.String("db", "", "keep the session's tables in this sqlite database file instead of in memory; an input unchanged since it was imported into the file is not read again")
 	// --allow-remote is a capability, not a security boundary. It decides whether
 	// sqly performs an HTTP request at all; it decides nothing about where that
 	// request may go. A caller that can add flags can add this one, so what it
@@ -168,13 +293,27 @@ func newArg(args []string) (*Arg, error) {
 	query := flag.StringP("sql", "s", "", "run one SQL statement, then exit")
 	sqlFile := flag.StringP("sql-file", "f", "", "run every SQL statement in this file, then exit; a dot-command is rejected, so use --script-file for those; printing several results needs --output-format table, vertical, or markdown")
 	scriptFile := flag.String("script-file", "", "run this sqly script, then exit: SQL statements and dot-commands, exactly as when piped in; use it to script .save and .import from a file")
+	flag.BoolVar(&arg.Watch, "watch", false, "with --sql or --sql-file, keep running: whenever an input file changes, import it again and print the result again; stop with ctrl-c")
+	watchInterval := flag.Duration("watch-interval", DefaultWatchInterval, "how often --watch checks the inputs for a change, as a go duration such as 500ms or 2s")
 	sqlDialect := flag.String("dialect", string(dialect.SQLite), "write the query in one of: "+DialectNameList()+"; sqly translates it to SQLite")
 	// Output.
 	output := flag.StringP("output", "o", "", "write the one query result to this file instead of stdout")
-	outputFormat := flag.String("output-format", model.PrintModeTable.String(), "print the query result as one of: "+model.PrintModeNames()+"; excel and parquet need --output")
+	outputFormat := flag.String("output-format", model.PrintModeTable.String(), "print the query result as one of: "+model.PrintModeNames()+"; excel, parquet, and sqlite need --output")
+	outputPartitionBy := flag.String("output-partition-by", "", "with --output DIR, write the result as a hive-style partitioned tree, one directory level per column named, as COLUMN[,COLUMN...] such as year,region")
+	outputDialect := flag.String("output-dialect", string(model.SQLDialectSQLite), "write sql output, and .dump to a .sql file, for one of: "+model.SQLDialectNames())
+	csvDelimiter := flag.String("csv-delimiter", ",", "separate the fields of a csv file sqly writes (--output, .dump, .save DIR) with this character, such as | or tab")
+	csvQuoteAll := flag.Bool("csv-quote-all", false, "quote every field of a csv file sqly writes, not only the ones that need it")
+	crlf := flag.Bool("crlf", false, "end each record of a csv or tsv file sqly writes with CRLF instead of LF")
+	bom := flag.Bool("bom", false, "start a csv or tsv file sqly writes with a UTF-8 byte-order mark, which Excel needs to read it as UTF-8")
+	nullString := flag.String("null-string", "", "write NULL as this text, such as NULL or \\N, in table, csv, tsv, and ltsv output (default: an empty value)")
+	mask := flag.String("mask", "", "mask these columns in every file sqly writes and every --serve response, as [TABLE.]COLUMN=hash|redact[:N][,...] such as 'email=hash,phone=redact'; hash writes the sha-256, redact keeps the last N characters (default 4)")
 	// Inspection.
 	flag.BoolVar(&arg.InspectFlag, "inspect", false, "print one JSON report of the imported tables (schema, row counts, source) and exit; no row data unless --inspect-sample asks for it")
 	inspectSample := flag.Int("inspect-sample", DefaultInspectSample, "sample rows per table in the --inspect report; 0 keeps the report schema-only")
+	inspectFormat := flag.String("format", string(InspectFormatJSON), "what --inspect prints: json (the report) or schema (a schema sidecar to keep beside the input as FILE.schema.json)")
+	// Server.
+	serveAddr := flag.String("serve", "", "import the inputs once, then answer HTTP queries on this address (such as 127.0.0.1:8080) until stopped; read-only unless --allow-writes")
+	flag.BoolVar(&arg.AllowWrites, "allow-writes", false, "let --serve run statements that change the session's tables")
 	// General.
 	flag.BoolVarP(&arg.HelpFlag, "help", "h", false, "print this help and exit")
 	flag.BoolVarP(&arg.VersionFlag, "version", "v", false, "print the sqly version and exit")
@@ -200,6 +339,144 @@ func newArg(args []string) (*Arg, error) {
 	if flag.Changed("stdin-format") && *stdinFormat == "" {
 		return nil, errEmptyStdinFormat
 	}
+	if flag.Changed("db") && *dbPath == "" {
+		return nil, errEmptyDB
+	}
+	if flag.Changed("serve") && *serveAddr == "" {
+		return nil, errEmptyServe
+	}
+	if flag.Changed("xml-record") && *xmlRecord == "" {
+		return nil, errEmptyXMLRecord
+	}
+	if *xmlRecord != "" {
+		if _, err := model.ParseXMLRecordPath(*xmlRecord); err != nil {
+			return nil, err
+		}
+	}
+	if flag.Changed("date-columns") && *dateColumns == "" {
+		return nil, errEmptyDateColumns
+	}
+	if *dateColumns != "" {
+		dates, err := model.ParseDateColumns(*dateColumns)
+		if err != nil {
+			return nil, fmt.Errorf("--date-columns: %w", err)
+		}
+		arg.Cleaning.Dates = dates
+	}
+	if flag.Changed("decimal-columns") && *decimalColumns == "" {
+		return nil, errEmptyDecimalColumns
+	}
+	if *decimalColumns != "" {
+		decimals, err := model.ParseDecimalColumns(*decimalColumns)
+		if err != nil {
+			return nil, fmt.Errorf("--decimal-columns: %w", err)
+		}
+		arg.Cleaning.Decimals = decimals
+	}
+	if flag.Changed("column-type") && *columnTypes == "" {
+		return nil, errEmptyColumnType
+	}
+	if *columnTypes != "" {
+		declared, err := model.ParseColumnTypes(*columnTypes)
+		if err != nil {
+			return nil, fmt.Errorf("--column-type: %w", err)
+		}
+		arg.ColumnTypes = declared
+	}
+	primaryKeyList, err := parseTableKeysFlag(&flag, "primary-key", *primaryKeys, errEmptyPrimaryKey)
+	if err != nil {
+		return nil, err
+	}
+	arg.PrimaryKeys = primaryKeyList
+	for i, key := range arg.PrimaryKeys {
+		if len(arg.PrimaryKeys[:i].ForTable(key.Table)) > 0 {
+			return nil, fmt.Errorf("--primary-key: table %q is given two primary keys; a table has one", key.Table)
+		}
+	}
+	indexList, err := parseTableKeysFlag(&flag, "index", *indexes, errEmptyIndex)
+	if err != nil {
+		return nil, err
+	}
+	arg.Indexes = indexList
+
+	// --union reads the files on the command line and nothing else: stdin has
+	// no file name for --source-file-column to record, and is not a shard of
+	// anything the user named.
+	if flag.Changed("union") && *union == "" {
+		return nil, errEmptyUnion
+	}
+	if *union != "" && *stdinFormat != "" {
+		return nil, errUnionWithStdin
+	}
+	if arg.SourceFileColumn && *union == "" && !arg.Partitioned {
+		return nil, errSourceFileColumnWithoutUnion
+	}
+	if arg.Partitioned && *stdinFormat != "" {
+		return nil, errPartitionedWithStdin
+	}
+	sqliteTableNames, err := parseSQLiteTables(&flag, *sqliteTables)
+	if err != nil {
+		return nil, err
+	}
+	if err := parseCSVDialect(&flag, &arg.CSVDialect, *delimiter, *quote, *commentPrefix, *skipLines); err != nil {
+		return nil, err
+	}
+	partitionBy, err := parsePartitionBy(&flag, *outputPartitionBy)
+	if err != nil {
+		return nil, err
+	}
+	if len(partitionBy) > 0 && *output == "" {
+		return nil, errOutputPartitionByWithoutOutput
+	}
+	csvOutput, err := parseCSVOutput(&flag, *csvDelimiter, *csvQuoteAll, *crlf, *bom)
+	if err != nil {
+		return nil, err
+	}
+	if flag.Changed("null-values") {
+		arg.Cleaning.NullValues = model.ParseNullValues(*nullValues)
+	}
+	if flag.Changed("normalize") {
+		form, err := model.ParseUnicodeNormalization(*normalize)
+		if err != nil {
+			return nil, err
+		}
+		arg.Normalize = form
+	}
+	if strings.ContainsAny(*nullString, "\t\r\n") {
+		return nil, errNullStringLayout
+	}
+	if flag.Changed("mask") && *mask == "" {
+		return nil, errEmptyMask
+	}
+	var masks model.ColumnMasks
+	if *mask != "" {
+		masks, err = model.ParseColumnMasks(*mask)
+		if err != nil {
+			return nil, fmt.Errorf("--mask: %w", err)
+		}
+		if (*query != "" || *sqlFile != "") && *output == "" && *scriptFile == "" {
+			return nil, errMaskWithoutOutput
+		}
+		// A partition column's values are written as directory names, which
+		// no mask reaches.
+		for _, column := range partitionBy {
+			if masks.Masks(column) {
+				return nil, fmt.Errorf("--mask: column %s is an --output-partition-by column, and its values would be written unmasked as directory names", column)
+			}
+		}
+	}
+
+	// The address is checked for shape only. Whether the port is free is a
+	// question for the moment the server starts, and a host that does not resolve
+	// is reported then too, naming the address as typed.
+	if *serveAddr != "" {
+		if _, _, err := net.SplitHostPort(*serveAddr); err != nil {
+			return nil, fmt.Errorf("invalid --serve address %q: want HOST:PORT, such as 127.0.0.1:8080 or :8080", *serveAddr)
+		}
+	}
+	if arg.AllowWrites && *serveAddr == "" {
+		return nil, errAllowWritesWithoutServe
+	}
 
 	// Reject an unknown --stdin-format here rather than when stdin is staged. It
 	// is a flag value like --row-mismatch or --dialect, so a typo should fail the
@@ -229,6 +506,31 @@ func newArg(args []string) (*Arg, error) {
 	if flag.Changed("inspect-sample") && !arg.InspectFlag {
 		return nil, errInspectSampleWithoutInspect
 	}
+	// --format chooses between the things --inspect prints, so it is held to
+	// the same rule, and a sidecar holds no rows for --inspect-sample to cap.
+	if flag.Changed("format") && !arg.InspectFlag {
+		return nil, errFormatWithoutInspect
+	}
+	format, err := parseInspectFormat(*inspectFormat)
+	if err != nil {
+		return nil, err
+	}
+	if format == InspectFormatSchema && flag.Changed("inspect-sample") {
+		return nil, errInspectSampleWithSchema
+	}
+	arg.InspectFormat = format
+
+	// --watch re-runs a query, so it needs one; --watch-interval only paces
+	// --watch. Both are decided from the command line, before anything is read.
+	if arg.Watch && *query == "" && *sqlFile == "" {
+		return nil, errWatchWithoutQuery
+	}
+	if flag.Changed("watch-interval") && !arg.Watch {
+		return nil, errWatchIntervalWithoutWatch
+	}
+	if *watchInterval <= 0 {
+		return nil, fmt.Errorf("%w, got %s", errNonPositiveWatchInterval, *watchInterval)
+	}
 
 	// A negative count is a malformed value, not a run that fails: it is caught
 	// here so the exit code says "fix the command line" and nothing has been read
@@ -268,10 +570,26 @@ func newArg(args []string) (*Arg, error) {
 	if err != nil {
 		return nil, err
 	}
+	outputDialectValue, err := model.ParseSQLDialect(*outputDialect)
+	if err != nil {
+		return nil, err
+	}
 
 	arg.Usage = usage(flag)
 	arg.Version = version
-	arg.Output = newOutput(*output, outputMode)
+	arg.Output = newOutput(*output, outputMode, outputDialectValue)
+	arg.Output.PartitionBy = partitionBy
+	arg.Output.CSV = csvOutput
+	arg.Output.NullString = *nullString
+	arg.Output.Mask = masks
+	// The layout flags lay out files, as --mask masks them, and a query that
+	// prints to the screen writes none.
+	if !csvOutput.IsZero() && (*query != "" || *sqlFile != "") && *output == "" && *scriptFile == "" {
+		return nil, fmt.Errorf("%s lays out the files sqly writes, and this query prints to the screen; add --output FILE, or drop the flag", csvOutput)
+	}
+	if err := arg.Output.checkCSVOutput(); err != nil {
+		return nil, err
+	}
 	arg.FilePaths = flag.Args()
 	arg.StdinFormat = *stdinFormat
 	arg.StdinTableName = *stdinTable
@@ -283,6 +601,18 @@ func newArg(args []string) (*Arg, error) {
 	arg.SQLFilePath = *sqlFile
 	arg.ScriptFilePath = *scriptFile
 	arg.InspectSample = *inspectSample
+	arg.DBPath = *dbPath
+	arg.WatchInterval = *watchInterval
+	arg.ServeAddr = *serveAddr
+	arg.XMLRecord = *xmlRecord
+	arg.SQLiteTables = sqliteTableNames
+	arg.Union = *union
+	if arg.Union != "" && len(arg.FilePaths) == 0 {
+		return nil, errUnionWithoutInputs
+	}
+	if arg.Partitioned && len(arg.FilePaths) == 0 {
+		return nil, errPartitionedWithoutInputs
+	}
 
 	return arg, nil
 }
@@ -355,9 +685,148 @@ func parseOutputFormat(name string) (model.PrintMode, error) {
 	return mode, nil
 }
 
-// newOutput returns the output destination and its selected format.
-func newOutput(filePath string, mode model.PrintMode) *Output {
-	return &Output{FilePath: filePath, Mode: mode}
+// parseTableKeysFlag parses the value of --primary-key or --index, refusing one
+// given explicitly empty the way --column-type does.
+func parseTableKeysFlag(flag *pflag.FlagSet, name, spec string, errEmpty error) (model.TableKeys, error) {
+	if flag.Changed(name) && spec == "" {
+		return nil, errEmpty
+	}
+	if spec == "" {
+		return nil, nil
+	}
+	keys, err := model.ParseTableKeys(spec)
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", name, err)
+	}
+	return keys, nil
+}
+
+// parsePartitionBy reads --output-partition-by into the columns it names, in
+// order. The order is the directory order, so a column named twice would be a
+// level whose value its parent already fixed, and an empty name is a typo.
+// Whether the columns exist is a question for the result, which the command
+// line cannot answer.
+func parsePartitionBy(flag *pflag.FlagSet, spec string) ([]string, error) {
+	if flag.Changed("output-partition-by") && strings.TrimSpace(spec) == "" {
+		return nil, errEmptyOutputPartitionBy
+	}
+	return parseNameList("output-partition-by", "column", spec, "year,region")
+}
+
+// parseSQLiteTables reads --sqlite-tables into the tables it names. Whether a
+// database has them is checked when it is imported, where the error can list
+// the tables it does have.
+func parseSQLiteTables(flag *pflag.FlagSet, spec string) ([]string, error) {
+	if flag.Changed("sqlite-tables") && strings.TrimSpace(spec) == "" {
+		return nil, errEmptySQLiteTables
+	}
+	return parseNameList("sqlite-tables", "table", spec, "users,orders")
+}
+
+// parseCSVDialect fills in the dialect the CSV and TSV inputs are read with,
+// from the flags that were given. An explicit empty --delimiter, --quote, or
+// --comment-prefix is refused rather than read as the default, for the reason
+// an empty --union is.
+func parseCSVDialect(flag *pflag.FlagSet, dialect *model.CSVDialect, delimiter, quote, commentPrefix string, skipLines int) error {
+	if flag.Changed("delimiter") {
+		r, err := model.ParseCSVDelimiter(delimiter)
+		if err != nil {
+			return err
+		}
+		dialect.Delimiter = r
+	}
+	if flag.Changed("quote") {
+		r, err := model.ParseCSVQuote(quote)
+		if err != nil {
+			return err
+		}
+		dialect.Quote = r
+	}
+	if flag.Changed("comment-prefix") && commentPrefix == "" {
+		return errEmptyCommentPrefix
+	}
+	dialect.CommentPrefix = commentPrefix
+	dialect.SkipLines = skipLines
+	return dialect.Validate()
+}
+
+// parseCSVOutput reads the flags that lay out a CSV or TSV file sqly writes.
+// --csv-delimiter has a default so --help can show it; only a changed one is
+// kept, so the zero layout stays the default one.
+func parseCSVOutput(flag *pflag.FlagSet, delimiter string, quoteAll, crlf, bom bool) (model.CSVOutput, error) {
+	output := model.CSVOutput{QuoteAll: quoteAll, CRLF: crlf, BOM: bom}
+	if flag.Changed("csv-delimiter") {
+		r, err := model.ParseCSVOutputDelimiter(delimiter)
+		if err != nil {
+			return model.CSVOutput{}, err
+		}
+		if r != ',' {
+			output.Delimiter = r
+		}
+	}
+	return output, nil
+}
+
+// checkCSVOutput refuses a CSV layout flag an --output file cannot use: one
+// whose format is known, from --output-format or from its extension, and is not
+// the format the flag lays out. Without --output the flags are for .dump and
+// .save DIR, which a session may still run, so they are kept; a --sql run with
+// neither is refused before this.
+func (o *Output) checkCSVOutput() error {
+	if o.FilePath == "" || o.CSV.IsZero() {
+		return nil
+	}
+	format, _, err := model.ResolveOutputTarget(o.FilePath, model.ExportFormatFromPrintMode(o.Mode), !o.Mode.IsDisplayOnly())
+	if err != nil {
+		// The shell reports a destination it cannot resolve, in its own words.
+		return nil //nolint:nilerr // reported when the result is written
+	}
+	switch {
+	case format == model.ExportCSV:
+		return nil
+	case format == model.ExportTSV && o.CSV.Delimiter == 0 && !o.CSV.QuoteAll:
+		return nil
+	case format == model.ExportTSV:
+		csvOnly := model.CSVOutput{Delimiter: o.CSV.Delimiter, QuoteAll: o.CSV.QuoteAll}
+		return fmt.Errorf("%s applies to csv output, and --output %s is tsv; drop the flag", csvOnly, o.FilePath)
+	default:
+		return fmt.Errorf("%s applies to csv and tsv output, and --output %s is %s; drop the flag", o.CSV, o.FilePath, format)
+	}
+}
+
+// parseNameList splits a comma-separated list of names given to --option,
+// refusing an empty name and a name given twice, which SQL names would make
+// the same name in either case.
+func parseNameList(option, kind, spec, example string) ([]string, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	var names []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("--%s %q names an empty %s; separate %s names with single commas, such as %s", option, spec, kind, kind, example)
+		}
+		if slices.ContainsFunc(names, func(n string) bool { return strings.EqualFold(n, name) }) {
+			return nil, fmt.Errorf("--%s names %s %q twice", option, kind, name)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func parseInspectFormat(name string) (InspectFormat, error) {
+	switch format := InspectFormat(strings.ToLower(strings.TrimSpace(name))); format {
+	case InspectFormatJSON, InspectFormatSchema:
+		return format, nil
+	}
+	return InspectFormatJSON, fmt.Errorf("invalid --format %q: want %s or %s", name, InspectFormatJSON, InspectFormatSchema)
+}
+
+// newOutput returns the output destination, its selected format, and the
+// dialect a sql script is written for.
+func newOutput(filePath string, mode model.PrintMode, sqlDialect model.SQLDialect) *Output {
+	return &Output{FilePath: filePath, Mode: mode, Dialect: sqlDialect}
 }
 
 // NeedsOutputToFile whether the data needs to be output to the file
@@ -379,10 +848,11 @@ type optionGroup struct {
 // newArg appears in exactly one group; helpUsage fails loudly if that stops
 // being true, so a flag added later cannot silently vanish from --help.
 var optionGroups = []optionGroup{
-	{title: "Input", options: []string{"stdin-format", "stdin-table", "encoding", "row-mismatch", "include-hidden-sheets", "allow-remote"}},
-	{title: "Query", options: []string{"sql", "sql-file", "script-file", "dialect"}},
-	{title: "Output", options: []string{"output", "output-format"}},
-	{title: "Inspection", options: []string{"inspect", "inspect-sample"}},
+	{title: "Input", options: []string{"stdin-format", "stdin-table", "encoding", "row-mismatch", "delimiter", "quote", "comment-prefix", "skip-lines", "no-header", "null-values", "trim", "date-columns", "decimal-columns", "normalize", "include-hidden-sheets", "xml-record", "sqlite-tables", "column-type", "primary-key", "index", "union", "partitioned", "source-file-column", "allow-remote", "db"}},
+	{title: "Query", options: []string{"sql", "sql-file", "script-file", "dialect", "watch", "watch-interval"}},
+	{title: "Output", options: []string{"output", "output-format", "output-partition-by", "output-dialect", "csv-delimiter", "csv-quote-all", "crlf", "bom", "null-string", "mask"}},
+	{title: "Inspection", options: []string{"inspect", "inspect-sample", "format"}},
+	{title: "Server", options: []string{"serve", "allow-writes"}},
 	{title: "General", options: []string{"help", "version"}},
 }
 
@@ -396,23 +866,54 @@ const (
 	argPolicy   = "POLICY"
 	argSQL      = "SQL"
 	argCount    = "N"
+	argTime     = "TIME"
+	argAddr     = "ADDR"
+	argPath     = "PATH"
+	argSpec     = "SPEC"
+	argColumns  = "COLUMNS"
+	argTables   = "TABLES"
+	argChar     = "CHAR"
+	argText     = "TEXT"
+	argValues   = "VALUES"
 )
 
 // optionArgNames gives each value-taking flag the placeholder --help shows after
 // it, so the kind of value a flag wants (a file, a directory, a format name) is
 // visible in the list itself instead of only in the prose.
 var optionArgNames = map[string]string{
-	"stdin-format":   argFormat,
-	"stdin-table":    argName,
-	"encoding":       argEncoding,
-	"row-mismatch":   argPolicy,
-	"sql":            argSQL,
-	"sql-file":       argFile,
-	"script-file":    argFile,
-	"dialect":        argName,
-	"output":         argFile,
-	"output-format":  argFormat,
-	"inspect-sample": argCount,
+	"stdin-format":        argFormat,
+	"stdin-table":         argName,
+	"encoding":            argEncoding,
+	"row-mismatch":        argPolicy,
+	"delimiter":           argChar,
+	"quote":               argChar,
+	"comment-prefix":      argText,
+	"skip-lines":          argCount,
+	"null-values":         argValues,
+	"decimal-columns":     argColumns,
+	"normalize":           argName,
+	"xml-record":          argPath,
+	"sqlite-tables":       argTables,
+	"column-type":         argSpec,
+	"primary-key":         argSpec,
+	"index":               argSpec,
+	"union":               argName,
+	"db":                  argFile,
+	"sql":                 argSQL,
+	"sql-file":            argFile,
+	"script-file":         argFile,
+	"dialect":             argName,
+	"watch-interval":      argTime,
+	"output":              argFile,
+	"output-format":       argFormat,
+	"output-partition-by": argColumns,
+	"output-dialect":      argName,
+	"csv-delimiter":       argChar,
+	"null-string":         argText,
+	"mask":                argSpec,
+	"inspect-sample":      argCount,
+	"format":              argFormat,
+	"serve":               argAddr,
 }
 
 // helpWidth is the column the option descriptions wrap at. 80 keeps --help
@@ -421,8 +922,8 @@ const helpWidth = 80
 
 // usage return usage message.
 func usage(flag pflag.FlagSet) string {
-	s := color.GreenString("sqly") + " - run SQL against CSV, TSV, LTSV, JSON, JSONL, Parquet, Excel, ACH,\n"
-	s += fmt.Sprintf("and Fedwire files (%s)\n", GetVersion())
+	s := color.GreenString("sqly") + " - run SQL against CSV, TSV, LTSV, JSON, JSONL, XML, Parquet, Excel,\n"
+	s += fmt.Sprintf("ACH, Fedwire, and SQLite files (%s)\n", GetVersion())
 	s += "\n"
 	s += "[Usage]\n"
 	s += fmt.Sprintf("  %s [OPTIONS] [FILE|DIRECTORY|URL ...]\n", color.GreenString("sqly"))
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -295,6 +296,23 @@ func TestNewArg(t *testing.T) {
 		}
 	})
 
+	t.Run("explicit empty --db is rejected", func(t *testing.T) {
+		_, err := NewArg([]string{"sqly", "--db", "", "testdata/user.csv"})
+		if !errors.Is(err, errEmptyDB) {
+			t.Errorf("mismatch error got=%v, want=%v", err, errEmptyDB)
+		}
+	})
+
+	t.Run("--db names the session database file", func(t *testing.T) {
+		arg, err := NewArg([]string{"sqly", "testdata/user.csv", "--db", "session.db"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if arg.DBPath != "session.db" {
+			t.Errorf("DBPath = %q, want %q", arg.DBPath, "session.db")
+		}
+	})
+
 	t.Run("invalid output format is rejected", func(t *testing.T) {
 		if _, err := NewArg([]string{"sqly", "--output-format", "yaml"}); err == nil {
 			t.Fatal("NewArg accepted unsupported output format yaml")
@@ -453,8 +471,8 @@ func TestNewArg(t *testing.T) {
 
 	t.Run("an invalid --encoding is rejected", func(t *testing.T) {
 		t.Parallel()
-		if _, err := NewArg([]string{"sqly", "--encoding", "latin1"}); err == nil {
-			t.Fatal("NewArg with --encoding latin1 returned nil error, want an error")
+		if _, err := NewArg([]string{"sqly", "--encoding", "koi8-r"}); err == nil {
+			t.Fatal("NewArg with --encoding koi8-r returned nil error, want an error")
 		}
 	})
 
@@ -593,8 +611,9 @@ func getStdout(t *testing.T, f func()) string {
 
 // TestNewArgDependentFlagValidation covers the flag dependencies the parser
 // enforces: --stdin-table without --stdin-format, --inspect-sample without
-// --inspect, the two write-back destinations together, and a SQLite-keyword
-// --stdin-table.
+// --inspect, the two write-back destinations together, a SQLite-keyword
+// --stdin-table, --watch without a query or with an interval of no length, and
+// --serve without an address or --allow-writes without --serve.
 func TestNewArgDependentFlagValidation(t *testing.T) {
 	t.Parallel()
 
@@ -623,6 +642,111 @@ func TestNewArgDependentFlagValidation(t *testing.T) {
 			args:    []string{"sqly", "--stdin-format", "csv", "--stdin-table", "select", "--sql", "SELECT 1"},
 			wantErr: errStdinTableReserved,
 		},
+		{
+			name:    "watch without a query is rejected",
+			args:    []string{"sqly", "--watch", "log.csv"},
+			wantErr: errWatchWithoutQuery,
+		},
+		{
+			name:    "watch with a script file is rejected",
+			args:    []string{"sqly", "--watch", "--script-file", "run.sqly", "log.csv"},
+			wantErr: errWatchWithoutQuery,
+		},
+		{
+			name:    "watch-interval without watch is rejected",
+			args:    []string{"sqly", "--watch-interval", "2s", "--sql", "SELECT 1"},
+			wantErr: errWatchIntervalWithoutWatch,
+		},
+		{
+			name:    "a zero watch-interval is rejected",
+			args:    []string{"sqly", "--watch", "--watch-interval", "0s", "--sql", "SELECT 1"},
+			wantErr: errNonPositiveWatchInterval,
+		},
+		{
+			name:    "an empty serve address is rejected",
+			args:    []string{"sqly", "--serve", "", "log.csv"},
+			wantErr: errEmptyServe,
+		},
+		{
+			name:    "allow-writes without serve is rejected",
+			args:    []string{"sqly", "--allow-writes", "--sql", "SELECT 1"},
+			wantErr: errAllowWritesWithoutServe,
+		},
+		{
+			name:    "an empty xml-record is rejected",
+			args:    []string{"sqly", "--xml-record", "", "feed.xml"},
+			wantErr: errEmptyXMLRecord,
+		},
+		{
+			name:    "an empty column-type is rejected",
+			args:    []string{"sqly", "--column-type", "", "users.csv"},
+			wantErr: errEmptyColumnType,
+		},
+		{
+			name:    "an empty primary-key is rejected",
+			args:    []string{"sqly", "--primary-key", "", "users.csv"},
+			wantErr: errEmptyPrimaryKey,
+		},
+		{
+			name:    "an empty index is rejected",
+			args:    []string{"sqly", "--index", "", "users.csv"},
+			wantErr: errEmptyIndex,
+		},
+		{
+			name:    "an empty union is rejected",
+			args:    []string{"sqly", "--union", "", "a.csv"},
+			wantErr: errEmptyUnion,
+		},
+		{
+			name:    "union without inputs is rejected",
+			args:    []string{"sqly", "--union", "events", "--sql", "SELECT 1"},
+			wantErr: errUnionWithoutInputs,
+		},
+		{
+			name:    "union with stdin-format is rejected",
+			args:    []string{"sqly", "--union", "events", "--stdin-format", "csv", "a.csv"},
+			wantErr: errUnionWithStdin,
+		},
+		{
+			name:    "source-file-column without union is rejected",
+			args:    []string{"sqly", "--source-file-column", "a.csv"},
+			wantErr: errSourceFileColumnWithoutUnion,
+		},
+		{
+			name:    "partitioned without inputs is rejected",
+			args:    []string{"sqly", "--partitioned", "--sql", "SELECT 1"},
+			wantErr: errPartitionedWithoutInputs,
+		},
+		{
+			name:    "partitioned with stdin-format is rejected",
+			args:    []string{"sqly", "--partitioned", "--stdin-format", "csv", "sales"},
+			wantErr: errPartitionedWithStdin,
+		},
+		{
+			name:    "an empty output-partition-by is rejected",
+			args:    []string{"sqly", "--output-partition-by", "", "-o", "out", "--sql", "SELECT 1"},
+			wantErr: errEmptyOutputPartitionBy,
+		},
+		{
+			name:    "output-partition-by without output is rejected",
+			args:    []string{"sqly", "--output-partition-by", "year", "--sql", "SELECT 1"},
+			wantErr: errOutputPartitionByWithoutOutput,
+		},
+		{
+			name:    "an empty sqlite-tables is rejected",
+			args:    []string{"sqly", "--sqlite-tables", " ", "app.db"},
+			wantErr: errEmptySQLiteTables,
+		},
+		{
+			name:    "format without inspect is rejected",
+			args:    []string{"sqly", "--format", "schema", "users.csv"},
+			wantErr: errFormatWithoutInspect,
+		},
+		{
+			name:    "inspect-sample with the schema format is rejected",
+			args:    []string{"sqly", "--inspect", "--format", "schema", "--inspect-sample", "3", "users.csv"},
+			wantErr: errInspectSampleWithSchema,
+		},
 	}
 
 	for _, tt := range tests {
@@ -641,6 +765,12 @@ func TestNewArgDependentFlagValidation(t *testing.T) {
 		ok := [][]string{
 			{"sqly", "--stdin-format", "csv", "--stdin-table", "data", "--sql", "SELECT 1"},
 			{"sqly", "--inspect", "--inspect-sample", "0"},
+			{"sqly", "--inspect", "--format", "schema", "users.csv"},
+			{"sqly", "--watch", "--watch-interval", "250ms", "--sql-file", "q.sql"},
+			{"sqly", "--serve", ":8080", "--allow-writes", "log.csv"},
+			{"sqly", "--union", "events", "--source-file-column", "logs/events-*.csv"},
+			{"sqly", "--partitioned", "--source-file-column", "sales"},
+			{"sqly", "--output-partition-by", "year, region", "-o", "out", "--sql", "SELECT 1"},
 		}
 		for _, args := range ok {
 			if _, err := NewArg(args); err != nil {
@@ -650,6 +780,326 @@ func TestNewArgDependentFlagValidation(t *testing.T) {
 	})
 }
 
+func TestNewArg_OutputPartitionBy(t *testing.T) {
+	t.Parallel()
+
+	arg, err := NewArg([]string{"sqly", "--output-partition-by", "year, region", "-o", "out", "--sql", "SELECT 1"})
+	if err != nil {
+		t.Fatalf("NewArg: %v", err)
+	}
+	if got := strings.Join(arg.Output.PartitionBy, "/"); got != "year/region" {
+		t.Errorf("PartitionBy = %q, want year/region", got)
+	}
+
+	for _, spec := range []string{"year,,region", "year,YEAR"} {
+		if _, err := NewArg([]string{"sqly", "--output-partition-by", spec, "-o", "out", "--sql", "SELECT 1"}); err == nil {
+			t.Errorf("NewArg accepted --output-partition-by %q, want a refusal", spec)
+		}
+	}
+}
+
+func TestNewArg_SQLiteTables(t *testing.T) {
+	t.Parallel()
+
+	arg, err := NewArg([]string{"sqly", "--sqlite-tables", "users, orders", "app.db"})
+	if err != nil {
+		t.Fatalf("NewArg: %v", err)
+	}
+	if got := strings.Join(arg.SQLiteTables, ","); got != "users,orders" {
+		t.Errorf("SQLiteTables = %q, want users,orders", got)
+	}
+
+	for _, spec := range []string{"users,", "users,USERS"} {
+		if _, err := NewArg([]string{"sqly", "--sqlite-tables", spec, "app.db"}); err == nil {
+			t.Errorf("NewArg accepted --sqlite-tables %q, want a refusal", spec)
+		}
+	}
+}
+
+func TestNewArg_CSVDialect(t *testing.T) {
+	t.Parallel()
+
+	arg, err := NewArg([]string{"sqly", "--delimiter", ";", "--quote", "'", "--comment-prefix", "#", "--skip-lines", "2", "--no-header", "data.csv"})
+	if err != nil {
+		t.Fatalf("NewArg: %v", err)
+	}
+	want := model.CSVDialect{Delimiter: ';', Quote: '\'', CommentPrefix: "#", SkipLines: 2, NoHeader: true}
+	if arg.CSVDialect != want {
+		t.Errorf("CSVDialect = %+v, want %+v", arg.CSVDialect, want)
+	}
+
+	for _, args := range [][]string{
+		{"--delimiter", ""},
+		{"--delimiter", ";;"},
+		{"--quote", ""},
+		{"--comment-prefix", ""},
+		{"--skip-lines", "-1"},
+		{"--delimiter", "|", "--quote", "|"},
+	} {
+		if _, err := NewArg(append(append([]string{"sqly"}, args...), "data.csv")); err == nil {
+			t.Errorf("NewArg accepted %v, want a refusal", args)
+		}
+	}
+}
+
+// TestNewArg_CSVOutput checks the output layout flags are read, and that one
+// is refused when --output names a format it cannot apply to.
+func TestNewArg_CSVOutput(t *testing.T) {
+	t.Parallel()
+
+	arg, err := NewArg([]string{"sqly", "--csv-delimiter", "|", "--csv-quote-all", "--crlf", "--bom", "--output", "out.csv", "data.csv"})
+	if err != nil {
+		t.Fatalf("NewArg: %v", err)
+	}
+	want := model.CSVOutput{Delimiter: '|', QuoteAll: true, CRLF: true, BOM: true}
+	if arg.Output.CSV != want {
+		t.Errorf("Output.CSV = %+v, want %+v", arg.Output.CSV, want)
+	}
+	arg, err = NewArg([]string{"sqly", "--csv-delimiter", ",", "data.csv"})
+	if err != nil || !arg.Output.CSV.IsZero() {
+		t.Errorf("--csv-delimiter , = %+v, %v; want the default layout", arg.Output.CSV, err)
+	}
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--csv-delimiter", `"`}, want: "invalid --csv-delimiter"},
+		{args: []string{"--crlf", "--output", "out.json"}, want: "--crlf applies to csv and tsv output"},
+		{args: []string{"--csv-quote-all", "--output", "out.tsv"}, want: "--csv-quote-all applies to csv output"},
+		{args: []string{"--bom", "--output-format", "csv", "--sql", "SELECT 1"}, want: "--bom lays out the files sqly writes, and this query prints to the screen"},
+	} {
+		_, err := NewArg(append(append([]string{"sqly"}, tt.args...), "data.csv"))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("NewArg(%v) error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+	if _, err := NewArg([]string{"sqly", "--crlf", "--bom", "--output", "out.tsv", "data.csv"}); err != nil {
+		t.Errorf("NewArg(--crlf --bom --output out.tsv) error = %v, want it accepted", err)
+	}
+	if _, err := NewArg([]string{"sqly", "--crlf", "--sql", "SELECT 1", "--output", "out.csv", "data.csv"}); err != nil {
+		t.Errorf("NewArg(--crlf --sql --output out.csv) error = %v, want it accepted", err)
+	}
+}
+
+func TestNewArg_ImportCleaning(t *testing.T) {
+	t.Parallel()
+
+	arg, err := NewArg([]string{"sqly", "--null-values", "NA,-", "--trim", "--normalize", "NFC", "--null-string", "NULL", "data.csv"})
+	if err != nil {
+		t.Fatalf("NewArg: %v", err)
+	}
+	want := model.ImportCleaning{Trim: true, NullValues: []string{"NA", "-", ""}}
+	if diff := cmp.Diff(arg.Cleaning, want); diff != "" {
+		t.Errorf("Cleaning mismatch (-got +want):\n%s", diff)
+	}
+	if arg.Normalize != model.UnicodeNormalizationNFC || arg.Output.NullString != "NULL" {
+		t.Errorf("Normalize = %q, NullString = %q; want nfc and NULL", arg.Normalize, arg.Output.NullString)
+	}
+
+	// An explicit empty list reads the empty fields as NULL; no flag reads none.
+	arg, err = NewArg([]string{"sqly", "--null-values", "", "data.csv"})
+	if err != nil || !arg.Cleaning.MapsNulls() {
+		t.Errorf("--null-values '' = %+v, %v; want empty fields read as NULL", arg.Cleaning, err)
+	}
+	arg, err = NewArg([]string{"sqly", "--date-columns", "ordered=%m/%d/%Y,orders.sent=%a, %d %b %Y", "data.csv"})
+	if err != nil || arg.Cleaning.Dates.String() != "ordered=%m/%d/%Y,orders.sent=%a, %d %b %Y" {
+		t.Errorf("--date-columns = %+v, %v; want both columns", arg.Cleaning.Dates, err)
+	}
+	arg, err = NewArg([]string{"sqly", "--decimal-columns", "amount,orders.fee", "data.csv"})
+	if err != nil || arg.Cleaning.Decimals.String() != "amount,orders.fee" {
+		t.Errorf("--decimal-columns = %+v, %v; want both columns", arg.Cleaning.Decimals, err)
+	}
+	arg, err = NewArg([]string{"sqly", "data.csv"})
+	if err != nil || !arg.Cleaning.IsZero() || arg.Normalize != model.UnicodeNormalizationNone {
+		t.Errorf("no flags = %+v, %q, %v; want no cleaning", arg.Cleaning, arg.Normalize, err)
+	}
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--normalize", "nfx"}, want: "invalid --normalize"},
+		{args: []string{"--normalize", ""}, want: "invalid --normalize"},
+		{args: []string{"--null-string", "a\tb"}, want: "--null-string cannot hold a tab"},
+		{args: []string{"--date-columns", ""}, want: "--date-columns requires a non-empty column"},
+		{args: []string{"--date-columns", "ordered=%H:%M"}, want: "--date-columns: invalid date column"},
+		{args: []string{"--decimal-columns", ""}, want: "--decimal-columns requires a non-empty column"},
+		{args: []string{"--decimal-columns", "amount,.fee"}, want: "--decimal-columns: invalid decimal column"},
+	} {
+		_, err := NewArg(append(append([]string{"sqly"}, tt.args...), "data.csv"))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("NewArg(%v) error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+// TestNewArg_Mask checks --mask is parsed into the output configuration, and
+// refused where it would hide nothing.
+func TestNewArg_Mask(t *testing.T) {
+	t.Parallel()
+
+	arg, err := NewArg([]string{"sqly", "--mask", "email=hash,phone=redact", "--sql", "SELECT 1", "--output", "out.csv", "data.csv"})
+	if err != nil {
+		t.Fatalf("NewArg: %v", err)
+	}
+	if got := arg.Output.Mask.String(); got != "email=hash,phone=redact:4" {
+		t.Errorf("Mask = %q, want email=hash,phone=redact:4", got)
+	}
+	if arg, err := NewArg([]string{"sqly", "--mask", "email=hash", "data.csv"}); err != nil || len(arg.Output.Mask) != 1 {
+		t.Errorf("interactive --mask = %v, want it kept for .dump and .save", err)
+	}
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--mask", ""}, want: "--mask requires a non-empty column"},
+		{args: []string{"--mask", "email=scramble"}, want: `--mask: invalid mask "email=scramble"`},
+		{args: []string{"--mask", "email=hash", "--sql", "SELECT 1"}, want: "this query prints to the screen"},
+		{args: []string{"--mask", "region=hash", "--sql", "SELECT 1", "--output", "out", "--output-partition-by", "region"}, want: "column region is an --output-partition-by column"},
+	} {
+		_, err := NewArg(append(append([]string{"sqly"}, tt.args...), "data.csv"))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("NewArg(%v) error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+// TestNewArg_ServeAddress checks --serve is given a HOST:PORT it can listen on.
+// A bare port is the likeliest slip, and net.Listen would reject it only after
+// every input had been imported.
+func TestNewArg_ServeAddress(t *testing.T) {
+	t.Parallel()
+
+	for _, addr := range []string{"8080", "localhost", "http://127.0.0.1:8080"} {
+		if _, err := NewArg([]string{"sqly", "--serve", addr, "log.csv"}); err == nil || !strings.Contains(err.Error(), "want HOST:PORT") {
+			t.Errorf("NewArg(--serve %q) error = %v, want it to ask for HOST:PORT", addr, err)
+		}
+	}
+	arg, err := NewArg([]string{"sqly", "--serve", "127.0.0.1:8080", "log.csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arg.ServeAddr != "127.0.0.1:8080" || arg.AllowWrites {
+		t.Errorf("ServeAddr = %q, AllowWrites = %v, want 127.0.0.1:8080 and read-only", arg.ServeAddr, arg.AllowWrites)
+	}
+}
+
+// TestNewArg_XMLRecord checks --xml-record is refused at the command line when
+// it is not a path from the root, rather than after the inputs have been read.
+func TestNewArg_XMLRecord(t *testing.T) {
+	t.Parallel()
+
+	for _, path := range []string{"item", "feed/item", "/feed/item[1]"} {
+		if _, err := NewArg([]string{"sqly", "--xml-record", path, "feed.xml"}); err == nil || !strings.Contains(err.Error(), "invalid --xml-record") {
+			t.Errorf("NewArg(--xml-record %q) error = %v, want it refused", path, err)
+		}
+	}
+	arg, err := NewArg([]string{"sqly", "--xml-record", "/feed/item", "feed.xml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arg.XMLRecord != "/feed/item" {
+		t.Errorf("XMLRecord = %q, want /feed/item", arg.XMLRecord)
+	}
+}
+
+// TestNewArg_ColumnType checks --column-type is parsed into declarations and a
+// malformed one is refused before any input is read.
+func TestNewArg_ColumnType(t *testing.T) {
+	t.Parallel()
+
+	arg, err := NewArg([]string{"sqly", "--column-type", "users.zip=text, orders.amount=REAL", "users.csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := model.ColumnTypes{
+		{Table: "users", Column: "zip", Type: model.ColumnTypeText},
+		{Table: "orders", Column: "amount", Type: model.ColumnTypeReal},
+	}
+	if !slices.Equal(arg.ColumnTypes, want) {
+		t.Errorf("ColumnTypes = %v, want %v", arg.ColumnTypes, want)
+	}
+	for _, spec := range []string{"zip=TEXT", "users.zip", "users.zip=DATE", "users.zip=TEXT,USERS.ZIP=REAL"} {
+		if _, err := NewArg([]string{"sqly", "--column-type", spec, "users.csv"}); err == nil || !strings.Contains(err.Error(), "--column-type") {
+			t.Errorf("NewArg(--column-type %q) error = %v, want it refused", spec, err)
+		}
+	}
+}
+
+func TestNewArg_TableKeys(t *testing.T) {
+	t.Parallel()
+
+	arg, err := NewArg([]string{"sqly", "--primary-key", "users(id),orders(user_id,day)", "--index", "orders(user_id)", "users.csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := arg.PrimaryKeys.String(); got != "users(id),orders(user_id,day)" {
+		t.Errorf("PrimaryKeys = %s", got)
+	}
+	if got := arg.Indexes.String(); got != "orders(user_id)" {
+		t.Errorf("Indexes = %s", got)
+	}
+	for _, args := range [][]string{
+		{"sqly", "--primary-key", "users", "users.csv"},
+		{"sqly", "--primary-key", "users(id),USERS(name)", "users.csv"},
+		{"sqly", "--index", "users(id),users(id)", "users.csv"},
+	} {
+		if _, err := NewArg(args); err == nil || !strings.Contains(err.Error(), args[1]) {
+			t.Errorf("NewArg(%v) error = %v, want it refused naming the flag", args, err)
+		}
+	}
+}
+
+func TestNewArg_InspectFormat(t *testing.T) {
+	t.Parallel()
+
+	arg, err := NewArg([]string{"sqly", "--inspect", "users.csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arg.InspectFormat != InspectFormatJSON {
+		t.Errorf("InspectFormat = %q, want the json default", arg.InspectFormat)
+	}
+	arg, err = NewArg([]string{"sqly", "--inspect", "--format", " Schema ", "users.csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arg.InspectFormat != InspectFormatSchema {
+		t.Errorf("InspectFormat = %q, want schema", arg.InspectFormat)
+	}
+	if _, err := NewArg([]string{"sqly", "--inspect", "--format", "yaml", "users.csv"}); err == nil || !strings.Contains(err.Error(), "--format") {
+		t.Errorf("NewArg(--format yaml) error = %v, want it refused", err)
+	}
+}
+
+func TestNewArg_OutputDialect(t *testing.T) {
+	t.Parallel()
+
+	arg, err := NewArg([]string{"sqly", "--output-format", "sql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arg.Output.Dialect != model.SQLDialectSQLite {
+		t.Errorf("Output.Dialect = %q, want the sqlite default", arg.Output.Dialect)
+	}
+	arg, err = NewArg([]string{"sqly", "--output-format", "sql", "--output-dialect", " PostgreSQL "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arg.Output.Dialect != model.SQLDialectPostgreSQL {
+		t.Errorf("Output.Dialect = %q, want postgresql", arg.Output.Dialect)
+	}
+	// GoogleSQL is a dialect a query can be read in, not one a script is
+	// written for.
+	for _, name := range []string{"googlesql", "oracle", ""} {
+		if _, err := NewArg([]string{"sqly", "--output-dialect", name}); err == nil || !strings.Contains(err.Error(), "invalid sql output dialect") {
+			t.Errorf("NewArg(--output-dialect %q) error = %v, want it refused", name, err)
+		}
+	}
+}
+
 // TestNewArg_NormalizesStdinFormat pins the fix for a value that passed
 // validation and then failed anyway. --stdin-format is validated trimmed and
 // lowercased, so " CSV " is accepted; storing the raw string meant the staging
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	// The zone database is compiled in, so convert_tz knows Asia/Tokyo on a
+	// machine with no zoneinfo of its own, such as Windows or a slim container.
+	_ "time/tzdata"
+
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// The date functions a session's SQL can call, for the dates a CSV file writes
+// in a shape SQLite's own date functions do not read: parse_date turns them
+// into ISO 8601, format_date writes ISO 8601 back out in any shape, and
+// convert_tz moves a time from one zone to another. The layouts are strftime's,
+// read by model.DateLayout, the same as --date-columns reads.
+//
+// date_trunc(unit, ts) is filesql's, and already the function it would be here.
+// parse_date and format_date are filesql's names too, in GoogleSQL's argument
+// order, layout first, and at the same argument count; there is no second
+// registration to be had under either spelling. So these are registered as
+// sqly_parse_date and sqly_format_date, and the interactor sends the calls a
+// statement makes to them (see interactor/date_functions.go). They take their
+// arguments in either order, telling the layout by its %, so a query written
+// for GoogleSQL reads the same.
+
+// dateFunctions are the functions registered by registerDateFunctions.
+var dateFunctions = []scalarFunction{
+	{name: model.ParseDateFunction, nArg: -1, fn: parseDate},
+	{name: model.FormatDateFunction, nArg: -1, fn: formatDate},
+	{name: "convert_tz", nArg: -1, fn: convertTZ},
+}
+
+// registerDateFunctions registers the date functions with the driver. Like
+// dialect.RegisterFunctions, it has to run before the first connection is
+// opened.
+func registerDateFunctions() error {
+	return registerScalarFunctions(dateFunctions)
+}
+
+// parseDate implements parse_date(text, layout): text read with layout and
+// written as ISO 8601 — the date alone, or the date and time when the layout
+// has a time of day. A value the layout does not read is NULL, as SQLite's
+// date() is for a value it cannot read, so one bad row does not fail a query
+// over thousands; a layout that is itself wrong fails it.
+func parseDate(args []driver.Value) (driver.Value, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("parse_date takes 2 arguments, got %d", len(args))
+	}
+	value, layout, ok, err := dateLayoutArgs("parse_date", args)
+	if !ok || err != nil {
+		return nil, err
+	}
+	if !layout.ReadsDate() {
+		return nil, fmt.Errorf("parse_date: date layout '%s' has no year; a date needs %%Y or %%y", layout)
+	}
+	t, err := layout.Parse(value)
+	if err != nil {
+		return nil, nil //nolint:nilerr // a value the layout does not read is NULL
+	}
+	return layout.ISO(t), nil
+}
+
+// formatDate implements format_date(ts, layout): ts, an ISO 8601 date or date
+// and time, written with layout. A ts that is not ISO 8601 is NULL.
+func formatDate(args []driver.Value) (driver.Value, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("format_date takes 2 arguments, got %d", len(args))
+	}
+	value, layout, ok, err := dateLayoutArgs("format_date", args)
+	if !ok || err != nil {
+		return nil, err
+	}
+	t, _, err := model.ParseISODateTime(value)
+	if err != nil {
+		return nil, nil //nolint:nilerr // a value that is not a date is NULL
+	}
+	return layout.Format(t), nil
+}
+
+// dateLayoutArgs reads the value and the layout of a parse_date or format_date
+// call. The layout is the argument with a % in it, the second when both or
+// neither have one. ok is false when either is NULL.
+func dateLayoutArgs(function string, args []driver.Value) (value string, layout model.DateLayout, ok bool, err error) {
+	texts, ok := textArgs(args)
+	if !ok {
+		return "", model.DateLayout{}, false, nil
+	}
+	value, layoutText := texts[0], texts[1]
+	if !strings.Contains(layoutText, "%") && strings.Contains(value, "%") {
+		value, layoutText = layoutText, value
+	}
+	layout, err = model.ParseDateLayout(layoutText)
+	if err != nil {
+		return "", model.DateLayout{}, false, fmt.Errorf("%s: %w", function, err)
+	}
+	return value, layout, true, nil
+}
+
+// convertTZ implements convert_tz(ts, from, to), MySQL's: ts read as a wall
+// clock time in zone from and written as the wall clock time in zone to, such
+// as convert_tz('2024-03-14 09:00', 'UTC', 'Asia/Tokyo') = 2024-03-14 18:00:00.
+// A ts with an offset of its own is read at that offset. A ts that is not ISO
+// 8601 is NULL; a zone sqly does not know fails the statement.
+func convertTZ(args []driver.Value) (driver.Value, error) {
+	if len(args) != 3 {
+		return nil, fmt.Errorf("convert_tz takes 3 arguments, got %d", len(args))
+	}
+	texts, ok := textArgs(args)
+	if !ok {
+		return nil, nil
+	}
+	from, err := loadZone(texts[1])
+	if err != nil {
+		return nil, err
+	}
+	to, err := loadZone(texts[2])
+	if err != nil {
+		return nil, err
+	}
+	t, zoned, err := model.ParseISODateTime(texts[0])
+	if err != nil {
+		return nil, nil //nolint:nilerr // a value that is not a date is NULL
+	}
+	if !zoned {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), from)
+	}
+	return model.FormatISODateTime(t.In(to), false), nil
+}
+
+// zones caches the zones convert_tz has loaded: loading one reads and parses
+// its zoneinfo, and a call names the same zones on every row.
+var zones sync.Map
+
+// loadZone returns the zone name names: an IANA name such as Europe/Berlin,
+// UTC, or an offset such as +09:00.
+func loadZone(name string) (*time.Location, error) {
+	if zone, ok := zones.Load(name); ok {
+		return zone.(*time.Location), nil //nolint:forcetypeassert // only *time.Location is stored
+	}
+	zone, err := parseZone(name)
+	if err != nil {
+		return nil, err
+	}
+	zones.Store(name, zone)
+	return zone, nil
+}
+
+// parseZone is loadZone without the cache.
+func parseZone(name string) (*time.Location, error) {
+	trimmed := strings.TrimSpace(name)
+	if trimmed == "" {
+		return nil, fmt.Errorf("convert_tz: empty time zone; name one such as UTC, Asia/Tokyo, or +09:00")
+	}
+	if trimmed[0] == '+' || trimmed[0] == '-' {
+		if zone, err := model.ParseUTCOffset(trimmed); err == nil {
+			return zone, nil
+		}
+		return nil, fmt.Errorf("convert_tz: invalid UTC offset '%s'; write it as +09:00 or -0500", name)
+	}
+	// Local would make the result depend on the machine the query ran on.
+	if strings.EqualFold(trimmed, "local") {
+		return nil, fmt.Errorf("convert_tz: unknown time zone '%s'; name the zone, such as Europe/Berlin", name)
+	}
+	zone, err := time.LoadLocation(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("convert_tz: unknown time zone '%s'; name one such as UTC, Asia/Tokyo, or +09:00", name)
+	}
+	return zone, nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestDateFunctions(t *testing.T) {
+	db := openFunctionDB(t)
+
+	tests := []struct {
+		query string
+		want  sql.NullString
+	}{
+		{"SELECT sqly_parse_date('03/14/2024', '%m/%d/%Y')", sql.NullString{String: "2024-03-14", Valid: true}},
+		{"SELECT sqly_parse_date('%d.%m.%Y %H:%M', '14.03.2024 10:22')", sql.NullString{String: "2024-03-14 10:22:00", Valid: true}},
+		{"SELECT sqly_parse_date(20240314, '%Y%m%d')", sql.NullString{String: "2024-03-14", Valid: true}},
+		{"SELECT sqly_parse_date('02/30/2024', '%m/%d/%Y')", sql.NullString{}},
+		{"SELECT sqly_parse_date(NULL, '%m/%d/%Y')", sql.NullString{}},
+		{"SELECT sqly_format_date('2024-03-14 10:22:00', '%d %b %Y, %I:%M %p')", sql.NullString{String: "14 Mar 2024, 10:22 AM", Valid: true}},
+		{"SELECT sqly_format_date('%Y/%m', '2024-03-14')", sql.NullString{String: "2024/03", Valid: true}},
+		{"SELECT sqly_format_date('14.03.2024', '%Y')", sql.NullString{}},
+		{"SELECT convert_tz('2024-03-14 09:00:00', 'UTC', 'Asia/Tokyo')", sql.NullString{String: "2024-03-14 18:00:00", Valid: true}},
+		{"SELECT convert_tz('2024-07-01 12:00', 'Europe/Berlin', 'America/New_York')", sql.NullString{String: "2024-07-01 06:00:00", Valid: true}},
+		{"SELECT convert_tz('2024-03-14', '+09:00', 'UTC')", sql.NullString{String: "2024-03-13 15:00:00", Valid: true}},
+		{"SELECT convert_tz('2024-03-14T09:00:00Z', 'Asia/Tokyo', 'UTC')", sql.NullString{String: "2024-03-14 09:00:00", Valid: true}},
+		{"SELECT convert_tz('soon', 'UTC', 'UTC')", sql.NullString{}},
+		{"SELECT date_trunc('month', '2024-03-14 10:22:00')", sql.NullString{String: "2024-03-01 00:00:00", Valid: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got sql.NullString
+			if err := db.QueryRowContext(context.Background(), tt.query).Scan(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateFunctions_Errors(t *testing.T) {
+	db := openFunctionDB(t)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT sqly_parse_date('2024', '%Y-%q')", "parse_date: invalid date layout \"%Y-%q\": unknown directive %q"},
+		{"SELECT sqly_parse_date('10:22', '%H:%M')", "parse_date: date layout '%H:%M' has no year"},
+		{"SELECT sqly_format_date('2024-03-14')", "format_date takes 2 arguments, got 1"},
+		{"SELECT convert_tz('2024-03-14', 'UTC', 'Mars/Olympus')", "convert_tz: unknown time zone 'Mars/Olympus'"},
+		{"SELECT convert_tz('2024-03-14', '+25:00', 'UTC')", "convert_tz: invalid UTC offset '+25:00'"},
+		{"SELECT convert_tz('2024-03-14', 'UTC', 'Local')", "convert_tz: unknown time zone 'Local'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got sql.NullString
+			err := db.QueryRowContext(context.Background(), tt.query).Scan(&got)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -30,6 +30,32 @@ func NewInMemDB() (MemoryDB, func(), error) {
 	return MemoryDB(db), func() { _ = db.Close() }, nil // #nosec G104
 }
 
+// NewFileDB opens the SQLite database file at path as the session database,
+// creating it when it does not exist. The return function is the function to
+// close the DB.
+//
+// The pool is pinned to one connection for the same reason NewInMemDB pins it:
+// filesql loads into the connection the repository queries, and a second
+// connection would add a writer the two of them do not know about.
+//
+// sql.Open does not touch the file, so the schema is read once here. A path
+// that is not a SQLite database, or a directory that does not exist, then fails
+// as "cannot open the session database" when sqly starts, rather than as a
+// baffling error from whichever import or query happened to reach it first.
+func NewFileDB(path string) (MemoryDB, func(), error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open the session database %s: %w", path, err)
+	}
+	db.SetMaxOpenConns(1)
+	var n int
+	if err := db.QueryRow("SELECT count(*) FROM sqlite_master").Scan(&n); err != nil {
+		_ = db.Close() // #nosec G104
+		return nil, nil, fmt.Errorf("cannot open the session database %s: %w", path, err)
+	}
+	return MemoryDB(db), func() { _ = db.Close() }, nil // #nosec G104
+}
+
 // sqlite3RegisterOnce is package-level rather than function-local so repeated
 // InitSQLite3 calls register the sqlite3 driver exactly once; database/sql
 // panics with "Register called twice" otherwise.
@@ -42,14 +68,24 @@ var sqlite3RegisterOnce sync.Once
 // runs before any database connection is opened, which is required because
 // modernc exposes registered functions only to connections opened afterward.
 // The helper functions are available under every dialect, including the default
-// SQLite one.
+// SQLite one, and so are sqly's own regular expression functions (see
+// regexp.go), statistical aggregates (see aggregate.go), date functions (see
+// datetime.go), hashing and redaction functions (see anonymize.go), and exact
+// decimal functions (see decimal.go), registered at the same point for the
+// same reason.
 func InitSQLite3() {
 	sqlite3RegisterOnce.Do(func() {
 		// A registration failure would be a programming error in the dialect
 		// package (an invalid function definition); its own tests cover that, so
 		// a failure here only leaves the helpers unavailable, which surfaces as a
-		// clear "no such function" error at query time.
+		// clear "no such function" error at query time. The same holds for the
+		// regular expression functions and the statistical aggregates.
 		_ = dialect.RegisterFunctions()
+		_ = registerRegexpFunctions()
+		_ = registerStatisticalAggregates()
+		_ = registerDateFunctions()
+		_ = registerAnonymizeFunctions()
+		_ = registerDecimalFunctions()
 		sql.Register("sqlite3", sqliteDriver{Driver: moderncSQLiteDriver()})
 	})
 }
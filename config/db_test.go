@@ -3,6 +3,9 @@ package config
 import (
 	"context"
 	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	_ "modernc.org/sqlite"
@@ -64,3 +67,52 @@ func TestSQLite3DriverEnablesForeignKeys(t *testing.T) {
 		t.Errorf("foreign_keys = %d, want 1", foreignKeys)
 	}
 }
+
+// TestNewFileDB checks what --db promises: a table written in one session is
+// there when the same file is opened again.
+func TestNewFileDB(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "session.db")
+
+	db, cleanup, err := NewFileDB(path)
+	if err != nil {
+		t.Fatalf("NewFileDB failed: %v", err)
+	}
+	if _, err := (*sql.DB)(db).ExecContext(context.Background(), "CREATE TABLE kept (id INTEGER)"); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	cleanup()
+
+	db, cleanup, err = NewFileDB(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer cleanup()
+	var n int
+	if err := (*sql.DB)(db).QueryRowContext(context.Background(),
+		"SELECT count(*) FROM sqlite_master WHERE name = 'kept'").Scan(&n); err != nil {
+		t.Fatalf("query reopened database: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("the table created before the reopen is missing")
+	}
+}
+
+// TestNewFileDB_RejectsAFileThatIsNotADatabase checks the failure arrives when
+// the database is opened, naming it, rather than at the first import.
+func TestNewFileDB_RejectsAFileThatIsNotADatabase(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "notes.db")
+	if err := os.WriteFile(path, []byte("these are not the pages of a SQLite database, only plain text\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, cleanup, err := NewFileDB(path)
+	if err == nil {
+		cleanup()
+		t.Fatal("NewFileDB accepted a text file as a database")
+	}
+	if !strings.Contains(err.Error(), "cannot open the session database "+path) {
+		t.Errorf("error = %q, want it to name the database", err)
+	}
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/nao1215/sqly/domain/model"
+	"modernc.org/sqlite"
+)
+
+// The exact decimal functions: arithmetic on amounts that must come out to the
+// cent. SQLite computes with doubles, so sum(amount) over a million rows of
+// 19.99 is off by a fraction of a cent that a reconciliation then flags, and
+// 0.1 + 0.2 is 0.30000000000000004. These compute with arbitrary-precision
+// integers scaled by a power of ten, the way a DECIMAL column in a database
+// that has one does.
+//
+// Each takes a decimal as text — a DECIMAL column's canonical text, or any
+// decimal literal — or as an integer, and returns its result as canonical
+// text, which is what a DECIMAL column holds. A REAL argument is read as the
+// shortest decimal that prints as it, which is the number it was written as
+// unless the arithmetic that made it drifted already. Text that is not a
+// decimal fails the statement, rather than being skipped as sum skips it: an
+// amount that does not count is the mistake these are there to catch.
+
+// decimalFunctions are the scalar functions registered by
+// registerDecimalFunctions.
+var decimalFunctions = []scalarFunction{
+	{name: "dec_add", nArg: 2, fn: decimalAdd},
+	{name: "dec_mul", nArg: 2, fn: decimalMul},
+	{name: "dec_round", nArg: -1, fn: decimalRound},
+}
+
+// decimalAggregates are the aggregates registered by registerDecimalFunctions.
+var decimalAggregates = []statisticalAggregate{
+	{name: "dec_sum", nArg: 1, start: func() sqlite.AggregateFunction { return &decimalSumAggregate{} }},
+}
+
+// registerDecimalFunctions registers the exact decimal functions with the
+// driver. Like the other functions, they reach only the connections opened
+// afterwards.
+func registerDecimalFunctions() error {
+	if err := registerScalarFunctions(decimalFunctions); err != nil {
+		return err
+	}
+	return registerAggregates(decimalAggregates)
+}
+
+// decimalArg reads arg as a decimal. It reports false for NULL.
+func decimalArg(function string, arg driver.Value) (model.Decimal, bool, error) {
+	var text string
+	switch v := arg.(type) {
+	case nil:
+		return model.Decimal{}, false, nil
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return model.Decimal{}, false, fmt.Errorf("%s: %v is not a decimal number", function, v)
+		}
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return model.Decimal{}, false, fmt.Errorf("%s: %v is not a decimal number", function, v)
+	}
+	d, err := model.ParseDecimal(text)
+	if err != nil {
+		return model.Decimal{}, false, fmt.Errorf("%s: %w", function, err)
+	}
+	return d, true, nil
+}
+
+// decimalPair reads the two arguments of a binary function. It reports false
+// when either is NULL.
+func decimalPair(function string, args []driver.Value) (model.Decimal, model.Decimal, bool, error) {
+	a, ok, err := decimalArg(function, args[0])
+	if err != nil || !ok {
+		return a, a, false, err
+	}
+	b, ok, err := decimalArg(function, args[1])
+	if err != nil || !ok {
+		return a, b, false, err
+	}
+	return a, b, true, nil
+}
+
+// decimalAdd implements dec_add(a, b): a + b, exactly, to the larger of the
+// two scales.
+func decimalAdd(args []driver.Value) (driver.Value, error) {
+	a, b, ok, err := decimalPair("dec_add", args)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return a.Add(b).String(), nil
+}
+
+// decimalMul implements dec_mul(a, b): a × b, exactly, to the sum of the two
+// scales, so 19.99 × 3 is 59.97 and 19.99 × 0.08 is 1.5992. dec_round takes it
+// back to cents.
+func decimalMul(args []driver.Value) (driver.Value, error) {
+	a, b, ok, err := decimalPair("dec_mul", args)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return a.Mul(b).String(), nil
+}
+
+// decimalRound implements dec_round(x[, places]): x rounded half away from zero
+// to places digits after the point, 0 when it is not given, and written with
+// exactly that many, so dec_round('19.9', 2) is 19.90.
+func decimalRound(args []driver.Value) (driver.Value, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, fmt.Errorf("dec_round takes 1 or 2 arguments, got %d", len(args))
+	}
+	x, ok, err := decimalArg("dec_round", args[0])
+	if err != nil || !ok {
+		return nil, err
+	}
+	places := int64(0)
+	if len(args) == 2 {
+		if args[1] == nil {
+			return nil, nil
+		}
+		if places, ok = regexpIntArg(args[1]); !ok || places < 0 || places > math.MaxInt32 {
+			return nil, fmt.Errorf("dec_round: places must be a count of digits, 0 or more, not %v", args[1])
+		}
+	}
+	return x.Round(int(places)).String(), nil
+}
+
+// decimalSumAggregate is dec_sum, the exact sum. Subtracting a row that leaves
+// a window is exact too, so unlike a running sum of doubles it never drifts.
+type decimalSumAggregate struct {
+	sum   model.Decimal
+	count int
+}
+
+// Step adds one row.
+func (a *decimalSumAggregate) Step(_ *sqlite.FunctionContext, args []driver.Value) error {
+	d, ok, err := decimalArg("dec_sum", args[0])
+	if err != nil || !ok {
+		return err
+	}
+	a.sum = a.sum.Add(d)
+	a.count++
+	return nil
+}
+
+// WindowInverse subtracts a row that left the window.
+func (a *decimalSumAggregate) WindowInverse(_ *sqlite.FunctionContext, args []driver.Value) error {
+	d, ok, err := decimalArg("dec_sum", args[0])
+	if err != nil || !ok {
+		return err
+	}
+	a.sum = a.sum.Sub(d)
+	a.count--
+	return nil
+}
+
+// WindowValue returns the sum so far, or NULL when no row has a value, as sum
+// does.
+func (a *decimalSumAggregate) WindowValue(_ *sqlite.FunctionContext) (driver.Value, error) {
+	if a.count == 0 {
+		return nil, nil
+	}
+	return a.sum.String(), nil
+}
+
+// Final has nothing to release.
+func (a *decimalSumAggregate) Final(*sqlite.FunctionContext) {}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestDecimalFunctions(t *testing.T) {
+	db := openFunctionDB(t)
+
+	tests := []struct {
+		query string
+		want  sql.NullString
+	}{
+		{"SELECT dec_add('0.1', '0.2')", sql.NullString{String: "0.3", Valid: true}},
+		{"SELECT dec_add('19.90', 1)", sql.NullString{String: "20.90", Valid: true}},
+		{"SELECT dec_add(0.1, 0.2)", sql.NullString{String: "0.3", Valid: true}},
+		{"SELECT dec_add('12345678901234567.89', '0.01')", sql.NullString{String: "12345678901234567.90", Valid: true}},
+		{"SELECT dec_add(NULL, '1')", sql.NullString{}},
+		{"SELECT dec_mul('19.99', '0.08')", sql.NullString{String: "1.5992", Valid: true}},
+		{"SELECT dec_mul('19.99', 3)", sql.NullString{String: "59.97", Valid: true}},
+		{"SELECT dec_round(dec_mul('19.99', '0.075'), 2)", sql.NullString{String: "1.50", Valid: true}},
+		{"SELECT dec_round('-2.345', 2)", sql.NullString{String: "-2.35", Valid: true}},
+		{"SELECT dec_round('19.9', 2)", sql.NullString{String: "19.90", Valid: true}},
+		{"SELECT dec_round('2.5')", sql.NullString{String: "3", Valid: true}},
+		{"SELECT dec_round('2.5', NULL)", sql.NullString{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got sql.NullString
+			if err := db.QueryRowContext(context.Background(), tt.query).Scan(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalSum(t *testing.T) {
+	db := openFunctionDB(t)
+	ctx := context.Background()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE t (g TEXT, v TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	// sum() of the ten dimes in a is 0.9999999999999999.
+	if _, err := db.ExecContext(ctx, `INSERT INTO t VALUES
+		('a', '0.10'), ('a', '0.10'), ('a', '0.10'), ('a', '0.10'), ('a', '0.10'),
+		('a', '0.10'), ('a', '0.10'), ('a', '0.10'), ('a', '0.10'), ('a', '0.10'),
+		('b', NULL), ('c', '12345678901234567.89'), ('c', '0.11')`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  sql.NullString
+	}{
+		{"SELECT dec_sum(v) FROM t WHERE g = 'a'", sql.NullString{String: "1.00", Valid: true}},
+		{"SELECT dec_sum(v) FROM t WHERE g = 'b'", sql.NullString{}},
+		{"SELECT dec_sum(v) FROM t WHERE g = 'none'", sql.NullString{}},
+		{"SELECT dec_sum(v) FROM t WHERE g = 'c'", sql.NullString{String: "12345678901234568.00", Valid: true}},
+		{"SELECT group_concat(s, ' ') FROM (SELECT dec_sum(v) OVER (ORDER BY rowid ROWS BETWEEN 1 PRECEDING AND CURRENT ROW) AS s FROM t WHERE g = 'c')",
+			sql.NullString{String: "12345678901234567.89 12345678901234568.00", Valid: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got sql.NullString
+			if err := db.QueryRowContext(ctx, tt.query).Scan(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalFunctions_Errors(t *testing.T) {
+	db := openFunctionDB(t)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT dec_add('1,200', '1')", `dec_add: "1,200" is not a decimal number`},
+		{"SELECT dec_mul('N/A', 2)", `dec_mul: "N/A" is not a decimal number`},
+		{"SELECT dec_round('1.5', -1)", "dec_round: places must be a count of digits, 0 or more, not -1"},
+		{"SELECT dec_round('1.5', 1, 2)", "dec_round takes 1 or 2 arguments, got 3"},
+		{"SELECT dec_sum(v) FROM (SELECT '1' AS v UNION ALL SELECT 'NA')", `dec_sum: "NA" is not a decimal number`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got sql.NullString
+			err := db.QueryRowContext(context.Background(), tt.query).Scan(&got)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
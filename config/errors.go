@@ -37,17 +37,30 @@ var ErrEmptyArg = errors.New("argument is empty")
 // table name unqueryable.
 var errInvalidStdinTable = errors.New("--stdin-table must be a valid table name: letters, digits, and underscores only, not starting with a digit")
 
-// errEmptyOutput, errEmptySQLFile, and errEmptyStdinFormat are returned when
+// errEmptyOutput, errEmptySQLFile, errEmptyStdinFormat, and errEmptyDB are returned when
 // their flag is given an explicit empty value. For each flag the
 // empty string is the "flag absent" sentinel, so accepting an explicit "" would
 // silently behave like the flag was never passed instead of surfacing the
 // malformed value.
 var (
-	errEmptyQuery       = errors.New("--sql requires a non-empty SQL statement")
-	errEmptyOutput      = errors.New("--output requires a non-empty destination path")
-	errEmptySQLFile     = errors.New("--sql-file requires a non-empty file path")
-	errEmptyScriptFile  = errors.New("--script-file requires a non-empty file path")
-	errEmptyStdinFormat = errors.New("--stdin-format requires a non-empty format: csv, tsv, ltsv, json, or jsonl")
+	errEmptyQuery             = errors.New("--sql requires a non-empty SQL statement")
+	errEmptyOutput            = errors.New("--output requires a non-empty destination path")
+	errEmptySQLFile           = errors.New("--sql-file requires a non-empty file path")
+	errEmptyScriptFile        = errors.New("--script-file requires a non-empty file path")
+	errEmptyStdinFormat       = errors.New("--stdin-format requires a non-empty format: csv, tsv, ltsv, json, or jsonl")
+	errEmptyDB                = errors.New("--db requires a non-empty database file path")
+	errEmptyServe             = errors.New("--serve requires a non-empty address, such as 127.0.0.1:8080")
+	errEmptyXMLRecord         = errors.New("--xml-record requires a non-empty element path, such as /feed/item")
+	errEmptyColumnType        = errors.New("--column-type requires a non-empty declaration, such as users.zip=TEXT")
+	errEmptyDateColumns       = errors.New("--date-columns requires a non-empty column, such as ordered=%m/%d/%Y")
+	errEmptyDecimalColumns    = errors.New("--decimal-columns requires a non-empty column, such as amount")
+	errEmptyPrimaryKey        = errors.New("--primary-key requires a non-empty key, such as users(id)")
+	errEmptyIndex             = errors.New("--index requires a non-empty index, such as orders(user_id)")
+	errEmptyUnion             = errors.New("--union requires a non-empty table name, such as events")
+	errEmptyOutputPartitionBy = errors.New("--output-partition-by requires at least one column name, such as year,region")
+	errEmptySQLiteTables      = errors.New("--sqlite-tables requires at least one table name, such as users,orders")
+	errEmptyCommentPrefix     = errors.New("--comment-prefix requires the text a comment line starts with, such as #")
+	errEmptyMask              = errors.New("--mask requires a non-empty column, such as email=hash")
 )
 
 // errStdinTableReserved is returned when --stdin-table is a SQLite keyword. Such
@@ -62,6 +75,8 @@ var errStdinTableReserved = errors.New("--stdin-table is a SQLite keyword and is
 var (
 	errStdinTableWithoutFormat     = errors.New("--stdin-table has no effect without --stdin-format FORMAT")
 	errInspectSampleWithoutInspect = errors.New("--inspect-sample has no effect without --inspect")
+	errFormatWithoutInspect        = errors.New("--format chooses what --inspect prints and has no effect without --inspect; to format query results, use --output-format")
+	errInspectSampleWithSchema     = errors.New("--inspect-sample has no effect with --format schema, which holds no rows")
 )
 
 // errNegativeInspectSample is returned when --inspect-sample is given a negative
@@ -70,3 +85,56 @@ var (
 // exits 2 having read no file, instead of exiting 1 after the import had already
 // happened.
 var errNegativeInspectSample = errors.New("--inspect-sample must be 0 or greater")
+
+// errWatchWithoutQuery and errWatchIntervalWithoutWatch are returned when a
+// watch flag is set without what it acts on. --watch re-runs a query when the
+// inputs change, so a run with no query has nothing to re-run; an interactive
+// shell already has .reload for the same job.
+var (
+	errWatchWithoutQuery         = errors.New("--watch re-runs a query, so it needs --sql or --sql-file")
+	errWatchIntervalWithoutWatch = errors.New("--watch-interval has no effect without --watch")
+)
+
+// errNonPositiveWatchInterval is returned when --watch-interval is zero or
+// negative. A zero interval would poll the inputs in a busy loop, and a negative
+// one names no interval at all.
+var errNonPositiveWatchInterval = errors.New("--watch-interval must be greater than zero")
+
+// errUnionWithoutInputs, errUnionWithStdin, and errSourceFileColumnWithoutUnion
+// are returned when --union or --source-file-column is given without what it
+// acts on: a union combines the files named on the command line, and the
+// source column is a column of that union.
+var (
+	errUnionWithoutInputs           = errors.New("--union reads the files it is given into one table, so it needs at least one file or pattern, such as 'logs/events-*.csv'")
+	errUnionWithStdin               = errors.New("--union combines the files named on the command line and cannot include the --stdin-format dataset; import it separately")
+	errSourceFileColumnWithoutUnion = errors.New("--source-file-column has no effect without --union NAME or --partitioned")
+)
+
+// errPartitionedWithoutInputs and errPartitionedWithStdin are returned when
+// --partitioned has no directory to read: the partitions are the directories
+// named on the command line, and stdin is a stream with no directories at all.
+var (
+	errPartitionedWithoutInputs = errors.New("--partitioned reads the directories it is given, so it needs at least one, such as sales")
+	errPartitionedWithStdin     = errors.New("--partitioned reads directories named on the command line and cannot include the --stdin-format dataset; import it separately")
+)
+
+// errOutputPartitionByWithoutOutput is returned when --output-partition-by has
+// no directory to write its tree into. Printed to stdout, a result is one
+// stream, and a stream has no directories to split it into.
+var errOutputPartitionByWithoutOutput = errors.New("--output-partition-by writes a directory of files, so it needs --output DIR")
+
+// errAllowWritesWithoutServe is returned when --allow-writes is set without
+// --serve. Every other way of running sqly already runs whatever statement it is
+// given, so the flag only means something for the server.
+var errAllowWritesWithoutServe = errors.New("--allow-writes has no effect without --serve ADDR")
+
+// errNullStringLayout is returned for a --null-string that holds a tab or a line
+// break. LTSV has no way to write either inside a value, and a marker that
+// starts a new field or record in one output format is no marker in any.
+var errNullStringLayout = errors.New("--null-string cannot hold a tab or a line break; use text such as NULL or \\N")
+
+// errMaskWithoutOutput is returned for a --mask on a run that prints its query
+// to the screen and writes no file. The mask applies to files, so the run would
+// show every value it was asked to hide, and the flag would look as if it had
+// done its job.
+var errMaskWithoutOutput = errors.New("--mask masks the files sqly writes, and this query prints to the screen; add --output FILE")
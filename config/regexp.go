@@ -0,0 +1,315 @@
+package config
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"sync"
+
+	"modernc.org/sqlite"
+)
+
+// The regular expression functions a session's SQL can call, in Go's RE2
+// syntax. Log-derived CSV files are where these earn their keep: a status code
+// buried in a message, a request path to cut apart, a column of free text to
+// test against a shape.
+//
+// Two of the names already belong to the dialect helpers filesql registers:
+// the REGEXP operator, which SQLite turns into a call to regexp(pattern, value),
+// and regexp_replace. Both are RE2 already, so sqly registers neither again.
+// regexp_extract is taken too, but only in GoogleSQL's two-argument form, and
+// the group argument is the point of asking for it here. SQLite tells functions
+// apart by name and argument count, so the three-argument form can sit beside
+// the two-argument one; modernc, though, refuses a second registration of the
+// same name whatever its count. It compares names as Go strings, where SQLite
+// ignores case, so the three-argument form is registered as REGEXP_EXTRACT.
+// That spelling is what lets it in, not what a query has to use.
+
+// scalarFunction is one scalar function sqly registers with the driver.
+type scalarFunction struct {
+	name string
+	// nArg is the argument count, or -1 for a function that checks its own.
+	nArg int32
+	fn   func(args []driver.Value) (driver.Value, error)
+	// volatile marks a function whose result is not fixed by its arguments,
+	// such as one that draws a random number. SQLite may evaluate a
+	// deterministic function once for a whole statement, so such a function
+	// must not be registered as one.
+	volatile bool
+}
+
+// regexpFunctions are the functions registered by registerRegexpFunctions. The
+// ones that take an optional argument check their own count, so a call with the
+// wrong number names the function rather than failing SQLite's lookup.
+var regexpFunctions = []scalarFunction{
+	{name: "regexp_like", nArg: -1, fn: regexpLike},
+	{name: "REGEXP_EXTRACT", nArg: 3, fn: regexpExtract},
+	{name: "regexp_split", nArg: -1, fn: regexpSplit},
+}
+
+// registerRegexpFunctions registers the regular expression functions with the
+// driver. Like dialect.RegisterFunctions, it has to run before the first
+// connection is opened.
+func registerRegexpFunctions() error {
+	return registerScalarFunctions(regexpFunctions)
+}
+
+// registerScalarFunctions registers functions with the driver as scalar
+// functions, deterministic unless they are marked volatile.
+func registerScalarFunctions(functions []scalarFunction) error {
+	for _, f := range functions {
+		impl := func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			return f.fn(args)
+		}
+		register := sqlite.RegisterDeterministicScalarFunction
+		if f.volatile {
+			register = sqlite.RegisterScalarFunction
+		}
+		if err := register(f.name, f.nArg, impl); err != nil {
+			return fmt.Errorf("register %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+// maxCachedRegexps bounds the patterns one statement keeps compiled. A pattern
+// is nearly always a literal, one per call site; the bound is for the statement
+// that builds its pattern from a column, which would otherwise keep one per row.
+const maxCachedRegexps = 256
+
+// statementRegexps holds the patterns compiled by the statement running now.
+// The functions are called once per row, so compiling a literal pattern on each
+// call would compile it once per row too. ResetRegexpCache empties it between
+// statements, so a long session does not keep every pattern it ever ran.
+var statementRegexps = struct {
+	sync.Mutex
+	compiled map[string]*regexp.Regexp
+}{compiled: map[string]*regexp.Regexp{}}
+
+// ResetRegexpCache forgets the patterns compiled so far. The repository calls
+// it before each statement it runs, which makes the cache a per-statement one.
+func ResetRegexpCache() {
+	statementRegexps.Lock()
+	defer statementRegexps.Unlock()
+	clear(statementRegexps.compiled)
+}
+
+// compileRegexp returns pattern compiled, from the cache when this statement
+// compiled it already. An invalid pattern fails the statement with the
+// function's name in the message, since a query may call several.
+func compileRegexp(function, pattern string) (*regexp.Regexp, error) {
+	statementRegexps.Lock()
+	defer statementRegexps.Unlock()
+	if re, ok := statementRegexps.compiled[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		// The syntax error repeats the pattern after its reason; the message
+		// already quotes the pattern, so only the reason is kept.
+		var syntaxErr *syntax.Error
+		if errors.As(err, &syntaxErr) {
+			return nil, fmt.Errorf("%s: invalid regular expression '%s': %s", function, pattern, syntaxErr.Code)
+		}
+		return nil, fmt.Errorf("%s: invalid regular expression '%s': %w", function, pattern, err)
+	}
+	if len(statementRegexps.compiled) >= maxCachedRegexps {
+		clear(statementRegexps.compiled)
+	}
+	statementRegexps.compiled[pattern] = re
+	return re, nil
+}
+
+// regexpLike implements regexp_like(value, pattern[, flags]): 1 when pattern
+// matches anywhere in value, 0 when it does not. The flags are the ones MySQL's
+// and PostgreSQL's regexp_like share: i ignores case, c respects it (the last
+// of the two wins), m lets ^ and $ match at line breaks, and s — or MySQL's n —
+// lets . match a line break.
+func regexpLike(args []driver.Value) (driver.Value, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, fmt.Errorf("regexp_like takes 2 or 3 arguments, got %d", len(args))
+	}
+	texts, ok := textArgs(args)
+	if !ok {
+		return nil, nil
+	}
+	pattern := texts[1]
+	if len(texts) == 3 {
+		prefix, err := regexpFlags(texts[2])
+		if err != nil {
+			return nil, err
+		}
+		pattern = prefix + pattern
+	}
+	re, err := compileRegexp("regexp_like", pattern)
+	if err != nil {
+		return nil, err
+	}
+	if re.MatchString(texts[0]) {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+// regexpFlags turns a regexp_like flags string into the RE2 flag group that
+// goes in front of the pattern.
+func regexpFlags(flags string) (string, error) {
+	var ignoreCase, multiLine, dotNewline bool
+	for _, flag := range flags {
+		switch flag {
+		case 'i':
+			ignoreCase = true
+		case 'c':
+			ignoreCase = false
+		case 'm':
+			multiLine = true
+		case 's', 'n':
+			dotNewline = true
+		default:
+			return "", fmt.Errorf("regexp_like: unknown flag %q in %q: want i, c, m, s or n", flag, flags)
+		}
+	}
+	var group string
+	if ignoreCase {
+		group += "i"
+	}
+	if multiLine {
+		group += "m"
+	}
+	if dotNewline {
+		group += "s"
+	}
+	if group == "" {
+		return "", nil
+	}
+	return "(?" + group + ")", nil
+}
+
+// regexpExtract implements regexp_extract(value, pattern, group): the text
+// group captured in the first match, or NULL when nothing matches or the group
+// took no part in the match. Group 0 is the whole match. The two-argument form
+// is GoogleSQL's helper, which returns the first group when the pattern has one
+// and the whole match otherwise.
+func regexpExtract(args []driver.Value) (driver.Value, error) {
+	texts, ok := textArgs(args[:2])
+	if !ok || args[2] == nil {
+		return nil, nil
+	}
+	re, err := compileRegexp("regexp_extract", texts[1])
+	if err != nil {
+		return nil, err
+	}
+	group, ok := regexpIntArg(args[2])
+	if !ok || group < 0 || group > int64(re.NumSubexp()) {
+		return nil, fmt.Errorf("regexp_extract: group %v does not exist; the pattern '%s' has groups 0 to %d", args[2], texts[1], re.NumSubexp())
+	}
+	match := re.FindStringSubmatchIndex(texts[0])
+	if match == nil || match[2*group] < 0 {
+		return nil, nil
+	}
+	return texts[0][match[2*group]:match[2*group+1]], nil
+}
+
+// regexpSplit implements regexp_split(value, pattern[, n]). With two arguments
+// it returns the pieces of value between the matches of pattern as a JSON
+// array, which json_each turns into rows. With n it returns the nth piece,
+// counted from 1, or from the end when n is negative, and NULL when there is no
+// such piece.
+func regexpSplit(args []driver.Value) (driver.Value, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, fmt.Errorf("regexp_split takes 2 or 3 arguments, got %d", len(args))
+	}
+	texts, ok := textArgs(args[:2])
+	if !ok {
+		return nil, nil
+	}
+	re, err := compileRegexp("regexp_split", texts[1])
+	if err != nil {
+		return nil, err
+	}
+	pieces := re.Split(texts[0], -1)
+	if len(args) == 2 {
+		return jsonStringArray(pieces)
+	}
+	if args[2] == nil {
+		return nil, nil
+	}
+	n, ok := regexpIntArg(args[2])
+	if !ok || n == 0 {
+		return nil, fmt.Errorf("regexp_split: piece %v does not exist; pieces are counted from 1, or from -1 at the end", args[2])
+	}
+	if n < 0 {
+		n += int64(len(pieces)) + 1
+	}
+	if n < 1 || n > int64(len(pieces)) {
+		return nil, nil
+	}
+	return pieces[n-1], nil
+}
+
+// jsonStringArray encodes pieces as a JSON array. HTML escaping is off so a <
+// in a log line reads back as < when the array is printed as it is.
+func jsonStringArray(pieces []string) (driver.Value, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(pieces); err != nil {
+		return nil, err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// textArgs reads args as text, the way SQLite would cast them. It
+// reports false when any of them is NULL, which makes the function's result
+// NULL, as it is for SQLite's own string functions.
+func textArgs(args []driver.Value) ([]string, bool) {
+	texts := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case nil:
+			return nil, false
+		case string:
+			texts[i] = v
+		case []byte:
+			texts[i] = string(v)
+		case int64:
+			texts[i] = strconv.FormatInt(v, 10)
+		case float64:
+			texts[i] = strconv.FormatFloat(v, 'g', -1, 64)
+		case bool:
+			texts[i] = "0"
+			if v {
+				texts[i] = "1"
+			}
+		default:
+			texts[i] = fmt.Sprint(v)
+		}
+	}
+	return texts, true
+}
+
+// regexpIntArg reads a group or piece number: an integer, a float with no
+// fraction, or text that spells one.
+func regexpIntArg(arg driver.Value) (int64, bool) {
+	switch v := arg.(type) {
+	case int64:
+		return v, true
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > math.MaxInt32 {
+			return 0, false
+		}
+		return int64(v), true
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
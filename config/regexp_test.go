@@ -0,0 +1,103 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// openFunctionDB opens a session database the functions sqly registers are
+// available on.
+func openFunctionDB(t *testing.T) *sql.DB {
+	t.Helper()
+	InitSQLite3()
+	db, cleanup, err := NewInMemDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cleanup)
+	return (*sql.DB)(db)
+}
+
+func TestRegexpFunctions(t *testing.T) {
+	db := openFunctionDB(t)
+
+	tests := []struct {
+		query string
+		want  sql.NullString
+	}{
+		{"SELECT 'ERROR 500 at /api' REGEXP '\\d{3}'", sql.NullString{String: "1", Valid: true}},
+		{"SELECT regexp_like('ERROR 500', 'error')", sql.NullString{String: "0", Valid: true}},
+		{"SELECT regexp_like('ERROR 500', 'error', 'i')", sql.NullString{String: "1", Valid: true}},
+		{"SELECT regexp_like('ERROR 500', 'error', 'ic')", sql.NullString{String: "0", Valid: true}},
+		{"SELECT regexp_like('a' || char(10) || 'b', '^b$', 'm')", sql.NullString{String: "1", Valid: true}},
+		{"SELECT regexp_like('a' || char(10) || 'b', 'a.b', 'n')", sql.NullString{String: "1", Valid: true}},
+		{"SELECT regexp_like(NULL, 'a')", sql.NullString{}},
+		{"SELECT regexp_extract('GET /api/users 200', '(\\w+) (\\S+) (\\d+)', 2)", sql.NullString{String: "/api/users", Valid: true}},
+		{"SELECT regexp_extract('GET /api/users 200', '(\\w+) (\\S+) (\\d+)', 0)", sql.NullString{String: "GET /api/users 200", Valid: true}},
+		{"SELECT REGEXP_EXTRACT('GET /api/users 200', '(\\w+) (\\S+)', '1')", sql.NullString{String: "GET", Valid: true}},
+		{"SELECT regexp_extract('id=7', 'id=(\\d+)')", sql.NullString{String: "7", Valid: true}},
+		{"SELECT regexp_extract('no digits', '(\\d+)', 1)", sql.NullString{}},
+		{"SELECT regexp_extract('ab', 'a(x)?b', 1)", sql.NullString{}},
+		{"SELECT regexp_replace('a1b22', '\\d+', '#')", sql.NullString{String: "a#b#", Valid: true}},
+		{"SELECT regexp_split('a, b,c', ',\\s*')", sql.NullString{String: `["a","b","c"]`, Valid: true}},
+		{"SELECT regexp_split('<a> <b>', ' ')", sql.NullString{String: `["<a>","<b>"]`, Valid: true}},
+		{"SELECT regexp_split('a, b,c', ',\\s*', 2)", sql.NullString{String: "b", Valid: true}},
+		{"SELECT regexp_split('a, b,c', ',\\s*', -1)", sql.NullString{String: "c", Valid: true}},
+		{"SELECT regexp_split('a, b,c', ',\\s*', 4)", sql.NullString{}},
+		{"SELECT group_concat(value, '|') FROM json_each(regexp_split('x1y22z', '\\d+'))", sql.NullString{String: "x|y|z", Valid: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got sql.NullString
+			if err := db.QueryRowContext(context.Background(), tt.query).Scan(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegexpFunctions_Errors(t *testing.T) {
+	db := openFunctionDB(t)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT regexp_like('a', '(')", "regexp_like: invalid regular expression '(': missing closing )"},
+		{"SELECT regexp_like('a', 'a', 'x')", `regexp_like: unknown flag 'x' in "x"`},
+		{"SELECT regexp_like('a')", "regexp_like takes 2 or 3 arguments, got 1"},
+		{"SELECT regexp_extract('a', '[', 1)", "regexp_extract: invalid regular expression '[': missing closing ]"},
+		{"SELECT regexp_extract('ab', '(a)', 2)", "regexp_extract: group 2 does not exist; the pattern '(a)' has groups 0 to 1"},
+		{"SELECT regexp_split('a', '*')", "regexp_split: invalid regular expression '*': missing argument to repetition operator"},
+		{"SELECT regexp_split('a', ',', 0)", "regexp_split: piece 0 does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got sql.NullString
+			err := db.QueryRowContext(context.Background(), tt.query).Scan(&got)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResetRegexpCache(t *testing.T) {
+	if _, err := compileRegexp("regexp_like", "a+"); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := compileRegexp("regexp_like", "a+")
+	second, _ := compileRegexp("regexp_like", "a+")
+	if first != second {
+		t.Error("a pattern was compiled twice within one statement")
+	}
+	ResetRegexpCache()
+	if third, _ := compileRegexp("regexp_like", "a+"); third == first {
+		t.Error("a pattern outlived the statement that compiled it")
+	}
+}
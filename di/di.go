@@ -18,6 +18,7 @@ import (
 	"github.com/nao1215/sqly/infrastructure/persistence"
 	"github.com/nao1215/sqly/interactor"
 	"github.com/nao1215/sqly/shell"
+	"github.com/nao1215/sqly/usecase"
 )
 
 // The two constructors that can fail once something is already open are reached
@@ -30,6 +31,7 @@ import (
 // still written out in full.
 var (
 	newInMemDB   = config.NewInMemDB
+	newFileDB    = config.NewFileDB
 	newSqlyShell = shell.NewShell
 )
 
@@ -51,26 +53,18 @@ func NewShell(args []string) (*shell.Shell, func(), error) {
 	}
 	commands := shell.NewCommands()
 
-	// The in-memory database is the session: every imported table lives in it.
-	// It is the first resource with a lifetime, so it is the last to be closed.
-	memoryDB, closeMemoryDB, err := newInMemDB()
+	// The session database holds every imported table: in memory by default,
+	// or the file --db names. It is the first resource with a lifetime, so it is
+	// the last to be closed.
+	openSessionDB := newInMemDB
+	if arg.DBPath != "" {
+		openSessionDB = func() (config.MemoryDB, func(), error) { return newFileDB(arg.DBPath) }
+	}
+	sessionDB, closeSessionDB, err := openSessionDB()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// The repository and the filesql adapter are two views of that same
-	// database, and they have to stay that way. filesql loads files straight
-	// into the connection the repository then queries; hand either of them a
-	// database of its own and an import would land somewhere no query looks.
-	sqlite3Repository := memory.NewSQLite3Repository(memoryDB)
-	fileSQLAdapter := newFileSQLAdapter(memoryDB)
-	sqlite3Interactor := interactor.NewSQLite3Interactor(sqlite3Repository, interactor.NewSQL(), fileSQLAdapter)
-
-	queryUsecase := interactor.NewQueryUsecase(sqlite3Interactor)
-	importUsecase := interactor.NewImportUsecase(sqlite3Interactor)
-	metadataUsecase := interactor.NewMetadataUsecase(sqlite3Interactor)
-	persistenceUsecase := interactor.NewPersistenceUsecase(sqlite3Interactor)
-
 	// History is a file under the user's config directory, separate from the
 	// session: it outlives the tables it was typed against. Nothing is held open
 	// for it — each entry is one appending write — so there is no second resource
@@ -79,22 +73,54 @@ func NewShell(args []string) (*shell.Shell, func(), error) {
 
 	exportUsecase := interactor.NewExportInteractor()
 
-	usecases := shell.NewUsecases(
-		queryUsecase,
-		importUsecase,
-		metadataUsecase,
-		historyUsecase,
-		exportUsecase,
-		persistenceUsecase,
-	)
-
-	sqlyShell, err := newSqlyShell(arg, cfg, commands, usecases)
+	sqlyShell, err := newSqlyShell(arg, cfg, commands, newSessionUsecases(sessionDB, historyUsecase, exportUsecase))
 	if err != nil {
-		closeMemoryDB()
+		closeSessionDB()
 		return nil, nil, err
 	}
 
-	return sqlyShell, closeMemoryDB, nil
+	// .open swaps the session database while the shell runs. The database it
+	// replaces is closed only once the new one has opened, so a path that fails
+	// leaves the session where it was; and the cleanup handed back below closes
+	// whichever database is current when the caller runs it, not the one the
+	// shell started with.
+	sqlyShell.SetSessionOpener(func(path string) (shell.Usecases, error) {
+		db, closeDB, err := newFileDB(path)
+		if err != nil {
+			return shell.Usecases{}, err
+		}
+		closeSessionDB()
+		closeSessionDB = closeDB
+		return newSessionUsecases(db, historyUsecase, exportUsecase), nil
+	})
+
+	return sqlyShell, func() { closeSessionDB() }, nil
+}
+
+// newSessionUsecases builds the usecases that work on one session database.
+// History and export are not tied to a database, so a session reopened by .open
+// keeps the same ones.
+func newSessionUsecases(
+	db config.MemoryDB,
+	historyUsecase usecase.HistoryUsecase,
+	exportUsecase usecase.ExportUsecase,
+) shell.Usecases {
+	// The repository and the filesql adapter are two views of the same
+	// database, and they have to stay that way. filesql loads files straight
+	// into the connection the repository then queries; hand either of them a
+	// database of its own and an import would land somewhere no query looks.
+	sqlite3Repository := memory.NewSQLite3Repository(db)
+	fileSQLAdapter := newFileSQLAdapter(db)
+	sqlite3Interactor := interactor.NewSQLite3Interactor(sqlite3Repository, interactor.NewSQL(), fileSQLAdapter)
+
+	return shell.NewUsecases(
+		interactor.NewQueryUsecase(sqlite3Interactor),
+		interactor.NewImportUsecase(sqlite3Interactor),
+		interactor.NewMetadataUsecase(sqlite3Interactor),
+		historyUsecase,
+		exportUsecase,
+		interactor.NewPersistenceUsecase(sqlite3Interactor),
+	)
 }
 
 // newFileSQLAdapter points filesql at the session database. The conversion is
@@ -3,6 +3,7 @@ package di
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"errors"
 	"os"
 	"path/filepath"
@@ -253,6 +254,78 @@ func TestNewShell_ReleasesTheSessionWhenTheShellFails(t *testing.T) {
 	}
 }
 
+// TestNewShell_DBFlagKeepsTheSessionInTheFile checks --db replaces the in-memory
+// database rather than sitting beside it: the run's table is in the file
+// afterwards, and the file is what the cleanup closes.
+func TestNewShell_DBFlagKeepsTheSessionInTheFile(t *testing.T) {
+	isolate(t)
+	captureStdout(t)
+
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "user.csv")
+	if err := os.WriteFile(csvPath, []byte("id,name\n1,gopher\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dbPath := filepath.Join(dir, "session.db")
+
+	released := recordReleases(t)
+	sqlyShell, cleanup, err := NewShell([]string{"sqly", "--db", dbPath, "--sql", "SELECT name FROM user", csvPath})
+	if err != nil {
+		t.Fatalf("NewShell: %v", err)
+	}
+	if err := sqlyShell.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	cleanup()
+	if got := released.names(); len(got) != 1 || got[0] != "session.db" {
+		t.Fatalf("released %v, want [session.db]; no in-memory database should have been opened", got)
+	}
+
+	db, closeDB, err := config.NewFileDB(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDB()
+	var name string
+	if err := (*sql.DB)(db).QueryRowContext(context.Background(), "SELECT name FROM user").Scan(&name); err != nil {
+		t.Fatalf("the imported table is not in the --db file: %v", err)
+	}
+	if name != "gopher" {
+		t.Errorf("name = %q, want gopher", name)
+	}
+}
+
+// TestNewShell_OpenSwitchesWhatTheCleanupCloses pins the lifetime .open changes.
+// The database it replaces is closed when it switches, and the cleanup closes the
+// one it switched to; a cleanup that still closed the first would leave the
+// second open for the life of the process.
+func TestNewShell_OpenSwitchesWhatTheCleanupCloses(t *testing.T) {
+	isolate(t)
+	captureStdout(t)
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "open.sqly")
+	if err := os.WriteFile(script, []byte(".open "+filepath.Join(dir, "other.db")+"\nSELECT 1;\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	released := recordReleases(t)
+	sqlyShell, cleanup, err := NewShell([]string{"sqly", "--script-file", script})
+	if err != nil {
+		t.Fatalf("NewShell: %v", err)
+	}
+	if err := sqlyShell.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := released.names(); len(got) != 1 || got[0] != "memory" {
+		t.Fatalf("released %v after .open, want [memory]", got)
+	}
+	cleanup()
+	if got := released.names(); len(got) != 2 || got[1] != "other.db" {
+		t.Fatalf("released %v, want [memory other.db]", got)
+	}
+}
+
 // releaseLog records which resources were released and in what order.
 type releaseLog struct {
 	released []string
@@ -273,9 +346,9 @@ func (l *releaseLog) record(name string, release func()) func() {
 func recordReleases(t *testing.T) *releaseLog {
 	t.Helper()
 
-	origInMem, origShell := newInMemDB, newSqlyShell
+	origInMem, origFile, origShell := newInMemDB, newFileDB, newSqlyShell
 	t.Cleanup(func() {
-		newInMemDB, newSqlyShell = origInMem, origShell
+		newInMemDB, newFileDB, newSqlyShell = origInMem, origFile, origShell
 	})
 
 	log := &releaseLog{}
@@ -286,6 +359,13 @@ func recordReleases(t *testing.T) *releaseLog {
 		}
 		return db, log.record("memory", release), nil
 	}
+	newFileDB = func(path string) (config.MemoryDB, func(), error) {
+		db, release, err := origFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return db, log.record(filepath.Base(path), release), nil
+	}
 	return log
 }
 
@@ -30,7 +30,8 @@ var ErrCellShapeMismatch = errors.New("table: row width does not match the heade
 // empty string is therefore a TEXT value distinct from NULL.
 type Cell struct {
 	// value is the driver's scalar for this cell: int64, float64, bool, string,
-	// []byte, time.Time, or nil for SQL NULL.
+	// []byte, time.Time, or nil for SQL NULL. A value of a DECIMAL column is a
+	// Decimal.
 	value any
 }
 
@@ -45,6 +46,27 @@ func NewCell(v any) Cell {
 	return Cell{value: v}
 }
 
+// NewDecimalCell returns a Cell for the driver value v of a DECIMAL column: the
+// Decimal its text spells. A value that is not one — a column updated with text
+// since it was declared — is kept as the driver's value, since a cell cannot
+// say what it does not hold.
+func NewDecimalCell(v any) Cell {
+	var text string
+	switch raw := v.(type) {
+	case string:
+		text = raw
+	case []byte:
+		text = string(raw)
+	default:
+		return NewCell(v)
+	}
+	d, err := ParseDecimal(text)
+	if err != nil {
+		return NewCell(v)
+	}
+	return Cell{value: d}
+}
+
 // IsNull reports whether the cell is SQL NULL.
 func (c Cell) IsNull() bool {
 	return c.value == nil
@@ -79,6 +101,8 @@ func (c Cell) String() string {
 		return strconv.FormatInt(v, 10)
 	case float64:
 		return formatFloat(v, 64)
+	case Decimal:
+		return v.String()
 	case float32:
 		// The value's own width, not float64's: formatting a float32 as 64 bits
 		// prints the error its conversion introduced (1.1 becomes
@@ -0,0 +1,308 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaskMethod is how a masked column's values are written in place of their own.
+type MaskMethod string
+
+const (
+	// MaskHash writes the SHA-256 of a value, in lowercase hex: the same value
+	// always hashes the same, so a masked column still joins and groups, but the
+	// value itself is not written.
+	MaskHash MaskMethod = "hash"
+	// MaskRedact writes a value with every character but the last few replaced
+	// by *, the way a card number or a phone number is shown on a receipt.
+	MaskRedact MaskMethod = "redact"
+)
+
+// DefaultRedactKeep is how many characters a redact mask leaves when the mask
+// does not say: the last four, which is what a receipt shows of a card number.
+const DefaultRedactKeep = 4
+
+// ColumnMask names a column whose values are masked whenever a table is written
+// to a file.
+type ColumnMask struct {
+	// Table is the table the column is in. It is empty for a column masked in
+	// every table and query result that has it.
+	Table string
+	// Column is the column's name, matched without ASCII case the way SQLite
+	// matches column names.
+	Column string
+	// Method is how the values are masked.
+	Method MaskMethod
+	// KeepLast is how many characters a redact mask leaves at the end of each
+	// value. A hash mask does not use it.
+	KeepLast int
+}
+
+// String is the mask as a user writes it: [TABLE.]COLUMN=hash or
+// [TABLE.]COLUMN=redact:N.
+func (m ColumnMask) String() string {
+	name := m.Column
+	if m.Table != "" {
+		name = m.Table + "." + name
+	}
+	if m.Method == MaskRedact {
+		return name + "=" + string(m.Method) + ":" + strconv.Itoa(m.KeepLast)
+	}
+	return name + "=" + string(m.Method)
+}
+
+// Apply returns value masked. An empty value is returned as it is: there is
+// nothing in it to hide, and a hash of it would only be a constant that marks
+// the gaps.
+func (m ColumnMask) Apply(value string) string {
+	if value == "" {
+		return value
+	}
+	if m.Method == MaskRedact {
+		return Redact(value, m.KeepLast)
+	}
+	return SHA256Hex([]byte(value))
+}
+
+// ColumnMasks is the set of columns --mask names.
+type ColumnMasks []ColumnMask
+
+// ParseColumnMasks parses the --mask form: comma-separated [TABLE.]COLUMN=METHOD
+// entries, such as "email=hash,users.phone=redact". A redact may say how many
+// characters it leaves, as redact:2. As in --column-type, the table is
+// everything before the first dot.
+//
+// A mask without a table masks the column in every table and query result that
+// has it, since each is one it could leak from. A mask with one masks that
+// table's column when the table is written, and a query result's column of that
+// name, because a result does not say which table a column came from.
+func ParseColumnMasks(spec string) (ColumnMasks, error) {
+	var masks ColumnMasks
+	for entry := range strings.SplitSeq(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		name, methodText, ok := strings.Cut(entry, "=")
+		table, column, qualified := strings.Cut(strings.TrimSpace(name), ".")
+		if !qualified {
+			table, column = "", strings.TrimSpace(name)
+		}
+		table, column = strings.TrimSpace(table), strings.TrimSpace(column)
+		if !ok || column == "" || (qualified && table == "") {
+			return nil, fmt.Errorf("invalid mask %q: want [TABLE.]COLUMN=METHOD, such as email=hash", entry)
+		}
+		mask, err := parseMaskMethod(column, strings.TrimSpace(methodText))
+		if err != nil {
+			return nil, fmt.Errorf("invalid mask %q: %w", entry, err)
+		}
+		mask.Table = table
+		for _, previous := range masks {
+			if strings.EqualFold(previous.Table, table) && strings.EqualFold(previous.Column, column) {
+				return nil, fmt.Errorf("invalid mask %q: the column is already masked as %s", entry, previous)
+			}
+		}
+		masks = append(masks, mask)
+	}
+	return masks, nil
+}
+
+// parseMaskMethod parses the METHOD half of a mask entry.
+func parseMaskMethod(column, text string) (ColumnMask, error) {
+	name, keepText, hasKeep := strings.Cut(text, ":")
+	switch MaskMethod(strings.ToLower(name)) {
+	case MaskHash:
+		if hasKeep {
+			return ColumnMask{}, fmt.Errorf("hash takes no count; only redact does, as redact:%d", DefaultRedactKeep)
+		}
+		return ColumnMask{Column: column, Method: MaskHash}, nil
+	case MaskRedact:
+		keep := DefaultRedactKeep
+		if hasKeep {
+			n, err := strconv.Atoi(keepText)
+			if err != nil || n < 0 {
+				return ColumnMask{}, fmt.Errorf("redact keeps a count of characters, 0 or more, not %q", keepText)
+			}
+			keep = n
+		}
+		return ColumnMask{Column: column, Method: MaskRedact, KeepLast: keep}, nil
+	default:
+		return ColumnMask{}, fmt.Errorf("unknown mask method %q: want %s or %s", name, MaskHash, MaskRedact)
+	}
+}
+
+// lookup returns the mask for a column, compared without ASCII case. The table
+// a mask names is not looked at: a table is given the masks ForTable or
+// ForResult picked for it.
+func (m ColumnMasks) lookup(column string) (ColumnMask, bool) {
+	for _, mask := range m {
+		if strings.EqualFold(mask.Column, column) {
+			return mask, true
+		}
+	}
+	return ColumnMask{}, false
+}
+
+// Masks reports whether the set masks a column in any table, compared without
+// ASCII case.
+func (m ColumnMasks) Masks(column string) bool {
+	_, ok := m.lookup(column)
+	return ok
+}
+
+// ForTable returns the masks that apply to a table, without their table: those
+// naming the table, compared without ASCII case, and those naming none. Where
+// both name a column, the one naming the table wins, because it was written for
+// that table and the other for every table.
+func (m ColumnMasks) ForTable(table string) ColumnMasks {
+	var masks ColumnMasks
+	for _, mask := range m {
+		if mask.Table != "" && strings.EqualFold(mask.Table, table) {
+			mask.Table = ""
+			masks = append(masks, mask)
+		}
+	}
+	for _, mask := range m {
+		if mask.Table == "" && !masks.Masks(mask.Column) {
+			masks = append(masks, mask)
+		}
+	}
+	return masks
+}
+
+// ForResult returns the masks that apply to a query result, without their
+// table. A result does not say which table a column came from, so every mask
+// applies to it by column, and where two name the same column the first wins.
+func (m ColumnMasks) ForResult() ColumnMasks {
+	var masks ColumnMasks
+	for _, mask := range m {
+		if !masks.Masks(mask.Column) {
+			mask.Table = ""
+			masks = append(masks, mask)
+		}
+	}
+	return masks
+}
+
+// Unmatched returns the masks that name no column of the tables they apply to.
+// A mask that names a table is checked against that table only, and only when
+// it is one of the tables; a mask that names none is unmatched when none of the
+// tables has the column. A mask that matches nothing masks nothing, so the
+// column it was meant to hide, under the name it really has, is written as it
+// is.
+func (m ColumnMasks) Unmatched(tables ...*Table) ColumnMasks {
+	var unmatched ColumnMasks
+	for _, mask := range m {
+		checked, matched := false, false
+		for _, t := range tables {
+			if mask.Table != "" && !strings.EqualFold(mask.Table, t.Name()) {
+				continue
+			}
+			checked = true
+			for _, name := range t.Columns {
+				if strings.EqualFold(mask.Column, name) {
+					matched = true
+					break
+				}
+			}
+			if matched {
+				break
+			}
+		}
+		if checked && !matched {
+			unmatched = append(unmatched, mask)
+		}
+	}
+	return unmatched
+}
+
+// String lists the masks comma-separated, in the form each was written.
+func (m ColumnMasks) String() string {
+	entries := make([]string, len(m))
+	for i, mask := range m {
+		entries[i] = mask.String()
+	}
+	return strings.Join(entries, ",")
+}
+
+// SHA256Hex returns the SHA-256 of data in lowercase hex.
+func SHA256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Redact returns s with every character but the last keepLast replaced by *.
+// Characters are counted as runes, so a name in any script keeps as many of its
+// own letters as it is told to. A keepLast at or beyond the length of s leaves
+// it as it is.
+func Redact(s string, keepLast int) string {
+	n := utf8.RuneCountInString(s)
+	if keepLast >= n {
+		return s
+	}
+	hidden := n - max(keepLast, 0)
+	var b strings.Builder
+	b.Grow(len(s))
+	for i, r := range []rune(s) {
+		if i < hidden {
+			b.WriteByte('*')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// WithMask returns a copy of the table that is masked by m when it is written
+// to a file. The rows are shared, as WithName shares them; the values are not
+// touched until Masked is asked for them.
+func (t *Table) WithMask(m ColumnMasks) *Table {
+	cloned := t.WithName(t.name)
+	cloned.mask = m
+	return cloned
+}
+
+// Masked returns the table with the columns its mask names masked, or the table
+// itself when the mask names none of its columns. NULL stays NULL, so a masked
+// column keeps the gaps it had, and a masked column's declared type becomes
+// TEXT, since a hash of a number is not one.
+func (t *Table) Masked() *Table {
+	masks := make([]*ColumnMask, len(t.header))
+	masking := false
+	for i, name := range t.header {
+		if mask, ok := t.mask.lookup(name); ok {
+			masks[i] = &mask
+			masking = true
+		}
+	}
+	if !masking {
+		return t
+	}
+	masked := t.WithName(t.name)
+	masked.mask = nil
+	for r, record := range masked.records {
+		record = append(make(Record, 0, len(record)), record...)
+		for i, mask := range masks {
+			if mask == nil || i >= len(record) || masked.IsNull(r, i) {
+				continue
+			}
+			record[i] = mask.Apply(record[i])
+			if _, ok := masked.cell(r, i); ok {
+				masked.cells[r*masked.columns+i] = NewCell(record[i])
+			}
+		}
+		masked.records[r] = record
+	}
+	if t.sqlScript != nil {
+		script := *t.sqlScript
+		script.Columns = append([]ColumnDefinition(nil), script.Columns...)
+		for i := range script.Columns {
+			if _, ok := t.mask.lookup(script.Columns[i].Name); ok {
+				script.Columns[i].Type = "TEXT"
+			}
+		}
+		masked.sqlScript = &script
+	}
+	return masked
+}
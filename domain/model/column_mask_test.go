@@ -0,0 +1,133 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseColumnMasks(t *testing.T) {
+	t.Parallel()
+
+	masks, err := ParseColumnMasks("email=hash, phone=redact,card=REDACT:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := masks.String(), "email=hash,phone=redact:4,card=redact:0"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if !masks.Masks("EMAIL") || masks.Masks("name") {
+		t.Errorf("Masks = %v, want email masked without case and name not", masks)
+	}
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"email", "want [TABLE.]COLUMN=METHOD"},
+		{"=hash", "want [TABLE.]COLUMN=METHOD"},
+		{".email=hash", "want [TABLE.]COLUMN=METHOD"},
+		{"email=shuffle", `unknown mask method "shuffle"`},
+		{"email=hash:2", "hash takes no count"},
+		{"phone=redact:-1", "0 or more"},
+		{"email=hash,Email=redact", "already masked as email=hash"},
+		{"users.email=hash,USERS.Email=redact", "already masked as users.email=hash"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseColumnMasks(tt.spec); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseColumnMasks(%q) error = %v, want it to mention %q", tt.spec, err, tt.want)
+		}
+	}
+}
+
+func TestTable_Masked(t *testing.T) {
+	t.Parallel()
+
+	masks, err := ParseColumnMasks("email=hash,phone=redact:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := NewTableFromCells("users", Header{"id", "email", "phone"}, [][]Cell{
+		{NewCell(int64(1)), NewCell("alice@example.com"), NewCell(int64(5550142))},
+		{NewCell(int64(2)), NewCell(nil), NewCell("")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table = table.WithSQLScript(SQLScript{Columns: []ColumnDefinition{{Name: "id", Type: "INTEGER"}, {Name: "email", Type: "TEXT"}, {Name: "phone", Type: "INTEGER"}}})
+
+	masked := table.WithMask(masks).Masked()
+	if got, want := masked.Records()[0], (Record{"1", SHA256Hex([]byte("alice@example.com")), "*****42"}); !equalRecords(got, want) {
+		t.Errorf("row 1 = %v, want %v", got, want)
+	}
+	if !masked.IsNull(1, 1) || masked.Records()[1][2] != "" {
+		t.Errorf("row 2 = %v, want NULL and the empty value kept", masked.Records()[1])
+	}
+	if c, _ := masked.NativeCell(0, 2); c.Value() != "*****42" {
+		t.Errorf("native phone = %#v, want the masked text", c.Value())
+	}
+	if masked.sqlScript.Columns[2].Type != "TEXT" || table.sqlScript.Columns[2].Type != "INTEGER" {
+		t.Errorf("phone type = %s, want TEXT in the masked copy only", masked.sqlScript.Columns[2].Type)
+	}
+	if got := table.Records()[0][1]; got != "alice@example.com" {
+		t.Errorf("original email = %q, want it untouched", got)
+	}
+	if unmasked := table.WithMask(ColumnMasks{{Column: "name", Method: MaskHash}}); unmasked.Masked() != unmasked {
+		t.Error("a mask naming no column of the table copied it")
+	}
+}
+
+func TestColumnMasks_ForTable(t *testing.T) {
+	t.Parallel()
+
+	masks, err := ParseColumnMasks("email=hash,USERS.email=redact:2,orders.card=hash,phone=redact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := masks.ForTable("users").String(), "email=redact:2,phone=redact:4"; got != want {
+		t.Errorf("ForTable(users) = %q, want %q", got, want)
+	}
+	if got, want := masks.ForTable("k").String(), "email=hash,phone=redact:4"; got != want {
+		t.Errorf("ForTable(k) = %q, want %q", got, want)
+	}
+	if got, want := masks.ForResult().String(), "email=hash,card=hash,phone=redact:4"; got != want {
+		t.Errorf("ForResult() = %q, want %q", got, want)
+	}
+}
+
+func TestColumnMasks_Unmatched(t *testing.T) {
+	t.Parallel()
+
+	masks, err := ParseColumnMasks("emial=hash,PHONE=redact,card=hash,users.emial=hash,pii.email=hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := NewTable("users", Header{"id", "email", "phone"}, nil)
+	cards := NewTable("cards", Header{"card"}, nil)
+	if got := masks.Unmatched(users, cards).String(); got != "emial=hash,users.emial=hash" {
+		t.Errorf("Unmatched = %q, want the misspelled masks, and none for a table not written", got)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		keep int
+		want string
+	}{
+		{"555-0142", 4, "****0142"},
+		{"山田太郎", 2, "**太郎"},
+		{"42", 4, "42"},
+		{"secret", 0, "******"},
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.in, tt.keep); got != tt.want {
+			t.Errorf("Redact(%q, %d) = %q, want %q", tt.in, tt.keep, got, tt.want)
+		}
+	}
+}
+
+func equalRecords(a, b Record) bool {
+	return strings.Join(a, "\x00") == strings.Join(b, "\x00")
+}
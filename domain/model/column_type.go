@@ -0,0 +1,193 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Declared column types an import accepts. The first four are SQLite's storage
+// affinities by name, which is every type SQLite can be told to convert to;
+// anything else a CREATE TABLE accepts (VARCHAR(10), DATE) maps onto one of these
+// by name anyway, and a user who writes one expects a check sqly would not do.
+// DATETIME is the exception, because it is the one other type an import infers:
+// a schema --inspect wrote has to be one an import accepts back.
+const (
+	ColumnTypeText     = "TEXT"
+	ColumnTypeInteger  = "INTEGER"
+	ColumnTypeReal     = "REAL"
+	ColumnTypeNumeric  = "NUMERIC"
+	ColumnTypeDatetime = "DATETIME"
+)
+
+// declarableColumnTypes lists the types a column can be declared as, in the
+// order a user is shown them.
+var declarableColumnTypes = []string{ColumnTypeText, ColumnTypeInteger, ColumnTypeReal, ColumnTypeNumeric, ColumnTypeDatetime}
+
+// IsDeclarableColumnType reports whether a column can be declared as the type,
+// which must already be upper-cased.
+func IsDeclarableColumnType(typeName string) bool {
+	_, ok := DeclarableColumnType(typeName)
+	return ok
+}
+
+// DeclarableColumnType returns the type a declaration of typeName, which must
+// already be upper-cased, is recorded as, and whether a column can be declared
+// as it at all. A decimal type is written the one way, DECIMAL(12,2), whatever
+// spacing it was declared with, and a decimal column's stored DECIMAL TEXT
+// reads back as the DECIMAL it was declared.
+func DeclarableColumnType(typeName string) (string, bool) {
+	if decimal, ok := ParseDecimalType(typeName); ok {
+		return decimal.String(), true
+	}
+	return typeName, slices.Contains(declarableColumnTypes, typeName)
+}
+
+// DeclarableColumnTypeNames lists the declarable types, comma-separated.
+func DeclarableColumnTypeNames() string {
+	return strings.Join(declarableColumnTypes, ", ") + ", " + ColumnTypeDecimal + "(p,s)"
+}
+
+// StorageColumnType returns the type SQLite is told a column declared as
+// typeName has. It is typeName, except for a decimal type, which is stored with
+// TEXT affinity so SQLite keeps its values as written.
+func StorageColumnType(typeName string) string {
+	if decimal, ok := ParseDecimalType(typeName); ok {
+		return decimal.StorageType()
+	}
+	return typeName
+}
+
+// ColumnType declares the type one column of an imported table is created with,
+// in place of the type the import would have inferred from its values.
+type ColumnType struct {
+	// Table is the table the declaration is for. It is empty in a declaration
+	// that applies to every table an import creates, which is what .import
+	// --types makes, since the files it names decide the tables.
+	Table string
+	// Column is the column the declaration is for.
+	Column string
+	// Type is one of the declarable types, upper-cased.
+	Type string
+}
+
+// IsNumeric reports whether the declared type converts values to numbers, and
+// so is one a value can fail to convert to. TEXT takes anything, and so does
+// DATETIME: a date is stored as the text it was written as. A DECIMAL is
+// checked too, but by sqly rather than by SQLite's conversion, so it is not
+// numeric here.
+func (c ColumnType) IsNumeric() bool {
+	return IsNumericColumnType(c.Type)
+}
+
+// IsNumericColumnType reports whether a declarable type converts values to
+// numbers.
+func IsNumericColumnType(typeName string) bool {
+	return typeName == ColumnTypeInteger || typeName == ColumnTypeReal || typeName == ColumnTypeNumeric
+}
+
+// String is the declaration as a user writes it: TABLE.COLUMN=TYPE, or
+// COLUMN:TYPE for one that names no table.
+func (c ColumnType) String() string {
+	if c.Table == "" {
+		return c.Column + ":" + c.Type
+	}
+	return c.Table + "." + c.Column + "=" + c.Type
+}
+
+// ColumnTypes is a set of column type declarations.
+type ColumnTypes []ColumnType
+
+// ParseColumnTypes parses the --column-type form: comma-separated
+// TABLE.COLUMN=TYPE declarations, such as "users.zip=TEXT,orders.amount=REAL".
+// The comma inside DECIMAL(12,2) is the type's own, not a separator.
+//
+// The table is everything before the first dot. A table sqly names after a file
+// never holds one — the dot is replaced on import — so the split is not
+// ambiguous for any table an import creates, while a column name may have one.
+func ParseColumnTypes(spec string) (ColumnTypes, error) {
+	return parseColumnTypes(spec, "=", func(name string) (string, string, bool) {
+		table, column, ok := strings.Cut(name, ".")
+		return table, column, ok && table != "" && column != ""
+	}, "TABLE.COLUMN=TYPE, such as users.zip=TEXT")
+}
+
+// ParseImportColumnTypes parses the .import --types form: comma-separated
+// COLUMN:TYPE declarations, such as "zip:TEXT,amount:REAL". They name no table,
+// because they apply to every table that one import creates.
+func ParseImportColumnTypes(spec string) (ColumnTypes, error) {
+	return parseColumnTypes(spec, ":", func(name string) (string, string, bool) {
+		return "", name, name != ""
+	}, "COLUMN:TYPE, such as zip:TEXT")
+}
+
+// parseColumnTypes is the half the two forms share: the list, the type, and
+// the refusal of a column declared twice.
+func parseColumnTypes(spec, sep string, split func(string) (string, string, bool), want string) (ColumnTypes, error) {
+	var types ColumnTypes
+	for _, entry := range splitOutsideParentheses(spec) {
+		entry = strings.TrimSpace(entry)
+		name, typeName, ok := strings.Cut(entry, sep)
+		if !ok {
+			return nil, fmt.Errorf("invalid column type %q: want %s", entry, want)
+		}
+		table, column, ok := split(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("invalid column type %q: want %s", entry, want)
+		}
+		typeName, ok = DeclarableColumnType(strings.ToUpper(strings.TrimSpace(typeName)))
+		if !ok {
+			return nil, fmt.Errorf("invalid column type %q: the type must be one of %s", entry, DeclarableColumnTypeNames())
+		}
+		declared := ColumnType{Table: table, Column: column, Type: typeName}
+		for _, previous := range types {
+			if strings.EqualFold(previous.Table, table) && strings.EqualFold(previous.Column, column) {
+				return nil, fmt.Errorf("invalid column type %q: the column is already declared as %s", entry, previous.Type)
+			}
+		}
+		types = append(types, declared)
+	}
+	return types, nil
+}
+
+// splitOutsideParentheses splits spec at the commas that are not inside a
+// pair of parentheses.
+func splitOutsideParentheses(spec string) []string {
+	var entries []string
+	depth, start := 0, 0
+	for i, r := range spec {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case r == ',' && depth == 0:
+			entries = append(entries, spec[start:i])
+			start = i + 1
+		}
+	}
+	return append(entries, spec[start:])
+}
+
+// ForTable returns the declarations that apply to a table: those naming it,
+// compared without ASCII case the way SQLite compares table names, and those
+// naming no table. The result names the table in every entry.
+func (c ColumnTypes) ForTable(table string) ColumnTypes {
+	var types ColumnTypes
+	for _, declared := range c {
+		if declared.Table == "" || strings.EqualFold(declared.Table, table) {
+			declared.Table = table
+			types = append(types, declared)
+		}
+	}
+	return types
+}
+
+// String lists the declarations comma-separated, in the form each was written.
+func (c ColumnTypes) String() string {
+	entries := make([]string, len(c))
+	for i, declared := range c {
+		entries[i] = declared.String()
+	}
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseColumnTypes(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseColumnTypes(" users.zip = text ,orders.unit.price=Numeric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ColumnTypes{
+		{Table: "users", Column: "zip", Type: ColumnTypeText},
+		{Table: "orders", Column: "unit.price", Type: ColumnTypeNumeric},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseColumnTypes = %v, want %v", got, want)
+	}
+	if s := got.String(); s != "users.zip=TEXT,orders.unit.price=NUMERIC" {
+		t.Errorf("String() = %q", s)
+	}
+
+	tests := map[string]string{
+		"zip=TEXT":                      "want TABLE.COLUMN=TYPE",
+		".zip=TEXT":                     "want TABLE.COLUMN=TYPE",
+		"users.zip":                     "want TABLE.COLUMN=TYPE",
+		"users.zip=VARCHAR":             "must be one of TEXT, INTEGER, REAL, NUMERIC, DATETIME",
+		"users.zip=TEXT,":               "want TABLE.COLUMN=TYPE",
+		"users.zip=TEXT,Users.ZIP=INT":  "must be one of",
+		"users.zip=TEXT,Users.ZIP=REAL": "already declared as TEXT",
+	}
+	for spec, want := range tests {
+		if _, err := ParseColumnTypes(spec); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseColumnTypes(%q) error = %v, want one containing %q", spec, err, want)
+		}
+	}
+}
+
+func TestParseImportColumnTypes(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseImportColumnTypes("zip:TEXT,amount:real")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ColumnTypes{
+		{Column: "zip", Type: ColumnTypeText},
+		{Column: "amount", Type: ColumnTypeReal},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseImportColumnTypes = %v, want %v", got, want)
+	}
+	for _, spec := range []string{"zip=TEXT", ":TEXT", "zip:TEXT,ZIP:REAL"} {
+		if _, err := ParseImportColumnTypes(spec); err == nil {
+			t.Errorf("ParseImportColumnTypes(%q) succeeded, want it refused", spec)
+		}
+	}
+}
+
+// TestColumnTypes_ForTable checks a declaration that names no table applies to
+// every table and one that names a table matches it without case, the way
+// SQLite matches table names.
+func TestColumnTypes_ForTable(t *testing.T) {
+	t.Parallel()
+
+	types := ColumnTypes{
+		{Table: "Users", Column: "zip", Type: ColumnTypeText},
+		{Table: "orders", Column: "amount", Type: ColumnTypeReal},
+		{Column: "id", Type: ColumnTypeText},
+	}
+	want := ColumnTypes{
+		{Table: "users", Column: "zip", Type: ColumnTypeText},
+		{Table: "users", Column: "id", Type: ColumnTypeText},
+	}
+	if got := types.ForTable("users"); !slices.Equal(got, want) {
+		t.Errorf("ForTable(users) = %v, want %v", got, want)
+	}
+	if got := (ColumnTypes{}).ForTable("users"); len(got) != 0 {
+		t.Errorf("ForTable on no declarations = %v, want none", got)
+	}
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// NoQuote is the CSVDialect.Quote of a file that quotes nothing: every
+// character, including one a CSV file would quote with, is data.
+const NoQuote rune = -1
+
+// noQuoteName is what --quote takes, and String prints, for NoQuote.
+const noQuoteName = "none"
+
+// CSVDialect is how a delimited text input is written, where it differs from
+// what its extension promises: a comma (or a tab), a double quote, and a header
+// on the first line.
+//
+// Files that break that promise are common and not malformed — a European
+// spreadsheet exports with semicolons, a mainframe extract puts a report title
+// above the header, a log writer starts every note with #. Reading one as the
+// extension says gives a table of one column, or a header made of the title, and
+// no error. The zero value is the promise itself, so an input with no dialect
+// is read exactly as it was before dialects existed.
+type CSVDialect struct {
+	// Delimiter separates fields. Zero is the format's own: a comma for CSV and
+	// a tab for TSV.
+	Delimiter rune
+	// Quote encloses a field that holds the delimiter, a quote, or a line break.
+	// Zero is the format's own, a double quote for CSV and none for TSV, whose
+	// quotes are data; NoQuote turns quoting off.
+	Quote rune
+	// CommentPrefix starts a line that is not a record. Empty means no line is
+	// a comment.
+	CommentPrefix string
+	// SkipLines is how many lines at the top of the file are read past before
+	// the header, comment or not.
+	SkipLines int
+	// NoHeader reads the first record as data, and names the columns c1, c2,
+	// and so on.
+	NoHeader bool
+}
+
+// IsZero reports whether the dialect is the format's own, with nothing to
+// change about how the file is read.
+func (d CSVDialect) IsZero() bool {
+	return d == CSVDialect{}
+}
+
+// String returns the dialect as the options that set it, such as
+// --delimiter ';' --no-header, in the order --help lists them. It is what a
+// message quotes and what a persistent session records, so the two name a
+// dialect the way the user wrote it. A character is quoted so a tab prints as
+// '\t' rather than as a gap.
+func (d CSVDialect) String() string {
+	var options []string
+	if d.Delimiter != 0 {
+		options = append(options, "--delimiter "+strconv.QuoteRune(d.Delimiter))
+	}
+	switch d.Quote {
+	case 0:
+	case NoQuote:
+		options = append(options, "--quote "+noQuoteName)
+	default:
+		options = append(options, "--quote "+strconv.QuoteRune(d.Quote))
+	}
+	if d.CommentPrefix != "" {
+		options = append(options, "--comment-prefix "+strconv.Quote(d.CommentPrefix))
+	}
+	if d.SkipLines > 0 {
+		options = append(options, "--skip-lines "+strconv.Itoa(d.SkipLines))
+	}
+	if d.NoHeader {
+		options = append(options, "--no-header")
+	}
+	return strings.Join(options, " ")
+}
+
+// Validate refuses a dialect whose characters contradict each other. It only
+// compares what was set: a quote of "," under a CSV's own delimiter is found
+// when the file is read, because only then is the format known.
+func (d CSVDialect) Validate() error {
+	if d.Delimiter != 0 && d.Delimiter == d.Quote {
+		return fmt.Errorf("--delimiter and --quote are both %s; a field could not tell where it ends", strconv.QuoteRune(d.Delimiter))
+	}
+	if d.SkipLines < 0 {
+		return fmt.Errorf("invalid --skip-lines %d: want 0 or more lines", d.SkipLines)
+	}
+	if strings.ContainsAny(d.CommentPrefix, "\r\n") {
+		return errors.New("invalid --comment-prefix: a comment is one line, so the prefix cannot hold a line break")
+	}
+	return nil
+}
+
+// ParseCSVDelimiter reads a --delimiter value: one character, or "tab" or \t
+// for a tab, which a shell makes awkward to type.
+func ParseCSVDelimiter(value string) (rune, error) {
+	switch value {
+	case "tab", `\t`:
+		return '\t', nil
+	}
+	r, err := singleDialectRune(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --delimiter %q: %w", value, err)
+	}
+	return r, nil
+}
+
+// ParseCSVQuote reads a --quote value: one character, or "none" for a file
+// that quotes nothing.
+func ParseCSVQuote(value string) (rune, error) {
+	if value == noQuoteName {
+		return NoQuote, nil
+	}
+	r, err := singleDialectRune(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --quote %q: %w; use none for a file that quotes nothing", value, err)
+	}
+	return r, nil
+}
+
+// singleDialectRune returns the one character value holds. A line break is
+// refused, because a record ends there whatever the dialect says.
+func singleDialectRune(value string) (rune, error) {
+	r, size := utf8.DecodeRuneInString(value)
+	switch {
+	case value == "":
+		return 0, errors.New("want one character")
+	case r == utf8.RuneError || size != len(value):
+		return 0, errors.New("want exactly one character")
+	case r == '\n' || r == '\r':
+		return 0, errors.New("a line break ends a record, so it cannot be a field's character")
+	default:
+		return r, nil
+	}
+}
+
+// HeaderlessColumnNames returns the names a file read with --no-header gets
+// for n columns: c1 to cN. They are short, valid unquoted identifiers, and they
+// say where the column is in the file, which is all that is known about it.
+func HeaderlessColumnNames(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = "c" + strconv.Itoa(i+1)
+	}
+	return names
+}
@@ -0,0 +1,96 @@
+package model
+
+import "testing"
+
+func TestParseCSVDelimiter(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input   string
+		want    rune
+		wantErr bool
+	}{
+		{input: ";", want: ';'},
+		{input: "|", want: '|'},
+		{input: "tab", want: '\t'},
+		{input: `\t`, want: '\t'},
+		{input: "¦", want: '¦'},
+		{input: "", wantErr: true},
+		{input: ";;", wantErr: true},
+		{input: "\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseCSVDelimiter(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCSVDelimiter(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseCSVDelimiter(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCSVQuote(t *testing.T) {
+	t.Parallel()
+	if got, err := ParseCSVQuote("'"); err != nil || got != '\'' {
+		t.Errorf("ParseCSVQuote(') = %q, %v; want '", got, err)
+	}
+	if got, err := ParseCSVQuote("none"); err != nil || got != NoQuote {
+		t.Errorf("ParseCSVQuote(none) = %q, %v; want NoQuote", got, err)
+	}
+	if _, err := ParseCSVQuote(""); err == nil {
+		t.Error("ParseCSVQuote(\"\") was accepted")
+	}
+}
+
+func TestCSVDialect_String(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		dialect CSVDialect
+		want    string
+	}{
+		{name: "the zero value is empty", dialect: CSVDialect{}, want: ""},
+		{name: "a tab is escaped", dialect: CSVDialect{Delimiter: '\t'}, want: `--delimiter '\t'`},
+		{name: "no quoting", dialect: CSVDialect{Quote: NoQuote}, want: "--quote none"},
+		{
+			name:    "every option, in --help order",
+			dialect: CSVDialect{Delimiter: ';', Quote: '\'', CommentPrefix: "#", SkipLines: 2, NoHeader: true},
+			want:    `--delimiter ';' --quote '\'' --comment-prefix "#" --skip-lines 2 --no-header`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.dialect.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVDialect_Validate(t *testing.T) {
+	t.Parallel()
+	if err := (CSVDialect{Delimiter: ';', Quote: '\''}).Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+	for _, d := range []CSVDialect{
+		{Delimiter: '|', Quote: '|'},
+		{SkipLines: -1},
+		{CommentPrefix: "#\n"},
+	} {
+		if err := d.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want an error", d)
+		}
+	}
+}
+
+func TestHeaderlessColumnNames(t *testing.T) {
+	t.Parallel()
+	got := HeaderlessColumnNames(3)
+	if len(got) != 3 || got[0] != "c1" || got[2] != "c3" {
+		t.Errorf("HeaderlessColumnNames(3) = %v, want [c1 c2 c3]", got)
+	}
+}
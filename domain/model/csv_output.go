@@ -0,0 +1,169 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// utf8BOM is the byte-order mark a UTF-8 file may start with. Excel reads a CSV
+// file without one as the machine's legacy code page, so it is how a file says
+// it is UTF-8 to the one reader that does not assume it.
+const utf8BOM = "\ufeff"
+
+// CSVOutput is how a CSV or TSV file sqly writes is laid out, where it differs
+// from what sqly writes by default: a comma (a tab for TSV), a field quoted only
+// when it has to be, a bare line feed after each record, and no byte-order mark.
+//
+// The default is RFC 4180 and what every CSV library reads. The options exist for
+// the readers that want something else and do not say so when they get it:
+// Excel on Windows shows UTF-8 without a BOM as mojibake, and a loader that
+// splits on "|" or expects every field quoted misreads a file that is valid CSV.
+// The zero value is the default, so a table that carries none is written exactly
+// as it always was.
+type CSVOutput struct {
+	// Delimiter separates the fields of a CSV file. Zero is a comma. A TSV file
+	// is separated by tabs whatever this is; that is what makes it TSV.
+	Delimiter rune
+	// QuoteAll quotes every field of a CSV file, the header included, rather
+	// than only the fields that hold a delimiter, a quote, or a line break. A
+	// TSV file is not quoted this way, because a TSV reader takes quotes as data.
+	QuoteAll bool
+	// CRLF ends each record with a carriage return and a line feed.
+	CRLF bool
+	// BOM starts the file with a UTF-8 byte-order mark.
+	BOM bool
+}
+
+// IsZero reports whether the layout is sqly's default.
+func (o CSVOutput) IsZero() bool {
+	return o == CSVOutput{}
+}
+
+// String returns the layout as the flags that set it, such as
+// --csv-delimiter '|' --crlf, in the order --help lists them.
+func (o CSVOutput) String() string {
+	var options []string
+	if o.Delimiter != 0 {
+		options = append(options, "--csv-delimiter "+strconv.QuoteRune(o.Delimiter))
+	}
+	if o.QuoteAll {
+		options = append(options, "--csv-quote-all")
+	}
+	if o.CRLF {
+		options = append(options, "--crlf")
+	}
+	if o.BOM {
+		options = append(options, "--bom")
+	}
+	return strings.Join(options, " ")
+}
+
+// ParseCSVOutputDelimiter reads a --csv-delimiter value. It takes what
+// --delimiter takes, except a double quote, which is the quote a written field
+// is enclosed in and so cannot also separate fields.
+func ParseCSVOutputDelimiter(value string) (rune, error) {
+	r, err := ParseCSVDelimiter(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --csv-delimiter %q: %w", value, errors.Unwrap(err))
+	}
+	if r == '"' {
+		return 0, fmt.Errorf("invalid --csv-delimiter %q: a double quote encloses a field, so it cannot also separate fields", value)
+	}
+	return r, nil
+}
+
+// WithCSVOutput returns a copy of the table that is laid out as output when it
+// is written as CSV or TSV. The rows are shared, as WithName shares them.
+func (t *Table) WithCSVOutput(output CSVOutput) *Table {
+	cloned := t.WithName(t.name)
+	cloned.csvOutput = output
+	return cloned
+}
+
+// DetectCSVOutput reads the layout a CSV or TSV file already has from its first
+// bytes, head, so a file written back in place keeps it: the BOM, the line
+// ending, and, for CSV, whether every field of the header is quoted. A file
+// whose header is not all quoted is taken to quote only where it must, which is
+// the only other layout a writer produces.
+//
+// The delimiter is not detected: comma is the one the file was read with, and a
+// tab means the file is TSV.
+func DetectCSVOutput(head []byte, comma rune) CSVOutput {
+	var output CSVOutput
+	if rest, ok := bytes.CutPrefix(head, []byte(utf8BOM)); ok {
+		output.BOM = true
+		head = rest
+	}
+	line, _, found := bytes.Cut(head, []byte("\n"))
+	if found && bytes.HasSuffix(line, []byte("\r")) {
+		output.CRLF = true
+		line = line[:len(line)-1]
+	}
+	if comma != '\t' && found {
+		output.QuoteAll = everyFieldQuoted(string(line), comma)
+	}
+	return output
+}
+
+// everyFieldQuoted reports whether every field of one line starts and ends
+// with a double quote. An empty line has no fields to quote.
+func everyFieldQuoted(line string, comma rune) bool {
+	if line == "" {
+		return false
+	}
+	for {
+		if !strings.HasPrefix(line, `"`) {
+			return false
+		}
+		line = line[1:]
+		// Skip to the closing quote, past any doubled ones.
+		for {
+			i := strings.IndexByte(line, '"')
+			if i < 0 {
+				return false
+			}
+			line = line[i+1:]
+			if !strings.HasPrefix(line, `"`) {
+				break
+			}
+			line = line[1:]
+		}
+		if line == "" {
+			return true
+		}
+		rest, ok := strings.CutPrefix(line, string(comma))
+		if !ok {
+			return false
+		}
+		line = rest
+	}
+}
+
+// writeQuotedRecord writes one record with every field quoted, which is a rule
+// encoding/csv's writer does not have. Quotes inside a field are doubled, and a
+// line break inside one is written the way encoding/csv writes it, so a file
+// has one line ending throughout whichever writer wrote it.
+func writeQuotedRecord(out io.Writer, record []string, comma rune, crlf bool) error {
+	newline := "\n"
+	if crlf {
+		newline = "\r\n"
+	}
+	var line strings.Builder
+	for i, field := range record {
+		if i > 0 {
+			line.WriteRune(comma)
+		}
+		field = strings.ReplaceAll(field, `"`, `""`)
+		if crlf {
+			field = strings.ReplaceAll(strings.ReplaceAll(field, "\r", ""), "\n", newline)
+		}
+		line.WriteString(`"` + field + `"`)
+	}
+	line.WriteString(newline)
+	_, err := io.WriteString(out, line.String())
+	return err
+}
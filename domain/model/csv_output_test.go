@@ -0,0 +1,113 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseCSVOutputDelimiter(t *testing.T) {
+	t.Parallel()
+
+	if r, err := ParseCSVOutputDelimiter("|"); err != nil || r != '|' {
+		t.Errorf("ParseCSVOutputDelimiter(|) = %q, %v; want '|'", r, err)
+	}
+	for _, value := range []string{"", "ab", `"`, "\n"} {
+		if _, err := ParseCSVOutputDelimiter(value); err == nil {
+			t.Errorf("ParseCSVOutputDelimiter(%q) accepted, want a refusal", value)
+		}
+	}
+}
+
+func TestCSVOutput_String(t *testing.T) {
+	t.Parallel()
+
+	if got := (CSVOutput{}).String(); got != "" {
+		t.Errorf("zero String() = %q, want empty", got)
+	}
+	output := CSVOutput{Delimiter: '|', QuoteAll: true, CRLF: true, BOM: true}
+	if want := "--csv-delimiter '|' --csv-quote-all --crlf --bom"; output.String() != want {
+		t.Errorf("String() = %q, want %q", output.String(), want)
+	}
+}
+
+func TestDetectCSVOutput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		head  string
+		comma rune
+		want  CSVOutput
+	}{
+		{name: "default layout", head: "id,name\n1,a\n", comma: ',', want: CSVOutput{}},
+		{name: "bom and crlf", head: utf8BOM + "id,name\r\n1,a\r\n", comma: ',', want: CSVOutput{BOM: true, CRLF: true}},
+		{name: "every header field quoted", head: `"id","na""me"` + "\n1,a\n", comma: ',', want: CSVOutput{QuoteAll: true}},
+		{name: "some header fields quoted", head: `"id",name` + "\n", comma: ',', want: CSVOutput{}},
+		{name: "quoted with another delimiter", head: `"id";"name"` + "\r\n", comma: ';', want: CSVOutput{QuoteAll: true, CRLF: true}},
+		{name: "tsv is never quote-all", head: `"id"` + "\t" + `"name"` + "\n", comma: '\t', want: CSVOutput{}},
+		{name: "a header cut short is not judged", head: `"id","na`, comma: ',', want: CSVOutput{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := DetectCSVOutput([]byte(tt.head), tt.comma); got != tt.want {
+				t.Errorf("DetectCSVOutput(%q) = %+v, want %+v", tt.head, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWriteDelimitedWithCSVOutput checks each layout option as a table prints
+// it, and that a TSV table keeps its tabs and its unquoted fields whatever the
+// layout asks of CSV.
+func TestWriteDelimitedWithCSVOutput(t *testing.T) {
+	t.Parallel()
+
+	table := NewTable("t", Header{"id", "note"}, []Record{
+		Record([]string{"1", "a|b"}),
+		Record([]string{"2", "say \"hi\"\nbye"}),
+	})
+	tests := []struct {
+		name   string
+		output CSVOutput
+		mode   PrintMode
+		want   string
+	}{
+		{
+			name:   "delimiter",
+			output: CSVOutput{Delimiter: '|'},
+			mode:   PrintModeCSV,
+			want:   "id|note\n1|\"a|b\"\n2|\"say \"\"hi\"\"\nbye\"\n",
+		},
+		{
+			name:   "quote all with crlf",
+			output: CSVOutput{QuoteAll: true, CRLF: true},
+			mode:   PrintModeCSV,
+			want:   "\"id\",\"note\"\r\n\"1\",\"a|b\"\r\n\"2\",\"say \"\"hi\"\"\r\nbye\"\r\n",
+		},
+		{
+			name:   "bom",
+			output: CSVOutput{BOM: true},
+			mode:   PrintModeCSV,
+			want:   utf8BOM + "id,note\n1,a|b\n2,\"say \"\"hi\"\"\nbye\"\n",
+		},
+		{
+			name:   "tsv ignores the delimiter and quote-all",
+			output: CSVOutput{Delimiter: '|', QuoteAll: true, CRLF: true},
+			mode:   PrintModeTSV,
+			want:   "id\tnote\r\n1\ta|b\r\n2\t\"say \"\"hi\"\"\r\nbye\"\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			if err := table.WithCSVOutput(tt.output).Print(&buf, tt.mode); err != nil {
+				t.Fatalf("Print: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Print = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
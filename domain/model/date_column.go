@@ -0,0 +1,115 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DateColumn names a column of CSV or TSV input whose dates are written in a
+// layout of the file's own, and are read with it and stored as ISO 8601.
+type DateColumn struct {
+	// Table is the table the column is in. It is empty for a column read that
+	// way in every input that has it.
+	Table string
+	// Column is the column's name, matched without ASCII case the way SQLite
+	// matches column names.
+	Column string
+	// Layout is how the column's dates are written.
+	Layout DateLayout
+}
+
+// String is the column as a user writes it: [TABLE.]COLUMN=LAYOUT.
+func (c DateColumn) String() string {
+	name := c.Column
+	if c.Table != "" {
+		name = c.Table + "." + name
+	}
+	return name + "=" + c.Layout.String()
+}
+
+// DateColumns is the set of columns --date-columns names.
+type DateColumns []DateColumn
+
+// ParseDateColumns parses the --date-columns form: comma-separated
+// [TABLE.]COLUMN=LAYOUT entries, such as "ordered=%m/%d/%Y,orders.shipped=%d.%m.%Y %H:%M".
+//
+// A layout may hold a comma of its own — RFC 1123's starts %a, %d — so a comma
+// only starts the next entry when what follows it, up to an =, is a name: no %,
+// and no other comma. As in --column-type, the table is everything before the
+// first dot.
+func ParseDateColumns(spec string) (DateColumns, error) {
+	var columns DateColumns
+	for _, entry := range splitDateColumnEntries(spec) {
+		entry = strings.TrimSpace(entry)
+		name, layoutText, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || layoutText == "" {
+			return nil, fmt.Errorf("invalid date column %q: want [TABLE.]COLUMN=LAYOUT, such as ordered=%%m/%%d/%%Y", entry)
+		}
+		table, column, qualified := strings.Cut(name, ".")
+		if !qualified {
+			table, column = "", name
+		}
+		if column == "" || (qualified && table == "") {
+			return nil, fmt.Errorf("invalid date column %q: want [TABLE.]COLUMN=LAYOUT, such as ordered=%%m/%%d/%%Y", entry)
+		}
+		layout, err := ParseDateLayout(layoutText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date column %q: %w", entry, err)
+		}
+		if !layout.ReadsDate() {
+			return nil, fmt.Errorf("invalid date column %q: the layout has no year; a date needs %%Y or %%y", entry)
+		}
+		for _, previous := range columns {
+			if strings.EqualFold(previous.Table, table) && strings.EqualFold(previous.Column, column) {
+				return nil, fmt.Errorf("invalid date column %q: the column is already read as %s", entry, previous.Layout)
+			}
+		}
+		columns = append(columns, DateColumn{Table: table, Column: column, Layout: layout})
+	}
+	return columns, nil
+}
+
+// splitDateColumnEntries splits spec at the commas that start an entry.
+func splitDateColumnEntries(spec string) []string {
+	var entries []string
+	start := 0
+	for i := 0; i < len(spec); i++ {
+		if spec[i] != ',' {
+			continue
+		}
+		next, _, ok := strings.Cut(spec[i+1:], "=")
+		if ok && strings.TrimSpace(next) != "" && !strings.ContainsAny(next, "%,") {
+			entries = append(entries, spec[start:i])
+			start = i + 1
+		}
+	}
+	return append(entries, spec[start:])
+}
+
+// ForTable returns the layouts that apply to the columns of a table, keyed by
+// the column name in lower case: those naming the table, compared without ASCII
+// case, and those naming none. One naming the table wins over one naming none.
+func (c DateColumns) ForTable(table string) map[string]DateLayout {
+	layouts := map[string]DateLayout{}
+	for _, column := range c {
+		if column.Table == "" {
+			layouts[strings.ToLower(column.Column)] = column.Layout
+		}
+	}
+	for _, column := range c {
+		if column.Table != "" && strings.EqualFold(column.Table, table) {
+			layouts[strings.ToLower(column.Column)] = column.Layout
+		}
+	}
+	return layouts
+}
+
+// String lists the columns comma-separated, in the form each was written.
+func (c DateColumns) String() string {
+	entries := make([]string, len(c))
+	for i, column := range c {
+		entries[i] = column.String()
+	}
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDateColumns(t *testing.T) {
+	t.Parallel()
+
+	spec := "ordered=%m/%d/%Y, orders.shipped=%d.%m.%Y %H:%M,sent=%a, %d %b %Y %H:%M:%S GMT"
+	columns, err := ParseDateColumns(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := columns.String(), "ordered=%m/%d/%Y,orders.shipped=%d.%m.%Y %H:%M,sent=%a, %d %b %Y %H:%M:%S GMT"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if columns[1].Table != "orders" || columns[1].Column != "shipped" || columns[0].Table != "" {
+		t.Errorf("tables = %+v, want orders.shipped qualified and ordered not", columns)
+	}
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"ordered", "want [TABLE.]COLUMN=LAYOUT"},
+		{"=%Y", "want [TABLE.]COLUMN=LAYOUT"},
+		{".ordered=%Y", "want [TABLE.]COLUMN=LAYOUT"},
+		{"ordered=%Y-%q", "unknown directive %q"},
+		{"ordered=%H:%M", "the layout has no year"},
+		{"ordered=%Y,ORDERED=%y", "already read as %Y"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseDateColumns(tt.spec); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseDateColumns(%q) error = %v, want it to mention %q", tt.spec, err, tt.want)
+		}
+	}
+}
+
+func TestDateColumns_ForTable(t *testing.T) {
+	t.Parallel()
+
+	columns, err := ParseDateColumns("ordered=%m/%d/%Y,Orders.Ordered=%d.%m.%Y,returns.due=%Y%m%d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders := columns.ForTable("orders")
+	if len(orders) != 1 || orders["ordered"].String() != "%d.%m.%Y" {
+		t.Errorf("ForTable(orders) = %v, want the table's own layout for ordered", orders)
+	}
+	other := columns.ForTable("invoices")
+	if len(other) != 1 || other["ordered"].String() != "%m/%d/%Y" {
+		t.Errorf("ForTable(invoices) = %v, want the unqualified layout for ordered", other)
+	}
+}
@@ -0,0 +1,525 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A DateLayout is how a file writes its dates, in the strftime directives a
+// shell user already knows from date(1) and SQLite's own strftime: %d.%m.%Y,
+// %m/%d/%Y %I:%M %p, %Y%m%d, or %a, %d %b %Y %H:%M:%S GMT for RFC 1123. SQLite's
+// date functions read ISO 8601 and nothing else, so a date in any other shape
+// has to be read with its layout and written back as ISO 8601 before date(),
+// strftime(), or a comparison can make sense of it.
+//
+// A layout is read by walking it, not by translating it into one of Go's
+// reference-time layouts: those treat any run of 1, 2, Jan, or PM in the text
+// around the directives as a directive of their own, and a layout a user wrote
+// should mean what it says.
+
+// SQL names the date functions are registered under. parse_date and
+// format_date are names the dialect helpers hold already, so the functions are
+// registered under these, and each call a statement makes is sent to them
+// before it runs.
+const (
+	ParseDateFunction  = "sqly_parse_date"
+	FormatDateFunction = "sqly_format_date"
+)
+
+// DateLayoutDirectives lists the directives a layout may use, for a message.
+const DateLayoutDirectives = "%Y %y %m %d %e %j %H %I %M %S %f %p %b %B %a %A %z %Z %s %F %T %D %R %%"
+
+// dateLayoutShorthands are the directives that stand for several others.
+var dateLayoutShorthands = map[byte]string{
+	'F': "%Y-%m-%d",
+	'T': "%H:%M:%S",
+	'D': "%m/%d/%y",
+	'R': "%H:%M",
+}
+
+// dateLayoutElement is one directive of a layout, or the literal text between
+// two of them.
+type dateLayoutElement struct {
+	// directive is the letter after the %, or 0 for literal text.
+	directive byte
+	literal   string
+}
+
+// DateLayout is a parsed strftime layout.
+type DateLayout struct {
+	text     string
+	elements []dateLayoutElement
+	// hasTime is whether the layout reads a time of day, and so whether the
+	// ISO 8601 form of what it reads has one.
+	hasTime bool
+	// hasZone is whether the layout reads a UTC offset or a zone name.
+	hasZone bool
+	// hasYear is whether the layout reads a year, which it has to for Parse.
+	hasYear bool
+}
+
+// ParseDateLayout parses a strftime layout.
+func ParseDateLayout(layout string) (DateLayout, error) {
+	l := DateLayout{text: layout}
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			l.elements = append(l.elements, dateLayoutElement{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+	expanded := layout
+	for i := 0; i < len(expanded); i++ {
+		c := expanded[i]
+		if c != '%' {
+			literal.WriteByte(c)
+			continue
+		}
+		if i+1 == len(expanded) {
+			return DateLayout{}, fmt.Errorf("invalid date layout %q: it ends in a lone %%", layout)
+		}
+		directive := expanded[i+1]
+		if expansion, ok := dateLayoutShorthands[directive]; ok {
+			expanded = expanded[:i] + expansion + expanded[i+2:]
+			i--
+			continue
+		}
+		i++
+		switch directive {
+		case '%':
+			literal.WriteByte('%')
+			continue
+		case 'Y', 'y', 's':
+			l.hasYear = true
+		case 'H', 'I', 'M', 'S', 'f', 'p':
+			l.hasTime = true
+		case 'z', 'Z':
+			l.hasZone = true
+		case 'm', 'd', 'e', 'j', 'b', 'h', 'B', 'a', 'A':
+		default:
+			return DateLayout{}, fmt.Errorf("invalid date layout %q: unknown directive %%%c; the directives are %s", layout, directive, DateLayoutDirectives)
+		}
+		if directive == 's' {
+			l.hasTime = true
+		}
+		flush()
+		l.elements = append(l.elements, dateLayoutElement{directive: directive})
+	}
+	flush()
+	return l, nil
+}
+
+// ReadsDate reports whether the layout can read a date, which it can when it
+// names the year, or is %s. The month and the day default to the first; the
+// year has nothing sensible to default to.
+func (l DateLayout) ReadsDate() bool {
+	return l.hasYear
+}
+
+// String returns the layout as it was written.
+func (l DateLayout) String() string {
+	return l.text
+}
+
+// dateFields are the values a layout reads, before they are put together.
+type dateFields struct {
+	year, month, day, yearDay  int
+	hour, minute, second, nano int
+	// hour12 and pm are set by %I and %p, which only make an hour together.
+	hour12, pm, hasPM bool
+	zone              *time.Location
+	unix              *int64
+}
+
+// Parse reads value with the layout. It fails with the reason when value does
+// not have the layout's shape or names a date that does not exist, such as
+// 31 February. A value with no offset is read as UTC.
+func (l DateLayout) Parse(value string) (time.Time, error) {
+	if !l.ReadsDate() {
+		return time.Time{}, fmt.Errorf("date layout %s has no year; a date needs %%Y or %%y", l.text)
+	}
+	f := dateFields{month: 1, day: 1}
+	rest := value
+	for _, e := range l.elements {
+		var err error
+		if e.directive == 0 {
+			rest, err = readLayoutLiteral(rest, e.literal)
+		} else {
+			rest, err = f.read(rest, e.directive)
+		}
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q does not match %s: %w", value, l.text, err)
+		}
+	}
+	if rest != "" {
+		return time.Time{}, fmt.Errorf("%q does not match %s: %q is left over", value, l.text, rest)
+	}
+	t, err := f.time()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q does not match %s: %w", value, l.text, err)
+	}
+	return t, nil
+}
+
+// readLayoutLiteral reads the literal text of a layout from the front of s. A
+// space in the layout reads any run of whitespace, none included, as strptime's
+// does, so a day padded with a space and one that is not both read.
+func readLayoutLiteral(s, literal string) (string, error) {
+	for i := 0; i < len(literal); i++ {
+		if literal[i] == ' ' {
+			s = strings.TrimLeft(s, " \t")
+			continue
+		}
+		if s == "" || s[0] != literal[i] {
+			return s, fmt.Errorf("expected %q at %q", literal[i:], s)
+		}
+		s = s[1:]
+	}
+	return s, nil
+}
+
+// read reads one directive from the front of s into f.
+func (f *dateFields) read(s string, directive byte) (string, error) {
+	var err error
+	switch directive {
+	case 'Y':
+		s, f.year, err = readLayoutNumber(s, 4, "year")
+	case 'y':
+		s, f.year, err = readLayoutNumber(s, 2, "year")
+		// POSIX's pivot: 69 to 99 are the 1900s, 00 to 68 the 2000s.
+		if f.year < 69 {
+			f.year += 2000
+		} else {
+			f.year += 1900
+		}
+	case 'm':
+		s, f.month, err = readLayoutNumber(s, 2, "month")
+	case 'd':
+		s, f.day, err = readLayoutNumber(s, 2, "day")
+	case 'e':
+		s, f.day, err = readLayoutNumber(strings.TrimLeft(s, " "), 2, "day")
+	case 'j':
+		s, f.yearDay, err = readLayoutNumber(s, 3, "day of the year")
+	case 'H':
+		s, f.hour, err = readLayoutNumber(s, 2, "hour")
+	case 'I':
+		s, f.hour, err = readLayoutNumber(s, 2, "hour")
+		f.hour12 = true
+	case 'M':
+		s, f.minute, err = readLayoutNumber(s, 2, "minute")
+	case 'S':
+		s, f.second, err = readLayoutNumber(s, 2, "second")
+	case 'f':
+		s, f.nano, err = readLayoutFraction(s)
+	case 'p':
+		switch {
+		case len(s) >= 2 && strings.EqualFold(s[:2], "AM"):
+			f.pm, f.hasPM = false, true
+		case len(s) >= 2 && strings.EqualFold(s[:2], "PM"):
+			f.pm, f.hasPM = true, true
+		default:
+			return s, fmt.Errorf("expected AM or PM at %q", s)
+		}
+		s = s[2:]
+	case 'b', 'h', 'B':
+		var month int
+		s, month, err = readLayoutName(s, monthNames, "month name")
+		f.month = month + 1
+	case 'a', 'A':
+		// The weekday follows from the date, so it is read past, not checked.
+		s, _, err = readLayoutName(s, weekdayNames, "weekday name")
+	case 'z':
+		s, f.zone, err = readLayoutOffset(s)
+	case 'Z':
+		s, f.zone, err = readLayoutZoneName(s)
+	case 's':
+		end := 0
+		if end < len(s) && s[end] == '-' {
+			end++
+		}
+		for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+			end++
+		}
+		n, parseErr := strconv.ParseInt(s[:end], 10, 64)
+		if parseErr != nil {
+			return s, fmt.Errorf("expected seconds since the epoch at %q", s)
+		}
+		f.unix = &n
+		s = s[end:]
+	}
+	return s, err
+}
+
+// readLayoutNumber reads up to width digits from the front of s. Reading no
+// more than the width is what lets %Y%m%d read 20240314.
+func readLayoutNumber(s string, width int, what string) (string, int, error) {
+	end := 0
+	for end < len(s) && end < width && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return s, 0, fmt.Errorf("expected the %s at %q", what, s)
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return s[end:], n, nil
+}
+
+// readLayoutFraction reads the digits of a fraction of a second, up to a
+// nanosecond's nine.
+func readLayoutFraction(s string) (string, int, error) {
+	end := 0
+	for end < len(s) && end < 9 && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return s, 0, fmt.Errorf("expected a fraction of a second at %q", s)
+	}
+	n, _ := strconv.Atoi(s[:end] + strings.Repeat("0", 9-end))
+	return s[end:], n, nil
+}
+
+var (
+	monthNames = []string{"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December"}
+	weekdayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+)
+
+// readLayoutName reads one of names, written in full or as its first three
+// letters, in any case, and returns its index.
+func readLayoutName(s string, names []string, what string) (string, int, error) {
+	for i, name := range names {
+		if len(s) >= len(name) && strings.EqualFold(s[:len(name)], name) {
+			return s[len(name):], i, nil
+		}
+	}
+	for i, name := range names {
+		if len(s) >= 3 && strings.EqualFold(s[:3], name[:3]) {
+			return s[3:], i, nil
+		}
+	}
+	return s, 0, fmt.Errorf("expected a %s at %q", what, s)
+}
+
+// readLayoutOffset reads a UTC offset: Z, +hh, +hhmm, or +hh:mm.
+func readLayoutOffset(s string) (string, *time.Location, error) {
+	if strings.HasPrefix(s, "Z") || strings.HasPrefix(s, "z") {
+		return s[1:], time.UTC, nil
+	}
+	if s == "" || (s[0] != '+' && s[0] != '-') {
+		return s, nil, fmt.Errorf("expected a UTC offset such as +0900 at %q", s)
+	}
+	sign := 1
+	if s[0] == '-' {
+		sign = -1
+	}
+	rest, hours, err := readLayoutNumber(s[1:], 2, "UTC offset")
+	if err != nil {
+		return s, nil, err
+	}
+	minutes := 0
+	rest = strings.TrimPrefix(rest, ":")
+	if len(rest) >= 2 && rest[0] >= '0' && rest[0] <= '9' {
+		rest, minutes, _ = readLayoutNumber(rest, 2, "UTC offset")
+	}
+	if hours > 23 || minutes > 59 {
+		return s, nil, fmt.Errorf("UTC offset %q is out of range", strings.TrimSuffix(s, rest))
+	}
+	return rest, fixedOffset(sign * (hours*3600 + minutes*60)), nil
+}
+
+// ParseUTCOffset reads a UTC offset written as %z reads one: Z, +hh, +hhmm, or
+// +hh:mm.
+func ParseUTCOffset(value string) (*time.Location, error) {
+	rest, zone, err := readLayoutOffset(value)
+	if err == nil && rest != "" {
+		err = fmt.Errorf("%q is left over", rest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid UTC offset %q: %w", value, err)
+	}
+	return zone, nil
+}
+
+// readLayoutZoneName reads a zone as %Z writes it. Only the names that mean one
+// offset wherever they are read are taken: an abbreviation such as CST is
+// three different zones, and reading it as any one of them would be a guess.
+func readLayoutZoneName(s string) (string, *time.Location, error) {
+	for _, name := range []string{"UTC", "GMT", "UT", "Z"} {
+		if len(s) >= len(name) && strings.EqualFold(s[:len(name)], name) {
+			rest := s[len(name):]
+			if rest != "" && (rest[0] == '+' || rest[0] == '-') {
+				return readLayoutOffset(rest)
+			}
+			return rest, time.UTC, nil
+		}
+	}
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		return readLayoutOffset(s)
+	}
+	return s, nil, fmt.Errorf("expected UTC, GMT, or an offset at %q; other zone names are ambiguous", s)
+}
+
+// fixedOffset is the zone of an offset, UTC itself for a zero one.
+func fixedOffset(seconds int) *time.Location {
+	if seconds == 0 {
+		return time.UTC
+	}
+	return time.FixedZone("", seconds)
+}
+
+// time puts the fields together, refusing the ones that name no real date or
+// time rather than rolling them over the way time.Date does.
+func (f *dateFields) time() (time.Time, error) {
+	zone := f.zone
+	if zone == nil {
+		zone = time.UTC
+	}
+	if f.unix != nil {
+		return time.Unix(*f.unix, 0).In(zone), nil
+	}
+	if f.hour12 {
+		if f.hour < 1 || f.hour > 12 {
+			return time.Time{}, fmt.Errorf("hour %d is out of range 1 to 12", f.hour)
+		}
+		if f.hasPM {
+			f.hour %= 12
+			if f.pm {
+				f.hour += 12
+			}
+		}
+	}
+	switch {
+	case f.month < 1 || f.month > 12:
+		return time.Time{}, fmt.Errorf("month %d is out of range", f.month)
+	case f.hour > 23:
+		return time.Time{}, fmt.Errorf("hour %d is out of range", f.hour)
+	case f.minute > 59:
+		return time.Time{}, fmt.Errorf("minute %d is out of range", f.minute)
+	case f.second > 59:
+		return time.Time{}, fmt.Errorf("second %d is out of range", f.second)
+	}
+	if f.yearDay != 0 {
+		t := time.Date(f.year, time.January, f.yearDay, f.hour, f.minute, f.second, f.nano, zone)
+		if t.Year() != f.year {
+			return time.Time{}, fmt.Errorf("%d has no day %d", f.year, f.yearDay)
+		}
+		return t, nil
+	}
+	t := time.Date(f.year, time.Month(f.month), f.day, f.hour, f.minute, f.second, f.nano, zone)
+	if f.day < 1 || t.Day() != f.day {
+		return time.Time{}, fmt.Errorf("%s %d has no day %d", time.Month(f.month), f.year, f.day)
+	}
+	return t, nil
+}
+
+// Format writes t with the layout.
+func (l DateLayout) Format(t time.Time) string {
+	var b strings.Builder
+	for _, e := range l.elements {
+		switch e.directive {
+		case 0:
+			b.WriteString(e.literal)
+		case 'Y':
+			fmt.Fprintf(&b, "%04d", t.Year())
+		case 'y':
+			fmt.Fprintf(&b, "%02d", t.Year()%100)
+		case 'm':
+			fmt.Fprintf(&b, "%02d", int(t.Month()))
+		case 'd':
+			fmt.Fprintf(&b, "%02d", t.Day())
+		case 'e':
+			fmt.Fprintf(&b, "%2d", t.Day())
+		case 'j':
+			fmt.Fprintf(&b, "%03d", t.YearDay())
+		case 'H':
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		case 'I':
+			fmt.Fprintf(&b, "%02d", (t.Hour()+11)%12+1)
+		case 'M':
+			fmt.Fprintf(&b, "%02d", t.Minute())
+		case 'S':
+			fmt.Fprintf(&b, "%02d", t.Second())
+		case 'f':
+			fmt.Fprintf(&b, "%06d", t.Nanosecond()/1000)
+		case 'p':
+			if t.Hour() < 12 {
+				b.WriteString("AM")
+			} else {
+				b.WriteString("PM")
+			}
+		case 'b', 'h':
+			b.WriteString(t.Month().String()[:3])
+		case 'B':
+			b.WriteString(t.Month().String())
+		case 'a':
+			b.WriteString(t.Weekday().String()[:3])
+		case 'A':
+			b.WriteString(t.Weekday().String())
+		case 'z':
+			b.WriteString(t.Format("-0700"))
+		case 'Z':
+			b.WriteString(t.Format("MST"))
+		case 's':
+			b.WriteString(strconv.FormatInt(t.Unix(), 10))
+		}
+	}
+	return b.String()
+}
+
+// ISO returns what the layout read from a value written as ISO 8601, in the
+// form SQLite's date functions read: the date alone when the layout has no time
+// of day, the date and time otherwise, with the fraction of a second when there
+// is one, and the offset when the layout read one.
+func (l DateLayout) ISO(t time.Time) string {
+	if !l.hasTime {
+		return t.Format(time.DateOnly)
+	}
+	return FormatISODateTime(t, l.hasZone)
+}
+
+// FormatISODateTime writes t as an ISO 8601 date and time, separated by a space
+// as SQLite writes them, with the fraction of a second when there is one. zoned
+// adds the UTC offset.
+func FormatISODateTime(t time.Time, zoned bool) string {
+	layout := "2006-01-02 15:04:05.999999999"
+	if zoned {
+		layout += "-07:00"
+	}
+	return t.Format(layout)
+}
+
+// isoDateTimeLayouts are the shapes ParseISODateTime reads: the ones SQLite's
+// own date functions read, with a T or a space between the date and the time
+// and an optional offset.
+var isoDateTimeLayouts = func() []string {
+	layouts := []string{time.DateOnly}
+	for _, sep := range []string{" ", "T"} {
+		for _, clock := range []string{"15:04", "15:04:05"} {
+			for _, zone := range []string{"", "Z07:00", "Z0700", " Z07:00"} {
+				layouts = append(layouts, "2006-01-02"+sep+clock+zone)
+			}
+		}
+	}
+	return layouts
+}()
+
+// errNotISODateTime is the reason ParseISODateTime gives for any value it does
+// not read; which of its shapes the value came closest to does not help.
+var errNotISODateTime = errors.New("not an ISO 8601 date or date and time")
+
+// ParseISODateTime reads a date, or a date and time, written as ISO 8601 the
+// way SQLite reads it. zoned reports whether the value carried an offset; one
+// that did not is read as UTC.
+func ParseISODateTime(value string) (t time.Time, zoned bool, err error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range isoDateTimeLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, strings.Contains(layout, "Z07"), nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("%q is %w", value, errNotISODateTime)
+}
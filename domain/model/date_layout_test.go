@@ -0,0 +1,173 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDateLayout_Parse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		layout string
+		value  string
+		want   string
+	}{
+		{"%m/%d/%Y", "03/14/2024", "2024-03-14"},
+		{"%m/%d/%Y", "3/4/2024", "2024-03-04"},
+		{"%d.%m.%Y %H:%M", "14.03.2024 10:22", "2024-03-14 10:22:00"},
+		{"%Y%m%d", "20240314", "2024-03-14"},
+		{"%a, %d %b %Y %H:%M:%S %Z", "Thu, 14 Mar 2024 09:00:00 GMT", "2024-03-14 09:00:00+00:00"},
+		{"%F %T", "2024-03-14 09:05:07", "2024-03-14 09:05:07"},
+		{"%F %T.%f", "2024-03-14 09:05:07.25", "2024-03-14 09:05:07.25"},
+		{"%d %B %Y", "1 february 2024", "2024-02-01"},
+		{"%m/%d/%y %I:%M %p", "03/14/24 12:30 AM", "2024-03-14 00:30:00"},
+		{"%m/%d/%y %I:%M %p", "03/14/69 1:30 pm", "1969-03-14 13:30:00"},
+		{"%Y-%m-%dT%H:%M:%S%z", "2024-03-14T09:00:00+0900", "2024-03-14 09:00:00+09:00"},
+		{"%Y-%m-%dT%H:%M:%S%z", "2024-03-14T09:00:00-05:30", "2024-03-14 09:00:00-05:30"},
+		{"%Y/%j", "2024/060", "2024-02-29"},
+		{"%Y-%m", "2024-03", "2024-03-01"},
+		{"%s", "1710406800", "2024-03-14 09:00:00"},
+		{"%e %b %Y", " 4 Mar 2024", "2024-03-04"},
+		{"100%% on %Y-%m-%d", "100% on 2024-03-14", "2024-03-14"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout+" "+tt.value, func(t *testing.T) {
+			t.Parallel()
+			layout, err := ParseDateLayout(tt.layout)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := layout.Parse(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if iso := layout.ISO(got); iso != tt.want {
+				t.Errorf("ISO(Parse(%q)) = %q, want %q", tt.value, iso, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateLayout_ParseErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		layout string
+		value  string
+		want   string
+	}{
+		{"%m/%d/%Y", "02/30/2024", "February 2024 has no day 30"},
+		{"%d/%m/%Y", "03/14/2024", "month 14 is out of range"},
+		{"%m/%d/%Y", "03-14-2024", `expected "/" at "-14-2024"`},
+		{"%Y-%m-%d", "2024-03-14 10:00", `" 10:00" is left over`},
+		{"%H:%M %d %b %Y", "25:00 1 Mar 2024", "hour 25 is out of range"},
+		{"%d %b %Y", "1 Foo 2024", "expected a month name"},
+		{"%Y %Z", "2024 CST", "other zone names are ambiguous"},
+		{"%Y/%j", "2023/366", "2023 has no day 366"},
+		{"%H:%M", "10:22", "has no year"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout+" "+tt.value, func(t *testing.T) {
+			t.Parallel()
+			layout, err := ParseDateLayout(tt.layout)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = layout.Parse(tt.value)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %v, want it to mention %q", tt.value, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateLayout_Errors(t *testing.T) {
+	t.Parallel()
+
+	for layout, want := range map[string]string{
+		"%Y-%q":   "unknown directive %q",
+		"%Y-%m-%": "ends in a lone %",
+	} {
+		if _, err := ParseDateLayout(layout); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseDateLayout(%q) error = %v, want it to mention %q", layout, err, want)
+		}
+	}
+}
+
+func TestDateLayout_Format(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2024, time.March, 4, 15, 7, 9, 250_000_000, time.FixedZone("", 9*3600))
+	tests := []struct {
+		layout string
+		want   string
+	}{
+		{"%d.%m.%Y", "04.03.2024"},
+		{"%e %B %y", " 4 March 24"},
+		{"%a %A %b", "Mon Monday Mar"},
+		{"%I:%M:%S %p", "03:07:09 PM"},
+		{"%T.%f", "15:07:09.250000"},
+		{"%j %z", "064 +0900"},
+		{"%D %R", "03/04/24 15:07"},
+		{"%H:%M only", "15:07 only"},
+		{"100%%", "100%"},
+	}
+	for _, tt := range tests {
+		layout, err := ParseDateLayout(tt.layout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := layout.Format(ts); got != tt.want {
+			t.Errorf("Format(%q) = %q, want %q", tt.layout, got, tt.want)
+		}
+	}
+}
+
+func TestParseISODateTime(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value string
+		want  string
+		zoned bool
+	}{
+		{"2024-03-14", "2024-03-14 00:00:00", false},
+		{"2024-03-14 09:05", "2024-03-14 09:05:00", false},
+		{"2024-03-14T09:05:07.5", "2024-03-14 09:05:07.5", false},
+		{"2024-03-14T09:05:07Z", "2024-03-14 09:05:07+00:00", true},
+		{"2024-03-14 09:05:07+09:00", "2024-03-14 09:05:07+09:00", true},
+	}
+	for _, tt := range tests {
+		got, zoned, err := ParseISODateTime(tt.value)
+		if err != nil {
+			t.Fatalf("ParseISODateTime(%q): %v", tt.value, err)
+		}
+		if s := FormatISODateTime(got, zoned); s != tt.want || zoned != tt.zoned {
+			t.Errorf("ParseISODateTime(%q) = %q, %v; want %q, %v", tt.value, s, zoned, tt.want, tt.zoned)
+		}
+	}
+	if _, _, err := ParseISODateTime("03/14/2024"); err == nil {
+		t.Error("ParseISODateTime(03/14/2024) succeeded, want an error")
+	}
+}
+
+func TestParseUTCOffset(t *testing.T) {
+	t.Parallel()
+
+	for value, want := range map[string]int{"+09:00": 9 * 3600, "-0530": -(5*3600 + 30*60), "Z": 0, "+02": 2 * 3600} {
+		zone, err := ParseUTCOffset(value)
+		if err != nil {
+			t.Fatalf("ParseUTCOffset(%q): %v", value, err)
+		}
+		if _, offset := time.Date(2024, 1, 1, 0, 0, 0, 0, zone).Zone(); offset != want {
+			t.Errorf("ParseUTCOffset(%q) offset = %d, want %d", value, offset, want)
+		}
+	}
+	for _, value := range []string{"+25:00", "+09:00x", "0900"} {
+		if _, err := ParseUTCOffset(value); err == nil {
+			t.Errorf("ParseUTCOffset(%q) succeeded, want an error", value)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number: an integer count of units of 10^-scale.
+// 19.90 is 1990 units of a hundredth, and stays 19.90 — not 19.9, and not the
+// nearest binary fraction — through every sum and product.
+//
+// A Decimal is never changed once made; every operation returns a new one.
+type Decimal struct {
+	// unscaled is the value times 10^scale. Nil is zero.
+	unscaled *big.Int
+	// scale is how many digits follow the decimal point.
+	scale int
+}
+
+// maxDecimalExponent bounds the exponent ParseDecimal reads, so a value such as
+// 1e999999999 is refused rather than spelt out a billion digits long.
+const maxDecimalExponent = 1000
+
+// errNotDecimal is why a value that is not a number cannot be a decimal.
+var errNotDecimal = errors.New("not a decimal number")
+
+// ParseDecimal reads a decimal number: an optional sign, digits with an
+// optional decimal point, and an optional exponent, such as -19.90, .5, or
+// 1.5e3. The surrounding whitespace is ignored. The digits after the point are
+// kept, trailing zeros and all, since 19.90 says it was counted to the cent.
+func ParseDecimal(s string) (Decimal, error) {
+	text := strings.TrimSpace(s)
+	mantissa, exponentText, hasExponent := strings.Cut(strings.ToLower(text), "e")
+	negative := false
+	if mantissa != "" && (mantissa[0] == '+' || mantissa[0] == '-') {
+		negative = mantissa[0] == '-'
+		mantissa = mantissa[1:]
+	}
+	whole, fraction, _ := strings.Cut(mantissa, ".")
+	digits := whole + fraction
+	if digits == "" || strings.ContainsFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) {
+		return Decimal{}, fmt.Errorf("%q is %w", s, errNotDecimal)
+	}
+	scale := len(fraction)
+	if hasExponent {
+		exponent, err := strconv.Atoi(exponentText)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("%q is %w", s, errNotDecimal)
+		}
+		if exponent > maxDecimalExponent || exponent < -maxDecimalExponent {
+			return Decimal{}, fmt.Errorf("%q has an exponent past %d, which no decimal column holds", s, maxDecimalExponent)
+		}
+		scale -= exponent
+	}
+	unscaled, _ := new(big.Int).SetString(digits, 10)
+	if negative {
+		unscaled.Neg(unscaled)
+	}
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{unscaled: unscaled, scale: scale}, nil
+}
+
+// pow10 returns 10^n.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// int returns the unscaled value, reading nil as zero.
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// Scale returns how many digits follow the decimal point.
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Unscaled returns the value times 10^Scale, as a new integer.
+func (d Decimal) Unscaled() *big.Int {
+	return new(big.Int).Set(d.int())
+}
+
+// IntegerDigits returns how many digits the value has before the point, not
+// counting the zero of a value below one: DECIMAL(2,2) holds 0.05.
+func (d Decimal) IntegerDigits() int {
+	if d.int().Sign() == 0 {
+		return 0
+	}
+	return max(len(new(big.Int).Abs(d.int()).String())-d.scale, 0)
+}
+
+// String writes the value in its canonical form: a minus sign only for a value
+// below zero, no leading zeros but the one before the point, and every digit of
+// the scale after it.
+func (d Decimal) String() string {
+	n := d.int()
+	digits := new(big.Int).Abs(n).String()
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+	if n.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// rescaled returns the unscaled value at a larger scale.
+func (d Decimal) rescaled(scale int) *big.Int {
+	return new(big.Int).Mul(d.int(), pow10(scale-d.scale))
+}
+
+// Add returns d + o, at the larger of the two scales.
+func (d Decimal) Add(o Decimal) Decimal {
+	scale := max(d.scale, o.scale)
+	return Decimal{unscaled: new(big.Int).Add(d.rescaled(scale), o.rescaled(scale)), scale: scale}
+}
+
+// Sub returns d - o, at the larger of the two scales.
+func (d Decimal) Sub(o Decimal) Decimal {
+	scale := max(d.scale, o.scale)
+	return Decimal{unscaled: new(big.Int).Sub(d.rescaled(scale), o.rescaled(scale)), scale: scale}
+}
+
+// Mul returns d × o, at the sum of the two scales, so no digit of the product
+// is lost.
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), o.int()), scale: d.scale + o.scale}
+}
+
+// Round returns d rounded to places digits after the point, half away from
+// zero as SQLite's round does, so 2.345 is 2.35 and -2.345 is -2.35. The result
+// has exactly places digits after the point: 19.9 rounded to 2 is 19.90.
+func (d Decimal) Round(places int) Decimal {
+	if places >= d.scale {
+		return Decimal{unscaled: d.rescaled(places), scale: places}
+	}
+	divisor := pow10(d.scale - places)
+	quotient, remainder := new(big.Int).QuoRem(d.int(), divisor, new(big.Int))
+	// Twice the remainder against the divisor decides the half, exactly.
+	if remainder.Abs(remainder).Lsh(remainder, 1).Cmp(divisor) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(d.int().Sign())))
+	}
+	return Decimal{unscaled: quotient, scale: places}
+}
+
+// ColumnTypeDecimal is the declared type of an exact decimal column, written
+// DECIMAL for one that keeps the scale each value was written with, or
+// DECIMAL(p,s) for one that holds p digits, s of them after the point.
+const ColumnTypeDecimal = "DECIMAL"
+
+// decimalStorageType is the type SQLite is told a decimal column has.
+//
+// DECIMAL itself has NUMERIC affinity, which converts "19.90" to the REAL 19.9
+// on the way in — the loss the column is declared to prevent. A type whose
+// name contains TEXT has TEXT affinity, and keeps the value as it was written,
+// so the column is created as DECIMAL TEXT, and read back as DECIMAL.
+const decimalStorageType = "DECIMAL TEXT"
+
+// maxDecimalPrecision is the most digits a DECIMAL(p,s) may declare, which is
+// PostgreSQL's limit.
+const maxDecimalPrecision = 1000
+
+// DecimalType is a declared DECIMAL or DECIMAL(p,s) column type.
+type DecimalType struct {
+	// Precision is how many digits a value may have, and Scale how many of
+	// them follow the point. Precision 0 is a plain DECIMAL, which takes any
+	// value and keeps its scale.
+	Precision int
+	Scale     int
+}
+
+// ParseDecimalType reads a declared type as a decimal type: DECIMAL,
+// DECIMAL(p), or DECIMAL(p,s), in any case and with any spacing, or the DECIMAL
+// TEXT form a decimal column is stored with. It reports false for any other
+// type, and for a precision or scale a column could not have.
+func ParseDecimalType(typeName string) (DecimalType, bool) {
+	name, args, hasArgs := strings.Cut(strings.ToUpper(typeName), "(")
+	switch strings.Join(strings.Fields(name), " ") {
+	case ColumnTypeDecimal, decimalStorageType:
+	default:
+		return DecimalType{}, false
+	}
+	if !hasArgs {
+		return DecimalType{}, true
+	}
+	args, ok := strings.CutSuffix(strings.TrimSpace(args), ")")
+	if !ok {
+		return DecimalType{}, false
+	}
+	precisionText, scaleText, hasScale := strings.Cut(args, ",")
+	precision, err := strconv.Atoi(strings.TrimSpace(precisionText))
+	if err != nil || precision < 1 || precision > maxDecimalPrecision {
+		return DecimalType{}, false
+	}
+	scale := 0
+	if hasScale {
+		if scale, err = strconv.Atoi(strings.TrimSpace(scaleText)); err != nil || scale < 0 || scale > precision {
+			return DecimalType{}, false
+		}
+	}
+	return DecimalType{Precision: precision, Scale: scale}, true
+}
+
+// IsDecimalColumnType reports whether a declared type is a decimal type, the
+// way SQLite reports the declared type of a column a query reads.
+func IsDecimalColumnType(typeName string) bool {
+	_, ok := ParseDecimalType(typeName)
+	return ok
+}
+
+// String is the type as a user declares it.
+func (t DecimalType) String() string {
+	return ColumnTypeDecimal + t.arguments()
+}
+
+// StorageType is the type SQLite is told the column has.
+func (t DecimalType) StorageType() string {
+	return decimalStorageType + t.arguments()
+}
+
+// arguments writes the precision and scale in parentheses, or nothing for a
+// plain DECIMAL.
+func (t DecimalType) arguments() string {
+	if t.Precision == 0 {
+		return ""
+	}
+	return fmt.Sprintf("(%d,%d)", t.Precision, t.Scale)
+}
+
+// dialectType is the type a decimal column is declared with in a script for
+// another database. DECIMAL(p,s) means the same in both. A plain DECIMAL is an
+// unconstrained NUMERIC in PostgreSQL; MySQL has no unconstrained decimal, and
+// its plain DECIMAL is DECIMAL(10,0), which would drop every fraction, so it
+// gets the widest one MySQL has.
+func (t DecimalType) dialectType(d SQLDialect) string {
+	switch {
+	case t.Precision > 0:
+		return t.String()
+	case d == SQLDialectMySQL:
+		return "DECIMAL(65,30)"
+	default:
+		return "NUMERIC"
+	}
+}
+
+// Canonical reads value and writes it as a column of the type stores it: in
+// canonical form, and for DECIMAL(p,s) with exactly s digits after the point. A
+// value with a nonzero digit past the scale, or more digits before the point
+// than p-s, is refused rather than rounded or cut: a column of amounts that
+// quietly drops a fraction of a cent is the drift the type is there to stop.
+func (t DecimalType) Canonical(value string) (string, error) {
+	d, err := ParseDecimal(value)
+	if err != nil {
+		return "", err
+	}
+	if t.Precision == 0 {
+		return d.String(), nil
+	}
+	if d.scale > t.Scale {
+		if rounded := d.Round(t.Scale); rounded.rescaled(d.scale).Cmp(d.int()) != 0 {
+			return "", fmt.Errorf("%q has more digits after the point than the %d %s holds", value, t.Scale, t)
+		}
+	}
+	d = d.Round(t.Scale)
+	if d.IntegerDigits() > t.Precision-t.Scale {
+		return "", fmt.Errorf("%q has more digits before the point than the %d %s holds", value, t.Precision-t.Scale, t)
+	}
+	return d.String(), nil
+}
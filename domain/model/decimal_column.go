@@ -0,0 +1,76 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DecimalColumn names a column of CSV or TSV input that is read as an exact
+// decimal: stored as its canonical text, in a column declared DECIMAL, rather
+// than inferred as a REAL.
+type DecimalColumn struct {
+	// Table is the table the column is in. It is empty for a column read that
+	// way in every input that has it.
+	Table string
+	// Column is the column's name, matched without ASCII case the way SQLite
+	// matches column names.
+	Column string
+}
+
+// String is the column as a user writes it: [TABLE.]COLUMN.
+func (c DecimalColumn) String() string {
+	if c.Table == "" {
+		return c.Column
+	}
+	return c.Table + "." + c.Column
+}
+
+// DecimalColumns is the set of columns --decimal-columns names.
+type DecimalColumns []DecimalColumn
+
+// ParseDecimalColumns parses the --decimal-columns form: comma-separated
+// [TABLE.]COLUMN entries, such as "amount,orders.fee". As in --column-type, the
+// table is everything before the first dot.
+func ParseDecimalColumns(spec string) (DecimalColumns, error) {
+	var columns DecimalColumns
+	for entry := range strings.SplitSeq(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		table, column, qualified := strings.Cut(entry, ".")
+		if !qualified {
+			table, column = "", entry
+		}
+		table, column = strings.TrimSpace(table), strings.TrimSpace(column)
+		if column == "" || (qualified && table == "") {
+			return nil, fmt.Errorf("invalid decimal column %q: want [TABLE.]COLUMN, such as amount", entry)
+		}
+		for _, previous := range columns {
+			if strings.EqualFold(previous.Table, table) && strings.EqualFold(previous.Column, column) {
+				return nil, fmt.Errorf("invalid decimal column %q: the column is already named", entry)
+			}
+		}
+		columns = append(columns, DecimalColumn{Table: table, Column: column})
+	}
+	return columns, nil
+}
+
+// ForTable returns the names, in lower case, of the columns that are read as
+// decimals in a table: those naming the table, compared without ASCII case,
+// and those naming none.
+func (c DecimalColumns) ForTable(table string) map[string]bool {
+	names := map[string]bool{}
+	for _, column := range c {
+		if column.Table == "" || strings.EqualFold(column.Table, table) {
+			names[strings.ToLower(column.Column)] = true
+		}
+	}
+	return names
+}
+
+// String lists the columns comma-separated, in the form each was written.
+func (c DecimalColumns) String() string {
+	entries := make([]string, len(c))
+	for i, column := range c {
+		entries[i] = column.String()
+	}
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,170 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"19.90", "19.90"},
+		{" -0019.90 ", "-19.90"},
+		{"+.5", "0.5"},
+		{"7.", "7"},
+		{"1.5e3", "1500"},
+		{"15e-3", "0.015"},
+		{"-0.00", "0.00"},
+		{"12345678901234567.89", "12345678901234567.89"},
+	}
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.in)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q): %v", tt.in, err)
+			continue
+		}
+		if got := d.String(); got != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"", "-", ".", "1,200", "1.2.3", "0x10", "1e", "NaN", "1e1001"} {
+		if _, err := ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q) succeeded, want it refused", in)
+		}
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	t.Parallel()
+
+	parse := func(s string) Decimal {
+		t.Helper()
+		d, err := ParseDecimal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	if got := parse("0.1").Add(parse("0.2")).String(); got != "0.3" {
+		t.Errorf("0.1 + 0.2 = %s, want 0.3", got)
+	}
+	if got := parse("19.99").Add(parse("-20")).String(); got != "-0.01" {
+		t.Errorf("19.99 + -20 = %s, want -0.01", got)
+	}
+	if got := parse("1.10").Sub(parse("0.1")).String(); got != "1.00" {
+		t.Errorf("1.10 - 0.1 = %s, want 1.00", got)
+	}
+	if got := parse("19.99").Mul(parse("0.08")).String(); got != "1.5992" {
+		t.Errorf("19.99 × 0.08 = %s, want 1.5992", got)
+	}
+
+	rounds := []struct {
+		in     string
+		places int
+		want   string
+	}{
+		{"2.345", 2, "2.35"},
+		{"-2.345", 2, "-2.35"},
+		{"2.344", 2, "2.34"},
+		{"19.9", 2, "19.90"},
+		{"0.5", 0, "1"},
+		{"-0.4", 0, "0"},
+	}
+	for _, tt := range rounds {
+		if got := parse(tt.in).Round(tt.places).String(); got != tt.want {
+			t.Errorf("Round(%s, %d) = %s, want %s", tt.in, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestParseDecimalType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    string
+		storage string
+	}{
+		{"decimal", "DECIMAL", "DECIMAL TEXT"},
+		{"DECIMAL(12, 2)", "DECIMAL(12,2)", "DECIMAL TEXT(12,2)"},
+		{"decimal(5)", "DECIMAL(5,0)", "DECIMAL TEXT(5,0)"},
+		{"DECIMAL TEXT(12,2)", "DECIMAL(12,2)", "DECIMAL TEXT(12,2)"},
+	}
+	for _, tt := range tests {
+		typ, ok := ParseDecimalType(tt.in)
+		if !ok || typ.String() != tt.want || typ.StorageType() != tt.storage {
+			t.Errorf("ParseDecimalType(%q) = %v, %v; want %s stored as %s", tt.in, typ, ok, tt.want, tt.storage)
+		}
+	}
+	for _, in := range []string{"NUMERIC", "DECIMAL(0,0)", "DECIMAL(2,3)", "DECIMAL(12,-1)", "DECIMAL(12,2", "DECIMALS"} {
+		if _, ok := ParseDecimalType(in); ok {
+			t.Errorf("ParseDecimalType(%q) succeeded, want it refused", in)
+		}
+	}
+}
+
+func TestDecimalType_Canonical(t *testing.T) {
+	t.Parallel()
+
+	money := DecimalType{Precision: 5, Scale: 2}
+	tests := []struct {
+		typ  DecimalType
+		in   string
+		want string
+	}{
+		{money, "19.9", "19.90"},
+		{money, "19.900", "19.90"},
+		{money, "0.05", "0.05"},
+		{money, "-999.99", "-999.99"},
+		{DecimalType{Precision: 2, Scale: 2}, "0.05", "0.05"},
+		{DecimalType{}, "019.900", "19.900"},
+	}
+	for _, tt := range tests {
+		got, err := tt.typ.Canonical(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("%s.Canonical(%q) = %q, %v; want %q", tt.typ, tt.in, got, err, tt.want)
+		}
+	}
+
+	refusals := []struct {
+		in   string
+		want string
+	}{
+		{"19.905", "has more digits after the point than the 2 DECIMAL(5,2) holds"},
+		{"1000", "has more digits before the point than the 3 DECIMAL(5,2) holds"},
+		{"N/A", "is not a decimal number"},
+	}
+	for _, tt := range refusals {
+		if _, err := money.Canonical(tt.in); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Canonical(%q) error = %v, want it to mention %q", tt.in, err, tt.want)
+		}
+	}
+}
+
+func TestParseDecimalColumns(t *testing.T) {
+	t.Parallel()
+
+	columns, err := ParseDecimalColumns("amount, orders.fee")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := columns.String(); got != "amount,orders.fee" {
+		t.Errorf("String() = %q, want amount,orders.fee", got)
+	}
+	if got := columns.ForTable("Orders"); len(got) != 2 || !got["amount"] || !got["fee"] {
+		t.Errorf("ForTable(Orders) = %v, want amount and fee", got)
+	}
+	if got := columns.ForTable("refunds"); len(got) != 1 || !got["amount"] {
+		t.Errorf("ForTable(refunds) = %v, want amount alone", got)
+	}
+
+	for _, spec := range []string{"", "amount,", ".fee", "orders.", "amount,AMOUNT"} {
+		if _, err := ParseDecimalColumns(spec); err == nil {
+			t.Errorf("ParseDecimalColumns(%q) succeeded, want it refused", spec)
+		}
+	}
+}
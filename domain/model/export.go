@@ -43,6 +43,12 @@ const (
 	ExportJSONL
 	// ExportParquet exports data as Apache Parquet
 	ExportParquet
+	// ExportXML exports data as an XML document with one element per row
+	ExportXML
+	// ExportSQL exports data as a CREATE TABLE and INSERT script
+	ExportSQL
+	// ExportSQLite exports data as a table in a SQLite database file
+	ExportSQLite
 )
 
 // String returns the string representation of the ExportFormat.
@@ -64,6 +70,12 @@ func (e ExportFormat) String() string {
 		return formatJSONL
 	case ExportParquet:
 		return formatParquet
+	case ExportXML:
+		return formatXML
+	case ExportSQL:
+		return formatSQL
+	case ExportSQLite:
+		return formatSQLite
 	}
 	return formatCSV
 }
@@ -87,16 +99,24 @@ func (e ExportFormat) Extension() string {
 		return ExtJSONL
 	case ExportParquet:
 		return ExtParquet
+	case ExportXML:
+		return ExtXML
+	case ExportSQL:
+		return ExtSQL
+	case ExportSQLite:
+		return ExtSQLite
 	}
 	return ExtCSV
 }
 
 // SupportsCompression reports whether output of this format can be wrapped in a
-// compression codec. Binary container formats (Parquet, Excel) carry their own
-// encoding and are not wrapped, so they return false.
+// compression codec. Binary container formats (Parquet, Excel, SQLite) carry
+// their own encoding and are not wrapped, so they return false. A SQLite
+// database in particular is read in place, and a compressed one is not a
+// database anything can open.
 func (e ExportFormat) SupportsCompression() bool {
 	switch e {
-	case ExportParquet, ExportExcel:
+	case ExportParquet, ExportExcel, ExportSQLite:
 		return false
 	default:
 		return true
@@ -106,7 +126,8 @@ func (e ExportFormat) SupportsCompression() bool {
 // ExportFormatFromExtension maps a base file extension (e.g. ".csv") to an
 // ExportFormat. The bool is false when the extension is not a known export
 // format, so callers can fall back instead of guessing. Matching is
-// case-insensitive. ".jsonl" maps to NDJSON since JSON Lines is newline-delimited.
+// case-insensitive. ".jsonl" maps to NDJSON since JSON Lines is newline-delimited,
+// and ".sqlite3" and ".db" map to SQLite along with ".sqlite".
 func ExportFormatFromExtension(ext string) (ExportFormat, bool) {
 	switch strings.ToLower(ext) {
 	case ExtCSV:
@@ -125,6 +146,12 @@ func ExportFormatFromExtension(ext string) (ExportFormat, bool) {
 		return ExportJSONL, true
 	case ExtParquet:
 		return ExportParquet, true
+	case ExtXML:
+		return ExportXML, true
+	case ExtSQL:
+		return ExportSQL, true
+	case ExtSQLite, ExtSQLite3, ExtDB:
+		return ExportSQLite, true
 	default:
 		return ExportCSV, false
 	}
@@ -410,6 +437,12 @@ func ExportFormatFromPrintMode(m PrintMode) ExportFormat {
 		return ExportJSONL
 	case PrintModeParquet:
 		return ExportParquet
+	case PrintModeXML:
+		return ExportXML
+	case PrintModeSQL:
+		return ExportSQL
+	case PrintModeSQLite:
+		return ExportSQLite
 	default:
 		return ExportCSV
 	}
@@ -23,6 +23,9 @@ func TestExportFormat_String(t *testing.T) {
 		{name: "json", ef: ExportJSON, want: "json"},
 		{name: "jsonl", ef: ExportJSONL, want: "jsonl"},
 		{name: "parquet", ef: ExportParquet, want: "parquet"},
+		{name: "xml", ef: ExportXML, want: "xml"},
+		{name: "sql", ef: ExportSQL, want: "sql"},
+		{name: "sqlite", ef: ExportSQLite, want: "sqlite"},
 		{name: "unknown defaults to csv", ef: ExportFormat(99), want: "csv"},
 	}
 	for _, tt := range tests {
@@ -51,6 +54,9 @@ func TestExportFormat_Extension(t *testing.T) {
 		{name: "json", ef: ExportJSON, want: ".json"},
 		{name: "jsonl", ef: ExportJSONL, want: ".jsonl"},
 		{name: "parquet", ef: ExportParquet, want: ".parquet"},
+		{name: "xml", ef: ExportXML, want: ".xml"},
+		{name: "sql", ef: ExportSQL, want: ".sql"},
+		{name: "sqlite", ef: ExportSQLite, want: ".sqlite"},
 		{name: "unknown defaults to .csv", ef: ExportFormat(99), want: ".csv"},
 	}
 	for _, tt := range tests {
@@ -81,6 +87,10 @@ func TestExportFormatFromExtension(t *testing.T) {
 		{name: ".ndjson maps to ndjson", ext: ".ndjson", want: ExportJSONL, wantOK: true},
 		{name: ".jsonl maps to ndjson", ext: ".jsonl", want: ExportJSONL, wantOK: true},
 		{name: ".parquet maps to parquet", ext: ".parquet", want: ExportParquet, wantOK: true},
+		{name: ".xml maps to xml", ext: ".xml", want: ExportXML, wantOK: true},
+		{name: ".sql maps to sql", ext: ".sql", want: ExportSQL, wantOK: true},
+		{name: ".sqlite maps to sqlite", ext: ".sqlite", want: ExportSQLite, wantOK: true},
+		{name: ".db maps to sqlite", ext: ".db", want: ExportSQLite, wantOK: true},
 		{name: "uppercase .CSV maps to csv", ext: ".CSV", want: ExportCSV, wantOK: true},
 		{name: "unknown extension is not recognized", ext: ".txt", want: ExportCSV, wantOK: false},
 		{name: "empty extension is not recognized", ext: "", want: ExportCSV, wantOK: false},
@@ -148,8 +158,11 @@ func TestExportFormat_SupportsCompression(t *testing.T) {
 		{name: "json supports compression", ef: ExportJSON, want: true},
 		{name: "ndjson supports compression", ef: ExportJSONL, want: true},
 		{name: "markdown supports compression", ef: ExportMarkdown, want: true},
+		{name: "xml supports compression", ef: ExportXML, want: true},
+		{name: "sql supports compression", ef: ExportSQL, want: true},
 		{name: "parquet does not support compression", ef: ExportParquet, want: false},
 		{name: "excel does not support compression", ef: ExportExcel, want: false},
+		{name: "sqlite does not support compression", ef: ExportSQLite, want: false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -369,7 +382,8 @@ func TestBuildOutputPath(t *testing.T) {
 func TestBuildOutputPath_Property(t *testing.T) {
 	formats := []ExportFormat{
 		ExportCSV, ExportTSV, ExportLTSV, ExportMarkdown,
-		ExportExcel, ExportJSON, ExportJSONL, ExportParquet,
+		ExportExcel, ExportJSON, ExportJSONL, ExportParquet, ExportXML, ExportSQL,
+		ExportSQLite,
 	}
 	comps := []Compression{
 		CompressionNone, CompressionGzip, CompressionXz, CompressionZstd,
@@ -413,6 +427,9 @@ func TestExportFormatFromPrintMode(t *testing.T) {
 		{name: "json", mode: PrintModeJSON, want: ExportJSON},
 		{name: "jsonl", mode: PrintModeJSONL, want: ExportJSONL},
 		{name: "parquet", mode: PrintModeParquet, want: ExportParquet},
+		{name: "xml", mode: PrintModeXML, want: ExportXML},
+		{name: "sql", mode: PrintModeSQL, want: ExportSQL},
+		{name: "sqlite", mode: PrintModeSQLite, want: ExportSQLite},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -26,6 +26,9 @@ const (
 	formatJSONL    = "jsonl"
 	formatParquet  = "parquet"
 	formatVertical = "vertical"
+	formatXML      = "xml"
+	formatSQL      = "sql"
+	formatSQLite   = "sqlite"
 )
 
 // Extension name constants.
@@ -39,6 +42,11 @@ const (
 	ExtJSONL    = ".jsonl"
 	ExtNDJSON   = ".ndjson"
 	ExtParquet  = ".parquet"
+	ExtXML      = ".xml"
+	ExtSQL      = ".sql"
+	ExtSQLite   = ".sqlite"
+	ExtSQLite3  = ".sqlite3"
+	ExtDB       = ".db"
 )
 
 // PrintMode is enum to specify output method
@@ -69,17 +77,26 @@ const (
 	// table, csv, tsv, and ltsv modes all fail at, and a 300-column row is the case
 	// sqly exists for.
 	PrintModeVertical
+	// PrintModeXML prints data as an XML document with one <row> element per
+	// record.
+	PrintModeXML
+	// PrintModeSQL prints data as a script of a CREATE TABLE statement and the
+	// INSERT statements that fill it.
+	PrintModeSQL
+	// PrintModeSQLite is an export-only mode, like Parquet: on screen it renders
+	// like CSV, and only --output or .dump writes the SQLite database file.
+	PrintModeSQLite
 )
 
 // printModes pairs each mode with the name a user types for it, in the order
 // --help, .mode's error, and completion all list them: the formats a person
-// reads first, then the two that only make sense written to a file.
+// reads first, then the three that only make sense written to a file.
 //
 // The order is part of the registry rather than each caller's business, so the
 // three lists a user might compare cannot disagree about it either.
 //
-// selectable says whether .mode can choose the format. Excel and Parquet cannot:
-// neither can be rendered to a terminal, so selecting one used to leave the
+// selectable says whether .mode can choose the format. Excel, Parquet, and
+// SQLite cannot: none can be rendered to a terminal, so selecting one used to leave the
 // session printing CSV while calling itself something else — a banner had to
 // admit it was "same as csv mode", and a script scraping that output depended on
 // excel meaning csv forever. They stay in the registry because --output-format
@@ -97,9 +114,12 @@ var printModes = []struct {
 	{PrintModeLTSV, formatLTSV, true},
 	{PrintModeJSON, formatJSON, true},
 	{PrintModeJSONL, formatJSONL, true},
+	{PrintModeXML, formatXML, true},
+	{PrintModeSQL, formatSQL, true},
 	{PrintModeMarkdownTable, formatMarkdown, true},
 	{PrintModeExcel, formatExcel, false},
 	{PrintModeParquet, formatParquet, false},
+	{PrintModeSQLite, formatSQLite, false},
 }
 
 // unknownPrintModeName is what String reports for a value that is not one of the
@@ -158,7 +178,7 @@ func SelectableModes() []PrintMode {
 
 // ParseSelectableMode returns the mode a user named to .mode, and whether the
 // name is one .mode can select. A format that only names a file (excel,
-// parquet) is not: it reports false, like an unknown name, so the caller says
+// parquet, sqlite) is not: it reports false, like an unknown name, so the caller says
 // what .mode takes rather than accepting a mode the screen cannot show.
 func ParseSelectableMode(name string) (PrintMode, bool) {
 	mode, ok := ParsePrintMode(name)
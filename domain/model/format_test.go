@@ -38,14 +38,14 @@ func TestPrintModeRoundTrips(t *testing.T) {
 func TestPrintModesCoversEveryDeclaredMode(t *testing.T) {
 	t.Parallel()
 
-	// PrintModeVertical is the last constant declared; update this when a mode is
+	// PrintModeSQLite is the last constant declared; update this when a mode is
 	// added after it.
-	for mode := PrintModeTable; mode <= PrintModeVertical; mode++ {
+	for mode := PrintModeTable; mode <= PrintModeSQLite; mode++ {
 		if mode.String() == unknownPrintModeName {
 			t.Errorf("PrintMode %d is declared but has no registry entry, so no flag can name it", mode)
 		}
 	}
-	if got, want := len(printModes), int(PrintModeVertical)+1; got != want {
+	if got, want := len(printModes), int(PrintModeSQLite)+1; got != want {
 		t.Errorf("the mode registry has %d entries, want %d (one per declared mode)", got, want)
 	}
 }
@@ -112,7 +112,7 @@ func TestPrintModeNamesListsEveryMode(t *testing.T) {
 func TestSelectableModesAreTheOnesAScreenCanShow(t *testing.T) {
 	t.Parallel()
 
-	wantSelectable := []string{"table", "vertical", "csv", "tsv", "ltsv", "json", "jsonl", "markdown"}
+	wantSelectable := []string{"table", "vertical", "csv", "tsv", "ltsv", "json", "jsonl", "xml", "sql", "markdown"}
 	wantOutputOnly := []string{"excel", "parquet"}
 
 	got := strings.Split(SelectableModeNames(), ", ")
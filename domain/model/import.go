@@ -20,6 +20,39 @@ type ImportError struct {
 func (e *ImportError) Error() string { return e.Path + ": " + e.Err.Error() }
 func (e *ImportError) Unwrap() error { return e.Err }
 
+// FileLoad is one input of an import: the file the loader reads, and what the
+// import declares for the tables it creates.
+//
+// The declarations travel with the file rather than being set on the loader
+// beforehand. Set beforehand, they were state "the next import" would pick up,
+// and a file a query reads in the middle of a statement had to clear the ones
+// an import had set, and leave them cleared for whatever imported after it.
+type FileLoad struct {
+	// Path is the file handed to the loader: the file the user named, or the
+	// staged copy of a download, a re-encoded text file, or a pseudo-file.
+	Path string
+	// ColumnTypes are the types the input's tables are declared with in place
+	// of the inferred ones. A value that cannot convert to a declared numeric
+	// type fails the import.
+	ColumnTypes ColumnTypes
+	// PrimaryKeys and Indexes are the keys the input's tables are declared
+	// with. A row that repeats a declared primary key, or has no value for it,
+	// fails the import.
+	PrimaryKeys TableKeys
+	Indexes     TableKeys
+	// SchemaSource is the file whose schema sidecar applies, when Path is a
+	// staged copy of it and the sidecar is beside the original. Empty looks
+	// beside Path.
+	SchemaSource string
+	// NoSchema is set for an input with nowhere to look for a sidecar: a
+	// download has no directory of its own.
+	NoSchema bool
+	// Dialect is how a CSV or TSV input is written, when its delimiter, quote,
+	// comments, leading lines, or header differ from its format's. The zero
+	// value is the format's own, and any other format ignores it.
+	Dialect CSVDialect
+}
+
 // SkippedRows is how much of one table's input the row-mismatch policy dropped.
 //
 // A skip is what the user asked for with --row-mismatch skip, so it is not a
@@ -0,0 +1,145 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ImportCleaning is what is done to each value of a CSV or TSV input as it is
+// read, before type inference sees it: the whitespace around it trimmed, the
+// placeholders a file writes for a missing value read as SQL NULL, the dates a
+// file writes its own way rewritten as ISO 8601, and the amounts that must stay
+// exact kept from being inferred as REAL.
+//
+// A file says "no value" in its own words — NA, N/A, -, null, or nothing at all
+// — and every one of them used to load as text. A single NA in a column of
+// numbers made the column TEXT, and the empty cells in an INTEGER column were
+// empty strings that sum() counted and IS NULL did not find. The zero value
+// cleans nothing, so an input is read byte for byte as it always was.
+type ImportCleaning struct {
+	// Trim strips the whitespace around every value and column name.
+	Trim bool
+	// NullValues are the values read as NULL, matched exactly, after trimming
+	// when Trim is set. An empty field is always one of them once any is named,
+	// because an empty cell is the only way a CSV file has of leaving a value
+	// out. Nil reads no value as NULL, which is not the same as a list holding
+	// only the empty string: that one reads empty fields as NULL and nothing
+	// else.
+	NullValues []string
+	// Dates are the columns whose dates are written in a layout of the file's
+	// own, read with it and staged as ISO 8601, so an import infers them as
+	// dates and SQLite's date functions can read them.
+	Dates DateColumns
+	// Decimals are the columns read as exact decimals, and stored as their
+	// canonical text in a column declared DECIMAL.
+	Decimals DecimalColumns
+}
+
+// ParseNullValues reads a --null-values list: the values, separated by commas.
+// An empty value, or an empty list, names the empty field, which the list
+// covers anyway; it lets --null-values "" ask for empty fields alone.
+func ParseNullValues(value string) []string {
+	values := strings.Split(value, ",")
+	if !slices.Contains(values, "") {
+		values = append(values, "")
+	}
+	return values
+}
+
+// IsZero reports whether the cleaning leaves every value as it is.
+func (c ImportCleaning) IsZero() bool {
+	return !c.Trim && c.NullValues == nil && len(c.Dates) == 0 && len(c.Decimals) == 0
+}
+
+// MapsNulls reports whether any value is read as NULL.
+func (c ImportCleaning) MapsNulls() bool {
+	return c.NullValues != nil
+}
+
+// Header returns a column name as the cleaning reads it. A name is trimmed but
+// never read as NULL: a column has to be called something.
+func (c ImportCleaning) Header(name string) string {
+	if c.Trim {
+		return strings.TrimSpace(name)
+	}
+	return name
+}
+
+// Value returns a value as the cleaning reads it. A value read as NULL is
+// returned empty: a CSV file cannot write NULL, so that is how it is staged, and
+// an empty value is always one of the NULL values.
+func (c ImportCleaning) Value(value string) string {
+	if c.Trim {
+		value = strings.TrimSpace(value)
+	}
+	if c.MapsNulls() && slices.Contains(c.NullValues, value) {
+		return ""
+	}
+	return value
+}
+
+// String returns the cleaning as the flags that set it, such as
+// --trim --null-values "NA,-", for a record of what an import was read with.
+func (c ImportCleaning) String() string {
+	var options []string
+	if c.Trim {
+		options = append(options, "--trim")
+	}
+	if c.MapsNulls() {
+		var named []string
+		for _, v := range c.NullValues {
+			if v != "" {
+				named = append(named, v)
+			}
+		}
+		options = append(options, "--null-values "+strconv.Quote(strings.Join(named, ",")))
+	}
+	if len(c.Dates) > 0 {
+		options = append(options, "--date-columns "+strconv.Quote(c.Dates.String()))
+	}
+	if len(c.Decimals) > 0 {
+		options = append(options, "--decimal-columns "+strconv.Quote(c.Decimals.String()))
+	}
+	return strings.Join(options, " ")
+}
+
+// UnicodeNormalization is the Unicode normalization form text inputs are
+// brought to as they are read, or none.
+//
+// The same word can be two different strings: "é" typed on a Mac keyboard is
+// often e followed by a combining accent, and on Windows the one precomposed
+// character. They look identical and compare unequal, so a join or a GROUP BY
+// across files from the two split what a reader sees as one value.
+type UnicodeNormalization string
+
+const (
+	// UnicodeNormalizationNone reads text as it is.
+	UnicodeNormalizationNone UnicodeNormalization = ""
+	// UnicodeNormalizationNFC composes characters, the form most text is in.
+	UnicodeNormalizationNFC UnicodeNormalization = "nfc"
+	// UnicodeNormalizationNFD decomposes them.
+	UnicodeNormalizationNFD UnicodeNormalization = "nfd"
+	// UnicodeNormalizationNFKC composes them and folds compatibility
+	// characters, such as full-width digits, into their plain forms.
+	UnicodeNormalizationNFKC UnicodeNormalization = "nfkc"
+	// UnicodeNormalizationNFKD decomposes them and folds compatibility
+	// characters.
+	UnicodeNormalizationNFKD UnicodeNormalization = "nfkd"
+)
+
+// UnicodeNormalizationHelp lists the forms --normalize accepts.
+func UnicodeNormalizationHelp() string {
+	return "nfc|nfd|nfkc|nfkd"
+}
+
+// ParseUnicodeNormalization reads a --normalize value, in any case.
+func ParseUnicodeNormalization(value string) (UnicodeNormalization, error) {
+	switch form := UnicodeNormalization(strings.ToLower(strings.TrimSpace(value))); form {
+	case UnicodeNormalizationNFC, UnicodeNormalizationNFD, UnicodeNormalizationNFKC, UnicodeNormalizationNFKD:
+		return form, nil
+	default:
+		return UnicodeNormalizationNone, fmt.Errorf("invalid --normalize %q: want one of %s", value, UnicodeNormalizationHelp())
+	}
+}
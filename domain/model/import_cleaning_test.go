@@ -0,0 +1,86 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseNullValues(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{value: "NA,N/A,-", want: []string{"NA", "N/A", "-", ""}},
+		{value: "NA,,-", want: []string{"NA", "", "-"}},
+		{value: "", want: []string{""}},
+	}
+	for _, tt := range tests {
+		if got := ParseNullValues(tt.value); !slices.Equal(got, tt.want) {
+			t.Errorf("ParseNullValues(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestImportCleaning_Value(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		cleaning ImportCleaning
+		value    string
+		want     string
+	}{
+		{name: "zero keeps the value", cleaning: ImportCleaning{}, value: " NA ", want: " NA "},
+		{name: "trim", cleaning: ImportCleaning{Trim: true}, value: " a b\u00a0", want: "a b"},
+		{name: "a null value", cleaning: ImportCleaning{NullValues: ParseNullValues("NA")}, value: "NA", want: ""},
+		{name: "a null value is matched exactly", cleaning: ImportCleaning{NullValues: ParseNullValues("NA")}, value: "na", want: "na"},
+		{name: "untrimmed does not match", cleaning: ImportCleaning{NullValues: ParseNullValues("NA")}, value: " NA", want: " NA"},
+		{name: "trimmed matches", cleaning: ImportCleaning{Trim: true, NullValues: ParseNullValues("NA")}, value: " NA", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.cleaning.Value(tt.value); got != tt.want {
+				t.Errorf("Value(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	cleaning := ImportCleaning{Trim: true, NullValues: ParseNullValues("NA")}
+	if got := cleaning.Header(" NA "); got != "NA" {
+		t.Errorf("Header = %q, want the name trimmed and kept", got)
+	}
+}
+
+func TestImportCleaning_String(t *testing.T) {
+	t.Parallel()
+
+	if got := (ImportCleaning{}).String(); got != "" {
+		t.Errorf("zero String() = %q, want empty", got)
+	}
+	cleaning := ImportCleaning{Trim: true, NullValues: ParseNullValues("NA,-")}
+	if want := `--trim --null-values "NA,-"`; cleaning.String() != want {
+		t.Errorf("String() = %q, want %q", cleaning.String(), want)
+	}
+	if want := `--null-values ""`; (ImportCleaning{NullValues: ParseNullValues("")}).String() != want {
+		t.Errorf("String() of empty fields alone = %q, want %q", (ImportCleaning{NullValues: ParseNullValues("")}).String(), want)
+	}
+}
+
+func TestParseUnicodeNormalization(t *testing.T) {
+	t.Parallel()
+
+	if got, err := ParseUnicodeNormalization("NFC"); err != nil || got != UnicodeNormalizationNFC {
+		t.Errorf("ParseUnicodeNormalization(NFC) = %q, %v; want nfc", got, err)
+	}
+	if got, err := ParseUnicodeNormalization("nfkd"); err != nil || got != UnicodeNormalizationNFKD {
+		t.Errorf("ParseUnicodeNormalization(nfkd) = %q, %v; want nfkd", got, err)
+	}
+	for _, value := range []string{"", "nfx"} {
+		if _, err := ParseUnicodeNormalization(value); err == nil {
+			t.Errorf("ParseUnicodeNormalization(%q) accepted, want a refusal", value)
+		}
+	}
+}
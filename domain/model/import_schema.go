@@ -0,0 +1,142 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ImportSchemaVersion is the version of the schema sidecar format. Like
+// --inspect's schema_version it is the document's own version: it changes only
+// when a sidecar written for one sqly would be read differently by another.
+const ImportSchemaVersion = 1
+
+// ImportSchema is a schema sidecar: what a data file's tables are declared to
+// hold, kept beside the file as FILE.schema.json and applied whenever the file
+// is imported. It is the shape --inspect --format=schema writes, so a schema can
+// be generated from the file once, edited, and committed next to it.
+//
+// A sidecar describes tables rather than one table because a workbook is one
+// file with a table per sheet.
+type ImportSchema struct {
+	SchemaVersion int           `json:"schema_version"`
+	Tables        []TableSchema `json:"tables"`
+}
+
+// TableSchema declares one table of a schema sidecar.
+type TableSchema struct {
+	// Name is the table the declaration is for. It is empty only in a schema
+	// read from a Frictionless data package, whose resource is a file rather
+	// than a table; such a schema is for the one table the file creates.
+	Name    string         `json:"name"`
+	Columns []ColumnSchema `json:"columns"`
+}
+
+// ColumnSchema declares one column of a table. The fields are the ones
+// --inspect reports for a column, under the same names.
+type ColumnSchema struct {
+	Name string `json:"name"`
+	// Type is one of the declarable column types.
+	Type string `json:"type"`
+	// Nullable is whether the column may hold NULL. A column that does not say
+	// is nullable, since that is what an import without a schema makes, and a
+	// constraint should be something a schema says rather than something it
+	// forgot to.
+	Nullable   *bool `json:"nullable,omitempty"`
+	PrimaryKey bool  `json:"primary_key"`
+}
+
+// NotNull reports whether the column is declared NOT NULL.
+func (c ColumnSchema) NotNull() bool {
+	return c.Nullable != nil && !*c.Nullable
+}
+
+// ParseImportSchema reads a schema sidecar. It is strict: a field it does not
+// know is refused rather than ignored, because a misspelt "nullable" would
+// otherwise leave a column unconstrained without a word, and a sidecar is
+// written by hand often enough for that to matter.
+func ParseImportSchema(data []byte) (*ImportSchema, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var schema ImportSchema
+	if err := decoder.Decode(&schema); err != nil {
+		return nil, fmt.Errorf("not a schema sidecar: %w", err)
+	}
+	if decoder.More() {
+		return nil, errors.New("not a schema sidecar: it holds more than one JSON document")
+	}
+	if schema.SchemaVersion != ImportSchemaVersion {
+		return nil, fmt.Errorf("schema_version is %d; this sqly reads version %d", schema.SchemaVersion, ImportSchemaVersion)
+	}
+	if len(schema.Tables) == 0 {
+		return nil, errors.New("the schema declares no tables")
+	}
+	for i, table := range schema.Tables {
+		if table.Name == "" {
+			return nil, fmt.Errorf("table %d of the schema has no name", i+1)
+		}
+		for _, previous := range schema.Tables[:i] {
+			if strings.EqualFold(previous.Name, table.Name) {
+				return nil, fmt.Errorf("the schema declares table %q twice", table.Name)
+			}
+		}
+		if err := schema.Tables[i].Normalize(); err != nil {
+			return nil, err
+		}
+	}
+	return &schema, nil
+}
+
+// Normalize upper-cases the column types and refuses a table the import could
+// not apply: one with no columns, a column declared twice, or a type that is
+// not declarable. A schema read from a data package is checked by it too.
+func (t *TableSchema) Normalize() error {
+	name := t.Name
+	if name == "" {
+		name = "the resource"
+	}
+	if len(t.Columns) == 0 {
+		return fmt.Errorf("table %q of the schema declares no columns", name)
+	}
+	for i, column := range t.Columns {
+		if column.Name == "" {
+			return fmt.Errorf("column %d of table %q has no name", i+1, name)
+		}
+		for _, previous := range t.Columns[:i] {
+			if strings.EqualFold(previous.Name, column.Name) {
+				return fmt.Errorf("table %q declares column %q twice", name, column.Name)
+			}
+		}
+		typeName, ok := DeclarableColumnType(strings.ToUpper(strings.TrimSpace(column.Type)))
+		if !ok {
+			return fmt.Errorf("column %q of table %q has type %q; the type must be one of %s",
+				column.Name, name, column.Type, DeclarableColumnTypeNames())
+		}
+		t.Columns[i].Type = typeName
+	}
+	return nil
+}
+
+// Column returns the declaration of the named column, compared without ASCII
+// case the way SQLite compares column names.
+func (t TableSchema) Column(name string) (ColumnSchema, bool) {
+	for _, column := range t.Columns {
+		if strings.EqualFold(column.Name, name) {
+			return column, true
+		}
+	}
+	return ColumnSchema{}, false
+}
+
+// MarshalImportSchema writes a schema sidecar the way --inspect --format=schema
+// prints one: indented, with a trailing newline, so the file is diffable when
+// it is committed beside its data.
+func MarshalImportSchema(schema ImportSchema) ([]byte, error) {
+	encoded, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(encoded, '\n'), nil
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseImportSchema(t *testing.T) {
+	t.Parallel()
+
+	schema, err := ParseImportSchema([]byte(`{
+  "schema_version": 1,
+  "tables": [
+    {"name": "orders", "columns": [
+      {"name": "id", "type": "integer", "nullable": false, "primary_key": true},
+      {"name": "note", "type": "Text", "primary_key": false}
+    ]}
+  ]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := schema.Tables[0]
+	if table.Columns[0].Type != ColumnTypeInteger || table.Columns[1].Type != ColumnTypeText {
+		t.Errorf("types = %q, %q, want them upper-cased", table.Columns[0].Type, table.Columns[1].Type)
+	}
+	if !table.Columns[0].NotNull() || table.Columns[1].NotNull() {
+		t.Error("only id should be NOT NULL; a column that does not say is nullable")
+	}
+	if column, ok := table.Column("ID"); !ok || !column.PrimaryKey {
+		t.Errorf("Column(ID) = %+v, %v, want the id column", column, ok)
+	}
+
+	tests := map[string]string{
+		`{"schema_version": 2, "tables": [{"name": "t", "columns": [{"name": "a", "type": "TEXT"}]}]}`: "schema_version is 2",
+		`{"schema_version": 1, "tables": []}`: "declares no tables",
+		`{"schema_version": 1, "tables": [{"name": "", "columns": [{"name": "a", "type": "TEXT"}]}]}`:                                                             "has no name",
+		`{"schema_version": 1, "tables": [{"name": "t", "columns": []}]}`:                                                                                         "declares no columns",
+		`{"schema_version": 1, "tables": [{"name": "t", "columns": [{"name": "a", "type": "VARCHAR"}]}]}`:                                                         "must be one of",
+		`{"schema_version": 1, "tables": [{"name": "t", "columns": [{"name": "a", "type": "TEXT"}, {"name": "A", "type": "TEXT"}]}]}`:                             `column "A" twice`,
+		`{"schema_version": 1, "tables": [{"name": "t", "columns": [{"name": "a", "type": "TEXT", "nullabel": false}]}]}`:                                         "unknown field",
+		`{"schema_version": 1, "tables": [{"name": "t", "columns": [{"name": "a", "type": "TEXT"}]}]} {}`:                                                         "more than one JSON document",
+		`{"schema_version": 1, "tables": [{"name": "t", "columns": [{"name": "a", "type": "TEXT"}]}, {"name": "T", "columns": [{"name": "a", "type": "TEXT"}]}]}`: `table "T" twice`,
+	}
+	for doc, want := range tests {
+		if _, err := ParseImportSchema([]byte(doc)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseImportSchema(%s) error = %v, want one containing %q", doc, err, want)
+		}
+	}
+}
+
+func TestMarshalImportSchema_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	nullable := false
+	want := ImportSchema{SchemaVersion: ImportSchemaVersion, Tables: []TableSchema{{
+		Name: "users",
+		Columns: []ColumnSchema{
+			{Name: "id", Type: ColumnTypeInteger, Nullable: &nullable, PrimaryKey: true},
+			{Name: "zip", Type: ColumnTypeText},
+		},
+	}}}
+	encoded, err := MarshalImportSchema(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(encoded), "}\n") {
+		t.Errorf("encoded schema %q should end in a newline", encoded)
+	}
+	got, err := ParseImportSchema(encoded)
+	if err != nil {
+		t.Fatalf("ParseImportSchema(MarshalImportSchema(...)): %v", err)
+	}
+	if got.Tables[0].Name != "users" || !got.Tables[0].Columns[0].NotNull() || !got.Tables[0].Columns[0].PrimaryKey {
+		t.Errorf("round trip = %+v", got)
+	}
+}
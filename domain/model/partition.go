@@ -0,0 +1,219 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A Hive-style partitioned dataset spreads one table over a directory tree,
+// one directory level per partition column:
+//
+//	sales/year=2024/region=eu/part-0.parquet
+//
+// The partition columns are not in the files. Their values are in the path, so
+// reading the files alone loses them, and a query for the EU rows of 2024 has
+// nothing to filter on. This is the layout Hive, Spark, Trino, DuckDB, and most
+// data lake tooling read and write, so it is the one sqly reads and writes too.
+
+// HiveDefaultPartition is the directory value Hive writes for a row whose
+// partition column is NULL or empty. A path segment cannot be empty, so some
+// placeholder is needed, and this is the one every reader of the layout knows.
+const HiveDefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+// PartitionValue is one key=value segment of a partitioned path: the column it
+// names, and that column's value for every row of the files below it.
+type PartitionValue struct {
+	Key   string
+	Value string
+}
+
+// ParsePartitionSegment reads one directory name of a partitioned path. It
+// reports false for a name that is not key=value with a key on the left, which
+// a partitioned tree has no place for.
+//
+// The value is unescaped the way Hive escapes it. The default partition reads as
+// an empty value, which is what an empty CSV field reads as too: a union stages
+// its rows as CSV, and one spelling of "no value" keeps the two from differing.
+func ParsePartitionSegment(segment string) (PartitionValue, bool) {
+	key, value, ok := strings.Cut(segment, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return PartitionValue{}, false
+	}
+	if value == HiveDefaultPartition {
+		value = ""
+	}
+	return PartitionValue{Key: unescapePartitionPath(key), Value: unescapePartitionPath(value)}, true
+}
+
+// Segment returns the directory name for this value: key=value, escaped so a
+// value holding a slash, an equals sign, or a percent sign stays one segment
+// that reads back as itself. An empty value is the default partition.
+func (p PartitionValue) Segment() string {
+	value := HiveDefaultPartition
+	if p.Value != "" {
+		value = escapePartitionPath(p.Value)
+	}
+	return escapePartitionPath(p.Key) + "=" + value
+}
+
+// escapePartitionPath percent-encodes the characters Hive escapes in a
+// partition path. Following Hive's list rather than URL escaping matters: a
+// reader of the layout unescapes exactly these, and a space encoded as %20 by a
+// writer that escapes more would be read back by Hive as the three characters.
+func escapePartitionPath(s string) string {
+	var b strings.Builder
+	for i := range len(s) {
+		c := s[i]
+		if c < 0x20 || c == 0x7f || strings.IndexByte("\"#%'*/:=?\\{[]^", c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// unescapePartitionPath undoes escapePartitionPath. A percent sign not followed
+// by two hex digits is kept as written, as Hive keeps it, so a directory named
+// by hand with a stray % still reads.
+func unescapePartitionPath(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) && isHexDigit(s[i+1]) && isHexDigit(s[i+2]) {
+			b.WriteByte(hexValue(s[i+1])<<4 | hexValue(s[i+2]))
+			i += 2
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isHexDigit(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func hexValue(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	default:
+		return c - '0'
+	}
+}
+
+// TablePartition is the rows of a table that share one value of each partition
+// column, without those columns: their values are in Values, which is where a
+// partitioned layout keeps them.
+type TablePartition struct {
+	Values []PartitionValue
+	Table  *Table
+}
+
+// PartitionBy splits the table into one TablePartition per distinct value of
+// the named columns, in the order each value first appears, so a query's ORDER
+// BY decides the order too.
+//
+// A NULL and an empty string land in the same partition, the default one,
+// because a path cannot tell them apart. Native values are kept, so a partition
+// written as Parquet or JSON keeps the types the query produced.
+func (t *Table) PartitionBy(columns []string) ([]TablePartition, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("partitioning needs at least one column")
+	}
+	keys := make([]int, 0, len(columns))
+	for _, column := range columns {
+		i := slices.IndexFunc(t.header, func(h string) bool { return strings.EqualFold(h, column) })
+		if i < 0 {
+			return nil, fmt.Errorf("the result has no column %q to partition by; its columns are %s", column, strings.Join(t.header, ", "))
+		}
+		if slices.ContainsFunc(t.header[i+1:], func(h string) bool { return strings.EqualFold(h, column) }) {
+			return nil, fmt.Errorf("the result has two columns named %q, so which one to partition by is not clear; alias one of them", column)
+		}
+		if slices.Contains(keys, i) {
+			return nil, fmt.Errorf("column %q is named twice to partition by", column)
+		}
+		keys = append(keys, i)
+	}
+	if len(keys) == len(t.header) {
+		return nil, errors.New("every column of the result is a partition column, which leaves the files with no columns to hold; select at least one more")
+	}
+
+	var header Header
+	var kept []int
+	for i, name := range t.header {
+		if !slices.Contains(keys, i) {
+			header = append(header, name)
+			kept = append(kept, i)
+		}
+	}
+
+	type group struct {
+		values  []PartitionValue
+		records []Record
+		cells   [][]Cell
+	}
+	var groups []*group
+	index := make(map[string]*group)
+	for row := range t.RowCount() {
+		values := make([]PartitionValue, len(keys))
+		for j, col := range keys {
+			value := ""
+			if !t.IsNull(row, col) {
+				value = t.ValueAt(row, col)
+			}
+			values[j] = PartitionValue{Key: t.header[col], Value: value}
+		}
+		id := partitionID(values)
+		g, ok := index[id]
+		if !ok {
+			g = &group{values: values}
+			index[id] = g
+			groups = append(groups, g)
+		}
+		if t.cells != nil {
+			cells := make([]Cell, len(kept))
+			for j, col := range kept {
+				cells[j], _ = t.cell(row, col)
+			}
+			g.cells = append(g.cells, cells)
+			continue
+		}
+		record := make(Record, len(kept))
+		for j, col := range kept {
+			record[j] = t.ValueAt(row, col)
+		}
+		g.records = append(g.records, record)
+	}
+
+	partitions := make([]TablePartition, 0, len(groups))
+	for _, g := range groups {
+		part := NewTable(t.name, header, g.records)
+		if t.cells != nil {
+			var err error
+			if part, err = NewTableFromCells(t.name, header, g.cells); err != nil {
+				return nil, err
+			}
+		}
+		part.sqlScript = t.sqlScript
+		partitions = append(partitions, TablePartition{Values: g.values, Table: part})
+	}
+	return partitions, nil
+}
+
+// partitionID is a map key for one combination of partition values. Each value
+// is quoted, so no two combinations share a key.
+func partitionID(values []PartitionValue) string {
+	var b strings.Builder
+	for _, v := range values {
+		fmt.Fprintf(&b, "%q", v.Value)
+	}
+	return b.String()
+}
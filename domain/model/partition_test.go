@@ -0,0 +1,115 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPartitionSegment(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		value   PartitionValue
+		segment string
+	}{
+		{name: "a plain value is written as is", value: PartitionValue{Key: "year", Value: "2024"}, segment: "year=2024"},
+		{name: "a slash and an equals sign are escaped", value: PartitionValue{Key: "path", Value: "a/b=c"}, segment: "path=a%2Fb%3Dc"},
+		{name: "a percent sign is escaped", value: PartitionValue{Key: "rate", Value: "5%"}, segment: "rate=5%25"},
+		{name: "a space is not escaped", value: PartitionValue{Key: "city", Value: "New York"}, segment: "city=New York"},
+		{name: "an empty value is the default partition", value: PartitionValue{Key: "region", Value: ""}, segment: "region=" + HiveDefaultPartition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.value.Segment(); got != tt.segment {
+				t.Errorf("Segment() = %q, want %q", got, tt.segment)
+			}
+			back, ok := ParsePartitionSegment(tt.segment)
+			if !ok || back != tt.value {
+				t.Errorf("ParsePartitionSegment(%q) = %+v, %v, want %+v", tt.segment, back, ok, tt.value)
+			}
+		})
+	}
+}
+
+func TestParsePartitionSegment(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		segment string
+		want    PartitionValue
+		ok      bool
+	}{
+		{name: "key=value", segment: "region=eu", want: PartitionValue{Key: "region", Value: "eu"}, ok: true},
+		{name: "an empty value", segment: "region=", want: PartitionValue{Key: "region"}, ok: true},
+		{name: "a stray percent sign is kept", segment: "rate=5%", want: PartitionValue{Key: "rate", Value: "5%"}, ok: true},
+		{name: "a lowercase escape", segment: "path=a%2fb", want: PartitionValue{Key: "path", Value: "a/b"}, ok: true},
+		{name: "no equals sign", segment: "2024", ok: false},
+		{name: "no key", segment: "=2024", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := ParsePartitionSegment(tt.segment)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("ParsePartitionSegment(%q) = %+v, %v, want %+v, %v", tt.segment, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestTable_PartitionBy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("rows are grouped by value in the order values first appear", func(t *testing.T) {
+		t.Parallel()
+		table, err := NewTableFromCells("sales", Header{"year", "region", "amount"}, [][]Cell{
+			{NewCell(int64(2024)), NewCell("eu"), NewCell(int64(1))},
+			{NewCell(int64(2023)), NewCell(nil), NewCell(int64(2))},
+			{NewCell(int64(2024)), NewCell("eu"), NewCell(int64(3))},
+			{NewCell(int64(2023)), NewCell(""), NewCell(int64(4))},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts, err := table.PartitionBy([]string{"YEAR", "region"})
+		if err != nil {
+			t.Fatalf("PartitionBy: %v", err)
+		}
+		if len(parts) != 2 {
+			t.Fatalf("got %d partitions, want 2 (NULL and empty share one)", len(parts))
+		}
+		first := parts[0]
+		if got := first.Values[0].Segment() + "/" + first.Values[1].Segment(); got != "year=2024/region=eu" {
+			t.Errorf("first partition = %s, want year=2024/region=eu", got)
+		}
+		if got := strings.Join(first.Table.Header(), ","); got != "amount" {
+			t.Errorf("partition header = %s, want the partition columns removed", got)
+		}
+		if first.Table.RowCount() != 2 || first.Table.ValueAt(1, 0) != "3" {
+			t.Errorf("first partition rows = %v", first.Table.Records())
+		}
+		if cell, ok := first.Table.NativeCell(0, 0); !ok || cell.Value() != int64(1) {
+			t.Errorf("native cell = %v, %v, want the INTEGER kept", cell, ok)
+		}
+		if got := parts[1].Values[1].Segment(); got != "region="+HiveDefaultPartition {
+			t.Errorf("second partition region = %s, want the default partition", got)
+		}
+	})
+
+	t.Run("an unknown column is refused naming the columns there are", func(t *testing.T) {
+		t.Parallel()
+		table := NewTable("t", Header{"a", "b"}, []Record{{"1", "2"}})
+		if _, err := table.PartitionBy([]string{"c"}); err == nil || !strings.Contains(err.Error(), "a, b") {
+			t.Errorf("PartitionBy error = %v, want the columns listed", err)
+		}
+	})
+
+	t.Run("partitioning by every column is refused", func(t *testing.T) {
+		t.Parallel()
+		table := NewTable("t", Header{"a"}, []Record{{"1"}})
+		if _, err := table.PartitionBy([]string{"a"}); err == nil {
+			t.Error("PartitionBy succeeded, want a refusal")
+		}
+	})
+}
@@ -0,0 +1,472 @@
+package model
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SQLDialect is the database a SQL script export is written for.
+//
+// It is not the --dialect a query is written in. That one is translated into
+// SQLite before anything runs; this one decides how a script sqly writes is
+// spelled, for a database sqly never talks to. The same run can read PostgreSQL
+// and write MySQL.
+type SQLDialect string
+
+const (
+	// SQLDialectSQLite writes a script for SQLite.
+	SQLDialectSQLite SQLDialect = "sqlite"
+	// SQLDialectMySQL writes a script for MySQL and MariaDB.
+	SQLDialectMySQL SQLDialect = "mysql"
+	// SQLDialectPostgreSQL writes a script for PostgreSQL.
+	SQLDialectPostgreSQL SQLDialect = "postgresql"
+)
+
+// sqlDialects lists the databases a script can be written for, in the order a
+// user is shown them. GoogleSQL, which --dialect reads, is not one of them: a
+// BigQuery load is a bq load of a file, not a script of INSERTs, and a
+// statement-per-row script would run into its DML quotas long before it
+// finished.
+var sqlDialects = []SQLDialect{SQLDialectSQLite, SQLDialectMySQL, SQLDialectPostgreSQL}
+
+// ParseSQLDialect returns the dialect a user named, ignoring surrounding
+// whitespace and letter case the way the format names do.
+func ParseSQLDialect(name string) (SQLDialect, error) {
+	normalized := SQLDialect(strings.ToLower(strings.TrimSpace(name)))
+	for _, d := range sqlDialects {
+		if d == normalized {
+			return d, nil
+		}
+	}
+	return SQLDialectSQLite, fmt.Errorf("invalid sql output dialect %q: want %s", name, SQLDialectNames())
+}
+
+// SQLDialectNames lists the dialects a script can be written for,
+// comma-separated in the order a user is shown them.
+func SQLDialectNames() string {
+	names := make([]string, len(sqlDialects))
+	for i, d := range sqlDialects {
+		names[i] = string(d)
+	}
+	return strings.Join(names, ", ")
+}
+
+// QuoteIdentifier quotes a table or column name for the dialect. SQLite and
+// PostgreSQL use the standard double quote; MySQL uses the backtick, because a
+// double quote there is a string unless the server runs in ANSI_QUOTES mode,
+// which sqly cannot know about. Either way the quote character is escaped by
+// doubling it, so a name is written exactly, keywords and odd characters
+// included.
+func (d SQLDialect) QuoteIdentifier(name string) string {
+	if d == SQLDialectMySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// ColumnDefinition is one column of a CREATE TABLE statement, in the shape
+// PRAGMA table_info reports it.
+type ColumnDefinition struct {
+	// Name is the column name.
+	Name string
+	// Type is the declared type, or "" for a column declared without one.
+	Type string
+	// NotNull is whether the column refuses NULL.
+	NotNull bool
+	// Default is the DEFAULT expression as SQLite stored it, or "" for none.
+	Default string
+	// PrimaryKey is the column's 1-based position in the primary key, or 0
+	// when it is not part of one.
+	PrimaryKey int
+}
+
+// SQLScript is what a table needs, beyond its rows, to be written as a script:
+// the dialect, the name to create, and the column definitions. It is attached to
+// a table with WithSQLScript by whoever knows those things — the shell, which
+// has the session's database to ask — because the writer only has the rows.
+type SQLScript struct {
+	// Dialect is the database the script is for.
+	Dialect SQLDialect
+	// Table is the name the script creates and inserts into. Empty falls back
+	// to the table's own name, and then to sqlScriptDefaultTable.
+	Table string
+	// Columns are the table's column definitions. Nil means the result has no
+	// table behind it, and each column's type is inferred from its values.
+	Columns []ColumnDefinition
+}
+
+// sqlScriptDefaultTable is the table a script creates when nothing named one:
+// a query result printed to stdout.
+const sqlScriptDefaultTable = "result"
+
+// sqlInsertBatchRows is how many rows one INSERT statement carries.
+//
+// One statement per row is the slowest way to load a table into any of the
+// three databases, and one statement for the whole table runs into a limit on
+// the statement's size — MySQL's max_allowed_packet is 64 MiB by default. Five
+// hundred rows of a wide export stay well below that, and past a few hundred
+// the load gets no faster.
+const sqlInsertBatchRows = 500
+
+// WithSQLScript returns a copy of the table that writes as script when printed
+// in PrintModeSQL. The rows are shared, as WithName shares them.
+func (t *Table) WithSQLScript(script SQLScript) *Table {
+	cloned := t.WithName(t.name)
+	cloned.sqlScript = &script
+	return cloned
+}
+
+// WriteSQLiteScript writes the table as a script for SQLite, whatever dialect
+// its SQLScript names. A SQLite database file is written by running this script
+// against it, and --output-dialect describes a script a person will run
+// somewhere else, not one sqly runs itself. The name and column definitions are
+// kept.
+func (t *Table) WriteSQLiteScript(out io.Writer) error {
+	var script SQLScript
+	if t.sqlScript != nil {
+		script = *t.sqlScript
+	}
+	script.Dialect = SQLDialectSQLite
+	return t.WithSQLScript(script).printSQL(out)
+}
+
+// BuildCreateStatement writes a CREATE TABLE statement for the dialect. A
+// single-column primary key is written inline; a composite one becomes a
+// table-level PRIMARY KEY clause, with the columns in key order.
+//
+// SQLite's declared types are written as they are for SQLite, and mapped for the
+// other two by SQLite's own affinity rules, because that is what the values in
+// them are: a column SQLite calls INTEGER holds 64-bit integers, which is a
+// BIGINT elsewhere and not the 32-bit INTEGER PostgreSQL would take it for, and
+// REAL is a double, where PostgreSQL's REAL is single precision.
+func BuildCreateStatement(d SQLDialect, table string, columns []ColumnDefinition) string {
+	keyed := 0
+	for _, c := range columns {
+		if c.PrimaryKey > 0 {
+			keyed++
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("CREATE TABLE ")
+	b.WriteString(d.QuoteIdentifier(table))
+	b.WriteString(" (")
+	for i, c := range columns {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(d.QuoteIdentifier(c.Name))
+		if colType := d.columnType(c); colType != "" {
+			b.WriteString(" ")
+			b.WriteString(colType)
+		}
+		if c.NotNull {
+			b.WriteString(" NOT NULL")
+		}
+		if c.Default != "" {
+			b.WriteString(" DEFAULT ")
+			b.WriteString(c.Default)
+		}
+		if keyed == 1 && c.PrimaryKey > 0 {
+			b.WriteString(" PRIMARY KEY")
+		}
+	}
+	if keyed > 1 {
+		b.WriteString(", PRIMARY KEY (")
+		for pos := 1; pos <= len(columns); pos++ {
+			for _, c := range columns {
+				if c.PrimaryKey != pos {
+					continue
+				}
+				if pos > 1 {
+					b.WriteString(", ")
+				}
+				b.WriteString(d.QuoteIdentifier(c.Name))
+			}
+		}
+		b.WriteString(")")
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// columnType is the type a column is declared with in the dialect.
+func (d SQLDialect) columnType(c ColumnDefinition) string {
+	if d == SQLDialectSQLite {
+		return c.Type
+	}
+	upper := strings.ToUpper(c.Type)
+	if decimal, ok := ParseDecimalType(upper); ok {
+		return decimal.dialectType(d)
+	}
+	// The order is SQLite's: https://www.sqlite.org/datatype3.html#determination_of_column_affinity
+	switch {
+	case strings.Contains(upper, "INT"):
+		return "BIGINT"
+	case strings.Contains(upper, "CHAR"), strings.Contains(upper, "CLOB"), strings.Contains(upper, "TEXT"), upper == "":
+		// MySQL cannot index a TEXT column without a prefix length, so a key
+		// column is a VARCHAR there. A column with no declared type takes text:
+		// both databases need a type, and text holds any value SQLite kept.
+		if d == SQLDialectMySQL && c.PrimaryKey > 0 {
+			return "VARCHAR(255)"
+		}
+		return "TEXT"
+	case strings.Contains(upper, "BLOB"):
+		if d == SQLDialectMySQL {
+			return "LONGBLOB"
+		}
+		return "BYTEA"
+	case strings.Contains(upper, "REAL"), strings.Contains(upper, "FLOA"), strings.Contains(upper, "DOUB"):
+		if d == SQLDialectMySQL {
+			return "DOUBLE"
+		}
+		return "DOUBLE PRECISION"
+	default:
+		// NUMERIC affinity: DECIMAL(10,2), DATE, BOOLEAN and the like. Those
+		// names mean the same to the other two, so they are kept.
+		return c.Type
+	}
+}
+
+// inferredColumns describes a result that has no table behind it, typing each
+// column by the values it holds: integers only is INTEGER, any fraction makes it
+// REAL, bytes that are not text make it a BLOB, and everything else is TEXT.
+func (t *Table) inferredColumns() []ColumnDefinition {
+	columns := make([]ColumnDefinition, t.ColumnCount())
+	for col, name := range t.Columns {
+		columns[col] = ColumnDefinition{Name: name, Type: t.inferredColumnType(col)}
+	}
+	return columns
+}
+
+// inferredColumnType is the declared type inferredColumns gives one column.
+func (t *Table) inferredColumnType(col int) string {
+	const (
+		typeInteger = "INTEGER"
+		typeReal    = "REAL"
+		typeText    = "TEXT"
+		typeBlob    = "BLOB"
+	)
+	var integers, reals, blobs, texts, decimals bool
+	for row := range t.RowCount() {
+		cell, ok := t.cell(row, col)
+		if !ok {
+			return typeText
+		}
+		switch v := cell.Value().(type) {
+		case nil:
+		case int64, bool:
+			integers = true
+		case float64:
+			reals = true
+		case Decimal:
+			decimals = true
+		case []byte:
+			if utf8.Valid(v) {
+				texts = true
+			} else {
+				blobs = true
+			}
+		default:
+			texts = true
+		}
+	}
+	switch {
+	case texts:
+		return typeText
+	case blobs:
+		return typeBlob
+	case decimals && (reals || integers):
+		// A decimal beside a number SQLite would compute with: text holds
+		// both as they print.
+		return typeText
+	case decimals:
+		return DecimalType{}.StorageType()
+	case reals:
+		return typeReal
+	case integers:
+		return typeInteger
+	default:
+		return typeText
+	}
+}
+
+// EnsureSQLWritable reports whether every value can be written as a literal in
+// the dialect. Like the other formats' checks, it runs in full before the first
+// statement goes out, so a script is never cut off part way through a table —
+// which a loader would run up to the cut and then fail on.
+func (t *Table) EnsureSQLWritable(d SQLDialect) error {
+	for row := range t.RowCount() {
+		for col := range t.ColumnCount() {
+			if _, err := t.sqlLiteral(d, row, col); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// errSQLNonFinite is why an infinity or NaN cannot be written: none of the three
+// has a literal for it that the others would read back as the same value, and
+// MySQL cannot store one at all.
+var errSQLNonFinite = errors.New("has no SQL literal")
+
+// sqlLiteral writes the value at (row, col) as a literal of the dialect. The
+// cell's native type decides the form, as it does for JSON: an INTEGER or REAL is
+// a number, a NULL is NULL, and text is a string literal even when it looks like
+// a number, so "007" keeps its zeros.
+func (t *Table) sqlLiteral(d SQLDialect, row, col int) (string, error) {
+	cell, ok := t.cell(row, col)
+	if !ok {
+		return d.stringLiteral(t.ColumnName(col), t.ValueAt(row, col))
+	}
+	switch v := cell.Value().(type) {
+	case nil:
+		return "NULL", nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case Decimal:
+		// MySQL and PostgreSQL read an unquoted decimal literal exactly. SQLite
+		// reads it as a REAL, and writes 19.90 into a DECIMAL TEXT column as
+		// 19.9, so there it is the string it is stored as.
+		if d == SQLDialectSQLite {
+			return d.stringLiteral(t.ColumnName(col), v.String())
+		}
+		return v.String(), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", fmt.Errorf("sql: value for column %q is %s, which %w in %s; filter it out or export to csv/json", t.ColumnName(col), cell.String(), errSQLNonFinite, d)
+		}
+		literal := strconv.FormatFloat(v, 'g', -1, 64)
+		// A float written without a point or an exponent is an INTEGER literal,
+		// and a column with no declared type would store it as one.
+		if !strings.ContainsAny(literal, ".eE") {
+			literal += ".0"
+		}
+		return literal, nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case []byte:
+		if !utf8.Valid(v) {
+			return d.blobLiteral(v), nil
+		}
+		return d.stringLiteral(t.ColumnName(col), string(v))
+	default:
+		return d.stringLiteral(t.ColumnName(col), cell.String())
+	}
+}
+
+// stringLiteral quotes text for the dialect. The single quote is doubled in all
+// three; MySQL also reads a backslash as an escape unless the server runs with
+// NO_BACKSLASH_ESCAPES, so there it is doubled too. A NUL is refused for
+// PostgreSQL, whose text type cannot hold one, and bytes that are not UTF-8 are
+// refused everywhere, because a script is a text file and every one of the three
+// would reject the statement.
+func (d SQLDialect) stringLiteral(label, s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", fmt.Errorf("sql: value for column %q is not valid UTF-8, so no %s string literal can hold it; re-read the input with the right --encoding", label, d)
+	}
+	if d == SQLDialectPostgreSQL && strings.ContainsRune(s, 0) {
+		return "", fmt.Errorf("sql: value for column %q contains a NUL character, which PostgreSQL text cannot hold; remove it or write the script for another dialect", label)
+	}
+	s = strings.ReplaceAll(s, "'", "''")
+	if d == SQLDialectMySQL {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + s + "'", nil
+}
+
+// blobLiteral writes bytes as the dialect's binary literal: X'..' for SQLite
+// and MySQL, and a bytea escape string for PostgreSQL.
+func (d SQLDialect) blobLiteral(b []byte) string {
+	if d == SQLDialectPostgreSQL {
+		return `'\x` + hex.EncodeToString(b) + `'::bytea`
+	}
+	return "X'" + hex.EncodeToString(b) + "'"
+}
+
+// beginTransaction and commitTransaction wrap the script, so a load that fails
+// part way leaves nothing behind, and every database loads it faster than it
+// would with a commit per statement.
+func (d SQLDialect) beginTransaction() string {
+	if d == SQLDialectMySQL {
+		return "START TRANSACTION;"
+	}
+	return "BEGIN;"
+}
+
+func (d SQLDialect) commitTransaction() string {
+	return "COMMIT;"
+}
+
+// printSQL writes the table as a script that creates it and fills it: a CREATE
+// TABLE statement, then INSERT statements of up to sqlInsertBatchRows rows each,
+// all in one transaction.
+func (t *Table) printSQL(out io.Writer) error {
+	script := SQLScript{Dialect: SQLDialectSQLite}
+	if t.sqlScript != nil {
+		script = *t.sqlScript
+	}
+	name := script.Table
+	if name == "" {
+		name = t.name
+	}
+	if name == "" {
+		name = sqlScriptDefaultTable
+	}
+	columns := script.Columns
+	if columns == nil {
+		columns = t.inferredColumns()
+	}
+	if len(columns) != t.ColumnCount() {
+		return fmt.Errorf("sql: the table has %d columns and its definition %d", t.ColumnCount(), len(columns))
+	}
+	d := script.Dialect
+	if err := t.EnsureSQLWritable(d); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(d.beginTransaction() + "\n")
+	b.WriteString(BuildCreateStatement(d, name, columns) + ";\n")
+	if _, err := io.WriteString(out, b.String()); err != nil {
+		return err
+	}
+
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = d.QuoteIdentifier(c.Name)
+	}
+	insert := "INSERT INTO " + d.QuoteIdentifier(name) + " (" + strings.Join(quoted, ", ") + ") VALUES\n"
+	literals := make([]string, t.ColumnCount())
+	for start := 0; start < t.RowCount(); start += sqlInsertBatchRows {
+		end := min(start+sqlInsertBatchRows, t.RowCount())
+		b.Reset()
+		b.WriteString(insert)
+		for row := start; row < end; row++ {
+			for col := range literals {
+				// Checked in full by EnsureSQLWritable above.
+				literals[col], _ = t.sqlLiteral(d, row, col)
+			}
+			b.WriteString("  (" + strings.Join(literals, ", ") + ")")
+			if row < end-1 {
+				b.WriteString(",\n")
+			}
+		}
+		b.WriteString(";\n")
+		if _, err := io.WriteString(out, b.String()); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(out, d.commitTransaction()+"\n")
+	return err
+}
@@ -0,0 +1,210 @@
+package model
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestTable_PrintSQL(t *testing.T) {
+	t.Parallel()
+
+	table, err := NewTableFromCells("users", Header{"id", "name", "score", "avatar"}, [][]Cell{
+		{NewCell(int64(1)), NewCell(`O'Brien \ co`), NewCell(2.0), NewCell([]byte{0xff, 0x00})},
+		{NewCell(int64(2)), NewCell(nil), NewCell(1.5), NewCell(nil)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := []ColumnDefinition{
+		{Name: "id", Type: "INTEGER", NotNull: true, PrimaryKey: 1},
+		{Name: "name", Type: "TEXT"},
+		{Name: "score", Type: "REAL", Default: "0"},
+		{Name: "avatar", Type: "BLOB"},
+	}
+
+	tests := []struct {
+		dialect SQLDialect
+		want    string
+	}{
+		{
+			dialect: SQLDialectSQLite,
+			want: `BEGIN;
+CREATE TABLE "users" ("id" INTEGER NOT NULL PRIMARY KEY, "name" TEXT, "score" REAL DEFAULT 0, "avatar" BLOB);
+INSERT INTO "users" ("id", "name", "score", "avatar") VALUES
+  (1, 'O''Brien \ co', 2.0, X'ff00'),
+  (2, NULL, 1.5, NULL);
+COMMIT;
+`,
+		},
+		{
+			dialect: SQLDialectMySQL,
+			want: "START TRANSACTION;\n" +
+				"CREATE TABLE `users` (`id` BIGINT NOT NULL PRIMARY KEY, `name` TEXT, `score` DOUBLE DEFAULT 0, `avatar` LONGBLOB);\n" +
+				"INSERT INTO `users` (`id`, `name`, `score`, `avatar`) VALUES\n" +
+				"  (1, 'O''Brien \\\\ co', 2.0, X'ff00'),\n" +
+				"  (2, NULL, 1.5, NULL);\n" +
+				"COMMIT;\n",
+		},
+		{
+			dialect: SQLDialectPostgreSQL,
+			want: `BEGIN;
+CREATE TABLE "users" ("id" BIGINT NOT NULL PRIMARY KEY, "name" TEXT, "score" DOUBLE PRECISION DEFAULT 0, "avatar" BYTEA);
+INSERT INTO "users" ("id", "name", "score", "avatar") VALUES
+  (1, 'O''Brien \ co', 2.0, '\xff00'::bytea),
+  (2, NULL, 1.5, NULL);
+COMMIT;
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			script := SQLScript{Dialect: tt.dialect, Columns: columns}
+			if err := table.WithSQLScript(script).Print(&out, PrintModeSQL); err != nil {
+				t.Fatalf("Print: %v", err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("Print(sql) =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTable_PrintSQLInfersTypes checks a result with no table behind it is
+// typed from its values, and keeps text that looks like a number as text.
+func TestTable_PrintSQLInfersTypes(t *testing.T) {
+	t.Parallel()
+
+	table, err := NewTableFromCells("", Header{"n", "x", "code", "empty"}, [][]Cell{
+		{NewCell(int64(1)), NewCell(int64(1)), NewCell("007"), NewCell(nil)},
+		{NewCell(int64(2)), NewCell(0.5), NewCell("8"), NewCell(nil)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := table.Print(&out, PrintModeSQL); err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+	want := `BEGIN;
+CREATE TABLE "result" ("n" INTEGER, "x" REAL, "code" TEXT, "empty" TEXT);
+INSERT INTO "result" ("n", "x", "code", "empty") VALUES
+  (1, 1, '007', NULL),
+  (2, 0.5, '8', NULL);
+COMMIT;
+`
+	if got := out.String(); got != want {
+		t.Errorf("Print(sql) =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTable_PrintSQLBatchesInserts(t *testing.T) {
+	t.Parallel()
+
+	records := make([]Record, sqlInsertBatchRows+1)
+	for i := range records {
+		records[i] = Record{"v"}
+	}
+	var out bytes.Buffer
+	if err := NewTable("t", Header{"a"}, records).Print(&out, PrintModeSQL); err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+	if got := strings.Count(out.String(), "INSERT INTO"); got != 2 {
+		t.Errorf("Print(sql) wrote %d INSERT statements for %d rows, want 2", got, len(records))
+	}
+	if got := strings.Count(out.String(), "('v')"); got != len(records) {
+		t.Errorf("Print(sql) wrote %d rows, want %d", got, len(records))
+	}
+}
+
+func TestTable_PrintSQLWithNoRows(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if err := NewTable("t", Header{"a"}, nil).Print(&out, PrintModeSQL); err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+	if want := "BEGIN;\nCREATE TABLE \"t\" (\"a\" TEXT);\nCOMMIT;\n"; out.String() != want {
+		t.Errorf("Print(sql) = %q, want %q", out.String(), want)
+	}
+}
+
+// TestTable_PrintSQLRefusals checks a value no literal can hold is refused
+// before a statement is written, so a loader never runs half a script.
+func TestTable_PrintSQLRefusals(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		dialect SQLDialect
+		cell    Cell
+		want    string
+	}{
+		{name: "an infinity", dialect: SQLDialectSQLite, cell: NewCell(math.Inf(1)), want: "has no SQL literal"},
+		{name: "a NaN", dialect: SQLDialectMySQL, cell: NewCell(math.NaN()), want: "has no SQL literal"},
+		{name: "a NUL for PostgreSQL", dialect: SQLDialectPostgreSQL, cell: NewCell("a\x00b"), want: "NUL character"},
+		{name: "text that is not UTF-8", dialect: SQLDialectSQLite, cell: NewCell("\xff"), want: "not valid UTF-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			table, err := NewTableFromCells("t", Header{"v"}, [][]Cell{{NewCell("ok")}, {tt.cell}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			err = table.WithSQLScript(SQLScript{Dialect: tt.dialect}).Print(&out, PrintModeSQL)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Print(sql) error = %v, want one containing %q", err, tt.want)
+			}
+			if out.Len() != 0 {
+				t.Errorf("Print(sql) wrote %q before failing, want nothing", out.String())
+			}
+		})
+	}
+}
+
+func TestBuildCreateStatement_CompositeKey(t *testing.T) {
+	t.Parallel()
+
+	columns := []ColumnDefinition{
+		{Name: "day", Type: "DATE", PrimaryKey: 2},
+		{Name: "store", Type: "TEXT", PrimaryKey: 1},
+		{Name: "total", Type: "DECIMAL(10,2)"},
+	}
+	tests := map[SQLDialect]string{
+		SQLDialectSQLite:     `CREATE TABLE "sales" ("day" DATE, "store" TEXT, "total" DECIMAL(10,2), PRIMARY KEY ("store", "day"))`,
+		SQLDialectMySQL:      "CREATE TABLE `sales` (`day` DATE, `store` VARCHAR(255), `total` DECIMAL(10,2), PRIMARY KEY (`store`, `day`))",
+		SQLDialectPostgreSQL: `CREATE TABLE "sales" ("day" DATE, "store" TEXT, "total" DECIMAL(10,2), PRIMARY KEY ("store", "day"))`,
+	}
+	for d, want := range tests {
+		if got := BuildCreateStatement(d, "sales", columns); got != want {
+			t.Errorf("BuildCreateStatement(%s) =\n%s\nwant\n%s", d, got, want)
+		}
+	}
+}
+
+func TestParseSQLDialect(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]SQLDialect{
+		"sqlite":       SQLDialectSQLite,
+		" MySQL ":      SQLDialectMySQL,
+		"postgresql":   SQLDialectPostgreSQL,
+		"PostgreSQL\t": SQLDialectPostgreSQL,
+	} {
+		got, err := ParseSQLDialect(name)
+		if err != nil || got != want {
+			t.Errorf("ParseSQLDialect(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseSQLDialect("googlesql"); err == nil {
+		t.Error("ParseSQLDialect(googlesql) succeeded, want it refused")
+	}
+	if got, want := SQLDialectMySQL.QuoteIdentifier("a`b"), "`a``b`"; got != want {
+		t.Errorf("QuoteIdentifier = %s, want %s", got, want)
+	}
+}
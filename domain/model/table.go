@@ -90,6 +90,19 @@ type Table struct {
 	// columns is the row width of cells. It is header's length; it is stored so
 	// indexing does not depend on the header slice a caller may have replaced.
 	columns int
+	// sqlScript is how the table is written in PrintModeSQL, when whoever built
+	// it knows more than the rows do. Nil writes a SQLite script whose column
+	// types are inferred from the values.
+	sqlScript *SQLScript
+	// csvOutput is how the table is laid out when written as CSV or TSV. The
+	// zero value is sqly's default layout.
+	csvOutput CSVOutput
+	// nullString is how a SQL NULL is written in table, CSV, TSV, and LTSV
+	// output. Empty writes it as an empty value.
+	nullString string
+	// mask names the columns whose values are masked when the table is written
+	// to a file. Nil masks nothing.
+	mask ColumnMasks
 }
 
 // NewTable create new Table from string records. Use it for tables that have no
@@ -178,6 +191,30 @@ func (t *Table) IsNull(row, col int) bool {
 	return ok && c.IsNull()
 }
 
+// WithNullString returns a copy of the table that writes a SQL NULL as s in
+// table, CSV, TSV, and LTSV output. The rows are shared, as WithName shares
+// them.
+//
+// A NULL prints as an empty value by default, which is also what an empty
+// string prints as, so the two could not be told apart on screen or in a file.
+// A marker such as NULL or \N makes the difference visible, and is what a loader
+// on the other end may expect. The JSON formats write a real null and are not
+// affected.
+func (t *Table) WithNullString(s string) *Table {
+	cloned := t.WithName(t.name)
+	cloned.nullString = s
+	return cloned
+}
+
+// displayValue returns the string written for the cell at (row, col) whose
+// display string is value: the table's null string for a NULL, value otherwise.
+func (t *Table) displayValue(row, col int, value string) string {
+	if t.nullString != "" && t.IsNull(row, col) {
+		return t.nullString
+	}
+	return value
+}
+
 // Name return table name.
 func (t *Table) Name() string {
 	return t.name
@@ -195,8 +232,12 @@ func (t *Table) Name() string {
 // reach them except through a RecordView or a copy.
 func (t *Table) WithName(name string) *Table {
 	cloned := &Table{
-		name:    name,
-		columns: t.columns,
+		name:       name,
+		columns:    t.columns,
+		sqlScript:  t.sqlScript,
+		csvOutput:  t.csvOutput,
+		nullString: t.nullString,
+		mask:       t.mask,
 	}
 	if t.header != nil {
 		cloned.header = append(make(Header, 0, len(t.header)), t.header...)
@@ -361,9 +402,13 @@ func (t *Table) Print(out io.Writer, mode PrintMode) error {
 		return t.printJSON(out)
 	case PrintModeJSONL:
 		return t.printNDJSON(out)
-	case PrintModeParquet:
-		// Export-only: on screen, render like CSV. The Parquet file is written
-		// by the export path (.dump / --output), not here.
+	case PrintModeXML:
+		return t.printXML(out)
+	case PrintModeSQL:
+		return t.printSQL(out)
+	case PrintModeParquet, PrintModeSQLite:
+		// Export-only: on screen, render like CSV. The Parquet or SQLite file is
+		// written by the export path (.dump / --output), not here.
 		return t.printCSV(out)
 	case PrintModeVertical:
 		return t.printVertical(out)
@@ -407,11 +452,11 @@ func (t *Table) printTable(out io.Writer) error {
 	}
 	table.Header(headers...)
 
-	for _, v := range t.Rows {
+	for r, v := range t.Rows {
 		// Convert the row to []any for the tablewriter API.
 		row := make([]any, v.Len())
 		for i := range v.Len() {
-			row[i] = v.At(i)
+			row[i] = t.displayValue(r, i, v.At(i))
 		}
 		if err := table.Append(row); err != nil {
 			return fmt.Errorf("failed to append table row: %w", err)
@@ -516,18 +561,42 @@ const loneEmptyField = `""`
 
 // writeDelimited writes the header and records as delimiter-separated values
 // using encoding/csv. Every delimited destination goes through here, stdout and
-// file export alike.
+// file export alike. The table's CSVOutput lays the values out; comma is the
+// format's own delimiter, which a CSV file's layout may replace.
 func (t *Table) writeDelimited(out io.Writer, comma rune) error {
+	output := t.csvOutput
+	if comma == '\t' {
+		// A TSV file is separated by tabs and quoted only where it must be;
+		// see CSVOutput.
+		output.Delimiter, output.QuoteAll = 0, false
+	}
+	if output.Delimiter != 0 {
+		comma = output.Delimiter
+	}
+	if output.BOM {
+		if _, err := io.WriteString(out, utf8BOM); err != nil {
+			return fmt.Errorf("failed to write byte-order mark: %w", err)
+		}
+	}
+	newline := "\n"
+	if output.CRLF {
+		newline = "\r\n"
+	}
+
 	w := csv.NewWriter(out)
 	w.Comma = comma
+	w.UseCRLF = output.CRLF
 	writeRecord := func(record []string) error {
+		if output.QuoteAll {
+			return writeQuotedRecord(out, record, comma, output.CRLF)
+		}
 		if len(record) == 1 && record[0] == "" {
 			// Flushing first keeps the two writers' output in order.
 			w.Flush()
 			if err := w.Error(); err != nil {
 				return err
 			}
-			_, err := io.WriteString(out, loneEmptyField+"\n")
+			_, err := io.WriteString(out, loneEmptyField+newline)
 			return err
 		}
 		return w.Write(record)
@@ -538,8 +607,13 @@ func (t *Table) writeDelimited(out io.Writer, comma rune) error {
 	// One buffer, refilled per row: encoding/csv needs a []string, and the view
 	// will not surrender its own, so this is the allocation-free bridge.
 	buf := make([]string, 0, t.ColumnCount())
-	for _, v := range t.Rows {
+	for r, v := range t.Rows {
 		buf = v.AppendTo(buf[:0])
+		if t.nullString != "" {
+			for i := range buf {
+				buf[i] = t.displayValue(r, i, buf[i])
+			}
+		}
 		if err := writeRecord(buf); err != nil {
 			return fmt.Errorf("failed to write record: %w", err)
 		}
@@ -561,10 +635,10 @@ func (t *Table) printLTSV(out io.Writer) error {
 	if err := t.EnsureLTSVWritable(); err != nil {
 		return err
 	}
-	for _, v := range t.Rows {
+	for row, v := range t.Rows {
 		r := make(Record, 0, v.Len())
 		for i := range v.Len() {
-			label, data := t.ColumnName(i), v.At(i)
+			label, data := t.ColumnName(i), t.displayValue(row, i, v.At(i))
 			r = append(r, label+":"+data)
 		}
 		if _, err := fmt.Fprintln(out, strings.Join(r, "\t")); err != nil {
@@ -743,7 +817,7 @@ func jsonRenderableValue(value any) error {
 	case nil, string, []byte, bool,
 		int, int8, int16, int32, int64,
 		uint, uint8, uint16, uint32, uint64,
-		float32, float64, time.Time:
+		float32, float64, time.Time, Decimal:
 		return nil
 	default:
 		return fmt.Errorf("cannot render %T as JSON", value)
@@ -833,6 +907,12 @@ func jsonScalarToken(value any) ([]byte, error) {
 	if value == nil {
 		return []byte("null"), nil
 	}
+	// A decimal is written as the number literal it is, digit for digit: JSON
+	// numbers have no precision of their own, and a consumer that reads them
+	// exactly gets 19.90 back rather than the double nearest it.
+	if d, ok := value.(Decimal); ok {
+		return []byte(d.String()), nil
+	}
 	if raw, ok := value.([]byte); ok {
 		// SQLite hands back both TEXT and BLOB as bytes, and JSON has no way to
 		// hold bytes at all. Text passes through as text; anything that is not
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TableKey names columns of a table, in order, for a primary key or an index
+// declared at import time: users(id), or orders(user_id, day) for a key of two
+// columns.
+type TableKey struct {
+	// Table is the table the key is for.
+	Table string
+	// Columns are the key's columns, in the order the key compares them.
+	Columns []string
+}
+
+// String is the key as a user writes it: TABLE(COLUMN[,COLUMN...]).
+func (k TableKey) String() string {
+	return k.Table + "(" + strings.Join(k.Columns, ",") + ")"
+}
+
+// IndexName is the name an index on the key is created with: idx_, the table,
+// and the columns, joined by underscores. It is derived rather than chosen so
+// that declaring the same index twice, or again on a .reload, names the same
+// index, and so .schema shows a name that says what the index is on.
+func (k TableKey) IndexName() string {
+	return "idx_" + k.Table + "_" + strings.Join(k.Columns, "_")
+}
+
+// CreateIndexStatement returns the SQLite statement that creates an index on
+// the key. IF NOT EXISTS makes declaring an index the table already has a
+// no-op rather than an error, since the name says what it is on.
+func (k TableKey) CreateIndexStatement() string {
+	columns := make([]string, len(k.Columns))
+	for i, c := range k.Columns {
+		columns[i] = SQLDialectSQLite.QuoteIdentifier(c)
+	}
+	return fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)",
+		SQLDialectSQLite.QuoteIdentifier(k.IndexName()), SQLDialectSQLite.QuoteIdentifier(k.Table), strings.Join(columns, ", "))
+}
+
+// sameKey reports whether two keys name the same table and columns, compared
+// without ASCII case the way SQLite compares names.
+func (k TableKey) sameKey(other TableKey) bool {
+	if !strings.EqualFold(k.Table, other.Table) || len(k.Columns) != len(other.Columns) {
+		return false
+	}
+	for i := range k.Columns {
+		if !strings.EqualFold(k.Columns[i], other.Columns[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// TableKeys is a set of key declarations.
+type TableKeys []TableKey
+
+// ParseTableKeys parses the form --index and --primary-key take:
+// comma-separated TABLE(COLUMN[,COLUMN...]) keys, such as
+// "users(id),orders(user_id,day)". A comma inside the parentheses separates
+// columns and one outside them separates keys, so a key of several columns
+// needs no quoting of its own beyond the shell's. The same key named twice is
+// refused, since it is a slip rather than a second index.
+func ParseTableKeys(spec string) (TableKeys, error) {
+	const want = "want TABLE(COLUMN[,COLUMN...]), such as users(id)"
+	var keys TableKeys
+	rest := strings.TrimSpace(spec)
+	for {
+		open := strings.Index(rest, "(")
+		closing := strings.Index(rest, ")")
+		if open < 0 || closing < open {
+			return nil, fmt.Errorf("invalid key %q: %s", rest, want)
+		}
+		entry := rest[:closing+1]
+		key := TableKey{Table: strings.TrimSpace(rest[:open])}
+		if key.Table == "" {
+			return nil, fmt.Errorf("invalid key %q: %s", entry, want)
+		}
+		for column := range strings.SplitSeq(rest[open+1:closing], ",") {
+			column = strings.TrimSpace(column)
+			if column == "" {
+				return nil, fmt.Errorf("invalid key %q: %s", entry, want)
+			}
+			for _, previous := range key.Columns {
+				if strings.EqualFold(previous, column) {
+					return nil, fmt.Errorf("invalid key %q: column %q is named twice", entry, column)
+				}
+			}
+			key.Columns = append(key.Columns, column)
+		}
+		if keys.Contains(key) {
+			return nil, fmt.Errorf("invalid key %q: it is already declared", entry)
+		}
+		keys = append(keys, key)
+
+		rest = strings.TrimSpace(rest[closing+1:])
+		if rest == "" {
+			return keys, nil
+		}
+		next, ok := strings.CutPrefix(rest, ",")
+		if !ok {
+			return nil, fmt.Errorf("invalid key list %q: separate keys with a comma, as in users(id),orders(user_id)", spec)
+		}
+		rest = strings.TrimSpace(next)
+	}
+}
+
+// Contains reports whether the set already declares the key.
+func (k TableKeys) Contains(key TableKey) bool {
+	for _, declared := range k {
+		if declared.sameKey(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForTable returns the keys declared for a table, compared without ASCII case
+// the way SQLite compares table names.
+func (k TableKeys) ForTable(table string) TableKeys {
+	var keys TableKeys
+	for _, declared := range k {
+		if strings.EqualFold(declared.Table, table) {
+			keys = append(keys, declared)
+		}
+	}
+	return keys
+}
+
+// String lists the keys comma-separated, in the form each was written.
+func (k TableKeys) String() string {
+	entries := make([]string, len(k))
+	for i, declared := range k {
+		entries[i] = declared.String()
+	}
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTableKeys(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseTableKeys(" users(id) , orders( user_id , day )")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := got.String(); s != "users(id),orders(user_id,day)" {
+		t.Errorf("String() = %q", s)
+	}
+	if keys := got.ForTable("ORDERS"); len(keys) != 1 || keys[0].IndexName() != "idx_orders_user_id_day" {
+		t.Errorf("ForTable(ORDERS) = %v, want the orders key", keys)
+	}
+	if !got.Contains(TableKey{Table: "Users", Columns: []string{"ID"}}) {
+		t.Error("Contains should compare names without case")
+	}
+
+	tests := map[string]string{
+		"users":                    "want TABLE(COLUMN",
+		"(id)":                     "want TABLE(COLUMN",
+		"users()":                  "want TABLE(COLUMN",
+		"users(id,)":               "want TABLE(COLUMN",
+		"users(id":                 "want TABLE(COLUMN",
+		"users(id,ID)":             "named twice",
+		"users(id) orders(id)":     "separate keys with a comma",
+		"users(id),USERS(ID)":      "already declared",
+		"users(id),":               "want TABLE(COLUMN",
+		"users(id),orders(id),x()": "want TABLE(COLUMN",
+	}
+	for spec, want := range tests {
+		if _, err := ParseTableKeys(spec); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseTableKeys(%q) error = %v, want one containing %q", spec, err, want)
+		}
+	}
+}
+
+func TestTableKey_CreateIndexStatement(t *testing.T) {
+	t.Parallel()
+
+	key := TableKey{Table: "order items", Columns: []string{"user_id", `odd"name`}}
+	want := `CREATE INDEX IF NOT EXISTS "idx_order items_user_id_odd""name" ON "order items" ("user_id", "odd""name")`
+	if got := key.CreateIndexStatement(); got != want {
+		t.Errorf("CreateIndexStatement() = %s, want %s", got, want)
+	}
+}
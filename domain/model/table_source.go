@@ -0,0 +1,50 @@
+package model
+
+import "strings"
+
+// ReservedTablePrefix is the table-name prefix sqly keeps for its own
+// bookkeeping inside a session database. A session opened with --db records in
+// a table under it where each table came from, and that table is not data: it
+// is hidden from .tables and write-back, and an import refuses a table name
+// that falls under the prefix so nothing a user loads can be hidden with it.
+const ReservedTablePrefix = "_sqly_"
+
+// IsReservedTableName reports whether name falls under ReservedTablePrefix.
+// SQLite compares ASCII table names case-insensitively, so "_SQLY_x" would
+// collide with the bookkeeping just as "_sqly_x" would.
+func IsReservedTableName(name string) bool {
+	return len(name) >= len(ReservedTablePrefix) &&
+		strings.EqualFold(name[:len(ReservedTablePrefix)], ReservedTablePrefix)
+}
+
+// TableSource is what a persistent session database remembers about one table:
+// the file it was read from, what that file looked like at the time, and what
+// the table held when it last matched the file.
+//
+// An in-memory session keeps the same facts in the shell and loses them on
+// exit, which is fine because the tables go with them. A --db session keeps the
+// tables, so it has to keep the facts too: without them the next run could not
+// tell a table still equal to its source from one that was edited, and would
+// have to import every file again to find out.
+type TableSource struct {
+	// Table is the table name.
+	Table string
+	// Source is the absolute path, or the URL, the table was imported from.
+	Source string
+	// Size is the source file's size in bytes when the table last matched it.
+	Size int64
+	// Digest is the SHA-256 of the source file's bytes when the table last
+	// matched it. Size is compared first because it is free; the digest decides.
+	Digest string
+	// Options describes the import settings the file was read with (encoding,
+	// row-mismatch policy, sheet policy). The same bytes read under different
+	// settings can produce a different table, so a file is only unchanged when
+	// the settings are too.
+	Options string
+	// Fingerprint is the table's content fingerprint at the moment it matched
+	// the source: after the import, or after an in-place save wrote it back.
+	Fingerprint string
+	// FromDirectory marks a table found by expanding a directory argument,
+	// which write-back refuses.
+	FromDirectory bool
+}
@@ -0,0 +1,28 @@
+package model
+
+import "testing"
+
+func TestIsReservedTableName(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		table string
+		want  bool
+	}{
+		{name: "the catalog itself", table: "_sqly_sources", want: true},
+		{name: "any name under the prefix", table: "_sqly_anything", want: true},
+		{name: "the prefix in another case", table: "_SQLY_x", want: true},
+		{name: "the bare prefix", table: "_sqly_", want: true},
+		{name: "a name that only starts like it", table: "_sqly", want: false},
+		{name: "the prefix later in the name", table: "my_sqly_table", want: false},
+		{name: "an ordinary name", table: "users", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := IsReservedTableName(tt.table); got != tt.want {
+				t.Errorf("IsReservedTableName(%q) = %v, want %v", tt.table, got, tt.want)
+			}
+		})
+	}
+}
@@ -350,6 +350,43 @@ func TestTablePrintJSON_NullDistinctFromEmpty(t *testing.T) {
 	})
 }
 
+// TestTableWithNullString checks that a NULL is written as the null string in
+// each text format that takes one, while an empty string stays empty and JSON
+// keeps its null.
+func TestTableWithNullString(t *testing.T) {
+	t.Parallel()
+
+	tbl, err := NewTableFromCells("t", Header{"n", "e"}, [][]Cell{
+		{NewCell(nil), NewCell("")},
+	})
+	if err != nil {
+		t.Fatalf("NewTableFromCells: %v", err)
+	}
+	tbl = tbl.WithNullString("NULL")
+	tests := []struct {
+		mode PrintMode
+		want string
+	}{
+		{mode: PrintModeCSV, want: "n,e\nNULL,\n"},
+		{mode: PrintModeTSV, want: "n\te\nNULL\t\n"},
+		{mode: PrintModeLTSV, want: "n:NULL\te:\n"},
+		{mode: PrintModeTable, want: "+------+---+\n|  n   | e |\n+------+---+\n| NULL |   |\n+------+---+\n"},
+		{mode: PrintModeJSONL, want: "{\"n\":null,\"e\":\"\"}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			t.Parallel()
+			out := &bytes.Buffer{}
+			if err := tbl.Print(out, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(out.String(), tt.want); diff != "" {
+				t.Errorf("value is mismatch (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestTablePrintJSONScalars(t *testing.T) {
 	t.Parallel()
 
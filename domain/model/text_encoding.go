@@ -22,9 +22,28 @@ const (
 	TextEncodingUTF16LE TextEncoding = "utf-16le"
 	// TextEncodingUTF16BE selects big-endian UTF-16 input.
 	TextEncodingUTF16BE TextEncoding = "utf-16be"
+	// TextEncodingWindows1252 selects Windows-1252 input, the Western European
+	// code page. It is also what latin-1 means: the WHATWG Encoding Standard
+	// reads ISO-8859-1 as Windows-1252, because files labelled one are
+	// overwhelmingly the other.
+	TextEncodingWindows1252 TextEncoding = "windows-1252"
+	// TextEncodingGBK selects GBK (code page 936) input, simplified Chinese.
+	TextEncodingGBK TextEncoding = "gbk"
+	// TextEncodingGB18030 selects GB18030 input, the superset of GBK that can
+	// write every Unicode character.
+	TextEncodingGB18030 TextEncoding = "gb18030"
+	// TextEncodingBig5 selects Big5 input, traditional Chinese.
+	TextEncodingBig5 TextEncoding = "big5"
+	// TextEncodingEUCKR selects EUC-KR input, Korean. It is read as code page
+	// 949, the superset Windows writes under the same name.
+	TextEncodingEUCKR TextEncoding = "euc-kr"
+	// TextEncodingAuto guesses each input's encoding from its bytes. It is a
+	// choice made per file as the file is read, not an encoding of its own: a
+	// file is always decoded as one of the encodings above.
+	TextEncodingAuto TextEncoding = "auto"
 )
 
-const textEncodingHelp = "utf-8|shift-jis|euc-jp|iso-2022-jp|utf-16le|utf-16be"
+const textEncodingHelp = "utf-8|shift-jis|euc-jp|iso-2022-jp|utf-16le|utf-16be|windows-1252|gbk|gb18030|big5|euc-kr|auto"
 
 // TextEncodingHelp returns the user-facing list shared by --encoding and
 // .encoding diagnostics.
@@ -38,7 +57,13 @@ func (e TextEncoding) String() string {
 		TextEncodingEUCJP,
 		TextEncodingISO2022JP,
 		TextEncodingUTF16LE,
-		TextEncodingUTF16BE:
+		TextEncodingUTF16BE,
+		TextEncodingWindows1252,
+		TextEncodingGBK,
+		TextEncodingGB18030,
+		TextEncodingBig5,
+		TextEncodingEUCKR,
+		TextEncodingAuto:
 		return string(e)
 	default:
 		return string(TextEncodingUTF8)
@@ -61,6 +86,18 @@ func ParseTextEncoding(name string) (TextEncoding, error) {
 		return TextEncodingUTF16LE, nil
 	case string(TextEncodingUTF16BE), "utf16be":
 		return TextEncodingUTF16BE, nil
+	case string(TextEncodingWindows1252), "windows1252", "cp1252", "latin-1", "latin1", "iso-8859-1", "iso8859-1":
+		return TextEncodingWindows1252, nil
+	case string(TextEncodingGBK), "cp936":
+		return TextEncodingGBK, nil
+	case string(TextEncodingGB18030), "gb-18030":
+		return TextEncodingGB18030, nil
+	case string(TextEncodingBig5), "big-5", "cp950":
+		return TextEncodingBig5, nil
+	case string(TextEncodingEUCKR), "euckr", "cp949", "uhc":
+		return TextEncodingEUCKR, nil
+	case string(TextEncodingAuto):
+		return TextEncodingAuto, nil
 	default:
 		return TextEncodingUTF8, fmt.Errorf("invalid text encoding %q: want %s", name, strings.ReplaceAll(textEncodingHelp, "|", ", "))
 	}
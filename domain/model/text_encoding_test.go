@@ -16,6 +16,12 @@ func TestTextEncoding_String(t *testing.T) {
 		{name: "iso-2022-jp", enc: TextEncodingISO2022JP, want: "iso-2022-jp"},
 		{name: "utf-16le", enc: TextEncodingUTF16LE, want: "utf-16le"},
 		{name: "utf-16be", enc: TextEncodingUTF16BE, want: "utf-16be"},
+		{name: "windows-1252", enc: TextEncodingWindows1252, want: "windows-1252"},
+		{name: "gbk", enc: TextEncodingGBK, want: "gbk"},
+		{name: "gb18030", enc: TextEncodingGB18030, want: "gb18030"},
+		{name: "big5", enc: TextEncodingBig5, want: "big5"},
+		{name: "euc-kr", enc: TextEncodingEUCKR, want: "euc-kr"},
+		{name: "auto", enc: TextEncodingAuto, want: "auto"},
 		{name: "unknown falls back to utf-8", enc: TextEncoding("bogus"), want: "utf-8"},
 	}
 	for _, tt := range tests {
@@ -46,7 +52,17 @@ func TestParseTextEncoding(t *testing.T) {
 		{name: "iso-2022-jp", input: "iso-2022-jp", want: TextEncodingISO2022JP},
 		{name: "utf-16le", input: "utf-16le", want: TextEncodingUTF16LE},
 		{name: "utf-16be", input: "utf-16be", want: TextEncodingUTF16BE},
-		{name: "invalid", input: "latin1", wantErr: true},
+		{name: "windows-1252", input: "windows-1252", want: TextEncodingWindows1252},
+		{name: "latin-1 alias", input: "latin1", want: TextEncodingWindows1252},
+		{name: "iso-8859-1 alias", input: "ISO-8859-1", want: TextEncodingWindows1252},
+		{name: "gbk", input: "gbk", want: TextEncodingGBK},
+		{name: "cp936 alias", input: "cp936", want: TextEncodingGBK},
+		{name: "gb18030", input: "gb18030", want: TextEncodingGB18030},
+		{name: "big5", input: "big5", want: TextEncodingBig5},
+		{name: "euc-kr", input: "euc-kr", want: TextEncodingEUCKR},
+		{name: "cp949 alias", input: "cp949", want: TextEncodingEUCKR},
+		{name: "auto", input: "auto", want: TextEncodingAuto},
+		{name: "invalid", input: "koi8-r", wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
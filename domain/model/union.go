@@ -0,0 +1,28 @@
+package model
+
+// UnionSourceColumn is the column a union import adds, when asked to, holding
+// the file each row was read from.
+const UnionSourceColumn = "_source_file"
+
+// UnionShard is one file a union import reads into its table.
+//
+// Data that arrives as daily shards — events-2024-01-01.csv, events-2024-01-02.csv
+// — is one table split by the program that wrote it, and querying it as one
+// table per day makes every question a UNION ALL over however many days there
+// are. A union import reads the shards into one table instead, aligning their
+// columns by name.
+type UnionShard struct {
+	// Path is where the shard is read from: the file itself, or a staged copy of
+	// a download or a re-encoded file.
+	Path string
+	// Label is what messages call the shard, and what UnionSourceColumn holds
+	// for its rows: the path the user's pattern matched, or the URL.
+	Label string
+	// Partition holds the key=value directories the shard was found under, for
+	// a partitioned import. Each becomes a column holding its value for every
+	// row of the shard. It is empty for a shard of an ordinary union.
+	Partition []PartitionValue
+	// Dialect is how the shard is written when it is CSV or TSV; the zero value
+	// is the format's own. A shard of any other format ignores it.
+	Dialect CSVDialect
+}
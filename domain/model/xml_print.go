@@ -0,0 +1,234 @@
+package model
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// xmlRootElement and xmlRowElement are the two names an XML export writes on
+// its own account: the document element, and the element each row becomes.
+//
+// They are fixed rather than taken from the table name because the table name is
+// not always there — a query result has none — and not always a valid XML name
+// when it is: 2024-sales.csv is table 2024-sales, and no XML name may start with
+// a digit. A partner's mapping is also written against element names, so names
+// that changed with the source file would break it on the next file. The pair
+// is also what sqly's own XML reader takes as its records by default: the
+// children of the root, all with one name.
+const (
+	xmlRootElement = "rows"
+	xmlRowElement  = "row"
+)
+
+// xmlAttributePrefix marks a column that becomes an attribute of the row element
+// rather than a child of it. It is the prefix sqly's XML reader gives an
+// attribute whose name a child element also uses, so a table read from XML
+// writes its attributes back as attributes.
+const xmlAttributePrefix = "@"
+
+// xmlField is where one column goes in a row: the XML name it is written under,
+// and whether that is an attribute or a child element.
+type xmlField struct {
+	name      string
+	attribute bool
+}
+
+// XMLName turns a column name into a valid XML 1.0 element or attribute name.
+//
+// A character a name cannot hold becomes '_', and a name that cannot start the
+// way it does gets a leading '_': a digit, '-', or '.' may follow the first
+// character but not be it, and a name starting with "xml" in any case is
+// reserved by the specification. A colon is replaced too, although XML allows
+// it, because a reader that knows namespaces takes the part before it for a
+// prefix that was never declared.
+//
+// The mapping is not one-to-one — "unit price" and "unit_price" both become
+// unit_price — which is why the export refuses a header whose names collide
+// after it rather than writing two columns into one element name.
+func XMLName(column string) string {
+	var b strings.Builder
+	for i, r := range column {
+		if i == 0 && !isXMLNameStart(r) && isXMLNameChar(r) {
+			b.WriteByte('_')
+		}
+		if !isXMLNameChar(r) {
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	name := b.String()
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		name = "_" + name
+	}
+	return name
+}
+
+// isXMLNameStart reports whether r may start a name. It follows the
+// NameStartChar production closely enough for a column name: letters and '_',
+// with ':' left out for the reason XMLName gives.
+func isXMLNameStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// isXMLNameChar reports whether r may appear in a name after its first
+// character: the start characters, digits, '-', '.', and the combining marks
+// some scripts need to spell a word at all.
+func isXMLNameChar(r rune) bool {
+	return isXMLNameStart(r) || unicode.IsDigit(r) || r == '-' || r == '.' ||
+		unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r) || r == '·'
+}
+
+// XMLCanCarry reports whether XML 1.0 can hold r, following its Char production.
+// A Go string never yields a surrogate when ranged over — invalid bytes decode to
+// U+FFFD — so that half of the production cannot be reached from here. The
+// callers reject a value holding such bytes before this runs, so a U+FFFD
+// reaching it is one the data really contained.
+//
+// It lives here rather than beside the XLSX writer because two formats are XML
+// now, and the rule for what either can carry is the same rule.
+func XMLCanCarry(r rune) bool {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return true
+	case r < 0x20:
+		return false
+	case r == 0xFFFE || r == 0xFFFF:
+		return false
+	default:
+		return true
+	}
+}
+
+// xmlFields maps the header to the names the row element uses, and refuses a
+// header that cannot be written as one.
+func (t *Table) xmlFields() ([]xmlField, error) {
+	fields := make([]xmlField, t.ColumnCount())
+	// What an import would call each field: the reader names an attribute after
+	// it, and an attribute whose name is also an element's gets the prefix back.
+	// The reimport check runs over these rather than the column names, because
+	// they are what the file will hold.
+	reimported := make(Header, t.ColumnCount())
+	taken := make(map[xmlField]string, t.ColumnCount())
+	for i, column := range t.Columns {
+		field := xmlField{name: XMLName(column)}
+		if rest, ok := strings.CutPrefix(column, xmlAttributePrefix); ok && rest != "" {
+			field = xmlField{name: XMLName(rest), attribute: true}
+		}
+		if first, ok := taken[field]; ok {
+			return nil, fmt.Errorf("xml: column names %q and %q are both written as %q, so one would hide the other; alias one of them (SELECT a AS a1, b AS a2)", first, column, field.name)
+		}
+		taken[field] = column
+		fields[i] = field
+		reimported[i] = field.name
+	}
+	for i, field := range fields {
+		if _, clash := taken[xmlField{name: field.name}]; field.attribute && clash {
+			reimported[i] = xmlAttributePrefix + field.name
+		}
+	}
+	if err := EnsureHeaderReimportable(formatXML, reimported); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// EnsureXMLWritable reports whether every value in the table can be written as
+// XML. Like the LTSV and JSON checks, it runs in full before the first byte goes
+// out, so a value refused in row 500 does not leave the 499 before it on stdout
+// looking like a complete document — which it would not even be, with the root
+// element never closed.
+//
+// XML 1.0 has no escape for most control characters: &#1; is as forbidden as
+// the byte itself. So a value holding one is refused rather than dropped or
+// replaced, which is the rule the XLSX writer already follows for the same
+// characters, and bytes that are not UTF-8 are refused the way csv refuses them.
+func (t *Table) EnsureXMLWritable() error {
+	for _, v := range t.Rows {
+		for i := range v.Len() {
+			label, value := t.ColumnName(i), v.At(i)
+			if err := ensureTextValueRepresentable(formatXML, label, value); err != nil {
+				return err
+			}
+			for _, r := range value {
+				if !XMLCanCarry(r) {
+					return fmt.Errorf("xml: value for column %q contains the character U+%04X, which XML 1.0 cannot represent even as a character reference; remove it or export to csv/tsv/json", label, r)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// printXML prints the table as one <row> element per record inside a <rows>
+// document element. A column becomes a child element, or an attribute when its
+// name starts with '@', and a NULL is written as no element or attribute at
+// all: an empty element is the empty string, and the two are different values to
+// every consumer that reads the file.
+//
+// Values are written as text, the way csv writes them. XML has no number type
+// of its own, and a schema that says which element holds one is the consumer's
+// to write, not sqly's to guess.
+func (t *Table) printXML(out io.Writer) error {
+	fields, err := t.xmlFields()
+	if err != nil {
+		return err
+	}
+	if err := t.EnsureXMLWritable(); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	if t.RowCount() == 0 {
+		fmt.Fprintf(&b, "<%s></%s>\n", xmlRootElement, xmlRootElement)
+		_, err := io.WriteString(out, b.String())
+		return err
+	}
+	fmt.Fprintf(&b, "<%s>\n", xmlRootElement)
+	if _, err := io.WriteString(out, b.String()); err != nil {
+		return err
+	}
+
+	values := make([]string, 0, t.ColumnCount())
+	for row, record := range t.Rows {
+		values = record.AppendTo(values[:0])
+		b.Reset()
+		b.WriteString("  <" + xmlRowElement)
+		for col, field := range fields {
+			if !field.attribute || col >= len(values) || t.IsNull(row, col) {
+				continue
+			}
+			b.WriteString(" " + field.name + `="`)
+			writeXMLText(&b, values[col])
+			b.WriteByte('"')
+		}
+		b.WriteString(">\n")
+		for col, field := range fields {
+			if field.attribute || col >= len(values) || t.IsNull(row, col) {
+				continue
+			}
+			b.WriteString("    <" + field.name + ">")
+			writeXMLText(&b, values[col])
+			b.WriteString("</" + field.name + ">\n")
+		}
+		b.WriteString("  </" + xmlRowElement + ">\n")
+		if _, err := io.WriteString(out, b.String()); err != nil {
+			return fmt.Errorf("failed to write XML row %d: %w", row+1, err)
+		}
+	}
+	_, err = fmt.Fprintf(out, "</%s>\n", xmlRootElement)
+	return err
+}
+
+// writeXMLText escapes s for element text and attribute values alike. Tabs and
+// line breaks are written as character references so an attribute keeps them —
+// a parser normalizes a literal one in an attribute to a space — and a carriage
+// return survives in element text, where a parser would fold "\r\n" to "\n".
+func writeXMLText(b *strings.Builder, s string) {
+	// xml.EscapeText only fails when its writer does, and a strings.Builder
+	// does not.
+	_ = xml.EscapeText(b, []byte(s))
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTable_PrintXML(t *testing.T) {
+	t.Parallel()
+
+	table, err := NewTableFromCells("t", Header{"@id", "name", "note", "unit price"}, [][]Cell{
+		{NewCell(int64(1)), NewCell("alice"), NewCell(`a<b & "c"`), NewCell(1.5)},
+		{NewCell(int64(2)), NewCell(""), NewCell(nil), NewCell(nil)},
+		{NewCell(nil), NewCell("line\nbreak"), NewCell("x"), NewCell(2.0)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := table.Print(&out, PrintModeXML); err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<rows>
+  <row id="1">
+    <name>alice</name>
+    <note>a&lt;b &amp; &#34;c&#34;</note>
+    <unit_price>1.5</unit_price>
+  </row>
+  <row id="2">
+    <name></name>
+  </row>
+  <row>
+    <name>line&#xA;break</name>
+    <note>x</note>
+    <unit_price>2</unit_price>
+  </row>
+</rows>
+`
+	if got := out.String(); got != want {
+		t.Errorf("Print(xml) =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTable_PrintXMLWithNoRows(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if err := NewTable("t", Header{"a"}, nil).Print(&out, PrintModeXML); err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+	if want := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<rows></rows>\n"; out.String() != want {
+		t.Errorf("Print(xml) = %q, want %q", out.String(), want)
+	}
+}
+
+// TestTable_PrintXMLRefusals checks what XML cannot carry is refused before a
+// byte is written, so stdout never holds half a document.
+func TestTable_PrintXMLRefusals(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header Header
+		record Record
+		want   string
+	}{
+		{name: "a control character", header: Header{"v"}, record: Record{"a\x01b"}, want: "U+0001"},
+		{name: "bytes that are not UTF-8", header: Header{"v"}, record: Record{"\xff"}, want: "not valid UTF-8"},
+		{name: "two names that map to one element", header: Header{"a b", "a_b"}, record: Record{"1", "2"}, want: `both written as "a_b"`},
+		{name: "two names an import folds into one", header: Header{"id", "ID"}, record: Record{"1", "2"}, want: "one column to an import"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			err := NewTable("t", tt.header, []Record{tt.record}).Print(&out, PrintModeXML)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Print(xml) error = %v, want one containing %q", err, tt.want)
+			}
+			if out.Len() != 0 {
+				t.Errorf("Print(xml) wrote %q before failing, want nothing", out.String())
+			}
+		})
+	}
+}
+
+func TestXMLName(t *testing.T) {
+	t.Parallel()
+
+	for column, want := range map[string]string{
+		"name":       "name",
+		"unit price": "unit_price",
+		"1st":        "_1st",
+		"-x":         "_-x",
+		"a:b":        "a_b",
+		"XmlData":    "_XmlData",
+		"":           "_",
+		"名前":         "名前",
+		"count(*)":   "count___",
+	} {
+		if got := XMLName(column); got != want {
+			t.Errorf("XMLName(%q) = %q, want %q", column, got, want)
+		}
+	}
+}
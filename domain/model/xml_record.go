@@ -0,0 +1,34 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseXMLRecordPath splits an --xml-record value such as /feed/item into the
+// element names from the root to a record element. The path is absolute because
+// the same name often means different things at different depths — an <id> of a
+// feed and an <id> of each of its items — and a relative one would take both.
+//
+// A namespace prefix is dropped, as it is from the document's element names, so
+// /atom:feed/atom:entry and /feed/entry name the same elements.
+//
+// It is parsed in one place so the flag is refused at the command line, before
+// anything is read, by the same rule the import applies.
+func ParseXMLRecordPath(path string) ([]string, error) {
+	trimmed := strings.TrimPrefix(path, "/")
+	if !strings.HasPrefix(path, "/") || trimmed == "" {
+		return nil, fmt.Errorf("invalid --xml-record %q: want the path of the record element from the root, such as /feed/item", path)
+	}
+	names := strings.Split(strings.TrimSuffix(trimmed, "/"), "/")
+	for i, name := range names {
+		if _, local, ok := strings.Cut(name, ":"); ok {
+			name = local
+		}
+		if name == "" || strings.ContainsAny(name, " \t[]*@:") {
+			return nil, fmt.Errorf("invalid --xml-record %q: want element names separated by /, such as /feed/item", path)
+		}
+		names[i] = name
+	}
+	return names, nil
+}
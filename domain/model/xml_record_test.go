@@ -0,0 +1,46 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseXMLRecordPath(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "one level below the root", input: "/feed/item", want: []string{"feed", "item"}},
+		{name: "the root itself", input: "/rows", want: []string{"rows"}},
+		{name: "a trailing slash is dropped", input: "/feed/items/item/", want: []string{"feed", "items", "item"}},
+		{name: "a namespace prefix is dropped", input: "/atom:feed/atom:entry", want: []string{"feed", "entry"}},
+		{name: "a prefix with no name is rejected", input: "/feed/atom:", wantErr: true},
+		{name: "a relative path is rejected", input: "feed/item", wantErr: true},
+		{name: "the bare root is rejected", input: "/", wantErr: true},
+		{name: "an empty segment is rejected", input: "/feed//item", wantErr: true},
+		{name: "a predicate is rejected", input: "/feed/item[1]", wantErr: true},
+		{name: "a wildcard is rejected", input: "/feed/*", wantErr: true},
+		{name: "an attribute step is rejected", input: "/feed/@id", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseXMLRecordPath(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseXMLRecordPath(%q) = %v, want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseXMLRecordPath(%q): %v", tt.input, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseXMLRecordPath(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
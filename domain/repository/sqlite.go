@@ -31,4 +31,17 @@ type SQLite3Repository interface {
 	Query(ctx context.Context, query string) (*model.Table, error)
 	// Exec execute "INSERT" or "UPDATE" or "DELETE" statement
 	Exec(ctx context.Context, statement string) (int64, error)
+	// TableSources returns what the session database remembers about where its
+	// tables came from. A database that has never recorded anything returns an
+	// empty slice, not an error.
+	TableSources(ctx context.Context) ([]model.TableSource, error)
+	// RecordTableSources stores or replaces the record of each given table.
+	RecordTableSources(ctx context.Context, sources []model.TableSource) error
+	// ForgetTableSources drops the record of each named table. A name with no
+	// record is ignored.
+	ForgetTableSources(ctx context.Context, tableNames []string) error
+	// SaveAsSQLite writes every table of the session, with its schema, indexes,
+	// views, and triggers, into a new SQLite database at path and returns the
+	// names of the tables written.
+	SaveAsSQLite(ctx context.Context, path string) ([]string, error)
 }
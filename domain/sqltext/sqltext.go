@@ -44,6 +44,9 @@ const (
 	Word Kind = iota
 	// Semicolon is a ";" in code, which is where a statement can end.
 	Semicolon
+	// closeParen is a ")" in code. Tokens never yields one; MatchingParen asks
+	// the walk for them.
+	closeParen
 )
 
 // Token is one significant thing found in code — outside every string literal,
@@ -67,16 +70,35 @@ func (t Token) Text(s string) string { return s[t.Start:t.End] }
 // Tokens iterates the code tokens of s in order.
 func Tokens(s string) iter.Seq[Token] {
 	return func(yield func(Token) bool) {
-		scan(s, yield)
+		scan(s, false, yield)
 	}
 }
 
+// MatchingParen returns the offset of the ")" that closes the "(" at s[open],
+// or -1 when s[open] is not a "(" or nothing closes it. A parenthesis inside a
+// string literal, a quoted identifier, or a comment is not counted, which is
+// why this cannot be a count of the bytes.
+func MatchingParen(s string, open int) int {
+	if open < 0 || open >= len(s) || s[open] != '(' {
+		return -1
+	}
+	closing := -1
+	scan(s[open:], true, func(tok Token) bool {
+		if tok.Kind == closeParen && tok.Depth == 0 {
+			closing = open + tok.Start
+			return false
+		}
+		return true
+	})
+	return closing
+}
+
 // EndsInsideBlockComment reports whether s stops before the "*/" that would
 // close a block comment it opened. A "/*" inside a string literal or a line
 // comment opens nothing, which is the reason this cannot be a search for the
 // last "/*".
 func EndsInsideBlockComment(s string) bool {
-	return scan(s, func(Token) bool { return true })
+	return scan(s, false, func(Token) bool { return true })
 }
 
 // HasWord reports whether word appears in s as a whole word in code. It is not a
@@ -192,8 +214,9 @@ func isWordByte(c byte) bool {
 // scan walks s, yielding each code token, and reports whether s ends inside an
 // unterminated block comment. A yield returning false stops the walk, in which
 // case that answer describes only the part reached — which is why
-// EndsInsideBlockComment never stops early.
-func scan(s string, yield func(Token) bool) (inBlockComment bool) {
+// EndsInsideBlockComment never stops early. parens adds a closeParen token for
+// each ")" in code, at the depth it closes back to.
+func scan(s string, parens bool, yield func(Token) bool) (inBlockComment bool) {
 	var (
 		depth                 int
 		inSingle, inDouble    bool
@@ -254,6 +277,9 @@ func scan(s string, yield func(Token) bool) (inBlockComment bool) {
 				if depth > 0 {
 					depth--
 				}
+				if parens && !yield(Token{Kind: closeParen, Start: i, End: i + 1, Depth: depth}) {
+					return inBlockComment
+				}
 			case c == ';':
 				if !yield(Token{Kind: Semicolon, Start: i, End: i + 1, Depth: depth}) {
 					return inBlockComment
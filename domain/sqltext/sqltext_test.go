@@ -265,3 +265,31 @@ func TestScannerIgnoresMultibyteText(t *testing.T) {
 		t.Error("a comment opener inside a multibyte literal opened a comment")
 	}
 }
+
+func TestMatchingParen(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		s    string
+		open int
+		want int
+	}{
+		{"a flat call", "f(a, b) + 1", 1, 6},
+		{"a nested call", "f(g(a), (b)) x", 1, 11},
+		{"a parenthesis in a string", "f(')', a)", 1, 8},
+		{"a parenthesis in a quoted identifier", `f(")", [)], a)`, 1, 13},
+		{"a parenthesis in a comment", "f(a -- )\n)", 1, 9},
+		{"nothing closes it", "f(a, (b)", 1, -1},
+		{"not a parenthesis", "f(a)", 0, -1},
+		{"out of range", "f(a)", 9, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := MatchingParen(tt.s, tt.open); got != tt.want {
+				t.Errorf("MatchingParen(%q, %d) = %d, want %d", tt.s, tt.open, got, tt.want)
+			}
+		})
+	}
+}
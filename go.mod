@@ -4,6 +4,7 @@ go 1.25.8
 
 require (
 	github.com/adrg/xdg v0.5.3
+	github.com/apache/arrow/go/v18 v18.0.0-20241007013041-ab95a4d25142
 	github.com/caarlos0/env/v11 v11.4.1
 	github.com/creack/pty v1.1.24
 	github.com/fatih/color v1.19.0
@@ -27,7 +28,6 @@ require (
 require (
 	github.com/JohnCGriffin/overflow v0.0.0-20211019200055-46fa312c352c // indirect
 	github.com/andybalholm/brotli v1.2.1 // indirect
-	github.com/apache/arrow/go/v18 v18.0.0-20241007013041-ab95a4d25142 // indirect
 	github.com/apache/thrift v0.23.0 // indirect
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/clipperhouse/displaywidth v0.11.0 // indirect
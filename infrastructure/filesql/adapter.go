@@ -12,6 +12,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/nao1215/filesql"
+	"github.com/nao1215/sqly/domain/cleanup"
 	"github.com/nao1215/sqly/domain/model"
 	infra "github.com/nao1215/sqly/infrastructure"
 )
@@ -37,6 +38,17 @@ type FileSQLAdapter struct {
 	// not build them — and is set by the --include-hidden-sheets flag for the
 	// whole session, so a later .import applies it too.
 	includeHiddenSheets bool
+	// xmlRecordPath names the elements of an XML input that are its rows, as a
+	// path from the root such as /feed/item. Empty takes the children of the
+	// root element. It is set by --xml-record for the whole session.
+	xmlRecordPath string
+	// sqliteTables names the tables an import of a SQLite database copies.
+	// Empty copies every table. It is set by --sqlite-tables for the whole
+	// session.
+	sqliteTables []string
+	// cleaning is what is done to the values of every CSV and TSV input as it
+	// is read. It is set by --trim and --null-values for the whole session.
+	cleaning model.ImportCleaning
 	// skipped holds what --row-mismatch skip discarded during the imports of
 	// this session, keyed by table. A dropped row is what the user asked for,
 	// but an import that says nothing leaves one dropped row and most of the
@@ -100,6 +112,12 @@ func (f *FileSQLAdapter) ExcelSheetTableNames(path string, sheetNames []string)
 	return tables, nil
 }
 
+// SetXMLRecordPath sets which elements of an XML input subsequent imports read
+// as rows. An empty path takes the children of the root element.
+func (f *FileSQLAdapter) SetXMLRecordPath(path string) {
+	f.xmlRecordPath = path
+}
+
 // filesqlExcelSheetPolicy maps sqly's setting onto the filesql policy that
 // drives the import. sqly's default is visible-only and filesql's is all, so
 // the mapping is where the two defaults are reconciled — deliberately, in one
@@ -125,8 +143,8 @@ func filesqlRowMismatchPolicy(policy model.RowMismatchPolicy) filesql.MalformedR
 	}
 }
 
-// stageFunc loads one input path into an open transaction.
-type stageFunc[T infra.Tx] func(ctx context.Context, tx T, path string) error
+// stageFunc loads one input into an open transaction.
+type stageFunc[T infra.Tx] func(ctx context.Context, tx T, file model.FileLoad) error
 
 // atomicImport is one ordered multi-file import: where the transaction comes
 // from, and how a single path is staged into it. Splitting these two out of
@@ -138,7 +156,7 @@ type atomicImport[T infra.Tx] struct {
 	stage    stageFunc[T]
 }
 
-// run stages every path inside one transaction.
+// run stages every input inside one transaction.
 //
 // Everything an import touches lives in that transaction, including the
 // metadata filesql keeps for writing ACH and Fedwire files back. There is no
@@ -149,12 +167,12 @@ type atomicImport[T infra.Tx] struct {
 // which the returned error says; WithTransaction owns commit, rollback, and the
 // joining of a cleanup error onto the cause.
 //
-// Staging runs in the order the paths were given, so when several inputs claim
+// Staging runs in the order the inputs were given, so when several inputs claim
 // the same base name, write-back resolves to the last one.
-func (a atomicImport[T]) run(ctx context.Context, paths []string) error {
+func (a atomicImport[T]) run(ctx context.Context, files []model.FileLoad) error {
 	committed, err := infra.WithTransaction(ctx, a.beginner, func(tx T) error {
-		for _, path := range paths {
-			if err := a.stage(ctx, tx, path); err != nil {
+		for _, file := range files {
+			if err := a.stage(ctx, tx, file); err != nil {
 				return err
 			}
 		}
@@ -174,9 +192,10 @@ func (a atomicImport[T]) run(ctx context.Context, paths []string) error {
 // LoadFiles loads multiple files into the shared database using filesql. Either
 // every input is applied or none is: a failure on the last of ten inputs rolls
 // back the nine before it, leaving tables and views that existed beforehand
-// untouched.
-func (f *FileSQLAdapter) LoadFiles(ctx context.Context, filePaths ...string) error {
-	if len(filePaths) == 0 {
+// untouched. Each input carries what the import declares for it, so nothing one
+// load is told carries over into the next.
+func (f *FileSQLAdapter) LoadFiles(ctx context.Context, files ...model.FileLoad) error {
+	if len(files) == 0 {
 		return nil
 	}
 	if f.sharedDB == nil {
@@ -185,13 +204,57 @@ func (f *FileSQLAdapter) LoadFiles(ctx context.Context, filePaths ...string) err
 	return atomicImport[*sql.Tx]{
 		beginner: infra.SQLTxBeginner{DB: f.sharedDB},
 		stage:    f.stageFile,
-	}.run(ctx, filePaths)
+	}.run(ctx, files)
 }
 
 // stageFile parses one input and applies it to the open import transaction.
-func (f *FileSQLAdapter) stageFile(ctx context.Context, tx *sql.Tx, path string) error {
+func (f *FileSQLAdapter) stageFile(ctx context.Context, tx *sql.Tx, file model.FileLoad) (err error) {
+	path := file.Path
+	// The schema is read before the file, so a sidecar that cannot be used is
+	// reported without parsing a file it would only have refused afterwards.
+	declarations, err := tableDeclarations(file)
+	if err != nil {
+		return importError(path, err)
+	}
+	if IsSQLiteFile(path) {
+		if err := f.stageSQLiteFile(ctx, tx, path); err != nil {
+			return importError(path, err)
+		}
+		if err := declareTables(ctx, tx, declarations); err != nil {
+			return importError(path, err)
+		}
+		return nil
+	}
+	loadPath := path
+	var absent xmlAbsentCells
+	if IsXMLFile(path) {
+		staged, absentCells, release, stageErr := stageXMLAsCSV(path, f.xmlRecordPath)
+		if stageErr != nil {
+			return importError(path, stageErr)
+		}
+		defer func() {
+			err = cleanup.Join(err, release(), "remove xml staging directory")
+		}()
+		loadPath, absent = staged, absentCells
+	}
+	cleaned, staged := false, false
+	cleaning := f.cleaning
+	cleaning.Decimals = stagedDecimalColumns(cleaning.Decimals, declarations)
+	if dialect := file.Dialect; !dialect.IsZero() || !cleaning.IsZero() {
+		if tsv, ok := delimitedKind(path); ok {
+			stagedPath, release, stageErr := stageDialectAsCSV(path, dialect, tsv, cleaning)
+			if stageErr != nil {
+				return importError(path, stageErr)
+			}
+			defer func() {
+				err = cleanup.Join(err, release(), "remove csv staging directory")
+			}()
+			loadPath, staged = stagedPath, true
+			cleaned = f.cleaning.MapsNulls()
+		}
+	}
 	builder := filesql.NewBuilder().
-		AddPath(path).
+		AddPath(loadPath).
 		WithMalformedRowPolicy(filesqlRowMismatchPolicy(f.rowMismatchPolicy)).
 		WithExcelSheetPolicy(filesqlExcelSheetPolicy(f.includeHiddenSheets))
 	validated, err := builder.Build(ctx)
@@ -206,6 +269,25 @@ func (f *FileSQLAdapter) stageFile(ctx context.Context, tx *sql.Tx, path string)
 		}
 		return importError(path, err)
 	}
+	// Before the declarations, so a column declared numeric finds NULL where
+	// the file had a placeholder or an XML record had no value, not an empty
+	// one.
+	if err := absent.apply(ctx, tx, GetTableNameFromFilePath(loadPath)); err != nil {
+		return importError(path, err)
+	}
+	if cleaned {
+		if err := nullEmptyValues(ctx, tx, GetTableNameFromFilePath(loadPath)); err != nil {
+			return importError(path, err)
+		}
+	}
+	if staged {
+		if declarations, err = declareDecimalColumns(ctx, tx, declarations, GetTableNameFromFilePath(loadPath), f.cleaning.Decimals); err != nil {
+			return importError(path, err)
+		}
+	}
+	if err := declareTables(ctx, tx, declarations); err != nil {
+		return importError(path, err)
+	}
 	return nil
 }
 
@@ -403,15 +485,20 @@ func (e *FileSQLError) Error() string {
 }
 
 // supportedBaseExts lists the base file extensions that filesql can handle.
-var supportedBaseExts = []string{".csv", ".tsv", ".ltsv", ".parquet", ".xlsx", ".json", ".jsonl"}
+var supportedBaseExts = []string{".csv", ".tsv", ".ltsv", ".parquet", ".xlsx", ".json", ".jsonl", model.ExtXML}
 
 // compressionExts lists the compression extensions that filesql can decompress.
 var compressionExts = []string{".gz", ".bz2", ".xz", ".zst", ".z", ".snappy", ".s2", ".lz4"}
 
 // IsSupportedFile checks if the file has a format supported by filesql.
 // This covers all formats that filesql can import: CSV, TSV, LTSV, JSON, JSONL,
-// Parquet, XLSX (with compression variants), plus ACH and Fedwire.
+// Parquet, XLSX (with compression variants), plus ACH and Fedwire; XML,
+// which sqly converts to CSV before filesql sees it; and SQLite databases,
+// which sqly copies itself.
 func IsSupportedFile(filePath string) bool {
+	if IsSQLiteFile(filePath) {
+		return true
+	}
 	lower := strings.ToLower(filePath)
 
 	// Check ACH and Fedwire (no compression variants)
@@ -436,6 +523,19 @@ func IsSupportedFile(filePath string) bool {
 	return false
 }
 
+// IsXMLFile checks if the file is an XML document (.xml), including compressed
+// variants.
+func IsXMLFile(filePath string) bool {
+	lower := strings.ToLower(filePath)
+	for _, ext := range compressionExts {
+		if before, ok := strings.CutSuffix(lower, ext); ok {
+			lower = before
+			break
+		}
+	}
+	return strings.HasSuffix(lower, model.ExtXML)
+}
+
 // IsExcelFile checks if the file is an Excel format (.xlsx), including compressed variants.
 func IsExcelFile(filePath string) bool {
 	lower := strings.ToLower(filePath)
@@ -39,9 +39,19 @@ func newTestAdapter(db *sql.DB) *testAdapter {
 	}
 }
 
-// LoadFile loads one file, the single-path case of LoadFiles.
+// LoadFile loads one file with nothing declared for it, the single-path case
+// of LoadFiles.
 func (a *testAdapter) LoadFile(ctx context.Context, filePath string) error {
-	return a.LoadFiles(ctx, filePath)
+	return a.LoadFiles(ctx, model.FileLoad{Path: filePath})
+}
+
+// fileLoads is the files at paths, with nothing declared for any of them.
+func fileLoads(paths ...string) []model.FileLoad {
+	loads := make([]model.FileLoad, 0, len(paths))
+	for _, path := range paths {
+		loads = append(loads, model.FileLoad{Path: path})
+	}
+	return loads
 }
 
 // Query reads rows the way the session does.
@@ -522,7 +532,7 @@ func TestFileSQLAdapter_LoadFilesNilDB(t *testing.T) {
 	ctx := context.Background()
 
 	// Test LoadFiles with nil database
-	err := adapter.LoadFiles(ctx, "test.csv")
+	err := adapter.LoadFiles(ctx, fileLoads("test.csv")...)
 	if err == nil {
 		t.Fatal("Expected LoadFiles to fail with nil database")
 	}
@@ -1287,7 +1297,7 @@ func BenchmarkAdapterLoadFiles(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
 	for range b.N {
-		if err := adapter.LoadFiles(context.Background(), csv); err != nil {
+		if err := adapter.LoadFiles(context.Background(), fileLoads(csv)...); err != nil {
 			b.Fatal(err)
 		}
 	}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/nao1215/sqly/domain/model"
 	infra "github.com/nao1215/sqly/infrastructure"
 )
 
@@ -170,8 +171,8 @@ func TestAtomicImportFailureMatrix(t *testing.T) {
 			var stagedPaths []string
 			importer := atomicImport[*fakeTx]{
 				beginner: beginner,
-				stage: func(_ context.Context, _ *fakeTx, path string) error {
-					stagedPaths = append(stagedPaths, path)
+				stage: func(_ context.Context, _ *fakeTx, file model.FileLoad) error {
+					stagedPaths = append(stagedPaths, file.Path)
 					if tt.stageErrAt == len(stagedPaths) {
 						return errStage
 					}
@@ -179,7 +180,7 @@ func TestAtomicImportFailureMatrix(t *testing.T) {
 				},
 			}
 
-			err := importer.run(t.Context(), paths)
+			err := importer.run(t.Context(), fileLoads(paths...))
 
 			if len(tt.wantErrs) == 0 {
 				if err != nil {
@@ -225,11 +226,11 @@ func TestAtomicImportNeverRollsBackAfterCommit(t *testing.T) {
 	tx := &fakeTx{rollbackErr: sql.ErrTxDone}
 	importer := atomicImport[*fakeTx]{
 		beginner: &fakeBeginner{tx: tx},
-		stage: func(_ context.Context, _ *fakeTx, _ string) error {
+		stage: func(_ context.Context, _ *fakeTx, _ model.FileLoad) error {
 			return nil
 		},
 	}
-	if err := importer.run(t.Context(), []string{"a.csv"}); err != nil {
+	if err := importer.run(t.Context(), fileLoads("a.csv")); err != nil {
 		t.Fatalf("run() = %v, want nil", err)
 	}
 	if tx.rollbacks != 0 {
@@ -247,11 +248,11 @@ func TestAtomicImportReportsTxDoneRollback(t *testing.T) {
 	tx := &fakeTx{rollbackErr: sql.ErrTxDone}
 	importer := atomicImport[*fakeTx]{
 		beginner: &fakeBeginner{tx: tx},
-		stage: func(_ context.Context, _ *fakeTx, _ string) error {
+		stage: func(_ context.Context, _ *fakeTx, _ model.FileLoad) error {
 			return stageErr
 		},
 	}
-	err := importer.run(t.Context(), []string{"a.csv"})
+	err := importer.run(t.Context(), fileLoads("a.csv"))
 	if !errors.Is(err, stageErr) {
 		t.Errorf("errors.Is(err, stageErr) = false; err = %v", err)
 	}
@@ -295,7 +296,7 @@ func TestLoadFilesRollsBackEveryEarlierInput(t *testing.T) {
 	}
 
 	adapter := newTestAdapter(db)
-	if err := adapter.LoadFiles(t.Context(), good1, good2, broken); err == nil {
+	if err := adapter.LoadFiles(t.Context(), fileLoads(good1, good2, broken)...); err == nil {
 		t.Fatal("LoadFiles with a broken last input = nil error, want an error")
 	}
 
@@ -318,7 +319,7 @@ func TestLoadFilesRollsBackEveryEarlierInput(t *testing.T) {
 
 	// The failed import must not poison the session: importing the good inputs
 	// afterwards has to work.
-	if err := adapter.LoadFiles(t.Context(), good1, good2); err != nil {
+	if err := adapter.LoadFiles(t.Context(), fileLoads(good1, good2)...); err != nil {
 		t.Fatalf("re-import after a rolled-back import failed: %v", err)
 	}
 	names = tableNames(t, db)
@@ -355,7 +356,7 @@ func TestLoadFilesRollbackLeavesNoACHWriteBackMetadata(t *testing.T) {
 	adapter := newTestAdapter(db)
 	// The ACH file imports cleanly; the CSV after it does not, so the whole
 	// import rolls back.
-	if err := adapter.LoadFiles(t.Context(), achFile, broken); err == nil {
+	if err := adapter.LoadFiles(t.Context(), fileLoads(achFile, broken)...); err == nil {
 		t.Fatal("LoadFiles = nil error, want the broken CSV to fail the import")
 	}
 
@@ -368,7 +369,7 @@ func TestLoadFilesRollbackLeavesNoACHWriteBackMetadata(t *testing.T) {
 	}
 
 	// Write-back becomes possible only once an import actually commits.
-	if err := adapter.LoadFiles(t.Context(), achFile); err != nil {
+	if err := adapter.LoadFiles(t.Context(), fileLoads(achFile)...); err != nil {
 		t.Fatalf("LoadFiles(ach) = %v, want success", err)
 	}
 	if err := adapter.DumpACHFile(t.Context(), "ppd_debit", out); err != nil {
@@ -94,7 +94,7 @@ func benchImport(b *testing.B, rows int) {
 		adapter := newTestAdapter(db)
 		b.StartTimer()
 
-		if err := adapter.LoadFiles(ctx, csv); err != nil {
+		if err := adapter.LoadFiles(ctx, fileLoads(csv)...); err != nil {
 			b.Fatal(err)
 		}
 
@@ -133,7 +133,7 @@ func BenchmarkImportMultiFile(b *testing.B) {
 		adapter := newTestAdapter(db)
 		b.StartTimer()
 
-		if err := adapter.LoadFiles(ctx, paths...); err != nil {
+		if err := adapter.LoadFiles(ctx, fileLoads(paths...)...); err != nil {
 			b.Fatal(err)
 		}
 
@@ -157,7 +157,7 @@ func benchQueryFixture(b *testing.B, rows int) (*testAdapter, func()) {
 		b.Fatal(err)
 	}
 	adapter := newTestAdapter(db)
-	if err := adapter.LoadFiles(context.Background(), csv); err != nil {
+	if err := adapter.LoadFiles(context.Background(), fileLoads(csv)...); err != nil {
 		b.Fatal(err)
 	}
 	return adapter, func() { _ = db.Close() }
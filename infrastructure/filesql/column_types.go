@@ -0,0 +1,538 @@
+package filesql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nao1215/sqly/domain/cleanup"
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// tableDeclaration is everything an import declares about one table it
+// creates: the table's schema from a sidecar, if the input has one, the column
+// types and primary key given on the command line, which win over the sidecar
+// for what they name, and the indexes to create once the table is built.
+type tableDeclaration struct {
+	table string
+	// schema is the sidecar's declaration of the table, and sidecar the file it
+	// came from; schema is nil when the input has none.
+	schema     *model.TableSchema
+	sidecar    string
+	types      model.ColumnTypes
+	primaryKey *model.TableKey
+	indexes    model.TableKeys
+}
+
+// rebuilds reports whether the table is built again with declared columns,
+// rather than kept as the import made it. Indexes alone do not need that.
+func (d tableDeclaration) rebuilds() bool {
+	return d.schema != nil || len(d.types) > 0 || d.primaryKey != nil
+}
+
+// keySource names what declared the table's primary key, for a refusal.
+func (d tableDeclaration) keySource() string {
+	if d.primaryKey != nil {
+		return "--primary-key " + d.primaryKey.String()
+	}
+	return "schema " + d.sidecar
+}
+
+// tableDeclarations gathers what the import of file declares, per table, in
+// the order the sidecar and then the column types name the tables.
+func tableDeclarations(file model.FileLoad) ([]tableDeclaration, error) {
+	path := file.Path
+	var declarations []tableDeclaration
+	source := schemaSourceFor(file)
+	sidecar, err := SchemaSidecar(source)
+	if err != nil {
+		return nil, err
+	}
+	if sidecar != "" {
+		schema, err := readImportSchema(source, sidecar)
+		if err != nil {
+			return nil, err
+		}
+		for i := range schema.Tables {
+			table := stagedSchemaTable(schema.Tables[i].Name, source, path)
+			declarations = append(declarations, tableDeclaration{table: table, schema: &schema.Tables[i], sidecar: sidecar})
+		}
+	}
+	// declaration returns the entry for a table, adding one if there is none.
+	declaration := func(table string) *tableDeclaration {
+		for i, d := range declarations {
+			if strings.EqualFold(d.table, table) {
+				return &declarations[i]
+			}
+		}
+		declarations = append(declarations, tableDeclaration{table: table})
+		return &declarations[len(declarations)-1]
+	}
+	for _, declared := range file.ColumnTypes {
+		d := declaration(declared.Table)
+		d.types = append(d.types, declared)
+	}
+	for _, key := range file.PrimaryKeys {
+		declaration(key.Table).primaryKey = &key
+	}
+	for _, index := range file.Indexes {
+		d := declaration(index.Table)
+		d.indexes = append(d.indexes, index)
+	}
+	return declarations, nil
+}
+
+// stagedSchemaTable is the table a sidecar's table is created as. A sidecar
+// names the tables of the file it sits beside, and a staged copy loaded under
+// another name — read_csv('o.csv') loads o.csv as _sqly_read_1.csv — creates
+// them under the copy's name: o becomes _sqly_read_1, and a workbook's o_Sheet1
+// becomes _sqly_read_1_Sheet1. A table the sidecar leaves unnamed is the one
+// the loaded file creates.
+func stagedSchemaTable(name, source, path string) string {
+	loaded := GetTableNameFromFilePath(path)
+	if name == "" {
+		return loaded
+	}
+	original := GetTableNameFromFilePath(source)
+	if original == loaded {
+		return name
+	}
+	if strings.EqualFold(name, original) {
+		return loaded
+	}
+	if len(name) > len(original) && strings.EqualFold(name[:len(original)+1], original+"_") {
+		return loaded + name[len(original):]
+	}
+	return name
+}
+
+// declareTables recreates each table an input created as declared, in place of
+// the types the import inferred, inside the import's transaction.
+//
+// filesql infers a type per column and has no way to be told one, so the
+// declaration is applied to the table it made: a new table with the declared
+// types, the rows copied into it, and the old one dropped. The copy is where
+// SQLite converts each value to its column's type, as an insert would have. A
+// value a numeric type cannot hold is not converted — SQLite keeps the text —
+// and that is what is checked for and refused, because a column declared
+// INTEGER that still holds "N/A" is the mistake the declaration was there to
+// catch. An empty value in a numeric column becomes NULL: there is no number
+// it could be, and refusing it would refuse nearly every real CSV.
+//
+// Running in the import's transaction is what makes a refusal leave nothing
+// behind: the whole import rolls back, the tables it replaced included. The
+// indexes are created last, on the table as it was finally built, since a
+// table built again does not keep the indexes of the one it replaced.
+func declareTables(ctx context.Context, tx *sql.Tx, declarations []tableDeclaration) error {
+	for _, d := range declarations {
+		if d.rebuilds() {
+			if err := retypeTable(ctx, tx, d); err != nil {
+				return err
+			}
+		}
+		if err := createIndexes(ctx, tx, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retypeTable applies one table's declarations.
+//
+// The rows are copied into a table with the declared types and no
+// constraints first, so each check below can name the row it refuses: a NOT
+// NULL or a primary key violated during the copy would fail it with SQLite's
+// own message, which names neither the row nor the value. Only a table whose
+// schema declares a constraint is copied a second time, into one that has it.
+func retypeTable(ctx context.Context, tx *sql.Tx, d tableDeclaration) error {
+	columns, err := tableColumnDefinitions(ctx, tx, d.table)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		if d.schema != nil {
+			return fmt.Errorf("schema %s describes table %q, which this input did not create", d.sidecar, d.table)
+		}
+		if d.primaryKey != nil {
+			return fmt.Errorf("primary key %s names table %q, which this input did not create", d.primaryKey, d.table)
+		}
+		return fmt.Errorf("column type %s names table %q, which this input did not create", d.types[0], d.table)
+	}
+
+	// checked holds, per column, the declared type a value has to convert to,
+	// or "" when the column is not declared numeric, and decimals the columns
+	// declared DECIMAL, whose values sqly reads and writes back itself.
+	checked := make([]string, len(columns))
+	decimals := make([]*model.DecimalType, len(columns))
+	constrained := false
+	if d.schema != nil {
+		if err := matchSchemaColumns(d, columns); err != nil {
+			return err
+		}
+		key := 0
+		for i := range columns {
+			declared, _ := d.schema.Column(columns[i].Name)
+			columns[i].Type = model.StorageColumnType(declared.Type)
+			columns[i].NotNull = declared.NotNull()
+			if declared.PrimaryKey {
+				key++
+				columns[i].PrimaryKey = key
+			}
+			if model.IsNumericColumnType(declared.Type) {
+				checked[i] = declared.Type
+			}
+			if decimal, ok := model.ParseDecimalType(declared.Type); ok {
+				decimals[i] = &decimal
+			}
+			constrained = constrained || columns[i].NotNull || declared.PrimaryKey
+		}
+	}
+	for _, declared := range d.types {
+		at := columnIndex(columns, declared.Column)
+		if at < 0 {
+			return fmt.Errorf("column type %s names column %q, which table %q does not have; its columns are %s",
+				declared, declared.Column, d.table, columnNames(columns))
+		}
+		columns[at].Type = model.StorageColumnType(declared.Type)
+		checked[at], decimals[at] = "", nil
+		if declared.IsNumeric() {
+			checked[at] = declared.Type
+		}
+		if decimal, ok := model.ParseDecimalType(declared.Type); ok {
+			decimals[at] = &decimal
+		}
+	}
+	if d.primaryKey != nil {
+		// A declared key replaces the sidecar's rather than adding to it: a
+		// table has one primary key, and the flag was given for this run.
+		for i := range columns {
+			columns[i].PrimaryKey = 0
+		}
+		for i, name := range d.primaryKey.Columns {
+			at := columnIndex(columns, name)
+			if at < 0 {
+				return fmt.Errorf("primary key %s names column %q, which table %q does not have; its columns are %s",
+					d.primaryKey, name, d.table, columnNames(columns))
+			}
+			columns[at].PrimaryKey = i + 1
+		}
+		constrained = true
+	}
+
+	staging := model.ReservedTablePrefix + "retype_" + d.table
+	unconstrained := make([]model.ColumnDefinition, len(columns))
+	for i, c := range columns {
+		unconstrained[i] = model.ColumnDefinition{Name: c.Name, Type: c.Type, Default: c.Default}
+	}
+	if _, err := tx.ExecContext(ctx, model.BuildCreateStatement(model.SQLDialectSQLite, staging, unconstrained)); err != nil {
+		return fmt.Errorf("declare the column types of %q: %w", d.table, err)
+	}
+	names := make([]string, len(columns))
+	values := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = QuoteIdentifier(c.Name)
+		values[i] = names[i]
+		if checked[i] != "" || decimals[i] != nil {
+			values[i] = fmt.Sprintf("CASE WHEN typeof(%[1]s) = 'text' THEN NULLIF(trim(%[1]s), '') ELSE %[1]s END", names[i])
+		}
+	}
+	// The rowid is carried over so a refusal below can name the row of the
+	// file a value came from: filesql inserts the rows in file order.
+	copyRows := fmt.Sprintf("INSERT INTO %s (rowid, %s) SELECT rowid, %s FROM %s",
+		QuoteIdentifier(staging), strings.Join(names, ", "), strings.Join(values, ", "), QuoteIdentifier(d.table))
+	if _, err := tx.ExecContext(ctx, copyRows); err != nil {
+		return fmt.Errorf("declare the column types of %q: %w", d.table, err)
+	}
+	for i, c := range columns {
+		if checked[i] != "" {
+			if err := ensureConverted(ctx, tx, staging, d.table, c.Name, checked[i]); err != nil {
+				return err
+			}
+		}
+		if decimals[i] != nil {
+			if err := canonicalizeDecimals(ctx, tx, staging, d.table, c.Name, *decimals[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if constrained {
+		if err := ensureConstraints(ctx, tx, staging, d, columns); err != nil {
+			return err
+		}
+	}
+
+	statements := []string{
+		"DROP TABLE " + QuoteIdentifier(d.table),
+		"ALTER TABLE " + QuoteIdentifier(staging) + " RENAME TO " + QuoteIdentifier(d.table),
+	}
+	if constrained {
+		// The rowid is left to SQLite here: the rows go in in file order, so it
+		// is the file order again, and a single INTEGER primary key is the rowid
+		// and could not be given both values.
+		statements = []string{
+			"DROP TABLE " + QuoteIdentifier(d.table),
+			model.BuildCreateStatement(model.SQLDialectSQLite, d.table, columns),
+			fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s ORDER BY rowid",
+				QuoteIdentifier(d.table), strings.Join(names, ", "), strings.Join(names, ", "), QuoteIdentifier(staging)),
+			"DROP TABLE " + QuoteIdentifier(staging),
+		}
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("declare the column types of %q: %w", d.table, err)
+		}
+	}
+	return nil
+}
+
+// matchSchemaColumns refuses a sidecar whose columns are not the table's. A
+// schema is a statement of what the file holds, so a column the file gained or
+// lost since the schema was written is the drift it is there to catch, not a
+// detail to paper over.
+func matchSchemaColumns(d tableDeclaration, columns []model.ColumnDefinition) error {
+	for _, declared := range d.schema.Columns {
+		if columnIndex(columns, declared.Name) < 0 {
+			return fmt.Errorf("schema %s declares column %q of table %q, which the table does not have; its columns are %s",
+				d.sidecar, declared.Name, d.table, columnNames(columns))
+		}
+	}
+	for _, c := range columns {
+		if _, ok := d.schema.Column(c.Name); !ok {
+			return fmt.Errorf("table %q has column %q, which schema %s does not declare", d.table, c.Name, d.sidecar)
+		}
+	}
+	return nil
+}
+
+// ensureConverted refuses the first value SQLite could not convert to the
+// declared type, naming the row it is on. An INTEGER column also refuses a
+// fraction, which SQLite would otherwise keep as a REAL in it.
+func ensureConverted(ctx context.Context, tx *sql.Tx, staging, table, column, columnType string) error {
+	allowed := "'integer', 'real'"
+	if columnType == model.ColumnTypeInteger {
+		allowed = "'integer'"
+	}
+	query := fmt.Sprintf("SELECT rowid, CAST(%[1]s AS TEXT) FROM %[2]s WHERE %[1]s IS NOT NULL AND typeof(%[1]s) NOT IN (%[3]s) ORDER BY rowid LIMIT 1",
+		QuoteIdentifier(column), QuoteIdentifier(staging), allowed)
+	var row int64
+	var value string
+	err := tx.QueryRowContext(ctx, query).Scan(&row, &value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("check the values of %q.%s: %w", table, column, err)
+	}
+	return fmt.Errorf("column %q of table %q is declared %s, but data row %d holds %s, which is not %s; fix the value or declare the column TEXT",
+		column, table, columnType, row, strconv.Quote(value), numericNoun(columnType))
+}
+
+// canonicalizeDecimals rewrites every value of a DECIMAL column in the form
+// its type stores, and refuses the first one that is not a decimal the type
+// holds, naming the row it is on.
+//
+// SQLite cannot do this part. The column has TEXT affinity, so it took any
+// text it was given, and a value that reached it through a REAL — from an
+// input that is not CSV or TSV, or a JSON number — is SQLite's rendering of
+// that REAL, not the canonical one.
+func canonicalizeDecimals(ctx context.Context, tx *sql.Tx, staging, table, column string, decimal model.DecimalType) error {
+	type rewrite struct {
+		row   int64
+		value string
+	}
+	var rewrites []rewrite
+	var refused error
+	err := func() (err error) {
+		rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT rowid, CAST(%[1]s AS TEXT) FROM %[2]s WHERE %[1]s IS NOT NULL ORDER BY rowid",
+			QuoteIdentifier(column), QuoteIdentifier(staging)))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			err = cleanup.Join(err, rows.Close(), "close the decimal values")
+		}()
+		for rows.Next() {
+			var row int64
+			var value string
+			if err := rows.Scan(&row, &value); err != nil {
+				return err
+			}
+			canonical, err := decimal.Canonical(value)
+			if err != nil {
+				refused = fmt.Errorf("column %q of table %q is declared %s, but in data row %d %w; fix the value or declare the column TEXT",
+					column, table, decimal, row, err)
+				return nil
+			}
+			if canonical != value {
+				rewrites = append(rewrites, rewrite{row: row, value: canonical})
+			}
+		}
+		return rows.Err()
+	}()
+	if err != nil {
+		return fmt.Errorf("check the values of %q.%s: %w", table, column, err)
+	}
+	if refused != nil {
+		return refused
+	}
+	update := fmt.Sprintf("UPDATE %s SET %s = ? WHERE rowid = ?", QuoteIdentifier(staging), QuoteIdentifier(column))
+	for _, r := range rewrites {
+		if _, err := tx.ExecContext(ctx, update, r.value, r.row); err != nil {
+			return fmt.Errorf("write the decimal values of %q.%s: %w", table, column, err)
+		}
+	}
+	return nil
+}
+
+// ensureConstraints refuses the first row that breaks the declared NOT NULL
+// columns or primary key. A primary key column counts as NOT NULL: SQLite
+// lets a NULL into most of them for old compatibility's sake, but a key with no
+// value does not identify a row. An empty value counts as no value, because an
+// empty cell is the only way a CSV file has of leaving one out, and a text
+// column keeps it as an empty string rather than a NULL.
+func ensureConstraints(ctx context.Context, tx *sql.Tx, staging string, d tableDeclaration, columns []model.ColumnDefinition) error {
+	key := make([]string, 0, len(columns))
+	for _, c := range primaryKeyOrder(columns) {
+		key = append(key, QuoteIdentifier(c.Name))
+	}
+	for _, c := range columns {
+		if !c.NotNull && c.PrimaryKey == 0 {
+			continue
+		}
+		var row int64
+		err := tx.QueryRowContext(ctx, fmt.Sprintf("SELECT rowid FROM %s WHERE %[2]s IS NULL OR %[2]s = '' ORDER BY rowid LIMIT 1",
+			QuoteIdentifier(staging), QuoteIdentifier(c.Name))).Scan(&row)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("check the values of %q.%s: %w", d.table, c.Name, err)
+		}
+		if !c.NotNull {
+			return fmt.Errorf("column %q of table %q is in the primary key declared by %s, but data row %d has no value for it",
+				c.Name, d.table, d.keySource(), row)
+		}
+		return fmt.Errorf("column %q of table %q is declared NOT NULL in schema %s, but data row %d has no value for it",
+			c.Name, d.table, d.sidecar, row)
+	}
+	if len(key) == 0 {
+		return nil
+	}
+
+	// The key columns are compared the way the primary key will compare them,
+	// since GROUP BY and the key's index both use the columns' own collation.
+	values := make([]string, len(key))
+	for i, k := range key {
+		values[i] = fmt.Sprintf("quote(%s)", k)
+	}
+	query := fmt.Sprintf("SELECT min(rowid), count(*), %s FROM %s GROUP BY %s HAVING count(*) > 1 ORDER BY min(rowid) LIMIT 1",
+		strings.Join(values, " || ', ' || "), QuoteIdentifier(staging), strings.Join(key, ", "))
+	var first, count int64
+	var value string
+	err := tx.QueryRowContext(ctx, query).Scan(&first, &count, &value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("check the primary key of %q: %w", d.table, err)
+	}
+	return fmt.Errorf("table %q has primary key (%s) declared by %s, but %d data rows hold %s, the first of them row %d",
+		d.table, strings.Join(key, ", "), d.keySource(), count, value, first)
+}
+
+// primaryKeyOrder returns the primary key's columns in key order, which for a
+// declared key of several columns is not the order of the table's columns.
+func primaryKeyOrder(columns []model.ColumnDefinition) []model.ColumnDefinition {
+	var key []model.ColumnDefinition
+	for ordinal := 1; ; ordinal++ {
+		at := slices.IndexFunc(columns, func(c model.ColumnDefinition) bool { return c.PrimaryKey == ordinal })
+		if at < 0 {
+			return key
+		}
+		key = append(key, columns[at])
+	}
+}
+
+// createIndexes creates the indexes declared for a table. A column the table
+// does not have is refused with the table's columns listed, rather than left
+// to SQLite's "no such column", which does not say which declaration was
+// wrong.
+func createIndexes(ctx context.Context, tx *sql.Tx, d tableDeclaration) error {
+	if len(d.indexes) == 0 {
+		return nil
+	}
+	columns, err := tableColumnDefinitions(ctx, tx, d.table)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("index %s names table %q, which this input did not create", d.indexes[0], d.table)
+	}
+	for _, index := range d.indexes {
+		for _, name := range index.Columns {
+			if columnIndex(columns, name) < 0 {
+				return fmt.Errorf("index %s names column %q, which table %q does not have; its columns are %s",
+					index, name, d.table, columnNames(columns))
+			}
+		}
+		if _, err := tx.ExecContext(ctx, index.CreateIndexStatement()); err != nil {
+			return fmt.Errorf("create index %s: %w", index, err)
+		}
+	}
+	return nil
+}
+
+// columnIndex returns the position of the named column, compared without
+// ASCII case, or -1.
+func columnIndex(columns []model.ColumnDefinition, name string) int {
+	for i, c := range columns {
+		if strings.EqualFold(c.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// columnNames lists the columns for a message.
+func columnNames(columns []model.ColumnDefinition) string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// numericNoun names what a numeric type holds, for a refusal.
+func numericNoun(columnType string) string {
+	if columnType == model.ColumnTypeInteger {
+		return "an integer"
+	}
+	return "a number"
+}
+
+// tableColumnDefinitions reads a table's columns from PRAGMA table_info. A
+// table that does not exist has none.
+func tableColumnDefinitions(ctx context.Context, tx *sql.Tx, table string) (_ []model.ColumnDefinition, err error) {
+	rows, err := tx.QueryContext(ctx, "SELECT name, type, \"notnull\", dflt_value, pk FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("read the columns of %q: %w", table, err)
+	}
+	defer func() {
+		err = cleanup.Join(err, rows.Close(), "close the column list")
+	}()
+	var columns []model.ColumnDefinition
+	for rows.Next() {
+		var c model.ColumnDefinition
+		var dflt sql.NullString
+		if err := rows.Scan(&c.Name, &c.Type, &c.NotNull, &dflt, &c.PrimaryKey); err != nil {
+			return nil, fmt.Errorf("read the columns of %q: %w", table, err)
+		}
+		c.Default = dflt.String
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
@@ -0,0 +1,151 @@
+package filesql
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nao1215/sqly/domain/model"
+	_ "modernc.org/sqlite"
+)
+
+// loadWithColumnTypes imports a CSV file with the given declarations and
+// returns the adapter, so a test can look at the table it made.
+func loadWithColumnTypes(t *testing.T, content string, types model.ColumnTypes) (*testAdapter, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "users.csv")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	adapter := newTestAdapter(db)
+	return adapter, adapter.LoadFiles(context.Background(), model.FileLoad{Path: path, ColumnTypes: types.ForTable("users")})
+}
+
+func TestFileSQLAdapter_ColumnTypes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("declared types replace inferred ones", func(t *testing.T) {
+		t.Parallel()
+		adapter, err := loadWithColumnTypes(t, "id,zip,amount\n1,12345,10\n2,98765,\n3,54321,2.5\n", model.ColumnTypes{
+			{Column: "zip", Type: model.ColumnTypeText},
+			{Column: "amount", Type: model.ColumnTypeReal},
+		})
+		if err != nil {
+			t.Fatalf("LoadFile: %v", err)
+		}
+		table, err := adapter.Query(context.Background(),
+			"SELECT group_concat(DISTINCT typeof(zip)), group_concat(DISTINCT typeof(amount)), count(*) FROM users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The empty amount is NULL: there is no number it could be.
+		got := strings.Join(table.Records()[0], " ")
+		if want := "text real,null 3"; got != want {
+			t.Errorf("types, rows = %q, want %q", got, want)
+		}
+
+		declared, err := adapter.Query(context.Background(), "SELECT name, type FROM pragma_table_info('users')")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var columns []string
+		for _, record := range declared.Records() {
+			columns = append(columns, strings.Join(record, " "))
+		}
+		if got := strings.Join(columns, ", "); !strings.Contains(got, "zip TEXT") || !strings.Contains(got, "amount REAL") {
+			t.Errorf("the table is declared %s, want zip TEXT and amount REAL", got)
+		}
+	})
+
+	t.Run("a value a numeric type cannot hold fails the import", func(t *testing.T) {
+		t.Parallel()
+		adapter, err := loadWithColumnTypes(t, "id,amount\n1,10\n2,N/A\n", model.ColumnTypes{
+			{Column: "amount", Type: model.ColumnTypeReal},
+		})
+		if err == nil || !strings.Contains(err.Error(), `data row 2 holds "N/A", which is not a number`) {
+			t.Fatalf("LoadFile error = %v, want the row and value named", err)
+		}
+		// The import rolled back, so the table filesql made is gone too.
+		if _, err := adapter.Query(context.Background(), "SELECT * FROM users"); err == nil {
+			t.Error("the refused import left a users table behind")
+		}
+	})
+
+	t.Run("an integer column refuses a fraction", func(t *testing.T) {
+		t.Parallel()
+		_, err := loadWithColumnTypes(t, "id,qty\n1,2.5\n", model.ColumnTypes{
+			{Column: "qty", Type: model.ColumnTypeInteger},
+		})
+		if err == nil || !strings.Contains(err.Error(), "which is not an integer") {
+			t.Errorf("LoadFile error = %v, want the fraction refused", err)
+		}
+	})
+
+	t.Run("a DECIMAL(p,s) column holds its values at its scale", func(t *testing.T) {
+		t.Parallel()
+		adapter, err := loadWithColumnTypes(t, "id,amount\n1,19.9\n2,12345678901234567.89\n3,\n", model.ColumnTypes{
+			{Column: "amount", Type: "DECIMAL(20,2)"},
+		})
+		if err != nil {
+			t.Fatalf("LoadFile: %v", err)
+		}
+		table, err := adapter.Query(context.Background(), "SELECT group_concat(quote(amount), ' ') FROM users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := table.Records()[0][0], "'19.90' '12345678901234567.89' NULL"; got != want {
+			t.Errorf("amounts = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("a DECIMAL(p,s) column refuses a digit past its scale", func(t *testing.T) {
+		t.Parallel()
+		_, err := loadWithColumnTypes(t, "id,amount\n1,19.90\n2,0.125\n", model.ColumnTypes{
+			{Column: "amount", Type: "DECIMAL(5,2)"},
+		})
+		if err == nil || !strings.Contains(err.Error(), `is declared DECIMAL(5,2), but in data row 2 "0.125" has more digits after the point than the 2 DECIMAL(5,2) holds`) {
+			t.Errorf("LoadFile error = %v, want the row and value named", err)
+		}
+	})
+
+	t.Run("a column the table does not have is refused", func(t *testing.T) {
+		t.Parallel()
+		_, err := loadWithColumnTypes(t, "id,zip\n1,123\n", model.ColumnTypes{
+			{Column: "postcode", Type: model.ColumnTypeText},
+		})
+		if err == nil || !strings.Contains(err.Error(), "its columns are id, zip") {
+			t.Errorf("LoadFile error = %v, want the table's columns listed", err)
+		}
+	})
+}
+
+func TestStagedSchemaTable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name, table, source, path, want string
+	}{
+		{name: "a file read where it is keeps the sidecar's names", table: "orders", source: "/d/o.csv", path: "/d/o.csv", want: "orders"},
+		{name: "the source's own table follows the copy", table: "O", source: "/d/o.csv", path: "/tmp/_sqly_read_1.csv", want: "_sqly_read_1"},
+		{name: "a sheet table follows the copy", table: "o_Sheet1", source: "/d/o.xlsx", path: "/tmp/_sqly_read_1.xlsx", want: "_sqly_read_1_Sheet1"},
+		{name: "an unnamed table is the copy's", table: "", source: "/d/o.csv", path: "/tmp/_sqly_read_1.csv", want: "_sqly_read_1"},
+		{name: "another table is left as named", table: "orders", source: "/d/o.csv", path: "/tmp/_sqly_read_1.csv", want: "orders"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := stagedSchemaTable(tt.table, tt.source, tt.path); got != tt.want {
+				t.Errorf("stagedSchemaTable(%q, %q, %q) = %q, want %q", tt.table, tt.source, tt.path, got, tt.want)
+			}
+		})
+	}
+}
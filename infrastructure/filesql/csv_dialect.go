@@ -0,0 +1,304 @@
+package filesql
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nao1215/filesql"
+	"github.com/nao1215/filesql/parser"
+	"github.com/nao1215/sqly/domain/cleanup"
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// A CSV or TSV input with a dialect of its own — semicolons, single quotes, a
+// preamble, comment lines, no header — is read here and staged as a plain CSV
+// file, which filesql then loads like any other. filesql reads one dialect per
+// format, and staging keeps it that way: type inference, the row-mismatch
+// policy, and table naming all stay filesql's, applied to the records the
+// dialect describes. An input with no dialect is never staged, unless its
+// values are cleaned (see import_cleaning.go), so it is read byte for byte as it
+// always was.
+
+// delimitedKind reports whether path is a CSV or TSV file, compressed or not,
+// and which of the two.
+func delimitedKind(path string) (tsv, ok bool) {
+	base := strings.ToLower(path)
+	for _, ext := range compressionExts {
+		if before, found := strings.CutSuffix(base, ext); found {
+			base = before
+			break
+		}
+	}
+	switch {
+	case strings.HasSuffix(base, ".tsv"):
+		return true, true
+	case strings.HasSuffix(base, ".csv"):
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// stageDialectAsCSV reads the CSV or TSV file at path with dialect and writes
+// its records, cleaned, to a plain CSV file named after it in a temporary
+// directory. It returns that file's path and the cleanup that removes it.
+func stageDialectAsCSV(path string, dialect model.CSVDialect, tsv bool, cleaning model.ImportCleaning) (staged string, release func() error, err error) {
+	reader, closeReader, err := filesql.NewCompressionFactory().CreateReaderForFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("open file: %w", err)
+	}
+	defer func() {
+		err = cleanup.Join(err, closeReader(), "close file")
+	}()
+	records, err := newDialectReader(parser.NormalizeLineEndings(skipUTF8BOM(reader)), dialect, tsv)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "sqly-dialect-")
+	if err != nil {
+		return "", nil, fmt.Errorf("create temp dir for csv staging: %w", err)
+	}
+	remove := func() error { return os.RemoveAll(dir) }
+
+	// Named after the source, as an XML input's staged file is, so the table is
+	// the one the source would have given.
+	staged = filepath.Join(dir, GetTableNameFromFilePath(path)+".csv")
+	if err := writeDialectRecords(staged, records, dialect, cleaning, GetTableNameFromFilePath(path)); err != nil {
+		return "", nil, cleanup.Join(err, remove(), "remove csv staging directory")
+	}
+	return staged, remove, nil
+}
+
+// writeDialectRecords writes every record records reads to staged, header
+// first. A file with nothing left once the skipped lines and comments are set
+// aside is refused: an empty table would look like an empty export, when the
+// likelier cause is a --skip-lines that read past the data. table is the table
+// the records load into, which picks the date and decimal columns that apply.
+func writeDialectRecords(staged string, records *dialectReader, dialect model.CSVDialect, cleaning model.ImportCleaning, table string) (err error) {
+	file, err := os.Create(staged) //nolint:gosec // staged is under a sqly-created temp dir
+	if err != nil {
+		return fmt.Errorf("create csv staging file: %w", err)
+	}
+	defer func() {
+		err = cleanup.Join(err, file.Close(), "close csv staging file")
+	}()
+
+	out := bufio.NewWriter(file)
+	header, err := records.Read()
+	if errors.Is(err, io.EOF) {
+		if dialect.IsZero() {
+			// Staged only to be cleaned, so there was nothing to read past.
+			return errors.New("file is empty")
+		}
+		return fmt.Errorf("no records are left once the file is read with %s", dialect)
+	}
+	if err != nil {
+		return err
+	}
+	cleanRecord(header, cleaning, true)
+	dates, err := dateColumnIndexes(header, table, cleaning.Dates)
+	if err != nil {
+		return err
+	}
+	decimals, err := decimalColumnIndexes(header, table, cleaning.Decimals)
+	if err != nil {
+		return err
+	}
+	writeCSVRecord(out, header)
+	for {
+		record, err := records.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		cleanRecord(record, cleaning, false)
+		if err := normalizeDates(record, header, dates, records.line); err != nil {
+			return err
+		}
+		keepDecimalsText(record, decimals)
+		writeCSVRecord(out, record)
+	}
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("write csv staging file: %w", err)
+	}
+	return nil
+}
+
+// dialectReader reads the records of a delimited file written in a dialect.
+// Its first record is the header: the file's own, or, for a file without one,
+// the c1..cN names for as many fields as the first row has.
+//
+// It reads the way filesql's CSV reader does where the dialect does not say
+// otherwise: a blank line is not a record, a quoted field may hold line breaks,
+// and a doubled quote inside one is a quote. A quote in the middle of an
+// unquoted field is data, since a dialect that turned quoting off, or chose
+// another character, has made the double quote an ordinary one.
+type dialectReader struct {
+	r         *bufio.Reader
+	delimiter string
+	// quote is empty for a file that quotes nothing.
+	quote   string
+	comment string
+	// line is the line last read, counted from the top of the file, skipped
+	// lines included, so a message points at the line an editor shows.
+	line int
+	// pending holds the first row of a headerless file while the names made
+	// for it are returned.
+	pending  []string
+	noHeader bool
+	started  bool
+}
+
+// newDialectReader reads past the lines dialect skips and returns a reader of
+// the records after them. tsv picks the format whose defaults apply.
+func newDialectReader(r io.Reader, dialect model.CSVDialect, tsv bool) (*dialectReader, error) {
+	delimiter, quote := ',', '"'
+	if tsv {
+		delimiter, quote = '\t', 0
+	}
+	if dialect.Delimiter != 0 {
+		delimiter = dialect.Delimiter
+	}
+	switch dialect.Quote {
+	case 0:
+	case model.NoQuote:
+		quote = 0
+	default:
+		quote = dialect.Quote
+	}
+	if delimiter == quote {
+		return nil, fmt.Errorf("the delimiter and the quote are both %q; set --delimiter or --quote to tell them apart", delimiter)
+	}
+
+	d := &dialectReader{
+		r:         bufio.NewReader(r),
+		delimiter: string(delimiter),
+		comment:   dialect.CommentPrefix,
+		noHeader:  dialect.NoHeader,
+	}
+	if quote != 0 {
+		d.quote = string(quote)
+	}
+	for range dialect.SkipLines {
+		if _, err := d.readLine(); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Read returns the next record, or io.EOF when there are none left.
+func (d *dialectReader) Read() ([]string, error) {
+	if d.pending != nil {
+		record := d.pending
+		d.pending = nil
+		return record, nil
+	}
+	record, err := d.readRecord()
+	if err != nil {
+		return nil, err
+	}
+	if d.noHeader && !d.started {
+		d.pending = record
+		record = model.HeaderlessColumnNames(len(record))
+	}
+	d.started = true
+	return record, nil
+}
+
+// readLine returns the next line without its terminator, or io.EOF when there
+// is none. A lone \r was made a \n before the reader saw it, but a \r\n was
+// left as it is, so the \r is dropped here, as encoding/csv drops it: left in,
+// it ends the last column name and the last value of every row.
+func (d *dialectReader) readLine() (string, error) {
+	line, err := d.r.ReadString('\n')
+	if errors.Is(err, io.EOF) && line == "" {
+		return "", io.EOF
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	d.line++
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+// readRecord returns the next line that is a record, split into its fields.
+func (d *dialectReader) readRecord() ([]string, error) {
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if d.comment != "" && strings.HasPrefix(line, d.comment) {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		return d.splitRecord(line)
+	}
+}
+
+// splitRecord splits one record into fields, reading further lines for a
+// quoted field that holds a line break.
+func (d *dialectReader) splitRecord(line string) ([]string, error) {
+	start := d.line
+	var fields []string
+	for {
+		if d.quote == "" || !strings.HasPrefix(line, d.quote) {
+			i := strings.Index(line, d.delimiter)
+			if i < 0 {
+				return append(fields, line), nil
+			}
+			fields = append(fields, line[:i])
+			line = line[i+len(d.delimiter):]
+			continue
+		}
+
+		var field strings.Builder
+		line = line[len(d.quote):]
+		for {
+			i := strings.Index(line, d.quote)
+			if i < 0 {
+				field.WriteString(line)
+				next, err := d.readLine()
+				if errors.Is(err, io.EOF) {
+					return nil, fmt.Errorf("%w: the quoted field that starts on line %d is never closed; if %s is not this file's quote, set --quote",
+						parser.ErrCSVSyntax, start, d.quote)
+				}
+				if err != nil {
+					return nil, err
+				}
+				field.WriteByte('\n')
+				line = next
+				continue
+			}
+			field.WriteString(line[:i])
+			line = line[i+len(d.quote):]
+			if !strings.HasPrefix(line, d.quote) {
+				break
+			}
+			field.WriteString(d.quote)
+			line = line[len(d.quote):]
+		}
+		fields = append(fields, field.String())
+		if line == "" {
+			return fields, nil
+		}
+		if !strings.HasPrefix(line, d.delimiter) {
+			return nil, fmt.Errorf("%w: line %d has text after a closing %s; if that is not this file's quote, set --quote",
+				parser.ErrCSVSyntax, d.line, d.quote)
+		}
+		line = line[len(d.delimiter):]
+	}
+}
@@ -0,0 +1,220 @@
+package filesql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nao1215/filesql/parser"
+	"github.com/nao1215/sqly/domain/model"
+	_ "modernc.org/sqlite"
+)
+
+// loadDialect writes content to a file called name, imports it read with
+// dialect, and returns the result of query as CSV.
+func loadDialect(t *testing.T, name, content string, dialect model.CSVDialect, query string) (string, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	adapter := newTestAdapter(db)
+	ctx := context.Background()
+	if err := adapter.LoadFiles(ctx, model.FileLoad{Path: path, Dialect: dialect}); err != nil {
+		return "", err
+	}
+	table, err := adapter.Query(ctx, query)
+	if err != nil {
+		t.Fatalf("Query(%s): %v", query, err)
+	}
+	var out bytes.Buffer
+	if err := table.Print(&out, model.PrintModeCSV); err != nil {
+		t.Fatal(err)
+	}
+	return out.String(), nil
+}
+
+func TestLoadCSVDialect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		dialect model.CSVDialect
+		query   string
+		want    string
+	}{
+		{
+			name:    "semicolons, with a comma in a value",
+			file:    "prices.csv",
+			content: "item;price\nbolt;1,5\n\"nut;washer\";2\n",
+			dialect: model.CSVDialect{Delimiter: ';'},
+			query:   "SELECT item, price FROM prices ORDER BY item",
+			want:    "item,price\nbolt,\"1,5\"\nnut;washer,2\n",
+		},
+		{
+			name:    "pipes and single quotes, a doubled quote inside one",
+			file:    "names.csv",
+			content: "id|name\n1|'O''Brien'\n2|'a|b'\n",
+			dialect: model.CSVDialect{Delimiter: '|', Quote: '\''},
+			query:   "SELECT id, typeof(id), name FROM names ORDER BY id",
+			want:    "id,typeof(id),name\n1,integer,O'Brien\n2,integer,a|b\n",
+		},
+		{
+			name:    "a preamble and comment lines",
+			file:    "report.csv",
+			content: "Quarterly report\nGenerated 2024-01-01\n# columns follow\nid,amount\n1,10\n# a note\n2,20\n",
+			dialect: model.CSVDialect{SkipLines: 2, CommentPrefix: "#"},
+			query:   "SELECT sum(amount) AS total FROM report",
+			want:    "total\n30\n",
+		},
+		{
+			name:    "no header",
+			file:    "raw.csv",
+			content: "1,alice\n2,bob\n",
+			dialect: model.CSVDialect{NoHeader: true},
+			query:   "SELECT c1, c2 FROM raw ORDER BY c1",
+			want:    "c1,c2\n1,alice\n2,bob\n",
+		},
+		{
+			name:    "no quoting keeps quotes as data",
+			file:    "inches.csv",
+			content: "size,label\n12,\"12\"\" pipe\n",
+			dialect: model.CSVDialect{Quote: model.NoQuote},
+			query:   "SELECT label FROM inches",
+			want:    "label\n\"\"\"12\"\"\"\" pipe\"\n",
+		},
+		{
+			name:    "a quoted field holding a line break",
+			file:    "notes.csv",
+			content: "id;note\n1;\"first\nsecond\"\n",
+			dialect: model.CSVDialect{Delimiter: ';'},
+			query:   "SELECT replace(note, char(10), '/') AS note FROM notes",
+			want:    "note\nfirst/second\n",
+		},
+		{
+			name:    "crlf line endings end at the last column",
+			file:    "crlf.csv",
+			content: "a;b\r\n1;2\r\n3;\"x\r\ny\"\r\n",
+			dialect: model.CSVDialect{Delimiter: ';'},
+			query:   "SELECT (SELECT group_concat(hex(name), ' ') FROM pragma_table_info('crlf')) AS names, a, hex(b) AS b FROM crlf ORDER BY a",
+			want:    "names,a,b\n61 62,1,32\n61 62,3,780A79\n",
+		},
+		{
+			name:    "a tsv with a preamble keeps its quotes as data",
+			file:    "log.tsv",
+			content: "exported by tool\nid\tmsg\n1\t\"hi\"\n",
+			dialect: model.CSVDialect{SkipLines: 1},
+			query:   "SELECT msg FROM log",
+			want:    "msg\n\"\"\"hi\"\"\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := loadDialect(t, tt.file, tt.content, tt.dialect, tt.query)
+			if err != nil {
+				t.Fatalf("import: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadCSVDialect_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		dialect model.CSVDialect
+		want    string
+	}{
+		{
+			name:    "an unclosed quote names the line it opened on",
+			content: "a;b\n1;'x\n2;y\n",
+			dialect: model.CSVDialect{Delimiter: ';', Quote: '\''},
+			want:    "starts on line 2 is never closed",
+		},
+		{
+			name:    "skipping past the data",
+			content: "a\n1\n",
+			dialect: model.CSVDialect{SkipLines: 5},
+			want:    "no records are left once the file is read with --skip-lines 5",
+		},
+		{
+			name:    "a quote that is also the delimiter",
+			content: "a,b\n",
+			dialect: model.CSVDialect{Quote: ','},
+			want:    "the delimiter and the quote are both ','",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := loadDialect(t, "data.csv", tt.content, tt.dialect, "SELECT 1")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("import error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialectReader_TextAfterAClosingQuote(t *testing.T) {
+	t.Parallel()
+
+	reader, err := newDialectReader(strings.NewReader("a,b\n\"x\"y,1\n"), model.CSVDialect{SkipLines: 0, CommentPrefix: "#"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.Read(); !errors.Is(err, parser.ErrCSVSyntax) {
+		t.Errorf("Read error = %v, want ErrCSVSyntax", err)
+	}
+}
+
+func TestStageUnion_ShardDialect(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "a.csv")
+	second := filepath.Join(dir, "b.csv")
+	if err := os.WriteFile(first, []byte("id;name\n1;x\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte("name;id\ny;2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dialect := model.CSVDialect{Delimiter: ';'}
+	dest := filepath.Join(dir, "events.csv")
+	adapter := newTestAdapter(nil)
+	if _, err := adapter.StageUnion(dest, []model.UnionShard{
+		{Path: first, Label: "a.csv", Dialect: dialect},
+		{Path: second, Label: "b.csv", Dialect: dialect},
+	}, false); err != nil {
+		t.Fatalf("StageUnion: %v", err)
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\"id\",\"name\"\n\"1\",\"x\"\n\"2\",\"y\"\n"; string(got) != want {
+		t.Errorf("staged:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -35,7 +35,7 @@ func TestLoadFiles_EmptyJSONClosedDB(t *testing.T) {
 	a := covErrFsqlClosedAdapter(t)
 	emptyJSON := covFsqlWriteCSV(t, "empty.json", "[]")
 
-	if err := a.LoadFiles(context.Background(), emptyJSON); err == nil {
+	if err := a.LoadFiles(context.Background(), fileLoads(emptyJSON)...); err == nil {
 		t.Fatal("LoadFiles(empty JSON) on closed DB = nil error, want error")
 	}
 }
@@ -0,0 +1,237 @@
+package filesql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// A CSV or TSV input read with --trim or --null-values is staged the way one
+// with a dialect is, and cleaned as it is: each value is trimmed, or emptied
+// when it is a placeholder for a missing one, before filesql sees it. That order
+// is the point. filesql infers a column's type from its values and passes over
+// the empty ones, so a column of numbers with an NA in it is INTEGER once the NA
+// is gone, and would have been TEXT had the NA been turned into NULL afterwards.
+//
+// A CSV file has no way to write NULL, so the staged file cannot carry it: the
+// placeholders go in as empty values, and the empty values of the table filesql
+// made are set to NULL once it is loaded, in the import's transaction.
+//
+// A column --date-columns names is read with its layout in the same pass, after
+// the rest of the cleaning, and staged as ISO 8601. filesql then infers it the
+// way it infers any column of ISO dates, and SQLite's date functions read it.
+// A value the layout does not read fails the import with the line it is on:
+// loading it as it was would leave one row that every date comparison passes
+// over, which is the silent mistake the option is there to prevent.
+
+// A column --decimal-columns names, or one declared DECIMAL, is staged with a
+// space before each of its values. filesql keeps a number written with
+// whitespace around it as text, because a numeric column would drop the
+// whitespace, and that is the one way to ask it not to infer the column REAL:
+// a REAL has already lost the digits past its fifteenth, and the trailing zero
+// of 19.90, by the time anything could read it back. The declaration then
+// trims the space and writes each value in canonical form.
+
+// SetImportCleaning sets what is done to the values of the CSV and TSV inputs of
+// subsequent imports as they are read. It holds for the whole session, as the
+// row-mismatch policy does.
+func (f *FileSQLAdapter) SetImportCleaning(cleaning model.ImportCleaning) {
+	f.cleaning = cleaning
+}
+
+// cleanRecord applies cleaning to one record of a staged input in place. The
+// header's names are trimmed but never emptied.
+func cleanRecord(record []string, cleaning model.ImportCleaning, header bool) {
+	for i, value := range record {
+		if header {
+			record[i] = cleaning.Header(value)
+			continue
+		}
+		record[i] = cleaning.Value(value)
+	}
+}
+
+// dateColumn is a column of a staged input read with a layout.
+type dateColumn struct {
+	index  int
+	layout model.DateLayout
+}
+
+// dateColumnIndexes returns the date columns of header, in header order, with
+// the layout each is read with. A column that names table and is not in
+// the header is an error, as a --column-type for a missing column is; one that
+// names no table applies to the inputs that have it.
+func dateColumnIndexes(header []string, table string, dates model.DateColumns) ([]dateColumn, error) {
+	if len(dates) == 0 {
+		return nil, nil
+	}
+	layouts := dates.ForTable(table)
+	var columns []dateColumn
+	for i, name := range header {
+		if layout, ok := layouts[strings.ToLower(name)]; ok {
+			columns = append(columns, dateColumn{index: i, layout: layout})
+		}
+	}
+	for _, column := range dates {
+		if column.Table == "" || !strings.EqualFold(column.Table, table) {
+			continue
+		}
+		if !slices.ContainsFunc(header, func(name string) bool { return strings.EqualFold(name, column.Column) }) {
+			return nil, fmt.Errorf("--date-columns %s: table %s has no column %q", column, table, column.Column)
+		}
+	}
+	return columns, nil
+}
+
+// normalizeDates rewrites the date columns of one cleaned record as ISO 8601
+// in place. An empty value is a missing one and is left empty. line is the
+// record's line in the file, for the message.
+func normalizeDates(record []string, header []string, dates []dateColumn, line int) error {
+	for _, date := range dates {
+		if date.index >= len(record) || record[date.index] == "" {
+			continue
+		}
+		t, err := date.layout.Parse(record[date.index])
+		if err != nil {
+			return fmt.Errorf("line %d, column %s: %w", line, header[date.index], err)
+		}
+		record[date.index] = date.layout.ISO(t)
+	}
+	return nil
+}
+
+// decimalColumnIndexes returns the positions of the decimal columns of header.
+// A column that names table and is not in the header is an error, as it is for
+// --date-columns; one that names no table applies to the inputs that have it.
+func decimalColumnIndexes(header []string, table string, decimals model.DecimalColumns) ([]int, error) {
+	if len(decimals) == 0 {
+		return nil, nil
+	}
+	names := decimals.ForTable(table)
+	var indexes []int
+	for i, name := range header {
+		if names[strings.ToLower(name)] {
+			indexes = append(indexes, i)
+		}
+	}
+	for _, column := range decimals {
+		if column.Table == "" || !strings.EqualFold(column.Table, table) {
+			continue
+		}
+		if !slices.ContainsFunc(header, func(name string) bool { return strings.EqualFold(name, column.Column) }) {
+			return nil, fmt.Errorf("--decimal-columns %s: table %s has no column %q", column, table, column.Column)
+		}
+	}
+	return indexes, nil
+}
+
+// keepDecimalsText puts a space before each value of the decimal columns of
+// one cleaned record, in place, so filesql loads them as text. An empty value
+// is a missing one and is left empty.
+func keepDecimalsText(record []string, decimals []int) {
+	for _, i := range decimals {
+		if i < len(record) && record[i] != "" {
+			record[i] = " " + record[i]
+		}
+	}
+}
+
+// stagedDecimalColumns returns the columns an input is staged with as
+// decimals: those --decimal-columns names, and those the declarations declare
+// DECIMAL. A column --column-type or .import --types declares as another type
+// is not one of them, because that declaration wins. The declared ones name no
+// table: an input staged as CSV makes one table, and a declaration for a
+// column it does not have is refused when it is applied.
+func stagedDecimalColumns(decimals model.DecimalColumns, declarations []tableDeclaration) model.DecimalColumns {
+	// typed reports whether a declared type other than DECIMAL wins for the
+	// column.
+	typed := func(column string) bool {
+		for _, d := range declarations {
+			for _, c := range d.types {
+				if strings.EqualFold(c.Column, column) && !model.IsDecimalColumnType(c.Type) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	var columns model.DecimalColumns
+	for _, c := range decimals {
+		if !typed(c.Column) {
+			columns = append(columns, c)
+		}
+	}
+	for _, d := range declarations {
+		if d.schema != nil {
+			for _, c := range d.schema.Columns {
+				if model.IsDecimalColumnType(c.Type) && !typed(c.Name) {
+					columns = append(columns, model.DecimalColumn{Column: c.Name})
+				}
+			}
+		}
+		for _, c := range d.types {
+			if model.IsDecimalColumnType(c.Type) {
+				columns = append(columns, model.DecimalColumn{Column: c.Column})
+			}
+		}
+	}
+	return columns
+}
+
+// declareDecimalColumns declares DECIMAL each column of table that decimals
+// names and the table has, unless --column-type or .import --types already
+// declares it something: a type given for one column wins over a list of them.
+// A schema's type for it does not, since the flag was given for this run.
+func declareDecimalColumns(ctx context.Context, tx *sql.Tx, declarations []tableDeclaration, table string, decimals model.DecimalColumns) ([]tableDeclaration, error) {
+	names := decimals.ForTable(table)
+	if len(names) == 0 {
+		return declarations, nil
+	}
+	columns, err := tableColumnDefinitions(ctx, tx, table)
+	if err != nil {
+		return nil, err
+	}
+	at := slices.IndexFunc(declarations, func(d tableDeclaration) bool { return strings.EqualFold(d.table, table) })
+	if at < 0 {
+		declarations = append(declarations, tableDeclaration{table: table})
+		at = len(declarations) - 1
+	}
+	d := &declarations[at]
+	for _, c := range columns {
+		if !names[strings.ToLower(c.Name)] {
+			continue
+		}
+		if slices.ContainsFunc(d.types, func(t model.ColumnType) bool { return strings.EqualFold(t.Column, c.Name) }) {
+			continue
+		}
+		d.types = append(d.types, model.ColumnType{Table: table, Column: c.Name, Type: model.ColumnTypeDecimal})
+	}
+	return declarations, nil
+}
+
+// nullEmptyValues sets every empty value of table to NULL. It runs on a table
+// loaded from a cleaned input, where an empty value is a missing one: either
+// the file left it out or it held one of the --null-values placeholders.
+func nullEmptyValues(ctx context.Context, tx *sql.Tx, table string) error {
+	columns, err := tableColumnDefinitions(ctx, tx, table)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	assignments := make([]string, len(columns))
+	for i, c := range columns {
+		name := QuoteIdentifier(c.Name)
+		assignments[i] = fmt.Sprintf("%s = NULLIF(%s, '')", name, name)
+	}
+	statement := fmt.Sprintf("UPDATE %s SET %s", QuoteIdentifier(table), strings.Join(assignments, ", "))
+	if _, err := tx.ExecContext(ctx, statement); err != nil {
+		return fmt.Errorf("read the empty values of %q as NULL: %w", table, err)
+	}
+	return nil
+}
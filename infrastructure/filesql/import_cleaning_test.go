@@ -0,0 +1,213 @@
+package filesql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// loadCleaned writes content to a file called name, imports it with cleaning
+// and dialect, and returns the result of query as CSV, NULL written as \N.
+func loadCleaned(t *testing.T, name, content string, cleaning model.ImportCleaning, dialect model.CSVDialect, query string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	adapter := newTestAdapter(db)
+	adapter.SetImportCleaning(cleaning)
+	ctx := context.Background()
+	if err := adapter.LoadFiles(ctx, model.FileLoad{Path: path, Dialect: dialect}); err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	table, err := adapter.Query(ctx, query)
+	if err != nil {
+		t.Fatalf("Query(%s): %v", query, err)
+	}
+	var out bytes.Buffer
+	if err := table.WithNullString(`\N`).Print(&out, model.PrintModeCSV); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+// mustDateColumns parses a --date-columns value.
+func mustDateColumns(t *testing.T, spec string) model.DateColumns {
+	t.Helper()
+	columns, err := model.ParseDateColumns(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return columns
+}
+
+// mustDecimalColumns parses a --decimal-columns value.
+func mustDecimalColumns(t *testing.T, spec string) model.DecimalColumns {
+	t.Helper()
+	columns, err := model.ParseDecimalColumns(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return columns
+}
+
+func TestLoadCleaned(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		cleaning model.ImportCleaning
+		dialect  model.CSVDialect
+		query    string
+		want     string
+	}{
+		{
+			name:     "placeholders are NULL and the column is typed by the rest",
+			file:     "scores.csv",
+			content:  "id,score\n1,10\n2,NA\n3,\n4,-\n",
+			cleaning: model.ImportCleaning{NullValues: model.ParseNullValues("NA,-")},
+			query:    "SELECT id, score, typeof(score) FROM scores ORDER BY id",
+			want:     "id,score,typeof(score)\n1,10,integer\n2,\\N,null\n3,\\N,null\n4,\\N,null\n",
+		},
+		{
+			name:     "a value that only looks like a placeholder is kept",
+			file:     "notes.csv",
+			content:  "id,note\n1,NA\n2,na\n",
+			cleaning: model.ImportCleaning{NullValues: model.ParseNullValues("NA")},
+			query:    "SELECT id, note FROM notes ORDER BY id",
+			want:     "id,note\n1,\\N\n2,na\n",
+		},
+		{
+			name:     "trimmed names and values",
+			file:     "padded.csv",
+			content:  " id , name \n 1 ,  a b  \n",
+			cleaning: model.ImportCleaning{Trim: true},
+			query:    "SELECT id, typeof(id), name FROM padded",
+			want:     "id,typeof(id),name\n1,integer,a b\n",
+		},
+		{
+			name:     "trimming only keeps the empty values empty",
+			file:     "blank.csv",
+			content:  "id,name\n1, \n",
+			cleaning: model.ImportCleaning{Trim: true},
+			query:    "SELECT id, quote(name) FROM blank",
+			want:     "id,quote(name)\n1,''\n",
+		},
+		{
+			name:     "with a dialect",
+			file:     "semi.tsv",
+			content:  "id;v\n1; N/A\n2;3\n",
+			cleaning: model.ImportCleaning{Trim: true, NullValues: model.ParseNullValues("N/A")},
+			dialect:  model.CSVDialect{Delimiter: ';'},
+			query:    "SELECT id, v, typeof(v) FROM semi ORDER BY id",
+			want:     "id,v,typeof(v)\n1,\\N,null\n2,3,integer\n",
+		},
+		{
+			name:     "dates read with their layout",
+			file:     "orders.csv",
+			content:  "id,ordered,shipped\n1,03/14/2024,14.03.2024 10:22\n2,NA,\n",
+			cleaning: model.ImportCleaning{NullValues: model.ParseNullValues("NA"), Dates: mustDateColumns(t, "ordered=%m/%d/%Y,orders.shipped=%d.%m.%Y %H:%M")},
+			query:    "SELECT id, ordered, shipped, date(shipped, '+1 day') FROM orders ORDER BY id",
+			want:     "id,ordered,shipped,\"date(shipped, '+1 day')\"\n1,2024-03-14,2024-03-14 10:22:00,2024-03-15\n2,\\N,\\N,\\N\n",
+		},
+		{
+			name:     "a date column of another table is left alone",
+			file:     "returns.csv",
+			content:  "id,shipped\n1,14.03.2024\n",
+			cleaning: model.ImportCleaning{Dates: mustDateColumns(t, "orders.shipped=%d.%m.%Y")},
+			query:    "SELECT shipped FROM returns",
+			want:     "shipped\n14.03.2024\n",
+		},
+		{
+			name:     "crlf line endings leave no carriage return to miss a placeholder or a column",
+			file:     "crlf.csv",
+			content:  "id,amount,ordered,note\r\n1,19.90,03/14/2024,NA\r\n2,0.10,12/01/2023,ok\r\n",
+			cleaning: model.ImportCleaning{NullValues: model.ParseNullValues("NA"), Dates: mustDateColumns(t, "ordered=%m/%d/%Y"), Decimals: mustDecimalColumns(t, "amount")},
+			query:    "SELECT id, amount, ordered, note FROM crlf ORDER BY id",
+			want:     "id,amount,ordered,note\n1,19.90,2024-03-14,\\N\n2,0.10,2023-12-01,ok\n",
+		},
+		{
+			name:     "decimal columns keep every digit as written",
+			file:     "payments.csv",
+			content:  "id,amount,fee\n1,19.90,0.10\n2,12345678901234567.89,0.20\n3,,0.30\n",
+			cleaning: model.ImportCleaning{Decimals: mustDecimalColumns(t, "amount,payments.fee")},
+			query:    "SELECT id, amount, typeof(amount), fee FROM payments ORDER BY id",
+			want:     "id,amount,typeof(amount),fee\n1,19.90,text,0.10\n2,12345678901234567.89,text,0.20\n3,\\N,null,0.30\n",
+		},
+		{
+			name:     "a decimal column of another table is left alone",
+			file:     "refunds.csv",
+			content:  "id,fee\n1,0.10\n",
+			cleaning: model.ImportCleaning{Decimals: mustDecimalColumns(t, "payments.fee")},
+			query:    "SELECT fee, typeof(fee) FROM refunds",
+			want:     "fee,typeof(fee)\n0.1,real\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := loadCleaned(t, tt.file, tt.content, tt.cleaning, tt.dialect, tt.query); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadCleaned_DateErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		dates   string
+		want    string
+	}{
+		{
+			name:    "a value the layout does not read fails with its line",
+			content: "id,ordered\n1,03/14/2024\n2,14/03/2024\n",
+			dates:   "ordered=%m/%d/%Y",
+			want:    `line 3, column ordered: "14/03/2024" does not match %m/%d/%Y: month 14 is out of range`,
+		},
+		{
+			name:    "a column the table does not have",
+			content: "id,ordered\n1,03/14/2024\n",
+			dates:   "orders.placed=%m/%d/%Y",
+			want:    `table orders has no column "placed"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "orders.csv")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			db, err := sql.Open("sqlite", ":memory:")
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = db.Close() })
+
+			adapter := newTestAdapter(db)
+			adapter.SetImportCleaning(model.ImportCleaning{Dates: mustDateColumns(t, tt.dates)})
+			err = adapter.LoadFile(context.Background(), path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadFile error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -40,6 +40,14 @@ func DumpTableToParquet(filePath string, table *model.Table) (err error) {
 		return fmt.Errorf("parquet: duplicate column name %q; a parquet schema names each column once, so alias one of them (SELECT a AS a1, b AS a2)", name)
 	}
 
+	decimals, err := parquetDecimalColumns(table)
+	if err != nil {
+		return err
+	}
+	if hasParquetDecimal(decimals) {
+		return writeParquetWithDecimals(filePath, table, decimals)
+	}
+
 	tmpDir, err := os.MkdirTemp("", "sqly-parquet-")
 	if err != nil {
 		return fmt.Errorf("create temp dir for parquet dump: %w", err)
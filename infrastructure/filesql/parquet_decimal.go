@@ -0,0 +1,158 @@
+package filesql
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/apache/arrow/go/v18/arrow"
+	"github.com/apache/arrow/go/v18/arrow/array"
+	"github.com/apache/arrow/go/v18/arrow/decimal128"
+	"github.com/apache/arrow/go/v18/arrow/memory"
+	"github.com/apache/arrow/go/v18/parquet/pqarrow"
+	"github.com/nao1215/sqly/domain/cleanup"
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// A result with a DECIMAL column is written to Parquet here rather than through
+// filesql's dump. filesql's writer knows three kinds of column — integers,
+// doubles, and strings — and a decimal staged as any of them arrives either as
+// the double nearest it, which is the drift the column is declared to prevent,
+// or as text a reader has to parse. Parquet has a decimal type of its own, and
+// this writes it: the unscaled integer and the scale, digit for digit.
+//
+// The other columns are typed as the staged export types them, from
+// parquetStagingColumnType, so adding a DECIMAL column to a query does not
+// change how the rest of it is written.
+
+// maxParquetDecimalPrecision is the most digits a 128-bit Parquet decimal
+// holds.
+const maxParquetDecimalPrecision = 38
+
+// parquetDecimalColumns returns the Parquet decimal type of every column that
+// holds decimals and nothing else but NULL, and nil for every other column.
+// The scale is the largest any value has, so no value loses a digit, and the
+// precision is what the widest value needs at that scale.
+func parquetDecimalColumns(t *model.Table) ([]*arrow.Decimal128Type, error) {
+	types := make([]*arrow.Decimal128Type, t.ColumnCount())
+	for col := range t.ColumnCount() {
+		var values []model.Decimal
+		decimal := true
+		for row := range t.RowCount() {
+			cell, ok := t.NativeCell(row, col)
+			if !ok {
+				return types, nil
+			}
+			if cell.IsNull() {
+				continue
+			}
+			d, ok := cell.Value().(model.Decimal)
+			if !ok {
+				decimal = false
+				break
+			}
+			values = append(values, d)
+		}
+		if !decimal || len(values) == 0 {
+			continue
+		}
+		scale := 0
+		for _, d := range values {
+			scale = max(scale, d.Scale())
+		}
+		precision := max(scale, 1)
+		for _, d := range values {
+			unscaled := d.Round(scale).Unscaled()
+			precision = max(precision, len(unscaled.Abs(unscaled).String()))
+		}
+		if precision > maxParquetDecimalPrecision {
+			return nil, fmt.Errorf("parquet: column %q holds decimals of %d digits, and a parquet decimal holds %d; round them with dec_round, or export to csv or json",
+				t.ColumnName(col), precision, maxParquetDecimalPrecision)
+		}
+		types[col] = &arrow.Decimal128Type{Precision: int32(precision), Scale: int32(scale)} //nolint:gosec // both are at most 38
+	}
+	return types, nil
+}
+
+// hasParquetDecimal reports whether any column is written as a decimal.
+func hasParquetDecimal(types []*arrow.Decimal128Type) bool {
+	return slices.ContainsFunc(types, func(t *arrow.Decimal128Type) bool { return t != nil })
+}
+
+// writeParquetWithDecimals writes table to a Parquet file at filePath, its
+// decimal columns as Parquet decimals.
+func writeParquetWithDecimals(filePath string, table *model.Table, decimals []*arrow.Decimal128Type) (err error) {
+	stagingTypes := parquetStagingTypes(table)
+	fields := make([]arrow.Field, table.ColumnCount())
+	for col, name := range table.Columns {
+		var dataType arrow.DataType
+		switch {
+		case decimals[col] != nil:
+			dataType = decimals[col]
+		case stagingTypes[col] == "INTEGER":
+			dataType = arrow.PrimitiveTypes.Int64
+		case stagingTypes[col] == "REAL":
+			dataType = arrow.PrimitiveTypes.Float64
+		default:
+			dataType = arrow.BinaryTypes.String
+		}
+		fields[col] = arrow.Field{Name: name, Type: dataType, Nullable: true}
+	}
+	schema := arrow.NewSchema(fields, nil)
+
+	builder := array.NewRecordBuilder(memory.NewGoAllocator(), schema)
+	defer builder.Release()
+	for row, record := range table.Rows {
+		for col := range table.ColumnCount() {
+			field := builder.Field(col)
+			if table.IsNull(row, col) {
+				field.AppendNull()
+				continue
+			}
+			cell, _ := table.NativeCell(row, col)
+			switch b := field.(type) {
+			case *array.Decimal128Builder:
+				d, _ := cell.Value().(model.Decimal)
+				b.Append(decimal128.FromBigInt(d.Round(int(decimals[col].Scale)).Unscaled()))
+			case *array.Int64Builder:
+				v, _ := cell.Value().(int64)
+				b.Append(v)
+			case *array.Float64Builder:
+				switch v := cell.Value().(type) {
+				case int64:
+					b.Append(float64(v))
+				case float64:
+					b.Append(v)
+				}
+			case *array.StringBuilder:
+				b.Append(record.At(col))
+			}
+		}
+	}
+	batch := builder.NewRecord()
+	defer batch.Release()
+
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600) //nolint:gosec // user-specified output path
+	if err != nil {
+		return fmt.Errorf("write parquet to %q: %w", filePath, err)
+	}
+	defer func() {
+		err = cleanup.Join(err, file.Close(), "close parquet file")
+	}()
+	// The writer closes a sink it can close. The file is closed above instead,
+	// where its error is checked, so the writer is only given its Write.
+	writer, err := pqarrow.NewFileWriter(schema, struct{ io.Writer }{file}, nil, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+	if err != nil {
+		return fmt.Errorf("write parquet to %q: %w", filePath, err)
+	}
+	if err := writer.Write(batch); err != nil {
+		_ = writer.Close() // the write error is the one to report
+		return fmt.Errorf("write parquet to %q: %w", filePath, err)
+	}
+	// Close writes the footer, so this is where an incomplete file shows up.
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("write parquet to %q: %w", filePath, err)
+	}
+	return nil
+}
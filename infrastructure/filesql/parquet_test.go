@@ -3,10 +3,15 @@ package filesql
 import (
 	"context"
 	"database/sql"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/apache/arrow/go/v18/arrow/array"
+	"github.com/apache/arrow/go/v18/arrow/memory"
+	"github.com/apache/arrow/go/v18/parquet/file"
+	"github.com/apache/arrow/go/v18/parquet/pqarrow"
 	libfilesql "github.com/nao1215/filesql"
 	"github.com/nao1215/sqly/domain/model"
 	_ "modernc.org/sqlite"
@@ -335,3 +340,83 @@ func TestDumpTableToParquet_PreservesValuesSQLCannotParse(t *testing.T) {
 		})
 	}
 }
+
+// TestDumpTableToParquet_WritesDecimals pins that a DECIMAL column reaches
+// Parquet as a Parquet decimal, its unscaled digits exact, rather than as the
+// double nearest it.
+func TestDumpTableToParquet_WritesDecimals(t *testing.T) {
+	t.Parallel()
+
+	decimal := func(s string) model.Cell { return model.NewDecimalCell(s) }
+	table, err := model.NewTableFromCells("payments", model.Header{"id", "amount"}, [][]model.Cell{
+		{model.NewCell(int64(1)), decimal("19.9")},
+		{model.NewCell(int64(2)), decimal("12345678901234567.89")},
+		{model.NewCell(int64(3)), model.NewCell(nil)},
+	})
+	if err != nil {
+		t.Fatalf("NewTableFromCells: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "payments.parquet")
+	if err := DumpTableToParquet(out, table); err != nil {
+		t.Fatalf("DumpTableToParquet: %v", err)
+	}
+
+	f, err := os.Open(out) //nolint:gosec // test temp path
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	reader, err := file.NewParquetReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = reader.Close() }()
+	arrowReader, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := arrowReader.ReadTable(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Release()
+
+	field := got.Schema().Field(1)
+	if field.Type.String() != "decimal(19, 2)" {
+		t.Fatalf("amount is written as %s, want decimal(19, 2)", field.Type)
+	}
+	amounts, ok := got.Column(1).Data().Chunk(0).(*array.Decimal128)
+	if !ok {
+		t.Fatalf("amount reads back as %T", got.Column(1).Data().Chunk(0))
+	}
+	// The unscaled integers, since arrow prints 19.90 at scale 2 as 19.9.
+	var values []string
+	for i := range amounts.Len() {
+		if amounts.IsNull(i) {
+			values = append(values, "NULL")
+			continue
+		}
+		values = append(values, amounts.Value(i).BigInt().String())
+	}
+	if got, want := strings.Join(values, " "), "1990 1234567890123456789 NULL"; got != want {
+		t.Errorf("unscaled amounts = %s, want %s", got, want)
+	}
+}
+
+// TestDumpTableToParquet_RefusesDecimalsParquetCannotHold pins that a decimal
+// wider than Parquet's 38 digits is refused, with a way out, rather than
+// written short.
+func TestDumpTableToParquet_RefusesDecimalsParquetCannotHold(t *testing.T) {
+	t.Parallel()
+
+	table, err := model.NewTableFromCells("wide", model.Header{"v"}, [][]model.Cell{
+		{model.NewDecimalCell("1" + strings.Repeat("0", 38) + ".5")},
+	})
+	if err != nil {
+		t.Fatalf("NewTableFromCells: %v", err)
+	}
+	err = DumpTableToParquet(filepath.Join(t.TempDir(), "wide.parquet"), table)
+	if err == nil || !strings.Contains(err.Error(), `column "v" holds decimals of 40 digits, and a parquet decimal holds 38`) {
+		t.Errorf("DumpTableToParquet error = %v, want the width refused", err)
+	}
+}
@@ -200,7 +200,7 @@ func TestFileSQLAdapter_ImportMode_PadRejectsBeforeEmptyJSONTable(t *testing.T)
 	adapter := newTestAdapter(db)
 	adapter.SetRowMismatchPolicy(model.RowMismatchPad)
 
-	if err := adapter.LoadFiles(context.Background(), emptyJSON, longCSV); err == nil {
+	if err := adapter.LoadFiles(context.Background(), fileLoads(emptyJSON, longCSV)...); err == nil {
 		t.Fatal("expected pad to reject the mixed import")
 	}
 	if _, err := adapter.Query(context.Background(), "SELECT * FROM empty"); err == nil {
@@ -240,7 +240,7 @@ func TestFileSQLAdapter_LoadFilesIsAtomicAcrossInputFormats(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			if err := newTestAdapter(db).LoadFiles(ctx, emptyJSON, bad); err == nil {
+			if err := newTestAdapter(db).LoadFiles(ctx, fileLoads(emptyJSON, bad)...); err == nil {
 				t.Fatal("mixed import returned nil, want an error")
 			}
 			var value string
@@ -288,7 +288,7 @@ func TestFileSQLAdapter_LoadFilesPreservesInputOrderForLastWins(t *testing.T) {
 		db.SetMaxOpenConns(1)
 		t.Cleanup(func() { _ = db.Close() })
 		ctx := context.Background()
-		if err := newTestAdapter(db).LoadFiles(ctx, paths...); err != nil {
+		if err := newTestAdapter(db).LoadFiles(ctx, fileLoads(paths...)...); err != nil {
 			t.Fatalf("LoadFiles: %v", err)
 		}
 		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM "same"`).Scan(&count); err != nil {
@@ -346,11 +346,11 @@ func TestFileSQLAdapter_LoadFilesRollsBackWhenLaterApplyFails(t *testing.T) {
 	}
 
 	adapter := newTestAdapter(db)
-	err = adapter.LoadFiles(ctx,
+	err = adapter.LoadFiles(ctx, fileLoads(
 		filepath.Join(dir, "first.csv"),
 		filepath.Join(dir, "same.csv"),
 		filepath.Join(dir, "blocked.csv"),
-	)
+	)...)
 	if err == nil {
 		t.Fatal("LoadFiles returned nil, want view collision error")
 	}
@@ -397,7 +397,7 @@ func TestFileSQLAdapter_LoadFilesEmptyJSONViewCollision(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := newTestAdapter(db).LoadFiles(ctx, emptyJSON); err == nil {
+	if err := newTestAdapter(db).LoadFiles(ctx, fileLoads(emptyJSON)...); err == nil {
 		t.Fatal("LoadFiles returned nil, want empty-table/view collision error")
 	}
 	var id int
@@ -0,0 +1,232 @@
+package filesql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// schemaSidecarSuffix is what a data file's own schema sidecar appends to its
+// name: orders.csv is described by orders.csv.schema.json.
+const schemaSidecarSuffix = ".schema.json"
+
+// dataPackageFile is the Frictionless descriptor a directory of data files may
+// carry, describing each file as a resource.
+const dataPackageFile = "datapackage.json"
+
+// schemaSourceFor returns the data file whose sidecar applies to an input: the
+// file it was staged from, for a download or a re-encoded text file loaded from
+// a temporary directory where no sidecar is, or the input itself. An input
+// marked NoSchema has none, which SchemaSidecar reads as "".
+func schemaSourceFor(file model.FileLoad) string {
+	switch {
+	case file.NoSchema:
+		return ""
+	case file.SchemaSource != "":
+		return file.SchemaSource
+	default:
+		return file.Path
+	}
+}
+
+// SchemaSidecar returns the schema file that describes the data file at path,
+// or "" when none does. The file's own FILE.schema.json comes first; failing
+// that, a datapackage.json in the same directory counts when it has a resource
+// for the file. The file's own sidecar wins because it is the more specific of
+// the two: a package describes a directory, and a sidecar was written for this
+// one file.
+//
+// A datapackage.json that cannot be read is an error rather than "no schema":
+// it sits beside the data on purpose, and skipping it would import the file
+// with none of what it declares. An empty FILE.schema.json is the exception,
+// and counts as absent: `sqly --inspect --format schema orders.csv >
+// orders.csv.schema.json` creates the file empty before sqly reads it, and the
+// command that writes a sidecar must not be the one it breaks.
+func SchemaSidecar(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	own := path + schemaSidecarSuffix
+	if info, err := os.Stat(own); err == nil && info.Mode().IsRegular() && info.Size() > 0 {
+		return own, nil
+	}
+	pkg := filepath.Join(filepath.Dir(path), dataPackageFile)
+	data, err := os.ReadFile(pkg) //nolint:gosec // the descriptor beside a file the user named
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", pkg, err)
+	}
+	resource, err := dataPackageResource(data, filepath.Base(path))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", pkg, err)
+	}
+	if resource == nil {
+		return "", nil
+	}
+	return pkg, nil
+}
+
+// readImportSchema reads the schema a sidecar declares for the data file at
+// path.
+func readImportSchema(path, sidecar string) (*model.ImportSchema, error) {
+	data, err := os.ReadFile(sidecar) //nolint:gosec // found by SchemaSidecar beside the data
+	if err != nil {
+		return nil, fmt.Errorf("read schema %s: %w", sidecar, err)
+	}
+	if filepath.Base(sidecar) != dataPackageFile {
+		schema, err := model.ParseImportSchema(data)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", sidecar, err)
+		}
+		return schema, nil
+	}
+	resource, err := dataPackageResource(data, filepath.Base(path))
+	if err == nil && resource == nil {
+		err = fmt.Errorf("no resource has the path %s", filepath.Base(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("schema %s: %w", sidecar, err)
+	}
+	table, err := resource.tableSchema()
+	if err != nil {
+		return nil, fmt.Errorf("schema %s: %w", sidecar, err)
+	}
+	return &model.ImportSchema{SchemaVersion: model.ImportSchemaVersion, Tables: []model.TableSchema{table}}, nil
+}
+
+// dataPackage is the part of a Frictionless data package sqly reads: each
+// resource's path and its inline table schema. Everything else a package may
+// hold — licences, sources, a title — is about the data rather than its shape,
+// and is ignored.
+type dataPackage struct {
+	Resources []dataPackageResourceJSON `json:"resources"`
+}
+
+// dataPackageResourceJSON is one resource of a data package. Its path is a
+// string or, for a file split in parts, a list of them; its schema is inline or
+// the path of a separate schema file, which sqly does not follow.
+type dataPackageResourceJSON struct {
+	Name   string          `json:"name"`
+	Path   json.RawMessage `json:"path"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+// frictionlessSchema is a Table Schema: the fields in column order and the
+// primary key, which is one field name or a list of them.
+type frictionlessSchema struct {
+	Fields []struct {
+		Name        string `json:"name"`
+		Type        string `json:"type"`
+		Constraints struct {
+			Required bool `json:"required"`
+		} `json:"constraints"`
+	} `json:"fields"`
+	PrimaryKey json.RawMessage `json:"primaryKey"`
+}
+
+// dataPackageResource returns the resource whose path is the file named base,
+// or nil when the package has none.
+func dataPackageResource(data []byte, base string) (*dataPackageResourceJSON, error) {
+	var pkg dataPackage
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("not a data package: %w", err)
+	}
+	for i, resource := range pkg.Resources {
+		paths, err := stringOrList(resource.Path)
+		if err != nil {
+			return nil, fmt.Errorf("resource %d: path: %w", i+1, err)
+		}
+		for _, p := range paths {
+			if filepath.Clean(filepath.FromSlash(p)) == base {
+				return &pkg.Resources[i], nil
+			}
+		}
+	}
+	return nil, nil //nolint:nilnil // no resource is the answer for a package that does not list the file
+}
+
+// tableSchema maps the resource's Table Schema onto sqly's. Frictionless types
+// are mapped to the storage a value of that type gets: an integer or a year is
+// an INTEGER, a number is a REAL, a date or a datetime is a DATETIME, and every
+// other type — a string, a boolean, a duration, an object — is TEXT, since that
+// is how the file spells it. A required field is NOT NULL.
+func (r *dataPackageResourceJSON) tableSchema() (model.TableSchema, error) {
+	if len(r.Schema) == 0 {
+		return model.TableSchema{}, fmt.Errorf("resource %q has no schema", r.Name)
+	}
+	if r.Schema[0] == '"' {
+		return model.TableSchema{}, fmt.Errorf("resource %q keeps its schema in a separate file, which sqly does not read; put the schema inline", r.Name)
+	}
+	var schema frictionlessSchema
+	if err := json.Unmarshal(r.Schema, &schema); err != nil {
+		return model.TableSchema{}, fmt.Errorf("resource %q: schema: %w", r.Name, err)
+	}
+	primaryKey, err := stringOrList(schema.PrimaryKey)
+	if err != nil {
+		return model.TableSchema{}, fmt.Errorf("resource %q: primaryKey: %w", r.Name, err)
+	}
+
+	var table model.TableSchema
+	for _, field := range schema.Fields {
+		nullable := !field.Constraints.Required
+		column := model.ColumnSchema{
+			Name:     field.Name,
+			Type:     frictionlessColumnType(field.Type),
+			Nullable: &nullable,
+		}
+		for _, key := range primaryKey {
+			if key == field.Name {
+				column.PrimaryKey = true
+			}
+		}
+		table.Columns = append(table.Columns, column)
+	}
+	for _, key := range primaryKey {
+		if _, ok := table.Column(key); !ok {
+			return model.TableSchema{}, fmt.Errorf("resource %q: primaryKey names %q, which is not one of its fields", r.Name, key)
+		}
+	}
+	if err := table.Normalize(); err != nil {
+		return model.TableSchema{}, fmt.Errorf("resource %q: %w", r.Name, err)
+	}
+	return table, nil
+}
+
+// frictionlessColumnType maps a Table Schema field type to a declarable type.
+func frictionlessColumnType(fieldType string) string {
+	switch strings.ToLower(fieldType) {
+	case "integer", "year":
+		return model.ColumnTypeInteger
+	case "number":
+		return model.ColumnTypeReal
+	case "date", "datetime":
+		return model.ColumnTypeDatetime
+	default:
+		return model.ColumnTypeText
+	}
+}
+
+// stringOrList reads a JSON value that is one string or a list of strings, as a
+// data package's paths and primary keys are. An absent value is an empty list.
+func stringOrList(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil {
+		return []string{one}, nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, errors.New("want a string or a list of strings")
+	}
+	return list, nil
+}
@@ -0,0 +1,178 @@
+package filesql
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// loadWithSidecar writes users.csv and, beside it, each of the named files,
+// then imports users.csv and returns the adapter.
+func loadWithSidecar(t *testing.T, content string, files map[string]string) (*testAdapter, error) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "users.csv")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	adapter := newTestAdapter(db)
+	return adapter, adapter.LoadFile(context.Background(), path)
+}
+
+// tableSQL returns the CREATE TABLE statement of the users table.
+func tableSQL(t *testing.T, adapter *testAdapter) string {
+	t.Helper()
+	table, err := adapter.Query(context.Background(), "SELECT sql FROM sqlite_master WHERE name = 'users'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table.Records()[0][0]
+}
+
+const usersSidecar = `{
+  "schema_version": 1,
+  "tables": [
+    {"name": "users", "columns": [
+      {"name": "id", "type": "INTEGER", "nullable": false, "primary_key": true},
+      {"name": "zip", "type": "TEXT", "primary_key": false},
+      {"name": "amount", "type": "REAL", "primary_key": false}
+    ]}
+  ]
+}
+`
+
+func TestFileSQLAdapter_SchemaSidecar(t *testing.T) {
+	t.Parallel()
+
+	t.Run("the file's own sidecar declares types and constraints", func(t *testing.T) {
+		t.Parallel()
+		adapter, err := loadWithSidecar(t, "id,zip,amount\n1,01234,10\n2,98765,2.5\n",
+			map[string]string{"users.csv.schema.json": usersSidecar})
+		if err != nil {
+			t.Fatalf("LoadFile: %v", err)
+		}
+		want := `CREATE TABLE "users" ("id" INTEGER NOT NULL PRIMARY KEY, "zip" TEXT, "amount" REAL)`
+		if got := tableSQL(t, adapter); got != want {
+			t.Errorf("table = %s, want %s", got, want)
+		}
+		table, err := adapter.Query(context.Background(), "SELECT group_concat(zip), count(*) FROM users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(table.Records()[0], " "); got != "01234,98765 2" {
+			t.Errorf("zip, rows = %q, want the leading zero kept and both rows", got)
+		}
+	})
+
+	t.Run("a duplicate primary key fails the import", func(t *testing.T) {
+		t.Parallel()
+		_, err := loadWithSidecar(t, "id,zip,amount\n1,01234,10\n1,98765,2.5\n",
+			map[string]string{"users.csv.schema.json": usersSidecar})
+		if err == nil || !strings.Contains(err.Error(), "primary key") {
+			t.Errorf("LoadFile error = %v, want the duplicate key reported", err)
+		}
+	})
+
+	t.Run("an empty NOT NULL value fails the import", func(t *testing.T) {
+		t.Parallel()
+		_, err := loadWithSidecar(t, "id,zip,amount\n1,01234,10\n,98765,2.5\n",
+			map[string]string{"users.csv.schema.json": usersSidecar})
+		if err == nil || !strings.Contains(err.Error(), "NOT NULL") {
+			t.Errorf("LoadFile error = %v, want the NULL reported", err)
+		}
+	})
+
+	t.Run("a file whose columns drifted from the sidecar is refused", func(t *testing.T) {
+		t.Parallel()
+		for _, content := range []string{"id,zip\n1,01234\n", "id,zip,amount,extra\n1,01234,10,x\n"} {
+			if _, err := loadWithSidecar(t, content, map[string]string{"users.csv.schema.json": usersSidecar}); err == nil {
+				t.Errorf("LoadFile(%q) succeeded, want the drift refused", content)
+			}
+		}
+	})
+
+	t.Run("an empty sidecar counts as absent", func(t *testing.T) {
+		t.Parallel()
+		adapter, err := loadWithSidecar(t, "id,zip\n1,01234\n", map[string]string{"users.csv.schema.json": ""})
+		if err != nil {
+			t.Fatalf("LoadFile: %v", err)
+		}
+		if got := tableSQL(t, adapter); strings.Contains(got, "PRIMARY KEY") {
+			t.Errorf("table = %s, want the inferred schema", got)
+		}
+	})
+
+	t.Run("a data package resource is mapped onto the table", func(t *testing.T) {
+		t.Parallel()
+		pkg := `{"name": "p", "resources": [{"name": "users", "path": "users.csv", "schema": {
+  "fields": [
+    {"name": "id", "type": "integer", "constraints": {"required": true}},
+    {"name": "zip", "type": "string"},
+    {"name": "joined", "type": "date"}
+  ],
+  "primaryKey": ["id"]
+}}]}`
+		adapter, err := loadWithSidecar(t, "id,zip,joined\n1,01234,2024-01-02\n", map[string]string{dataPackageFile: pkg})
+		if err != nil {
+			t.Fatalf("LoadFile: %v", err)
+		}
+		want := `CREATE TABLE "users" ("id" INTEGER NOT NULL PRIMARY KEY, "zip" TEXT, "joined" DATETIME)`
+		if got := tableSQL(t, adapter); got != want {
+			t.Errorf("table = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("the file's own sidecar wins over a data package", func(t *testing.T) {
+		t.Parallel()
+		pkg := `{"resources": [{"path": "users.csv", "schema": {"fields": [{"name": "id", "type": "string"}]}}]}`
+		adapter, err := loadWithSidecar(t, "id,zip,amount\n1,01234,10\n", map[string]string{
+			dataPackageFile:         pkg,
+			"users.csv.schema.json": usersSidecar,
+		})
+		if err != nil {
+			t.Fatalf("LoadFile: %v", err)
+		}
+		if got := tableSQL(t, adapter); !strings.Contains(got, "PRIMARY KEY") {
+			t.Errorf("table = %s, want the sidecar's declaration", got)
+		}
+	})
+}
+
+func TestSchemaSidecar(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "users.csv")
+	if got, err := SchemaSidecar(path); err != nil || got != "" {
+		t.Errorf("SchemaSidecar with none = %q, %v, want none", got, err)
+	}
+	pkg := filepath.Join(dir, dataPackageFile)
+	if err := os.WriteFile(pkg, []byte(`{"resources": [{"path": "other.csv"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := SchemaSidecar(path); err != nil || got != "" {
+		t.Errorf("SchemaSidecar with a package for another file = %q, %v, want none", got, err)
+	}
+	if err := os.WriteFile(pkg, []byte(`{"resources": [`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SchemaSidecar(path); err == nil {
+		t.Error("SchemaSidecar with a malformed package succeeded, want an error")
+	}
+}
@@ -0,0 +1,335 @@
+package filesql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/nao1215/sqly/domain/cleanup"
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// A SQLite database file is the one input that is already what sqly turns every
+// other input into. filesql does not read it, and ATTACH is refused in a session
+// for reasons that have nothing to do with importing (see
+// statementSaveCompatible), so its tables are copied here: each into a table of
+// the same name, inside the import's transaction like any other input.
+//
+// What is copied is what a table is, not everything a database holds around it.
+// Each column keeps its declared type, NOT NULL, and DEFAULT, and the table
+// keeps its primary key. Foreign keys, CHECK and UNIQUE constraints, indexes,
+// triggers, and views are left behind: a foreign key names a table the import
+// may not have copied, and sqly's database enforces them, so keeping one would
+// make whether an import works depend on which tables it was asked for.
+// A generated column becomes an ordinary one holding the values it had.
+
+// sqliteHeader is the first sixteen bytes of every SQLite database file.
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// sqliteExts lists the extensions read as SQLite databases. A database is
+// read in place, by SQLite itself, so there are no compressed variants.
+var sqliteExts = []string{".sqlite", ".sqlite3", ".db"}
+
+// IsSQLiteFile reports whether the path names a SQLite database file by its
+// extension.
+func IsSQLiteFile(filePath string) bool {
+	lower := strings.ToLower(filePath)
+	return slices.ContainsFunc(sqliteExts, func(ext string) bool { return strings.HasSuffix(lower, ext) })
+}
+
+// SetSQLiteTables sets the tables subsequent imports copy from a SQLite
+// database, from --sqlite-tables. Empty copies every table.
+func (f *FileSQLAdapter) SetSQLiteTables(tables []string) {
+	f.sqliteTables = tables
+}
+
+// SQLiteTables returns the tables an import of the database at path copies:
+// the ones --sqlite-tables names that it has, or every table when none are
+// named, in name order. A database that has none of the
+// named tables is an error, because importing it would do nothing.
+func (f *FileSQLAdapter) SQLiteTables(path string) ([]string, error) {
+	tables, err := f.selectSQLiteTables(path)
+	if err != nil {
+		return nil, fmt.Errorf("read the tables of %q: %w", path, err)
+	}
+	return tables, nil
+}
+
+// selectSQLiteTables is SQLiteTables without the path on its errors, for the
+// import, whose errors carry the path already.
+func (f *FileSQLAdapter) selectSQLiteTables(path string) (_ []string, err error) {
+	db, err := openSQLiteSource(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cleanup.Join(err, db.Close(), "close sqlite database")
+	}()
+	tables, err := sqliteSourceTables(context.Background(), db)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.sqliteTables) == 0 {
+		if len(tables) == 0 {
+			return nil, errors.New("the database has no tables")
+		}
+		return tables, nil
+	}
+	var selected []string
+	for _, table := range tables {
+		if slices.ContainsFunc(f.sqliteTables, func(name string) bool { return strings.EqualFold(name, table) }) {
+			selected = append(selected, table)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("the database has none of the tables --sqlite-tables names (%s); its tables are %s",
+			strings.Join(f.sqliteTables, ", "), strings.Join(tables, ", "))
+	}
+	return selected, nil
+}
+
+// stageSQLiteFile copies the selected tables of the database at path into the
+// import's transaction, each replacing any table of the same name.
+func (f *FileSQLAdapter) stageSQLiteFile(ctx context.Context, tx *sql.Tx, path string) (err error) {
+	tables, err := f.selectSQLiteTables(path)
+	if err != nil {
+		return err
+	}
+	db, err := openSQLiteSource(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = cleanup.Join(err, db.Close(), "close sqlite database")
+	}()
+	for _, table := range tables {
+		if err := copySQLiteTable(ctx, db, tx, table); err != nil {
+			return fmt.Errorf("table %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// openSQLiteSource opens a database file read-only. It is checked for SQLite's
+// header first: a .db file is as often some other program's format, and SQLite
+// opening one reports only "file is not a database", at the first query.
+func openSQLiteSource(path string) (*sql.DB, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	header := make([]byte, len(sqliteHeader))
+	_, readErr := io.ReadFull(file, header)
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("close file: %w", err)
+	}
+	if readErr != nil || !bytes.Equal(header, sqliteHeader) {
+		return nil, errors.New("the file is not a SQLite database")
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolve path: %w", err)
+	}
+	// mode=ro keeps the import from ever writing to the file it reads, which
+	// SQLite would otherwise do to a database left with a hot journal.
+	dsn := "file:" + (&url.URL{Path: filepath.ToSlash(abs)}).EscapedPath() + "?mode=ro"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// sqliteSourceTables lists a database's ordinary tables, leaving out views,
+// virtual tables and the shadow tables behind them, SQLite's own, and sqly's.
+// A --db session file holds sqly's record of its sources, which describes that
+// session and not this one, and whose name an import may not take anyway.
+func sqliteSourceTables(ctx context.Context, db *sql.DB) (_ []string, err error) {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM pragma_table_list WHERE schema = 'main' AND type = 'table' AND name NOT LIKE 'sqlite\\_%' ESCAPE '\\' ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	defer func() {
+		err = cleanup.Join(err, rows.Close(), "close table list")
+	}()
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("list tables: %w", err)
+		}
+		if model.IsReservedTableName(name) {
+			continue
+		}
+		tables = append(tables, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	return tables, nil
+}
+
+// sqliteColumn is one column of a source table, as PRAGMA table_xinfo
+// describes it.
+type sqliteColumn struct {
+	name         string
+	declaredType string
+	notNull      bool
+	defaultValue sql.NullString
+	// primaryKey is the column's position in the primary key, from 1, or 0.
+	primaryKey int
+}
+
+// sqliteTableColumns describes a source table's columns, hidden generated
+// ones included: they hold values like any other column once copied.
+func sqliteTableColumns(ctx context.Context, db *sql.DB, table string) (_ []sqliteColumn, err error) {
+	rows, err := db.QueryContext(ctx, "SELECT name, type, \"notnull\", dflt_value, pk FROM pragma_table_xinfo(?) WHERE hidden <> 1 ORDER BY cid", table)
+	if err != nil {
+		return nil, fmt.Errorf("read columns: %w", err)
+	}
+	defer func() {
+		err = cleanup.Join(err, rows.Close(), "close column list")
+	}()
+	var columns []sqliteColumn
+	for rows.Next() {
+		var c sqliteColumn
+		if err := rows.Scan(&c.name, &c.declaredType, &c.notNull, &c.defaultValue, &c.primaryKey); err != nil {
+			return nil, fmt.Errorf("read columns: %w", err)
+		}
+		columns = append(columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read columns: %w", err)
+	}
+	return columns, nil
+}
+
+// sqliteCreateTable builds the CREATE TABLE for a copy of a source table.
+func sqliteCreateTable(table string, columns []sqliteColumn) string {
+	definitions := make([]string, 0, len(columns)+1)
+	var key []sqliteColumn
+	for _, c := range columns {
+		definition := QuoteIdentifier(c.name)
+		if c.declaredType != "" {
+			definition += " " + c.declaredType
+		}
+		if c.notNull {
+			definition += " NOT NULL"
+		}
+		if c.defaultValue.Valid {
+			definition += " DEFAULT (" + c.defaultValue.String + ")"
+		}
+		definitions = append(definitions, definition)
+		if c.primaryKey > 0 {
+			key = append(key, c)
+		}
+	}
+	if len(key) > 0 {
+		slices.SortFunc(key, func(a, b sqliteColumn) int { return a.primaryKey - b.primaryKey })
+		names := make([]string, 0, len(key))
+		for _, c := range key {
+			names = append(names, QuoteIdentifier(c.name))
+		}
+		definitions = append(definitions, "PRIMARY KEY ("+strings.Join(names, ", ")+")")
+	}
+	return "CREATE TABLE " + QuoteIdentifier(table) + " (" + strings.Join(definitions, ", ") + ")"
+}
+
+// copySQLiteTable creates table in tx as the source declares it and copies its
+// rows across, each value as the source stores it.
+func copySQLiteTable(ctx context.Context, db *sql.DB, tx *sql.Tx, table string) (err error) {
+	columns, err := sqliteTableColumns(ctx, db, table)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DROP TABLE IF EXISTS "+QuoteIdentifier(table)); err != nil {
+		return fmt.Errorf("replace table: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, sqliteCreateTable(table, columns)); err != nil {
+		return fmt.Errorf("create table: %w", err)
+	}
+
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		names = append(names, QuoteIdentifier(c.name))
+	}
+	list := strings.Join(names, ", ")
+	rows, err := db.QueryContext(ctx, "SELECT "+list+" FROM "+QuoteIdentifier(table)) //nolint:gosec // identifiers are quoted
+	if err != nil {
+		return fmt.Errorf("read rows: %w", err)
+	}
+	defer func() {
+		err = cleanup.Join(err, rows.Close(), "close source rows")
+	}()
+	insert, err := tx.PrepareContext(ctx, "INSERT INTO "+QuoteIdentifier(table)+" ("+list+") VALUES ("+ //nolint:gosec // identifiers are quoted
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")+")")
+	if err != nil {
+		return fmt.Errorf("prepare insert: %w", err)
+	}
+	defer func() {
+		err = cleanup.Join(err, insert.Close(), "close insert")
+	}()
+
+	values := make([]any, len(columns))
+	targets := make([]any, len(columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(targets...); err != nil {
+			return fmt.Errorf("read rows: %w", err)
+		}
+		if _, err := insert.ExecContext(ctx, values...); err != nil {
+			return fmt.Errorf("copy row: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("read rows: %w", err)
+	}
+	return nil
+}
+
+// DumpTableToSQLite writes table into a new SQLite database file at filePath,
+// as one table of the same name.
+//
+// The database is built by running the table's SQL script against it — the one
+// --output-format sql would print, in SQLite's dialect — so the file and the
+// script cannot disagree about a column's type or a value's form: a table .dump
+// writes keeps its declared types and primary key in both, and a query result
+// gets the same inferred types in both.
+func DumpTableToSQLite(filePath string, table *model.Table) (err error) {
+	// SQLite's own message for a repeated column describes a CREATE TABLE the
+	// user never wrote, so the query that produced the columns is named instead.
+	if name, dup := duplicateColumnName(table); dup {
+		return fmt.Errorf("sqlite: duplicate column name %q; a table names each column once, so alias one of them (SELECT a AS a1, b AS a2)", name)
+	}
+	var script strings.Builder
+	if err := table.WriteSQLiteScript(&script); err != nil {
+		return err
+	}
+
+	db, err := sql.Open("sqlite", filepath.Clean(filePath))
+	if err != nil {
+		return fmt.Errorf("open sqlite database: %w", err)
+	}
+	defer func() {
+		err = cleanup.Join(err, db.Close(), "close sqlite database")
+	}()
+	// The script is run on one connection, so its BEGIN and COMMIT enclose the
+	// statements between them.
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(context.Background(), script.String()); err != nil {
+		return fmt.Errorf("write sqlite database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package filesql
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// writeSQLiteFile creates a database file at dir/name by running statements.
+func writeSQLiteFile(t *testing.T, dir, name string, statements ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	return path
+}
+
+// newSQLiteTestAdapter returns an adapter over a fresh in-memory database.
+func newSQLiteTestAdapter(t *testing.T) *testAdapter {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+	return newTestAdapter(db)
+}
+
+func TestIsSQLiteFile(t *testing.T) {
+	t.Parallel()
+	for path, want := range map[string]bool{
+		"app.db":        true,
+		"APP.SQLITE":    true,
+		"app.sqlite3":   true,
+		"app.db.gz":     false,
+		"app.csv":       false,
+		"dbs/app.dbase": false,
+	} {
+		if got := IsSQLiteFile(path); got != want {
+			t.Errorf("IsSQLiteFile(%q) = %v, want %v", path, got, want)
+		}
+		if want && !IsSupportedFile(path) {
+			t.Errorf("IsSupportedFile(%q) = false, want a SQLite database supported", path)
+		}
+	}
+}
+
+func TestFileSQLAdapter_LoadSQLiteFile(t *testing.T) {
+	t.Parallel()
+
+	schema := []string{
+		`CREATE TABLE users (id INTEGER NOT NULL, name VARCHAR(20) DEFAULT 'anon', score REAL, PRIMARY KEY (id))`,
+		`CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id), total NUMERIC, UNIQUE (user_id))`,
+		`CREATE INDEX orders_total ON orders (total)`,
+		`CREATE VIEW rich AS SELECT * FROM users WHERE score > 50`,
+		`INSERT INTO users VALUES (1, 'alice', 90.5), (2, NULL, 10)`,
+		`INSERT INTO orders VALUES (10, 1, 3.50), (11, 2, 7)`,
+	}
+
+	t.Run("every table is copied with its declared types and primary key", func(t *testing.T) {
+		t.Parallel()
+		path := writeSQLiteFile(t, t.TempDir(), "app.db", schema...)
+		adapter := newSQLiteTestAdapter(t)
+		if err := adapter.LoadFile(context.Background(), path); err != nil {
+			t.Fatalf("LoadFile: %v", err)
+		}
+
+		want := `CREATE TABLE "users" ("id" INTEGER NOT NULL, "name" VARCHAR(20) DEFAULT ('anon'), "score" REAL, PRIMARY KEY ("id"))`
+		if got := tableSQL(t, adapter); got != want {
+			t.Errorf("table = %s, want %s", got, want)
+		}
+		table, err := adapter.Query(context.Background(), "SELECT typeof(score), name IS NULL FROM users ORDER BY id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := table.Records(); got[0][0] != "real" || got[0][1] != "0" || got[1][1] != "1" {
+			t.Errorf("users = %v, want the stored values and NULL kept", got)
+		}
+		objects, err := adapter.Query(context.Background(), "SELECT group_concat(name, ',') FROM (SELECT name FROM sqlite_master ORDER BY name)")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := objects.Records()[0][0]; got != "orders,users" {
+			t.Errorf("objects = %s, want the two tables and no index or view", got)
+		}
+	})
+
+	t.Run("--sqlite-tables copies only the named tables", func(t *testing.T) {
+		t.Parallel()
+		path := writeSQLiteFile(t, t.TempDir(), "app.sqlite", schema...)
+		adapter := newSQLiteTestAdapter(t)
+		adapter.SetSQLiteTables([]string{"ORDERS"})
+		tables, err := adapter.SQLiteTables(path)
+		if err != nil || strings.Join(tables, ",") != "orders" {
+			t.Fatalf("SQLiteTables = %v, %v, want orders", tables, err)
+		}
+		if err := adapter.LoadFile(context.Background(), path); err != nil {
+			t.Fatalf("LoadFile: %v", err)
+		}
+		table, err := adapter.Query(context.Background(), "SELECT count(*) FROM sqlite_master WHERE name = 'users'")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := table.Records()[0][0]; got != "0" {
+			t.Errorf("users was copied too")
+		}
+	})
+
+	t.Run("a database with none of the named tables fails listing them", func(t *testing.T) {
+		t.Parallel()
+		path := writeSQLiteFile(t, t.TempDir(), "app.db", schema...)
+		adapter := newSQLiteTestAdapter(t)
+		adapter.SetSQLiteTables([]string{"missing"})
+		err := adapter.LoadFile(context.Background(), path)
+		if err == nil || !strings.Contains(err.Error(), "its tables are orders, users") {
+			t.Errorf("LoadFile error = %v, want the tables listed", err)
+		}
+	})
+
+	t.Run("a .db file that is not a database is refused", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "notes.db")
+		if err := os.WriteFile(path, []byte("not a database"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		err := newSQLiteTestAdapter(t).LoadFile(context.Background(), path)
+		if err == nil || !strings.Contains(err.Error(), "not a SQLite database") {
+			t.Errorf("LoadFile error = %v, want the file refused", err)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package filesql
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nao1215/sqly/domain/model"
+	_ "modernc.org/sqlite"
+)
+
+// loadWithTableKeys imports a CSV file as table users with the given primary
+// keys and indexes, and returns the adapter.
+func loadWithTableKeys(t *testing.T, content, primaryKeys, indexes string) (*testAdapter, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "users.csv")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	parse := func(spec string) model.TableKeys {
+		if spec == "" {
+			return nil
+		}
+		keys, err := model.ParseTableKeys(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return keys
+	}
+	adapter := newTestAdapter(db)
+	return adapter, adapter.LoadFiles(context.Background(), model.FileLoad{Path: path, PrimaryKeys: parse(primaryKeys), Indexes: parse(indexes)})
+}
+
+func TestFileSQLAdapter_TableKeys(t *testing.T) {
+	t.Parallel()
+
+	t.Run("a declared primary key is created in key order", func(t *testing.T) {
+		t.Parallel()
+		adapter, err := loadWithTableKeys(t, "id,day,name\n1,2,a\n1,3,b\n", "users(day,id)", "")
+		if err != nil {
+			t.Fatalf("LoadFile: %v", err)
+		}
+		want := `CREATE TABLE "users" ("id" INTEGER, "day" INTEGER, "name" TEXT, PRIMARY KEY ("day", "id"))`
+		if got := tableSQL(t, adapter); got != want {
+			t.Errorf("table = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("a repeated key fails the import", func(t *testing.T) {
+		t.Parallel()
+		_, err := loadWithTableKeys(t, "id,name\n1,a\n2,b\n1,c\n", "users(id)", "")
+		if err == nil || !strings.Contains(err.Error(), "--primary-key users(id)") || !strings.Contains(err.Error(), "row 1") {
+			t.Errorf("LoadFile error = %v, want the repeated key reported", err)
+		}
+	})
+
+	t.Run("an empty key fails the import", func(t *testing.T) {
+		t.Parallel()
+		_, err := loadWithTableKeys(t, "id,name\n1,a\n,b\n", "users(name,id)", "")
+		if err == nil || !strings.Contains(err.Error(), "data row 2 has no value") {
+			t.Errorf("LoadFile error = %v, want the empty key reported", err)
+		}
+	})
+
+	t.Run("a key column the table lacks fails the import", func(t *testing.T) {
+		t.Parallel()
+		_, err := loadWithTableKeys(t, "id,name\n1,a\n", "users(uid)", "")
+		if err == nil || !strings.Contains(err.Error(), "its columns are id, name") {
+			t.Errorf("LoadFile error = %v, want the columns listed", err)
+		}
+	})
+
+	t.Run("indexes are created on the table", func(t *testing.T) {
+		t.Parallel()
+		adapter, err := loadWithTableKeys(t, "id,name\n1,a\n", "users(id)", "users(name),users(name,id)")
+		if err != nil {
+			t.Fatalf("LoadFile: %v", err)
+		}
+		table, err := adapter.Query(context.Background(),
+			"SELECT group_concat(name, ' ') FROM (SELECT name FROM sqlite_master WHERE type = 'index' AND sql IS NOT NULL ORDER BY name)")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := table.Records()[0][0]; got != "idx_users_name idx_users_name_id" {
+			t.Errorf("indexes = %q, want both declared ones", got)
+		}
+	})
+
+	t.Run("an index on a column the table lacks fails the import", func(t *testing.T) {
+		t.Parallel()
+		// SQLite would take the quoted name for a string and index a constant.
+		_, err := loadWithTableKeys(t, "id,name\n1,a\n", "", "users(email)")
+		if err == nil || !strings.Contains(err.Error(), `names column "email"`) {
+			t.Errorf("LoadFile error = %v, want the column refused", err)
+		}
+	})
+}
@@ -0,0 +1,407 @@
+package filesql
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/nao1215/filesql"
+	"github.com/nao1215/filesql/parser"
+	"github.com/nao1215/sqly/domain/cleanup"
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// unionRecordReader is what StageUnion needs from a shard: its header, then its
+// rows, then io.EOF.
+type unionRecordReader interface {
+	Read() ([]string, error)
+}
+
+// StageUnion writes the rows of every shard to dest as one CSV file, whose
+// table is then loaded like any other.
+//
+// The shards are aligned by column name, not by position: a program that
+// writes a file a day is free to change the order of its columns between days,
+// and reading the second file positionally would put one column's values under
+// another's name without a word. The first shard's header decides the table's
+// columns and their order. A shard whose columns are not the same set is
+// refused, naming what it lacks and what it adds, because there is no way to
+// fill a column a file does not have that is not a guess.
+//
+// The row-mismatch policy applies to each shard as it would to the file on
+// its own, so what it dropped is returned to report once the import commits.
+// With sourceColumn set, every row also carries the shard it came from in
+// model.UnionSourceColumn.
+//
+// A shard of a partitioned import carries the key=value directories it was
+// found under. Each becomes a column after the file's own, holding its value
+// for every row of the shard; the shell has already made sure every shard names
+// the same keys in the same order.
+func (f *FileSQLAdapter) StageUnion(dest string, shards []model.UnionShard, sourceColumn bool) (skipped model.SkippedRows, err error) {
+	if len(shards) == 0 {
+		return model.SkippedRows{}, errors.New("a union needs at least one file")
+	}
+	out, err := os.OpenFile(filepath.Clean(dest), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return model.SkippedRows{}, fmt.Errorf("create union staging file: %w", err)
+	}
+	defer func() {
+		err = cleanup.Join(err, out.Close(), "close union staging file")
+	}()
+	w := bufio.NewWriter(out)
+
+	var header []string
+	for i, shard := range shards {
+		columns, err := f.appendUnionShard(w, shard, shards[0].Label, header, sourceColumn, &skipped)
+		if err != nil {
+			return model.SkippedRows{}, err
+		}
+		if i == 0 {
+			header = columns
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return model.SkippedRows{}, fmt.Errorf("write union staging file: %w", err)
+	}
+	return skipped, nil
+}
+
+// appendUnionShard writes one shard's rows, in the order of header, which the
+// shard named first set. The first shard has no header to follow yet: its own
+// is written, and returned to become the one every later shard is aligned to.
+func (f *FileSQLAdapter) appendUnionShard(w *bufio.Writer, shard model.UnionShard, first string, header []string, sourceColumn bool, skipped *model.SkippedRows) (columns []string, err error) {
+	reader, closeReader, err := f.openUnionShard(shard.Path, shard.Dialect)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", shard.Label, err)
+	}
+	defer func() {
+		err = cleanup.Join(err, closeReader(skipped), "close union shard")
+	}()
+
+	columns, err = reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: the file is empty; a union file needs at least a header", shard.Label)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", shard.Label, err)
+	}
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+	if err := checkUnionHeader(shard.Label, columns, sourceColumn); err != nil {
+		return nil, err
+	}
+	if err := checkPartitionColumns(shard, columns, sourceColumn); err != nil {
+		return nil, err
+	}
+
+	order := make([]int, len(columns))
+	if header == nil {
+		for i := range order {
+			order[i] = i
+		}
+		out := slices.Clone(columns)
+		for _, p := range shard.Partition {
+			out = append(out, p.Key)
+		}
+		if sourceColumn {
+			out = append(out, model.UnionSourceColumn)
+		}
+		writeCSVRecord(w, out)
+	} else {
+		if order, err = alignUnionHeader(header, columns); err != nil {
+			return nil, fmt.Errorf("%s does not have the columns of %s (%s): %w",
+				shard.Label, first, strings.Join(header, ", "), err)
+		}
+	}
+
+	width := len(columns)
+	row := make([]string, 0, width+len(shard.Partition)+1)
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", shard.Label, err)
+		}
+		if len(record) != width {
+			switch {
+			case f.rowMismatchPolicy == model.RowMismatchSkip:
+				skipped.Count++
+				skipped.Total++
+				continue
+			case f.rowMismatchPolicy == model.RowMismatchPad && len(record) < width:
+				record = append(record, make([]string, width-len(record))...)
+			case f.rowMismatchPolicy == model.RowMismatchPad:
+				return nil, fmt.Errorf("%s: --row-mismatch pad refuses to truncate a long row: data row %d has %d fields, want %d: %w",
+					shard.Label, n, len(record), width, filesql.ErrColumnMismatch)
+			default:
+				return nil, fmt.Errorf("%s: data row %d has %d fields, want %d: %w",
+					shard.Label, n, len(record), width, filesql.ErrColumnMismatch)
+			}
+		}
+		skipped.Total++
+		row = row[:0]
+		for _, i := range order {
+			row = append(row, record[i])
+		}
+		for _, p := range shard.Partition {
+			row = append(row, p.Value)
+		}
+		if sourceColumn {
+			row = append(row, shard.Label)
+		}
+		writeCSVRecord(w, row)
+	}
+	return columns, nil
+}
+
+// checkUnionHeader refuses a header a union cannot align: one naming a column
+// twice, which leaves "the column called x" meaning two things, or one that
+// already has the column the union was asked to add.
+func checkUnionHeader(label string, columns []string, sourceColumn bool) error {
+	for i, column := range columns {
+		if slices.ContainsFunc(columns[:i], func(c string) bool { return strings.EqualFold(c, column) }) {
+			return fmt.Errorf("%s names column %q twice, so its columns cannot be matched by name", label, column)
+		}
+		if sourceColumn && strings.EqualFold(column, model.UnionSourceColumn) {
+			return fmt.Errorf("%s already has a column %q, the one --source-file-column adds", label, column)
+		}
+	}
+	return nil
+}
+
+// checkPartitionColumns refuses a shard whose file already has a column one of
+// its partition directories names. Hive writers drop the partition columns from
+// the files they write, so a file that keeps one holds a second copy of the
+// value, and which of the two the table's column should be is not a question
+// with an answer that is not a guess.
+func checkPartitionColumns(shard model.UnionShard, columns []string, sourceColumn bool) error {
+	for _, p := range shard.Partition {
+		if slices.ContainsFunc(columns, func(c string) bool { return strings.EqualFold(c, p.Key) }) {
+			return fmt.Errorf("%s has a column %q, which its directory %s also sets; a partitioned file leaves its partition columns to the path",
+				shard.Label, p.Key, p.Segment())
+		}
+		if sourceColumn && strings.EqualFold(p.Key, model.UnionSourceColumn) {
+			return fmt.Errorf("%s is under a directory %s, whose column is the one --source-file-column adds", shard.Label, p.Segment())
+		}
+	}
+	return nil
+}
+
+// alignUnionHeader returns, for each column of header, where a shard with the
+// given columns holds it. SQLite matches column names in any ASCII case, so
+// the alignment does too.
+func alignUnionHeader(header, columns []string) ([]int, error) {
+	order := make([]int, 0, len(header))
+	var missing []string
+	for _, want := range header {
+		i := slices.IndexFunc(columns, func(c string) bool { return strings.EqualFold(c, want) })
+		if i < 0 {
+			missing = append(missing, want)
+			continue
+		}
+		order = append(order, i)
+	}
+	var extra []string
+	for _, column := range columns {
+		if !slices.ContainsFunc(header, func(h string) bool { return strings.EqualFold(h, column) }) {
+			extra = append(extra, column)
+		}
+	}
+	switch {
+	case len(missing) > 0 && len(extra) > 0:
+		return nil, fmt.Errorf("it lacks %s and adds %s", strings.Join(missing, ", "), strings.Join(extra, ", "))
+	case len(missing) > 0:
+		return nil, fmt.Errorf("it lacks %s", strings.Join(missing, ", "))
+	case len(extra) > 0:
+		return nil, fmt.Errorf("it adds %s", strings.Join(extra, ", "))
+	default:
+		return order, nil
+	}
+}
+
+// openUnionShard opens a shard for reading. A CSV or TSV shard, compressed or
+// not, is streamed with the reader filesql itself uses for the format, so it
+// reads the same here as it would imported on its own; one with a dialect is
+// streamed with the reader an import of it applies that dialect with. Any other
+// format is loaded the way an import of it alone would be (see openTableShard).
+//
+// The close function adds what the row-mismatch policy dropped while loading
+// to skipped, for a shard filesql applied the policy to itself.
+func (f *FileSQLAdapter) openUnionShard(path string, dialect model.CSVDialect) (unionRecordReader, func(*model.SkippedRows) error, error) {
+	if IsSQLiteFile(path) {
+		return nil, nil, errors.New("a SQLite database holds tables rather than rows, so it cannot be a shard; import it on its own")
+	}
+	isTSV, delimited := delimitedKind(path)
+	if !delimited {
+		return f.openTableShard(path)
+	}
+
+	reader, closeReader, err := filesql.NewCompressionFactory().CreateReaderForFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open file: %w", err)
+	}
+	closeShard := func(*model.SkippedRows) error { return closeReader() }
+	input := parser.NormalizeLineEndings(skipUTF8BOM(reader))
+	if !dialect.IsZero() {
+		records, err := newDialectReader(input, dialect, isTSV)
+		if err != nil {
+			return nil, nil, cleanup.Join(err, closeReader(), "close file")
+		}
+		return records, closeShard, nil
+	}
+	if isTSV {
+		return parser.NewTSVReader(input), closeShard, nil
+	}
+	csvReader := parser.NewCSVReader(input)
+	// The field count is the union's to judge, under the row-mismatch policy,
+	// so the reader is told to take any.
+	csvReader.FieldsPerRecord = -1
+	return csvReader, closeShard, nil
+}
+
+// openTableShard loads a shard of a format with no record stream to read —
+// Parquet, JSON, LTSV, a workbook — into a database of its own, and reads its
+// one table back as rows of text. Going through filesql keeps what the file
+// means the same as when it is imported on its own: a JSON shard is a data
+// column there too, and an XML shard's rows are the ones --xml-record picks.
+//
+// A shard that makes more than one table, such as a workbook with two sheets,
+// is refused: which of its tables belongs in the union is not something the
+// file says.
+func (f *FileSQLAdapter) openTableShard(path string) (_ unionRecordReader, _ func(*model.SkippedRows) error, err error) {
+	var releases []func() error
+	release := func() error {
+		var err error
+		for i := len(releases) - 1; i >= 0; i-- {
+			err = errors.Join(err, releases[i]())
+		}
+		return err
+	}
+	defer func() {
+		if err != nil {
+			err = cleanup.Join(err, release(), "release union shard")
+		}
+	}()
+
+	loadPath := path
+	if IsXMLFile(path) {
+		// A union's shards are read back as text, which has no NULL, so the
+		// absent values stay the empty strings the staged file holds.
+		staged, _, releaseXML, err := stageXMLAsCSV(path, f.xmlRecordPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		releases = append(releases, releaseXML)
+		loadPath = staged
+	}
+	// The shard is read once, start to finish, as the union is staged; there
+	// is no caller to cancel it but the import as a whole.
+	ctx := context.Background()
+	builder, err := filesql.NewBuilder().
+		AddPath(loadPath).
+		WithMalformedRowPolicy(filesqlRowMismatchPolicy(f.rowMismatchPolicy)).
+		WithExcelSheetPolicy(filesqlExcelSheetPolicy(f.includeHiddenSheets)).
+		Build(ctx)
+	if err != nil {
+		return nil, nil, unnamedCause(err)
+	}
+	db, err := builder.Open(ctx)
+	if err != nil {
+		return nil, nil, unnamedCause(err)
+	}
+	releases = append(releases, db.Close)
+
+	tables, err := userTables(ctx, db)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tables) != 1 {
+		return nil, nil, fmt.Errorf("the file makes %d tables (%s); a union reads files that make one", len(tables), strings.Join(tables, ", "))
+	}
+	rows, err := db.QueryContext(ctx, "SELECT * FROM "+QuoteIdentifier(tables[0])) //nolint:gosec // the name is quoted
+	if err != nil {
+		return nil, nil, fmt.Errorf("read table %s: %w", tables[0], err)
+	}
+	releases = append(releases, rows.Close)
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, fmt.Errorf("read table %s: %w", tables[0], err)
+	}
+
+	closeShard := func(skipped *model.SkippedRows) error {
+		for _, s := range builder.SkippedRows() {
+			skipped.Count += s.Count
+			skipped.Total += s.Count
+		}
+		return release()
+	}
+	return &tableShardReader{rows: rows, header: columns}, closeShard, nil
+}
+
+// userTables lists the tables of a database, leaving out SQLite's own.
+func userTables(ctx context.Context, db *sql.DB) (_ []string, err error) {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite\\_%' ESCAPE '\\' ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	defer func() {
+		err = cleanup.Join(err, rows.Close(), "close table list")
+	}()
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("list tables: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// tableShardReader reads a loaded shard as a CSV reader would: the header
+// first, then each row with every value as the text sqly displays for it. A
+// NULL is an empty field, which is what the staged CSV can say.
+type tableShardReader struct {
+	rows       *sql.Rows
+	header     []string
+	headerRead bool
+}
+
+// Read implements unionRecordReader.
+func (r *tableShardReader) Read() ([]string, error) {
+	if !r.headerRead {
+		r.headerRead = true
+		return slices.Clone(r.header), nil
+	}
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	values := make([]any, len(r.header))
+	targets := make([]any, len(values))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	if err := r.rows.Scan(targets...); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(values))
+	for i, v := range values {
+		if v != nil {
+			record[i] = model.NewCell(v).String()
+		}
+	}
+	return record, nil
+}
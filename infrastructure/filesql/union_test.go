@@ -0,0 +1,172 @@
+package filesql
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nao1215/filesql"
+	"github.com/nao1215/sqly/domain/model"
+)
+
+func writeUnionShard(t *testing.T, dir, name, content string) model.UnionShard {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return model.UnionShard{Path: path, Label: name}
+}
+
+func stageUnion(t *testing.T, adapter *FileSQLAdapter, sourceColumn bool, shards ...model.UnionShard) (string, model.SkippedRows, error) {
+	t.Helper()
+	dest := filepath.Join(t.TempDir(), "events.csv")
+	skipped, err := adapter.StageUnion(dest, shards, sourceColumn)
+	if err != nil {
+		return "", skipped, err
+	}
+	content, readErr := os.ReadFile(dest) //nolint:gosec // test reads the file it staged
+	if readErr != nil {
+		t.Fatalf("read staged union: %v", readErr)
+	}
+	return string(content), skipped, nil
+}
+
+func TestFileSQLAdapter_StageUnion(t *testing.T) {
+	t.Parallel()
+
+	t.Run("columns are aligned by name", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		got, _, err := stageUnion(t, NewFileSQLAdapter(nil), false,
+			writeUnionShard(t, dir, "a.csv", "id,name\n1,alice\n"),
+			writeUnionShard(t, dir, "b.tsv", "NAME\tid\nbob\t2\n"),
+		)
+		if err != nil {
+			t.Fatalf("StageUnion: %v", err)
+		}
+		if want := "\"id\",\"name\"\n\"1\",\"alice\"\n\"2\",\"bob\"\n"; got != want {
+			t.Errorf("staged = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("the source column names each row's shard", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		got, _, err := stageUnion(t, NewFileSQLAdapter(nil), true,
+			writeUnionShard(t, dir, "a.csv", "\ufeffid\n1\n"),
+			writeUnionShard(t, dir, "b.csv", "id\n2\n"),
+		)
+		if err != nil {
+			t.Fatalf("StageUnion: %v", err)
+		}
+		if want := "\"id\",\"_source_file\"\n\"1\",\"a.csv\"\n\"2\",\"b.csv\"\n"; got != want {
+			t.Errorf("staged = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("a shard with other columns is refused", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		_, _, err := stageUnion(t, NewFileSQLAdapter(nil), false,
+			writeUnionShard(t, dir, "a.csv", "id,name\n1,alice\n"),
+			writeUnionShard(t, dir, "b.csv", "id,email\n2,b@example.com\n"),
+		)
+		if err == nil {
+			t.Fatal("StageUnion succeeded, want the shard refused")
+		}
+		for _, want := range []string{"b.csv", "a.csv", "lacks name", "adds email"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not mention %q", err, want)
+			}
+		}
+	})
+
+	t.Run("a short row follows the row-mismatch policy", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		shards := []model.UnionShard{
+			writeUnionShard(t, dir, "a.csv", "id,name\n1,alice\n"),
+			writeUnionShard(t, dir, "b.csv", "id,name\n2\n3,carol\n"),
+		}
+
+		_, _, err := stageUnion(t, NewFileSQLAdapter(nil), false, shards...)
+		if !errors.Is(err, filesql.ErrColumnMismatch) {
+			t.Errorf("StageUnion error = %v, want ErrColumnMismatch", err)
+		}
+
+		skipping := NewFileSQLAdapter(nil)
+		skipping.SetRowMismatchPolicy(model.RowMismatchSkip)
+		got, skipped, err := stageUnion(t, skipping, false, shards...)
+		if err != nil {
+			t.Fatalf("StageUnion with skip: %v", err)
+		}
+		if want := "\"id\",\"name\"\n\"1\",\"alice\"\n\"3\",\"carol\"\n"; got != want {
+			t.Errorf("staged = %q, want %q", got, want)
+		}
+		if skipped.Count != 1 || skipped.Total != 3 {
+			t.Errorf("skipped = %+v, want 1 of 3", skipped)
+		}
+
+		padding := NewFileSQLAdapter(nil)
+		padding.SetRowMismatchPolicy(model.RowMismatchPad)
+		got, _, err = stageUnion(t, padding, false, shards...)
+		if err != nil {
+			t.Fatalf("StageUnion with pad: %v", err)
+		}
+		if !strings.Contains(got, "\"2\",\"\"\n") {
+			t.Errorf("staged = %q, want the short row padded", got)
+		}
+	})
+
+	t.Run("a shard of another format is read as it would be imported", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		parquet := filepath.Join(dir, "b.parquet")
+		if err := DumpTableToParquet(parquet, model.NewTable("b", model.Header{"name", "id"}, []model.Record{{"bob", "2"}})); err != nil {
+			t.Fatalf("write parquet shard: %v", err)
+		}
+		got, _, err := stageUnion(t, NewFileSQLAdapter(nil), false,
+			writeUnionShard(t, dir, "a.csv", "id,name\n1,alice\n"),
+			model.UnionShard{Path: parquet, Label: "b.parquet"},
+		)
+		if err != nil {
+			t.Fatalf("StageUnion: %v", err)
+		}
+		if want := "\"id\",\"name\"\n\"1\",\"alice\"\n\"2\",\"bob\"\n"; got != want {
+			t.Errorf("staged = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("partition values become columns after the file's own", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		eu := writeUnionShard(t, dir, "a.csv", "amount\n1\n")
+		eu.Partition = []model.PartitionValue{{Key: "year", Value: "2024"}, {Key: "region", Value: "eu"}}
+		us := writeUnionShard(t, dir, "b.csv", "amount\n2\n")
+		us.Partition = []model.PartitionValue{{Key: "year", Value: "2023"}, {Key: "region", Value: ""}}
+		got, _, err := stageUnion(t, NewFileSQLAdapter(nil), true, eu, us)
+		if err != nil {
+			t.Fatalf("StageUnion: %v", err)
+		}
+		want := "\"amount\",\"year\",\"region\",\"_source_file\"\n" +
+			"\"1\",\"2024\",\"eu\",\"a.csv\"\n" +
+			"\"2\",\"2023\",\"\",\"b.csv\"\n"
+		if got != want {
+			t.Errorf("staged = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("a file holding its partition column is refused", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		shard := writeUnionShard(t, dir, "a.csv", "year,amount\n2024,1\n")
+		shard.Partition = []model.PartitionValue{{Key: "year", Value: "2024"}}
+		_, _, err := stageUnion(t, NewFileSQLAdapter(nil), false, shard)
+		if err == nil || !strings.Contains(err.Error(), "year=2024") {
+			t.Errorf("StageUnion error = %v, want the duplicate column refused", err)
+		}
+	})
+}
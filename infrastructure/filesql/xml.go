@@ -0,0 +1,543 @@
+package filesql
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/nao1215/filesql"
+	"github.com/nao1215/sqly/domain/cleanup"
+	"github.com/nao1215/sqly/domain/model"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// filesql has no XML reader, so sqly turns an XML file into the CSV filesql
+// would have been handed if the feed had been exported that way, and loads that.
+// Going through CSV rather than building the table here keeps one loader: type
+// inference, the transaction, the source record, and every rule about column
+// names are filesql's, exactly as for a file that was a CSV to begin with.
+//
+// An XML file is a tree and a table is not, so the conversion needs to be told
+// which elements are the rows. --xml-record names them by their path from the
+// root (/feed/item); without it, the children of the root element are the rows,
+// which is the shape of nearly every feed, and a root whose children are not all
+// one kind of element is refused rather than guessed at.
+//
+// Within a row element:
+//
+//   - each attribute is a column, named after the attribute;
+//   - each child element holding only text is a column, named after the child;
+//   - the row element's own text, when it has any, is a column named after it.
+//
+// A child that is itself a tree — one with attributes or children of its own, or
+// one that repeats within a row — is kept whole, as JSON text in its column, for
+// SQLite's JSON functions to take apart. A column is one kind or the other for
+// every row: a child that repeats in one row is an array in all of them, so a
+// query does not have to ask which shape each row happened to have.
+//
+// A row that lacks a column holds NULL there, in a column of any kind, which is
+// also how the XML writer says a value is absent; an element that is present
+// and empty holds an empty string. CSV has no NULL, so the staged file holds an
+// empty field for the absent value too, and the rows that lacked each column
+// are recorded as the file is written, to be set to NULL once it is loaded.
+// Element and attribute names are compared by local name; namespace prefixes are
+// not part of a column name.
+
+// stageXMLAsCSV converts the XML file at path into a CSV file named after it in
+// a temporary directory, and returns that file's path, the cells whose records
+// had no value for them, and the cleanup that removes the file. The file is read
+// twice: once to learn the columns, because the header comes first, and once to
+// write the rows.
+func stageXMLAsCSV(path, recordPath string) (staged string, absent xmlAbsentCells, release func() error, err error) {
+	selector, err := newXMLRecordSelector(recordPath)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	layout := newXMLLayout()
+	if err := readXMLRecords(path, selector, layout.observe); err != nil {
+		return "", nil, nil, err
+	}
+	if err := selector.check(); err != nil {
+		return "", nil, nil, err
+	}
+	if layout.records == 0 {
+		return "", nil, nil, fmt.Errorf("no record element at %s; name the elements that are rows with --xml-record", selector.describe())
+	}
+	if len(layout.columns) == 0 {
+		return "", nil, nil, fmt.Errorf("the record elements at %s have no attributes, child elements, or text to make columns from", selector.describe())
+	}
+
+	dir, err := os.MkdirTemp("", "sqly-xml-")
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("create temp dir for xml staging: %w", err)
+	}
+	release = func() error { return os.RemoveAll(dir) }
+	defer func() {
+		if err != nil {
+			err = cleanup.Join(err, release(), "remove xml staging directory")
+		}
+	}()
+
+	// The staged file is named after the source, so the table filesql names
+	// after it is the one the source would have given.
+	staged = filepath.Join(dir, GetTableNameFromFilePath(path)+".csv")
+	absent, err = writeXMLAsCSV(path, staged, selector, layout)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return staged, absent, release, nil
+}
+
+// writeXMLAsCSV writes the header and one row per record element to staged, and
+// returns the cells it wrote empty because the record had no value for them.
+func writeXMLAsCSV(path, staged string, selector *xmlRecordSelector, layout *xmlLayout) (absent xmlAbsentCells, err error) {
+	file, err := os.Create(staged) //nolint:gosec // staged is under a sqly-created temp dir
+	if err != nil {
+		return nil, fmt.Errorf("create xml staging file: %w", err)
+	}
+	defer func() {
+		err = cleanup.Join(err, file.Close(), "close xml staging file")
+	}()
+
+	out := bufio.NewWriter(file)
+	header := make([]string, len(layout.columns))
+	for i, col := range layout.columns {
+		header[i] = layout.columnName(col)
+	}
+	writeCSVRecord(out, header)
+
+	selector.reset()
+	absent = make(xmlAbsentCells)
+	row := make([]string, len(layout.columns))
+	present := make([]bool, len(layout.columns))
+	rowid := int64(0)
+	err = readXMLRecords(path, selector, func(record *xmlNode) error {
+		rowid++
+		clear(row)
+		clear(present)
+		for key, value := range layout.values(record) {
+			row[layout.index[key]] = value
+			present[layout.index[key]] = true
+		}
+		for i, ok := range present {
+			if !ok {
+				absent[header[i]] = append(absent[header[i]], rowid)
+			}
+		}
+		writeCSVRecord(out, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := out.Flush(); err != nil {
+		return nil, fmt.Errorf("write xml staging file: %w", err)
+	}
+	return absent, nil
+}
+
+// xmlAbsentCells is, for each column of a staged XML table, the rowids of the
+// rows whose record had no value for it. filesql numbers the rows of the table
+// it creates from 1, in the order of the file, which is the order they were
+// written.
+type xmlAbsentCells map[string][]int64
+
+// xmlAbsentBatch is how many rowids one UPDATE names, well under SQLite's limit
+// on bound parameters.
+const xmlAbsentBatch = 500
+
+// apply sets the absent cells of the loaded table to NULL.
+func (a xmlAbsentCells) apply(ctx context.Context, tx *sql.Tx, table string) error {
+	for column, rowids := range a {
+		for batch := range slices.Chunk(rowids, xmlAbsentBatch) {
+			args := make([]any, len(batch))
+			for i, rowid := range batch {
+				args[i] = rowid
+			}
+			statement := fmt.Sprintf("UPDATE %s SET %s = NULL WHERE rowid IN (%s)", //nolint:gosec // both names are quoted
+				QuoteIdentifier(table), QuoteIdentifier(column), strings.TrimSuffix(strings.Repeat("?,", len(batch)), ","))
+			if _, err := tx.ExecContext(ctx, statement, args...); err != nil {
+				return fmt.Errorf("set the absent values of column %q to NULL: %w", column, err)
+			}
+		}
+	}
+	return nil
+}
+
+// writeCSVRecord writes one CSV line with every field quoted. encoding/csv
+// leaves an empty field bare, and a row holding one empty field would then be a
+// blank line, which a CSV reader skips: a one-column feed would lose every row
+// whose value was empty.
+func writeCSVRecord(w *bufio.Writer, fields []string) {
+	for i, field := range fields {
+		if i > 0 {
+			_ = w.WriteByte(',') // #nosec G104 -- bufio reports the error at Flush
+		}
+		_ = w.WriteByte('"')                                       // #nosec G104
+		_, _ = w.WriteString(strings.ReplaceAll(field, `"`, `""`)) // #nosec G104
+		_ = w.WriteByte('"')                                       // #nosec G104
+	}
+	_ = w.WriteByte('\n') // #nosec G104
+}
+
+// xmlRecordSelector decides which elements are records.
+type xmlRecordSelector struct {
+	// path is the element names from the root to a record, or nil to take the
+	// children of the root, whatever they are called, as long as they agree.
+	path []string
+	// seen is the names of the root's children, in order, when path is nil.
+	seen []string
+	// root is the name of the document element, for messages.
+	root string
+}
+
+func newXMLRecordSelector(recordPath string) (*xmlRecordSelector, error) {
+	if recordPath == "" {
+		return &xmlRecordSelector{}, nil
+	}
+	path, err := model.ParseXMLRecordPath(recordPath)
+	if err != nil {
+		return nil, err
+	}
+	return &xmlRecordSelector{path: path}, nil
+}
+
+// matches reports whether the element whose path from the root is stack is a
+// record.
+func (sel *xmlRecordSelector) matches(stack []string) bool {
+	if len(stack) == 1 {
+		sel.root = stack[0]
+	}
+	if sel.path != nil {
+		return slices.Equal(stack, sel.path)
+	}
+	if len(stack) != 2 {
+		return false
+	}
+	if !slices.Contains(sel.seen, stack[1]) {
+		sel.seen = append(sel.seen, stack[1])
+	}
+	return true
+}
+
+// check refuses a default selection that took elements of more than one kind.
+// A feed with a title beside its items would otherwise become a table with one
+// row that is not an item.
+func (sel *xmlRecordSelector) check() error {
+	if sel.path != nil || len(sel.seen) < 2 {
+		return nil
+	}
+	names := make([]string, len(sel.seen))
+	for i, name := range sel.seen {
+		names[i] = "<" + name + ">"
+	}
+	return fmt.Errorf("the root element <%s> holds %s elements, so which of them are rows is not clear; choose with --xml-record, such as --xml-record /%s/%s",
+		sel.root, strings.Join(names, ", "), sel.root, sel.seen[len(sel.seen)-1])
+}
+
+// describe names the record elements for a message.
+func (sel *xmlRecordSelector) describe() string {
+	if sel.path != nil {
+		return "/" + strings.Join(sel.path, "/")
+	}
+	if sel.root == "" {
+		return "the children of the root element"
+	}
+	return "/" + sel.root + "/*"
+}
+
+// reset prepares the selector for the second read.
+func (sel *xmlRecordSelector) reset() {
+	sel.seen = nil
+}
+
+// xmlNode is one element of a record, read whole.
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     strings.Builder
+}
+
+// readXMLRecords calls visit with each record element of the file at path, in
+// document order. It opens the file through filesql's compression handling, so a
+// .xml.gz reads like any other compressed input.
+func readXMLRecords(path string, selector *xmlRecordSelector, visit func(*xmlNode) error) (err error) {
+	reader, closeReader, err := filesql.NewCompressionFactory().CreateReaderForFile(path)
+	if err != nil {
+		return fmt.Errorf("open xml reader: %w", err)
+	}
+	defer func() {
+		err = cleanup.Join(err, closeReader(), "close xml reader")
+	}()
+
+	decoder := xml.NewDecoder(skipUTF8BOM(reader))
+	// encoding/xml reads UTF-8 only and hands any other declared encoding here.
+	// Feeds declare ISO-8859-1 and Windows-1252 often enough that refusing them
+	// would refuse a good share of the files this format exists for.
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		enc, err := htmlindex.Get(label)
+		if err != nil {
+			return nil, fmt.Errorf("the file declares encoding %q, which sqly cannot decode", label)
+		}
+		return enc.NewDecoder().Reader(input), nil
+	}
+
+	var stack []string
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			if len(stack) > 0 {
+				return fmt.Errorf("malformed xml: the file ends inside <%s>", stack[len(stack)-1])
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("malformed xml: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			if !selector.matches(stack) {
+				continue
+			}
+			record, err := readXMLElement(decoder, t)
+			if err != nil {
+				return fmt.Errorf("malformed xml: %w", err)
+			}
+			stack = stack[:len(stack)-1]
+			if err := visit(record); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// readXMLElement reads the element start opens, through its end.
+func readXMLElement(decoder *xml.Decoder, start xml.StartElement) (*xmlNode, error) {
+	node := &xmlNode{name: start.Name.Local}
+	for _, attr := range start.Attr {
+		// A namespace declaration is markup about names, not a value.
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		node.attrs = append(node.attrs, attr)
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("the file ends inside <%s>", node.name)
+			}
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := readXMLElement(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			node.children = append(node.children, child)
+		case xml.CharData:
+			node.text.Write(t)
+		case xml.EndElement:
+			return node, nil
+		}
+	}
+}
+
+// skipUTF8BOM drops a leading UTF-8 byte order mark, which encoding/xml reports
+// as text before the root element.
+func skipUTF8BOM(r io.Reader) io.Reader {
+	buffered := bufio.NewReader(r)
+	if head, err := buffered.Peek(3); err == nil && bytes.Equal(head, []byte{0xEF, 0xBB, 0xBF}) {
+		_, _ = buffered.Discard(3) // #nosec G104 -- the three bytes were just peeked
+	}
+	return buffered
+}
+
+// xmlColumnKind is how a column's values are written.
+type xmlColumnKind int
+
+const (
+	// xmlColumnText holds an attribute or a text-only child as it is.
+	xmlColumnText xmlColumnKind = iota
+	// xmlColumnJSON holds a child with a structure of its own, as JSON.
+	xmlColumnJSON
+	// xmlColumnJSONArray holds a child that repeats in some row, as a JSON array
+	// in every row.
+	xmlColumnJSONArray
+)
+
+// xmlColumnKey identifies a column before it is named: an attribute and a child
+// element may share a name, and are still two columns.
+type xmlColumnKey struct {
+	name      string
+	attribute bool
+}
+
+// xmlColumn is one column of the staged table.
+type xmlColumn struct {
+	key  xmlColumnKey
+	kind xmlColumnKind
+}
+
+// xmlLayout is the columns the records need, learned on the first read.
+type xmlLayout struct {
+	columns []*xmlColumn
+	index   map[xmlColumnKey]int
+	records int
+}
+
+func newXMLLayout() *xmlLayout {
+	return &xmlLayout{index: make(map[xmlColumnKey]int)}
+}
+
+// observe adds the columns one record needs, in the order they first appear.
+func (l *xmlLayout) observe(record *xmlNode) error {
+	l.records++
+	for _, attr := range record.attrs {
+		l.column(xmlColumnKey{name: attr.Name.Local, attribute: true})
+	}
+	for _, group := range groupXMLChildren(record) {
+		col := l.column(xmlColumnKey{name: group.name})
+		switch {
+		case len(group.nodes) > 1:
+			col.kind = xmlColumnJSONArray
+		case col.kind == xmlColumnText && !group.nodes[0].isLeaf():
+			col.kind = xmlColumnJSON
+		}
+	}
+	if strings.TrimSpace(record.text.String()) != "" && len(record.children) == 0 {
+		l.column(xmlColumnKey{name: record.name})
+	}
+	return nil
+}
+
+func (l *xmlLayout) column(key xmlColumnKey) *xmlColumn {
+	if i, ok := l.index[key]; ok {
+		return l.columns[i]
+	}
+	l.index[key] = len(l.columns)
+	col := &xmlColumn{key: key}
+	l.columns = append(l.columns, col)
+	return col
+}
+
+// columnName is the header a column is written under. An attribute that shares
+// its name with a child element is written as @name, the way XPath spells an
+// attribute, so neither one loses its name to the other.
+func (l *xmlLayout) columnName(col *xmlColumn) string {
+	if col.key.attribute {
+		if _, clash := l.index[xmlColumnKey{name: col.key.name}]; clash {
+			return "@" + col.key.name
+		}
+	}
+	return col.key.name
+}
+
+// values returns one record's value for each column it has.
+func (l *xmlLayout) values(record *xmlNode) map[xmlColumnKey]string {
+	values := make(map[xmlColumnKey]string, len(l.columns))
+	for _, attr := range record.attrs {
+		values[xmlColumnKey{name: attr.Name.Local, attribute: true}] = attr.Value
+	}
+	for _, group := range groupXMLChildren(record) {
+		key := xmlColumnKey{name: group.name}
+		switch l.columns[l.index[key]].kind {
+		case xmlColumnText:
+			values[key] = group.nodes[0].text.String()
+		case xmlColumnJSON:
+			values[key] = marshalXMLValue(group.nodes[0].jsonValue())
+		case xmlColumnJSONArray:
+			values[key] = marshalXMLValue(group.jsonValues())
+		}
+	}
+	if text := strings.TrimSpace(record.text.String()); text != "" && len(record.children) == 0 {
+		values[xmlColumnKey{name: record.name}] = text
+	}
+	return values
+}
+
+// xmlChildGroup is the children of one element that share a name.
+type xmlChildGroup struct {
+	name  string
+	nodes []*xmlNode
+}
+
+// jsonValues is each child of the group as JSON, in document order.
+func (g xmlChildGroup) jsonValues() []any {
+	items := make([]any, len(g.nodes))
+	for i, node := range g.nodes {
+		items[i] = node.jsonValue()
+	}
+	return items
+}
+
+// groupXMLChildren groups an element's children by name, in the order each name
+// first appears, which is the order their columns are created in.
+func groupXMLChildren(n *xmlNode) []xmlChildGroup {
+	var groups []xmlChildGroup
+	for _, child := range n.children {
+		i := slices.IndexFunc(groups, func(g xmlChildGroup) bool { return g.name == child.name })
+		if i < 0 {
+			groups = append(groups, xmlChildGroup{name: child.name})
+			i = len(groups) - 1
+		}
+		groups[i].nodes = append(groups[i].nodes, child)
+	}
+	return groups
+}
+
+// isLeaf reports whether an element is text and nothing else.
+func (n *xmlNode) isLeaf() bool {
+	return len(n.attrs) == 0 && len(n.children) == 0
+}
+
+// jsonValue is the element as JSON: its text when it is only text, and
+// otherwise an object with "@name" for each attribute, a key for each child
+// (an array when the child repeats), and "#text" for its own text.
+func (n *xmlNode) jsonValue() any {
+	if n.isLeaf() {
+		return n.text.String()
+	}
+	object := make(map[string]any, len(n.attrs)+len(n.children)+1)
+	for _, attr := range n.attrs {
+		object["@"+attr.Name.Local] = attr.Value
+	}
+	for _, group := range groupXMLChildren(n) {
+		if len(group.nodes) == 1 {
+			object[group.name] = group.nodes[0].jsonValue()
+			continue
+		}
+		object[group.name] = group.jsonValues()
+	}
+	if text := strings.TrimSpace(n.text.String()); text != "" {
+		object["#text"] = text
+	}
+	return object
+}
+
+// marshalXMLValue encodes a value built from strings, slices, and maps, which
+// cannot fail. Keys are written sorted, so the same element is the same text on
+// every import.
+func marshalXMLValue(v any) string {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
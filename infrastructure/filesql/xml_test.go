@@ -0,0 +1,248 @@
+package filesql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nao1215/sqly/domain/model"
+	_ "modernc.org/sqlite"
+)
+
+// loadXML writes content to a file called name, imports it with the given
+// record path, and returns the result of query as CSV.
+func loadXML(t *testing.T, name string, content []byte, recordPath, query string) (string, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	adapter := newTestAdapter(db)
+	adapter.SetXMLRecordPath(recordPath)
+	ctx := context.Background()
+	if err := adapter.LoadFile(ctx, path); err != nil {
+		return "", err
+	}
+	table, err := adapter.Query(ctx, query)
+	if err != nil {
+		t.Fatalf("Query(%s): %v", query, err)
+	}
+	var out bytes.Buffer
+	if err := table.Print(&out, model.PrintModeCSV); err != nil {
+		t.Fatal(err)
+	}
+	return out.String(), nil
+}
+
+const xmlFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://example.com/ns">
+  <title>Vendor feed</title>
+  <items>
+    <item id="1" lang="en">
+      <name>First</name>
+      <price currency="USD">10.5</price>
+      <tag>a</tag><tag>b</tag>
+    </item>
+    <item id="2">
+      <name>Second &amp; more</name>
+      <tag>c</tag>
+    </item>
+  </items>
+</feed>
+`
+
+func TestLoadXML_RecordPath(t *testing.T) {
+	t.Parallel()
+
+	got, err := loadXML(t, "feed.xml", []byte(xmlFeed), "/feed/items/item",
+		`SELECT id, typeof(id) AS id_type, lang, name, price, tag FROM feed ORDER BY id`)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	want := "id,id_type,lang,name,price,tag\n" +
+		`1,integer,en,First,"{""#text"":""10.5"",""@currency"":""USD""}","[""a"",""b""]"` + "\n" +
+		`2,integer,,Second & more,,"[""c""]"` + "\n"
+	if got != want {
+		t.Errorf("table =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestLoadXML_JSONColumnsAreQueryable checks a JSON function answers NULL for a
+// row without the element, instead of failing the whole query.
+func TestLoadXML_JSONColumnsAreQueryable(t *testing.T) {
+	t.Parallel()
+
+	got, err := loadXML(t, "feed.xml", []byte(xmlFeed), "/feed/items/item",
+		`SELECT id, json_extract(price, '$."@currency"') AS currency, json_array_length(tag) AS tags FROM feed ORDER BY id`)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if want := "id,currency,tags\n1,USD,2\n2,,1\n"; got != want {
+		t.Errorf("table =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestLoadXML_AbsentValuesAreNull checks a record without an element or an
+// attribute holds NULL for it, in every kind of column, so aggregates skip it;
+// an element that is there and empty is an empty string.
+func TestLoadXML_AbsentValuesAreNull(t *testing.T) {
+	t.Parallel()
+
+	content := `<r>
+  <i id="1" lang="en"><price>1.5</price><tag>a</tag><tag>b</tag><dim w="1"/><note></note></i>
+  <i id="2"><note>x</note></i>
+  <i id="3"><price>0</price><tag>c</tag></i>
+</r>`
+	got, err := loadXML(t, "absent.xml", []byte(content), "",
+		`SELECT count(price) AS prices, avg(price) AS mean,
+		        sum(lang IS NULL) AS langs, sum(tag IS NULL) AS tags, sum(dim IS NULL) AS dims,
+		        sum(note IS NULL) AS notes, sum(note = '') AS empty
+		 FROM absent`)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if want := "prices,mean,langs,tags,dims,notes,empty\n2,0.75,2,1,2,1,1\n"; got != want {
+		t.Errorf("table =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLoadXML_DefaultRecordsAreTheRootsChildren(t *testing.T) {
+	t.Parallel()
+
+	content := "\ufeff<rows><row><v>x</v></row><row><v></v></row><row/></rows>"
+	got, err := loadXML(t, "rows.xml", []byte(content), "", `SELECT count(*) AS n, count(NULLIF(v, '')) AS filled FROM rows`)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	// Every row arrives, the empty ones included: a one-column row holding an
+	// empty string must not be taken for a blank line.
+	if want := "n,filled\n3,1\n"; got != want {
+		t.Errorf("table =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLoadXML_AttributeAndChildWithOneName(t *testing.T) {
+	t.Parallel()
+
+	content := `<r><i id="a"><id>1</id></i></r>`
+	got, err := loadXML(t, "r.xml", []byte(content), "", `SELECT "@id", id FROM r`)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if want := "@id,id\na,1\n"; got != want {
+		t.Errorf("table =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLoadXML_DeclaredEncoding(t *testing.T) {
+	t.Parallel()
+
+	content := []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<r><i name=\"caf\xe9\"/></r>")
+	got, err := loadXML(t, "latin.xml", content, "", `SELECT name FROM latin`)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if want := "name\ncafé\n"; got != want {
+		t.Errorf("table =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLoadXML_Compressed(t *testing.T) {
+	t.Parallel()
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write([]byte(xmlFeed)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadXML(t, "feed.xml.gz", compressed.Bytes(), "/feed/items/item", `SELECT count(*) AS n FROM feed`)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if got != "n\n2\n" {
+		t.Errorf("table = %q, want two rows", got)
+	}
+}
+
+func TestLoadXML_Refusals(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		content    string
+		recordPath string
+		want       string
+	}{
+		{
+			name:    "the root holds more than one kind of element",
+			content: xmlFeed,
+			want:    "the root element <feed> holds <title>, <items> elements",
+		},
+		{
+			name:       "no element at the record path",
+			content:    xmlFeed,
+			recordPath: "/feed/entry",
+			want:       "no record element at /feed/entry",
+		},
+		{
+			name:    "records with nothing in them",
+			content: "<r><i/><i/></r>",
+			want:    "have no attributes, child elements, or text",
+		},
+		{
+			name:    "a document that ends early",
+			content: "<r><i a='1'/>",
+			want:    "malformed xml",
+		},
+		{
+			name:    "an encoding nobody has heard of",
+			content: `<?xml version="1.0" encoding="x-unknown"?><r><i a="1"/></r>`,
+			want:    `declares encoding "x-unknown"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := loadXML(t, "feed.xml", []byte(tt.content), tt.recordPath, "SELECT 1")
+			var importErr *model.ImportError
+			if !errors.As(err, &importErr) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadFile error = %v, want an ImportError containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsXMLFile(t *testing.T) {
+	t.Parallel()
+
+	for path, want := range map[string]bool{
+		"feed.xml":     true,
+		"FEED.XML":     true,
+		"feed.xml.gz":  true,
+		"feed.xml.zst": true,
+		"feed.xlsx":    false,
+		"feed.csv":     false,
+	} {
+		if got := IsXMLFile(path); got != want {
+			t.Errorf("IsXMLFile(%q) = %v, want %v", path, got, want)
+		}
+		if want && !IsSupportedFile(path) {
+			t.Errorf("IsSupportedFile(%q) = false, want true", path)
+		}
+	}
+}
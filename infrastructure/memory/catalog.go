@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// sourceCatalogTable is where a session database records which file each of
+// its tables came from. It lives in the session database itself, next to the
+// tables it describes, so a --db file carries its own provenance: opening it
+// again, from any directory, finds the record without a side file that could be
+// lost, moved, or describe a different database.
+//
+// The table is created on the first record, not when the database is opened. An
+// in-memory session never records anything, so it never grows a table a
+// `SELECT name FROM sqlite_master` would then show.
+const sourceCatalogTable = model.ReservedTablePrefix + "sources"
+
+const createSourceCatalog = "CREATE TABLE IF NOT EXISTS " + sourceCatalogTable + " (" +
+	"table_name TEXT PRIMARY KEY, " +
+	"source TEXT NOT NULL, " +
+	"size INTEGER NOT NULL, " +
+	"digest TEXT NOT NULL, " +
+	"options TEXT NOT NULL, " +
+	"fingerprint TEXT NOT NULL, " +
+	"from_directory INTEGER NOT NULL)"
+
+// TableSources returns every recorded table source, in table-name order.
+func (r *sqlite3Repository) TableSources(ctx context.Context) ([]model.TableSource, error) {
+	sources := []model.TableSource{}
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		exists, err := sourceCatalogExists(ctx, tx)
+		if err != nil || !exists {
+			return err
+		}
+		rows, err := tx.QueryContext(ctx,
+			"SELECT table_name, source, size, digest, options, fingerprint, from_directory FROM "+
+				sourceCatalogTable+" ORDER BY table_name")
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rows.Close() }()
+
+		for rows.Next() {
+			var src model.TableSource
+			if err := rows.Scan(&src.Table, &src.Source, &src.Size, &src.Digest,
+				&src.Options, &src.Fingerprint, &src.FromDirectory); err != nil {
+				return err
+			}
+			sources = append(sources, src)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// RecordTableSources stores each source, replacing an earlier record of the same
+// table. The records are written in one transaction, so an import that produced
+// several tables is never remembered as having produced only some of them.
+func (r *sqlite3Repository) RecordTableSources(ctx context.Context, sources []model.TableSource) error {
+	if len(sources) == 0 {
+		return nil
+	}
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, createSourceCatalog); err != nil {
+			return err
+		}
+		for _, src := range sources {
+			if _, err := tx.ExecContext(ctx,
+				"INSERT OR REPLACE INTO "+sourceCatalogTable+
+					" (table_name, source, size, digest, options, fingerprint, from_directory)"+
+					" VALUES (?, ?, ?, ?, ?, ?, ?)",
+				src.Table, src.Source, src.Size, src.Digest, src.Options, src.Fingerprint, src.FromDirectory); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ForgetTableSources deletes the records of the named tables. A database with no
+// catalog has nothing to forget, and is left without one.
+func (r *sqlite3Repository) ForgetTableSources(ctx context.Context, tableNames []string) error {
+	if len(tableNames) == 0 {
+		return nil
+	}
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		exists, err := sourceCatalogExists(ctx, tx)
+		if err != nil || !exists {
+			return err
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(tableNames)), ", ")
+		args := make([]any, 0, len(tableNames))
+		for _, name := range tableNames {
+			args = append(args, name)
+		}
+		_, err = tx.ExecContext(ctx,
+			"DELETE FROM "+sourceCatalogTable+" WHERE table_name IN ("+placeholders+")", args...)
+		return err
+	})
+}
+
+// sourceCatalogExists reports whether the catalog table has been created yet.
+func sourceCatalogExists(ctx context.Context, tx *sql.Tx) (bool, error) {
+	var n int
+	err := tx.QueryRowContext(ctx,
+		"SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", sourceCatalogTable).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
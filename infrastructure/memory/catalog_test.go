@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nao1215/sqly/config"
+	"github.com/nao1215/sqly/domain/model"
+)
+
+func TestSQLite3Repository_TableSourcesRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	memoryDB, cleanup, err := config.NewInMemDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	repo := NewSQLite3Repository(memoryDB)
+
+	// A database that has never recorded anything answers with nothing, and is
+	// not given a catalog table by being asked.
+	got, err := repo.TableSources(ctx)
+	if err != nil {
+		t.Fatalf("TableSources on a fresh database: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("TableSources on a fresh database = %v, want none", got)
+	}
+	if err := repo.ForgetTableSources(ctx, []string{"nothing"}); err != nil {
+		t.Fatalf("ForgetTableSources on a fresh database: %v", err)
+	}
+	assertNoCatalogTable(t, memoryDB)
+
+	users := model.TableSource{
+		Table: "users", Source: "/data/users.csv", Size: 42, Digest: "abc",
+		Options: "encoding=utf-8", Fingerprint: "fp1",
+	}
+	orders := model.TableSource{
+		Table: "orders", Source: "/data/dir/orders.csv", Size: 7, Digest: "def",
+		Options: "encoding=utf-8", Fingerprint: "fp2", FromDirectory: true,
+	}
+	if err := repo.RecordTableSources(ctx, []model.TableSource{users, orders}); err != nil {
+		t.Fatalf("RecordTableSources: %v", err)
+	}
+
+	// A second record of the same table replaces the first.
+	users.Digest, users.Fingerprint = "abd", "fp3"
+	if err := repo.RecordTableSources(ctx, []model.TableSource{users}); err != nil {
+		t.Fatalf("RecordTableSources (replace): %v", err)
+	}
+	got, err = repo.TableSources(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]model.TableSource{orders, users}, got); diff != "" {
+		t.Errorf("TableSources mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := repo.ForgetTableSources(ctx, []string{"orders", "never_recorded"}); err != nil {
+		t.Fatalf("ForgetTableSources: %v", err)
+	}
+	got, err = repo.TableSources(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]model.TableSource{users}, got); diff != "" {
+		t.Errorf("TableSources after forgetting orders (-want +got):\n%s", diff)
+	}
+}
+
+// TestSQLite3Repository_CatalogIsNotATable checks the catalog stays out of the
+// listings .tables and write-back read. It is bookkeeping: a session that saved
+// it as data would write a _sqly_sources.csv nobody asked for.
+func TestSQLite3Repository_CatalogIsNotATable(t *testing.T) {
+	ctx := context.Background()
+	memoryDB, cleanup, err := config.NewInMemDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	repo := NewSQLite3Repository(memoryDB)
+
+	if _, err := repo.Exec(ctx, "CREATE TABLE users (id INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.RecordTableSources(ctx, []model.TableSource{{Table: "users", Source: "/data/users.csv"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tables, err := repo.TablesName(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 || tables[0].Name() != "users" {
+		t.Errorf("TablesName = %v, want only users", tableNames(tables))
+	}
+	objects, err := repo.SchemaObjects(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 || objects[0].Name() != "users" {
+		t.Errorf("SchemaObjects = %v, want only users", tableNames(objects))
+	}
+}
+
+func assertNoCatalogTable(t *testing.T, db config.MemoryDB) {
+	t.Helper()
+	var n int
+	if err := (*sql.DB)(db).QueryRowContext(context.Background(),
+		"SELECT count(*) FROM sqlite_master WHERE name = ?", sourceCatalogTable).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("%s was created without anything being recorded", sourceCatalogTable)
+	}
+}
+
+func tableNames(tables []*model.Table) []string {
+	names := make([]string, 0, len(tables))
+	for _, table := range tables {
+		names = append(names, table.Name())
+	}
+	return names
+}
@@ -39,10 +39,11 @@ func (r *sqlite3Repository) inTx(ctx context.Context, fn func(tx *sql.Tx) error)
 // TablesName return all table name in import order.
 // SQLite's own bookkeeping tables (sqlite_sequence, sqlite_stat1) are excluded,
 // as is the one filesql keeps to remember where an ACH or Fedwire table was
-// loaded from. Each prefix is reserved by the layer that owns it: SQLite refuses
-// to create a table under sqlite_, and filesql refuses an import whose table
-// name would fall under _filesql_. Nothing a user imports can therefore be
-// hidden by the exclusions.
+// loaded from, and the one a --db session keeps to remember where every table
+// was loaded from. Each prefix is reserved by the layer that owns it: SQLite
+// refuses to create a table under sqlite_, filesql refuses an import whose table
+// name would fall under _filesql_, and sqly's own import refuses one under
+// _sqly_. Nothing a user imports can therefore be hidden by the exclusions.
 // Rows are ordered by sqlite_master.rowid, which is assigned in CREATE order, so
 // the result follows the order the source files were imported.
 func (r *sqlite3Repository) TablesName(ctx context.Context) ([]*model.Table, error) {
@@ -52,6 +53,7 @@ func (r *sqlite3Repository) TablesName(ctx context.Context) ([]*model.Table, err
 			"SELECT name FROM sqlite_master WHERE type = 'table'"+
 				" AND name NOT LIKE 'sqlite_%'"+
 				` AND name NOT LIKE '\_filesql\_%' ESCAPE '\'`+
+				` AND name NOT LIKE '\_sqly\_%' ESCAPE '\'`+
 				" ORDER BY rowid")
 		if err != nil {
 			return err
@@ -76,17 +78,18 @@ func (r *sqlite3Repository) TablesName(ctx context.Context) ([]*model.Table, err
 // SchemaObjects returns every queryable table and view in the session: base
 // tables and views in the main schema plus TEMP tables and views. It backs
 // .tables, which should enumerate everything the user can query, not only the
-// file-imported base tables that write-back targets. The reserved sqlite_ and
-// _filesql_ prefixes are excluded: no import can produce a name under either, so
-// the exclusion hides only the two layers' own bookkeeping. Names are sorted for
-// stable output.
+// file-imported base tables that write-back targets. The reserved sqlite_,
+// _filesql_, and _sqly_ prefixes are excluded: no import can produce a name under
+// any of them, so the exclusion hides only the layers' own bookkeeping. Names are
+// sorted for stable output.
 //
 // Each returned table carries the raw object name in Name() and the owning schema
 // ("main" or "temp") as the single Header entry, so .tables can disambiguate a
 // main object and a same-named temp object instead of collapsing them. UNION ALL
 // (not UNION) keeps both rows of such a collision.
 func (r *sqlite3Repository) SchemaObjects(ctx context.Context) ([]*model.Table, error) {
-	const reserved = ` AND name NOT LIKE 'sqlite_%' AND name NOT LIKE '\_filesql\_%' ESCAPE '\' `
+	const reserved = ` AND name NOT LIKE 'sqlite_%' AND name NOT LIKE '\_filesql\_%' ESCAPE '\'` +
+		` AND name NOT LIKE '\_sqly\_%' ESCAPE '\' `
 	const query = "SELECT name, 'main' AS schema_name FROM sqlite_master " +
 		"WHERE type IN ('table', 'view')" + reserved +
 		"UNION ALL " +
@@ -196,6 +199,9 @@ func (r *sqlite3Repository) Query(ctx context.Context, query string) (*model.Tab
 	// SQL NULL stays distinguishable from an empty string.
 	cells := [][]model.Cell{}
 
+	// Patterns compiled by the regular expression functions are kept for one
+	// statement, and this is where a statement starts.
+	config.ResetRegexpCache()
 	err := r.inTx(ctx, func(tx *sql.Tx) error {
 		rows, err := tx.QueryContext(ctx, query)
 		if err != nil {
@@ -210,6 +216,18 @@ func (r *sqlite3Repository) Query(ctx context.Context, query string) (*model.Tab
 		if len(header) == 0 {
 			return repository.ErrNoRows
 		}
+		// A column read straight from a DECIMAL column is a decimal, so JSON
+		// writes it as a number and Parquet as a decimal, digit for digit. SQLite
+		// reports no declared type for an expression, so dec_sum(amount) stays
+		// the text it returns.
+		columnTypes, err := rows.ColumnTypes()
+		if err != nil {
+			return err
+		}
+		decimals := make([]bool, len(header))
+		for i, c := range columnTypes {
+			decimals[i] = model.IsDecimalColumnType(c.DatabaseTypeName())
+		}
 
 		scanDest := make([]any, len(header))
 		values := make([]any, len(header))
@@ -224,6 +242,9 @@ func (r *sqlite3Repository) Query(ctx context.Context, query string) (*model.Tab
 			row := make([]model.Cell, len(header))
 			for i, value := range values {
 				row[i] = model.NewCell(value)
+				if decimals[i] {
+					row[i] = model.NewDecimalCell(value)
+				}
 			}
 			cells = append(cells, row)
 		}
@@ -251,6 +272,7 @@ func extractTableName(query string) string {
 // Exec execute "INSERT" or "UPDATE" or "DELETE" statement
 func (r *sqlite3Repository) Exec(ctx context.Context, statement string) (int64, error) {
 	var result sql.Result
+	config.ResetRegexpCache()
 	if err := r.inTx(ctx, func(tx *sql.Tx) error {
 		var err error
 		result, err = tx.ExecContext(ctx, statement)
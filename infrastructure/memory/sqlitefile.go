@@ -0,0 +1,230 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	infra "github.com/nao1215/sqly/infrastructure"
+)
+
+// sessionSchemaObject is one index, view, or trigger of the session, kept as the
+// statement that created it.
+type sessionSchemaObject struct {
+	kind     string
+	name     string
+	tableRef string
+	sql      string
+}
+
+// SaveAsSQLite writes every table of the session into a new SQLite database at
+// path and returns the names of the tables written.
+//
+// Each table is created from the statement that created it in the session, so a
+// table made with CREATE TABLE keeps its declared types, keys, and constraints
+// rather than the all-TEXT shape a query result would give it. Rows are copied
+// before the indexes, views, and triggers are created: a trigger created first
+// would fire on every copied row and change what was saved, and an index is
+// cheaper to build once over the finished table than to maintain row by row.
+// Foreign keys are left unenforced on the new connection, which is SQLite's
+// default, so the order tables are copied in cannot fail a reference.
+//
+// TEMP objects are not saved; they were never meant to outlive the session. The
+// reserved sqlite_, _filesql_, and _sqly_ names are not saved either, for the
+// same reason TablesName hides them: they are the bookkeeping of the layers that
+// own them, and the database written here belongs to the user.
+//
+// path must not be an existing database. The shell writes to a fresh staging
+// file and moves it into place, so a failure here never leaves a half-copied
+// database where the user asked for one.
+func (r *sqlite3Repository) SaveAsSQLite(ctx context.Context, path string) ([]string, error) {
+	target, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open %q: %w", path, err)
+	}
+	defer func() { _ = target.Close() }()
+	target.SetMaxOpenConns(1)
+
+	var tables []string
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		tables, err = sessionTables(ctx, tx)
+		if err != nil {
+			return err
+		}
+		objects, err := sessionSchemaObjects(ctx, tx, tables)
+		if err != nil {
+			return err
+		}
+		_, err = infra.WithTransaction(ctx, infra.SQLTxBeginner{DB: target}, func(out *sql.Tx) error {
+			for _, table := range tables {
+				if err := copySessionTable(ctx, tx, out, table); err != nil {
+					return fmt.Errorf("copy table %s: %w", table, err)
+				}
+			}
+			for _, object := range objects {
+				if _, err := out.ExecContext(ctx, object.sql); err != nil {
+					return fmt.Errorf("create %s %s: %w", object.kind, object.name, err)
+				}
+			}
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// sessionTables returns the ordinary tables of the main schema in CREATE order.
+// pragma_table_list is what tells a virtual table and its shadow tables apart
+// from an ordinary one; sqlite_master lists all three as "table", and copying a
+// shadow table by its own CREATE statement would collide with the one its
+// virtual table creates.
+func sessionTables(ctx context.Context, tx *sql.Tx) ([]string, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT m.name FROM sqlite_master m JOIN pragma_table_list l ON l.schema = 'main' AND l.name = m.name"+
+			" WHERE m.type = 'table' AND l.type = 'table'"+
+			" AND m.name NOT LIKE 'sqlite_%'"+
+			` AND m.name NOT LIKE '\_filesql\_%' ESCAPE '\'`+
+			` AND m.name NOT LIKE '\_sqly\_%' ESCAPE '\'`+
+			" ORDER BY m.rowid")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	tables := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// sessionSchemaObjects returns the indexes, views, and triggers of the main
+// schema that belong to the saved tables, in CREATE order, indexes first. A
+// view is saved unless its own name is reserved, and a trigger on a view
+// follows the view. An index SQLite made for a PRIMARY KEY or UNIQUE
+// constraint has no statement of its own; the table's CREATE statement brings
+// it back.
+func sessionSchemaObjects(ctx context.Context, tx *sql.Tx, tables []string) ([]sessionSchemaObject, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT type, name, tbl_name, sql FROM sqlite_master"+
+			" WHERE type IN ('index', 'view', 'trigger') AND sql IS NOT NULL"+
+			" ORDER BY CASE type WHEN 'index' THEN 0 WHEN 'view' THEN 1 ELSE 2 END, rowid")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	saved := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		saved[table] = true
+	}
+	objects := []sessionSchemaObject{}
+	for rows.Next() {
+		var object sessionSchemaObject
+		if err := rows.Scan(&object.kind, &object.name, &object.tableRef, &object.sql); err != nil {
+			return nil, err
+		}
+		if object.kind == "view" {
+			if isReservedName(object.name) {
+				continue
+			}
+			saved[object.name] = true
+		}
+		if !saved[object.tableRef] {
+			continue
+		}
+		objects = append(objects, object)
+	}
+	return objects, rows.Err()
+}
+
+// isReservedName reports whether name falls under a prefix a layer keeps for its
+// own bookkeeping.
+func isReservedName(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasPrefix(lower, "sqlite_") ||
+		strings.HasPrefix(lower, "_filesql_") ||
+		strings.HasPrefix(lower, "_sqly_")
+}
+
+// copySessionTable creates table in out from its session CREATE statement and
+// copies its rows. Generated columns are left out of the copy: SQLite computes
+// them in the new table from the same expression, and refuses a value for one.
+func copySessionTable(ctx context.Context, tx, out *sql.Tx, table string) error {
+	var create string
+	if err := tx.QueryRowContext(ctx,
+		"SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&create); err != nil {
+		return err
+	}
+	if _, err := out.ExecContext(ctx, create); err != nil {
+		return err
+	}
+
+	columns, err := storedColumns(ctx, tx, table)
+	if err != nil {
+		return err
+	}
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = infra.Quote(column)
+	}
+	list := strings.Join(quoted, ", ")
+
+	insert, err := out.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		infra.Quote(table), list, strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = insert.Close() }()
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM main.%s", list, infra.Quote(table)))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	values := make([]any, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		if _, err := insert.ExecContext(ctx, values...); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// storedColumns returns the columns of table that hold a value of their own, in
+// declaration order.
+func storedColumns(ctx context.Context, tx *sql.Tx, table string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT name FROM pragma_table_xinfo(?, 'main') WHERE hidden = 0 ORDER BY cid", table)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nao1215/sqly/config"
+	"github.com/nao1215/sqly/domain/model"
+)
+
+func TestSQLite3Repository_SaveAsSQLite(t *testing.T) {
+	ctx := context.Background()
+	memoryDB, cleanup, err := config.NewInMemDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	repo := NewSQLite3Repository(memoryDB)
+
+	for _, statement := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, name_len INTEGER GENERATED ALWAYS AS (length(name)))`,
+		`CREATE TABLE audit (user_id INTEGER, note TEXT)`,
+		`CREATE INDEX users_name ON users (name)`,
+		`CREATE VIEW long_names AS SELECT name FROM users WHERE name_len > 3`,
+		`CREATE TRIGGER users_audit AFTER INSERT ON users BEGIN INSERT INTO audit VALUES (new.id, 'added'); END`,
+		`INSERT INTO users (id, name) VALUES (1, 'alice'), (2, 'bob')`,
+		`CREATE TEMP TABLE scratch (x)`,
+	} {
+		if _, err := repo.Exec(ctx, statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	if err := repo.RecordTableSources(ctx, []model.TableSource{{Table: "users", Source: "/data/users.csv"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "session.db")
+	tables, err := repo.SaveAsSQLite(ctx, path)
+	if err != nil {
+		t.Fatalf("SaveAsSQLite: %v", err)
+	}
+	if diff := cmp.Diff([]string{"users", "audit"}, tables); diff != "" {
+		t.Errorf("tables mismatch (-want +got):\n%s", diff)
+	}
+
+	saved, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = saved.Close() }()
+
+	// The catalog and the temp table stay behind; every other object is kept.
+	var objects string
+	if err := saved.QueryRow(`SELECT group_concat(type || ':' || name, ',') FROM (SELECT type, name FROM sqlite_master ORDER BY type, name)`).Scan(&objects); err != nil {
+		t.Fatal(err)
+	}
+	if want := "index:users_name,table:audit,table:users,trigger:users_audit,view:long_names"; objects != want {
+		t.Errorf("objects = %s, want %s", objects, want)
+	}
+
+	// The trigger was created after the copy, so the rows the session held were
+	// not audited a second time, and the generated column was recomputed.
+	var audits int
+	if err := saved.QueryRow(`SELECT count(*) FROM audit`).Scan(&audits); err != nil {
+		t.Fatal(err)
+	}
+	if audits != 2 {
+		t.Errorf("audit rows = %d, want the 2 the session held", audits)
+	}
+	var names string
+	if err := saved.QueryRow(`SELECT group_concat(name, ',') FROM long_names`).Scan(&names); err != nil {
+		t.Fatal(err)
+	}
+	if names != "alice" {
+		t.Errorf("long_names = %q, want alice", names)
+	}
+}
@@ -80,6 +80,44 @@ func (c *MockSQLite3RepositoryExecCall) DoAndReturn(f func(context.Context, stri
 	return c
 }
 
+// ForgetTableSources mocks base method.
+func (m *MockSQLite3Repository) ForgetTableSources(ctx context.Context, tableNames []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgetTableSources", ctx, tableNames)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgetTableSources indicates an expected call of ForgetTableSources.
+func (mr *MockSQLite3RepositoryMockRecorder) ForgetTableSources(ctx, tableNames any) *MockSQLite3RepositoryForgetTableSourcesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgetTableSources", reflect.TypeOf((*MockSQLite3Repository)(nil).ForgetTableSources), ctx, tableNames)
+	return &MockSQLite3RepositoryForgetTableSourcesCall{Call: call}
+}
+
+// MockSQLite3RepositoryForgetTableSourcesCall wrap *gomock.Call
+type MockSQLite3RepositoryForgetTableSourcesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSQLite3RepositoryForgetTableSourcesCall) Return(arg0 error) *MockSQLite3RepositoryForgetTableSourcesCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSQLite3RepositoryForgetTableSourcesCall) Do(f func(context.Context, []string) error) *MockSQLite3RepositoryForgetTableSourcesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSQLite3RepositoryForgetTableSourcesCall) DoAndReturn(f func(context.Context, []string) error) *MockSQLite3RepositoryForgetTableSourcesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Header mocks base method.
 func (m *MockSQLite3Repository) Header(ctx context.Context, tableName string) (*model.Table, error) {
 	m.ctrl.T.Helper()
@@ -197,6 +235,83 @@ func (c *MockSQLite3RepositoryQueryCall) DoAndReturn(f func(context.Context, str
 	return c
 }
 
+// RecordTableSources mocks base method.
+func (m *MockSQLite3Repository) RecordTableSources(ctx context.Context, sources []model.TableSource) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordTableSources", ctx, sources)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordTableSources indicates an expected call of RecordTableSources.
+func (mr *MockSQLite3RepositoryMockRecorder) RecordTableSources(ctx, sources any) *MockSQLite3RepositoryRecordTableSourcesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordTableSources", reflect.TypeOf((*MockSQLite3Repository)(nil).RecordTableSources), ctx, sources)
+	return &MockSQLite3RepositoryRecordTableSourcesCall{Call: call}
+}
+
+// MockSQLite3RepositoryRecordTableSourcesCall wrap *gomock.Call
+type MockSQLite3RepositoryRecordTableSourcesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSQLite3RepositoryRecordTableSourcesCall) Return(arg0 error) *MockSQLite3RepositoryRecordTableSourcesCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSQLite3RepositoryRecordTableSourcesCall) Do(f func(context.Context, []model.TableSource) error) *MockSQLite3RepositoryRecordTableSourcesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSQLite3RepositoryRecordTableSourcesCall) DoAndReturn(f func(context.Context, []model.TableSource) error) *MockSQLite3RepositoryRecordTableSourcesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SaveAsSQLite mocks base method.
+func (m *MockSQLite3Repository) SaveAsSQLite(ctx context.Context, path string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAsSQLite", ctx, path)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveAsSQLite indicates an expected call of SaveAsSQLite.
+func (mr *MockSQLite3RepositoryMockRecorder) SaveAsSQLite(ctx, path any) *MockSQLite3RepositorySaveAsSQLiteCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAsSQLite", reflect.TypeOf((*MockSQLite3Repository)(nil).SaveAsSQLite), ctx, path)
+	return &MockSQLite3RepositorySaveAsSQLiteCall{Call: call}
+}
+
+// MockSQLite3RepositorySaveAsSQLiteCall wrap *gomock.Call
+type MockSQLite3RepositorySaveAsSQLiteCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSQLite3RepositorySaveAsSQLiteCall) Return(arg0 []string, arg1 error) *MockSQLite3RepositorySaveAsSQLiteCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSQLite3RepositorySaveAsSQLiteCall) Do(f func(context.Context, string) ([]string, error)) *MockSQLite3RepositorySaveAsSQLiteCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSQLite3RepositorySaveAsSQLiteCall) DoAndReturn(f func(context.Context, string) ([]string, error)) *MockSQLite3RepositorySaveAsSQLiteCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SchemaObjects mocks base method.
 func (m *MockSQLite3Repository) SchemaObjects(ctx context.Context) ([]*model.Table, error) {
 	m.ctrl.T.Helper()
@@ -236,6 +351,45 @@ func (c *MockSQLite3RepositorySchemaObjectsCall) DoAndReturn(f func(context.Cont
 	return c
 }
 
+// TableSources mocks base method.
+func (m *MockSQLite3Repository) TableSources(ctx context.Context) ([]model.TableSource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TableSources", ctx)
+	ret0, _ := ret[0].([]model.TableSource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TableSources indicates an expected call of TableSources.
+func (mr *MockSQLite3RepositoryMockRecorder) TableSources(ctx any) *MockSQLite3RepositoryTableSourcesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TableSources", reflect.TypeOf((*MockSQLite3Repository)(nil).TableSources), ctx)
+	return &MockSQLite3RepositoryTableSourcesCall{Call: call}
+}
+
+// MockSQLite3RepositoryTableSourcesCall wrap *gomock.Call
+type MockSQLite3RepositoryTableSourcesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSQLite3RepositoryTableSourcesCall) Return(arg0 []model.TableSource, arg1 error) *MockSQLite3RepositoryTableSourcesCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSQLite3RepositoryTableSourcesCall) Do(f func(context.Context) ([]model.TableSource, error)) *MockSQLite3RepositoryTableSourcesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSQLite3RepositoryTableSourcesCall) DoAndReturn(f func(context.Context) ([]model.TableSource, error)) *MockSQLite3RepositoryTableSourcesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // TablesName mocks base method.
 func (m *MockSQLite3Repository) TablesName(ctx context.Context) ([]*model.Table, error) {
 	m.ctrl.T.Helper()
@@ -6,7 +6,11 @@ import (
 
 	"github.com/nao1215/sqly/domain/model"
 	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
 	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
 	"golang.org/x/text/encoding/unicode"
 	"golang.org/x/text/transform"
 )
@@ -74,6 +78,16 @@ func exportEncoder(enc model.TextEncoding) (transform.Transformer, bool) {
 		target = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
 	case model.TextEncodingUTF16BE:
 		target = unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	case model.TextEncodingWindows1252:
+		target = charmap.Windows1252
+	case model.TextEncodingGBK:
+		target = simplifiedchinese.GBK
+	case model.TextEncodingGB18030:
+		target = simplifiedchinese.GB18030
+	case model.TextEncodingBig5:
+		target = traditionalchinese.Big5
+	case model.TextEncodingEUCKR:
+		target = korean.EUCKR
 	default:
 		return nil, false
 	}
@@ -182,7 +182,7 @@ func ensureLastRowSurvives(table *model.Table) error {
 func ensureExcelRepresentable(label, value string) error {
 	// Bytes that are not UTF-8 at all are checked first, because ranging over a
 	// string cannot see them: Go decodes each invalid byte as U+FFFD, which
-	// xmlCanCarry then passes, and the writer wrote U+FFFD in its place. That is
+	// model.XMLCanCarry then passes, and the writer wrote U+FFFD in its place. That is
 	// the substitution this function exists to stop, arriving through the one
 	// door it was not watching. Such a value is almost always a file read with
 	// the wrong --encoding, so the message says so.
@@ -196,27 +196,9 @@ func ensureExcelRepresentable(label, value string) error {
 		return fmt.Errorf("excel: value for column %q is %d characters, and an XLSX cell holds %d, so it would be written cut short; export to csv/tsv/json instead", label, n, excelCellMaxLen)
 	}
 	for _, r := range value {
-		if !xmlCanCarry(r) {
+		if !model.XMLCanCarry(r) {
 			return fmt.Errorf("excel: value for column %q contains the character U+%04X, which XLSX cannot represent; remove it or export to csv/tsv/json", label, r)
 		}
 	}
 	return nil
 }
-
-// xmlCanCarry reports whether XML 1.0 can hold r, following its Char production.
-// A Go string never yields a surrogate when ranged over — invalid bytes decode to
-// U+FFFD — so that half of the production cannot be reached from here. The caller
-// rejects a value holding such bytes before this runs, so a U+FFFD reaching it is
-// one the data really contained.
-func xmlCanCarry(r rune) bool {
-	switch {
-	case r == '\t' || r == '\n' || r == '\r':
-		return true
-	case r < 0x20:
-		return false
-	case r == 0xFFFE || r == 0xFFFF:
-		return false
-	default:
-		return true
-	}
-}
@@ -0,0 +1,76 @@
+package interactor
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/nao1215/sqly/domain/model"
+	"github.com/nao1215/sqly/domain/sqltext"
+)
+
+// parse_date and format_date are names filesql's dialect helpers hold already,
+// in GoogleSQL's order, layout first, and the driver refuses a second function
+// of either name. sqly's versions, which take the value first as well, are
+// registered under names of their own (see config/datetime.go), and each call a
+// statement makes is sent to them here, before the dialect translation runs.
+
+// routedDateFunctions maps the names a statement calls to the functions that
+// answer them.
+var routedDateFunctions = map[string]string{
+	"parse_date":  model.ParseDateFunction,
+	"format_date": model.FormatDateFunction,
+}
+
+// routeDateFunctions renames each parse_date and format_date call in stmt to
+// the function sqly registers for it. A word that is not followed by an opening
+// parenthesis — a column of that name — is left alone, and so is anything in a
+// string or a quoted identifier.
+//
+// An unaliased call in a select list is labeled with the text it was written
+// as, so the column is still called format_date(ordered, '%Y') and not after
+// the name it runs under.
+func routeDateFunctions(stmt string) string {
+	// edits replace stmt[start:end] with text, in order of start; a label is
+	// an edit that replaces nothing. A call nested in a labeled one is routed
+	// too, so the edits are made once every call has been found.
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var (
+		edits []edit
+		items = newSelectItems()
+	)
+	for tok := range sqltext.Tokens(stmt) {
+		if tok.Kind != sqltext.Word {
+			continue
+		}
+		word := tok.Text(stmt)
+		prev := items.see(strings.ToUpper(word), tok.Depth)
+		routed, ok := routedDateFunctions[strings.ToLower(word)]
+		if !ok {
+			continue
+		}
+		closing := sqltext.MatchingParen(stmt, skipSpace(stmt, tok.End))
+		if closing < 0 {
+			continue
+		}
+		edits = append(edits, edit{start: tok.Start, end: tok.End, text: routed})
+		if items.inList(tok.Depth) && standsAlone(stmt, tok, prev, closing+1) {
+			edits = append(edits, edit{start: closing + 1, end: closing + 1, text: asWritten(stmt[tok.Start : closing+1])})
+		}
+	}
+	if len(edits) == 0 {
+		return stmt
+	}
+	slices.SortStableFunc(edits, func(a, b edit) int { return a.start - b.start })
+	var b strings.Builder
+	last := 0
+	for _, e := range edits {
+		b.WriteString(stmt[last:e.start])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.WriteString(stmt[last:])
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package interactor
+
+import "testing"
+
+func TestRouteDateFunctions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "an unaliased select item keeps the text it was written as",
+			in:   "SELECT parse_date(ordered, '%m/%d/%Y') FROM orders",
+			want: `SELECT sqly_parse_date(ordered, '%m/%d/%Y') AS "parse_date(ordered, '%m/%d/%Y')" FROM orders`,
+		},
+		{
+			name: "an alias is the caller's",
+			in:   "SELECT FORMAT_DATE(ordered, '%Y') AS y FROM orders",
+			want: "SELECT sqly_format_date(ordered, '%Y') AS y FROM orders",
+		},
+		{
+			name: "a nested call is routed inside the labeled one",
+			in:   `SELECT format_date(parse_date(d, '%d.%m.%Y'), '%Y "Q"'), 1 FROM t`,
+			want: `SELECT sqly_format_date(sqly_parse_date(d, '%d.%m.%Y'), '%Y "Q"') AS "format_date(parse_date(d, '%d.%m.%Y'), '%Y ""Q""')", 1 FROM t`,
+		},
+		{
+			name: "a call outside the select list is not labeled",
+			in:   "SELECT id FROM t WHERE parse_date(d, '%Y%m%d') >= '2024-01-01'",
+			want: "SELECT id FROM t WHERE sqly_parse_date(d, '%Y%m%d') >= '2024-01-01'",
+		},
+		{
+			name: "a column of the same name is not a call",
+			in:   `SELECT parse_date, "format_date"(x) FROM t`,
+			want: `SELECT parse_date, "format_date"(x) FROM t`,
+		},
+		{
+			name: "the words in a string are not a call",
+			in:   "SELECT 'parse_date(x, y)' FROM t",
+			want: "SELECT 'parse_date(x, y)' FROM t",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := routeDateFunctions(tt.in); got != tt.want {
+				t.Errorf("routeDateFunctions(%q)\n got %s\nwant %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -33,12 +33,13 @@ func NewExportInteractor() usecase.ExportUsecase {
 type streamSerializer func(io.Writer, *model.Table) error
 
 // pathSerializer writes a table to a path it opens itself. Excel and Parquet
-// both build their container in memory and save it, so neither has a stream a
-// codec could wrap; callers reject compression for them upstream.
+// both build their container in memory and save it, and a SQLite database is
+// written by SQLite, so none has a stream a codec could wrap; callers reject
+// compression for them upstream.
 type pathSerializer func(string, *model.Table) error
 
 // streamSerializers names the writer for every format that has one. Formats
-// whose file output is their display rendering (Markdown, JSON, JSONL) print
+// whose file output is their display rendering (Markdown, JSON, JSONL, XML, SQL) print
 // themselves rather than carrying a second implementation of the same bytes.
 //
 // A registry rather than a switch so a test can assert that every declared
@@ -52,12 +53,15 @@ var streamSerializers = map[model.ExportFormat]streamSerializer{
 	model.ExportMarkdown: printSerializer(model.PrintModeMarkdownTable),
 	model.ExportJSON:     printSerializer(model.PrintModeJSON),
 	model.ExportJSONL:    printSerializer(model.PrintModeJSONL),
+	model.ExportXML:      printSerializer(model.PrintModeXML),
+	model.ExportSQL:      printSerializer(model.PrintModeSQL),
 }
 
 // pathSerializers names the writer for every format that opens its own file.
 var pathSerializers = map[model.ExportFormat]pathSerializer{
 	model.ExportExcel:   persistence.DumpExcel,
 	model.ExportParquet: filesql.DumpTableToParquet,
+	model.ExportSQLite:  filesql.DumpTableToSQLite,
 }
 
 // printSerializer adapts a display mode into a serializer, for the formats whose
@@ -69,10 +73,15 @@ func printSerializer(mode model.PrintMode) streamSerializer {
 }
 
 // DumpTable exports a table to a file in the specified format. Text and JSON
-// formats honor the compression codec and the text encoding; Excel and Parquet
-// are binary container formats that state their own encoding and ignore both
+// formats honor the compression codec and the text encoding; Excel, Parquet,
+// and SQLite are binary container formats that state their own encoding and ignore both
 // (callers reject compression for them upstream).
+//
+// The columns the table's mask names are masked here, before any serializer
+// sees the rows. --output and its partitions, .dump, and .save DIR all write
+// through this one function, so none of them can forget the mask.
 func (e *exportInteractor) DumpTable(filePath string, table *model.Table, format model.ExportFormat, compression model.Compression, encoding model.TextEncoding) error {
+	table = table.Masked()
 	if dump, ok := pathSerializers[format]; ok {
 		return dump(filepath.Clean(filePath), table)
 	}
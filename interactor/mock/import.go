@@ -233,6 +233,44 @@ func (c *MockImportUsecaseIsExcelFileCall) DoAndReturn(f func(string) bool) *Moc
 	return c
 }
 
+// IsSQLiteFile mocks base method.
+func (m *MockImportUsecase) IsSQLiteFile(filePath string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSQLiteFile", filePath)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsSQLiteFile indicates an expected call of IsSQLiteFile.
+func (mr *MockImportUsecaseMockRecorder) IsSQLiteFile(filePath any) *MockImportUsecaseIsSQLiteFileCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSQLiteFile", reflect.TypeOf((*MockImportUsecase)(nil).IsSQLiteFile), filePath)
+	return &MockImportUsecaseIsSQLiteFileCall{Call: call}
+}
+
+// MockImportUsecaseIsSQLiteFileCall wrap *gomock.Call
+type MockImportUsecaseIsSQLiteFileCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockImportUsecaseIsSQLiteFileCall) Return(arg0 bool) *MockImportUsecaseIsSQLiteFileCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockImportUsecaseIsSQLiteFileCall) Do(f func(string) bool) *MockImportUsecaseIsSQLiteFileCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockImportUsecaseIsSQLiteFileCall) DoAndReturn(f func(string) bool) *MockImportUsecaseIsSQLiteFileCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // IsSupportedFile mocks base method.
 func (m *MockImportUsecase) IsSupportedFile(filePath string) bool {
 	m.ctrl.T.Helper()
@@ -272,10 +310,10 @@ func (c *MockImportUsecaseIsSupportedFileCall) DoAndReturn(f func(string) bool)
 }
 
 // LoadFiles mocks base method.
-func (m *MockImportUsecase) LoadFiles(ctx context.Context, filePaths ...string) error {
+func (m *MockImportUsecase) LoadFiles(ctx context.Context, files ...model.FileLoad) error {
 	m.ctrl.T.Helper()
 	varargs := []any{ctx}
-	for _, a := range filePaths {
+	for _, a := range files {
 		varargs = append(varargs, a)
 	}
 	ret := m.ctrl.Call(m, "LoadFiles", varargs...)
@@ -284,9 +322,9 @@ func (m *MockImportUsecase) LoadFiles(ctx context.Context, filePaths ...string)
 }
 
 // LoadFiles indicates an expected call of LoadFiles.
-func (mr *MockImportUsecaseMockRecorder) LoadFiles(ctx any, filePaths ...any) *MockImportUsecaseLoadFilesCall {
+func (mr *MockImportUsecaseMockRecorder) LoadFiles(ctx any, files ...any) *MockImportUsecaseLoadFilesCall {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]any{ctx}, filePaths...)
+	varargs := append([]any{ctx}, files...)
 	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadFiles", reflect.TypeOf((*MockImportUsecase)(nil).LoadFiles), varargs...)
 	return &MockImportUsecaseLoadFilesCall{Call: call}
 }
@@ -303,13 +341,13 @@ func (c *MockImportUsecaseLoadFilesCall) Return(arg0 error) *MockImportUsecaseLo
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockImportUsecaseLoadFilesCall) Do(f func(context.Context, ...string) error) *MockImportUsecaseLoadFilesCall {
+func (c *MockImportUsecaseLoadFilesCall) Do(f func(context.Context, ...model.FileLoad) error) *MockImportUsecaseLoadFilesCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockImportUsecaseLoadFilesCall) DoAndReturn(f func(context.Context, ...string) error) *MockImportUsecaseLoadFilesCall {
+func (c *MockImportUsecaseLoadFilesCall) DoAndReturn(f func(context.Context, ...model.FileLoad) error) *MockImportUsecaseLoadFilesCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -352,6 +390,120 @@ func (c *MockImportUsecaseQuoteIdentifierCall) DoAndReturn(f func(string) string
 	return c
 }
 
+// SQLiteTables mocks base method.
+func (m *MockImportUsecase) SQLiteTables(path string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SQLiteTables", path)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SQLiteTables indicates an expected call of SQLiteTables.
+func (mr *MockImportUsecaseMockRecorder) SQLiteTables(path any) *MockImportUsecaseSQLiteTablesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SQLiteTables", reflect.TypeOf((*MockImportUsecase)(nil).SQLiteTables), path)
+	return &MockImportUsecaseSQLiteTablesCall{Call: call}
+}
+
+// MockImportUsecaseSQLiteTablesCall wrap *gomock.Call
+type MockImportUsecaseSQLiteTablesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockImportUsecaseSQLiteTablesCall) Return(arg0 []string, arg1 error) *MockImportUsecaseSQLiteTablesCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockImportUsecaseSQLiteTablesCall) Do(f func(string) ([]string, error)) *MockImportUsecaseSQLiteTablesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockImportUsecaseSQLiteTablesCall) DoAndReturn(f func(string) ([]string, error)) *MockImportUsecaseSQLiteTablesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SchemaSidecar mocks base method.
+func (m *MockImportUsecase) SchemaSidecar(path string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SchemaSidecar", path)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SchemaSidecar indicates an expected call of SchemaSidecar.
+func (mr *MockImportUsecaseMockRecorder) SchemaSidecar(path any) *MockImportUsecaseSchemaSidecarCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchemaSidecar", reflect.TypeOf((*MockImportUsecase)(nil).SchemaSidecar), path)
+	return &MockImportUsecaseSchemaSidecarCall{Call: call}
+}
+
+// MockImportUsecaseSchemaSidecarCall wrap *gomock.Call
+type MockImportUsecaseSchemaSidecarCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockImportUsecaseSchemaSidecarCall) Return(arg0 string, arg1 error) *MockImportUsecaseSchemaSidecarCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockImportUsecaseSchemaSidecarCall) Do(f func(string) (string, error)) *MockImportUsecaseSchemaSidecarCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockImportUsecaseSchemaSidecarCall) DoAndReturn(f func(string) (string, error)) *MockImportUsecaseSchemaSidecarCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SetImportCleaning mocks base method.
+func (m *MockImportUsecase) SetImportCleaning(cleaning model.ImportCleaning) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetImportCleaning", cleaning)
+}
+
+// SetImportCleaning indicates an expected call of SetImportCleaning.
+func (mr *MockImportUsecaseMockRecorder) SetImportCleaning(cleaning any) *MockImportUsecaseSetImportCleaningCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetImportCleaning", reflect.TypeOf((*MockImportUsecase)(nil).SetImportCleaning), cleaning)
+	return &MockImportUsecaseSetImportCleaningCall{Call: call}
+}
+
+// MockImportUsecaseSetImportCleaningCall wrap *gomock.Call
+type MockImportUsecaseSetImportCleaningCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockImportUsecaseSetImportCleaningCall) Return() *MockImportUsecaseSetImportCleaningCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockImportUsecaseSetImportCleaningCall) Do(f func(model.ImportCleaning)) *MockImportUsecaseSetImportCleaningCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockImportUsecaseSetImportCleaningCall) DoAndReturn(f func(model.ImportCleaning)) *MockImportUsecaseSetImportCleaningCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SetIncludeHiddenSheets mocks base method.
 func (m *MockImportUsecase) SetIncludeHiddenSheets(include bool) {
 	m.ctrl.T.Helper()
@@ -424,6 +576,78 @@ func (c *MockImportUsecaseSetRowMismatchPolicyCall) DoAndReturn(f func(model.Row
 	return c
 }
 
+// SetSQLiteTables mocks base method.
+func (m *MockImportUsecase) SetSQLiteTables(tables []string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetSQLiteTables", tables)
+}
+
+// SetSQLiteTables indicates an expected call of SetSQLiteTables.
+func (mr *MockImportUsecaseMockRecorder) SetSQLiteTables(tables any) *MockImportUsecaseSetSQLiteTablesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSQLiteTables", reflect.TypeOf((*MockImportUsecase)(nil).SetSQLiteTables), tables)
+	return &MockImportUsecaseSetSQLiteTablesCall{Call: call}
+}
+
+// MockImportUsecaseSetSQLiteTablesCall wrap *gomock.Call
+type MockImportUsecaseSetSQLiteTablesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockImportUsecaseSetSQLiteTablesCall) Return() *MockImportUsecaseSetSQLiteTablesCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockImportUsecaseSetSQLiteTablesCall) Do(f func([]string)) *MockImportUsecaseSetSQLiteTablesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockImportUsecaseSetSQLiteTablesCall) DoAndReturn(f func([]string)) *MockImportUsecaseSetSQLiteTablesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SetXMLRecordPath mocks base method.
+func (m *MockImportUsecase) SetXMLRecordPath(path string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetXMLRecordPath", path)
+}
+
+// SetXMLRecordPath indicates an expected call of SetXMLRecordPath.
+func (mr *MockImportUsecaseMockRecorder) SetXMLRecordPath(path any) *MockImportUsecaseSetXMLRecordPathCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetXMLRecordPath", reflect.TypeOf((*MockImportUsecase)(nil).SetXMLRecordPath), path)
+	return &MockImportUsecaseSetXMLRecordPathCall{Call: call}
+}
+
+// MockImportUsecaseSetXMLRecordPathCall wrap *gomock.Call
+type MockImportUsecaseSetXMLRecordPathCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockImportUsecaseSetXMLRecordPathCall) Return() *MockImportUsecaseSetXMLRecordPathCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockImportUsecaseSetXMLRecordPathCall) Do(f func(string)) *MockImportUsecaseSetXMLRecordPathCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockImportUsecaseSetXMLRecordPathCall) DoAndReturn(f func(string)) *MockImportUsecaseSetXMLRecordPathCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SkippedRows mocks base method.
 func (m *MockImportUsecase) SkippedRows(tables []string) []model.SkippedRows {
 	m.ctrl.T.Helper()
@@ -461,3 +685,42 @@ func (c *MockImportUsecaseSkippedRowsCall) DoAndReturn(f func([]string) []model.
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// StageUnion mocks base method.
+func (m *MockImportUsecase) StageUnion(dest string, shards []model.UnionShard, sourceColumn bool) (model.SkippedRows, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StageUnion", dest, shards, sourceColumn)
+	ret0, _ := ret[0].(model.SkippedRows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StageUnion indicates an expected call of StageUnion.
+func (mr *MockImportUsecaseMockRecorder) StageUnion(dest, shards, sourceColumn any) *MockImportUsecaseStageUnionCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StageUnion", reflect.TypeOf((*MockImportUsecase)(nil).StageUnion), dest, shards, sourceColumn)
+	return &MockImportUsecaseStageUnionCall{Call: call}
+}
+
+// MockImportUsecaseStageUnionCall wrap *gomock.Call
+type MockImportUsecaseStageUnionCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockImportUsecaseStageUnionCall) Return(arg0 model.SkippedRows, arg1 error) *MockImportUsecaseStageUnionCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockImportUsecaseStageUnionCall) Do(f func(string, []model.UnionShard, bool) (model.SkippedRows, error)) *MockImportUsecaseStageUnionCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockImportUsecaseStageUnionCall) DoAndReturn(f func(string, []model.UnionShard, bool) (model.SkippedRows, error)) *MockImportUsecaseStageUnionCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -41,6 +41,44 @@ func (m *MockMetadataUsecase) EXPECT() *MockMetadataUsecaseMockRecorder {
 	return m.recorder
 }
 
+// ForgetTableSources mocks base method.
+func (m *MockMetadataUsecase) ForgetTableSources(ctx context.Context, tableNames []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgetTableSources", ctx, tableNames)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgetTableSources indicates an expected call of ForgetTableSources.
+func (mr *MockMetadataUsecaseMockRecorder) ForgetTableSources(ctx, tableNames any) *MockMetadataUsecaseForgetTableSourcesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgetTableSources", reflect.TypeOf((*MockMetadataUsecase)(nil).ForgetTableSources), ctx, tableNames)
+	return &MockMetadataUsecaseForgetTableSourcesCall{Call: call}
+}
+
+// MockMetadataUsecaseForgetTableSourcesCall wrap *gomock.Call
+type MockMetadataUsecaseForgetTableSourcesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockMetadataUsecaseForgetTableSourcesCall) Return(arg0 error) *MockMetadataUsecaseForgetTableSourcesCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockMetadataUsecaseForgetTableSourcesCall) Do(f func(context.Context, []string) error) *MockMetadataUsecaseForgetTableSourcesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockMetadataUsecaseForgetTableSourcesCall) DoAndReturn(f func(context.Context, []string) error) *MockMetadataUsecaseForgetTableSourcesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Header mocks base method.
 func (m *MockMetadataUsecase) Header(ctx context.Context, tableName string) (*model.Table, error) {
 	m.ctrl.T.Helper()
@@ -119,6 +157,44 @@ func (c *MockMetadataUsecaseListCall) DoAndReturn(f func(context.Context, string
 	return c
 }
 
+// RecordTableSources mocks base method.
+func (m *MockMetadataUsecase) RecordTableSources(ctx context.Context, sources []model.TableSource) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordTableSources", ctx, sources)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordTableSources indicates an expected call of RecordTableSources.
+func (mr *MockMetadataUsecaseMockRecorder) RecordTableSources(ctx, sources any) *MockMetadataUsecaseRecordTableSourcesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordTableSources", reflect.TypeOf((*MockMetadataUsecase)(nil).RecordTableSources), ctx, sources)
+	return &MockMetadataUsecaseRecordTableSourcesCall{Call: call}
+}
+
+// MockMetadataUsecaseRecordTableSourcesCall wrap *gomock.Call
+type MockMetadataUsecaseRecordTableSourcesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockMetadataUsecaseRecordTableSourcesCall) Return(arg0 error) *MockMetadataUsecaseRecordTableSourcesCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockMetadataUsecaseRecordTableSourcesCall) Do(f func(context.Context, []model.TableSource) error) *MockMetadataUsecaseRecordTableSourcesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockMetadataUsecaseRecordTableSourcesCall) DoAndReturn(f func(context.Context, []model.TableSource) error) *MockMetadataUsecaseRecordTableSourcesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SchemaObjects mocks base method.
 func (m *MockMetadataUsecase) SchemaObjects(ctx context.Context) ([]*model.Table, error) {
 	m.ctrl.T.Helper()
@@ -158,6 +234,45 @@ func (c *MockMetadataUsecaseSchemaObjectsCall) DoAndReturn(f func(context.Contex
 	return c
 }
 
+// TableSources mocks base method.
+func (m *MockMetadataUsecase) TableSources(ctx context.Context) ([]model.TableSource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TableSources", ctx)
+	ret0, _ := ret[0].([]model.TableSource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TableSources indicates an expected call of TableSources.
+func (mr *MockMetadataUsecaseMockRecorder) TableSources(ctx any) *MockMetadataUsecaseTableSourcesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TableSources", reflect.TypeOf((*MockMetadataUsecase)(nil).TableSources), ctx)
+	return &MockMetadataUsecaseTableSourcesCall{Call: call}
+}
+
+// MockMetadataUsecaseTableSourcesCall wrap *gomock.Call
+type MockMetadataUsecaseTableSourcesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockMetadataUsecaseTableSourcesCall) Return(arg0 []model.TableSource, arg1 error) *MockMetadataUsecaseTableSourcesCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockMetadataUsecaseTableSourcesCall) Do(f func(context.Context) ([]model.TableSource, error)) *MockMetadataUsecaseTableSourcesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockMetadataUsecaseTableSourcesCall) DoAndReturn(f func(context.Context) ([]model.TableSource, error)) *MockMetadataUsecaseTableSourcesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // TablesName mocks base method.
 func (m *MockMetadataUsecase) TablesName(ctx context.Context) ([]*model.Table, error) {
 	m.ctrl.T.Helper()
@@ -115,3 +115,42 @@ func (c *MockPersistenceUsecaseDumpFedWireFileCall) DoAndReturn(f func(context.C
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// SaveAsSQLite mocks base method.
+func (m *MockPersistenceUsecase) SaveAsSQLite(ctx context.Context, path string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAsSQLite", ctx, path)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveAsSQLite indicates an expected call of SaveAsSQLite.
+func (mr *MockPersistenceUsecaseMockRecorder) SaveAsSQLite(ctx, path any) *MockPersistenceUsecaseSaveAsSQLiteCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAsSQLite", reflect.TypeOf((*MockPersistenceUsecase)(nil).SaveAsSQLite), ctx, path)
+	return &MockPersistenceUsecaseSaveAsSQLiteCall{Call: call}
+}
+
+// MockPersistenceUsecaseSaveAsSQLiteCall wrap *gomock.Call
+type MockPersistenceUsecaseSaveAsSQLiteCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPersistenceUsecaseSaveAsSQLiteCall) Return(arg0 []string, arg1 error) *MockPersistenceUsecaseSaveAsSQLiteCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPersistenceUsecaseSaveAsSQLiteCall) Do(f func(context.Context, string) ([]string, error)) *MockPersistenceUsecaseSaveAsSQLiteCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPersistenceUsecaseSaveAsSQLiteCall) DoAndReturn(f func(context.Context, string) ([]string, error)) *MockPersistenceUsecaseSaveAsSQLiteCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,109 @@
+package interactor
+
+import (
+	"strings"
+
+	"github.com/nao1215/sqly/domain/sqltext"
+)
+
+// A rewrite that replaces a call with another changes the text SQLite names an
+// unaliased column after. The helpers here find the calls that are a select
+// item of their own, so the rewrite can label the column with the text the user
+// wrote, as the dialect translation does for the expressions it rewrites.
+
+// selectListEnds are the words that end a select list at their depth, and can
+// follow an unaliased select item.
+var selectListEnds = map[string]bool{
+	"FROM": true, "WHERE": true, "GROUP": true, "HAVING": true, "WINDOW": true,
+	"ORDER": true, "LIMIT": true, "UNION": true, "INTERSECT": true, "EXCEPT": true,
+	"VALUES": true,
+}
+
+// selectItems follows the words of a statement and records, per parenthesis
+// depth, whether the words at that depth are in a select list now, and the word
+// last seen there.
+type selectItems struct {
+	selectList map[int]bool
+	previous   map[int]string
+}
+
+// newSelectItems returns a selectItems that has seen no word yet.
+func newSelectItems() *selectItems {
+	return &selectItems{selectList: map[int]bool{}, previous: map[int]string{}}
+}
+
+// see records word, upper-cased, at depth and returns the word before it there.
+func (s *selectItems) see(word string, depth int) (prev string) {
+	prev = s.previous[depth]
+	s.previous[depth] = word
+	switch {
+	case word == "SELECT":
+		s.selectList[depth] = true
+	case selectListEnds[word]:
+		s.selectList[depth] = false
+	}
+	return prev
+}
+
+// inList reports whether the word last seen at depth is in a select list.
+func (s *selectItems) inList(depth int) bool {
+	return s.selectList[depth]
+}
+
+// asWritten is the alias that labels a column with text, the call as written.
+func asWritten(text string) string {
+	return ` AS "` + strings.ReplaceAll(text, `"`, `""`) + `"`
+}
+
+// standsAlone reports whether the call from tok to end is a whole select item
+// with no alias: preceded by SELECT (or its DISTINCT or ALL) or a comma, and
+// followed by a comma, a closing parenthesis, the end of the statement, or the
+// clause after the select list. prev is the word before tok at its depth.
+func standsAlone(stmt string, tok sqltext.Token, prev string, end int) bool {
+	before := strings.TrimRight(stmt[:tok.Start], " \t\r\n\f")
+	switch {
+	case strings.HasSuffix(before, ","):
+	case prev == "SELECT" || prev == "DISTINCT" || prev == "ALL":
+		if !strings.HasSuffix(strings.ToUpper(before), prev) {
+			return false
+		}
+	default:
+		return false
+	}
+	after := skipSpace(stmt, end)
+	if after >= len(stmt) || strings.ContainsRune(",);", rune(stmt[after])) {
+		return true
+	}
+	word := after
+	for word < len(stmt) && isWordByte(stmt[word]) {
+		word++
+	}
+	return selectListEnds[strings.ToUpper(stmt[after:word])]
+}
+
+// skipWord returns the offset just past word at s[i], matched in any case and
+// as a whole word, and false when it is not there.
+func skipWord(s string, i int, word string) (int, bool) {
+	end := i + len(word)
+	if end > len(s) || !strings.EqualFold(s[i:end], word) {
+		return i, false
+	}
+	if end < len(s) && isWordByte(s[end]) {
+		return i, false
+	}
+	return end, true
+}
+
+// skipSpace returns the offset of the first byte at or after i that is not
+// ASCII whitespace.
+func skipSpace(s string, i int) int {
+	for i < len(s) && strings.ContainsRune(" \t\r\n\f", rune(s[i])) {
+		i++
+	}
+	return i
+}
+
+// isWordByte reports whether c can be part of an identifier or keyword.
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -105,6 +105,22 @@ func (si *SQLite3Interactor) Header(ctx context.Context, tableName string) (*mod
 	return si.r.Header(ctx, tableName)
 }
 
+// TableSources returns the recorded source of every table the session database
+// remembers.
+func (si *SQLite3Interactor) TableSources(ctx context.Context) ([]model.TableSource, error) {
+	return si.r.TableSources(ctx)
+}
+
+// RecordTableSources stores or replaces the record of each given table.
+func (si *SQLite3Interactor) RecordTableSources(ctx context.Context, sources []model.TableSource) error {
+	return si.r.RecordTableSources(ctx, sources)
+}
+
+// ForgetTableSources drops the record of each named table.
+func (si *SQLite3Interactor) ForgetTableSources(ctx context.Context, tableNames []string) error {
+	return si.r.ForgetTableSources(ctx, tableNames)
+}
+
 // Query execute "SELECT" or "EXPLAIN" query
 func (si *SQLite3Interactor) Query(ctx context.Context, query string) (*model.Table, error) {
 	return si.r.Query(ctx, query)
@@ -122,6 +138,15 @@ func (si *SQLite3Interactor) ExecSQL(ctx context.Context, statement string) (*mo
 	if stmt == "" {
 		return nil, 0, errors.New("no executable SQL statement: " + color.CyanString(statement))
 	}
+	// PostgreSQL's ordered-set aggregates are rewritten first; the translation
+	// has no rule for WITHIN GROUP.
+	if si.Dialect() == dialect.PostgreSQL {
+		rewritten, err := rewriteWithinGroup(stmt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("translate error (%s): %w: %s", si.Dialect(), err, color.CyanString(statement))
+		}
+		stmt = rewritten
+	}
 	// Translate the user statement from the configured dialect to SQLite before
 	// classification and execution. This is a no-op for the SQLite dialect.
 	translated, err := dialect.Translate(si.Dialect(), stmt)
@@ -129,6 +154,10 @@ func (si *SQLite3Interactor) ExecSQL(ctx context.Context, statement string) (*mo
 		return nil, 0, fmt.Errorf("translate error (%s): %w: %s", si.Dialect(), err, color.CyanString(statement))
 	}
 	stmt = translated
+	// parse_date and format_date run under sqly's names. The statement is
+	// SQLite's by now, so the label a call gets is a quoted identifier under
+	// every dialect.
+	stmt = routeDateFunctions(stmt)
 	// Rewrite shorthands the engine does not accept (e.g. "TABLE name").
 	stmt = normalizeStatement(stmt)
 
@@ -167,9 +196,10 @@ func (si *SQLite3Interactor) ExecSQL(ctx context.Context, statement string) (*mo
 	return nil, affectedRows, nil
 }
 
-// LoadFiles loads multiple files or directories into the database.
-func (si *SQLite3Interactor) LoadFiles(ctx context.Context, filePaths ...string) error {
-	return si.adapter.LoadFiles(ctx, filePaths...)
+// LoadFiles loads multiple files into the database, each with what the import
+// declares for it.
+func (si *SQLite3Interactor) LoadFiles(ctx context.Context, files ...model.FileLoad) error {
+	return si.adapter.LoadFiles(ctx, files...)
 }
 
 // SkippedRows reports what the row-mismatch policy dropped for the named
@@ -178,6 +208,11 @@ func (si *SQLite3Interactor) SkippedRows(tables []string) []model.SkippedRows {
 	return si.adapter.SkippedRows(tables)
 }
 
+// StageUnion writes the shards to dest as one CSV file, aligned by column name.
+func (si *SQLite3Interactor) StageUnion(dest string, shards []model.UnionShard, sourceColumn bool) (model.SkippedRows, error) {
+	return si.adapter.StageUnion(dest, shards, sourceColumn)
+}
+
 // SetRowMismatchPolicy sets how a mismatched CSV/TSV row is handled by subsequent
 // imports.
 func (si *SQLite3Interactor) SetRowMismatchPolicy(policy model.RowMismatchPolicy) {
@@ -195,6 +230,40 @@ func (si *SQLite3Interactor) IncludeHiddenSheets() bool {
 	return si.adapter.IncludeHiddenSheets()
 }
 
+// SetXMLRecordPath sets which elements of an XML input subsequent imports read
+// as rows.
+func (si *SQLite3Interactor) SetXMLRecordPath(path string) {
+	si.adapter.SetXMLRecordPath(path)
+}
+
+// IsSQLiteFile reports whether the path names a SQLite database file.
+func (si *SQLite3Interactor) IsSQLiteFile(filePath string) bool {
+	return filesql.IsSQLiteFile(filePath)
+}
+
+// SetSQLiteTables sets the tables subsequent imports copy from a SQLite
+// database.
+func (si *SQLite3Interactor) SetSQLiteTables(tables []string) {
+	si.adapter.SetSQLiteTables(tables)
+}
+
+// SQLiteTables returns the tables an import of the SQLite database at path
+// copies.
+func (si *SQLite3Interactor) SQLiteTables(path string) ([]string, error) {
+	return si.adapter.SQLiteTables(path)
+}
+
+// SchemaSidecar returns the schema file that describes the data file at path.
+func (si *SQLite3Interactor) SchemaSidecar(path string) (string, error) {
+	return filesql.SchemaSidecar(path)
+}
+
+// SetImportCleaning sets what subsequent imports do to the values of every CSV
+// and TSV input.
+func (si *SQLite3Interactor) SetImportCleaning(cleaning model.ImportCleaning) {
+	si.adapter.SetImportCleaning(cleaning)
+}
+
 // ExcelSheets reports every sheet of the workbook at path, in workbook order,
 // and whether the workbook shows it.
 func (si *SQLite3Interactor) ExcelSheets(path string) ([]model.ExcelSheet, error) {
@@ -238,3 +307,9 @@ func (si *SQLite3Interactor) DumpACHFile(ctx context.Context, baseName, outputPa
 func (si *SQLite3Interactor) DumpFedWireFile(ctx context.Context, baseName, outputPath string) error {
 	return si.adapter.DumpFedWireFile(ctx, baseName, outputPath)
 }
+
+// SaveAsSQLite writes every table of the session into a new SQLite database at
+// path and returns the names of the tables written.
+func (si *SQLite3Interactor) SaveAsSQLite(ctx context.Context, path string) ([]string, error) {
+	return si.r.SaveAsSQLite(ctx, path)
+}
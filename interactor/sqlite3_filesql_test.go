@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/nao1215/sqly/config"
+	"github.com/nao1215/sqly/domain/model"
 	"github.com/nao1215/sqly/infrastructure/filesql"
 	"github.com/nao1215/sqly/infrastructure/memory"
 	_ "modernc.org/sqlite"
@@ -50,7 +51,7 @@ func TestSQLite3Interactor_LoadFiles(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	if err := si.LoadFiles(ctx, csvPath); err != nil {
+	if err := si.LoadFiles(ctx, model.FileLoad{Path: csvPath}); err != nil {
 		t.Fatalf("LoadFiles: %v", err)
 	}
 
@@ -83,7 +84,7 @@ func TestSQLite3Interactor_ACHImportListsOnlyItsOwnTables(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.Background()
-	if err := si.LoadFiles(ctx, achPath); err != nil {
+	if err := si.LoadFiles(ctx, model.FileLoad{Path: achPath}); err != nil {
 		t.Fatalf("LoadFiles: %v", err)
 	}
 
@@ -229,7 +230,7 @@ func TestSQLite3Interactor_LoadFiles_PreservesFilesqlSchema(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	if err := si.LoadFiles(ctx, csvPath); err != nil {
+	if err := si.LoadFiles(ctx, model.FileLoad{Path: csvPath}); err != nil {
 		t.Fatalf("LoadFiles: %v", err)
 	}
 
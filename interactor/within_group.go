@@ -0,0 +1,142 @@
+package interactor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nao1215/filesql/dialect"
+	"github.com/nao1215/sqly/domain/sqltext"
+)
+
+// PostgreSQL writes its ordered-set aggregates with the sort order outside the
+// call: percentile_cont(0.9) WITHIN GROUP (ORDER BY latency). SQLite has no
+// WITHIN GROUP, and the dialect translation passes it through to a syntax error,
+// so it is rewritten here, before the translation runs, into the call the
+// session registers: percentile_cont(latency, 0.9). The aggregate sorts its own
+// values, so the ORDER BY has nothing left to say but which column.
+
+// orderedSetAggregates are the aggregates a WITHIN GROUP is rewritten for.
+var orderedSetAggregates = map[string]bool{
+	"percentile_cont": true,
+	"percentile_disc": true,
+	"mode":            true,
+}
+
+var (
+	// withinGroupOrderBy is the start of a WITHIN GROUP's parentheses.
+	withinGroupOrderBy = regexp.MustCompile(`(?is)^ORDER\s+BY\s+(.+)$`)
+	// withinGroupNulls is a NULLS FIRST or NULLS LAST at the end of the sort
+	// key. The aggregates skip NULL, so where it would sort does not matter.
+	withinGroupNulls = regexp.MustCompile(`(?is)\s+NULLS\s+(FIRST|LAST)$`)
+	// withinGroupDirection is an ASC or DESC at the end of the sort key.
+	withinGroupDirection = regexp.MustCompile(`(?is)\s+(ASC|DESC)$`)
+)
+
+// rewriteWithinGroup rewrites each ordered-set aggregate call with a WITHIN
+// GROUP in stmt into the plain call sqly registers. A call without WITHIN
+// GROUP is left as it is.
+//
+// An unaliased select item is labeled with the text it was written as, as the
+// dialect translation labels the expressions it rewrites, so the column is
+// still called percentile_cont(0.9) WITHIN GROUP (ORDER BY latency).
+func rewriteWithinGroup(stmt string) (string, error) {
+	var (
+		b     strings.Builder
+		last  int
+		items = newSelectItems()
+	)
+	for tok := range sqltext.Tokens(stmt) {
+		if tok.Kind != sqltext.Word || tok.Start < last {
+			continue
+		}
+		word := strings.ToUpper(tok.Text(stmt))
+		prev := items.see(word, tok.Depth)
+		if !orderedSetAggregates[strings.ToLower(word)] {
+			continue
+		}
+
+		call, ok, err := readWithinGroupCall(stmt, tok)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			continue
+		}
+		b.WriteString(stmt[last:tok.Start])
+		b.WriteString(call.replacement)
+		if items.inList(tok.Depth) && standsAlone(stmt, tok, prev, call.end) {
+			b.WriteString(asWritten(stmt[tok.Start:call.end]))
+		}
+		last = call.end
+	}
+	if last == 0 {
+		return stmt, nil
+	}
+	b.WriteString(stmt[last:])
+	return b.String(), nil
+}
+
+// withinGroupCall is one ordered-set aggregate call with a WITHIN GROUP.
+type withinGroupCall struct {
+	// end is the offset just past the WITHIN GROUP's closing parenthesis.
+	end         int
+	replacement string
+}
+
+// readWithinGroupCall reads the call whose name is tok. ok is false for a call
+// with no WITHIN GROUP after it, which is left to run as written.
+func readWithinGroupCall(stmt string, tok sqltext.Token) (call withinGroupCall, ok bool, err error) {
+	name := tok.Text(stmt)
+	open := skipSpace(stmt, tok.End)
+	closing := sqltext.MatchingParen(stmt, open)
+	if closing < 0 {
+		return withinGroupCall{}, false, nil
+	}
+	i, ok := skipWord(stmt, skipSpace(stmt, closing+1), "WITHIN")
+	if !ok {
+		return withinGroupCall{}, false, nil
+	}
+	i, ok = skipWord(stmt, skipSpace(stmt, i), "GROUP")
+	if !ok {
+		return withinGroupCall{}, false, nil
+	}
+	groupOpen := skipSpace(stmt, i)
+	groupClose := sqltext.MatchingParen(stmt, groupOpen)
+	if groupClose < 0 {
+		return withinGroupCall{}, false, fmt.Errorf("%w: %s ... WITHIN GROUP needs (ORDER BY expression)", dialect.ErrInvalidSyntax, name)
+	}
+	match := withinGroupOrderBy.FindStringSubmatch(strings.TrimSpace(stmt[groupOpen+1 : groupClose]))
+	if match == nil {
+		return withinGroupCall{}, false, fmt.Errorf("%w: %s ... WITHIN GROUP needs (ORDER BY expression)", dialect.ErrInvalidSyntax, name)
+	}
+	key := withinGroupNulls.ReplaceAllString(strings.TrimSpace(match[1]), "")
+	descending := false
+	if direction := withinGroupDirection.FindStringSubmatch(key); direction != nil {
+		descending = strings.EqualFold(direction[1], "DESC")
+		key = strings.TrimSpace(key[:len(key)-len(direction[0])])
+	}
+	fraction := strings.TrimSpace(stmt[open+1 : closing])
+
+	switch strings.ToLower(name) {
+	case "mode":
+		if fraction != "" {
+			return withinGroupCall{}, false, fmt.Errorf("%w: %s() WITHIN GROUP takes no argument", dialect.ErrInvalidSyntax, name)
+		}
+		call.replacement = fmt.Sprintf("%s(%s)", name, key)
+	case "percentile_disc":
+		if descending {
+			return withinGroupCall{}, false, fmt.Errorf("%w: %s ... WITHIN GROUP (ORDER BY ... DESC) is not supported; order the values ascending", dialect.ErrUnsupportedSyntax, name)
+		}
+		call.replacement = fmt.Sprintf("%s(%s, %s)", name, key, fraction)
+	default:
+		// A continuous percentile of the values sorted downwards is the
+		// complementary one of the values sorted upwards.
+		if descending {
+			fraction = "1 - (" + fraction + ")"
+		}
+		call.replacement = fmt.Sprintf("%s(%s, %s)", name, key, fraction)
+	}
+	call.end = groupClose + 1
+	return call, true, nil
+}
@@ -0,0 +1,92 @@
+package interactor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nao1215/filesql/dialect"
+)
+
+func TestRewriteWithinGroup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "an unaliased select item keeps the text it was written as",
+			in:   "SELECT percentile_cont(0.9) WITHIN GROUP (ORDER BY latency) FROM t",
+			want: `SELECT percentile_cont(latency, 0.9) AS "percentile_cont(0.9) WITHIN GROUP (ORDER BY latency)" FROM t`,
+		},
+		{
+			name: "an alias is the caller's",
+			in:   "SELECT g, percentile_disc(0.5) within group (order by v asc nulls last) AS p FROM t GROUP BY g",
+			want: "SELECT g, percentile_disc(v, 0.5) AS p FROM t GROUP BY g",
+		},
+		{
+			name: "a descending continuous percentile is the complementary ascending one",
+			in:   "SELECT percentile_cont(0.9) WITHIN GROUP (ORDER BY v DESC) p FROM t",
+			want: "SELECT percentile_cont(v, 1 - (0.9)) p FROM t",
+		},
+		{
+			name: "mode",
+			in:   "SELECT mode() WITHIN GROUP (ORDER BY lower(name)), 1 FROM t",
+			want: `SELECT mode(lower(name)) AS "mode() WITHIN GROUP (ORDER BY lower(name))", 1 FROM t`,
+		},
+		{
+			name: "a call inside an expression is not labeled",
+			in:   "SELECT round(percentile_cont(0.5) WITHIN GROUP (ORDER BY v), 2) FROM t",
+			want: "SELECT round(percentile_cont(v, 0.5), 2) FROM t",
+		},
+		{
+			name: "a call outside the select list is not labeled",
+			in:   "SELECT g FROM t GROUP BY g HAVING percentile_cont(0.5) WITHIN GROUP (ORDER BY v) > 1",
+			want: "SELECT g FROM t GROUP BY g HAVING percentile_cont(v, 0.5) > 1",
+		},
+		{
+			name: "a call without WITHIN GROUP is left alone",
+			in:   "SELECT percentile_cont(v, 0.5), mode(v) FROM t",
+			want: "SELECT percentile_cont(v, 0.5), mode(v) FROM t",
+		},
+		{
+			name: "the words in a string are not a call",
+			in:   "SELECT 'mode() WITHIN GROUP (ORDER BY v)' FROM t",
+			want: "SELECT 'mode() WITHIN GROUP (ORDER BY v)' FROM t",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := rewriteWithinGroup(tt.in)
+			if err != nil {
+				t.Fatalf("rewriteWithinGroup(%q): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("rewriteWithinGroup(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteWithinGroup_Refuses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want error
+	}{
+		{"SELECT percentile_disc(0.5) WITHIN GROUP (ORDER BY v DESC) FROM t", dialect.ErrUnsupportedSyntax},
+		{"SELECT percentile_cont(0.5) WITHIN GROUP (v) FROM t", dialect.ErrInvalidSyntax},
+		{"SELECT mode(v) WITHIN GROUP (ORDER BY v) FROM t", dialect.ErrInvalidSyntax},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+			if _, err := rewriteWithinGroup(tt.in); !errors.Is(err, tt.want) {
+				t.Errorf("rewriteWithinGroup(%q) error = %v, want %v", tt.in, err, tt.want)
+			}
+		})
+	}
+}
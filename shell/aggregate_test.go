@@ -0,0 +1,32 @@
+package shell
+
+import (
+	"testing"
+)
+
+func TestStatisticalAggregates(t *testing.T) {
+	dir := t.TempDir()
+	path := writeCSV(t, dir, "latency.csv", "host,ms\na,\"1,200\"\na,10\na,N/A\na,30\nb,5\n")
+
+	t.Run("a TEXT column of numbers has a median", func(t *testing.T) {
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--sql",
+			"SELECT host, median(ms) AS median, mode(host) OVER () AS busiest FROM latency GROUP BY host ORDER BY host", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "host,median,busiest\na,30,a\nb,5,a\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("--dialect postgresql runs WITHIN GROUP", func(t *testing.T) {
+		stdout, stderr, err := runWithArgs(t, "--dialect", "postgresql", "--output-format", "csv", "--sql",
+			"SELECT percentile_disc(0.5) WITHIN GROUP (ORDER BY ms) FROM latency", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "percentile_disc(0.5) WITHIN GROUP (ORDER BY ms)\n10\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+}
@@ -34,7 +34,8 @@ var textImportExtensions = map[string]bool{
 }
 
 // delimitedImportExtensions are the formats with a header row and a fixed field
-// count per row, and so the only ones --row-mismatch can affect.
+// count per row, and so the only ones --row-mismatch, the CSV dialect flags, and
+// --trim, --null-values, --date-columns, and --decimal-columns can affect.
 var delimitedImportExtensions = map[string]bool{
 	model.ExtCSV: true,
 	model.ExtTSV: true,
@@ -46,6 +47,20 @@ var excelImportExtensions = map[string]bool{
 	model.ExtExcel: true,
 }
 
+// xmlImportExtensions is the one format with record elements, and so the only
+// one --xml-record can affect.
+var xmlImportExtensions = map[string]bool{
+	model.ExtXML: true,
+}
+
+// sqliteImportExtensions are the SQLite database files, the only inputs that
+// hold tables --sqlite-tables can pick from.
+var sqliteImportExtensions = map[string]bool{
+	".db":      true,
+	".sqlite":  true,
+	".sqlite3": true,
+}
+
 // validateOptionApplicability rejects an import option the user typed that
 // cannot apply to any input of this run. It runs before the import so a rejected
 // run reads no file and writes nothing.
@@ -54,6 +69,12 @@ func (s *Shell) validateOptionApplicability() error {
 		return &invocationError{Err: fmt.Errorf("--encoding %s applies to csv, tsv, ltsv, json, and jsonl inputs, and this run has none; drop the flag",
 			s.argument.Encoding)}
 	}
+	// --normalize works on the same decoded stream --encoding does, so it can
+	// reach the same formats.
+	if s.argument.IsExplicit("normalize") && !s.hasInputMatching(textImportExtensions) {
+		return &invocationError{Err: fmt.Errorf("--normalize %s applies to csv, tsv, ltsv, json, and jsonl inputs, and this run has none; drop the flag",
+			s.argument.Normalize)}
+	}
 	if s.argument.IsExplicit("row-mismatch") && !s.hasInputMatching(delimitedImportExtensions) {
 		return &invocationError{Err: fmt.Errorf("--row-mismatch %s applies to csv and tsv inputs, and this run has none; drop the flag",
 			s.argument.RowMismatch)}
@@ -67,6 +88,25 @@ func (s *Shell) validateOptionApplicability() error {
 	if s.argument.IsExplicit("include-hidden-sheets") && s.hasAnyInput() && !s.hasInputMatching(excelImportExtensions) {
 		return &invocationError{Err: errors.New("--include-hidden-sheets applies to xlsx inputs, and this run has none; drop the flag")}
 	}
+	// --xml-record is session policy in the same way, and is checked the same
+	// way.
+	if s.argument.IsExplicit("xml-record") && s.hasAnyInput() && !s.hasInputMatching(xmlImportExtensions) {
+		return &invocationError{Err: errors.New("--xml-record applies to xml inputs, and this run has none; drop the flag")}
+	}
+	// The CSV dialect and cleaning flags are session policy too: they also set
+	// how a later .import reads its csv and tsv files. --null-values and --trim
+	// are among them, though --normalize applies to every text input, because
+	// they clean values and csv and tsv are the inputs sqly splits into values
+	// itself: a JSON document loads whole into one column, and LTSV is split
+	// by filesql.
+	for _, flag := range []string{"delimiter", "quote", "comment-prefix", "skip-lines", "no-header", "null-values", "trim", "date-columns", "decimal-columns"} {
+		if s.argument.IsExplicit(flag) && s.hasAnyInput() && !s.hasInputMatching(delimitedImportExtensions) {
+			return &invocationError{Err: fmt.Errorf("--%s applies to csv and tsv inputs, and this run has none; drop the flag", flag)}
+		}
+	}
+	if s.argument.IsExplicit("sqlite-tables") && s.hasAnyInput() && !s.hasInputMatching(sqliteImportExtensions) {
+		return &invocationError{Err: errors.New("--sqlite-tables applies to sqlite database inputs (.db, .sqlite, .sqlite3), and this run has none; drop the flag")}
+	}
 	return nil
 }
 
@@ -78,7 +118,7 @@ func (s *Shell) hasAnyInput() bool {
 }
 
 // hasInputMatching reports whether any input of this run could be one of the
-// given formats. A directory or a URL is counted as a match: its contents are
+// given formats. A directory, an archive, or a URL is counted as a match: its contents are
 // not known until the import runs, and rejecting a run over what a directory
 // might not contain would be worse than letting an option go unused there.
 func (s *Shell) hasInputMatching(extensions map[string]bool) bool {
@@ -91,7 +131,7 @@ func (s *Shell) hasInputMatching(extensions map[string]bool) bool {
 		if isRemoteURL(path) {
 			return true // the server decides the format; the import reports a mismatch
 		}
-		if info, err := os.Stat(path); err != nil || info.IsDir() {
+		if info, err := os.Stat(path); err != nil || info.IsDir() || isArchiveFile(path) {
 			return true // unknown contents, or a path the import step will report
 		}
 		if extensions[importExtension(path)] {
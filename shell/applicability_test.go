@@ -21,6 +21,8 @@ func TestOptionApplicability(t *testing.T) {
 	gzipped := writeCSV(t, dir, "rows.csv.gz", "unused")
 	xlsx := writeCSV(t, dir, "book.xlsx", "not really a workbook")
 	gzippedXLSX := writeCSV(t, dir, "book.xlsx.gz", "unused")
+	xml := writeCSV(t, dir, "feed.xml", "<feed/>")
+	database := writeCSV(t, dir, "app.sqlite3", "not really a database")
 	sub := filepath.Join(dir, "nested")
 	if err := os.Mkdir(sub, 0o750); err != nil {
 		t.Fatal(err)
@@ -106,6 +108,28 @@ func TestOptionApplicability(t *testing.T) {
 			args:    []string{"--stdin-format", "csv", "--include-hidden-sheets", "--sql", "SELECT 1"},
 			wantErr: "--include-hidden-sheets",
 		},
+		{
+			name: "xml-record with an xml input is accepted",
+			args: []string{"--xml-record", "/feed/item", "--sql", "SELECT 1", xml},
+		},
+		{
+			name:    "xml-record with only a csv input is rejected",
+			args:    []string{"--xml-record", "/feed/item", "--sql", "SELECT 1", csv},
+			wantErr: "--xml-record",
+		},
+		{
+			name: "xml-record is accepted with no input at all",
+			args: []string{"--xml-record", "/feed/item"},
+		},
+		{
+			name: "sqlite-tables with a sqlite database input is accepted",
+			args: []string{"--sqlite-tables", "users", "--sql", "SELECT 1", database},
+		},
+		{
+			name:    "sqlite-tables with only a csv input is rejected",
+			args:    []string{"--sqlite-tables", "users", "--sql", "SELECT 1", csv},
+			wantErr: "--sqlite-tables",
+		},
 		{
 			name: "an option left at its default is never rejected",
 			args: []string{"--sql", "SELECT 1", parquet},
@@ -0,0 +1,389 @@
+package shell
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nao1215/filesql"
+)
+
+// An archive is read the way a directory is: every supported file in it becomes
+// its own table. A SaaS export arrives as a .zip of a dozen CSVs and a batch job
+// ships a .tar.gz bundle, and unpacking either by hand before every run is a
+// step that exists only because sqly could not do it.
+//
+// The members are extracted into a staging directory and loaded from there,
+// under a name built from the member's whole path, so `reports/q1.csv` becomes
+// the table reports_q1 and two q1.csv files in different folders do not want
+// one table. Each table's source is the member, written as the archive and the
+// member path joined by "!" (`/data/bundle.zip!reports/q1.csv`). That is what
+// --inspect and .tables report, and what a --db session records. Everything
+// that needs a file on disk — .reload, --watch, the output overwrite guard —
+// reads the archive part, so a rewritten archive is reloaded whole, the way a
+// workbook is reloaded with all its sheets.
+//
+// An archive is never written back. Rebuilding one would mean rewriting every
+// member to change one, and the members a session never read would have to
+// survive the trip byte for byte.
+
+// archiveMemberSeparator joins an archive and a member path in a table source.
+const archiveMemberSeparator = "!"
+
+// archiveFormat is one archive file suffix sqly opens, and how.
+type archiveFormat struct {
+	suffix      string
+	zip         bool
+	compression filesql.CompressionType
+}
+
+// archiveFormats lists the suffixes longest first, so ".tar.gz" is matched
+// before a shorter suffix could claim the name.
+var archiveFormats = []archiveFormat{
+	{suffix: ".tar.bz2", compression: filesql.CompressionBZ2},
+	{suffix: ".tar.zst", compression: filesql.CompressionZSTD},
+	{suffix: ".tar.gz", compression: filesql.CompressionGZ},
+	{suffix: ".tar.xz", compression: filesql.CompressionXZ},
+	{suffix: ".tbz2", compression: filesql.CompressionBZ2},
+	{suffix: ".tzst", compression: filesql.CompressionZSTD},
+	{suffix: ".tgz", compression: filesql.CompressionGZ},
+	{suffix: ".txz", compression: filesql.CompressionXZ},
+	{suffix: ".tar", compression: filesql.CompressionNone},
+	{suffix: ".zip", zip: true},
+}
+
+// archiveFormatOf returns the archive format a path names, by its suffix.
+func archiveFormatOf(p string) (archiveFormat, bool) {
+	lower := strings.ToLower(p)
+	for _, format := range archiveFormats {
+		if strings.HasSuffix(lower, format.suffix) {
+			return format, true
+		}
+	}
+	return archiveFormat{}, false
+}
+
+// isArchiveFile reports whether a path names an archive sqly opens.
+func isArchiveFile(p string) bool {
+	_, ok := archiveFormatOf(p)
+	return ok
+}
+
+// archiveMemberSource is the table source of a member of an archive.
+func archiveMemberSource(archive, member string) string {
+	return archive + archiveMemberSeparator + member
+}
+
+// splitArchiveSource splits the source of an archive member into the archive
+// and the member path. The first "!" that ends an archive name is the split, so
+// a "!" inside a member path stays in the member. A source that is not a member
+// reports false.
+func splitArchiveSource(source string) (archive, member string, ok bool) {
+	for i := 0; i < len(source); i++ {
+		j := strings.Index(source[i:], archiveMemberSeparator)
+		if j < 0 {
+			return "", "", false
+		}
+		i += j
+		if isArchiveFile(source[:i]) && i+1 < len(source) {
+			return source[:i], source[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// archiveFileOf returns the file on disk a source lives in: the archive for a
+// member, and the source itself for anything else.
+func archiveFileOf(source string) string {
+	if archive, _, ok := splitArchiveSource(source); ok {
+		return archive
+	}
+	return source
+}
+
+// isImportableFile reports whether a file name is one sqly can import, either
+// directly or as an archive of such files.
+func (s *Shell) isImportableFile(name string) bool {
+	return s.usecases.importer.IsSupportedFile(name) || isArchiveFile(name)
+}
+
+// planInput adds one file argument to the plan: an archive is expanded into
+// its members, anything else is planned as the file it is.
+func (s *Shell) planInput(ctx context.Context, plan *importPlan, cleanPath, displayPath string, fromDirectory bool) error {
+	if isArchiveFile(cleanPath) {
+		return s.planArchive(ctx, plan, cleanPath, displayPath, fromDirectory)
+	}
+	return s.planFile(ctx, plan, cleanPath, displayPath, fromDirectory)
+}
+
+// planArchive adds every supported member of an archive, each as its own
+// target. In a persistent session a member whose tables were imported from an
+// unchanged archive is set aside instead, and only the rest are extracted.
+//
+// The digest and the stamp are the archive's, shared by every member: the
+// archive is the file that changes, and a member cannot be read again without
+// reading it.
+func (s *Shell) planArchive(ctx context.Context, plan *importPlan, cleanPath, displayPath string, fromDirectory bool) error {
+	if plan.alreadyPlanned(cleanPath) {
+		return nil
+	}
+	plan.seen = append(plan.seen, cleanPath)
+
+	members, err := s.archiveMembers(cleanPath, displayPath)
+	if err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return fmt.Errorf("no supported files found in archive %s", displayPath)
+	}
+
+	var stamp *sourceStamp
+	if !isRemoteURL(displayPath) {
+		stamp = stampSource(cleanPath)
+	}
+	var digest *sourceDigest
+	if s.persistent() {
+		if digest, err = digestSource(cleanPath); err != nil {
+			return fmt.Errorf("failed to read file %s: %w", displayPath, err)
+		}
+	}
+
+	wanted := make(map[string]bool, len(members))
+	for _, member := range members {
+		source := archiveMemberSource(displayPath, member)
+		if tables, ok := s.unchangedSource(ctx, plan, source, digest); ok {
+			plan.reused = append(plan.reused, reusedSource{
+				target: importTarget{loadPath: source, displayPath: source, fromDirectory: fromDirectory, digest: digest, stamp: stamp, dialect: fileDialect(plan.dialect, member)},
+				tables: tables,
+			})
+			continue
+		}
+		if s.persistent() {
+			s.warnDiscardedEdits(ctx, plan, source)
+		}
+		wanted[member] = true
+	}
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	dir, err := os.MkdirTemp("", "sqly-archive-")
+	if err != nil {
+		return fmt.Errorf("create temp dir for %s: %w", displayPath, err)
+	}
+	plan.cleanups = append(plan.cleanups, func() { _ = os.RemoveAll(dir) })
+
+	// A local archive is as unbounded as any local file. A downloaded one is
+	// held to the download limit once more after it is unpacked, because the
+	// limit was on what the server sent, and a small archive can expand into
+	// far more than that.
+	var limit int64
+	if isRemoteURL(displayPath) {
+		limit = s.downloadLimit()
+	}
+	staged, err := s.extractArchive(cleanPath, displayPath, dir, wanted, limit)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if !wanted[member] {
+			continue
+		}
+		prepared, cleanup, err := s.prepareImportLoadPath(staged[member], archiveMemberSource(displayPath, member))
+		if err != nil {
+			return err
+		}
+		if cleanup != nil {
+			plan.cleanups = append(plan.cleanups, cleanup)
+		}
+		plan.targets = append(plan.targets, importTarget{
+			loadPath:      prepared,
+			displayPath:   archiveMemberSource(displayPath, member),
+			fromDirectory: fromDirectory,
+			digest:        digest,
+			stamp:         stamp,
+			dialect:       fileDialect(plan.dialect, member),
+		})
+	}
+	return nil
+}
+
+// archiveMembers returns the path of every member of the archive sqly would
+// import, in the order the archive stores them.
+func (s *Shell) archiveMembers(archive, displayPath string) ([]string, error) {
+	var members []string
+	err := walkArchive(archive, func(name string, _ io.Reader) error {
+		member, ok, err := s.importableMember(name, displayPath)
+		if ok {
+			members = append(members, member)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// importableMember returns the cleaned path of a member sqly would import, and
+// whether it is one. A directory, a format sqly does not read, and the
+// metadata an archiver adds beside the files (macOS's __MACOSX folder and ._
+// files, and any dot-file) are passed over. A member whose path climbs out of
+// the archive is refused: nothing sqly does would write there, but an archive
+// built that way was built to make some tool do it, and it is not an input to
+// trust.
+func (s *Shell) importableMember(name, displayPath string) (string, bool, error) {
+	member := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+	if path.IsAbs(member) || member == ".." || strings.HasPrefix(member, "../") {
+		return "", false, fmt.Errorf("archive %s has a member outside the archive, %s; it was not read", displayPath, name)
+	}
+	if strings.HasSuffix(name, "/") || member == "." {
+		return "", false, nil
+	}
+	for _, part := range strings.Split(member, "/") {
+		if part == "__MACOSX" || strings.HasPrefix(part, ".") {
+			return "", false, nil
+		}
+	}
+	if !s.usecases.importer.IsSupportedFile(member) {
+		return "", false, nil
+	}
+	return member, true, nil
+}
+
+// extractArchive writes each wanted member into dir, under a file name made
+// from its whole path, and returns where each one went. Two members whose names
+// would be the same file — "a/b_c.csv" and "a_b/c.csv", or two that differ only
+// in case — are refused rather than letting the second overwrite the first.
+//
+// A limit above zero caps the bytes written across every member.
+func (s *Shell) extractArchive(archive, displayPath, dir string, wanted map[string]bool, limit int64) (map[string]string, error) {
+	staged := make(map[string]string, len(wanted))
+	byName := make(map[string]string, len(wanted))
+	var written int64
+	err := walkArchive(archive, func(name string, body io.Reader) error {
+		member, ok, err := s.importableMember(name, displayPath)
+		if err != nil || !ok || !wanted[member] {
+			return err
+		}
+		fileName := strings.ReplaceAll(member, "/", "_")
+		if previous, taken := byName[strings.ToLower(fileName)]; taken {
+			return fmt.Errorf("archive %s has members %s and %s, which would both be read as %s; rename one of them",
+				displayPath, previous, member, fileName)
+		}
+		byName[strings.ToLower(fileName)] = member
+
+		dest := filepath.Join(dir, fileName)
+		file, err := os.Create(dest) //nolint:gosec // dest is a flattened name under a sqly-created temp dir
+		if err != nil {
+			return fmt.Errorf("create staging file for %s in %s: %w", member, displayPath, err)
+		}
+		reader := body
+		if limit > 0 {
+			// One byte past what is left, so a member that reaches the limit
+			// exactly is not mistaken for one that went over it.
+			reader = io.LimitReader(body, limit-written+1)
+		}
+		n, copyErr := io.Copy(file, reader)
+		closeErr := file.Close()
+		written += n
+		if limit > 0 && written > limit {
+			return fmt.Errorf("archive %s: its members expand past the %d byte limit on a download", displayPath, limit)
+		}
+		if err := errors.Join(copyErr, closeErr); err != nil {
+			return fmt.Errorf("extract %s from %s: %w", member, displayPath, err)
+		}
+		staged[member] = dest
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return staged, nil
+}
+
+// walkArchive calls fn for every regular file in the archive, in stored order,
+// with its name as the archive records it and a reader of its contents. The
+// reader is valid only until fn returns.
+func walkArchive(archive string, fn func(name string, body io.Reader) error) error {
+	format, ok := archiveFormatOf(archive)
+	if !ok {
+		return fmt.Errorf("%s is not an archive", archive)
+	}
+	if format.zip {
+		return walkZip(archive, fn)
+	}
+	return walkTar(archive, format.compression, fn)
+}
+
+// walkZip is walkArchive for a zip file.
+func walkZip(archive string, fn func(name string, body io.Reader) error) error {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("open archive %s: %w", archive, err)
+	}
+	defer func() { _ = reader.Close() }()
+	for _, file := range reader.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		if err := visitZipMember(file, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visitZipMember opens one zip member for fn and closes it afterwards.
+func visitZipMember(file *zip.File, fn func(name string, body io.Reader) error) error {
+	body, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", file.Name, err)
+	}
+	defer func() { _ = body.Close() }()
+	return fn(file.Name, body)
+}
+
+// walkTar is walkArchive for a tar file, compressed or not.
+func walkTar(archive string, compression filesql.CompressionType, fn func(name string, body io.Reader) error) error {
+	file, err := os.Open(archive) //nolint:gosec // the archive is a path the user asked to import
+	if err != nil {
+		return fmt.Errorf("open archive %s: %w", archive, err)
+	}
+	defer func() { _ = file.Close() }()
+	stream, closeStream, err := filesql.NewCompressionHandler(compression).CreateReader(file)
+	if err != nil {
+		return fmt.Errorf("open archive %s: %w", archive, err)
+	}
+	defer func() { _ = closeStream() }()
+
+	reader := tar.NewReader(stream)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read archive %s: %w", archive, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(header.Name, reader); err != nil {
+			return err
+		}
+	}
+}
+
+// isArchiveMember reports whether a table source is a member of an archive.
+func isArchiveMember(source string) bool {
+	_, _, ok := splitArchiveSource(source)
+	return ok
+}
@@ -0,0 +1,262 @@
+package shell
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// archiveMember is one file written into a test archive, in order.
+type archiveMember struct {
+	name, content string
+}
+
+func writeZip(t *testing.T, path string, members ...archiveMember) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, m := range members {
+		w, err := zw.Create(m.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(m.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeTarGz(t *testing.T, path string, members ...archiveMember) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, m := range members {
+		if err := tw.WriteHeader(&tar.Header{Name: m.name, Mode: 0o600, Size: int64(len(m.content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(m.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSplitArchiveSource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		source, archive, member string
+	}{
+		{"/data/bundle.zip!reports/q1.csv", "/data/bundle.zip", "reports/q1.csv"},
+		{"/data/bundle.tar.gz!a.csv", "/data/bundle.tar.gz", "a.csv"},
+		{"/data/wow!/bundle.tgz!a!b.csv", "/data/wow!/bundle.tgz", "a!b.csv"},
+		{"/data/wow!.csv", "", ""},
+		{"/data/bundle.zip", "", ""},
+	}
+	for _, tt := range tests {
+		archive, member, ok := splitArchiveSource(tt.source)
+		if ok != (tt.archive != "") || archive != tt.archive || member != tt.member {
+			t.Errorf("splitArchiveSource(%q) = %q, %q, %v; want %q, %q", tt.source, archive, member, ok, tt.archive, tt.member)
+		}
+	}
+}
+
+func TestArchiveImport(t *testing.T) {
+	t.Run("a zip's supported members become tables named after their paths", func(t *testing.T) {
+		bundle := writeZip(t, filepath.Join(t.TempDir(), "bundle.zip"),
+			archiveMember{"reports/q1.csv", "id,amount\n1,10\n2,20\n"},
+			archiveMember{"data/users.json", `[{"id":1,"name":"alice"}]`},
+			archiveMember{"README.txt", "not a table"},
+			archiveMember{"__MACOSX/reports/._q1.csv", "resource fork"},
+			archiveMember{".hidden.csv", "id\n9\n"},
+		)
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv",
+			"--sql", "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE '\\_sqly\\_%' ESCAPE '\\' ORDER BY name", bundle)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "name\ndata_users\nreports_q1\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("--inspect reports the member of a tar.gz as the source", func(t *testing.T) {
+		bundle := writeTarGz(t, filepath.Join(t.TempDir(), "bundle.tar.gz"),
+			archiveMember{"reports/q1.csv", "id,amount\n1,10\n"},
+		)
+
+		report := runInspectJSON(t, []string{"sqly", "--inspect", bundle})
+		if len(report.Tables) != 1 {
+			t.Fatalf("tables = %+v, want one", report.Tables)
+		}
+		if got, want := report.Tables[0].Source, bundle+"!reports/q1.csv"; got != want {
+			t.Errorf("source = %q, want %q", got, want)
+		}
+		if report.Tables[0].Name != "reports_q1" || report.Tables[0].RowCount != 1 {
+			t.Errorf("table = %s with %d row(s), want reports_q1 with 1", report.Tables[0].Name, report.Tables[0].RowCount)
+		}
+	})
+
+	t.Run("a member outside the archive is refused", func(t *testing.T) {
+		bundle := writeZip(t, filepath.Join(t.TempDir(), "bundle.zip"),
+			archiveMember{"../escape.csv", "id\n1\n"},
+		)
+
+		_, _, err := runWithArgs(t, "--sql", "SELECT 1", bundle)
+		var importErr *importFailedError
+		if !errors.As(err, &importErr) || !strings.Contains(err.Error(), "outside the archive") {
+			t.Errorf("Run error = %v, want the member refused", err)
+		}
+	})
+
+	t.Run("members that flatten to one table name are refused", func(t *testing.T) {
+		bundle := writeZip(t, filepath.Join(t.TempDir(), "bundle.zip"),
+			archiveMember{"a/b.csv", "id\n1\n"},
+			archiveMember{"a_b.csv", "id\n2\n"},
+		)
+
+		_, _, err := runWithArgs(t, "--sql", "SELECT 1", bundle)
+		if err == nil || !strings.Contains(err.Error(), "rename one of them") {
+			t.Errorf("Run error = %v, want the collision named", err)
+		}
+	})
+
+	t.Run("an archive without supported members fails the import", func(t *testing.T) {
+		bundle := writeZip(t, filepath.Join(t.TempDir(), "bundle.zip"),
+			archiveMember{"notes.txt", "hello"},
+		)
+
+		_, _, err := runWithArgs(t, "--sql", "SELECT 1", bundle)
+		if err == nil || !strings.Contains(err.Error(), "no supported files found in archive") {
+			t.Errorf("Run error = %v, want the empty archive reported", err)
+		}
+	})
+
+	t.Run(".save --in-place does not write into the archive", func(t *testing.T) {
+		dir := t.TempDir()
+		bundle := writeZip(t, filepath.Join(dir, "bundle.zip"),
+			archiveMember{"q1.csv", "id,amount\n1,10\n"},
+		)
+		before, err := os.ReadFile(bundle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		script := filepath.Join(dir, "save.sql")
+		writeScript(t, script, "UPDATE q1 SET amount = 11;\n.save --in-place\n")
+
+		_, stderr, err := runWithArgs(t, "--script-file", script, bundle)
+		if err == nil || !strings.Contains(stderr+err.Error(), "which sqly does not write") {
+			t.Errorf("Run error = %v (%s), want the write-back refused", err, stderr)
+		}
+		after, err := os.ReadFile(bundle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(before, after) {
+			t.Error("the archive was modified")
+		}
+	})
+
+	t.Run("--output onto the archive is refused", func(t *testing.T) {
+		bundle := writeZip(t, filepath.Join(t.TempDir(), "bundle.zip"),
+			archiveMember{"q1.csv", "id,amount\n1,10\n"},
+		)
+
+		_, _, err := runWithArgs(t, "--sql", "SELECT * FROM q1", "--output", bundle, bundle)
+		if err == nil || !strings.Contains(err.Error(), "sqly does not write archives") {
+			t.Errorf("Run error = %v, want the archive protected", err)
+		}
+	})
+}
+
+func TestImportRemoteArchive(t *testing.T) {
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	w, err := zw.Create("reports/q1.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compresses to a few hundred bytes and expands past the test limit.
+	if _, err := w.Write([]byte("id\n" + strings.Repeat("1\n", int(testDownloadLimit)))); err != nil {
+		t.Fatal(err)
+	}
+	small, err := zw.Create("small.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := small.Write([]byte("id\n1\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		_, _ = w.Write(zipped.Bytes())
+	}))
+	defer server.Close()
+
+	newRemoteShell := func(t *testing.T, limit int64) (*Shell, func()) {
+		t.Helper()
+		s, cleanup, err := newShell(t, []string{"sqly", "--allow-remote"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.httpClient.Transport = server.Client().Transport
+		s.maxDownloadBytes = limit
+		return s, cleanup
+	}
+
+	t.Run("an archive that expands past the download limit is refused", func(t *testing.T) {
+		s, cleanup := newRemoteShell(t, testDownloadLimit)
+		defer cleanup()
+
+		err := s.commands.importCommand(context.Background(), s, []string{server.URL + "/bundle.zip"})
+		if err == nil || !strings.Contains(err.Error(), "byte limit on a download") {
+			t.Errorf("importCommand error = %v, want the expansion limited", err)
+		}
+	})
+
+	t.Run("within the limit the members are imported", func(t *testing.T) {
+		s, cleanup := newRemoteShell(t, 4*testDownloadLimit)
+		defer cleanup()
+
+		if err := s.commands.importCommand(context.Background(), s, []string{server.URL + "/bundle.zip"}); err != nil {
+			t.Fatalf("importCommand: %v", err)
+		}
+		if got, want := s.tableSources["reports_q1"], server.URL+"/bundle.zip!reports/q1.csv"; got != want {
+			t.Errorf("source = %q, want %q", got, want)
+		}
+		if _, ok := s.tableSources["small"]; !ok {
+			t.Error("small.csv was not imported")
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// typesArg is the .import option that declares column types for the tables the
+// import creates.
+const typesArg = "--types"
+
+// splitTypesArg takes .import's --types option out of its arguments, so what is
+// left is the paths. It is stripped the way .save strips --follow-symlinks:
+// taking it as a path would report a file named "--types" as missing, which says
+// nothing about what was wrong.
+func splitTypesArg(argv []string) ([]string, model.ColumnTypes, error) {
+	i := slices.Index(argv, typesArg)
+	if i < 0 {
+		return argv, nil, nil
+	}
+	if i == len(argv)-1 || strings.HasPrefix(argv[i+1], "-") {
+		return nil, nil, &invocationError{Err: errors.New(".import --types requires the column types to declare, such as zip:TEXT\n" + importUsageText())}
+	}
+	types, err := model.ParseImportColumnTypes(argv[i+1])
+	if err != nil {
+		return nil, nil, &invocationError{Err: fmt.Errorf(".import --types: %w", err)}
+	}
+	if slices.Contains(argv[i+2:], typesArg) {
+		return nil, nil, &invocationError{Err: errors.New(".import --types was given twice; declare every column in one comma-separated list")}
+	}
+	return append(append([]string{}, argv[:i]...), argv[i+2:]...), types, nil
+}
+
+// declaredColumnTypes returns the types a plan declares for one table: the
+// .import --types declarations, or on a .reload the ones the table was last
+// imported with, and the session's --column-type declarations for the table.
+// Where both name a column, the .import one wins, because it was written for
+// this import and the flag was written for the whole session.
+func (s *Shell) declaredColumnTypes(plan *importPlan, table string) model.ColumnTypes {
+	declared := plan.columnTypes.ForTable(table)
+	if plan.reloading {
+		declared = s.importColumnTypes[table]
+	}
+	return mergeColumnTypes(declared, s.state.columnTypes.ForTable(table))
+}
+
+// recordedColumnTypes returns the types the table was last imported with.
+func (s *Shell) recordedColumnTypes(table string) model.ColumnTypes {
+	return mergeColumnTypes(s.importColumnTypes[table], s.state.columnTypes.ForTable(table))
+}
+
+// mergeColumnTypes appends to declared each session declaration for a column it
+// does not already name.
+func mergeColumnTypes(declared, session model.ColumnTypes) model.ColumnTypes {
+	merged := slices.Clone(declared)
+	for _, d := range session {
+		if !slices.ContainsFunc(declared, func(c model.ColumnType) bool { return strings.EqualFold(c.Column, d.Column) }) {
+			merged = append(merged, d)
+		}
+	}
+	return merged
+}
+
+// recordImportColumnTypes remembers the .import --types declarations the tables
+// were created with, so a .reload declares them again. A table imported without
+// any forgets the ones it had: importing it again is a new statement of how to
+// read it. A .reload keeps what is recorded, since that is what it applied.
+func (s *Shell) recordImportColumnTypes(plan *importPlan, tables []string) {
+	if plan.reloading {
+		return
+	}
+	for _, table := range tables {
+		declared := plan.columnTypes.ForTable(table)
+		if len(declared) == 0 {
+			delete(s.importColumnTypes, table)
+			continue
+		}
+		if s.importColumnTypes == nil {
+			s.importColumnTypes = make(map[string]model.ColumnTypes)
+		}
+		s.importColumnTypes[table] = declared
+	}
+}
+
+// checkColumnTypeTables refuses a --column-type that names a table the startup
+// import did not create. The declaration is filtered by table before it reaches
+// the importer, so a misspelt table name would otherwise be dropped without a
+// word and the column left with the type the flag was there to override.
+func (s *Shell) checkColumnTypeTables(ctx context.Context) error {
+	if len(s.state.columnTypes) == 0 {
+		return nil
+	}
+	tables, err := s.usecases.metadata.TablesName(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get table names: %w", err)
+	}
+	for _, declared := range s.state.columnTypes {
+		if !slices.ContainsFunc(tables, func(t *model.Table) bool { return strings.EqualFold(t.Name(), declared.Table) }) {
+			return &invocationError{Err: fmt.Errorf("--column-type %s names table %q, which no input created", declared, declared.Table)}
+		}
+	}
+	return nil
+}
+
+// cleanedColumn is one entry of a cleaning flag that names a column, such as
+// --decimal-columns orders.amount.
+type cleanedColumn struct {
+	flag   string
+	entry  string
+	table  string
+	column string
+}
+
+// checkCleanedColumns refuses a cleaning flag entry that names a column no
+// table of the startup import has. The import applies an entry to whichever
+// tables have the column and passes over the rest, so a misspelt name would
+// otherwise leave the column as it was read — an amount a REAL after all, or
+// dates in the layout they were written in — without a word. A TABLE.COLUMN entry for a table that has no such column is
+// refused by the import itself.
+func (s *Shell) checkCleanedColumns(ctx context.Context) error {
+	var entries []cleanedColumn
+	for _, c := range s.state.importCleaning.Dates {
+		entries = append(entries, cleanedColumn{flag: "--date-columns", entry: c.String(), table: c.Table, column: c.Column})
+	}
+	for _, c := range s.state.importCleaning.Decimals {
+		entries = append(entries, cleanedColumn{flag: "--decimal-columns", entry: c.String(), table: c.Table, column: c.Column})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	tables, err := s.usecases.metadata.TablesName(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get table names: %w", err)
+	}
+	columns := make(map[string][]string, len(tables))
+	for _, t := range tables {
+		header, err := s.usecases.metadata.Header(ctx, t.Name())
+		if err != nil {
+			return fmt.Errorf("failed to get the columns of table %q: %w", t.Name(), err)
+		}
+		for _, name := range header.Columns {
+			columns[t.Name()] = append(columns[t.Name()], name)
+		}
+	}
+	for _, e := range entries {
+		found := false
+		for table, names := range columns {
+			if e.table != "" && !strings.EqualFold(table, e.table) {
+				continue
+			}
+			if slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(name, e.column) }) {
+				found = true
+				break
+			}
+		}
+		if !found && e.table != "" {
+			return &invocationError{Err: fmt.Errorf("%s %s names table %q, which no input created", e.flag, e.entry, e.table)}
+		}
+		if !found {
+			return &invocationError{Err: fmt.Errorf("%s %s names column %q, which no input has", e.flag, e.entry, e.column)}
+		}
+	}
+	return nil
+}
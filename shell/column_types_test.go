@@ -0,0 +1,97 @@
+package shell
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestColumnTypeFlag(t *testing.T) {
+	t.Run("a declared type replaces the inferred one", func(t *testing.T) {
+		dir := t.TempDir()
+		src := writeCSV(t, dir, "users.csv", "id,zip\n1,12345\n2,98765\n")
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--column-type", "users.zip=TEXT",
+			"--sql", "SELECT DISTINCT typeof(zip) FROM users", src)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if stdout != "typeof(zip)\ntext\n" {
+			t.Errorf("stdout = %q, want zip stored as text", stdout)
+		}
+	})
+
+	t.Run("a value that cannot convert fails the import", func(t *testing.T) {
+		dir := t.TempDir()
+		src := writeCSV(t, dir, "orders.csv", "id,amount\n1,10\n2,ten\n")
+
+		_, _, err := runWithArgs(t, "--column-type", "orders.amount=REAL", "--sql", "SELECT 1", src)
+		var importErr *importFailedError
+		if !errors.As(err, &importErr) || !strings.Contains(err.Error(), `data row 2 holds "ten"`) {
+			t.Errorf("Run error = %v, want an import failure naming the row", err)
+		}
+	})
+
+	t.Run("a table no input created is refused", func(t *testing.T) {
+		dir := t.TempDir()
+		src := writeCSV(t, dir, "users.csv", "id\n1\n")
+
+		_, _, err := runWithArgs(t, "--column-type", "user.id=TEXT", "--sql", "SELECT 1", src)
+		var invocationErr *invocationError
+		if !errors.As(err, &invocationErr) || !strings.Contains(err.Error(), `names table "user"`) {
+			t.Errorf("Run error = %v, want an invocationError naming the table", err)
+		}
+	})
+}
+
+func TestImportCommand_Types(t *testing.T) {
+	t.Run("types apply to the import and are reapplied by .reload", func(t *testing.T) {
+		csvPath := filepath.Join(t.TempDir(), "users.csv")
+		writeDaily(t, csvPath, "id,zip\n1,12345\n")
+		s := newReloadShell(t)
+
+		if err := s.exec(t.Context(), ".import "+csvPath+" --types id:TEXT,zip:TEXT"); err != nil {
+			t.Fatalf(".import --types: %v", err)
+		}
+		want := "typeof(id),typeof(zip)\ntext,text\n"
+		if got := queryReloadShell(t, s, "SELECT typeof(id), typeof(zip) FROM users"); got != want {
+			t.Errorf("after .import --types got %q, want %q", got, want)
+		}
+
+		writeDaily(t, csvPath, "id,zip\n1,12345\n2,67890\n")
+		if _, err := getExecStdErrOutput(t, s.exec, ".reload users"); err != nil {
+			t.Fatalf(".reload: %v", err)
+		}
+		want = "typeof(id),typeof(zip)\ntext,text\ntext,text\n"
+		if got := queryReloadShell(t, s, "SELECT typeof(id), typeof(zip) FROM users"); got != want {
+			t.Errorf("after .reload got %q, want %q", got, want)
+		}
+
+		// Importing it again without --types is a new statement of how to read it.
+		if err := s.exec(t.Context(), ".import "+csvPath); err != nil {
+			t.Fatalf(".import: %v", err)
+		}
+		want = "typeof(id)\ninteger\n"
+		if got := queryReloadShell(t, s, "SELECT DISTINCT typeof(id) FROM users"); got != want {
+			t.Errorf("after a plain .import got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("a malformed --types is a usage error", func(t *testing.T) {
+		csvPath := filepath.Join(t.TempDir(), "users.csv")
+		writeDaily(t, csvPath, "id\n1\n")
+		s := newReloadShell(t)
+
+		for _, line := range []string{
+			".import " + csvPath + " --types",
+			".import " + csvPath + " --types id=TEXT",
+			".import --types id:TEXT",
+		} {
+			var invocationErr *invocationError
+			if err := s.exec(t.Context(), line); !errors.As(err, &invocationErr) {
+				t.Errorf("exec(%q) error = %v, want an invocationError", line, err)
+			}
+		}
+	})
+}
@@ -37,6 +37,9 @@ func NewCommands() CommandList {
 	c[schemaCommand] = command{execute: c.schemaCommand, name: schemaCommand, description: "print CREATE TABLE statement of a table"}
 	c[describeCommand] = command{execute: c.describeCommand, name: describeCommand, description: "print column information of a table"}
 	c[saveCommand] = command{execute: c.saveCommand, name: saveCommand, description: "write tables back to files: .save DIR (to a directory) or .save --in-place (overwrite sources)"}
+	c[indexCommand] = command{execute: c.indexCommand, name: indexCommand, description: "create an index on columns of a table, and again whenever the table is imported"}
+	c[reloadCommand] = command{execute: c.reloadCommand, name: reloadCommand, description: "re-read the source files of tables whose source changed on disk"}
+	c[openCommand] = command{execute: c.openCommand, name: openCommand, description: "switch the session to a SQLite database file, creating it if needed"}
 	c[dialectCommand] = command{execute: c.dialectCommand, name: dialectCommand, description: "show or set the SQL dialect for queries (sqlite, mysql, postgresql, googlesql)"}
 	return c
 }
@@ -0,0 +1,128 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// The .import options that set how its CSV and TSV files are written. They are
+// the flags of the same names, for one import: a session started on a
+// semicolon-delimited export may still .import a plain CSV, and the other way
+// round, so an .import that gives any of them reads its files with exactly
+// those, and not with the session's. Mixing the two would read a file with half
+// of one dialect and half of another, which describes no file anyone wrote.
+const (
+	delimiterArg     = "--delimiter"
+	quoteArg         = "--quote"
+	commentPrefixArg = "--comment-prefix"
+	skipLinesArg     = "--skip-lines"
+	noHeaderArg      = "--no-header"
+)
+
+// splitDialectArgs takes .import's dialect options out of its arguments, so
+// what is left is the paths. It is stripped the way splitTypesArg strips
+// --types. The dialect is nil when the import gave none, and the session's
+// applies.
+//
+// A value is taken as written, even one that starts with "-": --delimiter - is a
+// file separated by hyphens, not a missing value.
+func splitDialectArgs(argv []string) ([]string, *model.CSVDialect, error) {
+	var (
+		dialect model.CSVDialect
+		given   bool
+		rest    []string
+		seen    []string
+	)
+	for i := 0; i < len(argv); i++ {
+		option := argv[i]
+		switch option {
+		case noHeaderArg:
+			dialect.NoHeader = true
+		case delimiterArg, quoteArg, commentPrefixArg, skipLinesArg:
+			if i == len(argv)-1 {
+				return nil, nil, &invocationError{Err: fmt.Errorf(".import %s requires a value\n%s", option, importUsageText())}
+			}
+			i++
+			if err := setDialectOption(&dialect, option, argv[i]); err != nil {
+				return nil, nil, &invocationError{Err: fmt.Errorf(".import %w", err)}
+			}
+		default:
+			rest = append(rest, option)
+			continue
+		}
+		if slices.Contains(seen, option) {
+			return nil, nil, &invocationError{Err: fmt.Errorf(".import %s was given twice; an import reads its files one way", option)}
+		}
+		seen = append(seen, option)
+		given = true
+	}
+	if !given {
+		return argv, nil, nil
+	}
+	if err := dialect.Validate(); err != nil {
+		return nil, nil, &invocationError{Err: fmt.Errorf(".import %w", err)}
+	}
+	return rest, &dialect, nil
+}
+
+// setDialectOption applies one valued dialect option, parsed by the rule the
+// flag of the same name is.
+func setDialectOption(dialect *model.CSVDialect, option, value string) error {
+	var err error
+	switch option {
+	case delimiterArg:
+		dialect.Delimiter, err = model.ParseCSVDelimiter(value)
+	case quoteArg:
+		dialect.Quote, err = model.ParseCSVQuote(value)
+	case commentPrefixArg:
+		if value == "" {
+			err = errors.New("--comment-prefix requires the text a comment line starts with, such as #")
+		}
+		dialect.CommentPrefix = value
+	case skipLinesArg:
+		dialect.SkipLines, err = strconv.Atoi(value)
+		if err != nil || dialect.SkipLines < 0 {
+			err = fmt.Errorf("invalid --skip-lines %q: want 0 or more lines", value)
+		}
+	}
+	return err
+}
+
+// importDialect is the dialect an import reads its CSV and TSV files with: its
+// own, when the .import gave one, and the session's otherwise.
+func (s *Shell) importDialect(given *model.CSVDialect) model.CSVDialect {
+	if given != nil {
+		return *given
+	}
+	return s.state.csvDialect
+}
+
+// fileDialect is the dialect a file of the plan is read with: the plan's, for a
+// csv or tsv file, and none for any other. A directory import mixes formats, and
+// the dialect describes only the delimited ones.
+func fileDialect(dialect model.CSVDialect, path string) model.CSVDialect {
+	if !delimitedImportExtensions[importExtension(path)] {
+		return model.CSVDialect{}
+	}
+	return dialect
+}
+
+// recordImportDialect remembers the dialect the tables were read with, so a
+// .reload reads their source the same way and an in-place save knows the file
+// is not one it can write. A table read without one forgets any it had.
+func (s *Shell) recordImportDialect(dialect model.CSVDialect, tables []string) {
+	for _, table := range tables {
+		if dialect.IsZero() {
+			delete(s.importDialects, table)
+			continue
+		}
+		if s.importDialects == nil {
+			s.importDialects = make(map[string]model.CSVDialect)
+		}
+		s.importDialects[table] = dialect
+	}
+}
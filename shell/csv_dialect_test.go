@@ -0,0 +1,132 @@
+package shell
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nao1215/sqly/domain/model"
+)
+
+func TestSplitDialectArgs(t *testing.T) {
+	t.Parallel()
+
+	rest, dialect, err := splitDialectArgs([]string{"a.csv", "--delimiter", ";", "--no-header", "b.csv", "--skip-lines", "2"})
+	if err != nil {
+		t.Fatalf("splitDialectArgs: %v", err)
+	}
+	if want := []string{"a.csv", "b.csv"}; strings.Join(rest, " ") != strings.Join(want, " ") {
+		t.Errorf("rest = %v, want %v", rest, want)
+	}
+	if want := (model.CSVDialect{Delimiter: ';', SkipLines: 2, NoHeader: true}); dialect == nil || *dialect != want {
+		t.Errorf("dialect = %+v, want %+v", dialect, want)
+	}
+
+	if _, dialect, err := splitDialectArgs([]string{"a.csv"}); err != nil || dialect != nil {
+		t.Errorf("no options: dialect = %+v, err = %v; want nil, nil", dialect, err)
+	}
+
+	for _, argv := range [][]string{
+		{"a.csv", "--delimiter"},
+		{"a.csv", "--quote", "ab"},
+		{"a.csv", "--skip-lines", "-1"},
+		{"a.csv", "--delimiter", ";", "--delimiter", ","},
+		{"a.csv", "--delimiter", "|", "--quote", "|"},
+	} {
+		var invocation *invocationError
+		if _, _, err := splitDialectArgs(argv); !errors.As(err, &invocation) {
+			t.Errorf("splitDialectArgs(%q) error = %v, want an invocationError", argv, err)
+		}
+	}
+}
+
+func TestCSVDialectImport(t *testing.T) {
+	t.Run("the flags read a semicolon file with a preamble", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "prices.csv", "Price list\n# exported\nitem;price\nbolt;1,5\n")
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--delimiter", ";", "--skip-lines", "1", "--comment-prefix", "#",
+			"--sql", "SELECT item, price FROM prices", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "item,price\nbolt,\"1,5\"\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("an .import's options apply to its own files only", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCSV(t, dir, "raw.csv", "1|a\n2|b\n")
+		writeCSV(t, dir, "plain.csv", "id,name\n3,c\n")
+		script := filepath.Join(dir, "load.sql")
+		writeScript(t, script, ".import --delimiter | --no-header "+filepath.Join(dir, "raw.csv")+"\n"+
+			".import "+filepath.Join(dir, "plain.csv")+"\n"+
+			"SELECT (SELECT group_concat(c2) FROM raw) AS raw, (SELECT name FROM plain) AS plain;\n")
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--script-file", script)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "raw,plain\n\"a,b\",c\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("an .import's options replace the session's", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCSV(t, dir, "report.csv", "title\nid;name\n1;a\n")
+		writeCSV(t, dir, "later.csv", "id;name\n2;b\n")
+		script := filepath.Join(dir, "load.sql")
+		writeScript(t, script, ".import --delimiter ; "+filepath.Join(dir, "later.csv")+"\n"+
+			"SELECT (SELECT name FROM report) AS report, (SELECT name FROM later) AS later;\n")
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--delimiter", ";", "--skip-lines", "1",
+			"--script-file", script, filepath.Join(dir, "report.csv"))
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "report,later\na,b\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("a flag no input can use is refused", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "data.json")
+		writeScript(t, path, `{"id":1}`+"\n")
+
+		_, _, err := runWithArgs(t, "--no-header", "--sql", "SELECT 1", path)
+		var invocation *invocationError
+		if !errors.As(err, &invocation) || !strings.Contains(err.Error(), "--no-header applies to csv and tsv inputs") {
+			t.Errorf("Run error = %v, want the --no-header refusal", err)
+		}
+	})
+
+	t.Run("an in-place save refuses a table read with a dialect", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "raw.csv", "1;a\n")
+		script := filepath.Join(dir, "edit.sql")
+		writeScript(t, script, "UPDATE raw SET c2 = 'b';\n.save --in-place\n")
+
+		_, stderr, err := runWithArgs(t, "--delimiter", ";", "--no-header", "--script-file", script, path)
+		if err == nil || !strings.Contains(stderr, "was read with --delimiter ';' --no-header, which .save --in-place cannot write back") {
+			t.Errorf("Run error = %v, stderr = %q; want the write-back refusal", err, stderr)
+		}
+	})
+}
+
+func TestCSVDialectReload(t *testing.T) {
+	dir := t.TempDir()
+	path := writeCSV(t, dir, "raw.csv", "1;a\n")
+	s := newReloadShell(t, "--delimiter", ";", "--no-header", path)
+
+	writeCSV(t, dir, "raw.csv", "1;a\n2;b\n")
+	if _, err := getExecStdErrOutput(t, s.exec, ".reload raw"); err != nil {
+		t.Fatalf(".reload: %v", err)
+	}
+	if got := queryReloadShell(t, s, "SELECT group_concat(c2) AS names FROM raw"); got != "names\n\"a,b\"\n" {
+		t.Errorf("after = %q, want the file read again with its dialect", got)
+	}
+}
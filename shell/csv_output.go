@@ -0,0 +1,79 @@
+package shell
+
+import (
+	"io"
+
+	"github.com/nao1215/filesql"
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// csvLayoutSniffBytes is how much of a source an in-place save reads to learn
+// its layout. The layout is decided by the BOM and the header line, and a header
+// longer than this is taken to quote only where it must.
+const csvLayoutSniffBytes = 64 << 10
+
+// sourceCSVOutput is the layout an in-place save writes a CSV or TSV source in:
+// the one the file already has, so a round trip through sqly changes the rows
+// the session changed and nothing else. A file saved with CRLF and a BOM by
+// Excel goes back to Excel the way it came.
+//
+// The session's --csv-delimiter and the flags beside it are not applied. They
+// describe the files a run creates, and the file being replaced already answers
+// the question for itself. A source read with a --delimiter of its own is
+// written with that delimiter, and encoding is the one the file is written in.
+func sourceCSVOutput(source string, format model.ExportFormat, dialect model.CSVDialect, encoding model.TextEncoding) model.CSVOutput {
+	comma := ','
+	switch format {
+	case model.ExportCSV:
+		if dialect.Delimiter != 0 {
+			comma = dialect.Delimiter
+		}
+	case model.ExportTSV:
+		comma = '\t'
+	default:
+		return model.CSVOutput{}
+	}
+	var output model.CSVOutput
+	// Only an encoding that writes these characters as their ASCII bytes can be
+	// sniffed byte by byte; UTF-16 would need decoding first, and its writer
+	// puts back the BOM it needs on its own.
+	if encoding != model.TextEncodingUTF16LE && encoding != model.TextEncodingUTF16BE {
+		if head, err := readSourceHead(source, csvLayoutSniffBytes); err == nil {
+			output = model.DetectCSVOutput(head, comma)
+		}
+	}
+	// A UTF-8 BOM is only kept on a file written as UTF-8: a write-back in
+	// another encoding could not write one, and the file was not read as one.
+	if encoding != model.TextEncodingUTF8 {
+		output.BOM = false
+	}
+	if comma != ',' && comma != '\t' {
+		output.Delimiter = comma
+	}
+	return output
+}
+
+// readSourceHead returns the first limit bytes of a source, decompressed. A
+// source that cannot be read is written back in the default layout; the save
+// itself does not need to read it, and it reports any problem with the
+// destination.
+func readSourceHead(source string, limit int64) ([]byte, error) {
+	reader, closeReader, err := filesql.NewCompressionFactory().CreateReaderForFile(source)
+	if err != nil {
+		return nil, err
+	}
+	head, err := io.ReadAll(io.LimitReader(reader, limit))
+	if closeErr := closeReader(); err == nil {
+		err = closeErr
+	}
+	return head, err
+}
+
+// writesBackInPlace reports whether a CSV source read with dialect can be
+// written over in place. Only a dialect that changed the delimiter can: a
+// writer puts the same delimiter back, where a preamble, comment lines, an
+// unusual quote, or a missing header would be lost.
+func writesBackInPlace(dialect model.CSVDialect, source string) bool {
+	return dialect.Delimiter != 0 && dialect == model.CSVDialect{Delimiter: dialect.Delimiter} &&
+		importExtension(source) == model.ExtCSV
+}
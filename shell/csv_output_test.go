@@ -0,0 +1,71 @@
+package shell
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCSVOutputLayout(t *testing.T) {
+	t.Run("--output writes the layout the flags ask for", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "items.csv", "id,name\n1,a\n")
+		out := filepath.Join(dir, "out.csv")
+
+		if _, stderr, err := runWithArgs(t, "--csv-delimiter", "|", "--csv-quote-all", "--crlf", "--bom",
+			"--sql", "SELECT id, name FROM items", "--output", out, path); err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if got, want := readFile(t, out), "\ufeff\"id\"|\"name\"\r\n\"1\"|\"a\"\r\n"; got != want {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	})
+
+	t.Run(".dump and .save DIR write the layout too", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "items.csv", "id,name\n1,a\n")
+		dumped := filepath.Join(dir, "dumped.csv")
+		saved := filepath.Join(dir, "saved")
+		script := filepath.Join(dir, "save.sql")
+		writeScript(t, script, "UPDATE items SET name = 'b';\n.dump items "+dumped+"\n.save "+saved+"\n")
+
+		if _, stderr, err := runWithArgs(t, "--csv-delimiter", ";", "--crlf", "--script-file", script, path); err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		want := "id;name\r\n1;b\r\n"
+		if got := readFile(t, dumped); got != want {
+			t.Errorf(".dump wrote %q, want %q", got, want)
+		}
+		if got := readFile(t, filepath.Join(saved, "items.csv")); got != want {
+			t.Errorf(".save DIR wrote %q, want %q", got, want)
+		}
+	})
+
+	t.Run("an in-place save keeps the layout the source has", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "items.csv", "\ufeff\"id\",\"name\"\r\n\"1\",\"a\"\r\n")
+		script := filepath.Join(dir, "edit.sql")
+		writeScript(t, script, "UPDATE items SET name = 'b';\n.save --in-place\n")
+
+		// The session flags describe new files, so they do not reach the source.
+		if _, stderr, err := runWithArgs(t, "--csv-delimiter", "|", "--script-file", script, path); err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if got, want := readFile(t, path), "\ufeff\"id\",\"name\"\r\n\"1\",\"b\"\r\n"; got != want {
+			t.Errorf("source = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("an in-place save keeps a --delimiter the source was read with", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "items.csv", "id;name\n1;a\n")
+		script := filepath.Join(dir, "edit.sql")
+		writeScript(t, script, "UPDATE items SET name = 'b';\n.save --in-place\n")
+
+		if _, stderr, err := runWithArgs(t, "--delimiter", ";", "--script-file", script, path); err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if got, want := readFile(t, path), "id;name\n1;b\n"; got != want {
+			t.Errorf("source = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDateFunctions(t *testing.T) {
+	dir := t.TempDir()
+	path := writeCSV(t, dir, "orders.csv", "id,ordered,shipped\n1,03/14/2024,14.03.2024 10:22\n2,12/01/2023,01.12.2023 23:05\n")
+
+	t.Run("parse_date and format_date keep the names they were called by", func(t *testing.T) {
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--sql",
+			"SELECT id, parse_date(ordered, '%m/%d/%Y'), format_date(parse_date(shipped, '%d.%m.%Y %H:%M'), '%Y-%m %I%p') AS s FROM orders ORDER BY 2", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		want := "id,\"parse_date(ordered, '%m/%d/%Y')\",s\n2,2023-12-01,2023-12 11PM\n1,2024-03-14,2024-03 10AM\n"
+		if stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("GoogleSQL's argument order reads the same", func(t *testing.T) {
+		stdout, stderr, err := runWithArgs(t, "--dialect", "googlesql", "--output-format", "csv", "--sql",
+			"SELECT FORMAT_DATE('%d/%m/%Y', PARSE_DATE('%m/%d/%Y', ordered)) AS d FROM orders WHERE id = 1", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "d\n14/03/2024\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("convert_tz and date_trunc", func(t *testing.T) {
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--sql",
+			"SELECT convert_tz(parse_date(shipped, '%d.%m.%Y %H:%M'), 'Europe/Berlin', 'UTC') AS utc, date_trunc('month', parse_date(ordered, '%m/%d/%Y')) AS m FROM orders WHERE id = 1", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "utc,m\n2024-03-14 09:22:00,2024-03-01 00:00:00\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+}
+
+func TestDateColumns(t *testing.T) {
+	t.Run("the named columns load as ISO 8601", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "orders.csv", "id,ordered,shipped\n1,03/14/2024,14.03.2024 10:22\n2,12/01/2023,\n")
+
+		stdout, stderr, err := runWithArgs(t, "--date-columns", "ordered=%m/%d/%Y,orders.shipped=%d.%m.%Y %H:%M", "--output-format", "csv",
+			"--sql", "SELECT id, ordered, shipped FROM orders WHERE ordered >= '2024-01-01'", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "id,ordered,shipped\n1,2024-03-14,2024-03-14 10:22:00\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("a value the layout does not read fails the import", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "orders.csv", "id,ordered\n1,2024-03-14\n")
+
+		_, _, err := runWithArgs(t, "--date-columns", "ordered=%m/%d/%Y", "--sql", "SELECT 1", path)
+		if err == nil || !strings.Contains(err.Error(), `line 2, column ordered: "2024-03-14" does not match %m/%d/%Y`) {
+			t.Errorf("err = %v, want the line and the layout", err)
+		}
+	})
+
+	t.Run("a column no input has is refused", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "orders.csv", "id,ordered\n1,03/14/2024\n")
+
+		_, _, err := runWithArgs(t, "--date-columns", "dd=%m/%d/%Y", "--sql", "SELECT 1", path)
+		if err == nil || !strings.Contains(err.Error(), `--date-columns dd=%m/%d/%Y names column "dd", which no input has`) {
+			t.Errorf("err = %v, want the column refused", err)
+		}
+	})
+
+	t.Run("it has to apply to an input", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "orders.json", `[{"id": 1}]`)
+
+		_, _, err := runWithArgs(t, "--date-columns", "ordered=%m/%d/%Y", "--sql", "SELECT 1", path)
+		if err == nil || !strings.Contains(err.Error(), "--date-columns applies to csv and tsv inputs") {
+			t.Errorf("err = %v, want the flag refused", err)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecimalColumns(t *testing.T) {
+	t.Run("the named columns load exactly and sum exactly", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "payments.csv", "id,amount\n1,19.90\n2,12345678901234567.89\n3,0.11\n")
+
+		stdout, stderr, err := runWithArgs(t, "--decimal-columns", "amount", "--output-format", "csv", "--sql",
+			"SELECT dec_sum(amount) AS total, dec_round(dec_mul(max(amount), '0.075'), 2) AS tax FROM payments WHERE id <> 2", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "total,tax\n20.01,1.49\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("json writes a decimal column as its number, digit for digit", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "payments.csv", "id,amount\n1,19.90\n2,12345678901234567.89\n3,\n")
+
+		stdout, stderr, err := runWithArgs(t, "--decimal-columns", "payments.amount", "--output-format", "json", "--sql",
+			"SELECT id, amount FROM payments ORDER BY id", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		for _, want := range []string{`"amount":19.90`, `"amount":12345678901234567.89`, `"amount":null`} {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("stdout = %s, want it to hold %s", stdout, want)
+			}
+		}
+	})
+
+	t.Run("--column-type declares DECIMAL(p,s)", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "payments.csv", "id,amount\n1,19.9\n2,5\n")
+
+		stdout, stderr, err := runWithArgs(t, "--column-type", "payments.amount=DECIMAL(12,2)", "--output-format", "csv", "--sql",
+			"SELECT amount, (SELECT type FROM pragma_table_info('payments') WHERE name = 'amount') AS type FROM payments ORDER BY id", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "amount,type\n19.90,\"DECIMAL TEXT(12,2)\"\n5.00,\"DECIMAL TEXT(12,2)\"\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("a value that is not a decimal fails the import", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "payments.csv", "id,amount\n1,19.90\n2,N/A\n")
+
+		_, _, err := runWithArgs(t, "--decimal-columns", "amount", "--sql", "SELECT 1", path)
+		if err == nil || !strings.Contains(err.Error(), `in data row 2 "N/A" is not a decimal number`) {
+			t.Errorf("err = %v, want the row and the value", err)
+		}
+	})
+
+	t.Run("a column no input has is refused", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "payments.csv", "id,amount\n1,19.90\n")
+
+		for _, tt := range []struct{ spec, want string }{
+			{"amt", `--decimal-columns amt names column "amt", which no input has`},
+			{"refunds.amount", `--decimal-columns refunds.amount names table "refunds", which no input created`},
+		} {
+			_, _, err := runWithArgs(t, "--decimal-columns", tt.spec, "--sql", "SELECT 1", path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("--decimal-columns %s: err = %v, want %q", tt.spec, err, tt.want)
+			}
+		}
+	})
+
+	t.Run("a dec_* result is text, and json writes it as a string", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "payments.csv", "id,amount\n1,19.90\n")
+
+		stdout, stderr, err := runWithArgs(t, "--decimal-columns", "amount", "--output-format", "json", "--sql",
+			"SELECT amount, dec_add(amount, '0.10') AS total FROM payments", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := `{"amount":19.90,"total":"20.00"}`; !strings.Contains(stdout, want) {
+			t.Errorf("stdout = %s, want it to hold %s", stdout, want)
+		}
+	})
+
+	t.Run("it has to apply to an input", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "payments.json", `[{"id": 1}]`)
+
+		_, _, err := runWithArgs(t, "--decimal-columns", "amount", "--sql", "SELECT 1", path)
+		if err == nil || !strings.Contains(err.Error(), "--decimal-columns applies to csv and tsv inputs") {
+			t.Errorf("err = %v, want the flag refused", err)
+		}
+	})
+}
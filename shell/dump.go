@@ -77,12 +77,30 @@ func (c CommandList) dumpCommand(ctx context.Context, s *Shell, argv []string) e
 		return err
 	}
 	filePath := model.BuildOutputPath(userPath, exportFmt, compression)
+	// A table dumped as a script is recreated from its own definition — the
+	// declared types, NOT NULL, defaults, and primary key — rather than from types
+	// guessed at from the values, which is what a query result has to make do with.
+	// A SQLite database is written from the same script, and keeps them too.
+	if exportFmt == model.ExportSQL || exportFmt == model.ExportSQLite {
+		cols, err := s.tableColumns(ctx, tableName)
+		if err != nil {
+			return asMissingTableError(err, tableName)
+		}
+		// main.users creates users: the schema is where this session kept it,
+		// not part of the name the other database should give it.
+		_, object := s.resolveObjectName(ctx, tableName)
+		table = table.WithSQLScript(model.SQLScript{Dialect: s.state.outputDialect, Table: object, Columns: columnDefinitions(cols)})
+	}
+	if err := checkUnmatchedMasks(s.state.mask, "table "+tableName, table.WithName(tableName)); err != nil {
+		return err
+	}
+	table = table.WithCSVOutput(s.state.csvOutput).WithNullString(s.state.nullString).WithMask(s.state.mask.ForTable(tableName))
 	// Refuse a destination that aliases an imported source file, including symlink
 	// aliases. A destructive source overwrite must go through .save --in-place, not
 	// .dump, so a stray .dump cannot silently rewrite the dataset in another
 	// format.
 	if name, aliased := s.outputAliasesImportedSource(filePath); aliased {
-		return &outputPathError{Path: filePath, Err: fmt.Errorf(".dump destination %s is the source file for table %q; use .save --in-place to overwrite a source", filePath, name)}
+		return &outputPathError{Path: filePath, Err: fmt.Errorf(".dump destination %s %s", filePath, s.aliasedSourceReason(name))}
 	}
 	// The result is serialized beside the destination and moved onto it, the same
 	// way --output and .save write.
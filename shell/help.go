@@ -32,6 +32,7 @@ func helpGroups() []helpGroup {
 			{helpCommand, "show this help"},
 			{modeCommand + " MODE", "change output mode (table, vertical, csv, tsv, ltsv, json, jsonl, markdown, ...)"},
 			{dialectCommand + " [NAME]", "show or set the query dialect (sqlite, mysql, postgresql, googlesql)"},
+			{openCommand + " FILE", "keep the session in a SQLite file; unchanged inputs are not re-read"},
 			{clearCommand, "clear the terminal screen"},
 			{exitCommand, "exit sqly"},
 		}},
@@ -47,6 +48,8 @@ func helpGroups() []helpGroup {
 		}},
 		{"Import / Export", []helpLine{
 			{importCommand + " PATH...", "load files or directories into the session"},
+			{reloadCommand + " [TABLE...]", "re-read tables whose source file changed on disk"},
+			{indexCommand + " TABLE COL...", "index columns of a table; kept when it is imported again"},
 			{rowMismatchCommand + " POLICY", "CSV/TSV row whose field count differs from the header: error, skip, pad"},
 			{dumpCommand + " TABLE FILE", "export a table to a file (format follows .mode; default csv)"},
 			{saveCommand + " DIR", "write changed tables into DIR (sources untouched)"},
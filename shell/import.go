@@ -41,6 +41,25 @@ func (e *importFailedError) Error() string {
 // left behind: no table, no source record, no baseline, and no temporary file.
 // See import_plan.go for why the phases are separated.
 func (c CommandList) importCommand(ctx context.Context, s *Shell, argv []string) error {
+	// The startup inputs are paths and nothing else: a file named --types given
+	// after "--" is a file.
+	var (
+		columnTypes model.ColumnTypes
+		dialect     *model.CSVDialect
+	)
+	union := s.startupUnion()
+	if !s.importingStartupInputs {
+		var err error
+		if argv, columnTypes, err = splitTypesArg(argv); err != nil {
+			return err
+		}
+		if argv, dialect, err = splitDialectArgs(argv); err != nil {
+			return err
+		}
+		if argv, union, err = splitUnionArg(argv); err != nil {
+			return err
+		}
+	}
 	if len(argv) == 0 {
 		// A missing path argument is a command error so a batch script fails fast
 		// instead of skipping the import and exiting 0. The usage rides on the error.
@@ -51,23 +70,35 @@ func (c CommandList) importCommand(ctx context.Context, s *Shell, argv []string)
 		// than reporting it as an input sqly could not read.
 		return &invocationError{Err: errors.New(".import requires at least one file or directory path\n" + importUsageText())}
 	}
-	return s.runImport(ctx, argv, argv)
+	return s.runImport(ctx, argv, argv, columnTypes, s.importDialect(dialect), union)
 }
 
 // runImport is importCommand's body, with the labels to quote in messages kept
 // separate from the paths being resolved. They differ only for an internal
 // caller that resolves one place while the user named another.
-func (s *Shell) runImport(ctx context.Context, argv, labels []string) error {
-	plan, err := s.resolveImportPlan(ctx, argv, labels)
+func (s *Shell) runImport(ctx context.Context, argv, labels []string, columnTypes model.ColumnTypes, dialect model.CSVDialect, union *unionSpec) error {
+	plan, err := s.resolveImportPlan(ctx, argv, labels, columnTypes, dialect, union)
 	if err != nil {
 		return s.reportImportFailure(err)
 	}
 	defer plan.release()
+	return s.loadPlan(ctx, plan)
+}
 
+// loadPlan runs the phases of an import that follow resolution: preflight, one
+// load, and the bookkeeping. It is separate from runImport because .reload
+// resolves its own plan, from the sources the session already recorded, and
+// then has to load it exactly the way an import does.
+func (s *Shell) loadPlan(ctx context.Context, plan *importPlan) error {
 	claims, err := s.preflightTableNames(ctx, plan)
 	if err != nil {
 		return s.reportImportFailure(err)
 	}
+	// Every input was unchanged, so there is nothing to load.
+	if len(plan.targets) == 0 {
+		s.keepUnchangedSources(ctx, plan)
+		return nil
+	}
 
 	// The listing that decides whether the import produced anything is the one
 	// .tables and .save read, so a name one of them admits cannot be a name the
@@ -80,7 +111,11 @@ func (s *Shell) runImport(ctx context.Context, argv, labels []string) error {
 
 	// One call, one transaction. A failure here rolls the whole thing back, so
 	// the session is exactly as it was and the next line can say so plainly.
-	if err := s.usecases.importer.LoadFiles(ctx, plan.loadPaths()...); err != nil {
+	loads, err := s.fileLoads(plan)
+	if err != nil {
+		return s.reportImportFailure(err)
+	}
+	if err := s.usecases.importer.LoadFiles(ctx, loads...); err != nil {
 		return s.reportImportFailure(s.describeLoadFailure(plan, err))
 	}
 
@@ -94,10 +129,11 @@ func (s *Shell) runImport(ctx context.Context, argv, labels []string) error {
 		s.warnSkippedExcelSheets(target.loadPath, target.displayPath)
 	}
 
-	imported := s.recordImportedTables(ctx, claims, afterSet)
+	imported := s.recordImportedTables(ctx, plan, claims, afterSet)
 	if len(imported) == 0 && len(diffTableNames(after, beforeSet)) == 0 {
 		return s.reportImportFailure(importProducedNothing(plan))
 	}
+	s.keepUnchangedSources(ctx, plan)
 
 	// A successful import can change a table's columns without changing the
 	// table-name set (re-import), so drop the cached completion suggestions.
@@ -312,12 +348,18 @@ func (s *Shell) warnKeywordTableNames(names []string) {
 // back over the source, which is the moment the loss stops being recoverable.
 func (s *Shell) warnSkippedRows(names []string) {
 	for _, skipped := range s.usecases.importer.SkippedRows(names) {
-		fmt.Fprintf(s.importStatusWriter(),
-			"warning: table %q: skipped %d of %d data rows whose field count differs from the header\n",
-			skipped.Table, skipped.Count, skipped.Total)
+		s.warnSkipped(skipped)
 	}
 }
 
+// warnSkipped reports the rows one table lost. A union import counts its own,
+// because it applies the policy while staging its shards (see union.go).
+func (s *Shell) warnSkipped(skipped model.SkippedRows) {
+	fmt.Fprintf(s.importStatusWriter(),
+		"warning: table %q: skipped %d of %d data rows whose field count differs from the header\n",
+		skipped.Table, skipped.Count, skipped.Total)
+}
+
 // markDirImported records that a table came from a directory import, so
 // write-back can reject it even when its source points at a single file.
 func (s *Shell) markDirImported(name string) {
@@ -760,16 +802,34 @@ func isAllDigits(s string) bool {
 // instead of skipping the import and exiting 0.
 func importUsageText() string {
 	return "[Usage]\n" +
-		"  .import FILE_PATH(S)|DIRECTORY_PATH(S)\n" +
+		"  .import FILE_PATH(S)|DIRECTORY_PATH(S)|ARCHIVE(S)|PATTERN(S) [--types COLUMN:TYPE[,...]]\n" +
+		"          [--union TABLE] [--partitioned] [--source-file-column]\n" +
+		"          [--delimiter CHAR] [--quote CHAR] [--comment-prefix TEXT] [--skip-lines N] [--no-header]\n" +
 		"\n" +
 		"  - Quote arguments that contain spaces: .import \"my data.csv\"\n" +
 		"\n" +
-		"  - Supported file format: csv, tsv, ltsv, json, jsonl, parquet, xlsx [+compressed], ach, fed\n" +
+		"  - Supported file format: csv, tsv, ltsv, json, jsonl, xml, parquet, xlsx [+compressed], ach, fed,\n" +
+		"    db, sqlite, sqlite3\n" +
 		"  - Compression (csv/tsv/ltsv/json/jsonl/parquet/xlsx only): .gz, .bz2, .xz, .zst, .z, .snappy, .s2, .lz4\n" +
 		"  - Files and directories can be mixed in arguments\n" +
 		"  - Directories are automatically detected and all supported files are imported\n" +
+		"  - Archives (.zip, .tar, .tar.gz, .tgz, .tar.bz2, .tar.xz, .tar.zst) are unpacked and each supported\n" +
+		"    member becomes a table named after its path, such as reports/q1.csv -> reports_q1\n" +
 		"  - If import multiple files/directories, separate them with spaces\n" +
 		"  - For Excel files, each sheet the workbook shows becomes its own table (enables cross-sheet JOINs);\n" +
 		"    start sqly with --include-hidden-sheets to import the hidden ones too\n" +
-		"  - JSON/JSONL data is stored in a 'data' column; use json_extract() to query fields"
+		"  - For SQLite databases, each table is copied with its declared types and primary key;\n" +
+		"    start sqly with --sqlite-tables to copy only the tables named\n" +
+		"  - JSON/JSONL data is stored in a 'data' column; use json_extract() to query fields\n" +
+		"  - --types creates the named columns of every table the import creates with these types\n" +
+		"    instead of inferred ones, such as --types zip:TEXT,amount:REAL; TYPE is one of:\n" +
+		"    TEXT, INTEGER, REAL, NUMERIC, DATETIME. A value a numeric type cannot hold fails the import\n" +
+		"  - A pattern such as logs/*.csv imports every file it matches\n" +
+		"  - --union reads every file into the one table TABLE, matching columns by name;\n" +
+		"    --source-file-column adds a _source_file column naming the file each row came from\n" +
+		"  - --partitioned reads each directory as one Hive-style partitioned table, such as\n" +
+		"    sales/year=2024/region=eu/part-0.parquet, with each key=value directory a column\n" +
+		"  - --delimiter, --quote, --comment-prefix, --skip-lines, and --no-header set how this import's\n" +
+		"    csv and tsv files are written, such as --delimiter ';' --skip-lines 2; given any of them, the\n" +
+		"    import reads with those alone rather than the session's. --no-header names columns c1..cN"
 }
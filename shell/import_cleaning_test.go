@@ -0,0 +1,68 @@
+package shell
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportCleaning(t *testing.T) {
+	t.Run("placeholders are NULL, and --null-string writes them", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "scores.csv", "id, score \n1, 10 \n2,NA\n3,\n4,N/A\n")
+
+		stdout, stderr, err := runWithArgs(t, "--null-values", "NA,N/A", "--trim", "--null-string", "NULL", "--output-format", "csv",
+			"--sql", "SELECT id, score, typeof(score) AS type FROM scores ORDER BY id", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "id,score,type\n1,10,integer\n2,NULL,null\n3,NULL,null\n4,NULL,null\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("--normalize makes the two spellings of a name one", func(t *testing.T) {
+		dir := t.TempDir()
+		composed := writeCSV(t, dir, "a.csv", "name\nCaf\u00e9\n")
+		decomposed := writeCSV(t, dir, "b.csv", "name\nCafe\u0301\n")
+		query := "SELECT count(*) AS n FROM a JOIN b USING (name)"
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--sql", query, composed, decomposed)
+		if err != nil || stdout != "n\n0\n" {
+			t.Errorf("without --normalize: %q, %v (%s); want no match", stdout, err, stderr)
+		}
+		stdout, stderr, err = runWithArgs(t, "--normalize", "nfc", "--output-format", "csv", "--sql", query, composed, decomposed)
+		if err != nil || stdout != "n\n1\n" {
+			t.Errorf("with --normalize nfc: %q, %v (%s); want one match", stdout, err, stderr)
+		}
+	})
+
+	t.Run("an in-place save writes NULL as --null-string", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "scores.csv", "id,score\n1,10\n2,NA\n")
+		script := filepath.Join(dir, "edit.sql")
+		writeScript(t, script, "UPDATE scores SET score = 11 WHERE id = 1;\n.save --in-place\n")
+
+		if _, stderr, err := runWithArgs(t, "--null-values", "NA", "--null-string", "NA", "--script-file", script, path); err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if got, want := readFile(t, path), "id,score\n1,11\n2,NA\n"; got != want {
+			t.Errorf("source = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("a flag no input can use is refused", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "data.parquet")
+		writeScript(t, path, "")
+
+		for _, args := range [][]string{{"--trim"}, {"--null-values", "NA"}, {"--normalize", "nfc"}} {
+			_, _, err := runWithArgs(t, append(args, "--sql", "SELECT 1", path)...)
+			var invocation *invocationError
+			if !errors.As(err, &invocation) || !strings.Contains(err.Error(), args[0]+" ") {
+				t.Errorf("Run(%v) error = %v, want the %s refusal", args, err, args[0])
+			}
+		}
+	})
+}
@@ -7,6 +7,8 @@ import (
 	"unicode/utf8"
 
 	"github.com/nao1215/sqly/domain/model"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/transform"
 )
 
 // A text input that is not UTF-8 is refused, and naming a legacy encoding used
@@ -17,17 +19,20 @@ import (
 // through the door the flag opened.
 //
 // Which bytes to look at depends on the encoding, so there are two checks rather
-// than one. Shift-JIS, EUC-JP, and ISO-2022-JP cannot represent U+FFFD at all,
-// so one in the decoder's output can only have been substituted. UTF-16 can
-// represent it, so its output says nothing and the source bytes are checked
-// instead: a code unit is two bytes, and a surrogate means nothing alone.
+// than one. Shift-JIS, EUC-JP, ISO-2022-JP, Windows-1252, GBK, Big5, and EUC-KR
+// cannot represent U+FFFD at all, so one in the decoder's output can only have
+// been substituted. UTF-16 and GB18030 can represent it, so their output says
+// nothing and the source bytes are checked instead: a UTF-16 code unit is two
+// bytes and a surrogate means nothing alone, and GB18030 writes U+FFFD as one
+// particular four-byte sequence, which any other substitution is not.
 
 // newDecodeValidatingReader wraps the decoded stream so a substitution fails the
 // read, for the encodings where a U+FFFD in the output proves one. It returns
 // decoded unchanged for the others, which are checked at the source instead.
 func newDecodeValidatingReader(enc model.TextEncoding, decoded io.Reader) io.Reader {
 	switch enc {
-	case model.TextEncodingShiftJIS, model.TextEncodingEUCJP, model.TextEncodingISO2022JP:
+	case model.TextEncodingShiftJIS, model.TextEncodingEUCJP, model.TextEncodingISO2022JP,
+		model.TextEncodingWindows1252, model.TextEncodingGBK, model.TextEncodingBig5, model.TextEncodingEUCKR:
 		return &replacementDetectingReader{reader: decoded, encoding: enc}
 	default:
 		return decoded
@@ -43,6 +48,8 @@ func newSourceValidatingReader(enc model.TextEncoding, source io.Reader) io.Read
 		return &utf16ValidatingReader{reader: source, encoding: enc, littleEndian: true}
 	case model.TextEncodingUTF16BE:
 		return &utf16ValidatingReader{reader: source, encoding: enc}
+	case model.TextEncodingGB18030:
+		return &gb18030ValidatingReader{reader: source, decoder: simplifiedchinese.GB18030.NewDecoder()}
 	default:
 		return source
 	}
@@ -157,6 +164,94 @@ func (r *utf16ValidatingReader) scan(chunk []byte) error {
 	return nil
 }
 
+// gb18030Replacement is how GB18030 writes U+FFFD. The decoder returns U+FFFD
+// for these four bytes and for every sequence it has no character for, so these
+// are the only bytes a U+FFFD may come from.
+const gb18030Replacement = "\x84\x31\xa4\x37"
+
+// gb18030ValidatingReader fails the read on source bytes that are not GB18030.
+// It splits the stream into characters the way the decoder does, and decodes
+// each multi-byte one on its own to learn whether the decoder has a character
+// for it.
+type gb18030ValidatingReader struct {
+	reader  io.Reader
+	decoder transform.Transformer
+	// pending holds the start of a character a read ended inside.
+	pending []byte
+	offset  int64
+}
+
+func (r *gb18030ValidatingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if invalid := r.scan(p[:n]); invalid != nil {
+			return n, invalid
+		}
+	}
+	if errors.Is(err, io.EOF) && len(r.pending) > 0 {
+		return n, substitutionError(model.TextEncodingGB18030, r.offset)
+	}
+	return n, err
+}
+
+func (r *gb18030ValidatingReader) scan(chunk []byte) error {
+	buf := chunk
+	if len(r.pending) > 0 {
+		buf = append(r.pending, chunk...)
+	}
+	var decoded [utf8.UTFMax]byte
+	for len(buf) > 0 {
+		size := gb18030CharLen(buf)
+		if size == 0 {
+			// The rest may still become a character once more input arrives.
+			r.pending = append(r.pending[:0], buf...)
+			return nil
+		}
+		if size < 0 {
+			return substitutionError(model.TextEncodingGB18030, r.offset)
+		}
+		if size > 1 && string(buf[:size]) != gb18030Replacement {
+			n, _, err := r.decoder.Transform(decoded[:], buf[:size], true)
+			if err != nil || string(decoded[:n]) == string(utf8.RuneError) {
+				return substitutionError(model.TextEncodingGB18030, r.offset)
+			}
+		}
+		buf = buf[size:]
+		r.offset += int64(size)
+	}
+	r.pending = r.pending[:0]
+	return nil
+}
+
+// gb18030CharLen returns the length of the GB18030 character buf starts with:
+// one byte, two, or four. It returns 0 when buf ends before the character does,
+// and -1 when the bytes cannot start a character.
+func gb18030CharLen(buf []byte) int {
+	switch c0 := buf[0]; {
+	case c0 <= 0x80:
+		// ASCII, and 0x80, which the decoder reads as the euro sign.
+		return 1
+	case c0 == 0xff:
+		return -1
+	case len(buf) < 2:
+		return 0
+	}
+	switch c1 := buf[1]; {
+	case 0x40 <= c1 && c1 <= 0x7e, 0x80 <= c1 && c1 <= 0xfe:
+		return 2
+	case 0x30 <= c1 && c1 <= 0x39:
+		if len(buf) < 4 {
+			return 0
+		}
+		if buf[2] < 0x81 || buf[2] == 0xff || buf[3] < 0x30 || buf[3] > 0x39 {
+			return -1
+		}
+		return 4
+	default:
+		return -1
+	}
+}
+
 // substitutionError words the refusal. It names the encoding the run declared,
 // because that is the thing to change: the bytes are not wrong on their own,
 // they are wrong for the encoding they were read as.
@@ -10,9 +10,14 @@ import (
 
 	"github.com/nao1215/filesql"
 	"github.com/nao1215/sqly/domain/model"
+	"golang.org/x/text/encoding/charmap"
 	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
 	"golang.org/x/text/encoding/unicode"
 	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 func isTextImportPath(path string) bool {
@@ -26,8 +31,23 @@ func isTextImportPath(path string) bool {
 	}
 }
 
-func (s *Shell) prepareImportLoadPath(path string) (string, func(), error) {
-	if !isTextImportPath(path) || s.state.importEncoding == model.TextEncodingUTF8 {
+// prepareImportLoadPath returns the path to load a text input from: the file
+// itself when it is UTF-8 and --normalize is not given, or a UTF-8 copy decoded
+// from the session's encoding and brought to its normalization form. name is
+// the input as the user gave it, which is how a guess made by --encoding auto
+// refers to it.
+func (s *Shell) prepareImportLoadPath(path, name string) (string, func(), error) {
+	if !isTextImportPath(path) {
+		return path, nil, nil
+	}
+	enc := s.state.importEncoding
+	if enc == model.TextEncodingAuto {
+		var err error
+		if enc, err = s.guessImportEncoding(path, name); err != nil {
+			return "", nil, err
+		}
+	}
+	if enc == model.TextEncodingUTF8 && s.state.normalization == model.UnicodeNormalizationNone {
 		return path, nil, nil
 	}
 
@@ -59,12 +79,19 @@ func (s *Shell) prepareImportLoadPath(path string) (string, func(), error) {
 		return "", nil, fmt.Errorf("create staging file for %s: %w", path, err)
 	}
 
-	// The source is checked before the decoder for UTF-16 and the decoder's output
-	// after it for the legacy encodings; see import_decode_validate.go for why the
+	// The source is checked before the decoder for UTF-16 and GB18030 and the
+	// decoder's output after it for the other legacy encodings; see import_decode_validate.go for why the
 	// two cannot be one check.
-	validated := newSourceValidatingReader(s.state.importEncoding, reader)
-	decoded := transform.NewReader(validated, newImportDecoder(s.state.importEncoding))
-	_, copyErr := io.Copy(file, newDecodeValidatingReader(s.state.importEncoding, decoded))
+	validated := newSourceValidatingReader(enc, reader)
+	decoded := transform.NewReader(validated, newImportDecoder(enc))
+	text := newDecodeValidatingReader(enc, decoded)
+	// Normalized after decoding, because a normalization form is defined over
+	// characters, and before parsing, so column names are normalized with the
+	// values and a join across files matches what a reader sees as equal.
+	if form, ok := normalizationForm(s.state.normalization); ok {
+		text = transform.NewReader(text, form)
+	}
+	_, copyErr := io.Copy(file, text)
 	closeErr := file.Close()
 	if !readerClosed {
 		if err := cleanupReader(); err != nil {
@@ -76,7 +103,7 @@ func (s *Shell) prepareImportLoadPath(path string) (string, func(), error) {
 	}
 	if copyErr != nil {
 		cleanup()
-		return "", nil, fmt.Errorf("decode %s as %s: %w", path, s.state.importEncoding, copyErr)
+		return "", nil, fmt.Errorf("decode %s as %s: %w", path, enc, copyErr)
 	}
 	if closeErr != nil {
 		cleanup()
@@ -94,7 +121,8 @@ func (s *Shell) prepareImportLoadPath(path string) (string, func(), error) {
 // refuses them now, which is the right answer but not an actionable one on its
 // own — the reader is told a byte is invalid, not that sqly can decode the file
 // for them. Nothing here guesses which encoding it is: the bytes do not say, and
-// a wrong guess would put the corruption back in a different shape.
+// a wrong guess would put the corruption back in a different shape. A guess is
+// offered as --encoding auto, which says what it chose.
 //
 // The encoding is fixed for the session at startup, so an .import typed into a
 // running shell is told to restart with the flag rather than pointed at a
@@ -106,13 +134,30 @@ func (s *Shell) encodingAdvice(err error) string {
 		return ""
 	}
 	if s.importingStartupInputs {
-		return fmt.Sprintf("\nhint: this file is not UTF-8. If it is Shift-JIS, EUC-JP, or another legacy encoding,"+
-			" load it with %s (one of: %s), e.g. %s shift-jis.",
-			encodingFlag, model.TextEncodingHelp(), encodingFlag)
+		return fmt.Sprintf("\nhint: this file is not UTF-8. If it is Shift-JIS, Windows-1252, or another legacy encoding,"+
+			" load it with %s (one of: %s), e.g. %s shift-jis, or let %s auto guess it.",
+			encodingFlag, model.TextEncodingHelp(), encodingFlag, encodingFlag)
 	}
 	return fmt.Sprintf("\nhint: this file is not UTF-8. The encoding is chosen when sqly starts,"+
-		" so restart with %s (one of: %s), e.g. sqly %s shift-jis FILE.",
-		encodingFlag, model.TextEncodingHelp(), encodingFlag)
+		" so restart with %s (one of: %s), e.g. sqly %s shift-jis FILE, or sqly %s auto FILE to have it guessed.",
+		encodingFlag, model.TextEncodingHelp(), encodingFlag, encodingFlag)
+}
+
+// normalizationForm returns the transformer for a --normalize form, or false
+// for none.
+func normalizationForm(form model.UnicodeNormalization) (norm.Form, bool) {
+	switch form {
+	case model.UnicodeNormalizationNFC:
+		return norm.NFC, true
+	case model.UnicodeNormalizationNFD:
+		return norm.NFD, true
+	case model.UnicodeNormalizationNFKC:
+		return norm.NFKC, true
+	case model.UnicodeNormalizationNFKD:
+		return norm.NFKD, true
+	default:
+		return 0, false
+	}
 }
 
 func newImportDecoder(enc model.TextEncoding) transform.Transformer {
@@ -128,6 +173,16 @@ func newImportDecoder(enc model.TextEncoding) transform.Transformer {
 		fallback = unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder()
 	case model.TextEncodingUTF16BE:
 		fallback = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewDecoder()
+	case model.TextEncodingWindows1252:
+		fallback = charmap.Windows1252.NewDecoder()
+	case model.TextEncodingGBK:
+		fallback = simplifiedchinese.GBK.NewDecoder()
+	case model.TextEncodingGB18030:
+		fallback = simplifiedchinese.GB18030.NewDecoder()
+	case model.TextEncodingBig5:
+		fallback = traditionalchinese.Big5.NewDecoder()
+	case model.TextEncodingEUCKR:
+		fallback = korean.EUCKR.NewDecoder()
 	default:
 		fallback = transform.Nop
 	}
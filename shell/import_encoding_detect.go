@@ -0,0 +1,364 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nao1215/sqly/domain/model"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/transform"
+)
+
+// --encoding auto is for the file whose encoding the user does not know, which
+// is most legacy files: a CSV export from a Windows program says nothing about
+// its code page, and the person holding it learns which one it was by trying
+// encodings until the names stop being mojibake. sqly can do that trying for
+// them, and reports what it settled on so the guess can be checked.
+//
+// It is a guess, and it is made to be refusable rather than clever. The bytes
+// are decoded as each candidate in turn, and a candidate the bytes are not valid
+// in is dropped, which settles most files: UTF-8 is rarely valid by accident,
+// and a Western file fails every double-byte encoding at the first accented
+// letter followed by a space. What is left is ranked by how much the decoded
+// text looks like the language the encoding is for — kana for Japanese, the
+// commonest Hangul for Korean, the commonest characters for each Chinese script,
+// and accented letters inside words for Western text. Mojibake is text full of
+// characters nobody writes, so it ranks low.
+//
+// The whole file is still decoded with the same checks a named encoding gets.
+// The guess only picks the encoding; a byte it does not decode is refused as it
+// would be for --encoding shift-jis.
+
+// encodingSniffBytes is how much of a file --encoding auto reads to make its
+// guess. It is enough text for the ranking to be decided by the language rather
+// than by chance, and small enough that guessing costs nothing next to the load.
+const encodingSniffBytes = 64 << 10
+
+// encodingCandidate is an encoding --encoding auto may choose for a file that is
+// not UTF-8, and how it ranks the text the file decodes to as that encoding.
+// weight scores one non-ASCII character, given the characters either side.
+type encodingCandidate struct {
+	encoding model.TextEncoding
+	weight   func(prev, r, next rune) int
+}
+
+// legacyEncodingCandidates are tried in this order, which is also the order a
+// tie is decided in: GBK before GB18030, because a file both read the same way
+// is GBK, and Windows-1252 last, because every byte sequence it has a character
+// for is some Western text.
+var legacyEncodingCandidates = []encodingCandidate{
+	{encoding: model.TextEncodingShiftJIS, weight: japaneseWeight},
+	{encoding: model.TextEncodingEUCJP, weight: japaneseWeight},
+	{encoding: model.TextEncodingGBK, weight: chineseWeight(commonSimplifiedHan)},
+	{encoding: model.TextEncodingGB18030, weight: chineseWeight(commonSimplifiedHan)},
+	{encoding: model.TextEncodingBig5, weight: chineseWeight(commonTraditionalHan)},
+	{encoding: model.TextEncodingEUCKR, weight: koreanWeight},
+	{encoding: model.TextEncodingWindows1252, weight: westernWeight},
+}
+
+// guessImportEncoding is the encoding --encoding auto reads the file at path in.
+// name is what the user called the file, for the report and for the error. A
+// guess is reported unless it is UTF-8, which is what the file would have been
+// read as without the flag.
+func (s *Shell) guessImportEncoding(path, name string) (model.TextEncoding, error) {
+	head, truncated, err := readEncodingHead(path)
+	if err != nil {
+		return "", fmt.Errorf("open import reader for %s: %w", name, err)
+	}
+	enc, guessed, err := detectTextEncoding(head, truncated)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	if guessed && enc != model.TextEncodingUTF8 {
+		fmt.Fprintf(s.importStatusWriter(),
+			"Read %s as %s, which %s auto guessed from its bytes; if the text looks wrong, name the encoding with %s\n",
+			name, enc, encodingFlag, encodingFlag)
+	}
+	return enc, nil
+}
+
+// sourceEncoding is the encoding a save writes a table read from source in: the
+// session's --encoding, or under --encoding auto the one the source is in now.
+// A file written back in the encoding it has stays readable by whatever read it
+// before. A source that cannot be guessed, or a table with none, is written as
+// UTF-8.
+func (s *Shell) sourceEncoding(source string) model.TextEncoding {
+	if s.state.importEncoding != model.TextEncodingAuto {
+		return s.state.importEncoding
+	}
+	if source == "" || !isTextImportPath(source) {
+		return model.TextEncodingUTF8
+	}
+	head, truncated, err := readEncodingHead(source)
+	if err != nil {
+		return model.TextEncodingUTF8
+	}
+	enc, _, err := detectTextEncoding(head, truncated)
+	if err != nil {
+		return model.TextEncodingUTF8
+	}
+	return enc
+}
+
+// readEncodingHead returns the first encodingSniffBytes of a file, and whether
+// the file goes on past them.
+func readEncodingHead(path string) ([]byte, bool, error) {
+	head, err := readSourceHead(path, encodingSniffBytes+1)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(head) > encodingSniffBytes {
+		return head[:encodingSniffBytes], true, nil
+	}
+	return head, false, nil
+}
+
+// detectTextEncoding picks the encoding head is in, and reports whether that
+// was a guess. A byte-order mark is not a guess, and neither is a file of
+// nothing but ASCII, which every candidate reads the same way. truncated says
+// head is the start of a longer file, so its last line may stop partway through
+// a character.
+func detectTextEncoding(head []byte, truncated bool) (model.TextEncoding, bool, error) {
+	switch {
+	case bytes.HasPrefix(head, []byte(utf8BOM)):
+		return model.TextEncodingUTF8, false, nil
+	case bytes.HasPrefix(head, []byte{0xff, 0xfe}):
+		return model.TextEncodingUTF16LE, false, nil
+	case bytes.HasPrefix(head, []byte{0xfe, 0xff}):
+		return model.TextEncodingUTF16BE, false, nil
+	}
+	if enc, ok := utf16ByZeroBytes(head); ok {
+		return enc, true, nil
+	}
+	if truncated {
+		// A line feed is never part of a multi-byte character in any candidate,
+		// so cutting after one leaves only whole characters.
+		if end := bytes.LastIndexByte(head, '\n'); end >= 0 {
+			head = head[:end+1]
+		}
+	}
+	if utf8.Valid(head) {
+		if isISO2022JP(head) && decodesCleanly(model.TextEncodingISO2022JP, head) {
+			return model.TextEncodingISO2022JP, true, nil
+		}
+		return model.TextEncodingUTF8, !isASCII(head), nil
+	}
+
+	best, bestScore := model.TextEncoding(""), 0
+	for _, candidate := range legacyEncodingCandidates {
+		text, ok := decodeStrictly(candidate.encoding, head)
+		if !ok {
+			continue
+		}
+		if score := scoreDecodedText(text, candidate.weight); best == "" || score > bestScore {
+			best, bestScore = candidate.encoding, score
+		}
+	}
+	if best == "" {
+		names := []string{string(model.TextEncodingUTF8)}
+		for _, candidate := range legacyEncodingCandidates {
+			names = append(names, string(candidate.encoding))
+		}
+		return "", false, fmt.Errorf("%s auto cannot read it as any of %s; name its encoding with %s",
+			encodingFlag, strings.Join(names, ", "), encodingFlag)
+	}
+	return best, true, nil
+}
+
+// utf16ByZeroBytes recognizes UTF-16 without a byte-order mark by its zero
+// bytes: ASCII text in UTF-16 is every other byte zero, on the high side of
+// each code unit. No other candidate puts zero bytes in text. UTF-16 holding
+// little or no ASCII has few zeros and is not recognized; such files almost
+// always carry a byte-order mark.
+func utf16ByZeroBytes(head []byte) (model.TextEncoding, bool) {
+	units := len(head) / 2
+	if units < 2 {
+		return "", false
+	}
+	var evenZeros, oddZeros int
+	for i := 0; i < units*2; i += 2 {
+		if head[i] == 0 {
+			evenZeros++
+		}
+		if head[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	switch {
+	case oddZeros*4 >= units && evenZeros*10 < oddZeros:
+		return model.TextEncodingUTF16LE, true
+	case evenZeros*4 >= units && oddZeros*10 < evenZeros:
+		return model.TextEncodingUTF16BE, true
+	default:
+		return "", false
+	}
+}
+
+// isISO2022JP reports whether ASCII text switches into JIS X 0208, which is
+// what makes a 7-bit file ISO-2022-JP rather than ASCII.
+func isISO2022JP(head []byte) bool {
+	return bytes.Contains(head, []byte("\x1b$B")) || bytes.Contains(head, []byte("\x1b$@"))
+}
+
+func isASCII(head []byte) bool {
+	for _, b := range head {
+		if b >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// decodesCleanly reports whether head is valid in enc.
+func decodesCleanly(enc model.TextEncoding, head []byte) bool {
+	_, ok := decodeStrictly(enc, head)
+	return ok
+}
+
+// decodeStrictly decodes head as enc with the checks an import makes, and
+// reports whether they passed.
+func decodeStrictly(enc model.TextEncoding, head []byte) (string, bool) {
+	validated := newSourceValidatingReader(enc, bytes.NewReader(head))
+	decoded := transform.NewReader(validated, newImportDecoder(enc))
+	text, err := io.ReadAll(newDecodeValidatingReader(enc, decoded))
+	if err != nil {
+		return "", false
+	}
+	return string(text), true
+}
+
+// scoreDecodedText adds up weight over the non-ASCII characters of text, which
+// are the only ones the candidates disagree about.
+func scoreDecodedText(text string, weight func(prev, r, next rune) int) int {
+	runes := []rune(text)
+	score := 0
+	for i, r := range runes {
+		if r < utf8.RuneSelf {
+			continue
+		}
+		var prev, next rune
+		if i > 0 {
+			prev = runes[i-1]
+		}
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		score += weight(prev, r, next)
+	}
+	return score
+}
+
+// japaneseWeight counts kana, which Japanese text is full of and no other
+// candidate's mojibake produces much of, and the commonest kanji, which carry a
+// file of names. Other kanji count for nothing, since every double-byte
+// candidate turns arbitrary bytes into some kanji. Half-width kana count for
+// nothing either: they are real in old Shift-JIS files, but Korean bytes read
+// as Shift-JIS are made of them.
+func japaneseWeight(_, r, _ rune) int {
+	switch {
+	case r >= 0x3041 && r <= 0x30ff, strings.ContainsRune(commonJapaneseKanji, r):
+		return 2
+	case isCJKPunctuation(r):
+		return 1
+	case unicode.Is(unicode.Han, r), r >= 0xff61 && r <= 0xff9f:
+		return 0
+	default:
+		return -2
+	}
+}
+
+// chineseWeight counts the commonest characters of one Chinese script, which
+// real text and real names use constantly and mojibake almost never lands on.
+func chineseWeight(common string) func(prev, r, next rune) int {
+	return func(_, r, _ rune) int {
+		switch {
+		case strings.ContainsRune(common, r):
+			return 3
+		case isCJKPunctuation(r):
+			return 1
+		case unicode.Is(unicode.Han, r):
+			return 0
+		default:
+			return -2
+		}
+	}
+}
+
+// koreanWeight counts the Hangul syllables of KS X 1001, the 2,350 that
+// everyday Korean is written in. Code page 949 has a character for most byte
+// pairs, so Japanese and Chinese text read as Korean decodes to the rest of
+// the syllables and to hanja, which modern Korean rarely uses.
+func koreanWeight(_, r, _ rune) int {
+	switch {
+	case commonHangul()[r]:
+		return 2
+	case isCJKPunctuation(r):
+		return 1
+	case unicode.Is(unicode.Han, r):
+		return -1
+	default:
+		return -2
+	}
+}
+
+// westernWeight counts accented letters inside words. Western text has them
+// one at a time between ASCII letters, while double-byte text read as
+// Windows-1252 comes out as runs of them.
+func westernWeight(prev, r, next rune) int {
+	switch {
+	case unicode.IsLetter(r) && r < 0x250:
+		if isASCIILetter(prev) || isASCIILetter(next) {
+			return 1
+		}
+		return -1
+	case r >= 0xa0 && r <= 0xbf, r >= 0x2010 && r <= 0x203a, r == 0xd7, r == 0xf7, r == 0x20ac, r == 0x2122:
+		return 0
+	default:
+		return -2
+	}
+}
+
+func isASCIILetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+// isCJKPunctuation covers the ideographic punctuation and the full-width forms
+// all three languages write in.
+func isCJKPunctuation(r rune) bool {
+	return r >= 0x3000 && r <= 0x303f || r >= 0xff01 && r <= 0xff5e
+}
+
+// commonHangul is the set of KS X 1001 syllables, read from the rows of EUC-KR
+// that hold them.
+var commonHangul = sync.OnceValue(func() map[rune]bool {
+	set := make(map[rune]bool, 2350)
+	decoder := korean.EUCKR.NewDecoder()
+	for lead := 0xb0; lead <= 0xc8; lead++ {
+		for trail := 0xa1; trail <= 0xfe; trail++ {
+			decoded, err := decoder.Bytes([]byte{byte(lead), byte(trail)})
+			if r, _ := utf8.DecodeRune(decoded); err == nil && r != utf8.RuneError {
+				set[r] = true
+			}
+		}
+	}
+	return set
+})
+
+// commonJapaneseKanji, commonSimplifiedHan, and commonTraditionalHan are the
+// commonest characters of each writing system: those of running text, then
+// those of family and given names, which is most of what a CSV of people holds.
+const (
+	commonJapaneseKanji = "日一国人年大十二本中長出三同時政事自行社見月分議後前民生連五発間対上部東者党地合市業内相方四定今回新場金員九入選立開手米力学問高代明実円関決子動京全目表戦経通外最言氏現理調体化田当八六約主題下首意法不来作性的要用制治度務強気小七成期公持野協取都和統以機平総加山思家話世受区領多県続進正安設保改数記院女初北午指権心界支第産結百派点教報済書府活原先共得解名交資予川向際" +
+		"佐藤鈴木渡辺伊村松井林清池橋阿森石島岡崎浦吉藤宮坂斎谷小沢和久恵美子郎太雄夫彦介之江真由香"
+	commonSimplifiedHan = "的一是不了在人有我他这个们中来上大为和国地到以说时要就出会可也你对生能而子那得于着下自之年过发后作里用道行所然家种事成方多经么去法学如都同现当没动面起看定天分还进好小部其些主样理心她本前开但因只从想实日" +
+		"王李张刘陈杨黄赵吴周徐孙马朱胡郭何高林罗郑梁谢宋唐许韩冯邓曹彭曾萧田董袁潘蒋蔡余杜叶程苏魏吕丁任沈姚卢姜崔钟谭陆汪范金石廖贾夏韦方白邹孟熊秦邱江尹薛段雷侯龙史陶黎贺顾毛郝龚邵万钱严武戴莫孔汤" +
+		"伟芳娜敏静丽强磊军洋勇艳杰娟涛明超秀霞平刚桂英华玉兰红建文辉力"
+	commonTraditionalHan = "的一是不了在人有我他這個們中來上大為和國地到以說時要就出會可也你對生能而子那得於著下自之年過發後作裡用道行所然家種事成方多經麼去法學如都同現當沒動面起看定天分還進好小部其些主樣理心她本前開但因只從想實日" +
+		"王李張劉陳楊黃趙吳周徐孫馬朱胡郭何高林羅鄭梁謝宋唐許韓馮鄧曹彭曾蕭田董袁潘蔣蔡余杜葉程蘇魏呂丁任沈姚盧姜崔鍾譚陸汪范金石廖賈夏韋方白鄒孟熊秦邱江尹薛段雷侯龍史陶黎賀顧毛郝龔邵萬錢嚴武戴莫孔湯" +
+		"偉芳娜敏靜麗強磊軍洋勇豔傑娟濤明超秀霞平剛桂英華玉蘭紅建文輝力美怡婷雅慧"
+)
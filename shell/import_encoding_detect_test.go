@@ -0,0 +1,127 @@
+package shell
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nao1215/sqly/domain/model"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// TestDetectTextEncoding checks --encoding auto against a short file in each
+// encoding it can choose. The files are the size of a small export, which is
+// the hard case: a large file gives the ranking far more to go on.
+func TestDetectTextEncoding(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name     string
+		encoding encoding.Encoding
+		text     string
+		want     model.TextEncoding
+	}{
+		{name: "shift-jis", encoding: japanese.ShiftJIS, text: "id,name,city\n1,山田太郎,東京都\n2,すずき,なごや\n", want: model.TextEncodingShiftJIS},
+		{name: "euc-jp", encoding: japanese.EUCJP, text: "id,name,city\n1,山田太郎,東京都\n2,すずき,なごや\n", want: model.TextEncodingEUCJP},
+		{name: "iso-2022-jp", encoding: japanese.ISO2022JP, text: "id,name\n1,山田太郎\n", want: model.TextEncodingISO2022JP},
+		{name: "gbk", encoding: simplifiedchinese.GBK, text: "id,name,note\n1,张伟,我们的产品是中国最好的\n2,王芳,他在上海工作\n", want: model.TextEncodingGBK},
+		{name: "gb18030", encoding: simplifiedchinese.GB18030, text: "id,name,note\n1,张伟,我们的产品是中国最好的𠀀\n", want: model.TextEncodingGB18030},
+		{name: "big5", encoding: traditionalchinese.Big5, text: "id,name,note\n1,陳大文,我們的產品是台灣最好的\n2,林小美,他在台北工作\n", want: model.TextEncodingBig5},
+		{name: "euc-kr", encoding: korean.EUCKR, text: "id,name,city\n1,김철수,서울특별시\n2,이영희,부산광역시\n", want: model.TextEncodingEUCKR},
+		{name: "windows-1252", encoding: charmap.Windows1252, text: "id,name,city\n1,Renée Müller,Zürich\n2,François Lefèvre,Besançon\n", want: model.TextEncodingWindows1252},
+		{name: "utf-16le without a BOM", encoding: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), text: "id,name\n1,Zoë\n", want: model.TextEncodingUTF16LE},
+		{name: "utf-16be without a BOM", encoding: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), text: "id,name\n1,Zoë\n", want: model.TextEncodingUTF16BE},
+		{name: "utf-8", encoding: unicode.UTF8, text: "id,name\n1,山田太郎\n", want: model.TextEncodingUTF8},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			head, err := tt.encoding.NewEncoder().Bytes([]byte(tt.text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, guessed, err := detectTextEncoding(head, false)
+			if err != nil || got != tt.want || !guessed {
+				t.Errorf("detectTextEncoding = %s, guessed %v, %v; want %s, guessed", got, guessed, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectTextEncoding_NotAGuess(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name string
+		head string
+		want model.TextEncoding
+	}{
+		{name: "ascii", head: "id,name\n1,a\n", want: model.TextEncodingUTF8},
+		{name: "utf-8 BOM", head: "\ufeffid\n", want: model.TextEncodingUTF8},
+		{name: "utf-16le BOM", head: "\xff\xfei\x00", want: model.TextEncodingUTF16LE},
+		{name: "utf-16be BOM", head: "\xfe\xff\x00i", want: model.TextEncodingUTF16BE},
+	} {
+		got, guessed, err := detectTextEncoding([]byte(tt.head), false)
+		if err != nil || got != tt.want || guessed {
+			t.Errorf("%s: detectTextEncoding = %s, guessed %v, %v; want %s, not guessed", tt.name, got, guessed, err, tt.want)
+		}
+	}
+}
+
+func TestDetectTextEncoding_Refusals(t *testing.T) {
+	t.Parallel()
+
+	// 0x81 then a line feed is half a character in every double-byte candidate,
+	// and Windows-1252 has no character for 0x81.
+	if got, _, err := detectTextEncoding([]byte("a\n\x81\n"), false); err == nil || !strings.Contains(err.Error(), "name its encoding with --encoding") {
+		t.Errorf("detectTextEncoding = %s, %v; want a refusal", got, err)
+	}
+
+	// The start of a longer file may end partway through a character, which is
+	// not a reason to refuse it.
+	head, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte("id,name\n1,すずき\n2,さとう\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _, err := detectTextEncoding(head[:len(head)-2], true); err != nil || got != model.TextEncodingShiftJIS {
+		t.Errorf("truncated detectTextEncoding = %s, %v; want shift-jis", got, err)
+	}
+}
+
+// TestEncodingAuto checks a run with --encoding auto end to end: the guess is
+// reported and used, and a save writes the file back in the encoding it had.
+func TestEncodingAuto(t *testing.T) {
+	dir := t.TempDir()
+	content, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("id,name\n1,张伟\n2,王芳\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "people.csv")
+	writeScript(t, path, string(content))
+	script := filepath.Join(dir, "edit.sql")
+	writeScript(t, script, "UPDATE people SET name = '李娜' WHERE id = 1;\nSELECT name FROM people ORDER BY id;\n.save --in-place\n")
+
+	stdout, stderr, err := runWithArgs(t, "--encoding", "auto", "--output-format", "csv", "--script-file", script, path)
+	if err != nil {
+		t.Fatalf("Run: %v (%s)", err, stderr)
+	}
+	if !strings.Contains(stderr, "Read "+path+" as gbk") {
+		t.Errorf("stderr = %q, want the guess reported", stderr)
+	}
+	if !strings.Contains(stdout, "name\n李娜\n王芳\n") {
+		t.Errorf("stdout = %q, want the names decoded", stdout)
+	}
+	want, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("id,name\n1,李娜\n2,王芳\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); !bytes.Equal([]byte(got), want) {
+		t.Errorf("saved file = %q, want it written back as GBK", got)
+	}
+}
@@ -223,7 +223,7 @@ func TestPrepareImportLoadPathDecodesTextInput(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if got, cleanupStaged, err := s.prepareImportLoadPath(path); err != nil || got == "" || cleanupStaged == nil {
+	if got, cleanupStaged, err := s.prepareImportLoadPath(path, path); err != nil || got == "" || cleanupStaged == nil {
 		t.Fatalf("prepareImportLoadPath() = %q, %v, cleanup=%v", got, err, cleanupStaged != nil)
 	} else {
 		defer cleanupStaged()
@@ -236,7 +236,7 @@ func TestPrepareImportLoadPathDecodesTextInput(t *testing.T) {
 		}
 	}
 
-	if got, cleanupStaged, err := s.prepareImportLoadPath("data.xlsx"); err != nil || got != "data.xlsx" || cleanupStaged != nil {
+	if got, cleanupStaged, err := s.prepareImportLoadPath("data.xlsx", "data.xlsx"); err != nil || got != "data.xlsx" || cleanupStaged != nil {
 		t.Errorf("non-text input = %q, cleanup=%v, err=%v", got, cleanupStaged != nil, err)
 	}
 
@@ -245,10 +245,10 @@ func TestPrepareImportLoadPathDecodesTextInput(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer utf8Cleanup()
-	if got, cleanupStaged, err := utf8Shell.prepareImportLoadPath(path); err != nil || got != path || cleanupStaged != nil {
+	if got, cleanupStaged, err := utf8Shell.prepareImportLoadPath(path, path); err != nil || got != path || cleanupStaged != nil {
 		t.Errorf("UTF-8 input = %q, cleanup=%v, err=%v", got, cleanupStaged != nil, err)
 	}
-	if _, _, err := s.prepareImportLoadPath(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+	if _, _, err := s.prepareImportLoadPath(filepath.Join(t.TempDir(), "missing.csv"), "missing.csv"); err == nil {
 		t.Error("prepareImportLoadPath(missing file) returned nil error")
 	}
 }
@@ -276,6 +276,16 @@ func TestPrepareImportLoadPathRefusesBytesTheEncodingCannotDecode(t *testing.T)
 		{name: "utf-16le odd length", encoding: "utf-16le", content: []byte("a\x00\n\x00\x41")},
 		// A high surrogate with nothing after it is not a character.
 		{name: "utf-16le unpaired surrogate", encoding: "utf-16le", content: []byte("a\x00\n\x00\x00\xd8\x41\x00")},
+		// Windows-1252 leaves five bytes without a character.
+		{name: "windows-1252 undefined byte", encoding: "windows-1252", content: []byte("a\n\x81\n")},
+		// A double-byte lead followed by a line feed is half a character.
+		{name: "gbk lead byte", encoding: "gbk", content: []byte("a\n\xb0\n")},
+		{name: "big5 lead byte", encoding: "big5", content: []byte("a\n\xa4\n")},
+		{name: "euc-kr user-defined row", encoding: "euc-kr", content: []byte("a\n\xc9\xa1\n")},
+		// GB18030 can write U+FFFD, so these are caught at the source: a four-byte
+		// sequence past the last character, and one cut short at the end.
+		{name: "gb18030 unassigned sequence", encoding: "gb18030", content: []byte("a\n\xfe\x39\xfe\x39\n")},
+		{name: "gb18030 truncated sequence", encoding: "gb18030", content: []byte("a\n\x81\x30")},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			s, cleanup, err := newShell(t, []string{"sqly", "--encoding", test.encoding})
@@ -289,7 +299,7 @@ func TestPrepareImportLoadPathRefusesBytesTheEncodingCannotDecode(t *testing.T)
 				t.Fatal(err)
 			}
 
-			staged, cleanupStaged, err := s.prepareImportLoadPath(path)
+			staged, cleanupStaged, err := s.prepareImportLoadPath(path, path)
 			if cleanupStaged != nil {
 				cleanupStaged()
 			}
@@ -319,7 +329,7 @@ func TestPrepareImportLoadPathKeepsAGenuineReplacementCharacter(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	staged, cleanupStaged, err := s.prepareImportLoadPath(path)
+	staged, cleanupStaged, err := s.prepareImportLoadPath(path, path)
 	if err != nil {
 		t.Fatalf("prepareImportLoadPath() error = %v, want a file holding U+FFFD to load", err)
 	}
@@ -331,6 +341,25 @@ func TestPrepareImportLoadPathKeepsAGenuineReplacementCharacter(t *testing.T) {
 	if !strings.Contains(string(decoded), "�") {
 		t.Errorf("staged content = %q, want the replacement character the file holds", decoded)
 	}
+
+	gb18030, gbCleanup, err := newShell(t, []string{"sqly", "--encoding", "gb18030"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gbCleanup()
+	path = filepath.Join(t.TempDir(), "fffd-gb18030.csv")
+	// GB18030 writes U+FFFD as these four bytes.
+	if err := os.WriteFile(path, []byte("a\n\x84\x31\xa4\x37\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	staged, gbStagedCleanup, err := gb18030.prepareImportLoadPath(path, path)
+	if err != nil {
+		t.Fatalf("prepareImportLoadPath(gb18030) error = %v, want a file holding U+FFFD to load", err)
+	}
+	defer gbStagedCleanup()
+	if decoded, err := os.ReadFile(staged); err != nil || string(decoded) != "a\n\ufffd\n" { //nolint:gosec // staged is a path sqly created
+		t.Errorf("staged gb18030 content = %q, %v; want the replacement character the file holds", decoded, err)
+	}
 }
 
 type roundTripFunc func(*http.Request) (*http.Response, error)
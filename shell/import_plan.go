@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"slices"
 	"strings"
+
+	"github.com/nao1215/sqly/domain/model"
 )
 
 // An import of several inputs is one operation, not a sequence of them.
@@ -43,6 +46,32 @@ type importTarget struct {
 	// fromDirectory marks a file found by expanding a directory argument, so
 	// write-back can keep refusing it: a directory is not one editable source.
 	fromDirectory bool
+	// digest is the file as it was read, taken before the load so a file
+	// rewritten during the import is not recorded as the one loaded. Only a
+	// persistent session takes it (see sessiondb.go); nil otherwise.
+	digest *sourceDigest
+	// stamp is the file's size and modification time, taken before the load for
+	// the same reason as digest. A download or a stdin dataset has none: there is
+	// no local file for .reload to compare it with later.
+	stamp *sourceStamp
+	// sourcePath is the local file the input was read from, beside which its
+	// schema sidecar is looked for. It is empty for a download and a stdin
+	// dataset, which have no directory of their own.
+	sourcePath string
+	// dialect is how the file is written, when it is a csv or tsv file read
+	// with dialect options, or a union whose shards were. It is zero for every
+	// other file.
+	dialect model.CSVDialect
+	// union is set for the staged file of a union import, and holds what the
+	// session records about it once the load commits. See union.go.
+	union *unionImport
+}
+
+// reusedSource is an input a persistent session did not read, because the
+// tables it produced last time are still exactly what it would produce now.
+type reusedSource struct {
+	target importTarget
+	tables []string
 }
 
 // inputName is what to call an input in a message. A staged --stdin-format
@@ -71,6 +100,18 @@ type importPlan struct {
 	// directoryLabels names the directory arguments, as the user wrote them, for
 	// the banner a successful directory import prints.
 	directoryLabels []string
+	// reused holds the inputs left out of targets because they are unchanged.
+	reused []reusedSource
+	// columnTypes is the .import --types declarations, which apply to every
+	// table the import creates.
+	columnTypes model.ColumnTypes
+	// dialect is how the import reads its csv and tsv files: the .import's own
+	// dialect options, or the session's. A .reload plans each source with the
+	// dialect it was last read with.
+	dialect model.CSVDialect
+	// reloading marks a .reload plan, which declares the types each table was
+	// last imported with rather than any of its own.
+	reloading bool
 }
 
 // alreadyPlanned reports whether this source is already in the plan.
@@ -91,13 +132,44 @@ func (p *importPlan) release() {
 	p.cleanups = nil
 }
 
-// loadPaths returns the paths to hand filesql, in the planned order.
-func (p *importPlan) loadPaths() []string {
-	paths := make([]string, 0, len(p.targets))
-	for _, target := range p.targets {
-		paths = append(paths, target.loadPath)
+// fileLoads returns the inputs to hand filesql, in the planned order, each with
+// what the import declares for it. The tables an input will create are known
+// before it is read, so the column types and keys are resolved here, per input,
+// and the importer only applies them; they are built for every load, so a
+// declaration never carries over into an import it was not made for.
+//
+// The tables are asked for with no existing tables to compare against, because
+// the preflight's claims leave out a table a file is re-imported over, and a
+// re-import, like a .reload, still creates that table again.
+func (s *Shell) fileLoads(plan *importPlan) ([]model.FileLoad, error) {
+	loads := make([]model.FileLoad, 0, len(plan.targets))
+	for _, target := range plan.targets {
+		tables, err := s.tablesClaimedBy(target, nil)
+		if err != nil {
+			return nil, err
+		}
+		load := model.FileLoad{Path: target.loadPath}
+		for _, table := range tables {
+			load.ColumnTypes = append(load.ColumnTypes, s.declaredColumnTypes(plan, table)...)
+			load.PrimaryKeys = append(load.PrimaryKeys, s.state.primaryKeys.ForTable(table)...)
+			load.Indexes = append(load.Indexes, s.state.indexes.ForTable(table)...)
+		}
+		// The importer finds a sidecar beside the path it loads, which is right
+		// for a file read where it is and wrong for a staged copy: a re-encoded
+		// text file or a pseudo-file is loaded from a temporary directory, and
+		// its sidecar is beside the file the user named. A download has no
+		// directory of its own, so it has no sidecar at all.
+		if target.loadPath != target.sourcePath {
+			load.SchemaSource, load.NoSchema = target.sourcePath, target.sourcePath == ""
+		}
+		// A union's staged file is plain CSV, written from shards that were
+		// read with the dialect already.
+		if target.union == nil {
+			load.Dialect = target.dialect
+		}
+		loads = append(loads, load)
 	}
-	return paths
+	return loads, nil
 }
 
 // resolveImportPlan turns the paths a user named into the files that will be
@@ -106,7 +178,7 @@ func (p *importPlan) loadPaths() []string {
 // It writes nothing to the database. A failure here — an unreachable URL, a
 // missing path, a directory with nothing supported in it — ends the import with
 // the session exactly as it was, which is the first half of "all or nothing".
-func (s *Shell) resolveImportPlan(ctx context.Context, argv, labels []string) (*importPlan, error) {
+func (s *Shell) resolveImportPlan(ctx context.Context, argv, labels []string, columnTypes model.ColumnTypes, dialect model.CSVDialect, union *unionSpec) (*importPlan, error) {
 	// The remote capability is checked across every input before the first one is
 	// resolved, so a mix of local files and a URL this session may not download
 	// refuses without staging the local half. It is checked here rather than only
@@ -117,7 +189,28 @@ func (s *Shell) resolveImportPlan(ctx context.Context, argv, labels []string) (*
 		return nil, err
 	}
 
-	plan := &importPlan{}
+	plan := &importPlan{columnTypes: columnTypes, dialect: dialect}
+	if union != nil {
+		dir, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the working directory: %w", err)
+		}
+		// A partitioned import with no table of its own makes a table of each
+		// directory it is given.
+		planInputs := s.planUnion
+		if union.table == "" {
+			planInputs = s.planPartitioned
+		}
+		if err := planInputs(ctx, plan, argv, dir, *union); err != nil {
+			plan.release()
+			return nil, err
+		}
+		return plan, nil
+	}
+	argv, labels, err := expandInputPatterns(argv, labels)
+	if err != nil {
+		return nil, err
+	}
 	for i, input := range argv {
 		// The label is what the user wrote, which is not always the path being
 		// resolved: an internal caller may resolve a temporary directory while the
@@ -142,18 +235,18 @@ func (s *Shell) resolveImportPlan(ctx context.Context, argv, labels []string) (*
 
 		if info.IsDir() {
 			plan.directoryLabels = append(plan.directoryLabels, label)
-			if err := s.planDirectory(plan, cleanPath, label); err != nil {
+			if err := s.planDirectory(ctx, plan, cleanPath, label); err != nil {
 				plan.release()
 				return nil, err
 			}
 			continue
 		}
-		if err := s.planFile(plan, cleanPath, label, false); err != nil {
+		if err := s.planInput(ctx, plan, cleanPath, label, false); err != nil {
 			plan.release()
 			return nil, err
 		}
 	}
-	if len(plan.targets) == 0 {
+	if len(plan.targets) == 0 && len(plan.reused) == 0 {
 		plan.release()
 		return nil, errors.New("no supported files to import")
 	}
@@ -161,7 +254,7 @@ func (s *Shell) resolveImportPlan(ctx context.Context, argv, labels []string) (*
 }
 
 // planDirectory adds every supported file under a directory argument.
-func (s *Shell) planDirectory(plan *importPlan, cleanPath, displayPath string) error {
+func (s *Shell) planDirectory(ctx context.Context, plan *importPlan, cleanPath, displayPath string) error {
 	files, err := s.supportedFilesInDir(cleanPath)
 	if err != nil {
 		return fmt.Errorf("failed to scan directory %s: %w", displayPath, err)
@@ -173,7 +266,7 @@ func (s *Shell) planDirectory(plan *importPlan, cleanPath, displayPath string) e
 	// order on every platform. Without that, which file a failing import names
 	// would depend on the filesystem's readdir order.
 	for _, file := range files {
-		if err := s.planFile(plan, file, file, true); err != nil {
+		if err := s.planFile(ctx, plan, file, file, true); err != nil {
 			return fmt.Errorf("failed to prepare %s from directory %s: %w", file, displayPath, err)
 		}
 	}
@@ -181,27 +274,58 @@ func (s *Shell) planDirectory(plan *importPlan, cleanPath, displayPath string) e
 }
 
 // planFile adds one file, staging it first when the format or the encoding
-// needs it.
-func (s *Shell) planFile(plan *importPlan, cleanPath, displayPath string, fromDirectory bool) error {
+// needs it. In a persistent session a file unchanged since its tables were
+// imported is set aside instead, and not read at all.
+func (s *Shell) planFile(ctx context.Context, plan *importPlan, cleanPath, displayPath string, fromDirectory bool) error {
 	// Identity is decided on the file itself, before any staging: a staged copy
 	// gets a fresh temp path every time and would never match anything.
 	if plan.alreadyPlanned(cleanPath) {
 		return nil
 	}
 	plan.seen = append(plan.seen, cleanPath)
+	// The session's own --db file holds the tables an import of it would
+	// replace. A directory that happens to hold it is read without it.
+	if s.persistent() && sameFilePath(cleanPath, s.sessionPath) {
+		if fromDirectory {
+			return nil
+		}
+		return fmt.Errorf("%s is this session's --db file, and its tables are already the session's tables", displayPath)
+	}
+
+	// A staged stdin dataset is a fresh file every run with nothing to compare
+	// it to, so it is neither digested nor recorded.
+	var stamp *sourceStamp
+	if cleanPath != s.stdinStagedPath && !isRemoteURL(displayPath) {
+		stamp = stampSource(cleanPath)
+	}
+	var digest *sourceDigest
+	if s.persistent() && cleanPath != s.stdinStagedPath {
+		var err error
+		if digest, err = digestSource(cleanPath); err != nil {
+			return fmt.Errorf("failed to read file %s: %w", displayPath, err)
+		}
+		if tables, ok := s.unchangedSource(ctx, plan, displayPath, digest); ok {
+			plan.reused = append(plan.reused, reusedSource{
+				target: importTarget{loadPath: cleanPath, displayPath: displayPath, fromDirectory: fromDirectory, digest: digest, stamp: stamp, dialect: fileDialect(plan.dialect, cleanPath)},
+				tables: tables,
+			})
+			return nil
+		}
+		s.warnDiscardedEdits(ctx, plan, displayPath)
+	}
 
 	loadPath := cleanPath
 	if !s.usecases.importer.IsSupportedFile(cleanPath) {
 		staged, cleanup, ok := s.stagePseudoFileAsCSV(cleanPath)
 		if !ok {
-			return fmt.Errorf("unsupported file format: %s (supported: csv, tsv, ltsv, json, jsonl, parquet, xlsx [+compressed], ach, fed)",
+			return fmt.Errorf("unsupported file format: %s (supported: csv, tsv, ltsv, json, jsonl, xml, parquet, xlsx [+compressed], ach, fed, db, sqlite, sqlite3, zip, tar [+compressed])",
 				filepath.Base(cleanPath))
 		}
 		plan.cleanups = append(plan.cleanups, cleanup)
 		loadPath = staged
 	}
 
-	prepared, cleanup, err := s.prepareImportLoadPath(loadPath)
+	prepared, cleanup, err := s.prepareImportLoadPath(loadPath, displayPath)
 	if err != nil {
 		return err
 	}
@@ -209,10 +333,18 @@ func (s *Shell) planFile(plan *importPlan, cleanPath, displayPath string, fromDi
 		plan.cleanups = append(plan.cleanups, cleanup)
 	}
 
+	sourcePath := cleanPath
+	if cleanPath == s.stdinStagedPath || isRemoteURL(displayPath) {
+		sourcePath = ""
+	}
 	plan.targets = append(plan.targets, importTarget{
 		loadPath:      prepared,
 		displayPath:   displayPath,
 		fromDirectory: fromDirectory,
+		digest:        digest,
+		stamp:         stamp,
+		sourcePath:    sourcePath,
+		dialect:       fileDialect(plan.dialect, cleanPath),
 	})
 	return nil
 }
@@ -246,6 +378,13 @@ func (s *Shell) preflightTableNames(ctx context.Context, plan *importPlan) ([]cl
 	// differing only in case would still want one table.
 	claimedBy := make(map[string]importTarget, len(plan.targets))
 	claims := make([]claimedTables, 0, len(plan.targets))
+	// A kept input still claims its tables: another input in the same import
+	// that wants one of them collides with it exactly as if both were read.
+	for _, r := range plan.reused {
+		for _, table := range r.tables {
+			claimedBy[strings.ToLower(table)] = r.target
+		}
+	}
 
 	for _, target := range plan.targets {
 		tables, err := s.tablesClaimedBy(target, existingSet)
@@ -253,6 +392,11 @@ func (s *Shell) preflightTableNames(ctx context.Context, plan *importPlan) ([]cl
 			return nil, err
 		}
 		for _, table := range tables {
+			if model.IsReservedTableName(table) {
+				return nil, fmt.Errorf(
+					"%s maps to table %q, but names starting with %q are reserved for sqly's own bookkeeping; rename the file",
+					s.inputName(target), table, model.ReservedTablePrefix)
+			}
 			key := strings.ToLower(table)
 			if previous, taken := claimedBy[key]; taken {
 				return nil, fmt.Errorf(
@@ -263,14 +407,63 @@ func (s *Shell) preflightTableNames(ctx context.Context, plan *importPlan) ([]cl
 		}
 		claims = append(claims, claimedTables{target: target, tables: tables})
 	}
+	if err := s.checkSQLiteTablesFound(plan); err != nil {
+		return nil, err
+	}
 	return claims, nil
 }
 
+// checkSQLiteTablesFound refuses an import whose databases lack a table
+// --sqlite-tables names. Each database copies the named tables it has, so a
+// misspelled name would otherwise be a table that silently never arrives.
+func (s *Shell) checkSQLiteTablesFound(plan *importPlan) error {
+	if len(s.state.sqliteTables) == 0 {
+		return nil
+	}
+	found := make(map[string]bool)
+	hasDatabase := false
+	for _, r := range plan.reused {
+		if s.usecases.importer.IsSQLiteFile(r.target.loadPath) {
+			hasDatabase = true
+			for _, table := range r.tables {
+				found[strings.ToLower(table)] = true
+			}
+		}
+	}
+	for _, target := range plan.targets {
+		if !s.usecases.importer.IsSQLiteFile(target.loadPath) {
+			continue
+		}
+		hasDatabase = true
+		tables, err := s.usecases.importer.SQLiteTables(target.loadPath)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", target.displayPath, err)
+		}
+		for _, table := range tables {
+			found[strings.ToLower(table)] = true
+		}
+	}
+	if !hasDatabase {
+		return nil
+	}
+	var missing []string
+	for _, name := range s.state.sqliteTables {
+		if !found[strings.ToLower(name)] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("--sqlite-tables names %s, which no database in this import has", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 // tablesClaimedBy returns the tables an input will create.
 //
 // A workbook is asked directly, because its sheets decide its tables and the
 // sheet policy decides which sheets count — the same answer the load will
-// reach. Everything else is named after its file; the formats that produce
+// reach. A SQLite database is asked too, for the tables it holds that
+// --sqlite-tables selects. Everything else is named after its file; the formats that produce
 // several tables from one file (ACH, Fedwire) claim their base name here and
 // are attributed exactly after the load, when the tables exist to be seen.
 func (s *Shell) tablesClaimedBy(target importTarget, existing map[string]struct{}) ([]string, error) {
@@ -293,6 +486,16 @@ func (s *Shell) tablesClaimedBy(target importTarget, existing map[string]struct{
 		}
 		return tables, nil
 	}
+	if s.usecases.importer.IsSQLiteFile(target.loadPath) {
+		tables, err := s.usecases.importer.SQLiteTables(target.loadPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", target.displayPath, err)
+		}
+		return slices.DeleteFunc(tables, func(table string) bool {
+			_, taken := existing[table]
+			return taken && s.ownsTable(table, target.displayPath)
+		}), nil
+	}
 
 	// A file re-imported over the table it already owns is not claiming a name
 	// from anyone, so it is left out of the collision check.
@@ -318,10 +521,14 @@ func (s *Shell) ownsTable(table, source string) bool {
 // source, its directory marker, its content baseline, a workbook's sheet record
 // — is a claim about what the database holds, and making any of those before
 // the commit would leave the session describing rows that were rolled back.
-func (s *Shell) recordImportedTables(ctx context.Context, claims []claimedTables, after map[string]struct{}) []string {
+func (s *Shell) recordImportedTables(ctx context.Context, plan *importPlan, claims []claimedTables, after map[string]struct{}) []string {
 	var imported []string
 	for _, claim := range claims {
 		owned := s.tablesNamedAfterFile(claim.target.loadPath, after)
+		if s.usecases.importer.IsSQLiteFile(claim.target.loadPath) {
+			// A database's tables are named after its tables, not after the file.
+			owned = s.copiedSQLiteTables(claim.target.loadPath, after)
+		}
 		if len(owned) == 0 {
 			// A re-import that overwrote tables it already owned creates no new
 			// name to match, so the record is what says which tables are its.
@@ -330,7 +537,18 @@ func (s *Shell) recordImportedTables(ctx context.Context, claims []claimedTables
 		if len(owned) == 0 {
 			continue
 		}
-		s.recordTableSources(ctx, owned, claim.target.displayPath)
+		if claim.target.union != nil {
+			for _, name := range owned {
+				s.recordUnionTable(ctx, name, claim.target)
+			}
+		} else {
+			s.recordTableSources(ctx, owned, claim.target.displayPath)
+			for _, name := range owned {
+				delete(s.unionImports, name)
+			}
+		}
+		s.recordImportColumnTypes(plan, owned)
+		s.recordImportDialect(claim.target.dialect, owned)
 		if claim.target.fromDirectory {
 			for _, name := range owned {
 				s.markDirImported(name)
@@ -338,6 +556,8 @@ func (s *Shell) recordImportedTables(ctx context.Context, claims []claimedTables
 		} else {
 			s.clearDirImported(owned)
 		}
+		s.rememberTableSources(ctx, owned, claim.target.digest, claim.target.fromDirectory)
+		s.recordSourceStamp(claim.target.displayPath, claim.target.stamp)
 		s.warnKeywordTableNames(owned)
 		s.warnSkippedRows(owned)
 		imported = append(imported, owned...)
@@ -346,6 +566,19 @@ func (s *Shell) recordImportedTables(ctx context.Context, claims []claimedTables
 	return imported
 }
 
+// copiedSQLiteTables returns the tables in after that an import of the
+// database at path copied.
+func (s *Shell) copiedSQLiteTables(path string, after map[string]struct{}) []string {
+	tables, err := s.usecases.importer.SQLiteTables(path)
+	if err != nil {
+		return nil
+	}
+	return slices.DeleteFunc(tables, func(table string) bool {
+		_, ok := after[table]
+		return !ok
+	})
+}
+
 // importProducedNothing explains an import that committed without creating a
 // table. A workbook whose only sheet has no cells arrives here, and saying
 // "collision" would send the user looking for a second input that does not
@@ -36,7 +36,7 @@ func TestImportDirectory_EmptyDir_ReturnsError(t *testing.T) {
 
 	// filesql returns an error for empty directories (no supported files found),
 	// so the import propagates it rather than reporting an empty success.
-	if err := s.runImport(context.Background(), []string{emptyDir}, []string{emptyDir}); err == nil {
+	if err := s.runImport(context.Background(), []string{emptyDir}, []string{emptyDir}, nil, model.CSVDialect{}, nil); err == nil {
 		t.Fatal("expected error for empty directory, got nil")
 	}
 }
@@ -58,14 +58,14 @@ func TestImportDirectory_ReimportSameDir_ReportsOverwrite(t *testing.T) {
 	ctx := context.Background()
 
 	// First import creates the table.
-	if err := s.runImport(ctx, []string{dir}, []string{dir}); err != nil {
+	if err := s.runImport(ctx, []string{dir}, []string{dir}, nil, model.CSVDialect{}, nil); err != nil {
 		t.Fatalf("first import: %v", err)
 	}
 
 	// Re-importing the same directory overwrites the existing table. The
 	// directory still contains a supported file, so the import succeeds (it
 	// overwrote data) rather than failing with "No supported files".
-	if err := s.runImport(ctx, []string{dir}, []string{dir}); err != nil {
+	if err := s.runImport(ctx, []string{dir}, []string{dir}, nil, model.CSVDialect{}, nil); err != nil {
 		t.Fatalf("second import: %v", err)
 	}
 }
@@ -213,7 +213,7 @@ func TestImportDirectory_RecordsPerFileSource(t *testing.T) {
 	copyTestFile(t, "customer-transfer.fed", filepath.Join(dir, "customer-transfer.fed"))
 
 	ctx := context.Background()
-	if err := s.runImport(ctx, []string{dir}, []string{dir}); err != nil {
+	if err := s.runImport(ctx, []string{dir}, []string{dir}, nil, model.CSVDialect{}, nil); err != nil {
 		t.Fatalf("runImport: %v", err)
 	}
 
@@ -265,7 +265,7 @@ func TestImportDirectory_RejectsDuplicateBasenameCollision(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = s.runImport(context.Background(), []string{dir}, []string{dir})
+	err = s.runImport(context.Background(), []string{dir}, []string{dir}, nil, model.CSVDialect{}, nil)
 	if err == nil {
 		t.Fatal("expected a collision error for duplicate basenames, got nil")
 	}
@@ -290,7 +290,7 @@ func TestImportDirectory_RejectsSanitizedCollision(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = s.runImport(context.Background(), []string{dir}, []string{dir})
+	err = s.runImport(context.Background(), []string{dir}, []string{dir}, nil, model.CSVDialect{}, nil)
 	if err == nil {
 		t.Fatal("expected a collision error for sanitized-name collision, got nil")
 	}
@@ -319,7 +319,7 @@ func TestImportDirectory_ReimportOverFileImport_UpdatesSourceAndBlocksSave(t *te
 	if err := os.WriteFile(orig, origData, 0o600); err != nil {
 		t.Fatal(err)
 	}
-	if err := s.runImport(ctx, []string{orig}, []string{orig}); err != nil {
+	if err := s.runImport(ctx, []string{orig}, []string{orig}, nil, model.CSVDialect{}, nil); err != nil {
 		t.Fatalf("runImport: %v", err)
 	}
 	if s.dirImported["user"] {
@@ -336,7 +336,7 @@ func TestImportDirectory_ReimportOverFileImport_UpdatesSourceAndBlocksSave(t *te
 		t.Fatal(err)
 	}
 
-	if err := s.runImport(ctx, []string{dir}, []string{dir}); err != nil {
+	if err := s.runImport(ctx, []string{dir}, []string{dir}, nil, model.CSVDialect{}, nil); err != nil {
 		t.Fatalf("runImport re-import: %v", err)
 	}
 	if !s.dirImported["user"] {
@@ -496,7 +496,7 @@ func TestShell_runImport_directoryImportsAndReportsTables(t *testing.T) {
 
 	// Import progress goes to stderr, so capture stderr here.
 	out := captureStderr(t, func() {
-		err = s.runImport(context.Background(), []string{dir}, []string{"fixtures"})
+		err = s.runImport(context.Background(), []string{dir}, []string{"fixtures"}, nil, model.CSVDialect{}, nil)
 	})
 	if err != nil {
 		t.Fatalf("runImport returned error: %v", err)
@@ -529,7 +529,7 @@ func TestImportFile_UnsupportedFormat(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = s.runImport(context.Background(), []string{tmpFile}, []string{tmpFile})
+	err = s.runImport(context.Background(), []string{tmpFile}, []string{tmpFile}, nil, model.CSVDialect{}, nil)
 	if err == nil {
 		t.Fatal("expected error for unsupported format")
 	}
@@ -551,7 +551,7 @@ func TestImportFile_CSVSuccess(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	if err := s.runImport(ctx, []string{tmpFile}, []string{tmpFile}); err != nil {
+	if err := s.runImport(ctx, []string{tmpFile}, []string{tmpFile}, nil, model.CSVDialect{}, nil); err != nil {
 		t.Fatalf("runImport: %v", err)
 	}
 
@@ -577,7 +577,7 @@ func TestImportFile_NonexistentFile(t *testing.T) {
 	}
 	defer cleanup()
 
-	err = s.runImport(context.Background(), []string{"/nonexistent/file.csv"}, []string{"/nonexistent/file.csv"})
+	err = s.runImport(context.Background(), []string{"/nonexistent/file.csv"}, []string{"/nonexistent/file.csv"}, nil, model.CSVDialect{}, nil)
 	if err == nil {
 		t.Fatal("expected error for nonexistent file")
 	}
@@ -599,7 +599,7 @@ func TestImportDirectory_WithCSVFiles(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	if err := s.runImport(ctx, []string{dir}, []string{dir}); err != nil {
+	if err := s.runImport(ctx, []string{dir}, []string{dir}, nil, model.CSVDialect{}, nil); err != nil {
 		t.Fatalf("runImport: %v", err)
 	}
 
@@ -844,7 +844,7 @@ func TestStagePseudoFileScopedToPseudoFiles(t *testing.T) {
 	config.Stdout = &bytes.Buffer{}
 	config.Stderr = &bytes.Buffer{}
 	defer func() { config.Stdout, config.Stderr = backout, backerr }()
-	if err := shell.runImport(context.Background(), []string{plain}, []string{plain}); err == nil {
+	if err := shell.runImport(context.Background(), []string{plain}, []string{plain}, nil, model.CSVDialect{}, nil); err == nil {
 		t.Error("runImport accepted a non-pseudo extensionless file, want an unsupported-format error")
 	}
 }
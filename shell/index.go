@@ -0,0 +1,132 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// indexCommand creates an index on columns of a table, as `.index TABLE
+// COLUMN [COLUMN...]`. An imported table has no index of its own, so a join on
+// a five-million-row file scans it once for every row of the other; an index on
+// the join column is what makes that a lookup.
+//
+// The index is also declared for the rest of the session, as --index declares
+// one: a .reload or a later .import builds the table again, and a table built
+// again does not keep the indexes of the one it replaced.
+func (c CommandList) indexCommand(ctx context.Context, s *Shell, argv []string) error {
+	if len(argv) < 2 {
+		return &invocationError{Err: errors.New(".index requires a table and the columns to index\n" + indexUsageText())}
+	}
+	index := model.TableKey{Table: argv[0]}
+	for _, column := range argv[1:] {
+		if slices.ContainsFunc(index.Columns, func(c string) bool { return strings.EqualFold(c, column) }) {
+			return &invocationError{Err: fmt.Errorf(".index names column %q twice", column)}
+		}
+		index.Columns = append(index.Columns, column)
+	}
+	// The columns are checked first because SQLite reads a quoted name that is
+	// no column as a string, and would index that constant without complaint.
+	columns, err := s.tableColumns(ctx, index.Table)
+	if err != nil {
+		return err
+	}
+	if columns.RowCount() == 0 {
+		return fmt.Errorf("no such table: %s", index.Table)
+	}
+	names := make([]string, 0, columns.RowCount())
+	for i := range columns.RowCount() {
+		if rec, ok := columns.Row(i); ok && rec.Len() > 1 {
+			names = append(names, rec.At(1))
+		}
+	}
+	for _, column := range index.Columns {
+		if !slices.ContainsFunc(names, func(n string) bool { return strings.EqualFold(n, column) }) {
+			return fmt.Errorf("table %q has no column %q; its columns are %s", index.Table, column, strings.Join(names, ", "))
+		}
+	}
+	if _, _, err := s.usecases.query.ExecSQL(ctx, index.CreateIndexStatement()); err != nil {
+		return fmt.Errorf("failed to create index %s: %w", index, err)
+	}
+	if !s.state.indexes.Contains(index) {
+		s.state.indexes = append(s.state.indexes, index)
+	}
+	return nil
+}
+
+// indexUsageText is the .index usage, shown when it is called without enough
+// arguments.
+func indexUsageText() string {
+	return "[Usage]\n" +
+		"  .index TABLE COLUMN [COLUMN...]\n" +
+		"\n" +
+		"  - Several columns make one index over all of them, in that order\n" +
+		"  - The index is created again whenever the table is imported or reloaded"
+}
+
+// keyOptions names the primary key and indexes declared for a table, for the
+// settings a persistent session records with it. A key changes the table a
+// file becomes, so a run that declares a different one imports the file again.
+func (s *Shell) keyOptions(table string) string {
+	var options string
+	if key := s.state.primaryKeys.ForTable(table); len(key) > 0 {
+		options += ";primary-key=" + key.String()
+	}
+	if declared := s.state.indexes.ForTable(table); len(declared) > 0 {
+		options += ";index=" + declared.String()
+	}
+	return options
+}
+
+// checkKeyTables refuses a --primary-key or --index that names a table the
+// startup import did not create, for the reason checkColumnTypeTables refuses
+// a --column-type: the declaration would otherwise be dropped without a word.
+func (s *Shell) checkKeyTables(ctx context.Context) error {
+	if len(s.state.primaryKeys) == 0 && len(s.state.indexes) == 0 {
+		return nil
+	}
+	tables, err := s.usecases.metadata.TablesName(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get table names: %w", err)
+	}
+	declared := []struct {
+		flag string
+		keys model.TableKeys
+	}{{"--primary-key", s.state.primaryKeys}, {"--index", s.state.indexes}}
+	for _, d := range declared {
+		for _, key := range d.keys {
+			if !slices.ContainsFunc(tables, func(t *model.Table) bool { return strings.EqualFold(t.Name(), key.Table) }) {
+				return &invocationError{Err: fmt.Errorf("%s %s names table %q, which no input created", d.flag, key, key.Table)}
+			}
+		}
+	}
+	return nil
+}
+
+// tableIndexStatements returns the CREATE INDEX statements SQLite stored for a
+// table, in the order they were created. An index SQLite makes on its own, for
+// a primary key or a UNIQUE constraint, has no statement and is left out: the
+// CREATE TABLE already says it is there.
+func (s *Shell) tableIndexStatements(ctx context.Context, schema, table string, isTemp bool) ([]string, error) {
+	master := "sqlite_master"
+	if isTemp || strings.EqualFold(schema, "temp") {
+		master = "sqlite_temp_master"
+	}
+	literal := "'" + strings.ReplaceAll(table, "'", "''") + "'"
+	res, err := s.usecases.query.Query(ctx,
+		"SELECT sql FROM "+master+" WHERE type = 'index' AND tbl_name = "+literal+" COLLATE NOCASE AND sql IS NOT NULL ORDER BY rowid")
+	if err != nil {
+		return nil, err
+	}
+	statements := make([]string, 0, res.RowCount())
+	for i := range res.RowCount() {
+		if rec, ok := res.Row(i); ok && rec.Len() > 0 {
+			statements = append(statements, rec.At(0))
+		}
+	}
+	return statements, nil
+}
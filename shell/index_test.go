@@ -0,0 +1,97 @@
+package shell
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrimaryKeyAndIndexFlags(t *testing.T) {
+	t.Run("the key and indexes are created and .schema shows them", func(t *testing.T) {
+		dir := t.TempDir()
+		users := writeCSV(t, dir, "users.csv", "id,name\n1,a\n2,b\n")
+		orders := writeCSV(t, dir, "orders.csv", "id,user_id\n10,1\n11,1\n")
+		script := filepath.Join(dir, "run.sqly")
+		writeScript(t, script, ".schema users\n.schema orders\n")
+
+		stdout, stderr, err := runWithArgs(t, "--primary-key", "users(id)", "--index", "orders(user_id)",
+			"--script-file", script, users, orders)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		want := `CREATE TABLE "users" ("id" INTEGER PRIMARY KEY, "name" TEXT)` + "\n" +
+			`CREATE TABLE "orders" ("id" INTEGER, "user_id" INTEGER)` + "\n" +
+			`CREATE INDEX "idx_orders_user_id" ON "orders" ("user_id")` + "\n"
+		if stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("a repeated key fails the import", func(t *testing.T) {
+		dir := t.TempDir()
+		src := writeCSV(t, dir, "users.csv", "id,name\n1,a\n1,b\n")
+
+		_, _, err := runWithArgs(t, "--primary-key", "users(id)", "--sql", "SELECT 1", src)
+		var importErr *importFailedError
+		if !errors.As(err, &importErr) || !strings.Contains(err.Error(), "2 data rows hold 1") {
+			t.Errorf("Run error = %v, want an import failure naming the key", err)
+		}
+	})
+
+	t.Run("a table no input created is refused", func(t *testing.T) {
+		dir := t.TempDir()
+		src := writeCSV(t, dir, "users.csv", "id\n1\n")
+
+		for _, flag := range []string{"--primary-key", "--index"} {
+			_, _, err := runWithArgs(t, flag, "user(id)", "--sql", "SELECT 1", src)
+			var invocationErr *invocationError
+			if !errors.As(err, &invocationErr) || !strings.Contains(err.Error(), `names table "user"`) {
+				t.Errorf("Run %s error = %v, want an invocationError naming the table", flag, err)
+			}
+		}
+	})
+}
+
+func TestIndexCommand(t *testing.T) {
+	t.Run("the index is created and kept by .reload", func(t *testing.T) {
+		csvPath := filepath.Join(t.TempDir(), "orders.csv")
+		writeDaily(t, csvPath, "id,user_id\n10,1\n")
+		s := newReloadShell(t, csvPath)
+
+		if err := s.exec(t.Context(), ".index orders user_id id"); err != nil {
+			t.Fatalf(".index: %v", err)
+		}
+		const query = "SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'orders'"
+		want := "name\nidx_orders_user_id_id\n"
+		if got := queryReloadShell(t, s, query); got != want {
+			t.Errorf("after .index got %q, want %q", got, want)
+		}
+
+		writeDaily(t, csvPath, "id,user_id\n10,1\n11,2\n")
+		if _, err := getExecStdErrOutput(t, s.exec, ".reload orders"); err != nil {
+			t.Fatalf(".reload: %v", err)
+		}
+		if got := queryReloadShell(t, s, query); got != want {
+			t.Errorf("after .reload got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("a column or table that does not exist is refused", func(t *testing.T) {
+		csvPath := filepath.Join(t.TempDir(), "orders.csv")
+		writeDaily(t, csvPath, "id,user_id\n10,1\n")
+		s := newReloadShell(t, csvPath)
+
+		tests := map[string]string{
+			".index orders email": "its columns are id, user_id",
+			".index nope id":      "no such table",
+			".index orders":       "requires a table and the columns",
+			".index orders id ID": "twice",
+		}
+		for line, want := range tests {
+			if err := s.exec(t.Context(), line); err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("exec(%q) error = %v, want one containing %q", line, err, want)
+			}
+		}
+	})
+}
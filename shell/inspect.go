@@ -148,6 +148,9 @@ func (s *Shell) runInspect(ctx context.Context) error {
 	}
 	// Sort by name so the report is deterministic regardless of import order.
 	slices.Sort(names)
+	if s.argument.InspectFormat == config.InspectFormatSchema {
+		return s.runInspectSchema(ctx, names)
+	}
 
 	report := inspectReport{
 		SchemaVersion: InspectSchemaVersion,
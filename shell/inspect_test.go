@@ -426,3 +426,44 @@ func TestInspect_NoInputErrors(t *testing.T) {
 		t.Fatal("expected an error when --inspect is given no input, got nil")
 	}
 }
+
+// TestInspect_SchemaFormatRoundTrips checks that what --format schema prints is
+// a sidecar the next import reads back: written beside the file and edited, it
+// decides the table the file becomes.
+func TestInspect_SchemaFormatRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	csv := writeCSV(t, dir, "users.csv", "id,zip\n1,12345\n2,98765\n")
+
+	out := runInspectRaw(t, []string{"sqly", "--inspect", "--format", "schema", csv})
+	schema, err := model.ParseImportSchema([]byte(out))
+	if err != nil {
+		t.Fatalf("the printed schema does not parse: %v\n%s", err, out)
+	}
+	if len(schema.Tables) != 1 || schema.Tables[0].Name != "users" {
+		t.Fatalf("tables = %+v, want users", schema.Tables)
+	}
+	if zip, _ := schema.Tables[0].Column("zip"); zip.Type != model.ColumnTypeInteger {
+		t.Errorf("zip type = %q, want the inferred INTEGER", zip.Type)
+	}
+
+	notNull := false
+	schema.Tables[0].Columns[0].Nullable = &notNull
+	schema.Tables[0].Columns[0].PrimaryKey = true
+	schema.Tables[0].Columns[1].Type = model.ColumnTypeText
+	edited, err := model.MarshalImportSchema(*schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(csv+".schema.json", edited, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := runWithArgs(t, "--output-format", "csv",
+		"--sql", "SELECT DISTINCT typeof(zip), (SELECT pk FROM pragma_table_info('users') WHERE name = 'id') FROM users", csv)
+	if err != nil {
+		t.Fatalf("Run: %v (%s)", err, stderr)
+	}
+	if !strings.HasSuffix(stdout, "\ntext,1\n") {
+		t.Errorf("stdout = %q, want zip kept as text and id the primary key", stdout)
+	}
+}
@@ -0,0 +1,158 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnonymizeFunctions(t *testing.T) {
+	dir := t.TempDir()
+	path := writeCSV(t, dir, "users.csv", "id,email,phone\n1,alice@example.com,555-0142\n")
+
+	stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--sql",
+		"SELECT id, substr(sha256(email), 1, 8) AS h, uuid_v5('dns', email) = uuid_v5('dns', 'alice@example.com') AS same, redact(phone, 4) AS phone FROM users", path)
+	if err != nil {
+		t.Fatalf("Run: %v (%s)", err, stderr)
+	}
+	if want := "id,h,same,phone\n1,ff8d9819,1,****0142\n"; stdout != want {
+		t.Errorf("stdout = %q, want %q", stdout, want)
+	}
+}
+
+func TestMask(t *testing.T) {
+	// sha256("alice@example.com")
+	const aliceHash = "ff8d9819fc0e12bf0d24892e45987e249a28dce836a85cad60e28eaaa8c6d976"
+
+	t.Run("--output writes the named columns masked", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "users.csv", "id,email,phone\n1,alice@example.com,555-0142\n2,,555-0199\n")
+		out := filepath.Join(dir, "out.csv")
+
+		_, stderr, err := runWithArgs(t, "--mask", "EMAIL=hash,phone=redact:2", "--sql", "SELECT * FROM users ORDER BY id", "--output", out, path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		got, err := os.ReadFile(filepath.Clean(out))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "id,email,phone\n1," + aliceHash + ",******42\n2,,******99\n"; string(got) != want {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("a mask that names no column of the result is refused before anything is written", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "users.csv", "id,email\n1,alice@example.com\n")
+		out := filepath.Join(dir, "out.csv")
+
+		_, _, err := runWithArgs(t, "--mask", "emial=hash", "--sql", "SELECT * FROM users", "--output", out, path)
+		if code := ExitCode(err); code != ExitUsage || !strings.Contains(err.Error(), "--mask emial=hash names no column of the result") {
+			t.Errorf("exit %d (%v), want %d naming the mask", code, err, ExitUsage)
+		}
+		if _, err := os.Stat(out); !os.IsNotExist(err) {
+			t.Errorf("output was written: %v", err)
+		}
+	})
+
+	t.Run(".dump and .save DIR refuse a mask that names no column of the tables they write", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "users.csv", "id,email\n1,alice@example.com\n")
+		out := filepath.Join(dir, "out.csv")
+		outDir := filepath.Join(dir, "out")
+		script := filepath.Join(dir, "dump.sql")
+
+		for _, command := range []string{".dump users " + out, "UPDATE users SET id = 2;\n.save " + outDir} {
+			writeScript(t, script, command+"\n")
+			_, stderr, err := runWithArgs(t, "--mask", "email=hash,emial=hash", "--script-file", script, path)
+			if code := ExitCode(err); code != ExitUsage || !strings.Contains(stderr, "--mask emial=hash names no column of") {
+				t.Errorf("%s: exit %d (%v, %s), want %d naming the mask", command, code, err, stderr, ExitUsage)
+			}
+		}
+		for _, written := range []string{out, outDir} {
+			if _, err := os.Stat(written); !os.IsNotExist(err) {
+				t.Errorf("%s was written: %v", written, err)
+			}
+		}
+	})
+
+	t.Run("a mask naming a table masks that table only and is checked only when it is written", func(t *testing.T) {
+		dir := t.TempDir()
+		users := writeCSV(t, dir, "users.csv", "id,email\n1,alice@example.com\n")
+		k := writeCSV(t, dir, "k.csv", "id,email\n1,bob@example.com\n")
+		script := filepath.Join(dir, "dump.sql")
+		writeScript(t, script, ".dump k "+filepath.Join(dir, "k_out.csv")+"\n.dump users "+filepath.Join(dir, "users_out.csv")+"\n")
+
+		_, stderr, err := runWithArgs(t, "--mask", "users.email=hash,pii.email=hash", "--script-file", script, users, k)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if strings.Contains(stderr, "--mask") {
+			t.Errorf("stderr = %q, want no complaint about a mask for a table not written", stderr)
+		}
+		for file, want := range map[string]string{
+			"users_out.csv": "id,email\n1," + aliceHash + "\n",
+			"k_out.csv":     "id,email\n1,bob@example.com\n",
+		} {
+			got, err := os.ReadFile(filepath.Join(dir, file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != want {
+				t.Errorf("%s = %q, want %q", file, got, want)
+			}
+		}
+	})
+
+	t.Run("a JSON export keeps NULL and writes the hash as text", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "users.csv", "id,email\n1,alice@example.com\n")
+		out := filepath.Join(dir, "out.json")
+
+		_, stderr, err := runWithArgs(t, "--mask", "email=hash", "--sql", "SELECT id, email FROM users UNION ALL SELECT 2, NULL", "--output", out, path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		got, err := os.ReadFile(filepath.Clean(out))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(got), `"email":"`+aliceHash+`"`) || !strings.Contains(string(got), `"email":null`) {
+			t.Errorf("output = %s, want the hash and a null", got)
+		}
+	})
+
+	t.Run(".save DIR writes the masked table, and --in-place is refused", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "users.csv", "id,email\n1,alice@example.com\n")
+		outDir := filepath.Join(dir, "out")
+		script := filepath.Join(dir, "save.sql")
+		writeScript(t, script, "UPDATE users SET id = 2;\n.save "+outDir+"\n")
+
+		if _, stderr, err := runWithArgs(t, "--mask", "email=hash", "--script-file", script, path); err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		got, err := os.ReadFile(filepath.Join(outDir, "users.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "id,email\n2," + aliceHash + "\n"; string(got) != want {
+			t.Errorf("saved = %q, want %q", got, want)
+		}
+
+		writeScript(t, script, "UPDATE users SET id = 3;\n.save --in-place\n")
+		_, stderr, err := runWithArgs(t, "--mask", "email=hash", "--script-file", script, path)
+		if err == nil || !strings.Contains(stderr, "would overwrite the sources") {
+			t.Errorf("Run error = %v (%s), want the in-place save refused", err, stderr)
+		}
+		source, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(source), "alice@example.com") {
+			t.Errorf("source = %q, want it untouched", source)
+		}
+	})
+}
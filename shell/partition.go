@@ -0,0 +1,111 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/nao1215/sqly/config"
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// --output-partition-by writes a result the way --partitioned reads one: a
+// directory per value of each partition column, and the rows below it without
+// those columns, which the path already holds.
+//
+//	out/year=2024/region=eu/part-0.parquet
+//
+// The tree is written whole or not at all. It is built in a staging directory
+// beside the destination and renamed onto it once every file is written, so a
+// value the format cannot hold in the tenth partition does not leave nine
+// behind for the next reader of the lake to take for the whole result.
+
+// partitionFileBase is the name of the one file written in each partition,
+// before its extension.
+const partitionFileBase = "part-0"
+
+// ensurePartitionDestination rejects a --output-partition-by destination sqly
+// cannot write a tree to. It must be a directory that does not exist yet or is
+// empty: sqly does not merge a result into files already there, because rows
+// from two runs in one partition are a table nobody asked for, and it does not
+// delete them either.
+func ensurePartitionDestination(path string) error {
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && !info.IsDir():
+		return &outputPathError{Path: path, Err: fmt.Errorf("--output-partition-by writes a directory, and output destination %q is a file", path)}
+	case err == nil:
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return &outputPathError{Path: path, Err: fmt.Errorf("output destination %q: %w", path, err)}
+		}
+		if len(entries) > 0 {
+			return &outputPathError{Path: path, Err: fmt.Errorf(
+				"output destination %q already holds files; --output-partition-by writes into a new or empty directory, so remove it or name another", path)}
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return &outputPathError{Path: path, Err: fmt.Errorf("output destination %q: %w", path, err)}
+	}
+	parent := filepath.Dir(filepath.Clean(path))
+	if info, err := os.Stat(parent); err != nil || !info.IsDir() {
+		return &outputPathError{Path: path, Err: fmt.Errorf("output destination %q: directory %q does not exist", path, parent)}
+	}
+	return nil
+}
+
+// outputPartitioned writes table to the --output directory as a partitioned
+// tree, one file per combination of the partition columns' values.
+func (s *Shell) outputPartitioned(table *model.Table) error {
+	dest := filepath.Clean(s.argument.Output.FilePath)
+	mode := s.state.mode.PrintMode
+	exportFmt, compression, err := resolveOutputTarget(dest, model.ExportFormatFromPrintMode(mode), !mode.IsDisplayOnly())
+	if err != nil {
+		return err
+	}
+	partitions, err := table.PartitionBy(s.argument.Output.PartitionBy)
+	if err != nil {
+		return &invocationError{Err: fmt.Errorf("--output-partition-by: %w", err)}
+	}
+
+	staging, err := os.MkdirTemp(filepath.Dir(dest), ".sqly-out-*")
+	if err != nil {
+		return &outputPathError{Path: dest, Err: fmt.Errorf("output destination %q: cannot create a staging directory beside it: %w", dest, err)}
+	}
+	defer func() {
+		_ = os.RemoveAll(staging) //nolint:errcheck // best-effort cleanup; after the rename there is nothing left
+	}()
+	for _, part := range partitions {
+		segments := make([]string, 0, len(part.Values))
+		for _, value := range part.Values {
+			segments = append(segments, value.Segment())
+		}
+		dir := filepath.Join(staging, filepath.Join(segments...))
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return &outputPathError{Path: dest, Err: fmt.Errorf("output destination %q: %w", dest, err)}
+		}
+		file := model.BuildOutputPath(filepath.Join(dir, partitionFileBase), exportFmt, compression)
+		if err := s.usecases.export.DumpTable(file, part.Table.WithCSVOutput(s.state.csvOutput).WithNullString(s.state.nullString).WithMask(s.state.mask.ForResult()), exportFmt, compression, model.TextEncodingUTF8); err != nil {
+			return &outputPathError{Path: dest, Err: fmt.Errorf("%s: %s", strings.Join(segments, "/"), renamePathInMessage(err.Error(), staging, dest))}
+		}
+	}
+
+	// The staging directory was created private, as a temporary directory is;
+	// the tree it becomes is an ordinary output directory.
+	if err := os.Chmod(staging, 0o750); err != nil { //nolint:gosec // an output directory, readable like the files in it
+		return &outputPathError{Path: dest, Err: fmt.Errorf("output destination %q: %w", dest, err)}
+	}
+	// An empty directory at the destination was checked for before the import,
+	// and is replaced by the tree.
+	if err := os.Remove(dest); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return &outputPathError{Path: dest, Err: fmt.Errorf("output destination %q: %w", dest, err)}
+	}
+	if err := os.Rename(staging, dest); err != nil {
+		return &outputPathError{Path: dest, Err: fmt.Errorf("output destination %q: %w", dest, err)}
+	}
+	fmt.Fprintf(config.Stderr, "Output sql result to %s (%d partition(s), output mode=%s)\n",
+		color.HiCyanString(dest), len(partitions), exportFmt.String())
+	return nil
+}
@@ -0,0 +1,149 @@
+package shell
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePartition writes one file of a partitioned tree, creating the
+// directories above it.
+func writePartition(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatalf("mkdir for %s: %v", rel, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", rel, err)
+	}
+}
+
+func TestPartitionedImport(t *testing.T) {
+	t.Run("a tree becomes one table with a column per key", func(t *testing.T) {
+		sales := filepath.Join(t.TempDir(), "sales")
+		writePartition(t, sales, "year=2024/region=eu/part-0.csv", "id,amount\n1,10\n")
+		writePartition(t, sales, "year=2024/region=us/part-0.csv", "amount,id\n20,2\n")
+		writePartition(t, sales, "year=2023/region=a%2Fb/part-0.csv", "id,amount\n3,30\n")
+		writePartition(t, sales, "_SUCCESS.csv", "not,rows\n")
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--partitioned",
+			"--sql", "SELECT id, amount, year, region FROM sales ORDER BY id", sales)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "id,amount,year,region\n1,10,2024,eu\n2,20,2024,us\n3,30,2023,a/b\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("a directory that is not key=value fails the import", func(t *testing.T) {
+		sales := filepath.Join(t.TempDir(), "sales")
+		writePartition(t, sales, "2024/part-0.csv", "id\n1\n")
+
+		_, _, err := runWithArgs(t, "--partitioned", "--sql", "SELECT 1", sales)
+		var importErr *importFailedError
+		if !errors.As(err, &importErr) || !strings.Contains(err.Error(), "not key=value") {
+			t.Errorf("Run error = %v, want the directory refused", err)
+		}
+	})
+
+	t.Run("files under different keys fail the import naming both", func(t *testing.T) {
+		sales := filepath.Join(t.TempDir(), "sales")
+		writePartition(t, sales, "year=2024/part-0.csv", "id\n1\n")
+		writePartition(t, sales, "region=eu/part-0.csv", "id\n2\n")
+
+		_, _, err := runWithArgs(t, "--partitioned", "--sql", "SELECT 1", sales)
+		if err == nil || !strings.Contains(err.Error(), "partitioned by region, but") {
+			t.Errorf("Run error = %v, want the keys compared", err)
+		}
+	})
+
+	t.Run("a file is refused", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "a.csv", "id\n1\n")
+
+		_, _, err := runWithArgs(t, "--partitioned", "--sql", "SELECT 1", path)
+		var invocationErr *invocationError
+		if !errors.As(err, &invocationErr) {
+			t.Errorf("Run error = %v, want an invocationError", err)
+		}
+	})
+
+	t.Run(".import --partitioned names the table after the directory", func(t *testing.T) {
+		dir := t.TempDir()
+		sales := filepath.Join(dir, "sales")
+		writePartition(t, sales, "year=2024/part-0.tsv", "amount\n5\n")
+		script := filepath.Join(dir, "run.sqly")
+		writeScript(t, script, ".import --partitioned "+sales+"\nSELECT year, amount FROM sales;\n")
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--script-file", script)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "year,amount\n2024,5\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+}
+
+func TestOutputPartitionBy(t *testing.T) {
+	t.Run("the result is written as a tree and reads back as the same table", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "sales.csv", "id,year,region,amount\n1,2024,eu,10\n2,2024,us,20\n3,2023,eu,30\n4,2023,,40\n")
+		out := filepath.Join(dir, "out")
+
+		_, stderr, err := runWithArgs(t, "--output-format", "json", "--output-partition-by", "year,region",
+			"-o", out, "--sql", "SELECT * FROM sales", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		for _, rel := range []string{
+			"year=2024/region=eu/part-0.json",
+			"year=2024/region=us/part-0.json",
+			"year=2023/region=eu/part-0.json",
+			"year=2023/region=__HIVE_DEFAULT_PARTITION__/part-0.json",
+		} {
+			if _, err := os.Stat(filepath.Join(out, filepath.FromSlash(rel))); err != nil {
+				t.Errorf("missing %s: %v", rel, err)
+			}
+		}
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--partitioned",
+			"--sql", "SELECT json_extract(data, '$.id') AS id, year, region FROM out ORDER BY id", out)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "id,year,region\n1,2024,eu\n2,2024,us\n3,2023,eu\n4,2023,\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("a column the result lacks is refused and nothing is written", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "sales.csv", "id\n1\n")
+		out := filepath.Join(dir, "out")
+
+		_, _, err := runWithArgs(t, "--output-partition-by", "year", "-o", out, "--sql", "SELECT * FROM sales", path)
+		if err == nil || !strings.Contains(err.Error(), `no column "year"`) {
+			t.Errorf("Run error = %v, want the column named", err)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+			t.Errorf("the output directory holds %d entries, want only the input", len(entries))
+		}
+	})
+
+	t.Run("a directory that already holds files is refused before the import", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeCSV(t, dir, "sales.csv", "id,year\n1,2024\n")
+		out := filepath.Join(dir, "out")
+		writePartition(t, out, "year=2020/part-0.csv", "id\n9\n")
+
+		_, _, err := runWithArgs(t, "--output-partition-by", "year", "-o", out, "--sql", "SELECT * FROM sales", path)
+		if err == nil || !strings.Contains(err.Error(), "already holds files") {
+			t.Errorf("Run error = %v, want the destination refused", err)
+		}
+	})
+}
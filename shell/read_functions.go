@@ -0,0 +1,394 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/nao1215/filesql"
+	"github.com/nao1215/sqly/domain/model"
+	"github.com/nao1215/sqly/domain/sqltext"
+)
+
+// read_csv('data/2024.csv') and its siblings read a file from inside a
+// statement, so a script can reach a file halfway through without an .import,
+// and a --sql-file script, which takes no dot-commands, can reach one at all.
+//
+// They look like table-valued functions and are not registered as any. SQLite
+// fixes a table-valued function's columns when the function is registered, and
+// the columns of read_csv are the header of whichever file it is handed. So the
+// shell expands the calls before the statement runs instead: each file is loaded
+// by the importer an .import uses, into a table under the reserved prefix, the
+// call is replaced by that table's name, and the table is dropped once the
+// statement is done. Every format rule, encoding, and schema sidecar an .import
+// honors is honored here for the same reason: it is the same loader.
+//
+// The path has to be a string literal. A path computed from a column would need
+// the statement to run before sqly knows which files it reads, and the file is
+// loaded before the statement runs.
+
+// fileReadFunction is one of the read_* functions: the formats it reads, and
+// whether it takes a sheet after the path.
+type fileReadFunction struct {
+	name string
+	// exts are the extensions it reads, before any compression extension.
+	exts []string
+	// sheet is true for read_xlsx, whose second argument names a sheet.
+	sheet bool
+}
+
+// fileReadFunctions are the read_* functions, keyed by their lower-cased names.
+var fileReadFunctions = map[string]fileReadFunction{
+	"read_csv":     {name: "read_csv", exts: []string{model.ExtCSV}},
+	"read_json":    {name: "read_json", exts: []string{model.ExtJSON, model.ExtJSONL, model.ExtNDJSON}},
+	"read_parquet": {name: "read_parquet", exts: []string{model.ExtParquet}},
+	"read_xlsx":    {name: "read_xlsx", exts: []string{model.ExtExcel}, sheet: true},
+}
+
+// usage is how the function is called, for the error that says it was not.
+func (f fileReadFunction) usage() string {
+	if f.sheet {
+		return f.name + "('book" + f.exts[0] + "', 'Sheet1')"
+	}
+	return f.name + "('data" + f.exts[0] + "')"
+}
+
+// fileReadCall is one read_* call found in a statement.
+type fileReadCall struct {
+	fn fileReadFunction
+	// start and end are the byte offsets of the whole call, from the function
+	// name to its closing parenthesis, end exclusive.
+	start, end int
+	path       string
+	sheet      string
+}
+
+// label is the call as the user wrote it, with the URL redacted, for messages.
+func (c fileReadCall) label() string {
+	if c.sheet != "" {
+		return fmt.Sprintf("%s('%s', '%s')", c.fn.name, redactURL(c.path), c.sheet)
+	}
+	return fmt.Sprintf("%s('%s')", c.fn.name, redactURL(c.path))
+}
+
+// findFileReadCalls returns the read_* calls in a statement, in order. A name
+// counts as a call only in code and only when a "(" follows it, so a column
+// named read_csv, a quoted "read_csv", and 'read_csv(' inside a string are all
+// left alone.
+func findFileReadCalls(stmt string) ([]fileReadCall, error) {
+	var calls []fileReadCall
+	for tok := range sqltext.Tokens(stmt) {
+		if tok.Kind != sqltext.Word {
+			continue
+		}
+		fn, ok := fileReadFunctions[strings.ToLower(tok.Text(stmt))]
+		if !ok {
+			continue
+		}
+		open := skipSpace(stmt, tok.End)
+		if open >= len(stmt) || stmt[open] != '(' {
+			continue
+		}
+		args, end, ok := stringLiteralArgs(stmt, open+1)
+		if !ok || len(args) == 0 || len(args) > 2 || (len(args) == 2 && !fn.sheet) {
+			return nil, fmt.Errorf("%s takes the path of a file as a string literal, as in %s", fn.name, fn.usage())
+		}
+		call := fileReadCall{fn: fn, start: tok.Start, end: end, path: args[0]}
+		if len(args) == 2 {
+			call.sheet = args[1]
+		}
+		calls = append(calls, call)
+	}
+	return calls, nil
+}
+
+// stringLiteralArgs reads a comma-separated list of single-quoted SQL string
+// literals starting at i, just past an opening parenthesis, and returns them
+// with the offset just past the closing one. ok is false for anything else in
+// the list: an expression, a column, a number, or a list left open.
+func stringLiteralArgs(stmt string, i int) (args []string, end int, ok bool) {
+	for {
+		i = skipSpace(stmt, i)
+		if i >= len(stmt) || stmt[i] != '\'' {
+			return nil, 0, false
+		}
+		var b strings.Builder
+		for i++; ; i++ {
+			if i >= len(stmt) {
+				return nil, 0, false
+			}
+			if stmt[i] == '\'' {
+				if i+1 < len(stmt) && stmt[i+1] == '\'' {
+					b.WriteByte('\'')
+					i++
+					continue
+				}
+				break
+			}
+			b.WriteByte(stmt[i])
+		}
+		args = append(args, b.String())
+		i = skipSpace(stmt, i+1)
+		if i >= len(stmt) {
+			return nil, 0, false
+		}
+		switch stmt[i] {
+		case ')':
+			return args, i + 1, true
+		case ',':
+			i++
+		default:
+			return nil, 0, false
+		}
+	}
+}
+
+// skipSpace returns the offset of the first byte at or after i that is not
+// ASCII whitespace.
+func skipSpace(s string, i int) int {
+	for i < len(s) && strings.ContainsRune(" \t\r\n\f", rune(s[i])) {
+		i++
+	}
+	return i
+}
+
+// fileReadPaths returns the paths a statement's read_* calls name, for the
+// remote-input policy to look at before a script starts. A statement whose calls
+// cannot be read names none: running it is where that is reported.
+func fileReadPaths(stmt string) []string {
+	calls, err := findFileReadCalls(stmt)
+	if err != nil {
+		return nil
+	}
+	paths := make([]string, 0, len(calls))
+	for _, call := range calls {
+		paths = append(paths, call.path)
+	}
+	return paths
+}
+
+// definesStoredSQL reports whether a statement is a CREATE VIEW or CREATE
+// TRIGGER, whose body SQLite keeps and runs later.
+func definesStoredSQL(stmt string) bool {
+	var words []string
+	for tok := range sqltext.Tokens(stmt) {
+		if tok.Kind != sqltext.Word || tok.Depth != 0 {
+			continue
+		}
+		words = append(words, strings.ToUpper(tok.Text(stmt)))
+		if len(words) == 3 {
+			break
+		}
+	}
+	if len(words) < 2 || words[0] != "CREATE" {
+		return false
+	}
+	kind := words[1]
+	if (kind == "TEMP" || kind == "TEMPORARY") && len(words) == 3 {
+		kind = words[2]
+	}
+	return kind == "VIEW" || kind == "TRIGGER"
+}
+
+// expandFileReads loads the files a statement's read_* calls name and returns
+// the statement with each call replaced by the table its file was loaded into.
+// release drops those tables and removes what was staged for them; it is never
+// nil, and the caller runs it once the statement has run.
+//
+// A view or a trigger is refused rather than expanded. Either would keep the
+// name of a table that is dropped the moment the CREATE finishes, and fail on
+// first use with "no such table" naming a table the user never wrote.
+func (s *Shell) expandFileReads(ctx context.Context, stmt string) (expanded string, release func(), err error) {
+	release = func() {}
+	calls, err := findFileReadCalls(stmt)
+	if err != nil || len(calls) == 0 {
+		return stmt, release, err
+	}
+	if definesStoredSQL(stmt) {
+		return "", release, fmt.Errorf("%s cannot be used in a view or a trigger, which would outlive the table it reads; load the file with .import, or CREATE TABLE ... AS SELECT from it", calls[0].fn.name)
+	}
+	paths := make([]string, 0, len(calls))
+	for _, call := range calls {
+		paths = append(paths, call.path)
+	}
+	if err := s.authorizeRemoteInputs(paths); err != nil {
+		return "", release, err
+	}
+
+	var (
+		tables   []string
+		cleanups []func()
+	)
+	release = func() {
+		// The statement may have been canceled; the tables go regardless, since
+		// one left behind would still be in the session after it.
+		dropCtx := context.WithoutCancel(ctx)
+		for _, table := range tables {
+			_, _, _ = s.usecases.query.ExecSQL(dropCtx, "DROP TABLE IF EXISTS "+model.SQLDialectSQLite.QuoteIdentifier(table))
+		}
+		for _, cleanup := range slices.Backward(cleanups) {
+			cleanup()
+		}
+	}
+
+	names := make([]string, len(calls))
+	for i, call := range calls {
+		loaded, table, cleanup, err := s.loadFileRead(ctx, call)
+		tables = append(tables, loaded...)
+		if cleanup != nil {
+			cleanups = append(cleanups, cleanup)
+		}
+		if err != nil {
+			release()
+			return "", func() {}, err
+		}
+		names[i] = table
+	}
+
+	var b strings.Builder
+	last := 0
+	for i, call := range calls {
+		b.WriteString(stmt[last:call.start])
+		b.WriteString(names[i])
+		last = call.end
+	}
+	b.WriteString(stmt[last:])
+	return b.String(), release, nil
+}
+
+// loadFileRead loads the file one read_* call names and returns every table the
+// load created, the one the call reads, and a cleanup for the staged files.
+// The tables are returned even with an error, so the caller can drop whatever
+// was created before it.
+//
+// The file is staged under a reserved name before it is loaded, because the
+// importer names a table after its file: data.csv would otherwise become a table
+// named data, replacing one the session already holds. The staged file is a link
+// to the real one where the platform allows it, and a copy where it does not.
+func (s *Shell) loadFileRead(ctx context.Context, call fileReadCall) (tables []string, table string, cleanup func(), err error) {
+	var cleanups []func()
+	cleanup = func() {
+		for _, c := range slices.Backward(cleanups) {
+			c()
+		}
+	}
+
+	path, release, info, err := s.resolveImportTarget(ctx, call.path)
+	if err != nil {
+		return nil, "", cleanup, fmt.Errorf("%s: %w", call.label(), err)
+	}
+	if release != nil {
+		cleanups = append(cleanups, release)
+	}
+	if info.IsDir() {
+		return nil, "", cleanup, fmt.Errorf("%s: %s is a directory; %s reads one file", call.label(), call.path, call.fn.name)
+	}
+
+	compression := filesql.NewCompressionFactory()
+	uncompressed := compression.RemoveCompressionExtension(path)
+	ext := strings.ToLower(filepath.Ext(uncompressed))
+	if !slices.Contains(call.fn.exts, ext) {
+		return nil, "", cleanup, fmt.Errorf("%s: %s reads %s files, not %q; use the read_ function for the file's format, or .import it",
+			call.label(), call.fn.name, strings.Join(call.fn.exts, ", "), filepath.Ext(uncompressed))
+	}
+
+	dir, err := os.MkdirTemp("", "sqly-read-")
+	if err != nil {
+		return nil, "", cleanup, fmt.Errorf("%s: create temp dir: %w", call.label(), err)
+	}
+	cleanups = append(cleanups, func() { _ = os.RemoveAll(dir) })
+	s.fileReads++
+	staged := filepath.Join(dir, fmt.Sprintf("%sread_%d%s%s", model.ReservedTablePrefix, s.fileReads, ext, path[len(uncompressed):]))
+	if err := stageFileRead(path, staged); err != nil {
+		return nil, "", cleanup, fmt.Errorf("%s: stage %s: %w", call.label(), call.path, err)
+	}
+
+	loadPath, releaseText, err := s.prepareImportLoadPath(staged, call.path)
+	if err != nil {
+		return nil, "", cleanup, fmt.Errorf("%s: %w", call.label(), err)
+	}
+	if releaseText != nil {
+		cleanups = append(cleanups, releaseText)
+	}
+
+	// The column types and keys an .import declares are for its own inputs,
+	// and none of them names this file. Its sidecar is the one beside the file
+	// the call names; a download, as for an .import, has none. The session's
+	// CSV dialect is session policy, like the encoding above, so it holds for a
+	// file a query reads as it does for one .import reads.
+	load := model.FileLoad{
+		Path:         loadPath,
+		SchemaSource: path,
+		NoSchema:     isRemoteURL(call.path),
+		Dialect:      fileDialect(s.state.csvDialect, loadPath),
+	}
+
+	tables, table, err = s.fileReadTables(loadPath, call)
+	if err != nil {
+		return nil, "", cleanup, fmt.Errorf("%s: %w", call.label(), err)
+	}
+	if err := s.usecases.importer.LoadFiles(ctx, load); err != nil {
+		return tables, "", cleanup, fmt.Errorf("%s: %w", call.label(), err)
+	}
+	return tables, table, cleanup, nil
+}
+
+// fileReadTables returns the tables loading a staged file creates and the one
+// the call reads. A workbook creates a table for each sheet it loads; the call
+// reads the sheet it names, or the only sheet when it names none.
+func (s *Shell) fileReadTables(loadPath string, call fileReadCall) (tables []string, table string, err error) {
+	if !s.usecases.importer.IsExcelFile(loadPath) {
+		table = s.usecases.importer.GetTableNameFromFilePath(loadPath)
+		return []string{table}, table, nil
+	}
+	sheets, err := s.usecases.importer.ExcelSheets(loadPath)
+	if err != nil {
+		return nil, "", err
+	}
+	var loaded []string
+	for _, sheet := range sheets {
+		if sheet.Visible || s.usecases.importer.IncludeHiddenSheets() {
+			loaded = append(loaded, sheet.Name)
+		}
+	}
+	tables, err = s.usecases.importer.ExcelSheetTableNames(loadPath, loaded)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if call.sheet == "" {
+		if len(loaded) != 1 {
+			return nil, "", fmt.Errorf("the workbook has %d sheets (%s); name the one to read, as in %s",
+				len(loaded), strings.Join(loaded, ", "), call.fn.usage())
+		}
+		return tables, tables[0], nil
+	}
+	if i := slices.Index(loaded, call.sheet); i >= 0 {
+		return tables, tables[i], nil
+	}
+	if slices.ContainsFunc(sheets, func(sheet model.ExcelSheet) bool { return sheet.Name == call.sheet }) {
+		return nil, "", fmt.Errorf("sheet %q is hidden; start sqly with --include-hidden-sheets to read it", call.sheet)
+	}
+	return nil, "", fmt.Errorf("the workbook has no sheet %q; its sheets are %s", call.sheet, strings.Join(loaded, ", "))
+}
+
+// stageFileRead puts the file at src where the importer will read it, at dest:
+// a symbolic link, or a copy where links cannot be made.
+func stageFileRead(src, dest string) error {
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	linkErr := os.Symlink(abs, dest)
+	if linkErr == nil {
+		return nil
+	}
+	if err := copyFileContents(src, dest); err != nil {
+		return errors.Join(linkErr, err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package shell
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindFileReadCalls(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		stmt  string
+		want  []string // path, or path|sheet
+		isErr bool
+	}{
+		{"one call", "SELECT * FROM read_csv('a.csv')", []string{"a.csv"}, false},
+		{"any case, space before the parenthesis", "SELECT * FROM READ_CSV ( 'a.csv' )", []string{"a.csv"}, false},
+		{"a doubled quote in the path", "SELECT * FROM read_csv('it''s.csv')", []string{"it's.csv"}, false},
+		{"a sheet", "SELECT * FROM read_xlsx('b.xlsx', 'Q1')", []string{"b.xlsx|Q1"}, false},
+		{"two calls", "SELECT * FROM read_csv('a.csv') JOIN read_json('b.json') USING (id)", []string{"a.csv", "b.json"}, false},
+		{"a column of the same name", "SELECT read_csv FROM t", nil, false},
+		{"inside a string", "SELECT 'read_csv(''a.csv'')'", nil, false},
+		{"a quoted identifier", `SELECT * FROM "read_csv"`, nil, false},
+		{"a column for the path", "SELECT * FROM read_csv(path)", nil, true},
+		{"a sheet for read_csv", "SELECT * FROM read_csv('a.csv', 'x')", nil, true},
+		{"no argument", "SELECT * FROM read_parquet()", nil, true},
+		{"an unterminated literal", "SELECT * FROM read_csv('a.csv", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			calls, err := findFileReadCalls(tt.stmt)
+			if (err != nil) != tt.isErr {
+				t.Fatalf("findFileReadCalls(%q) error = %v, want error %v", tt.stmt, err, tt.isErr)
+			}
+			var got []string
+			for _, call := range calls {
+				if !strings.HasSuffix(tt.stmt[call.start:call.end], ")") {
+					t.Errorf("call span %q is not the whole call", tt.stmt[call.start:call.end])
+				}
+				entry := call.path
+				if call.sheet != "" {
+					entry += "|" + call.sheet
+				}
+				got = append(got, entry)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("findFileReadCalls(%q) = %v, want %v", tt.stmt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileReadFunctions(t *testing.T) {
+	t.Run("a file joins a table the session imported", func(t *testing.T) {
+		dir := t.TempDir()
+		users := writeCSV(t, dir, "users.csv", "id,name\n1,a\n2,b\n")
+		orders := writeCSV(t, dir, "orders.csv", "user_id,total\n1,10\n1,5\n2,7\n")
+
+		query := "SELECT u.name, SUM(o.total) AS total FROM users u JOIN read_csv('" + orders +
+			"') o ON o.user_id = u.id GROUP BY u.name ORDER BY u.name"
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--sql", query, users)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "name,total\na,15\nb,7\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("the table is gone once the statement has run", func(t *testing.T) {
+		dir := t.TempDir()
+		data := writeCSV(t, dir, "data.csv", "id\n1\n")
+		script := filepath.Join(dir, "run.sql")
+		writeScript(t, script, "CREATE TABLE kept AS SELECT * FROM read_csv('"+data+"');\n"+
+			"SELECT (SELECT COUNT(*) FROM kept) AS n, group_concat(name) AS tables FROM sqlite_master WHERE type = 'table';\n")
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--sql-file", script)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "n,tables\n1,kept\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("a schema sidecar beside the file applies to the table it is read as", func(t *testing.T) {
+		dir := t.TempDir()
+		data := writeCSV(t, dir, "o.csv", "id,zip\n1,123\n")
+		sidecar := `{"schema_version": 1, "tables": [{"name": "o", "columns": [` +
+			`{"name": "id", "type": "INTEGER"}, {"name": "zip", "type": "TEXT"}]}]}`
+		if err := os.WriteFile(data+".schema.json", []byte(sidecar), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--sql", "SELECT typeof(zip) AS t FROM read_csv('"+data+"')")
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "t\ntext\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("a workbook sheet is named", func(t *testing.T) {
+		dir := t.TempDir()
+		book := writeWorkbook(t, dir, "book.xlsx",
+			sheetSpec{name: "First", value: "one"},
+			sheetSpec{name: "Second", value: "two"},
+		)
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--sql", "SELECT v FROM read_xlsx('"+book+"', 'Second')")
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "v\ntwo\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+
+		_, _, err = runWithArgs(t, "--sql", "SELECT v FROM read_xlsx('"+book+"')")
+		if err == nil || !strings.Contains(err.Error(), "name the one to read") {
+			t.Errorf("Run without a sheet error = %v, want one asking for the sheet", err)
+		}
+	})
+
+	t.Run("a file of another format is refused", func(t *testing.T) {
+		dir := t.TempDir()
+		data := writeCSV(t, dir, "data.csv", "id\n1\n")
+
+		_, _, err := runWithArgs(t, "--sql", "SELECT * FROM read_json('"+data+"')")
+		if err == nil || !strings.Contains(err.Error(), "read_json reads .json") {
+			t.Errorf("Run error = %v, want the format refused", err)
+		}
+	})
+
+	t.Run("a view is refused", func(t *testing.T) {
+		dir := t.TempDir()
+		data := writeCSV(t, dir, "data.csv", "id\n1\n")
+
+		_, _, err := runWithArgs(t, "--sql", "CREATE VIEW v AS SELECT * FROM read_csv('"+data+"')")
+		if err == nil || !strings.Contains(err.Error(), "cannot be used in a view") {
+			t.Errorf("Run error = %v, want the view refused", err)
+		}
+	})
+
+	t.Run("a URL needs --allow-remote", func(t *testing.T) {
+		dir := t.TempDir()
+		script := filepath.Join(dir, "run.sql")
+		writeScript(t, script, "SELECT 1;\nSELECT * FROM read_csv('https://example.com/data.csv');\n")
+
+		stdout, _, err := runWithArgs(t, "--sql-file", script)
+		var invocationErr *invocationError
+		if !errors.As(err, &invocationErr) || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("Run error = %v, want an invocationError naming line 2", err)
+		}
+		if stdout != "" {
+			t.Errorf("stdout = %q, want nothing: the script must be refused before it runs", stdout)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package shell
+
+import (
+	"testing"
+)
+
+func TestRegexpFunctions(t *testing.T) {
+	dir := t.TempDir()
+	path := writeCSV(t, dir, "access.csv", "line\nGET /api/users 200\nPOST /api/login 500\nGET /health 200\n")
+
+	t.Run("the functions read a log line apart", func(t *testing.T) {
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--sql",
+			"SELECT regexp_extract(line, '(\\w+) (\\S+) (\\d+)', 2) AS path, regexp_split(line, ' ', -1) AS status "+
+				"FROM access WHERE line REGEXP '^GET' AND regexp_like(line, '/API/', 'i')", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "path,status\n/api/users,200\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("an invalid pattern fails the statement", func(t *testing.T) {
+		_, _, err := runWithArgs(t, "--sql", "SELECT regexp_like(line, '(') FROM access", path)
+		if err == nil {
+			t.Fatal("Run succeeded, want an error")
+		}
+		if got := ExitCode(err); got != ExitFailure {
+			t.Errorf("ExitCode = %d, want ExitFailure (%d): %v", got, ExitFailure, err)
+		}
+	})
+
+	for _, tt := range []struct {
+		dialect string
+		query   string
+	}{
+		{"mysql", "SELECT count(*) AS n FROM access WHERE REGEXP_LIKE(line, ' 5[0-9]{2}$')"},
+		{"mysql", "SELECT count(*) AS n FROM access WHERE line RLIKE ' 5[0-9]{2}$'"},
+		{"postgresql", "SELECT count(*) AS n FROM access WHERE line ~ ' 5[0-9]{2}$'"},
+		{"postgresql", "SELECT count(*) AS n FROM access WHERE regexp_like(line, ' 5[0-9]{2}$')"},
+	} {
+		t.Run("--dialect "+tt.dialect+": "+tt.query, func(t *testing.T) {
+			stdout, stderr, err := runWithArgs(t, "--dialect", tt.dialect, "--output-format", "csv", "--sql", tt.query, path)
+			if err != nil {
+				t.Fatalf("Run: %v (%s)", err, stderr)
+			}
+			if want := "n\n1\n"; stdout != want {
+				t.Errorf("stdout = %q, want %q", stdout, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nao1215/sqly/config"
+)
+
+// .reload picks up a source file another process rewrote, without restarting
+// the session. It is an import in every way that matters — the same staging,
+// the same preflight, one transaction for every source it reads — so a reload
+// that fails on the third of three files leaves all three tables as they were.
+// What it adds is the choice of what to read: only the sources that changed
+// since the session last read them, decided from the session's own record.
+
+// sourceStamp is what the filesystem said about a source file when the session
+// last read it or wrote it. A size or a modification time that differs now means
+// the file was rewritten.
+type sourceStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// stampSource returns the stamp of a local regular file, or nil for anything
+// .reload cannot compare: a download, a staged stdin dataset, a pseudo-file.
+func stampSource(path string) *sourceStamp {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+	return &sourceStamp{size: info.Size(), modTime: info.ModTime()}
+}
+
+// recordSourceStamp remembers the stamp a source had when its tables were read
+// from it or written to it. A member of an archive is stamped as the archive,
+// which is the file on disk that changes.
+func (s *Shell) recordSourceStamp(source string, stamp *sourceStamp) {
+	source = absoluteSource(archiveFileOf(source))
+	if s.sourceStamps == nil {
+		s.sourceStamps = make(map[string]sourceStamp)
+	}
+	if stamp == nil {
+		delete(s.sourceStamps, source)
+		return
+	}
+	s.sourceStamps[source] = *stamp
+}
+
+// sourceChanged reports whether a source file differs from what the session
+// last read from it or wrote to it.
+//
+// The stamp answers when there is one. A table restored from a --db file has
+// none — the process that read the file is gone — so the digest its record
+// holds answers instead. A source with neither is reported changed: reading a
+// file again that turns out to be the same costs time, while keeping one that
+// turns out to be different costs a wrong answer.
+func (s *Shell) sourceChanged(source string) (bool, error) {
+	if isRemoteURL(source) {
+		return true, nil
+	}
+	info, err := os.Stat(source)
+	if err != nil {
+		return false, localImportAccessError(source, err)
+	}
+	if stamp, ok := s.sourceStamps[source]; ok {
+		return info.Size() != stamp.size || !info.ModTime().Equal(stamp.modTime), nil
+	}
+	for _, rec := range s.sourceRecords {
+		if archiveFileOf(rec.Source) != source {
+			continue
+		}
+		digest, err := digestSource(source)
+		if err != nil {
+			return false, fmt.Errorf("failed to read file %s: %w", source, err)
+		}
+		return digest == nil || digest.size != rec.Size || digest.sum != rec.Digest, nil
+	}
+	return true, nil
+}
+
+// reloadSource is one source file .reload will read, with the tables it
+// currently backs.
+type reloadSource struct {
+	source        string
+	tables        []string
+	fromDirectory bool
+	// union is set for the table of a union import, which is read again from
+	// its inputs rather than from source.
+	union *unionImport
+}
+
+// reloadCommand re-reads the source files of the named tables, or of every
+// table that has one, when they changed on disk.
+func (c CommandList) reloadCommand(ctx context.Context, s *Shell, argv []string) error {
+	sources, err := s.reloadSources(argv)
+	if err != nil {
+		return err
+	}
+	changed, err := s.changedSources(sources)
+	if err != nil {
+		return s.reportImportFailure(err)
+	}
+	if len(changed) == 0 {
+		fmt.Fprintln(config.Stderr, "nothing to reload: every source is unchanged since the session read it")
+		return nil
+	}
+
+	// Say what is about to be thrown away before it is. Reloading is what was
+	// asked for, so it goes ahead; but an edit that was never saved exists only
+	// in the table, and after this it exists nowhere.
+	for _, src := range changed {
+		for _, name := range src.tables {
+			if s.tableNeedsSourceWrite(ctx, name) {
+				fmt.Fprintf(config.Stderr,
+					"warning: table %q has changes that were not saved to %s; reloading discards them\n",
+					name, src.source)
+			}
+		}
+	}
+	return s.reload(ctx, changed)
+}
+
+// changedSources returns the sources that changed since the session last read
+// or wrote them.
+func (s *Shell) changedSources(sources []reloadSource) ([]reloadSource, error) {
+	var changed []reloadSource
+	for _, src := range sources {
+		var ok bool
+		var err error
+		if src.union != nil {
+			ok, err = s.unionChanged(src.union)
+		} else {
+			ok, err = s.sourceChanged(src.source)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			changed = append(changed, src)
+		}
+	}
+	return changed, nil
+}
+
+// reload reads the sources again and replaces their tables, in one transaction.
+func (s *Shell) reload(ctx context.Context, sources []reloadSource) error {
+	plan, err := s.resolveReloadPlan(ctx, sources)
+	if err != nil {
+		return s.reportImportFailure(err)
+	}
+	defer plan.release()
+	if err := s.loadPlan(ctx, plan); err != nil {
+		return err
+	}
+	for _, src := range sources {
+		fmt.Fprintf(config.Stderr, "reloaded %s from %s\n", strings.Join(src.tables, ", "), src.source)
+	}
+	return nil
+}
+
+// reloadSources groups the tables to reload by the source they came from. With
+// no arguments that is every table with a source file; with arguments, each one
+// must be a table the session imported from a file.
+//
+// A source backs every table it produced, so naming one sheet of a workbook
+// reloads the workbook, and with it every sheet: there is no reading one sheet
+// of a file without replacing what the others were read from.
+func (s *Shell) reloadSources(argv []string) ([]reloadSource, error) {
+	var names []string
+	if len(argv) == 0 {
+		for name, source := range s.tableSources {
+			if source != stdinTableSource {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return nil, errors.New("no table in this session was imported from a file, so there is nothing to reload")
+		}
+	} else {
+		for _, arg := range argv {
+			name, ok := s.tableWithSource(arg)
+			if !ok {
+				return nil, &invocationError{Err: fmt.Errorf(
+					"table %q was not imported from a file in this session, so it has nothing to reload from\n%s", arg, reloadUsageText())}
+			}
+			if s.tableSources[name] == stdinTableSource {
+				return nil, fmt.Errorf("table %q came from stdin, which cannot be read again", name)
+			}
+			names = append(names, name)
+		}
+	}
+
+	bySource := make(map[string]*reloadSource)
+	var order []string
+	for name, recorded := range s.tableSources {
+		if recorded == stdinTableSource {
+			continue
+		}
+		// The members of an archive are read again together, from the archive,
+		// because there is no reading one member without unpacking the rest.
+		source := archiveFileOf(recorded)
+		// Every table of a source is listed, named or not, because every one of
+		// them is about to be replaced.
+		if !slices.ContainsFunc(names, func(n string) bool { return archiveFileOf(s.tableSources[n]) == source }) {
+			continue
+		}
+		src, ok := bySource[source]
+		if !ok {
+			src = &reloadSource{source: source}
+			bySource[source] = src
+			order = append(order, source)
+		}
+		src.tables = append(src.tables, name)
+		src.fromDirectory = src.fromDirectory || s.dirImported[name]
+		if union, ok := s.unionImports[name]; ok {
+			src.union = union
+		}
+	}
+	// Map order is random; a fixed order keeps which source a failure names the
+	// same on every run.
+	slices.Sort(order)
+	sources := make([]reloadSource, 0, len(order))
+	for _, source := range order {
+		src := bySource[source]
+		slices.Sort(src.tables)
+		sources = append(sources, *src)
+	}
+	return sources, nil
+}
+
+// tableWithSource finds the table a .reload argument names. SQLite matches an
+// ASCII table name in any case, so .reload does too.
+func (s *Shell) tableWithSource(arg string) (string, bool) {
+	if _, ok := s.tableSources[arg]; ok {
+		return arg, true
+	}
+	for name := range s.tableSources {
+		if strings.EqualFold(name, arg) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// resolveReloadPlan resolves the changed sources into an import plan. It is
+// resolveImportPlan for inputs the session already knows: a table found in a
+// directory is read back as one, so reloading it does not make it saveable.
+func (s *Shell) resolveReloadPlan(ctx context.Context, sources []reloadSource) (*importPlan, error) {
+	paths := make([]string, 0, len(sources))
+	for _, src := range sources {
+		if src.union != nil {
+			paths = append(paths, src.union.inputs...)
+			continue
+		}
+		paths = append(paths, src.source)
+	}
+	if err := s.authorizeRemoteInputs(paths); err != nil {
+		return nil, err
+	}
+
+	plan := &importPlan{reloading: true}
+	for _, src := range sources {
+		// Each source is read the way it was last read, whatever the session's
+		// own dialect is now.
+		plan.dialect = s.importDialects[src.tables[0]]
+		if src.union != nil {
+			union := unionSpec{table: src.tables[0], sourceColumn: src.union.sourceColumn, partitioned: src.union.partitioned, option: "--union"}
+			if err := s.planUnion(ctx, plan, src.union.inputs, src.union.dir, union); err != nil {
+				plan.release()
+				return nil, err
+			}
+			continue
+		}
+		cleanPath, cleanup, _, err := s.resolveImportTarget(ctx, src.source)
+		if cleanup != nil {
+			plan.cleanups = append(plan.cleanups, cleanup)
+		}
+		if err != nil {
+			plan.release()
+			return nil, err
+		}
+		if err := s.planInput(ctx, plan, cleanPath, src.source, src.fromDirectory); err != nil {
+			plan.release()
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+func reloadUsageText() string {
+	return "[Usage]\n  .reload [TABLE...]"
+}
@@ -0,0 +1,202 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newReloadShell starts an in-memory session with the given inputs imported.
+func newReloadShell(t *testing.T, inputs ...string) *Shell {
+	t.Helper()
+	s, cleanup, err := newShell(t, append([]string{"sqly", "--output-format", "csv"}, inputs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cleanup)
+	if err := s.init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func queryReloadShell(t *testing.T, s *Shell, query string) string {
+	t.Helper()
+	got, err := getExecStdOutput(t, s.exec, query)
+	if err != nil {
+		t.Fatalf("exec(%q): %v", query, err)
+	}
+	// A session separates consecutive result sets with a blank line.
+	return strings.TrimLeft(string(got), "\n")
+}
+
+func TestReloadCommand(t *testing.T) {
+	t.Run("an unchanged source is not read again", func(t *testing.T) {
+		csvPath := filepath.Join(t.TempDir(), "daily.csv")
+		writeDaily(t, csvPath, "id,name\n1,a\n")
+		s := newReloadShell(t, csvPath)
+
+		stderr, err := getExecStdErrOutput(t, s.exec, ".reload")
+		if err != nil {
+			t.Fatalf(".reload: %v", err)
+		}
+		if !strings.Contains(string(stderr), "nothing to reload") {
+			t.Errorf(".reload stderr = %q, want nothing reloaded", stderr)
+		}
+	})
+
+	t.Run("a rewritten source replaces its table", func(t *testing.T) {
+		csvPath := filepath.Join(t.TempDir(), "daily.csv")
+		writeDaily(t, csvPath, "id,name\n1,a\n")
+		s := newReloadShell(t, csvPath)
+
+		writeDaily(t, csvPath, "id,name\n1,a\n2,b\n3,c\n")
+		stderr, err := getExecStdErrOutput(t, s.exec, ".reload")
+		if err != nil {
+			t.Fatalf(".reload: %v", err)
+		}
+		if want := "reloaded daily from " + csvPath; !strings.Contains(string(stderr), want) {
+			t.Errorf(".reload stderr = %q, want it to contain %q", stderr, want)
+		}
+		if got := queryReloadShell(t, s, "SELECT count(*) AS n FROM daily"); got != "n\n3\n" {
+			t.Errorf("table after .reload = %q, want the three rows the file now holds", got)
+		}
+
+		stderr, err = getExecStdErrOutput(t, s.exec, ".reload")
+		if err != nil {
+			t.Fatalf("second .reload: %v", err)
+		}
+		if !strings.Contains(string(stderr), "nothing to reload") {
+			t.Errorf("second .reload stderr = %q, want the file it just read treated as unchanged", stderr)
+		}
+	})
+
+	t.Run("unsaved edits are named before they are discarded", func(t *testing.T) {
+		csvPath := filepath.Join(t.TempDir(), "daily.csv")
+		writeDaily(t, csvPath, "id,name\n1,a\n")
+		s := newReloadShell(t, csvPath)
+
+		if _, err := getExecStdErrOutput(t, s.exec, "UPDATE daily SET name = 'z'"); err != nil {
+			t.Fatal(err)
+		}
+		writeDaily(t, csvPath, "id,name\n1,b\n2,c\n")
+		stderr, err := getExecStdErrOutput(t, s.exec, ".reload daily")
+		if err != nil {
+			t.Fatalf(".reload: %v", err)
+		}
+		if want := `table "daily" has changes that were not saved to ` + csvPath; !strings.Contains(string(stderr), want) {
+			t.Errorf(".reload stderr = %q, want it to contain %q", stderr, want)
+		}
+		if got := queryReloadShell(t, s, "SELECT name FROM daily ORDER BY id"); got != "name\nb\nc\n" {
+			t.Errorf("table after .reload = %q, want the file's rows", got)
+		}
+	})
+
+	t.Run("a named table reloads only its own source", func(t *testing.T) {
+		dir := t.TempDir()
+		dailyPath := filepath.Join(dir, "daily.csv")
+		weeklyPath := filepath.Join(dir, "weekly.csv")
+		writeDaily(t, dailyPath, "id\n1\n")
+		writeDaily(t, weeklyPath, "id\n1\n")
+		s := newReloadShell(t, dailyPath, weeklyPath)
+
+		writeDaily(t, dailyPath, "id\n1\n2\n")
+		writeDaily(t, weeklyPath, "id\n1\n2\n")
+		if _, err := getExecStdErrOutput(t, s.exec, ".reload DAILY"); err != nil {
+			t.Fatalf(".reload: %v", err)
+		}
+		if got := queryReloadShell(t, s, "SELECT count(*) AS n FROM daily"); got != "n\n2\n" {
+			t.Errorf("daily after .reload DAILY = %q, want it reloaded", got)
+		}
+		if got := queryReloadShell(t, s, "SELECT count(*) AS n FROM weekly"); got != "n\n1\n" {
+			t.Errorf("weekly after .reload DAILY = %q, want it left as imported", got)
+		}
+	})
+
+	t.Run("one source that fails to load leaves every table as it was", func(t *testing.T) {
+		dir := t.TempDir()
+		dailyPath := filepath.Join(dir, "daily.csv")
+		weeklyPath := filepath.Join(dir, "weekly.csv")
+		writeDaily(t, dailyPath, "id,name\n1,a\n")
+		writeDaily(t, weeklyPath, "id,name\n1,a\n")
+		s := newReloadShell(t, dailyPath, weeklyPath)
+
+		writeDaily(t, dailyPath, "id,name\n1,a\n2,b\n")
+		writeDaily(t, weeklyPath, "id,name\n1,a\n2,b,extra\n")
+		if _, err := getExecStdErrOutput(t, s.exec, ".reload"); err == nil {
+			t.Fatal(".reload succeeded with a source that has a malformed row")
+		}
+		if got := queryReloadShell(t, s, "SELECT count(*) AS n FROM daily"); got != "n\n1\n" {
+			t.Errorf("daily after a failed .reload = %q, want the row it had before", got)
+		}
+	})
+
+	t.Run("a source the session saved in place is not reloaded", func(t *testing.T) {
+		csvPath := filepath.Join(t.TempDir(), "daily.csv")
+		writeDaily(t, csvPath, "id,name\n1,a\n")
+		s := newReloadShell(t, csvPath)
+
+		for _, req := range []string{"UPDATE daily SET name = 'longer name'", ".save --in-place"} {
+			if _, err := getExecStdErrOutput(t, s.exec, req); err != nil {
+				t.Fatalf("exec(%q): %v", req, err)
+			}
+		}
+		stderr, err := getExecStdErrOutput(t, s.exec, ".reload")
+		if err != nil {
+			t.Fatalf(".reload: %v", err)
+		}
+		if !strings.Contains(string(stderr), "nothing to reload") {
+			t.Errorf(".reload stderr = %q, want the file the session wrote treated as unchanged", stderr)
+		}
+	})
+
+	t.Run("a table restored from --db is compared by digest", func(t *testing.T) {
+		dir := t.TempDir()
+		dbPath := filepath.Join(dir, "session.db")
+		csvPath := filepath.Join(dir, "daily.csv")
+		writeDaily(t, csvPath, "id,name\n1,a\n")
+		runPersistentSession(t, dbPath, []string{csvPath})
+
+		if stderr := runPersistentSession(t, dbPath, nil, ".reload"); !strings.Contains(stderr, "nothing to reload") {
+			t.Errorf(".reload in a reopened session stderr = %q, want the recorded digest to match", stderr)
+		}
+		writeDaily(t, csvPath, "id,name\n1,a\n2,b\n")
+		if stderr := runPersistentSession(t, dbPath, nil, ".reload"); !strings.Contains(stderr, "reloaded daily") {
+			t.Errorf(".reload in a reopened session stderr = %q, want the changed file reloaded", stderr)
+		}
+		if got := queryPersistentSession(t, dbPath, "SELECT count(*) AS n FROM daily"); got != "n\n2\n" {
+			t.Errorf("table after .reload = %q, want the rows the file now holds", got)
+		}
+	})
+
+	t.Run("a table with no source is a usage error", func(t *testing.T) {
+		csvPath := filepath.Join(t.TempDir(), "daily.csv")
+		writeDaily(t, csvPath, "id\n1\n")
+		s := newReloadShell(t, csvPath)
+
+		err := NewCommands().reloadCommand(context.Background(), s, []string{"nosuch"})
+		var invocation *invocationError
+		if !errors.As(err, &invocation) {
+			t.Errorf("reloadCommand(nosuch) error = %v, want an invocationError", err)
+		}
+	})
+
+	t.Run("a deleted source is reported", func(t *testing.T) {
+		csvPath := filepath.Join(t.TempDir(), "daily.csv")
+		writeDaily(t, csvPath, "id\n1\n")
+		s := newReloadShell(t, csvPath)
+
+		if err := os.Remove(csvPath); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := getExecStdErrOutput(t, s.exec, ".reload"); err == nil {
+			t.Error(".reload succeeded although the source file is gone")
+		}
+		if got := queryReloadShell(t, s, "SELECT count(*) AS n FROM daily"); got != "n\n1\n" {
+			t.Errorf("table after a failed .reload = %q, want it kept", got)
+		}
+	})
+}
@@ -23,7 +23,7 @@ const (
 )
 
 const (
-	remoteSupportedFormatsHelp = "csv, tsv, ltsv, json, jsonl, parquet, xlsx [+compressed], ach, fed"
+	remoteSupportedFormatsHelp = "csv, tsv, ltsv, json, jsonl, xml, parquet, xlsx [+compressed], ach, fed, db, sqlite, sqlite3, zip, tar [+compressed]"
 	remoteCSVFilename          = "download.csv"
 	remoteJSONContentType      = "application/json"
 	remoteJSONFilename         = "download.json"
@@ -264,7 +264,7 @@ func (s *Shell) downloadRemoteInput(ctx context.Context, rawURL string) (string,
 	if err != nil {
 		return "", nil, err
 	}
-	if !s.usecases.importer.IsSupportedFile(filename) {
+	if !s.isImportableFile(filename) {
 		return "", nil, fmt.Errorf("unsupported remote file format: %s (supported: %s)", filename, remoteSupportedFormatsHelp)
 	}
 
@@ -322,7 +322,7 @@ func (s *Shell) remoteDownloadFilename(rawURL string, resp *http.Response) (stri
 	// the user typed has to find the table that URL produced. Left to the order
 	// below, a redirect turned "SELECT * FROM sales" against sales.csv into "no
 	// such table", and a header could name the table anything it liked.
-	if base := filepath.Base(remoteFilenameHint(rawURL)); s.usecases.importer.IsSupportedFile(base) {
+	if base := filepath.Base(remoteFilenameHint(rawURL)); s.isImportableFile(base) {
 		return base, nil
 	}
 
@@ -342,7 +342,7 @@ func (s *Shell) remoteDownloadFilename(rawURL string, resp *http.Response) (stri
 		if candidate == "" || candidate == "." || candidate == string(filepath.Separator) {
 			continue
 		}
-		if s.usecases.importer.IsSupportedFile(candidate) {
+		if s.isImportableFile(candidate) {
 			return candidate, nil
 		}
 		if first == "" {
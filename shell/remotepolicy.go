@@ -83,7 +83,8 @@ func (s *Shell) authorizeRemoteInputs(inputs []string) error {
 }
 
 // authorizeScriptRemoteInputs applies the same policy to the `.import` lines of
-// a parsed script, before the script's first statement runs.
+// a parsed script, and to the URLs its statements hand read_csv and the other
+// read_* functions, before the script's first statement runs.
 //
 // Checking the whole script up front rather than at the failing line is what
 // makes the refusal clean: no earlier statement has executed, no earlier import
@@ -94,6 +95,12 @@ func (s *Shell) authorizeScriptRemoteInputs(elements []scriptElement) error {
 		return nil
 	}
 	for _, element := range elements {
+		if !element.isDotCommand() {
+			if err := s.authorizeRemoteInputs(fileReadPaths(element.text)); err != nil {
+				return &scriptError{Err: fmt.Errorf("line %d: %w", element.startLine, err)}
+			}
+			continue
+		}
 		if element.commandName() != importCommand {
 			continue
 		}
@@ -28,6 +28,7 @@ const (
 	flagSQLFile  = "--sql-file"
 	flagScript   = "--script-file"
 	flagInspect  = "--inspect"
+	flagServe    = "--serve"
 	devStdinPath = "/dev/stdin"
 )
 
@@ -49,6 +50,8 @@ const (
 	modeScriptFile
 	// modeInspect prints the JSON report and exits.
 	modeInspect
+	// modeServe answers HTTP queries until it is stopped.
+	modeServe
 )
 
 // String names the mode for an error message.
@@ -66,6 +69,8 @@ func (m runMode) String() string {
 		return flagScript
 	case modeInspect:
 		return flagInspect
+	case modeServe:
+		return flagServe
 	default:
 		return "unknown"
 	}
@@ -106,6 +111,8 @@ func (s *Shell) planRun() (runPlan, error) {
 	switch {
 	case arg.InspectFlag:
 		return s.planWithQuerySource(runPlan{mode: modeInspect, stdinIsDataset: stdinIsDataset})
+	case arg.ServeAddr != "":
+		return s.planWithQuerySource(runPlan{mode: modeServe, stdinIsDataset: stdinIsDataset})
 	case arg.Query != "":
 		return s.planWithQuerySource(runPlan{mode: modeInlineSQL, stdinIsDataset: stdinIsDataset})
 	case arg.SQLFilePath != "":
@@ -182,6 +189,8 @@ func (m runMode) source() string {
 		return "the script in the file"
 	case modeInspect:
 		return "the files named on the command line"
+	case modeServe:
+		return "the queries it is sent over HTTP"
 	default:
 		return "the statement on the command line"
 	}
@@ -20,6 +20,10 @@ const inPlaceArg = "--in-place"
 // source. See planSymlinkPolicy for why that needs asking for.
 const followSymlinksArg = "--follow-symlinks"
 
+// asSQLiteArg is the .save argument that writes the whole session into one
+// SQLite database instead of back to its source files.
+const asSQLiteArg = "--as-sqlite"
+
 // noDataChangedMessage explains a save that wrote nothing because the session
 // left every table as imported, so a read-only session never looks like a
 // successful write-back that silently produced no file.
@@ -27,8 +31,12 @@ const noDataChangedMessage = "no table data changed in this session; nothing to
 
 // saveCommand writes the current tables back to files from the interactive
 // shell. ".save DIR" writes into a directory without touching the sources;
-// ".save --in-place" overwrites the source files.
+// ".save --in-place" overwrites the source files; ".save --as-sqlite FILE"
+// writes every table into one SQLite database.
 func (c CommandList) saveCommand(ctx context.Context, s *Shell, argv []string) error {
+	if slices.Contains(argv, asSQLiteArg) {
+		return s.saveAsSQLite(ctx, argv)
+	}
 	// --follow-symlinks modifies an in-place save and nothing else, so it is
 	// stripped here and validated against the destination below. Taking it as a
 	// second positional argument would report it as "too many arguments", which
@@ -43,11 +51,13 @@ func (c CommandList) saveCommand(ctx context.Context, s *Shell, argv []string) e
 		// A missing or extra argument is a command error so a batch script fails
 		// fast instead of skipping the save and exiting 0. The usage and note ride
 		// on the error path.
-		return &invocationError{Err: errors.New(".save requires a single argument: a directory or --in-place\n" +
+		return &invocationError{Err: errors.New(".save requires a single argument: a directory, --in-place, or --as-sqlite FILE\n" +
 			"[Usage]\n" +
-			"  .save DIRECTORY   write each table into DIRECTORY (originals untouched)\n" +
-			"  .save --in-place  overwrite each table's source file\n" +
-			"                    add --follow-symlinks to write through a symlinked source\n" +
+			"  .save DIRECTORY         write each table into DIRECTORY (originals untouched)\n" +
+			"  .save --in-place        overwrite each table's source file\n" +
+			"                          add --follow-symlinks to write through a symlinked source\n" +
+			"  .save --as-sqlite FILE  write every table, with its schema and indexes, into\n" +
+			"                          the SQLite database FILE\n" +
 			"[Note]\n" +
 			"  csv/tsv/ltsv/parquet sources are written; compression is preserved.\n" +
 			"  A whole ACH/Fedwire set is reconstructed back into a single .ach/.fed file\n" +
@@ -104,6 +114,77 @@ func (c CommandList) saveCommand(ctx context.Context, s *Shell, argv []string) e
 	return s.writeBack(ctx, destDir, followSymlinks)
 }
 
+// saveAsSQLite writes every table of the session into one SQLite database.
+//
+// It differs from the other two forms in what it saves and when. They write
+// back the imported tables the session changed, each to a file of its own
+// format, and skip a table made with CREATE TABLE because it has no file to go
+// back to. A database has room for all of them, so this form saves every table,
+// changed or not, imported or created, with its schema and indexes: it is how
+// a session's work is kept as a whole rather than how an edit reaches its
+// source. That is also why it runs in a session that changed nothing.
+//
+// The database is written to a staging file and moved into place, like every
+// other file sqly writes, so a failed copy never leaves a half-written database
+// behind or damages the one it was replacing.
+func (s *Shell) saveAsSQLite(ctx context.Context, argv []string) (err error) {
+	i := slices.Index(argv, asSQLiteArg)
+	rest := append(append([]string{}, argv[:i]...), argv[i+1:]...)
+	if len(rest) != 1 || strings.HasPrefix(rest[0], "-") || strings.TrimSpace(rest[0]) == "" {
+		return &invocationError{Err: fmt.Errorf(".save %s takes a single database file, e.g. .save %s session.db; it does not combine with %s or %s",
+			asSQLiteArg, asSQLiteArg, inPlaceArg, followSymlinksArg)}
+	}
+	// A database copy of the session is made by SQLite, table by table, and
+	// never passes through the export the mask is applied in.
+	if len(s.state.mask) > 0 {
+		return &invocationError{Err: fmt.Errorf(".save %s copies every table as it is and cannot apply --mask %s; export the masked tables with .save DIR or .dump",
+			asSQLiteArg, s.state.mask)}
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		var already *writeBackError
+		if !errors.As(err, &already) {
+			err = &writeBackError{Err: err}
+		}
+	}()
+
+	tables, err := s.usecases.metadata.TablesName(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+	if len(tables) == 0 {
+		return noTablesToSaveError(s.isTTY())
+	}
+	dest, err := expandTilde(rest[0])
+	if err != nil {
+		return err
+	}
+	// The session's own --db file is open and being read from; replacing it
+	// underneath the connection would lose the session it is copying.
+	if s.persistent() && sameFilePath(dest, s.sessionPath) {
+		return fmt.Errorf(".save %s %s: that is this session's --db file, which already holds the session", asSQLiteArg, rest[0])
+	}
+	if table, ok := s.outputAliasesImportedSource(dest); ok {
+		return fmt.Errorf(".save %s %s: that is the file table %s was imported from; choose another file", asSQLiteArg, rest[0], table)
+	}
+
+	var saved []string
+	if err := s.writeFileAtomically(dest, func(staging string) error {
+		names, err := s.usecases.persistence.SaveAsSQLite(ctx, staging)
+		saved = names
+		return err
+	}); err != nil {
+		return err
+	}
+	// A save is a write that succeeded, so the counts held back for it are
+	// released before the report of what it wrote.
+	s.flushPendingAffected()
+	fmt.Fprintf(config.Stderr, "saved %d table(s) to %s: %s\n", len(saved), dest, strings.Join(saved, ", "))
+	return nil
+}
+
 // noTablesToSaveError builds the empty-session save error with recovery guidance
 // tailored to the run mode. Save is safety-sensitive, so the message names the
 // next step (.import a file, or pass input files) instead of a bare "no tables to
@@ -120,8 +201,11 @@ func noTablesToSaveError(interactive bool) error {
 // can be written is left to .save, which sees the tables the session actually
 // changed and reports them the same way whether it was typed at the prompt or
 // read from a script.
+//
+// A script whose every .save is --as-sqlite is not checked: a database keeps
+// the schema a statement creates, so nothing it could run is lost.
 func (s *Shell) preflightSave(elements []scriptElement) error {
-	if !runsHelper(elements, saveCommand) {
+	if !runsWriteBackSave(elements) {
 		return nil
 	}
 	// Reject a statement whose effect write-back cannot represent (DDL, schema
@@ -140,6 +224,17 @@ func (s *Shell) preflightSave(elements []scriptElement) error {
 	return nil
 }
 
+// runsWriteBackSave reports whether a script runs a .save that writes tables
+// back to files, as opposed to one that writes the session to a database.
+func runsWriteBackSave(elements []scriptElement) bool {
+	for _, e := range elements {
+		if e.commandName() == saveCommand && !slices.Contains(strings.Fields(e.text), asSQLiteArg) {
+			return true
+		}
+	}
+	return false
+}
+
 // finishNonInteractive flushes the affected-row counts a non-interactive run
 // buffered. They are buffered rather than printed as they happen so that a run
 // which fails after a DML statement leaves stdout free of success text.
@@ -193,6 +288,13 @@ type writeTarget struct {
 	setKind  string
 	baseName string
 	members  []string
+	// csvOutput lays out a CSV or TSV target: the source's own layout for an
+	// in-place save, and the session's --csv-delimiter and its siblings for a
+	// save into a directory.
+	csvOutput model.CSVOutput
+	// encoding is the text encoding the table was read in, which is the one it
+	// is written in. See sourceEncoding.
+	encoding model.TextEncoding
 }
 
 // writeBack persists the current tables to files. When destDir is empty the
@@ -238,12 +340,64 @@ func (s *Shell) writeBack(ctx context.Context, destDir string, followSymlinks bo
 		fmt.Fprintln(config.Stderr, "no imported table changed in this session; nothing to save")
 		return nil
 	}
+	if err := s.checkMaskedSave(ctx, destDir, targets); err != nil {
+		return err
+	}
 	if err := s.applySymlinkPolicy(destDir, targets, followSymlinks); err != nil {
 		return err
 	}
 	return s.executeWriteBack(ctx, destDir, targets)
 }
 
+// checkMaskedSave refuses a save --mask cannot mask. The mask is applied as a
+// table is exported, and two kinds of save are not an export of a table: an
+// in-place save, which would overwrite the data the session read with the
+// masked values and lose the originals for good, and an ACH or Fedwire set,
+// which is rebuilt from its tables as a file of that format rather than
+// written from a table. A mask that names no column of the tables being saved
+// is refused, as checkUnmatchedMasks says.
+func (s *Shell) checkMaskedSave(ctx context.Context, destDir string, targets []writeTarget) error {
+	if len(s.state.mask) == 0 {
+		return nil
+	}
+	if destDir == "" {
+		return &invocationError{Err: fmt.Errorf(".save %s would overwrite the sources with the values --mask %s hides; save the masked tables elsewhere with .save DIR",
+			inPlaceArg, s.state.mask)}
+	}
+	for _, tgt := range targets {
+		if tgt.setKind != "" {
+			return &invocationError{Err: fmt.Errorf("--mask cannot mask the %s set %s, which is rebuilt as a file of its own format rather than written from a table; export its tables with .dump instead",
+				strings.ToUpper(tgt.setKind), tgt.baseName)}
+		}
+	}
+	tables := make([]*model.Table, 0, len(targets))
+	names := make([]string, 0, len(targets))
+	for _, tgt := range targets {
+		header, err := s.usecases.metadata.Header(ctx, tgt.table)
+		if err != nil {
+			return fmt.Errorf("failed to read table %s: %w", tgt.table, err)
+		}
+		tables = append(tables, header)
+		names = append(names, tgt.table)
+	}
+	return checkUnmatchedMasks(s.state.mask, strings.Join(names, ", "), tables...)
+}
+
+// checkUnmatchedMasks refuses a write that a --mask entry would mask nothing in.
+// A mask that matches no column is most often a misspelling of the column it
+// meant, and that column is in the tables under its real name: writing them
+// anyway would write it in clear text at exit 0. .dump and .save DIR check a
+// table the way --output checks a result. A mask naming a table is checked only
+// when that table is written, so a session whose tables do not all share a
+// masked column names its table, as TABLE.COLUMN, and writes the others freely.
+func checkUnmatchedMasks(masks model.ColumnMasks, what string, tables ...*model.Table) error {
+	if unmatched := masks.Unmatched(tables...); len(unmatched) > 0 {
+		return &invocationError{Err: fmt.Errorf("--mask %s names no column of %s, so nothing it names would be masked; a mask meant for one table names it, as TABLE.COLUMN",
+			unmatched, what)}
+	}
+	return nil
+}
+
 // applySymlinkPolicy decides whether an in-place save may write through a
 // symlinked source, and says where the write is going when it may.
 //
@@ -350,7 +504,8 @@ func (s *Shell) planWriteBack(ctx context.Context, destDir string, skipUnchanged
 		// owns, even when it happens to be ACH/Fedwire. Leave it for the per-table
 		// pass, which rejects directory imports with a clear error, instead of
 		// reconstructing a whole-set file the user did not point sqly at directly.
-		if s.dirImported[t.Name()] {
+		// An archive member is left to it for the same reason.
+		if s.dirImported[t.Name()] || isArchiveMember(source) {
 			continue
 		}
 		format := model.FinancialWriteFormat(source)
@@ -397,6 +552,24 @@ func (s *Shell) planWriteBack(ctx context.Context, destDir string, skipUnchanged
 			problems = append(problems, fmt.Sprintf("%s: came from a remote URL (%s)", name, source))
 			continue
 		}
+		if archive, member, ok := splitArchiveSource(source); ok {
+			problems = append(problems, fmt.Sprintf("%s: came from %s in the archive %s, which sqly does not write", name, member, archive))
+			continue
+		}
+		// A union has no one file to write back to: its rows came from several,
+		// and splitting them up again is not something the table remembers.
+		if _, ok := s.unionImports[name]; ok {
+			problems = append(problems, fmt.Sprintf("%s: came from a union of several files (%s)", name, source))
+			continue
+		}
+		// A file read with a CSV dialect would be written back as a plain CSV:
+		// its preamble, its comments, and its missing header would all be gone,
+		// and the next reader of the file expects them. A delimiter alone is
+		// written back as it was read.
+		if dialect, ok := s.importDialects[name]; ok && destDir == "" && !writesBackInPlace(dialect, source) {
+			problems = append(problems, fmt.Sprintf("%s: was read with %s, which .save --in-place cannot write back; use .save DIR", name, dialect))
+			continue
+		}
 		// A directory import is not a single editable source the session owns, so
 		// reject it even though its source may point at a per-file path for
 		// --inspect provenance.
@@ -459,7 +632,12 @@ func (s *Shell) planWriteBack(ctx context.Context, destDir string, skipUnchanged
 			continue
 		}
 		plannedDest.claim(dest, name)
-		targets = append(targets, writeTarget{table: name, dest: dest, format: format, comp: comp})
+		encoding := s.sourceEncoding(source)
+		csvOutput := s.state.csvOutput
+		if destDir == "" {
+			csvOutput = sourceCSVOutput(source, format, s.importDialects[name], encoding)
+		}
+		targets = append(targets, writeTarget{table: name, dest: dest, format: format, comp: comp, csvOutput: csvOutput, encoding: encoding})
 	}
 
 	if len(problems) > 0 {
@@ -719,6 +897,10 @@ func (s *Shell) executeWriteBack(ctx context.Context, destDir string, targets []
 			for _, name := range w.baselines {
 				s.snapshotSourceFromTable(ctx, name)
 			}
+			s.rememberSavedSources(ctx, w.baselines, w.target.dest)
+			// The session wrote this file, so it is not a change .reload should
+			// pick up.
+			s.recordSourceStamp(w.target.dest, stampSource(w.target.dest))
 		}
 		// Write-back is a file-output operation; its confirmation is control-plane
 		// output and goes to stderr so stdout stays free of non-data noise.
@@ -879,7 +1061,8 @@ func (s *Shell) stageWriteTarget(ctx context.Context, tgt writeTarget) (stagedWr
 	// encoding it was read with. Writing UTF-8 instead changed the file's
 	// encoding without saying so, and the same command run again read the result
 	// as the encoding it was told, which is mojibake.
-	if err := s.usecases.export.DumpTable(staging, table, tgt.format, tgt.comp, s.state.importEncoding); err != nil {
+	table = table.WithCSVOutput(tgt.csvOutput).WithNullString(s.state.nullString).WithMask(s.state.mask.ForTable(tgt.table))
+	if err := s.usecases.export.DumpTable(staging, table, tgt.format, tgt.comp, tgt.encoding); err != nil {
 		_ = s.fs().Remove(staging)
 		return stagedWrite{}, fmt.Errorf("failed to save table %s to %s: %w", tgt.table, tgt.dest, err)
 	}
@@ -942,7 +1125,8 @@ func resolveFilePath(p string) string {
 //
 // Only the formats that round-trip cleanly through sqly's table model are
 // allowed: CSV, TSV, LTSV (with the source's compression), and Parquet.
-// JSON/JSONL (stored in a single data column), Excel, ACH, and Fedwire are not.
+// JSON/JSONL (stored in a single data column), XML (only its record elements
+// are kept), Excel, ACH, and Fedwire are not.
 //
 // Three different things make a source unwritable, and they need three different
 // answers. Reporting them all as "write-back to data.csv.bz2 is not supported
@@ -1,11 +1,9 @@
 package shell
 
 import (
-	"cmp"
 	"context"
 	"errors"
 	"fmt"
-	"slices"
 	"strconv"
 	"strings"
 
@@ -18,7 +16,8 @@ import (
 // happens to spell the same word.
 const schemaTableColumn = "table"
 
-// schemaCommand prints the CREATE TABLE statement of a table.
+// schemaCommand prints the CREATE TABLE statement of a table, followed by the
+// CREATE INDEX statement of each index on it.
 //
 // In JSON/NDJSON mode it emits a structured `{table, schema}` object so the
 // schema is machine-readable; in other modes it prints the raw CREATE statement,
@@ -60,6 +59,10 @@ func isStructuredMode(m model.PrintMode) bool {
 // back to synthesizing from column metadata. A schema-qualified name (main.user,
 // temp.t) is resolved against that schema. Returns an error when the object does
 // not exist.
+//
+// The table's indexes follow on lines of their own, since an index declared
+// with --index or .index is part of what the table is: a join that is fast in
+// one session and slow in another differs by nothing else.
 func (s *Shell) tableCreateStatement(ctx context.Context, tableName string) (string, error) {
 	schema, object := s.resolveObjectName(ctx, tableName)
 	stored, isTemp, err := s.storedCreateSQL(ctx, schema, object)
@@ -73,7 +76,11 @@ func (s *Shell) tableCreateStatement(ctx context.Context, tableName string) (str
 		if isTemp {
 			stored = injectTempKeyword(stored)
 		}
-		return stored, nil
+		indexes, err := s.tableIndexStatements(ctx, schema, object, isTemp)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(append([]string{stored}, indexes...), "\n"), nil
 	}
 
 	// Fallback: synthesize from column metadata (also detects a missing table).
@@ -213,70 +220,31 @@ func isSchemaName(prefix string) bool {
 // fallback stays faithful to the real schema: it preserves quoted identifiers,
 // detected types, NOT NULL, DEFAULT, and the primary key. A single-column key is
 // written inline; a composite key becomes a table-level PRIMARY KEY clause.
+//
+// The statement itself is written by model.BuildCreateStatement, which the SQL
+// script export shares, so .schema and a script of the same table cannot
+// disagree about what the table is.
 func (s *Shell) buildCreateStatement(tableName string, cols *model.Table) string {
-	pkCols := primaryKeyColumns(cols)
-
-	var b strings.Builder
-	b.WriteString("CREATE TABLE ")
-	b.WriteString(s.usecases.importer.QuoteIdentifier(tableName))
-	b.WriteString(" (")
-	for i, rec := range cols.Rows {
-		if i > 0 {
-			b.WriteString(", ")
-		}
-		b.WriteString(s.usecases.importer.QuoteIdentifier(rec.At(1)))
-		if colType := rec.At(2); colType != "" {
-			b.WriteString(" ")
-			b.WriteString(colType)
-		}
-		if rec.At(3) == "1" {
-			b.WriteString(" NOT NULL")
-		}
-		if def := rec.At(4); def != "" {
-			b.WriteString(" DEFAULT ")
-			b.WriteString(def) // PRAGMA already gives a SQL-literal token
-		}
-		if pk := rec.At(5); len(pkCols) == 1 && pk != "0" && pk != "" {
-			b.WriteString(" PRIMARY KEY")
-		}
-	}
-	if len(pkCols) > 1 {
-		b.WriteString(", PRIMARY KEY (")
-		for i, name := range pkCols {
-			if i > 0 {
-				b.WriteString(", ")
-			}
-			b.WriteString(s.usecases.importer.QuoteIdentifier(name))
-		}
-		b.WriteString(")")
-	}
-	b.WriteString(")")
-	return b.String()
+	return model.BuildCreateStatement(model.SQLDialectSQLite, tableName, columnDefinitions(cols))
 }
 
-// primaryKeyColumns returns the primary-key column names in key order (PRAGMA's
-// pk value is the 1-based position within the key, 0 when not part of it).
-func primaryKeyColumns(cols *model.Table) []string {
-	type pkCol struct {
-		name string
-		pos  int
-	}
-	var pks []pkCol
+// columnDefinitions converts PRAGMA table_info records into column definitions.
+// PRAGMA's pk value is the column's 1-based position within the key, 0 when it
+// is not part of it; a value that does not parse is taken as 0.
+func columnDefinitions(cols *model.Table) []model.ColumnDefinition {
+	defs := make([]model.ColumnDefinition, 0, cols.RowCount())
 	for _, rec := range cols.Rows {
-		pk := rec.At(5)
-		if pk == "0" || pk == "" {
-			continue
-		}
-		pos, err := strconv.Atoi(pk)
+		pk, err := strconv.Atoi(rec.At(5))
 		if err != nil {
-			continue
+			pk = 0
 		}
-		pks = append(pks, pkCol{name: rec.At(1), pos: pos})
-	}
-	slices.SortFunc(pks, func(a, b pkCol) int { return cmp.Compare(a.pos, b.pos) })
-	names := make([]string, len(pks))
-	for i, p := range pks {
-		names[i] = p.name
-	}
-	return names
+		defs = append(defs, model.ColumnDefinition{
+			Name:       rec.At(1),
+			Type:       rec.At(2),
+			NotNull:    rec.At(3) == "1",
+			Default:    rec.At(4), // PRAGMA already gives a SQL-literal token
+			PrimaryKey: pk,
+		})
+	}
+	return defs
 }
@@ -0,0 +1,72 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nao1215/sqly/config"
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// schemaOption names the schema sidecar of a source by its digest, for the
+// settings a persistent session records with each table: editing the sidecar
+// changes what the file imports as, exactly as changing a flag would. A source
+// with no sidecar, or one that cannot be read, names none; the import that
+// follows is where an unreadable sidecar is reported.
+func (s *Shell) schemaOption(source string) string {
+	if source == "" || source == stdinTableSource || isRemoteURL(source) {
+		return ""
+	}
+	sidecar, err := s.usecases.importer.SchemaSidecar(source)
+	if err != nil || sidecar == "" {
+		return ""
+	}
+	digest, err := digestSource(sidecar)
+	if err != nil || digest == nil {
+		return ""
+	}
+	return digest.sum
+}
+
+// runInspectSchema prints the schema sidecar of the imported tables: for each
+// table, its columns with their types, nullability, and primary key, in the
+// shape an import reads back from FILE.schema.json. Run over one file, the
+// output is that file's sidecar as it stands, ready to be edited and kept.
+//
+// A column whose type a schema cannot declare is refused rather than written,
+// since a sidecar that its own import rejects would only move the error to the
+// next run.
+func (s *Shell) runInspectSchema(ctx context.Context, names []string) error {
+	schema := model.ImportSchema{
+		SchemaVersion: model.ImportSchemaVersion,
+		Tables:        make([]model.TableSchema, 0, len(names)),
+	}
+	for _, name := range names {
+		columns, err := s.inspectColumns(ctx, name)
+		if err != nil {
+			return err
+		}
+		table := model.TableSchema{Name: name, Columns: make([]model.ColumnSchema, 0, len(columns))}
+		for _, c := range columns {
+			typeName, ok := model.DeclarableColumnType(strings.ToUpper(c.Type))
+			if !ok {
+				return fmt.Errorf("column %q of table %q has type %q, which a schema cannot declare; the type must be one of %s",
+					c.Name, name, c.Type, model.DeclarableColumnTypeNames())
+			}
+			table.Columns = append(table.Columns, model.ColumnSchema{
+				Name:       c.Name,
+				Type:       typeName,
+				Nullable:   &c.Nullable,
+				PrimaryKey: c.PrimaryKey,
+			})
+		}
+		schema.Tables = append(schema.Tables, table)
+	}
+	encoded, err := model.MarshalImportSchema(schema)
+	if err != nil {
+		return fmt.Errorf("failed to encode the schema: %w", err)
+	}
+	_, err = config.Stdout.Write(encoded)
+	return err
+}
@@ -0,0 +1,365 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nao1215/sqly/config"
+	"github.com/nao1215/sqly/domain/model"
+	"github.com/nao1215/sqly/domain/sqltext"
+)
+
+// --serve keeps one session alive behind HTTP. A dashboard that shelled out to
+// sqly once per request paid for process start and a full import every time; a
+// server imports once and answers from the session it built, which is the same
+// session the shell would have given it — the same tables, the same dialect,
+// the same --db file when there is one.
+//
+// It is read-only unless --allow-writes says otherwise. A query endpoint is
+// something other programs are pointed at, often by someone other than whoever
+// started it, and a statement that changes the tables changes them for every
+// client after it. The default answers only statements that read.
+//
+// The session is one SQLite connection, so requests are answered one at a time.
+// That is not a limitation being worked around: it is what makes a write that
+// was allowed, and the read after it, see the same database.
+//
+// Three endpoints, all JSON except the query result itself:
+//
+//	POST /query           {"sql": "...", "format": "json|jsonl|csv"}
+//	GET  /tables          the imported tables and their sources
+//	GET  /schema/{table}  one table's columns and row count, as --inspect reports it
+
+// maxServeQueryBody caps a POST /query body. A query is text a person or a
+// program wrote; a megabyte of it is not a query, and the cap keeps a client
+// from making the server hold an unbounded body in memory.
+const maxServeQueryBody = 1 << 20
+
+// serveShutdownTimeout is how long a stopped server waits for the request in
+// flight before closing its connections anyway.
+const serveShutdownTimeout = 5 * time.Second
+
+// serveFormats are the result formats POST /query renders. They are the
+// formats a program parses, which is who is on the other end of the socket.
+var serveFormats = map[string]model.PrintMode{
+	"json":  model.PrintModeJSON,
+	"jsonl": model.PrintModeJSONL,
+	"csv":   model.PrintModeCSV,
+}
+
+// serveContentTypes is the Content-Type each result format is sent with.
+var serveContentTypes = map[model.PrintMode]string{
+	model.PrintModeJSON:  "application/json",
+	model.PrintModeJSONL: "application/x-ndjson",
+	model.PrintModeCSV:   "text/csv; charset=utf-8",
+}
+
+// serveQueryRequest is the body of POST /query.
+type serveQueryRequest struct {
+	SQL    string `json:"sql"`
+	Format string `json:"format"`
+}
+
+// serveTable is one entry of GET /tables.
+type serveTable struct {
+	Name   string `json:"name"`
+	Source string `json:"source,omitempty"`
+}
+
+// serveTables is the body of GET /tables.
+type serveTables struct {
+	Tables []serveTable `json:"tables"`
+}
+
+// serveAffected is the body of POST /query for a statement that returns no
+// rows, which only an --allow-writes server runs.
+type serveAffected struct {
+	AffectedRows int64 `json:"affected_rows"`
+}
+
+// serveError is the body of every failed request.
+type serveError struct {
+	Error string `json:"error"`
+}
+
+// validateServeFlags rejects flags that ask a --serve run to do something else
+// as well. A server answers what it is sent, so a query on the command line, a
+// report, or a destination file would each be silently dropped.
+func (s *Shell) validateServeFlags() error {
+	if s.argument.ServeAddr == "" {
+		return nil
+	}
+	conflicts := []struct {
+		given bool
+		flag  string
+	}{
+		{s.argument.Query != "", flagSQL},
+		{s.argument.SQLFilePath != "", flagSQLFile},
+		{s.argument.ScriptFilePath != "", flagScript},
+		{s.argument.InspectFlag, flagInspect},
+		{s.argument.Watch, "--watch"},
+		{s.argument.Output.FilePath != "", "--output"},
+		// Each request names its own format, so a run-wide one would be ignored
+		// for every request that did and wrong for every request that did not.
+		{s.argument.IsExplicit("output-format"), "--output-format"},
+	}
+	for _, c := range conflicts {
+		if c.given {
+			return &invocationError{Err: fmt.Errorf("%s cannot be combined with %s; the server runs the queries it is sent", flagServe, c.flag)}
+		}
+	}
+	return nil
+}
+
+// runServe answers HTTP requests on the --serve address until ctx is canceled.
+// Being stopped is how a server ends, so it is not an error.
+func (s *Shell) runServe(ctx context.Context) error {
+	var lc net.ListenConfig
+	listener, err := lc.Listen(ctx, "tcp", s.argument.ServeAddr)
+	if err != nil {
+		return fmt.Errorf("cannot serve on %s: %w", s.argument.ServeAddr, err)
+	}
+	return s.serve(ctx, listener)
+}
+
+// serve answers HTTP requests on listener until ctx is canceled.
+func (s *Shell) serve(ctx context.Context, listener net.Listener) error {
+	tables, err := s.usecases.metadata.TablesName(ctx)
+	if err != nil {
+		_ = listener.Close() // #nosec G104
+		return fmt.Errorf("failed to get table names: %w", err)
+	}
+	server := &http.Server{
+		Handler:           s.serveHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	access := "read-only"
+	if s.argument.AllowWrites {
+		access = "writes allowed"
+	}
+	fmt.Fprintf(config.Stderr, "serving %d table(s) on http://%s (%s); press Ctrl-C to stop\n",
+		len(tables), listener.Addr(), access)
+
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+
+	select {
+	case err := <-served:
+		return fmt.Errorf("the server on %s stopped: %w", listener.Addr(), err)
+	case <-ctx.Done():
+	}
+	// ctx is already canceled, so the shutdown gets a context of its own: the
+	// request in flight is given a moment to finish rather than cut off mid-body.
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), serveShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		_ = server.Close() // #nosec G104
+	}
+	<-served
+	return nil
+}
+
+// serveHandler routes the server's endpoints. Every request holds the session
+// for as long as it runs; see the note at the top of this file.
+func (s *Shell) serveHandler() http.Handler {
+	var mu sync.Mutex
+	serialized := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			h(w, r)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /query", serialized(s.serveQuery))
+	mux.HandleFunc("GET /tables", serialized(s.serveTables))
+	mux.HandleFunc("GET /schema/{table}", serialized(s.serveSchema))
+	return mux
+}
+
+// serveQuery runs one statement and sends its result.
+func (s *Shell) serveQuery(w http.ResponseWriter, r *http.Request) {
+	var req serveQueryRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxServeQueryBody))
+	// An unknown field is a typo until proven otherwise: {"query": "..."} would
+	// otherwise be an empty statement, and the error would name the wrong thing.
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeServeError(w, http.StatusBadRequest, fmt.Errorf(`the body must be a JSON object such as {"sql": "SELECT 1", "format": "json"}: %w`, err))
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	if format == "" {
+		format = "json"
+	}
+	mode, ok := serveFormats[format]
+	if !ok {
+		writeServeError(w, http.StatusBadRequest, fmt.Errorf("format %q is not served; want json, jsonl, or csv", req.Format))
+		return
+	}
+
+	stmt, err := serveStatement(req.SQL)
+	if err != nil {
+		writeServeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.argument.AllowWrites && !readOnlyStatement(stmt) {
+		writeServeError(w, http.StatusForbidden, errors.New(
+			"this server is read-only and answers only SELECT, VALUES, TABLE, WITH ... SELECT, and EXPLAIN; start it with --allow-writes to run other statements"))
+		return
+	}
+
+	// read_csv and its siblings read files from the machine the server runs on,
+	// which is not something a client of the server was ever offered.
+	if paths := fileReadPaths(stmt); len(paths) > 0 {
+		writeServeError(w, http.StatusForbidden, errors.New(
+			"read_csv, read_json, read_parquet, and read_xlsx are not served: they read files on the server's machine; import the file when starting the server instead"))
+		return
+	}
+
+	table, affected, err := s.usecases.query.ExecSQL(r.Context(), strings.TrimRight(stmt, ";"))
+	if err != nil {
+		writeServeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if table == nil {
+		writeServeJSON(w, http.StatusOK, serveAffected{AffectedRows: affected})
+		return
+	}
+
+	// Rendered whole before anything is sent, so a rendering failure is a 500
+	// with an error body rather than a 200 with half a result. A response
+	// leaves the machine as a file does, so --mask applies to it as it does to
+	// a file.
+	var body bytes.Buffer
+	if err := table.WithMask(s.state.mask.ForResult()).Masked().WithNullString(s.state.nullString).Print(&body, mode); err != nil {
+		writeServeError(w, http.StatusInternalServerError, fmt.Errorf("failed to render the result: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", serveContentTypes[mode])
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body.Bytes()) // #nosec G104 -- a client that hung up has nobody to tell
+}
+
+// serveTables lists the session's tables with the file each came from.
+func (s *Shell) serveTables(w http.ResponseWriter, r *http.Request) {
+	names, err := s.serveTableNames(r.Context())
+	if err != nil {
+		writeServeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	body := serveTables{Tables: make([]serveTable, 0, len(names))}
+	for _, name := range names {
+		// A URL source can carry a password, and this is a network response.
+		body.Tables = append(body.Tables, serveTable{Name: name, Source: redactURL(s.tableSources[name])})
+	}
+	writeServeJSON(w, http.StatusOK, body)
+}
+
+// serveSchema describes one table the way --inspect does, without sample rows:
+// the row data is what POST /query is for.
+func (s *Shell) serveSchema(w http.ResponseWriter, r *http.Request) {
+	requested := r.PathValue("table")
+	names, err := s.serveTableNames(r.Context())
+	if err != nil {
+		writeServeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	// SQLite matches an ASCII table name in any case, so the path does too.
+	i := slices.IndexFunc(names, func(name string) bool { return strings.EqualFold(name, requested) })
+	if i < 0 {
+		writeServeError(w, http.StatusNotFound, fmt.Errorf("no such table: %s", requested))
+		return
+	}
+	entry, err := s.inspectTable(r.Context(), names[i], 0)
+	if err != nil {
+		writeServeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeServeJSON(w, http.StatusOK, entry)
+}
+
+// serveTableNames returns the session's tables in name order, the order
+// --inspect reports them in.
+func (s *Shell) serveTableNames(ctx context.Context) ([]string, error) {
+	tables, err := s.usecases.metadata.TablesName(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	names := make([]string, 0, len(tables))
+	for _, t := range tables {
+		names = append(names, t.Name())
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// serveStatement returns the one SQL statement a request carries. One, because
+// a request gets one result, the same rule --sql follows; and SQL, because a
+// dot-command acts on the shell, and the shell is not what a client is handed.
+func serveStatement(sql string) (string, error) {
+	elements, err := parseScript(sql)
+	if err != nil {
+		return "", err
+	}
+	switch len(elements) {
+	case 0:
+		return "", errors.New(`"sql" holds no executable SQL statement`)
+	case 1:
+	default:
+		return "", fmt.Errorf(`"sql" holds %d statements; send one statement per request`, len(elements))
+	}
+	if elements[0].isDotCommand() {
+		return "", fmt.Errorf("helper command %q does not run over HTTP; send a SQL statement", elements[0].commandName())
+	}
+	return elements[0].text, nil
+}
+
+// readOnlyStatement reports whether a statement only reads. It is decided by the
+// statement's shape, the same shape sqly routes statements by: a PRAGMA is left
+// out because some of them set things, and telling those apart is not worth
+// the chance of getting one wrong on a server that promised not to change.
+func readOnlyStatement(stmt string) bool {
+	switch sqltext.LeadingKeyword(stmt) {
+	case kwSelect, kwValues, "TABLE", "EXPLAIN":
+		return true
+	case "WITH":
+		switch sqltext.MainVerb(stmt) {
+		case kwInsert, kwUpdate, kwDelete, kwReplace:
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// ansiEscape matches the color sequences sqly's terminal errors carry. A
+// response body is not a terminal.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func writeServeError(w http.ResponseWriter, status int, err error) {
+	writeServeJSON(w, status, serveError{Error: ansiEscape.ReplaceAllString(err.Error(), "")})
+}
+
+func writeServeJSON(w http.ResponseWriter, status int, body any) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, "failed to encode the response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(encoded, '\n')) // #nosec G104 -- a client that hung up has nobody to tell
+}
@@ -0,0 +1,280 @@
+package shell
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newServeShell imports the files the way a --serve run does and returns a test
+// server answering with its handler.
+func newServeShell(t *testing.T, args ...string) (*Shell, *httptest.Server) {
+	t.Helper()
+	s, cleanup, err := newShell(t, append([]string{"sqly", "--serve", "127.0.0.1:0"}, args...))
+	if err != nil {
+		t.Fatalf("newShell: %v", err)
+	}
+	t.Cleanup(cleanup)
+	if err := s.init(context.Background()); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	server := httptest.NewServer(s.serveHandler())
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+// serveRequest sends one request and returns the status, Content-Type, and body.
+func serveRequest(t *testing.T, server *httptest.Server, method, path, body string) (int, string, string) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, resp.Header.Get("Content-Type"), string(got)
+}
+
+func writeServeInputs(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "user.csv")
+	writeDaily(t, csvPath, "id,name\n1,alice\n2,bob\n")
+	return csvPath
+}
+
+func TestServe_Query(t *testing.T) {
+	_, server := newServeShell(t, writeServeInputs(t))
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		want        string
+	}{
+		{
+			name:        "json is the default",
+			body:        `{"sql": "SELECT id, name FROM user ORDER BY id"}`,
+			contentType: "application/json",
+			want:        `{"id":1,"name":"alice"}`,
+		},
+		{
+			name:        "jsonl",
+			body:        `{"sql": "SELECT name FROM user ORDER BY id", "format": "jsonl"}`,
+			contentType: "application/x-ndjson",
+			want:        "{\"name\":\"alice\"}\n{\"name\":\"bob\"}\n",
+		},
+		{
+			name:        "csv",
+			body:        `{"sql": "SELECT name FROM user ORDER BY id;", "format": "CSV"}`,
+			contentType: "text/csv; charset=utf-8",
+			want:        "name\nalice\nbob\n",
+		},
+		{
+			name:        "a CTE that reads",
+			body:        `{"sql": "WITH u AS (SELECT name FROM user) SELECT count(*) AS n FROM u", "format": "csv"}`,
+			contentType: "text/csv; charset=utf-8",
+			want:        "n\n2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, contentType, body := serveRequest(t, server, http.MethodPost, "/query", tt.body)
+			if status != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", status, body)
+			}
+			if contentType != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", contentType, tt.contentType)
+			}
+			if !strings.Contains(body, tt.want) {
+				t.Errorf("body = %q, want it to contain %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestServe_QueryRefusals(t *testing.T) {
+	_, server := newServeShell(t, writeServeInputs(t))
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{name: "an UPDATE", body: `{"sql": "UPDATE user SET name = 'x'"}`, status: http.StatusForbidden, want: "--allow-writes"},
+		{name: "a DROP", body: `{"sql": "DROP TABLE user"}`, status: http.StatusForbidden, want: "read-only"},
+		{name: "a CTE that deletes", body: `{"sql": "WITH d AS (SELECT 1) DELETE FROM user"}`, status: http.StatusForbidden, want: "read-only"},
+		{name: "two statements", body: `{"sql": "SELECT 1; SELECT 2"}`, status: http.StatusBadRequest, want: "one statement per request"},
+		{name: "a file read", body: `{"sql": "SELECT * FROM read_csv('/etc/passwd.csv')"}`, status: http.StatusForbidden, want: "not served"},
+		{name: "a helper command", body: `{"sql": ".tables"}`, status: http.StatusBadRequest, want: "does not run over HTTP"},
+		{name: "no statement", body: `{"sql": "  "}`, status: http.StatusBadRequest, want: "no executable SQL"},
+		{name: "an unknown format", body: `{"sql": "SELECT 1", "format": "excel"}`, status: http.StatusBadRequest, want: "want json, jsonl, or csv"},
+		{name: "an unknown field", body: `{"query": "SELECT 1"}`, status: http.StatusBadRequest, want: "unknown field"},
+		{name: "a bad statement", body: `{"sql": "SELECT nope FROM user"}`, status: http.StatusBadRequest, want: "nope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, contentType, body := serveRequest(t, server, http.MethodPost, "/query", tt.body)
+			if status != tt.status {
+				t.Errorf("status = %d, want %d: %s", status, tt.status, body)
+			}
+			if contentType != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", contentType)
+			}
+			var got serveError
+			if err := json.Unmarshal([]byte(body), &got); err != nil || !strings.Contains(got.Error, tt.want) {
+				t.Errorf("body = %q, want a JSON error containing %q", body, tt.want)
+			}
+			if strings.Contains(body, "\\u001b") {
+				t.Errorf("body = %q, want no terminal escapes", body)
+			}
+		})
+	}
+
+	// Nothing that was refused reached the table.
+	_, _, body := serveRequest(t, server, http.MethodPost, "/query", `{"sql": "SELECT name FROM user ORDER BY id", "format": "csv"}`)
+	if body != "name\nalice\nbob\n" {
+		t.Errorf("after the refusals the table reads %q, want it unchanged", body)
+	}
+}
+
+func TestServe_AllowWrites(t *testing.T) {
+	_, server := newServeShell(t, "--allow-writes", writeServeInputs(t))
+
+	status, _, body := serveRequest(t, server, http.MethodPost, "/query", `{"sql": "UPDATE user SET name = 'carol' WHERE id = 2"}`)
+	if status != http.StatusOK || strings.TrimSpace(body) != `{"affected_rows":1}` {
+		t.Fatalf("UPDATE = %d %q, want 200 with one affected row", status, body)
+	}
+	_, _, body = serveRequest(t, server, http.MethodPost, "/query", `{"sql": "SELECT name FROM user WHERE id = 2", "format": "csv"}`)
+	if body != "name\ncarol\n" {
+		t.Errorf("the next read = %q, want it to see the write", body)
+	}
+}
+
+func TestServe_Mask(t *testing.T) {
+	_, server := newServeShell(t, "--mask", "name=redact:2", writeServeInputs(t))
+
+	status, _, body := serveRequest(t, server, http.MethodPost, "/query", `{"sql": "SELECT id, name FROM user ORDER BY id", "format": "csv"}`)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", status, body)
+	}
+	if want := "id,name\n1,***ce\n2,*ob\n"; body != want {
+		t.Errorf("body = %q, want the names masked as a written file masks them", body)
+	}
+}
+
+func TestServe_TablesAndSchema(t *testing.T) {
+	csvPath := writeServeInputs(t)
+	_, server := newServeShell(t, csvPath)
+
+	t.Run("tables", func(t *testing.T) {
+		status, _, body := serveRequest(t, server, http.MethodGet, "/tables", "")
+		if status != http.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		var got serveTables
+		if err := json.Unmarshal([]byte(body), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Tables) != 1 || got.Tables[0].Name != "user" || got.Tables[0].Source != csvPath {
+			t.Errorf("tables = %+v, want user from %s", got.Tables, csvPath)
+		}
+	})
+
+	t.Run("schema", func(t *testing.T) {
+		status, _, body := serveRequest(t, server, http.MethodGet, "/schema/USER", "")
+		if status != http.StatusOK {
+			t.Fatalf("status = %d: %s", status, body)
+		}
+		var got inspectTable
+		if err := json.Unmarshal([]byte(body), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != "user" || got.RowCount != 2 || len(got.Columns) != 2 || got.Columns[1].Name != "name" {
+			t.Errorf("schema = %+v, want user with 2 rows and columns id, name", got)
+		}
+	})
+
+	t.Run("an unknown table", func(t *testing.T) {
+		status, _, body := serveRequest(t, server, http.MethodGet, "/schema/nope", "")
+		if status != http.StatusNotFound || !strings.Contains(body, "no such table: nope") {
+			t.Errorf("status = %d, body = %q, want 404 naming the table", status, body)
+		}
+	})
+
+	t.Run("a wrong method", func(t *testing.T) {
+		if status, _, _ := serveRequest(t, server, http.MethodGet, "/query", ""); status != http.StatusMethodNotAllowed {
+			t.Errorf("GET /query = %d, want 405", status)
+		}
+	})
+}
+
+// TestServe_StopsWhenCanceled checks a stopped server returns nil, the way a
+// stopped --watch does: Ctrl-C is how a server ends.
+func TestServe_StopsWhenCanceled(t *testing.T) {
+	s, _ := newServeShell(t, writeServeInputs(t))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := captureStderr(t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- s.serve(ctx, listener) }()
+
+		resp, err := http.Post("http://"+listener.Addr().String()+"/query", "application/json", //nolint:noctx
+			strings.NewReader(`{"sql": "SELECT count(*) AS n FROM user", "format": "csv"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(got) != "n\n2\n" {
+			t.Errorf("body = %q, want n\\n2\\n", got)
+		}
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("serve returned %v after being stopped, want nil", err)
+		}
+	})
+	if !strings.Contains(stderr, "serving 1 table(s) on http://"+listener.Addr().String()+" (read-only)") {
+		t.Errorf("stderr = %q, want it to say where it serves", stderr)
+	}
+}
+
+func TestServe_RefusesOtherActions(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "--sql", args: []string{"--sql", "SELECT 1"}, want: "--serve cannot be combined with --sql"},
+		{name: "--inspect", args: []string{"--inspect"}, want: "--serve cannot be combined with --inspect"},
+		{name: "--output-format", args: []string{"--output-format", "csv"}, want: "--serve cannot be combined with --output-format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"sqly", "--serve", "127.0.0.1:0"}, tt.args...)
+			_, _, err := runShell(t, append(args, writeServeInputs(t)))
+			var invocation *invocationError
+			if !errors.As(err, &invocation) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Run error = %v, want an invocationError containing %q", err, tt.want)
+			}
+		})
+	}
+}
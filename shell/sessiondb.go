@@ -0,0 +1,413 @@
+package shell
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/nao1215/filesql/dialect"
+	"github.com/nao1215/sqly/config"
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// A session opened with --db, or switched over by .open, keeps its tables in a
+// SQLite file instead of in memory. The tables survive the process; what has to
+// survive with them is everything the shell knows *about* them — which file each
+// one came from, and what that file and the table held when they last agreed.
+// That record lives in the session database itself (see
+// infrastructure/memory/catalog.go) and is read back when the file is opened.
+//
+// The point of keeping it is the next import. A source file whose bytes have not
+// changed since it was loaded, read with the same settings, into a table nobody
+// has edited since, would produce exactly the table that is already there; the
+// import is skipped and the table is kept. Anything less certain than that — a
+// file one byte longer, a table someone UPDATEd, a different --encoding — is
+// imported again, because keeping a stale table silently is worse than the
+// minutes the import costs.
+//
+// An in-memory session records nothing. Its tables and its bookkeeping both end
+// with the process, so there would be nothing for a record to be compared with.
+
+// SessionOpener opens the SQLite database file at path and returns usecases bound
+// to it. It releases the database it replaces only once the new one is open, so
+// a failure leaves the current session as it was.
+type SessionOpener func(path string) (Usecases, error)
+
+// SetSessionOpener gives the shell the means to switch its session database,
+// which .open needs. The composition root sets it; a shell without one refuses
+// .open rather than guessing how to build the session's usecases.
+func (s *Shell) SetSessionOpener(open SessionOpener) {
+	s.openSession = open
+}
+
+// persistent reports whether the session database is a file.
+func (s *Shell) persistent() bool {
+	return s.sessionPath != ""
+}
+
+// sourceDigest is what a source file looked like when it was read: its size and
+// the SHA-256 of its bytes.
+type sourceDigest struct {
+	size int64
+	sum  string
+}
+
+// digestSource hashes a regular file. Anything else — a FIFO, a /dev/fd entry,
+// process substitution — has no bytes to re-read later, and reading it here
+// would consume what the import is about to load, so it gets no digest and is
+// always imported.
+func digestSource(path string) (*sourceDigest, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil //nolint:nilnil // no digest is the answer for a non-regular file
+	}
+	f, err := os.Open(path) //nolint:gosec // the path is an import the user named
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return nil, err
+	}
+	return &sourceDigest{size: n, sum: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// importOptions names the session settings that decide what table a file's bytes
+// become. Two reads of the same file under different settings are different
+// imports, so the settings are recorded with the digest and compared with it.
+// The column types are the ones declared for the table the record is for, since
+// those are the only ones that changed what it holds, and schema is the digest
+// of the source's schema sidecar, if it has one. The keys are the ones declared
+// for the table, and dialect is the CSV dialect the source is read with.
+func (s *Shell) importOptions(table string, declared model.ColumnTypes, dialect model.CSVDialect, schema string) string {
+	options := fmt.Sprintf("encoding=%s;row-mismatch=%s;include-hidden-sheets=%t",
+		s.state.importEncoding, s.state.rowMismatch, s.state.includeHiddenSheets)
+	// Appended only when set, so a database recorded before the option existed
+	// still matches a run that does not use it.
+	if s.state.xmlRecord != "" {
+		options += ";xml-record=" + s.state.xmlRecord
+	}
+	if len(s.state.sqliteTables) > 0 {
+		options += ";sqlite-tables=" + strings.Join(s.state.sqliteTables, ",")
+	}
+	if len(declared) > 0 {
+		options += ";column-type=" + declared.String()
+	}
+	if !dialect.IsZero() {
+		options += ";dialect=" + dialect.String()
+	}
+	if schema != "" {
+		options += ";schema=" + schema
+	}
+	if !s.state.importCleaning.IsZero() {
+		options += ";cleaning=" + s.state.importCleaning.String()
+	}
+	if s.state.normalization != model.UnicodeNormalizationNone {
+		options += ";normalize=" + string(s.state.normalization)
+	}
+	return options + s.keyOptions(table)
+}
+
+// restoreTableSources reads the session database's record of its tables back
+// into the shell, so a reopened session can save, inspect, and skip re-imports
+// exactly as the session that imported them could.
+//
+// A record whose table is gone (dropped by a statement in an earlier session) is
+// forgotten here, so it cannot vouch for a table that no longer exists.
+func (s *Shell) restoreTableSources(ctx context.Context) error {
+	records, err := s.usecases.metadata.TableSources(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the table sources recorded in %s: %w", s.sessionPath, err)
+	}
+	tables, err := s.usecases.metadata.TablesName(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get table names: %w", err)
+	}
+	existing := tableNameSet(tables)
+
+	var stale []string
+	for _, rec := range records {
+		if _, ok := existing[rec.Table]; !ok {
+			stale = append(stale, rec.Table)
+			continue
+		}
+		s.tableSources[rec.Table] = rec.Source
+		if s.importBaseline == nil {
+			s.importBaseline = make(map[string]string)
+		}
+		s.importBaseline[rec.Table] = rec.Fingerprint
+		s.snapshotSourceBaseline(rec.Table, rec.Fingerprint)
+		if rec.FromDirectory {
+			s.markDirImported(rec.Table)
+		}
+		s.sourceRecords[rec.Table] = rec
+	}
+	if err := s.usecases.metadata.ForgetTableSources(ctx, stale); err != nil {
+		return fmt.Errorf("failed to update the table sources recorded in %s: %w", s.sessionPath, err)
+	}
+	return nil
+}
+
+// unchangedSource returns the tables a source produced when importing it again
+// would reproduce them exactly: the file has the size and digest it had, the
+// import settings are the same, and every table it produced still exists and
+// still holds what it held then. ok is false whenever any of that is not so.
+func (s *Shell) unchangedSource(ctx context.Context, plan *importPlan, displayPath string, digest *sourceDigest) ([]string, bool) {
+	if digest == nil {
+		return nil, false
+	}
+	source := absoluteSource(displayPath)
+	schema := s.schemaOption(displayPath)
+
+	var tables []string
+	for name, rec := range s.sourceRecords {
+		if !sameSourceLocation(rec.Source, source) {
+			continue
+		}
+		if rec.Size != digest.size || rec.Digest != digest.sum || rec.Options != s.importOptions(name, s.declaredColumnTypes(plan, name), fileDialect(plan.dialect, displayPath), schema) {
+			return nil, false
+		}
+		tables = append(tables, name)
+	}
+	if len(tables) == 0 {
+		return nil, false
+	}
+	slices.Sort(tables)
+
+	existing, err := s.usecases.metadata.TablesName(ctx)
+	if err != nil {
+		return nil, false
+	}
+	existingSet := tableNameSet(existing)
+	for _, name := range tables {
+		if _, ok := existingSet[name]; !ok {
+			return nil, false
+		}
+		current, err := s.tableContentFingerprint(ctx, name)
+		if err != nil || current != s.sourceRecords[name].Fingerprint {
+			return nil, false
+		}
+	}
+	return tables, true
+}
+
+// warnDiscardedEdits warns about each table a source produced in an earlier run
+// that holds changes never saved to the source, before importing the source
+// again replaces it. A statement committed to the --db file is as much an edit
+// as one made in this run. A .reload plan warns about nothing here: .reload has
+// already named each table it discards before it planned the import.
+func (s *Shell) warnDiscardedEdits(ctx context.Context, plan *importPlan, displayPath string) {
+	if plan.reloading {
+		return
+	}
+	source := absoluteSource(displayPath)
+	var tables []string
+	for name, rec := range s.sourceRecords {
+		if sameSourceLocation(rec.Source, source) {
+			tables = append(tables, name)
+		}
+	}
+	if len(tables) == 0 {
+		return
+	}
+	slices.Sort(tables)
+	existing, err := s.usecases.metadata.TablesName(ctx)
+	if err != nil {
+		return
+	}
+	existingSet := tableNameSet(existing)
+	for _, name := range tables {
+		if _, ok := existingSet[name]; !ok || !s.tableNeedsSourceWrite(ctx, name) {
+			continue
+		}
+		fmt.Fprintf(config.Stderr,
+			"warning: table %q has changes that were not saved to %s; importing it again discards them\n",
+			name, displayPath)
+	}
+}
+
+// rememberTableSources records, in a persistent session database, what each
+// table's source looked like at the moment the table matched it.
+//
+// A failure is a warning, not an error: the import or save it follows has
+// already succeeded, and the only cost of a missing record is that the next run
+// imports the file again.
+func (s *Shell) rememberTableSources(ctx context.Context, names []string, digest *sourceDigest, fromDirectory bool) {
+	if !s.persistent() || len(names) == 0 {
+		return
+	}
+	records := make([]model.TableSource, 0, len(names))
+	var unrecorded []string
+	for _, name := range names {
+		source, okSource := s.tableSources[name]
+		fingerprint, okFingerprint := s.sourceBaseline[name]
+		// A table with nothing to compare against next time — a stdin dataset,
+		// a pseudo-file, a fingerprint that could not be computed — must not keep
+		// an older record either, or that record would vouch for the new table.
+		if digest == nil || !okSource || !okFingerprint || source == stdinTableSource {
+			unrecorded = append(unrecorded, name)
+			delete(s.sourceRecords, name)
+			continue
+		}
+		records = append(records, model.TableSource{
+			Table:         name,
+			Source:        source,
+			Size:          digest.size,
+			Digest:        digest.sum,
+			Options:       s.importOptions(name, s.recordedColumnTypes(name), s.importDialects[name], s.schemaOption(source)),
+			Fingerprint:   fingerprint,
+			FromDirectory: fromDirectory,
+		})
+	}
+	err := errors.Join(
+		s.usecases.metadata.RecordTableSources(ctx, records),
+		s.usecases.metadata.ForgetTableSources(ctx, unrecorded),
+	)
+	if err != nil {
+		fmt.Fprintf(config.Stderr,
+			"warning: could not record where %s came from in %s; the next run will import it again: %v\n",
+			strings.Join(names, ", "), s.sessionPath, err)
+		return
+	}
+	for _, rec := range records {
+		s.sourceRecords[rec.Table] = rec
+	}
+}
+
+// rememberSavedSources records the new state of sources an in-place save has just
+// rewritten: the file now holds the table, so the next run may keep the table
+// instead of reading the file back. An in-place save refuses a table found in a
+// directory, so none of these is one.
+func (s *Shell) rememberSavedSources(ctx context.Context, names []string, dest string) {
+	if !s.persistent() {
+		return
+	}
+	digest, err := digestSource(dest)
+	if err != nil {
+		digest = nil
+	}
+	s.rememberTableSources(ctx, names, digest, false)
+}
+
+// keepUnchangedSources finishes an import for the inputs it skipped: each one's
+// tables are attributed to the input as it was named this time, and the user is
+// told the file was not read, so a run that took a second instead of a minute
+// does not look like it did nothing.
+func (s *Shell) keepUnchangedSources(ctx context.Context, plan *importPlan) {
+	for _, r := range plan.reused {
+		s.recordImportColumnTypes(plan, r.tables)
+		s.recordImportDialect(r.target.dialect, r.tables)
+		if r.target.fromDirectory {
+			for _, name := range r.tables {
+				s.markDirImported(name)
+			}
+		} else {
+			s.clearDirImported(r.tables)
+		}
+		// The directory mark is part of the record, and naming a file directly
+		// that an earlier run found in a directory changes it.
+		if rec := s.sourceRecords[r.tables[0]]; rec.FromDirectory != r.target.fromDirectory {
+			s.rememberTableSources(ctx, r.tables, &sourceDigest{size: rec.Size, sum: rec.Digest}, r.target.fromDirectory)
+		}
+		s.recordSourceStamp(r.target.displayPath, r.target.stamp)
+		if !s.reportOnly() {
+			fmt.Fprintf(s.importStatusWriter(), "%s is unchanged since it was imported into %s; kept %s\n",
+				r.target.displayPath, s.sessionPath, strings.Join(r.tables, ", "))
+		}
+	}
+}
+
+// openCommand switches the session to the SQLite database file at path,
+// creating it if it does not exist. It behaves like sqlite3's .open: the
+// current session database is closed, and the tables the file holds become the
+// session's tables.
+func (c CommandList) openCommand(ctx context.Context, s *Shell, argv []string) error {
+	if len(argv) != 1 {
+		return &invocationError{Err: errors.New(".open takes exactly one database file path\n" + openUsageText())}
+	}
+	if s.openSession == nil {
+		return errors.New(".open is not available in this session")
+	}
+	path, err := expandTilde(argv[0])
+	if err != nil {
+		return fmt.Errorf("invalid path %s: %w", argv[0], err)
+	}
+	if strings.TrimSpace(path) == "" {
+		return &invocationError{Err: errors.New(".open was given an empty path\n" + openUsageText())}
+	}
+
+	// An in-memory session's tables exist nowhere else, so closing it loses
+	// them. That is what .open means, and what sqlite3 does too; saying it is
+	// the difference between a choice and an accident.
+	var lost []*model.Table
+	if !s.persistent() {
+		lost, _ = s.usecases.metadata.TablesName(ctx)
+	}
+
+	usecases, err := s.openSession(path)
+	if err != nil {
+		return err
+	}
+	if len(lost) > 0 {
+		names := make([]string, 0, len(lost))
+		for _, t := range lost {
+			names = append(names, t.Name())
+		}
+		fmt.Fprintf(config.Stderr, "warning: the in-memory session was closed; its tables are gone: %s\n",
+			strings.Join(names, ", "))
+	}
+
+	currentDialect := s.usecases.query.Dialect()
+	s.usecases = usecases
+	s.sessionPath = path
+	s.resetSessionState(currentDialect)
+	if err := s.restoreTableSources(ctx); err != nil {
+		return err
+	}
+	tables, err := s.usecases.metadata.TablesName(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get table names: %w", err)
+	}
+	fmt.Fprintf(config.Stderr, "opened %s (%d table(s))\n", path, len(tables))
+	return nil
+}
+
+// resetSessionState forgets everything the shell knew about the tables of the
+// database it just closed, and applies the session's settings to the usecases
+// of the one it opened: the dialect, the row-mismatch policy, and the sheet
+// policy belong to the session, not to a database.
+func (s *Shell) resetSessionState(sqlDialect dialect.Dialect) {
+	s.usecases.query.SetDialect(sqlDialect)
+	s.usecases.importer.SetRowMismatchPolicy(s.state.rowMismatch)
+	s.usecases.importer.SetIncludeHiddenSheets(s.state.includeHiddenSheets)
+	s.usecases.importer.SetXMLRecordPath(s.state.xmlRecord)
+	s.usecases.importer.SetSQLiteTables(s.state.sqliteTables)
+	s.usecases.importer.SetImportCleaning(s.state.importCleaning)
+
+	s.tableSources = make(map[string]string)
+	s.sourceRecords = make(map[string]model.TableSource)
+	s.sourceStamps = nil
+	s.dirImported = nil
+	s.importColumnTypes = nil
+	s.importDialects = nil
+	s.importBaseline = nil
+	s.sourceBaseline = nil
+	s.excelWorkbooks = nil
+	s.dataChanged = false
+	s.invalidateCompletionCache()
+}
+
+func openUsageText() string {
+	return "[Usage]\n  .open FILE"
+}
@@ -0,0 +1,315 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nao1215/sqly/config"
+)
+
+// runPersistentSession starts a shell on the --db file with the given inputs,
+// runs its startup import and then each request, and returns what it wrote to
+// stderr. The shell is closed before it returns, so the next call is a new run
+// against the same file, as a second invocation of sqly would be.
+func runPersistentSession(t *testing.T, dbPath string, inputs []string, requests ...string) string {
+	t.Helper()
+	args := append([]string{"sqly", "--db", dbPath}, inputs...)
+	s, cleanup, err := newShell(t, args)
+	if err != nil {
+		t.Fatalf("newShell(%v): %v", args, err)
+	}
+	defer cleanup()
+
+	backup := config.Stderr
+	defer func() { config.Stderr = backup }()
+	var stderr bytes.Buffer
+	config.Stderr = &stderr
+
+	ctx := context.Background()
+	if err := s.init(ctx); err != nil {
+		t.Fatalf("init: %v\nstderr: %s", err, stderr.String())
+	}
+	for _, req := range requests {
+		if err := s.exec(ctx, req); err != nil {
+			t.Fatalf("exec(%q): %v\nstderr: %s", req, err, stderr.String())
+		}
+	}
+	return stderr.String()
+}
+
+// queryPersistentSession runs one query against the --db file without naming an
+// input and returns the printed result.
+func queryPersistentSession(t *testing.T, dbPath, query string) string {
+	t.Helper()
+	s, cleanup, err := newShell(t, []string{"sqly", "--db", dbPath, "--output-format", "csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if err := s.init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := getExecStdOutput(t, s.exec, query)
+	if err != nil {
+		t.Fatalf("exec(%q): %v", query, err)
+	}
+	return string(got)
+}
+
+func writeDaily(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPersistentSession_KeepsAnUnchangedInput(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "session.db")
+	csvPath := filepath.Join(dir, "daily.csv")
+	writeDaily(t, csvPath, "id,name\n1,a\n2,b\n")
+
+	if stderr := runPersistentSession(t, dbPath, []string{csvPath}); strings.Contains(stderr, "unchanged") {
+		t.Fatalf("the first run had nothing to keep, but said: %s", stderr)
+	}
+
+	stderr := runPersistentSession(t, dbPath, []string{csvPath})
+	want := csvPath + " is unchanged since it was imported into " + dbPath + "; kept daily"
+	if !strings.Contains(stderr, want) {
+		t.Errorf("second run stderr = %q, want it to contain %q", stderr, want)
+	}
+	if got := queryPersistentSession(t, dbPath, "SELECT count(*) AS n FROM daily"); got != "n\n2\n" {
+		t.Errorf("kept table = %q, want the two imported rows", got)
+	}
+}
+
+// TestPersistentSession_ReimportsWhenAnythingDiffers covers every way an input
+// stops being unchanged. Each one must read the file again: keeping the old
+// table would answer queries from data the file no longer holds, or from edits
+// the file never had.
+func TestPersistentSession_ReimportsWhenAnythingDiffers(t *testing.T) {
+	tests := []struct {
+		name string
+		// between runs between the first run and the second.
+		between func(t *testing.T, dbPath, csvPath string)
+		// extra flags for the second run.
+		extra []string
+		want  string
+		// warn is whether the second run warns that it discards an edit.
+		warn bool
+	}{
+		{
+			name: "the file was rewritten",
+			between: func(t *testing.T, _, csvPath string) {
+				writeDaily(t, csvPath, "id,name\n1,a\n2,b\n3,c\n")
+			},
+			want: "n\n3\n",
+		},
+		{
+			name: "the table was edited",
+			between: func(t *testing.T, dbPath, _ string) {
+				runPersistentSession(t, dbPath, nil, "DELETE FROM daily WHERE id = 1")
+			},
+			want: "n\n2\n",
+			warn: true,
+		},
+		{
+			name:    "the import settings changed",
+			between: func(*testing.T, string, string) {},
+			extra:   []string{"--row-mismatch", "pad"},
+			want:    "n\n2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			dbPath := filepath.Join(dir, "session.db")
+			csvPath := filepath.Join(dir, "daily.csv")
+			writeDaily(t, csvPath, "id,name\n1,a\n2,b\n")
+
+			runPersistentSession(t, dbPath, []string{csvPath})
+			tt.between(t, dbPath, csvPath)
+
+			stderr := runPersistentSession(t, dbPath, append([]string{csvPath}, tt.extra...))
+			if strings.Contains(stderr, "unchanged") {
+				t.Errorf("the input was kept, but %s: %s", tt.name, stderr)
+			}
+			if got := strings.Contains(stderr, `table "daily" has changes that were not saved`); got != tt.warn {
+				t.Errorf("warned about discarded changes = %t, want %t; stderr: %s", got, tt.warn, stderr)
+			}
+			if got := queryPersistentSession(t, dbPath, "SELECT count(*) AS n FROM daily"); got != tt.want {
+				t.Errorf("table after the second run = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPersistentSession_ReloadWarnsOnce checks .reload names an unsaved edit it
+// discards once: the import it runs must not warn about the same table again.
+func TestPersistentSession_ReloadWarnsOnce(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "session.db")
+	csvPath := filepath.Join(dir, "daily.csv")
+	writeDaily(t, csvPath, "id,name\n1,a\n2,b\n")
+
+	runPersistentSession(t, dbPath, []string{csvPath})
+	writeDaily(t, csvPath, "id,name\n1,a\n2,b\n3,c\n")
+	stderr := runPersistentSession(t, dbPath, nil, "DELETE FROM daily WHERE id = 1", ".reload daily")
+
+	if got := strings.Count(stderr, `table "daily" has changes that were not saved`); got != 1 {
+		t.Errorf("warned about the discarded edit %d times, want once; stderr: %s", got, stderr)
+	}
+	if got := queryPersistentSession(t, dbPath, "SELECT count(*) AS n FROM daily"); got != "n\n3\n" {
+		t.Errorf("table after .reload = %q, want the three rows the file now holds", got)
+	}
+}
+
+// TestPersistentSession_RestoresSourcesForWriteBack checks a later run knows
+// where its tables came from without being told: --db alone is enough to save
+// in place, and the save is recorded so the saved file is not read back.
+func TestPersistentSession_RestoresSourcesForWriteBack(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "session.db")
+	csvPath := filepath.Join(dir, "daily.csv")
+	writeDaily(t, csvPath, "id,name\n1,a\n2,b\n")
+
+	runPersistentSession(t, dbPath, []string{csvPath})
+	runPersistentSession(t, dbPath, nil, "UPDATE daily SET name = 'z' WHERE id = 1", ".save --in-place")
+
+	got, err := os.ReadFile(csvPath) //nolint:gosec // test fixture path
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "1,z") {
+		t.Fatalf("source after .save --in-place = %q, want the updated row", got)
+	}
+
+	stderr := runPersistentSession(t, dbPath, []string{csvPath})
+	if !strings.Contains(stderr, "is unchanged since it was imported") {
+		t.Errorf("the file the session saved was read back; stderr: %s", stderr)
+	}
+}
+
+// TestPersistentSession_RefusesAReservedTableName checks an input cannot take a
+// name under the prefix the session's own record lives under. .tables hides that
+// prefix, so a table there would be imported and then never listed.
+func TestPersistentSession_RefusesAReservedTableName(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "_sqly_sources.csv")
+	writeDaily(t, csvPath, "id\n1\n")
+
+	s, cleanup, err := newShell(t, []string{"sqly", csvPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	err = s.init(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "reserved for sqly's own bookkeeping") {
+		t.Fatalf("init error = %v, want the reserved-name refusal", err)
+	}
+}
+
+func TestOpenCommand(t *testing.T) {
+	t.Run("switches an in-memory session to the file and says what was lost", func(t *testing.T) {
+		dir := t.TempDir()
+		dbPath := filepath.Join(dir, "session.db")
+		csvPath := filepath.Join(dir, "daily.csv")
+		writeDaily(t, csvPath, "id,name\n1,a\n")
+
+		s, cleanup, err := newShell(t, []string{"sqly", csvPath})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cleanup()
+		if err := s.init(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		stderr, err := getExecStdErrOutput(t, s.exec, ".open "+dbPath)
+		if err != nil {
+			t.Fatalf(".open: %v", err)
+		}
+		if !strings.Contains(string(stderr), "its tables are gone: daily") {
+			t.Errorf(".open stderr = %q, want the lost in-memory tables named", stderr)
+		}
+		if !strings.Contains(string(stderr), "opened "+dbPath+" (0 table(s))") {
+			t.Errorf(".open stderr = %q, want the opened database reported", stderr)
+		}
+		if len(s.tableSources) != 0 {
+			t.Errorf("tableSources after .open = %v, want the closed session's forgotten", s.tableSources)
+		}
+
+		if _, err := getExecStdErrOutput(t, s.exec, ".import "+csvPath); err != nil {
+			t.Fatalf(".import after .open: %v", err)
+		}
+		cleanup()
+
+		stderr2 := runPersistentSession(t, dbPath, []string{csvPath})
+		if !strings.Contains(stderr2, "is unchanged since it was imported") {
+			t.Errorf("the table imported after .open was not recorded in the file; stderr: %s", stderr2)
+		}
+	})
+
+	t.Run("a database that cannot be opened leaves the session as it was", func(t *testing.T) {
+		dir := t.TempDir()
+		csvPath := filepath.Join(dir, "daily.csv")
+		writeDaily(t, csvPath, "id,name\n1,a\n")
+		notADB := filepath.Join(dir, "notes.db")
+		writeDaily(t, notADB, "plain text that SQLite will not take for a database file header\n")
+
+		s, cleanup, err := newShell(t, []string{"sqly", csvPath})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cleanup()
+		if err := s.init(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := getExecStdErrOutput(t, s.exec, ".open "+notADB); err == nil {
+			t.Fatal(".open accepted a file that is not a database")
+		}
+		got, err := getExecStdOutput(t, s.exec, "SELECT name FROM daily")
+		if err != nil {
+			t.Fatalf("the session lost its table after a failed .open: %v", err)
+		}
+		if !strings.Contains(string(got), "a") {
+			t.Errorf("query after a failed .open = %q", got)
+		}
+	})
+
+	t.Run("needs exactly one path", func(t *testing.T) {
+		s, cleanup, err := newShell(t, []string{"sqly"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cleanup()
+
+		for _, argv := range [][]string{nil, {"a.db", "b.db"}} {
+			err := NewCommands().openCommand(context.Background(), s, argv)
+			var invocation *invocationError
+			if !errors.As(err, &invocation) {
+				t.Errorf("openCommand(%v) error = %v, want an invocationError", argv, err)
+			}
+		}
+	})
+
+	t.Run("refuses without an opener", func(t *testing.T) {
+		s, cleanup, err := newShell(t, []string{"sqly"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cleanup()
+		s.SetSessionOpener(nil)
+
+		if err := NewCommands().openCommand(context.Background(), s, []string{"x.db"}); err == nil {
+			t.Error("openCommand with no opener returned nil")
+		}
+	})
+}
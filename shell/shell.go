@@ -54,6 +54,9 @@ const (
 	describeCommand = ".describe"
 	saveCommand     = ".save"
 	dialectCommand  = ".dialect"
+	openCommand     = ".open"
+	reloadCommand   = ".reload"
+	indexCommand    = ".index"
 	helpFlag        = "--help"
 	versionFlag     = "--version"
 	helpArgument    = "help"
@@ -111,6 +114,12 @@ type Shell struct {
 	// then the file may be gone — a workbook fetched over HTTP is staged into a
 	// temp directory the import cleans up.
 	excelWorkbooks []excelWorkbookImport
+	// fileReads counts the files read_csv and its siblings have loaded, so each
+	// is staged under a table name of its own. See read_functions.go.
+	fileReads int
+	// unionImports holds, for each table a union import created, what reading
+	// it again takes. See union.go.
+	unionImports map[string]*unionImport
 
 	// tableSources maps an imported table name to the source path it came from.
 	// It is populated on every import and used by the --inspect report and by
@@ -121,6 +130,15 @@ type Shell struct {
 	// provenance), but write-back still rejects them because a directory import
 	// is not a single editable source the session owns.
 	dirImported map[string]bool
+	// importColumnTypes holds, per table, the types the .import --types that
+	// created it declared, so a .reload of the table declares them again. The
+	// session's --column-type declarations are not in it; they apply to every
+	// import anyway.
+	importColumnTypes map[string]model.ColumnTypes
+	// importDialects holds, per table, the CSV dialect its source was read
+	// with, so a .reload reads the source the same way and an in-place save
+	// knows it is not a file sqly can write. See csv_dialect.go.
+	importDialects map[string]model.CSVDialect
 	// dataChanged is set when an executed statement actually changed table data
 	// (a DML that affected at least one row, or a DML RETURNING that returned at
 	// least one row). A non-interactive run only writes back when data changed, so
@@ -140,6 +158,26 @@ type Shell struct {
 	// `UPDATE; .save out; .save --in-place` leave the source with its old rows:
 	// the export moved a baseline that describes a file it never touched.
 	sourceBaseline map[string]string
+	// sessionPath is the SQLite file the session database lives in, from --db or
+	// the last .open; empty for an in-memory session. sourceRecords is what that
+	// file records about where each of its tables came from, kept in step with
+	// it, so deciding whether an input is unchanged does not read the record
+	// back for every file. openSession switches the session to another file; see
+	// sessiondb.go.
+	sessionPath   string
+	sourceRecords map[string]model.TableSource
+	openSession   SessionOpener
+	// sourceStamps is the size and modification time each source file had when
+	// the session last read it or wrote it, keyed by the absolute source. .reload
+	// compares against it to tell a rewritten file from an untouched one; see
+	// reload.go.
+	sourceStamps map[string]sourceStamp
+	// newWatchTicker starts the clock --watch polls the inputs on, and
+	// watchTickDone, when set, is called once a tick has been handled. Both exist
+	// so a test can tick by hand and know when the tick is over instead of
+	// waiting on the wall clock; see watch.go.
+	newWatchTicker watchTicker
+	watchTickDone  func()
 	// pendingAffected holds "affected is N row(s)" lines produced during a
 	// write-back run. They are buffered rather than printed immediately and flushed
 	// to stdout only after write-back succeeds, so a run that fails during
@@ -257,8 +295,11 @@ func NewShell(
 		isTTY:          config.IsInputFromTTY,
 		historyEnabled: true,
 		tableSources:   make(map[string]string),
+		sessionPath:    arg.DBPath,
+		sourceRecords:  make(map[string]model.TableSource),
 		allowRemote:    arg.AllowRemote,
 		httpClient:     newRemoteClient(),
+		newWatchTicker: newTimeTicker,
 	}, nil
 }
 
@@ -288,6 +329,12 @@ func (s *Shell) Run(ctx context.Context) error {
 	if err := s.validateInspectFlags(); err != nil {
 		return err
 	}
+	if err := s.validateWatchFlags(); err != nil {
+		return err
+	}
+	if err := s.validateServeFlags(); err != nil {
+		return err
+	}
 
 	// --output is honored by --sql (a single result) and --sql-file (the script's
 	// one result set). Without either (batch stdin or interactive) the flag was
@@ -307,7 +354,11 @@ func (s *Shell) Run(ctx context.Context) error {
 	// result never spends time reading files: an existing directory would be
 	// silently rewritten to a sibling file, and a missing parent directory would
 	// only surface after the query had already run.
-	if err := ensureWritableDestination(s.argument.Output.FilePath); err != nil {
+	if len(s.argument.Output.PartitionBy) > 0 {
+		if err := ensurePartitionDestination(s.argument.Output.FilePath); err != nil {
+			return err
+		}
+	} else if err := ensureWritableDestination(s.argument.Output.FilePath); err != nil {
 		return err
 	}
 
@@ -329,6 +380,9 @@ func (s *Shell) Run(ctx context.Context) error {
 	if err := s.validateBinaryOutputFormat(); err != nil {
 		return err
 	}
+	if err := s.validateOutputDialect(); err != nil {
+		return err
+	}
 
 	// An import option the user typed that no input of this run can use is a
 	// no-op the user did not ask for. Reject it before reading anything.
@@ -377,6 +431,9 @@ func (s *Shell) Run(ctx context.Context) error {
 	case modeInspect:
 		return s.runInspect(ctx)
 
+	case modeServe:
+		return s.runServe(ctx)
+
 	case modeInlineSQL, modeSQLFile:
 		if err := s.prepareForScript(ctx, elements); err != nil {
 			return err
@@ -384,7 +441,10 @@ func (s *Shell) Run(ctx context.Context) error {
 		// With --output, export the run's single result set to the file instead of
 		// printing each statement's result.
 		if s.argument.Output.FilePath != "" {
-			return s.runSQLFileToOutput(ctx, elements)
+			if err := s.runSQLFileToOutput(ctx, elements); err != nil || !s.argument.Watch {
+				return err
+			}
+			return s.watch(ctx, elements)
 		}
 		ranAny, err := s.runScript(ctx, elements)
 		if err != nil {
@@ -393,7 +453,10 @@ func (s *Shell) Run(ctx context.Context) error {
 		if !ranAny {
 			return nil
 		}
-		return s.finishNonInteractive(ctx)
+		if err := s.finishNonInteractive(ctx); err != nil || !s.argument.Watch {
+			return err
+		}
+		return s.watch(ctx, elements)
 
 	case modeStdinScript, modeScriptFile:
 		if err := s.prepareForScript(ctx, elements); err != nil {
@@ -658,7 +721,7 @@ func (s *Shell) validateBinaryOutputFormat() error {
 		return nil
 	}
 	switch s.argument.Output.Mode {
-	case model.PrintModeExcel, model.PrintModeParquet:
+	case model.PrintModeExcel, model.PrintModeParquet, model.PrintModeSQLite:
 		return &invocationError{Err: fmt.Errorf("--output-format %s writes a binary file and cannot be printed; add --output FILE",
 			s.argument.Output.Mode)}
 	default:
@@ -687,12 +750,39 @@ func (s *Shell) validateOutputFormatAgainstDestination() error {
 	return nil
 }
 
+// validateOutputDialect rejects an --output-dialect that a --sql or --sql-file
+// run would not use. The dialect only decides how a sql script is spelled, so a
+// run printing CSV with --output-dialect mysql is a command line that says two
+// things, and the user meant one of them. In the shell it is a standing choice,
+// like the format, which .mode sql or a .dump to a .sql file can pick up later.
+func (s *Shell) validateOutputDialect() error {
+	if !s.argument.IsExplicit("output-dialect") {
+		return nil
+	}
+	if s.argument.Query == "" && s.argument.SQLFilePath == "" {
+		return nil
+	}
+	mode := s.state.mode.PrintMode
+	writesSQL := mode == model.PrintModeSQL
+	if s.argument.Output.FilePath != "" {
+		// A format the destination contradicts was refused just before this, so
+		// what is left to resolve is which format a display mode's extension
+		// picks. A destination the write will refuse is the write's to report.
+		format, _, err := resolveOutputTarget(s.argument.Output.FilePath, model.ExportFormatFromPrintMode(mode), !mode.IsDisplayOnly())
+		writesSQL = err != nil || format == model.ExportSQL
+	}
+	if !writesSQL {
+		return &invocationError{Err: errors.New("--output-dialect only changes sql output; add --output-format sql, or write to a .sql file")}
+	}
+	return nil
+}
+
 // positionalSubcommandHint reports whether the first positional argument is the
-// accidental subcommand form "help" or "version" (the flags are --help and
-// --version) and returns a correcting hint. Why check os.Stat: a real file or
-// directory actually named "help"/"version" should still import, so the hint
-// fires only when no such path exists. The match is case-insensitive to also
-// catch "HELP"/"Version".
+// accidental subcommand form "help", "version", or "serve" (the flags are
+// --help, --version, and --serve ADDR) and returns a correcting hint. Why check
+// os.Stat: a real file or directory actually named "help"/"version" should still
+// import, so the hint fires only when no such path exists. The match is
+// case-insensitive to also catch "HELP"/"Version".
 func positionalSubcommandHint(paths []string) (string, bool) {
 	if len(paths) == 0 {
 		return "", false
@@ -704,6 +794,8 @@ func positionalSubcommandHint(paths []string) (string, bool) {
 		flag = helpFlag
 	case "version":
 		flag = versionFlag
+	case "serve":
+		flag = flagServe + " ADDR"
 	default:
 		return "", false
 	}
@@ -731,6 +823,9 @@ func (s *Shell) init(ctx context.Context) error {
 	// --include-hidden-sheets keeps that policy for every later .import, so what
 	// the flag means does not change halfway through a session.
 	s.usecases.importer.SetIncludeHiddenSheets(s.state.includeHiddenSheets)
+	s.usecases.importer.SetXMLRecordPath(s.state.xmlRecord)
+	s.usecases.importer.SetSQLiteTables(s.state.sqliteTables)
+	s.usecases.importer.SetImportCleaning(s.state.importCleaning)
 
 	// History is best-effort: a read-only or unwritable history DB (CI,
 	// sandboxes, containers) must not block the requested query or command.
@@ -739,6 +834,15 @@ func (s *Shell) init(ctx context.Context) error {
 		s.disableHistory(err)
 	}
 
+	// A --db file may already hold tables from an earlier run. Their sources are
+	// read back before anything is imported, because the import is what asks
+	// whether a source is unchanged.
+	if s.persistent() {
+		if err := s.restoreTableSources(ctx); err != nil {
+			return err
+		}
+	}
+
 	paths := s.argument.FilePaths
 	stdinAbsPath := ""
 	stagedStdinPath := ""
@@ -774,6 +878,15 @@ func (s *Shell) init(ctx context.Context) error {
 	s.importingStartupInputs = true
 	importErr := s.commands.importCommand(ctx, s, paths)
 	s.importingStartupInputs = false
+	if importErr == nil {
+		importErr = s.checkColumnTypeTables(ctx)
+	}
+	if importErr == nil {
+		importErr = s.checkKeyTables(ctx)
+	}
+	if importErr == nil {
+		importErr = s.checkCleanedColumns(ctx)
+	}
 	// Re-point any stdin-derived table's source from the ephemeral temp path to
 	// a stable "stdin" marker, so --inspect does not leak the temp path
 	// and write-back can reject stdin-backed tables instead of writing to a
@@ -1001,7 +1114,7 @@ func (s *Shell) getCompletions(ctx context.Context, input string) []Suggest {
 		strings.HasPrefix(currentWord, `..\`) || // Windows relative path
 		strings.HasPrefix(currentWord, `C:\`) || // Windows absolute path (common drive)
 		// Also check if the word looks like a filename with supported extensions
-		(strings.Contains(currentWord, ".") && s.usecases.importer.IsSupportedFile(currentWord))
+		(strings.Contains(currentWord, ".") && s.isImportableFile(currentWord))
 	// Check if we're at the end of a path with / or \
 	atEndOfPath := (strings.HasSuffix(text, "/") || strings.HasSuffix(text, `\`)) && len(strings.TrimSpace(text)) > 0
 	// If it looks like a file path OR we're at end of path, provide file completions
@@ -1117,7 +1230,7 @@ func (s *Shell) getRegularCompletions(ctx context.Context, input string) []Sugge
 
 // outputFormatDescription is what completion says a format does. Most say only
 // their own name, so the default is derived from it and a format added to the
-// registry needs nothing here; the three whose name does not describe what
+// registry needs nothing here; the four whose name does not describe what
 // happens say more.
 func outputFormatDescription(mode model.PrintMode) string {
 	switch mode {
@@ -1127,6 +1240,8 @@ func outputFormatDescription(mode model.PrintMode) string {
 		return "jsonl (newline-delimited JSON) output format"
 	case model.PrintModeParquet:
 		return "parquet export format"
+	case model.PrintModeSQLite:
+		return "sqlite database export format"
 	default:
 		return mode.String() + " output format"
 	}
@@ -1317,7 +1432,7 @@ func (s *Shell) runScript(ctx context.Context, elements []scriptElement) (bool,
 			s.state.mode, len(s.capturedRowsets), multiResultAdvice)}
 	}
 	for _, table := range s.capturedRowsets {
-		if err := printResultTable(table, s.state.mode.PrintMode); err != nil {
+		if err := printResultTable(table.WithNullString(s.state.nullString), s.state.mode.PrintMode); err != nil {
 			return ranAny, err
 		}
 	}
@@ -1376,7 +1491,12 @@ func (s *Shell) execSQL(ctx context.Context, req string) error {
 	// warnDialectTranslationOnce.
 	s.warnDialectTranslationOnce(s.usecases.query.Dialect())
 	req = strings.TrimRight(req, ";")
-	table, affectedRows, err := s.usecases.query.ExecSQL(ctx, req)
+	query, release, err := s.expandFileReads(ctx, req)
+	if err != nil {
+		return err
+	}
+	table, affectedRows, err := s.usecases.query.ExecSQL(ctx, query)
+	release()
 	if err != nil {
 		return s.withMissingNameHint(ctx, err)
 	}
@@ -1422,6 +1542,11 @@ func (s *Shell) execSQL(ctx context.Context, req string) error {
 		return nil
 	}
 
+	// A result written as a SQL script is written for the session's
+	// --output-dialect. The table carries that, because the writer sees nothing
+	// else; every other format ignores it.
+	table = table.WithSQLScript(model.SQLScript{Dialect: s.state.outputDialect})
+
 	// While collecting a --sql-file script's output, capture each rowset instead
 	// of printing it. The script's single result set is exported after the run.
 	if s.collectingOutput {
@@ -1440,7 +1565,7 @@ func (s *Shell) execSQL(ctx context.Context, req string) error {
 	if s.printedResults > 0 {
 		fmt.Fprintln(config.Stdout)
 	}
-	if err := printResultTable(table, s.state.mode.PrintMode); err != nil {
+	if err := printResultTable(table.WithNullString(s.state.nullString), s.state.mode.PrintMode); err != nil {
 		return err
 	}
 	s.printedResults++
@@ -1495,6 +1620,18 @@ func resolveOutputTarget(path string, explicit model.ExportFormat, explicitSet b
 // resolved from both the chosen output mode and the destination path, so a path
 // like "result.parquet" or "out.ndjson.gz" is honored even without a mode flag.
 func (s *Shell) outputToFile(table *model.Table) error {
+	// A mask that matches no column of the result is refused as one that
+	// matches no column of a saved table is; see checkUnmatchedMasks. A result
+	// does not say which table a column came from, so every mask is checked
+	// against it by column.
+	if unmatched := s.state.mask.ForResult().Unmatched(table); len(unmatched) > 0 {
+		return &invocationError{Err: fmt.Errorf("--mask %s names no column of the result, so nothing it names would be masked; the result's columns are %s",
+			unmatched, strings.Join(table.Header(), ", "))}
+	}
+	table = table.WithCSVOutput(s.state.csvOutput).WithNullString(s.state.nullString).WithMask(s.state.mask.ForResult())
+	if len(s.argument.Output.PartitionBy) > 0 {
+		return s.outputPartitioned(table)
+	}
 	// ACH and Fedwire are input-only formats: sqly can read them but cannot
 	// produce them, so reject such a destination instead of silently writing CSV
 	// bytes to a misleading .ach/.fed path.
@@ -1512,7 +1649,7 @@ func (s *Shell) outputToFile(table *model.Table) error {
 	// destructive source write must go through .save --in-place, not a one-off
 	// export, so a stray --output cannot silently destroy the dataset.
 	if name, aliased := s.outputAliasesImportedSource(filePath); aliased {
-		return &outputPathError{Path: filePath, Err: fmt.Errorf("--output destination %s is the source file for table %q; use .save --in-place to overwrite a source", filePath, name)}
+		return &outputPathError{Path: filePath, Err: fmt.Errorf("--output destination %s %s", filePath, s.aliasedSourceReason(name))}
 	}
 	// The result is serialized beside the destination and moved onto it, so a
 	// format that rejects a value part-way — or a full disk — leaves an existing
@@ -1545,13 +1682,24 @@ func (s *Shell) outputAliasesImportedSource(path string) (string, bool) {
 		if src == stdinTableSource {
 			continue
 		}
-		if sameFilePath(path, src) {
+		// Writing over an archive would destroy every member in it, not only
+		// the one this table came from.
+		if sameFilePath(path, archiveFileOf(src)) {
 			return table, true
 		}
 	}
 	return "", false
 }
 
+// aliasedSourceReason completes the refusal of an export onto the source of
+// table. An archive has no .save --in-place to point at, so it says that instead.
+func (s *Shell) aliasedSourceReason(table string) string {
+	if isArchiveMember(s.tableSources[table]) {
+		return fmt.Sprintf("is the archive table %q was imported from; sqly does not write archives", table)
+	}
+	return fmt.Sprintf("is the source file for table %q; use .save --in-place to overwrite a source", table)
+}
+
 // prepareForScript records what the whole script implies before its first
 // statement runs: whether a write-back is coming, which decides if the
 // affected-row counts can be printed as they happen.
@@ -1658,7 +1806,7 @@ func trimGaps(s string) string {
 
 // isValidFileForCompletion checks if file has a supported extension.
 func (s *Shell) isValidFileForCompletion(filename string) bool {
-	return s.usecases.importer.IsSupportedFile(filename)
+	return s.isImportableFile(filename)
 }
 
 // splitPathPrefix splits a typed path prefix at its last separator into the
@@ -1856,6 +2004,8 @@ func pathCommandSpec(cmd string) (pathArg int, multi, dirsOnly, ok bool) {
 		return 1, false, true, true
 	case dumpCommand: // .dump TABLE PATH
 		return 2, false, false, true
+	case openCommand: // .open FILE
+		return 1, false, false, true
 	}
 	return 0, false, false, false
 }
@@ -1896,7 +2046,7 @@ func valueCommandSpec(cmd string) (argumentValues, bool) {
 		return argRowMismatch, true
 	// .dump names a table first and a path second; the path half is
 	// pathCommandSpec's, and only the table half arrives here.
-	case schemaCommand, describeCommand, dumpCommand:
+	case schemaCommand, describeCommand, dumpCommand, reloadCommand, indexCommand:
 		return argTable, true
 	case pwdCommand, tablesCommand, clearCommand, exitCommand, helpCommand:
 		return argNone, true
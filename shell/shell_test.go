@@ -21,6 +21,7 @@ import (
 	"github.com/nao1215/sqly/infrastructure/persistence"
 	"github.com/nao1215/sqly/interactor"
 	"github.com/nao1215/sqly/testutil"
+	"github.com/nao1215/sqly/usecase"
 	"golang.org/x/text/encoding/japanese"
 )
 
@@ -323,10 +324,10 @@ func TestShellExec(t *testing.T) {
 		}
 	})
 
-	// excel and parquet name a file, not a screen. Selecting one used to leave the
-	// session printing CSV under another name, with the banner admitting it.
-	t.Run("execute .mode: excel and parquet are refused", func(t *testing.T) {
-		for _, name := range []string{"excel", "parquet"} {
+	// excel, parquet, and sqlite name a file, not a screen. Selecting one used to
+	// leave the session printing CSV under another name, with the banner admitting it.
+	t.Run("execute .mode: excel, parquet, and sqlite are refused", func(t *testing.T) {
+		for _, name := range []string{"excel", "parquet", "sqlite"} {
 			shell, cleanup, err := newShell(t, []string{"sqly"})
 			if err != nil {
 				t.Fatal(err)
@@ -1101,27 +1102,37 @@ func newShell(tb testing.TB, args []string) (*Shell, func(), error) {
 	}
 	configConfig := &config.Config{HistoryPath: historyPath}
 	commandList := NewCommands()
-	memoryDB, cleanup, err := config.NewInMemDB()
+	// The session database is the one --db names, as in production, and an
+	// in-memory one otherwise.
+	openDB := config.NewInMemDB
+	if arg.DBPath != "" {
+		openDB = func() (config.MemoryDB, func(), error) { return config.NewFileDB(arg.DBPath) }
+	}
+	memoryDB, cleanup, err := openDB()
 	if err != nil {
+		cleanup2()
 		return nil, nil, err
 	}
-	// Create filesql adapter and repositories for tests
-	filesqlAdapter := filesql.NewFileSQLAdapter((*sql.DB)(memoryDB))
-
-	// Use memory-based sqlite3 repository matching production wiring (di/di.go)
-	sqlite3Repository := memory.NewSQLite3Repository(memoryDB)
-	sqlHelper := interactor.NewSQL()
-	sqLite3Interactor := interactor.NewSQLite3Interactor(sqlite3Repository, sqlHelper, filesqlAdapter)
 
 	historyUsecase := persistence.NewHistoryRepository(historyPath)
 	exportInteractor := interactor.NewExportInteractor()
-	usecases := NewUsecases(sqLite3Interactor, sqLite3Interactor, sqLite3Interactor, historyUsecase, exportInteractor, sqLite3Interactor)
-	shellShell, err := NewShell(arg, configConfig, commandList, usecases)
+	shellShell, err := NewShell(arg, configConfig, commandList, newTestSessionUsecases(memoryDB, historyUsecase, exportInteractor))
 	if err != nil {
 		cleanup2()
 		cleanup()
 		return nil, nil, err
 	}
+	// .open swaps the database the way di.NewShell does: the old one is closed
+	// once the new one has opened, and the cleanup closes whichever is current.
+	shellShell.SetSessionOpener(func(path string) (Usecases, error) {
+		db, closeDB, err := config.NewFileDB(path)
+		if err != nil {
+			return Usecases{}, err
+		}
+		cleanup()
+		cleanup = closeDB
+		return newTestSessionUsecases(db, historyUsecase, exportInteractor), nil
+	})
 	// Prepare the history file, as a session does at startup.
 	if err := historyUsecase.Init(context.Background()); err != nil {
 		cleanup2()
@@ -1134,6 +1145,15 @@ func newShell(tb testing.TB, args []string) (*Shell, func(), error) {
 	}, nil
 }
 
+// newTestSessionUsecases wires the usecases of one session database the way
+// di/di.go does.
+func newTestSessionUsecases(db config.MemoryDB, historyUsecase usecase.HistoryUsecase, exportUsecase usecase.ExportUsecase) Usecases {
+	filesqlAdapter := filesql.NewFileSQLAdapter((*sql.DB)(db))
+	sqlite3Repository := memory.NewSQLite3Repository(db)
+	sqLite3Interactor := interactor.NewSQLite3Interactor(sqlite3Repository, interactor.NewSQL(), filesqlAdapter)
+	return NewUsecases(sqLite3Interactor, sqLite3Interactor, sqLite3Interactor, historyUsecase, exportUsecase, sqLite3Interactor)
+}
+
 func getStdoutForRunFunc(t *testing.T, f func(ctx context.Context) error) []byte {
 	t.Helper()
 	backupColorStdout := config.Stdout
@@ -0,0 +1,87 @@
+package shell
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDumpSQL_WritesALoadableScript is the case the format exists for: a table
+// dumped to a .sql file loads into another database and comes back as the same
+// rows, with the types the session gave it.
+func TestDumpSQL_WritesALoadableScript(t *testing.T) {
+	dir := t.TempDir()
+	src := writeCSV(t, dir, "users.csv", "id,name,score\n1,O'Brien,2.5\n2,,3\n")
+	dest := filepath.Join(dir, "users.sql")
+	script := filepath.Join(dir, "s.sqly")
+	writeScript(t, script, ".dump users "+dest+"\n")
+
+	if _, stderr, err := runWithArgs(t, "--script-file", script, src); err != nil {
+		t.Fatalf(".dump: %v (%s)", err, stderr)
+	}
+	content := readFile(t, dest)
+	if !strings.HasPrefix(content, "BEGIN;\nCREATE TABLE \"users\" (") {
+		t.Errorf("the script does not start with the table's definition:\n%s", content)
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(content); err != nil {
+		t.Fatalf("loading the script: %v\n%s", err, content)
+	}
+	var n int
+	var name string
+	if err := db.QueryRow(`SELECT count(*), max(name) FROM users`).Scan(&n, &name); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || name != "O'Brien" {
+		t.Errorf("the loaded table has %d rows and name %q, want 2 and O'Brien", n, name)
+	}
+}
+
+func TestSQLOutput_WritesTheChosenDialect(t *testing.T) {
+	dir := t.TempDir()
+	src := writeCSV(t, dir, "users.csv", "id,name\n1,a\\b\n")
+
+	stdout, stderr, err := runWithArgs(t, "--output-format", "sql", "--output-dialect", "mysql",
+		"--sql", "SELECT id, name FROM users", src)
+	if err != nil {
+		t.Fatalf("Run: %v (%s)", err, stderr)
+	}
+	want := "START TRANSACTION;\n" +
+		"CREATE TABLE `users` (`id` BIGINT, `name` TEXT);\n" +
+		"INSERT INTO `users` (`id`, `name`) VALUES\n" +
+		"  (1, 'a\\\\b');\n" +
+		"COMMIT;\n"
+	if stdout != want {
+		t.Errorf("stdout =\n%s\nwant\n%s", stdout, want)
+	}
+}
+
+// TestSQLOutput_DialectWithoutSQLIsRefused checks --output-dialect is not
+// silently ignored by a run that writes something else, and that a .sql
+// destination counts as choosing sql.
+func TestSQLOutput_DialectWithoutSQLIsRefused(t *testing.T) {
+	dir := t.TempDir()
+	src := writeCSV(t, dir, "users.csv", "id\n1\n")
+
+	_, _, err := runWithArgs(t, "--output-format", "csv", "--output-dialect", "postgresql", "--sql", "SELECT id FROM users", src)
+	var invocationErr *invocationError
+	if !errors.As(err, &invocationErr) || !strings.Contains(err.Error(), "--output-dialect") {
+		t.Errorf("Run error = %v, want an invocationError naming --output-dialect", err)
+	}
+
+	dest := filepath.Join(dir, "out.sql")
+	if _, stderr, err := runWithArgs(t, "--output", dest, "--output-dialect", "postgresql", "--sql", "SELECT id FROM users", src); err != nil {
+		t.Fatalf("Run with a .sql destination: %v (%s)", err, stderr)
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Errorf("the .sql destination was not written: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package shell
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// querySQLiteDB returns the first column of every row query yields in the
+// database at path, joined with ",".
+func querySQLiteDB(t *testing.T, path, query string) string {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	defer func() { _ = rows.Close() }()
+	var got []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, value)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return strings.Join(got, ",")
+}
+
+func TestSQLiteExport(t *testing.T) {
+	t.Run("--output FILE.sqlite writes the result as a typed table", func(t *testing.T) {
+		dir := t.TempDir()
+		scores := writeCSV(t, dir, "scores.csv", "name,score\nalice,90.5\nbob,71\n")
+		out := filepath.Join(dir, "result.sqlite")
+
+		if _, stderr, err := runWithArgs(t, "--sql", "SELECT name, score FROM scores ORDER BY name", "--output", out, scores); err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if got := querySQLiteDB(t, out, "SELECT name || '=' || typeof(score) FROM scores ORDER BY name"); got != "alice=real,bob=real" {
+			t.Errorf("rows = %s, want the scores stored as numbers", got)
+		}
+	})
+
+	t.Run("--output-format sqlite without --output is refused", func(t *testing.T) {
+		scores := writeCSV(t, t.TempDir(), "scores.csv", "name,score\nalice,90.5\n")
+
+		_, _, err := runWithArgs(t, "--output-format", "sqlite", "--sql", "SELECT * FROM scores", scores)
+		var invocation *invocationError
+		if !errors.As(err, &invocation) {
+			t.Errorf("Run error = %v, want an invocation error", err)
+		}
+	})
+
+	t.Run(".save --as-sqlite keeps created tables, indexes, and views", func(t *testing.T) {
+		dir := t.TempDir()
+		scores := writeCSV(t, dir, "scores.csv", "name,score\nalice,90.5\nbob,71\n")
+		out := filepath.Join(dir, "session.db")
+		script := filepath.Join(dir, "save.sql")
+		writeScript(t, script, strings.Join([]string{
+			"CREATE TABLE grades (name TEXT PRIMARY KEY, grade TEXT NOT NULL);",
+			"INSERT INTO grades SELECT name, CASE WHEN score >= 80 THEN 'A' ELSE 'B' END FROM scores;",
+			"CREATE INDEX grades_grade ON grades (grade);",
+			"CREATE VIEW top AS SELECT name FROM grades WHERE grade = 'A';",
+			".save --as-sqlite " + out,
+		}, "\n")+"\n")
+
+		_, stderr, err := runWithArgs(t, "--script-file", script, scores)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if !strings.Contains(stderr, "saved 2 table(s) to "+out+": scores, grades") {
+			t.Errorf("stderr = %q, want the saved tables reported", stderr)
+		}
+		if got := querySQLiteDB(t, out, "SELECT name FROM sqlite_master WHERE sql IS NOT NULL ORDER BY name"); got != "grades,grades_grade,scores,top" {
+			t.Errorf("objects = %s, want both tables, the index, and the view", got)
+		}
+		if got := querySQLiteDB(t, out, "SELECT name FROM top"); got != "alice" {
+			t.Errorf("top = %s, want alice", got)
+		}
+	})
+
+	t.Run(".save --as-sqlite refuses an imported source", func(t *testing.T) {
+		dir := t.TempDir()
+		scores := writeCSV(t, dir, "scores.csv", "name,score\nalice,90.5\n")
+		script := filepath.Join(dir, "save.sql")
+		writeScript(t, script, ".save --as-sqlite "+scores+"\n")
+
+		_, stderr, err := runWithArgs(t, "--script-file", script, scores)
+		if err == nil || !strings.Contains(stderr, "the file table scores was imported from") {
+			t.Errorf("Run error = %v (%s), want the source refused", err, stderr)
+		}
+	})
+
+	t.Run(".save --as-sqlite does not combine with --in-place", func(t *testing.T) {
+		dir := t.TempDir()
+		scores := writeCSV(t, dir, "scores.csv", "name,score\nalice,90.5\n")
+		script := filepath.Join(dir, "save.sql")
+		writeScript(t, script, ".save --as-sqlite --in-place\n")
+
+		_, _, err := runWithArgs(t, "--script-file", script, scores)
+		var invocation *invocationError
+		if !errors.As(err, &invocation) {
+			t.Errorf("Run error = %v, want an invocation error", err)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package shell
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// writeSQLiteDB creates a database file at dir/name by running statements.
+func writeSQLiteDB(t *testing.T, dir, name string, statements ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	return path
+}
+
+func TestSQLiteImport(t *testing.T) {
+	appDB := func(t *testing.T, dir string) string {
+		t.Helper()
+		return writeSQLiteDB(t, dir, "app.db",
+			`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`,
+			`CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id), total REAL)`,
+			`INSERT INTO users VALUES (1, 'alice'), (2, 'bob')`,
+			`INSERT INTO orders VALUES (10, 1, 2.5), (11, 1, 4), (12, 2, 1)`)
+	}
+
+	t.Run("every table is imported and joins across formats", func(t *testing.T) {
+		dir := t.TempDir()
+		path := appDB(t, dir)
+		regions := writeCSV(t, dir, "regions.csv", "user_id,region\n1,eu\n2,us\n")
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--sql",
+			"SELECT u.name, r.region, sum(o.total) AS total FROM users u JOIN orders o ON o.user_id = u.id JOIN regions r ON r.user_id = u.id GROUP BY u.id ORDER BY u.id",
+			path, regions)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "name,region,total\nalice,eu,6.5\nbob,us,1\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("--sqlite-tables imports only the tables named", func(t *testing.T) {
+		path := appDB(t, t.TempDir())
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--sqlite-tables", "orders",
+			"--sql", "SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name", path)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "name\norders\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("a table --sqlite-tables names that the database lacks fails the import", func(t *testing.T) {
+		path := appDB(t, t.TempDir())
+
+		_, _, err := runWithArgs(t, "--sqlite-tables", "users,customers", "--sql", "SELECT 1", path)
+		if err == nil || !strings.Contains(err.Error(), "--sqlite-tables names customers") {
+			t.Errorf("Run error = %v, want the missing table named", err)
+		}
+	})
+
+	t.Run("another session's --db file imports its tables, not sqly's record of them", func(t *testing.T) {
+		dir := t.TempDir()
+		session := filepath.Join(dir, "p.db")
+		csvPath := writeCSV(t, dir, "daily.csv", "id,name\n1,a\n2,b\n")
+		if _, stderr, err := runWithArgs(t, "--db", session, "--sql", "SELECT 1", csvPath); err != nil {
+			t.Fatalf("Run --db: %v (%s)", err, stderr)
+		}
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv",
+			"--sql", "SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name", session)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "name\ndaily\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("the session's own --db file is refused", func(t *testing.T) {
+		dir := t.TempDir()
+		session := filepath.Join(dir, "session.db")
+
+		_, _, err := runWithArgs(t, "--db", session, "--sql", "SELECT 1", session)
+		if err == nil || !strings.Contains(err.Error(), "this session's --db file") {
+			t.Errorf("Run error = %v, want the session file refused", err)
+		}
+	})
+}
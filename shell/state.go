@@ -26,6 +26,48 @@ type state struct {
 	// seeded from --include-hidden-sheets and does not change during a session,
 	// so every .import reads workbooks the same way the initial import did.
 	includeHiddenSheets bool
+	// xmlRecord is the session's XML record path: the elements of an XML input
+	// that are its rows. It is seeded from --xml-record and, like the sheet
+	// policy, holds for every import of the session.
+	xmlRecord string
+	// sqliteTables is the session's --sqlite-tables: the tables an import of a
+	// SQLite database copies, or every table when empty. It holds for every
+	// import of the session, like xmlRecord.
+	sqliteTables []string
+	// csvDialect is how the session reads csv and tsv inputs, from --delimiter
+	// and the flags beside it. An .import that gives dialect options of its own
+	// reads its files with those instead; see csv_dialect.go.
+	csvDialect model.CSVDialect
+	// columnTypes is the session's --column-type declarations. Each names its
+	// table, so it applies to whichever import creates that table, and like the
+	// other import settings it holds for every import of the session.
+	columnTypes model.ColumnTypes
+	// primaryKeys and indexes are the session's --primary-key and --index
+	// declarations, held the way columnTypes is. An index .index creates is
+	// added to indexes, so the table keeps it when it is imported again.
+	primaryKeys model.TableKeys
+	indexes     model.TableKeys
+	// outputDialect is the database a sql script is written for, whether the
+	// script is a printed result, an --output file, or a .dump. It is seeded
+	// from --output-dialect.
+	outputDialect model.SQLDialect
+	// csvOutput is how a CSV or TSV file the session writes is laid out:
+	// --output, .dump, and .save DIR. It is seeded from --csv-delimiter,
+	// --csv-quote-all, --crlf, and --bom.
+	csvOutput model.CSVOutput
+	// importCleaning is what is done to the values of the CSV and TSV inputs as
+	// they are read, and normalization the Unicode form every text input is
+	// brought to. They are seeded from --trim, --null-values, --date-columns,
+	// --decimal-columns, and --normalize, and hold for every import of the
+	// session.
+	importCleaning model.ImportCleaning
+	normalization  model.UnicodeNormalization
+	// nullString is how a NULL is written in table, CSV, TSV, and LTSV output,
+	// on screen and in a file alike. It is seeded from --null-string.
+	nullString string
+	// mask names the columns masked in every file the session writes from a
+	// table: --output, .dump, and .save DIR. It is seeded from --mask.
+	mask model.ColumnMasks
 }
 
 // newState return *state.
@@ -38,12 +80,28 @@ func newState(arg *config.Arg) (*state, error) {
 	if importEncoding == "" {
 		importEncoding = model.TextEncodingUTF8
 	}
+	outputDialect := arg.Output.Dialect
+	if outputDialect == "" {
+		outputDialect = model.SQLDialectSQLite
+	}
 	return &state{
 		cwd:                 dir,
 		mode:                newMode(config.Stdout, arg.Output.Mode),
 		rowMismatch:         arg.RowMismatch,
 		importEncoding:      importEncoding,
 		includeHiddenSheets: arg.IncludeHiddenSheets,
+		xmlRecord:           arg.XMLRecord,
+		sqliteTables:        arg.SQLiteTables,
+		csvDialect:          arg.CSVDialect,
+		columnTypes:         arg.ColumnTypes,
+		primaryKeys:         arg.PrimaryKeys,
+		indexes:             arg.Indexes,
+		outputDialect:       outputDialect,
+		csvOutput:           arg.Output.CSV,
+		importCleaning:      arg.Cleaning,
+		normalization:       arg.Normalize,
+		nullString:          arg.Output.NullString,
+		mask:                arg.Output.Mask,
 	}, nil
 }
 
@@ -109,7 +167,7 @@ func (m *mode) changeOutputModeIfNeeded(modeName string) error {
 	// disagree about which spellings name a format.
 	target, ok := model.ParseSelectableMode(modeName)
 	if !ok {
-		return &invocationError{Err: fmt.Errorf("invalid output mode %q: want %s (excel and parquet name a file, not a screen: write one with .dump TABLE FILE.xlsx or --output)", modeName, model.SelectableModeNames())}
+		return &invocationError{Err: fmt.Errorf("invalid output mode %q: want %s (excel, parquet, and sqlite name a file, not a screen: write one with .dump TABLE FILE.xlsx or --output)", modeName, model.SelectableModeNames())}
 	}
 
 	// Selecting the mode that is already in effect is what the caller asked for,
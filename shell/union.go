@@ -0,0 +1,487 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/nao1215/sqly/domain/model"
+)
+
+// A union import reads every file it is given into one table. Data that
+// arrives as shards — a file a day, a file per host — is one table that the
+// program writing it happened to split, and a table per shard makes every
+// question about it a UNION ALL over however many shards there are this week.
+//
+// The shards are aligned by column name and must agree on which columns there
+// are (see StageUnion). The union is staged as one CSV file and loaded like any
+// other input, so it gets the same preflight, the same single transaction, and
+// the same declared types and keys as a table read from one file.
+//
+// A partitioned import is a union too: the files of a Hive-style tree, each
+// carrying the key=value directories it sits under as columns (see
+// model.PartitionValue).
+
+const (
+	// unionArg is .import's spelling of --union.
+	unionArg = "--union"
+	// sourceFileColumnArg is .import's spelling of --source-file-column.
+	sourceFileColumnArg = "--source-file-column"
+	// partitionedArg is .import's spelling of --partitioned.
+	partitionedArg = "--partitioned"
+)
+
+// unionSpec is the table a union import reads its inputs into. A partitioned
+// import without a table of its own reads each directory into the table named
+// after it, and leaves table empty.
+type unionSpec struct {
+	table        string
+	sourceColumn bool
+	partitioned  bool
+	// option is how the request was spelled — the flag or the .import option —
+	// for messages about it.
+	option string
+}
+
+// unionImport is what the session remembers about a union table, so .reload
+// and --watch can read it again: the inputs as the user gave them, and the
+// shards those inputs matched when they were last read.
+type unionImport struct {
+	// inputs are the paths, patterns, directories, and URLs the user named.
+	inputs []string
+	// dir is the working directory the inputs were named in. A relative pattern
+	// is matched against it, so a .cd in between does not change what it means.
+	dir          string
+	sourceColumn bool
+	partitioned  bool
+	// stamps holds each local shard's stamp, keyed by its absolute path. A new
+	// file matching a pattern changes the union as much as a rewritten one.
+	stamps map[string]sourceStamp
+	// skipped is what the row-mismatch policy dropped, reported once the
+	// import commits.
+	skipped model.SkippedRows
+}
+
+// unionFile is one file a union reads: where it is, what to call it, and, in a
+// partitioned tree, the key=value directories it was found under.
+type unionFile struct {
+	path      string
+	label     string
+	partition []model.PartitionValue
+}
+
+// splitUnionArg takes .import's --union, --partitioned, and
+// --source-file-column options out of its arguments, so what is left is the
+// inputs. It is stripped the way splitTypesArg strips --types.
+func splitUnionArg(argv []string) ([]string, *unionSpec, error) {
+	sourceColumn := false
+	if i := slices.Index(argv, sourceFileColumnArg); i >= 0 {
+		sourceColumn = true
+		argv = append(append([]string{}, argv[:i]...), argv[i+1:]...)
+	}
+	partitioned := false
+	if i := slices.Index(argv, partitionedArg); i >= 0 {
+		partitioned = true
+		argv = append(append([]string{}, argv[:i]...), argv[i+1:]...)
+	}
+	i := slices.Index(argv, unionArg)
+	if i < 0 {
+		if partitioned {
+			return argv, &unionSpec{sourceColumn: sourceColumn, partitioned: true, option: ".import --partitioned"}, nil
+		}
+		if sourceColumn {
+			return nil, nil, &invocationError{Err: errors.New(".import --source-file-column has no effect without --union TABLE or --partitioned\n" + importUsageText())}
+		}
+		return argv, nil, nil
+	}
+	if i == len(argv)-1 || strings.HasPrefix(argv[i+1], "-") {
+		return nil, nil, &invocationError{Err: errors.New(".import --union requires the table to read the files into, such as events\n" + importUsageText())}
+	}
+	union := &unionSpec{table: argv[i+1], sourceColumn: sourceColumn, partitioned: partitioned, option: ".import --union"}
+	if slices.Contains(argv[i+2:], unionArg) {
+		return nil, nil, &invocationError{Err: errors.New(".import --union was given twice; one import reads into one table")}
+	}
+	return append(append([]string{}, argv[:i]...), argv[i+2:]...), union, nil
+}
+
+// checkUnionTable refuses a union table name that sqly would not create as
+// written. The staged file is named after the table, and the import names the
+// table after the file, so a name the file-name rule would change — one with a
+// dot, or a leading digit — would quietly become a different table.
+func (s *Shell) checkUnionTable(union unionSpec) error {
+	if created := s.usecases.importer.GetTableNameFromFilePath(union.table + model.ExtCSV); created != union.table {
+		return &invocationError{Err: fmt.Errorf(
+			"%s %q is not a table name sqly can create as written; use letters, digits, and underscores, such as %s",
+			union.option, union.table, created)}
+	}
+	return nil
+}
+
+// isInputPattern reports whether an input is a glob pattern rather than a
+// path. A file whose name really holds a * or a [ is taken as that file: the
+// shell that ran sqly already had its chance to expand the pattern, so a
+// pattern that reaches here names no file of that name.
+func isInputPattern(path string) bool {
+	if isRemoteURL(path) || !strings.ContainsAny(path, "*?[") {
+		return false
+	}
+	_, err := os.Lstat(path)
+	return err != nil
+}
+
+// globInput returns the files a pattern matches, in the lexical order
+// filepath.Glob gives them, so which shard comes first — and with it the
+// column order of a union — is the same on every run.
+func globInput(pattern, label string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %w", label, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no file matches %s", label)
+	}
+	return matches, nil
+}
+
+// expandInputPatterns replaces each pattern among the inputs with the files it
+// matches. It is how `sqly 'logs/*.csv'` works where no shell expands the
+// pattern first — quoted on purpose, or on Windows — with each match becoming
+// the table it would have become had the shell named it.
+func expandInputPatterns(argv, labels []string) ([]string, []string, error) {
+	paths := make([]string, 0, len(argv))
+	names := make([]string, 0, len(argv))
+	for i, input := range argv {
+		label := input
+		if i < len(labels) {
+			label = labels[i]
+		}
+		expanded, err := expandTilde(input)
+		if err != nil || !isInputPattern(expanded) {
+			paths = append(paths, input)
+			names = append(names, label)
+			continue
+		}
+		matches, err := globInput(expanded, label)
+		if err != nil {
+			return nil, nil, err
+		}
+		paths = append(paths, matches...)
+		names = append(names, matches...)
+	}
+	return paths, names, nil
+}
+
+// unionFiles expands the inputs of a union into the files it reads, in the
+// order they were named. A directory contributes its supported files, a
+// pattern its matches. A file matched twice is read once.
+//
+// A relative input is taken from dir, and its files are labeled relative to
+// dir, so reading the union again after a .cd finds the same files under the
+// same names.
+//
+// A partitioned union reads directories only, and each file carries the
+// partition its path below the directory names (see partitionFiles).
+func (s *Shell) unionFiles(inputs []string, dir string, partitioned bool) ([]unionFile, error) {
+	var files []unionFile
+	add := func(file unionFile) {
+		if !slices.ContainsFunc(files, func(f unionFile) bool { return f.path == file.path }) {
+			files = append(files, file)
+		}
+	}
+	for _, input := range inputs {
+		if strings.TrimSpace(input) == "" {
+			return nil, &invocationError{Err: errors.New(".import was given an empty path\n" + importUsageText())}
+		}
+		if isRemoteURL(input) {
+			if partitioned {
+				return nil, &invocationError{Err: fmt.Errorf("--partitioned reads a directory tree, and %s is a URL; download the tree and name its directory", input)}
+			}
+			add(unionFile{path: input, label: input})
+			continue
+		}
+		expanded, err := expandTilde(input)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %s: %w", input, err)
+		}
+		relative := !filepath.IsAbs(expanded)
+		path := expanded
+		if relative {
+			path = filepath.Join(dir, expanded)
+		}
+		label := func(p string) string {
+			if rel, err := filepath.Rel(dir, p); err == nil && relative {
+				return rel
+			}
+			return p
+		}
+
+		matches := []string{path}
+		if isInputPattern(path) {
+			if matches, err = globInput(path, input); err != nil {
+				return nil, err
+			}
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if partitioned {
+				if err != nil {
+					return nil, localImportAccessError(label(match), err)
+				}
+				if !info.IsDir() {
+					return nil, &invocationError{Err: fmt.Errorf("--partitioned reads a directory tree, and %s is a file; name the directory holding it", label(match))}
+				}
+				found, err := s.partitionFiles(match, label)
+				if err != nil {
+					return nil, err
+				}
+				for _, file := range found {
+					add(file)
+				}
+				continue
+			}
+			if err != nil || !info.IsDir() {
+				// A path that cannot be read is reported by the import, which says
+				// why in the same words as for any other input.
+				add(unionFile{path: match, label: label(match)})
+				continue
+			}
+			found, err := s.supportedFilesInDir(match)
+			if err != nil {
+				return nil, fmt.Errorf("failed to scan directory %s: %w", label(match), err)
+			}
+			if len(found) == 0 {
+				return nil, fmt.Errorf("no supported files found in directory %s", label(match))
+			}
+			for _, file := range found {
+				add(unionFile{path: file, label: label(file)})
+			}
+		}
+	}
+	if partitioned {
+		if err := checkPartitionKeys(files); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// partitionFiles returns the supported files of a partitioned tree, each with
+// the partition its directories below root name.
+//
+// A file or directory whose name starts with an underscore or a dot is left
+// out, as every reader of the layout leaves it out: it is where writers keep
+// their bookkeeping — _SUCCESS markers, _temporary directories, .crc checksums
+// — and none of it is rows. Any other directory must be key=value, because a
+// directory that names no column would put its files' rows in the table with
+// nothing to tell them from the rest.
+func (s *Shell) partitionFiles(root string, label func(string) string) ([]unionFile, error) {
+	found, err := s.supportedFilesInDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan directory %s: %w", label(root), err)
+	}
+	var files []unionFile
+	for _, path := range found {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan directory %s: %w", label(root), err)
+		}
+		segments := strings.Split(filepath.ToSlash(rel), "/")
+		if slices.ContainsFunc(segments, func(segment string) bool {
+			return strings.HasPrefix(segment, "_") || strings.HasPrefix(segment, ".")
+		}) {
+			continue
+		}
+		var partition []model.PartitionValue
+		for _, segment := range segments[:len(segments)-1] {
+			value, ok := model.ParsePartitionSegment(segment)
+			if !ok {
+				return nil, fmt.Errorf("%s is under a directory %q that is not key=value; every directory of a partitioned tree names a column and its value, such as year=2024",
+					label(path), segment)
+			}
+			if slices.ContainsFunc(partition, func(p model.PartitionValue) bool { return strings.EqualFold(p.Key, value.Key) }) {
+				return nil, fmt.Errorf("%s is under two directories for column %q", label(path), value.Key)
+			}
+			partition = append(partition, value)
+		}
+		files = append(files, unionFile{path: path, label: label(path), partition: partition})
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no supported files found in directory %s", label(root))
+	}
+	return files, nil
+}
+
+// checkPartitionKeys refuses a tree whose files are not partitioned the same
+// way. The partition keys are the table's columns, so a file one level
+// shallower than the rest, or under region= where the rest are under year=,
+// has no value for a column the others do, and a file has no way to say which.
+func checkPartitionKeys(files []unionFile) error {
+	keys := func(file unionFile) string {
+		names := make([]string, 0, len(file.partition))
+		for _, p := range file.partition {
+			names = append(names, p.Key)
+		}
+		if len(names) == 0 {
+			return "nothing"
+		}
+		return strings.Join(names, ", ")
+	}
+	for _, file := range files[1:] {
+		if !strings.EqualFold(keys(file), keys(files[0])) {
+			return fmt.Errorf("%s is partitioned by %s, but %s by %s; every file of a partitioned table sits under the same key=value directories",
+				files[0].label, keys(files[0]), file.label, keys(file))
+		}
+	}
+	return nil
+}
+
+// planUnion stages the inputs as one file for the union's table and adds it to
+// the plan. Every shard is read here, before anything touches the database, so
+// a shard whose columns do not fit fails the import having written nothing.
+func (s *Shell) planUnion(ctx context.Context, plan *importPlan, inputs []string, dir string, union unionSpec) error {
+	if err := s.checkUnionTable(union); err != nil {
+		return err
+	}
+	files, err := s.unionFiles(inputs, dir, union.partitioned)
+	if err != nil {
+		return err
+	}
+
+	record := &unionImport{inputs: inputs, dir: dir, sourceColumn: union.sourceColumn, partitioned: union.partitioned, stamps: make(map[string]sourceStamp)}
+	shards := make([]model.UnionShard, 0, len(files))
+	for _, file := range files {
+		cleanPath, cleanup, _, err := s.resolveImportTarget(ctx, file.path)
+		if cleanup != nil {
+			plan.cleanups = append(plan.cleanups, cleanup)
+		}
+		if err != nil {
+			return err
+		}
+		// The stamp is taken before the file is read, so a shard rewritten
+		// during the import is not recorded as the one loaded.
+		if stamp := stampSource(cleanPath); stamp != nil && !isRemoteURL(file.path) {
+			record.stamps[file.path] = *stamp
+		}
+		prepared, cleanup, err := s.prepareImportLoadPath(cleanPath, file.path)
+		if err != nil {
+			return err
+		}
+		if cleanup != nil {
+			plan.cleanups = append(plan.cleanups, cleanup)
+		}
+		shards = append(shards, model.UnionShard{Path: prepared, Label: file.label, Partition: file.partition, Dialect: fileDialect(plan.dialect, cleanPath)})
+	}
+
+	staging, err := os.MkdirTemp("", "sqly-union-")
+	if err != nil {
+		return fmt.Errorf("failed to create a staging directory for table %s: %w", union.table, err)
+	}
+	plan.cleanups = append(plan.cleanups, func() { _ = os.RemoveAll(staging) }) //nolint:errcheck // best-effort cleanup of a temp dir
+	dest := filepath.Join(staging, union.table+model.ExtCSV)
+	if record.skipped, err = s.usecases.importer.StageUnion(dest, shards, union.sourceColumn); err != nil {
+		return fmt.Errorf("cannot read %d file(s) into table %s: %w", len(shards), union.table, err)
+	}
+	record.skipped.Table = union.table
+
+	plan.targets = append(plan.targets, importTarget{
+		loadPath:    dest,
+		displayPath: unionSource(inputs, dir),
+		union:       record,
+		dialect:     plan.dialect,
+	})
+	return nil
+}
+
+// unionSource is the source a union table records: its inputs, made absolute,
+// which is what --inspect reports and what a refused .save names.
+func unionSource(inputs []string, dir string) string {
+	sources := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		if expanded, err := expandTilde(input); err == nil && !isRemoteURL(input) && !filepath.IsAbs(expanded) {
+			input = filepath.Join(dir, expanded)
+		}
+		sources = append(sources, input)
+	}
+	return strings.Join(sources, ", ")
+}
+
+// recordUnionTable records a committed union import: the table's source, and
+// what .reload needs to read the union again.
+func (s *Shell) recordUnionTable(ctx context.Context, name string, target importTarget) {
+	s.warnTableSourceReplaced(name, target.displayPath)
+	if s.tableSources == nil {
+		s.tableSources = make(map[string]string)
+	}
+	s.tableSources[name] = target.displayPath
+	s.snapshotBaseline(ctx, name)
+	if s.unionImports == nil {
+		s.unionImports = make(map[string]*unionImport)
+	}
+	s.unionImports[name] = target.union
+	if target.union.skipped.Count > 0 {
+		s.warnSkipped(target.union.skipped)
+	}
+}
+
+// unionChanged reports whether a union would read anything different now: a
+// shard rewritten, removed, or newly matched by one of its patterns. A union
+// with a URL among its inputs is always changed, as a URL source is.
+func (s *Shell) unionChanged(union *unionImport) (bool, error) {
+	files, err := s.unionFiles(union.inputs, union.dir, union.partitioned)
+	if err != nil {
+		return false, err
+	}
+	if len(files) != len(union.stamps) {
+		return true, nil
+	}
+	for _, file := range files {
+		stamp, ok := union.stamps[file.path]
+		if !ok {
+			return true, nil
+		}
+		info, err := os.Stat(file.path)
+		if err != nil {
+			return false, localImportAccessError(file.label, err)
+		}
+		if info.Size() != stamp.size || !info.ModTime().Equal(stamp.modTime) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// planPartitioned reads each directory a partitioned import names into the
+// table named after it, the way a file becomes the table named after it.
+func (s *Shell) planPartitioned(ctx context.Context, plan *importPlan, inputs []string, dir string, union unionSpec) error {
+	for _, input := range inputs {
+		expanded, err := expandTilde(input)
+		if err != nil {
+			return fmt.Errorf("invalid path %s: %w", input, err)
+		}
+		if !filepath.IsAbs(expanded) {
+			expanded = filepath.Join(dir, expanded)
+		}
+		table := union
+		table.table = s.usecases.importer.GetTableNameFromFilePath(filepath.Base(expanded))
+		if err := s.planUnion(ctx, plan, []string{input}, dir, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startupUnion is the union the command line asks for, or nil.
+func (s *Shell) startupUnion() *unionSpec {
+	switch {
+	case s.argument.Union != "":
+		return &unionSpec{table: s.argument.Union, sourceColumn: s.argument.SourceFileColumn, partitioned: s.argument.Partitioned, option: "--union"}
+	case s.argument.Partitioned:
+		return &unionSpec{sourceColumn: s.argument.SourceFileColumn, partitioned: true, option: "--partitioned"}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,154 @@
+package shell
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitUnionArg(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		argv   []string
+		rest   []string
+		table  string
+		source bool
+		isErr  bool
+	}{
+		{"no union", []string{"a.csv"}, []string{"a.csv"}, "", false, false},
+		{"a union", []string{"--union", "events", "logs/*.csv"}, []string{"logs/*.csv"}, "events", false, false},
+		{"a union with the source column", []string{"logs/*.csv", "--source-file-column", "--union", "events"}, []string{"logs/*.csv"}, "events", true, false},
+		{"a union without a table", []string{"logs/*.csv", "--union"}, nil, "", false, true},
+		{"the source column alone", []string{"--source-file-column", "a.csv"}, nil, "", false, true},
+		{"a union twice", []string{"--union", "a", "--union", "b", "x.csv"}, nil, "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rest, union, err := splitUnionArg(tt.argv)
+			if (err != nil) != tt.isErr {
+				t.Fatalf("splitUnionArg(%v) error = %v, want error %v", tt.argv, err, tt.isErr)
+			}
+			if err != nil {
+				return
+			}
+			if strings.Join(rest, " ") != strings.Join(tt.rest, " ") {
+				t.Errorf("rest = %v, want %v", rest, tt.rest)
+			}
+			var table string
+			var source bool
+			if union != nil {
+				table, source = union.table, union.sourceColumn
+			}
+			if table != tt.table || source != tt.source {
+				t.Errorf("union = %q (source column %v), want %q (%v)", table, source, tt.table, tt.source)
+			}
+		})
+	}
+}
+
+func TestUnionImport(t *testing.T) {
+	t.Run("a pattern's files become one table, aligned by column name", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCSV(t, dir, "events-2024-01-01.csv", "id,name\n1,a\n")
+		writeCSV(t, dir, "events-2024-01-02.csv", "name,id\nb,2\n")
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--union", "events", "--source-file-column",
+			"--sql", "SELECT id, name, substr(_source_file, -21) AS file FROM events ORDER BY id", filepath.Join(dir, "events-*.csv"))
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "id,name,file\n1,a,events-2024-01-01.csv\n2,b,events-2024-01-02.csv\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("a file with other columns fails the import naming both", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCSV(t, dir, "a.csv", "id,name\n1,a\n")
+		writeCSV(t, dir, "b.csv", "id,email\n2,b@example.com\n")
+
+		_, _, err := runWithArgs(t, "--union", "events", "--sql", "SELECT 1", filepath.Join(dir, "*.csv"))
+		var importErr *importFailedError
+		if !errors.As(err, &importErr) {
+			t.Fatalf("Run error = %v, want an importFailedError", err)
+		}
+		for _, want := range []string{"a.csv", "b.csv", "lacks name", "adds email"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not mention %q", err, want)
+			}
+		}
+	})
+
+	t.Run("without --union a pattern's files keep a table each", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCSV(t, dir, "a.csv", "id\n1\n")
+		writeCSV(t, dir, "b.csv", "id\n2\n")
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv",
+			"--sql", "SELECT (SELECT id FROM a) AS a, (SELECT id FROM b) AS b", filepath.Join(dir, "*.csv"))
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "a,b\n1,2\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("a pattern matching nothing fails the import", func(t *testing.T) {
+		_, _, err := runWithArgs(t, "--sql", "SELECT 1", filepath.Join(t.TempDir(), "*.csv"))
+		if err == nil || !strings.Contains(err.Error(), "no file matches") {
+			t.Errorf("Run error = %v, want the empty pattern reported", err)
+		}
+	})
+
+	t.Run(".import --union reads into the named table", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCSV(t, dir, "a.csv", "id\n1\n")
+		writeCSV(t, dir, "b.tsv", "id\n2\n")
+		script := filepath.Join(dir, "run.sqly")
+		writeScript(t, script, ".import --union ids "+filepath.Join(dir, "a.csv")+" "+filepath.Join(dir, "b.tsv")+"\n"+
+			"SELECT SUM(id) AS total FROM ids;\n")
+
+		stdout, stderr, err := runWithArgs(t, "--output-format", "csv", "--script-file", script)
+		if err != nil {
+			t.Fatalf("Run: %v (%s)", err, stderr)
+		}
+		if want := "total\n3\n"; stdout != want {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+	})
+
+	t.Run("a union table is not saved", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCSV(t, dir, "a.csv", "id\n1\n")
+		script := filepath.Join(dir, "run.sqly")
+		writeScript(t, script, ".import --union ids "+filepath.Join(dir, "*.csv")+"\n"+
+			"UPDATE ids SET id = 2;\n.save ids\n")
+
+		_, stderr, err := runWithArgs(t, "--script-file", script)
+		if err == nil || !strings.Contains(stderr, "came from a union of several files") {
+			t.Errorf("Run error = %v (%s), want the save refused", err, stderr)
+		}
+	})
+}
+
+func TestUnionReload(t *testing.T) {
+	dir := t.TempDir()
+	writeCSV(t, dir, "day1.csv", "id\n1\n")
+	s := newReloadShell(t, "--union", "days", filepath.Join(dir, "day*.csv"))
+
+	if got := queryReloadShell(t, s, "SELECT COUNT(*) AS n FROM days"); got != "n\n1\n" {
+		t.Fatalf("before = %q", got)
+	}
+	writeCSV(t, dir, "day2.csv", "id\n2\n")
+	if _, err := getExecStdErrOutput(t, s.exec, ".reload days"); err != nil {
+		t.Fatalf(".reload: %v", err)
+	}
+	if got := queryReloadShell(t, s, "SELECT COUNT(*) AS n FROM days"); got != "n\n2\n" {
+		t.Errorf("after = %q, want the new file read into the table", got)
+	}
+}
@@ -0,0 +1,146 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nao1215/sqly/config"
+)
+
+// --watch turns a --sql or --sql-file run into a monitor. The first result is
+// printed exactly as it would be without the flag; after that the process stays
+// up, looks at its inputs every --watch-interval, and when one has changed it
+// reads that one again — through the same atomic import .reload uses — and runs
+// the query again.
+//
+// What it saves is the import. The shell loop it replaces (`while sleep 5; do
+// sqly --sql ... big.csv; done`) read every input on every tick whether or not
+// anything had changed; --watch costs a stat per input per tick until something
+// does, and then reads only what did.
+//
+// A tick that fails — a file caught half-written, a file that was rotated away,
+// a query that no longer matches the columns — is reported and the watch goes
+// on. The tables are left as they were, because the import is one transaction,
+// and the next change gets another try. Only the first run can fail the
+// process: a query that never worked is a mistake on the command line, not a
+// moment in the life of a log file.
+
+// watchTicker starts the clock --watch polls on and returns its channel and the
+// function that stops it.
+type watchTicker func(interval time.Duration) (<-chan time.Time, func())
+
+// newTimeTicker is the watchTicker a real run uses.
+func newTimeTicker(interval time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(interval)
+	return t.C, t.Stop
+}
+
+// validateWatchFlags rejects an input --watch could never notice changing. Both
+// are decided from the command line, so the run is refused before it reads
+// anything rather than printing one result and then watching nothing.
+func (s *Shell) validateWatchFlags() error {
+	if !s.argument.Watch {
+		return nil
+	}
+	// Stdin is read once. There is no second read to compare it with, and a
+	// pipe that is still open is waited on by the import, not by the watch.
+	if s.argument.StdinFormat != "" {
+		return &invocationError{Err: errors.New("--watch cannot watch a --stdin-format dataset: stdin is read once; name the file instead")}
+	}
+	for _, path := range s.argument.FilePaths {
+		if isRemoteURL(path) {
+			return &invocationError{Err: fmt.Errorf("--watch polls local files, and %s is a URL; download it and watch the copy", path)}
+		}
+	}
+	return nil
+}
+
+// watch re-runs the query each time an input changes, until ctx is canceled.
+//
+// A query that changes the tables it reads — an UPDATE before the SELECT — would
+// be applied a second time to a table the tick did not reload, so for such a
+// script every input is read again whenever any one of them changes. Each run
+// then starts from the files, as the first one did.
+func (s *Shell) watch(ctx context.Context, elements []scriptElement) error {
+	sources, err := s.reloadSources(nil)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(sources))
+	for _, src := range sources {
+		names = append(names, src.source)
+	}
+	fmt.Fprintf(config.Stderr, "watching %s every %s; press Ctrl-C to stop\n",
+		strings.Join(names, ", "), s.argument.WatchInterval)
+
+	reloadAll := slices.ContainsFunc(sqlStatements(elements), statementModifiesData)
+	ticks, stop := s.newWatchTicker(s.argument.WatchInterval)
+	defer stop()
+
+	// Being stopped is how a watch ends, so it is not an error: the signal that
+	// stopped it already decides the exit code, and "context canceled" on stderr
+	// would read as something having gone wrong.
+	//
+	// The same failure is reported once, not once per tick: a deleted input
+	// would otherwise fill the terminal at the polling rate.
+	var lastFailure string
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticks:
+		}
+		err := s.watchTick(ctx, elements, reloadAll)
+		switch {
+		case err == nil:
+			lastFailure = ""
+		case ctx.Err() != nil:
+			return nil
+		case err.Error() != lastFailure:
+			fmt.Fprintf(config.Stderr, "%v\n", err)
+			lastFailure = err.Error()
+		}
+		if s.watchTickDone != nil {
+			s.watchTickDone()
+		}
+	}
+}
+
+// watchTick reloads what changed since the last tick and, when anything did,
+// runs the query again.
+func (s *Shell) watchTick(ctx context.Context, elements []scriptElement, reloadAll bool) error {
+	sources, err := s.reloadSources(nil)
+	if err != nil {
+		return err
+	}
+	changed, err := s.changedSources(sources)
+	if err != nil {
+		return err
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	if reloadAll {
+		changed = sources
+	}
+	if err := s.reload(ctx, changed); err != nil {
+		return err
+	}
+	return s.runWatchedQuery(ctx, elements)
+}
+
+// runWatchedQuery runs the query the way the first run did: into --output when
+// one was named, and to stdout otherwise.
+func (s *Shell) runWatchedQuery(ctx context.Context, elements []scriptElement) error {
+	if s.argument.Output.FilePath != "" {
+		return s.runSQLFileToOutput(ctx, elements)
+	}
+	if _, err := s.runScript(ctx, elements); err != nil {
+		return err
+	}
+	return s.finishNonInteractive(ctx)
+}
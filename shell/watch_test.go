@@ -0,0 +1,166 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nao1215/sqly/config"
+)
+
+// runWatched runs sqly with --watch and hands steps a tick function that
+// advances the watch by one poll and returns once the watch has handled it, so a
+// step can change a file knowing no tick is looking at it. steps starts once the
+// first run is over and the watch has taken its first, idle, tick. The run is
+// stopped the way Ctrl-C stops it, by canceling its context.
+func runWatched(t *testing.T, args []string, steps func(tick func())) (stdout, stderr string) {
+	t.Helper()
+	s, cleanup, err := newShell(t, args)
+	if err != nil {
+		t.Fatalf("newShell: %v", err)
+	}
+	defer cleanup()
+
+	backupOut, backupErr := config.Stdout, config.Stderr
+	var out, errOut strings.Builder
+	config.Stdout, config.Stderr = &out, &errOut
+	defer func() { config.Stdout, config.Stderr = backupOut, backupErr }()
+
+	ticks := make(chan time.Time)
+	handled := make(chan struct{})
+	s.newWatchTicker = func(time.Duration) (<-chan time.Time, func()) { return ticks, func() {} }
+	s.watchTickDone = func() { handled <- struct{}{} }
+	s.isTTY = func() bool { return false }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	tick := func() {
+		select {
+		case ticks <- time.Now():
+		case err := <-done:
+			t.Fatalf("the watch ended before the tick: %v\nstderr: %s", err, errOut.String())
+		}
+		<-handled
+	}
+	tick()
+	steps(tick)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run returned %v after the watch was stopped, want nil", err)
+	}
+	return out.String(), errOut.String()
+}
+
+func TestWatch_ReRunsTheQueryWhenAnInputChanges(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "log.csv")
+	writeDaily(t, csvPath, "id\n1\n")
+
+	stdout, stderr := runWatched(t,
+		[]string{"sqly", "--watch", "--output-format", "csv", "--sql", "SELECT count(*) AS n FROM log", csvPath},
+		func(tick func()) {
+			// Nothing changed: the query is not run again.
+			tick()
+			tick()
+			writeDaily(t, csvPath, "id\n1\n2\n")
+			tick()
+			writeDaily(t, csvPath, "id\n1\n2\n3\n")
+			tick()
+		})
+
+	if want := "n\n1\nn\n2\nn\n3\n"; stdout != want {
+		t.Errorf("stdout = %q, want one result per change and none for the unchanged tick (%q)", stdout, want)
+	}
+	if !strings.Contains(stderr, "watching "+csvPath) {
+		t.Errorf("stderr = %q, want it to say what is watched", stderr)
+	}
+	if got := strings.Count(stderr, "reloaded log from "+csvPath); got != 2 {
+		t.Errorf("stderr reported %d reloads, want 2: %s", got, stderr)
+	}
+}
+
+// TestWatch_KeepsWatchingAfterAFailedTick checks a tick that cannot import is
+// reported once, leaves the last good table in place, and does not end the
+// watch: a log caught mid-rotation is a moment, not a failure of the run.
+func TestWatch_KeepsWatchingAfterAFailedTick(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "log.csv")
+	writeDaily(t, csvPath, "id,name\n1,a\n")
+
+	stdout, stderr := runWatched(t,
+		[]string{"sqly", "--watch", "--output-format", "csv", "--sql", "SELECT count(*) AS n FROM log", csvPath},
+		func(tick func()) {
+			if err := os.Remove(csvPath); err != nil {
+				t.Fatal(err)
+			}
+			tick()
+			tick()
+			writeDaily(t, csvPath, "id,name\n1,a\n2,b\n")
+			tick()
+		})
+
+	if want := "n\n1\nn\n2\n"; stdout != want {
+		t.Errorf("stdout = %q, want %q", stdout, want)
+	}
+	if got := strings.Count(stderr, "path does not exist: "+csvPath); got != 1 {
+		t.Errorf("the missing input was reported %d times, want once: %s", got, stderr)
+	}
+}
+
+// TestWatch_ReadsEveryInputAgainForAModifyingScript checks a script that changes
+// its tables starts each run from the files. Reloading only the input that
+// changed would apply the UPDATE to the other table a second time.
+func TestWatch_ReadsEveryInputAgainForAModifyingScript(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "log.csv")
+	pricePath := filepath.Join(dir, "price.csv")
+	sqlPath := filepath.Join(dir, "report.sql")
+	writeDaily(t, logPath, "id\n1\n")
+	writeDaily(t, pricePath, "amount\n10\n")
+	writeDaily(t, sqlPath, "UPDATE price SET amount = amount * 2;\nSELECT (SELECT count(*) FROM log) AS n, amount FROM price;\n")
+
+	stdout, _ := runWatched(t,
+		[]string{"sqly", "--watch", "--output-format", "csv", "--sql-file", sqlPath, logPath, pricePath},
+		func(tick func()) {
+			writeDaily(t, logPath, "id\n1\n2\n")
+			tick()
+		})
+
+	if !strings.Contains(stdout, "n,amount\n1,20\n") || !strings.Contains(stdout, "n,amount\n2,20\n") {
+		t.Errorf("stdout = %q, want the doubled price from the file on both runs", stdout)
+	}
+}
+
+func TestWatch_RefusesWhatItCannotPoll(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "a stdin dataset",
+			args: []string{"sqly", "--watch", "--stdin-format", "csv", "--sql", "SELECT 1"},
+			want: "--watch cannot watch a --stdin-format dataset",
+		},
+		{
+			name: "a URL",
+			args: []string{"sqly", "--watch", "--allow-remote", "--sql", "SELECT 1", "https://example.com/log.csv"},
+			want: "--watch polls local files",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := runShell(t, tt.args)
+			var invocation *invocationError
+			if !errors.As(err, &invocation) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Run error = %v, want an invocationError containing %q", err, tt.want)
+			}
+		})
+	}
+}
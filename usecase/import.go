@@ -22,8 +22,10 @@ import (
 //
 //nolint:interfacebloat // See the paragraph above.
 type ImportUsecase interface {
-	// LoadFiles loads multiple files or directories into the database
-	LoadFiles(ctx context.Context, filePaths ...string) error
+	// LoadFiles loads multiple files into the database, in one transaction.
+	// Each carries the column types, keys, schema sidecar, and CSV dialect the
+	// import declares for it, so nothing one load is told reaches another.
+	LoadFiles(ctx context.Context, files ...model.FileLoad) error
 	// SetRowMismatchPolicy sets how a CSV/TSV row (one whose field count
 	// differs from the header) is handled by subsequent imports.
 	SetRowMismatchPolicy(policy model.RowMismatchPolicy)
@@ -45,6 +47,31 @@ type ImportUsecase interface {
 	SetIncludeHiddenSheets(include bool)
 	// IncludeHiddenSheets reports whether Excel imports load hidden sheets.
 	IncludeHiddenSheets() bool
+	// SetXMLRecordPath sets which elements of an XML input subsequent imports
+	// read as rows, as a path from the root such as /feed/item. An empty path
+	// takes the children of the root element.
+	SetXMLRecordPath(path string)
+	// IsSQLiteFile reports whether the path names a SQLite database file.
+	IsSQLiteFile(filePath string) bool
+	// SetSQLiteTables sets the tables subsequent imports copy from a SQLite
+	// database. Empty copies every table.
+	SetSQLiteTables(tables []string)
+	// SQLiteTables returns the tables an import of the SQLite database at path
+	// copies, which are the tables it creates.
+	SQLiteTables(path string) ([]string, error)
+	// SchemaSidecar returns the schema file that describes the data file at
+	// path — its FILE.schema.json, or a datapackage.json beside it with a
+	// resource for it — or "" when none does. An import applies it on its own;
+	// this is for a caller that needs to know it is there.
+	SchemaSidecar(path string) (string, error)
+	// SetImportCleaning sets what subsequent imports do to the values of every
+	// CSV and TSV input as they read it: trim them, and read the placeholders
+	// for a missing value as NULL.
+	SetImportCleaning(cleaning model.ImportCleaning)
+	// StageUnion writes the rows of the CSV and TSV shards to dest as one CSV
+	// file, aligning their columns by name, and returns what the row-mismatch
+	// policy dropped. With sourceColumn set, each row also names its shard.
+	StageUnion(dest string, shards []model.UnionShard, sourceColumn bool) (model.SkippedRows, error)
 	// ExcelSheets reports every sheet of the workbook at path, in workbook
 	// order, and whether the workbook shows it. It reads only the sheet
 	// directory, so it answers for a workbook that has not been imported.
@@ -10,7 +10,9 @@ import (
 
 // MetadataUsecase inspects table metadata: the list of tables, a table's
 // header, and a table's records. Commands that report on tables without
-// executing SQL depend on this interface.
+// executing SQL depend on this interface. It also keeps the record of where each
+// table came from in a persistent session database, which is metadata about the
+// same tables and outlives the session that wrote it.
 type MetadataUsecase interface {
 	// TablesName return all table name.
 	TablesName(ctx context.Context) ([]*model.Table, error)
@@ -21,4 +23,11 @@ type MetadataUsecase interface {
 	Header(ctx context.Context, tableName string) (*model.Table, error)
 	// List get records in the specified table
 	List(ctx context.Context, tableName string) (*model.Table, error)
+	// TableSources returns the recorded source of every table the session
+	// database remembers.
+	TableSources(ctx context.Context) ([]model.TableSource, error)
+	// RecordTableSources stores or replaces the record of each given table.
+	RecordTableSources(ctx context.Context, sources []model.TableSource) error
+	// ForgetTableSources drops the record of each named table.
+	ForgetTableSources(ctx context.Context, tableNames []string) error
 }
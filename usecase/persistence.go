@@ -4,9 +4,10 @@ import "context"
 
 //go:generate mockgen -typed -source=$GOFILE -destination=../interactor/mock/$GOFILE -package mock
 
-// PersistenceUsecase reconstructs native financial files from a table set. These
-// operations round-trip session tables back out to on-disk files, so they are
-// grouped apart from plain file import.
+// PersistenceUsecase reconstructs native financial files from a table set and
+// saves the whole session as a SQLite database. These operations round-trip
+// session tables back out to on-disk files, so they are grouped apart from plain
+// file import.
 type PersistenceUsecase interface {
 	// DumpACHFile reconstructs a complete ACH file at outputPath from the table set
 	// registered under baseName, reflecting any UPDATEs applied in the session.
@@ -14,4 +15,8 @@ type PersistenceUsecase interface {
 	// DumpFedWireFile reconstructs a complete Fedwire file at outputPath from the
 	// message table registered under baseName, reflecting any UPDATEs in the session.
 	DumpFedWireFile(ctx context.Context, baseName, outputPath string) error
+	// SaveAsSQLite writes every table of the session into a new SQLite database
+	// at path, keeping each table's schema and its indexes, views, and triggers,
+	// and returns the names of the tables written.
+	SaveAsSQLite(ctx context.Context, path string) ([]string, error)
 }